@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/user/modulox"
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/memory"
+)
+
+const chatHelp = `Commands:
+  /model [name]          show or switch the model
+  /tools                 list the enabled tools
+  /tool add|remove NAME  enable or disable a built-in tool
+  /session [name]        show or switch the session
+  /sessions              list saved sessions
+  /history               show the session's messages
+  /clear                 delete the session's messages
+  /help                  show this help
+  /exit                  leave the chat`
+
+// chatSession is the state of an interactive chat
+type chatSession struct {
+	app           *modulox.App
+	conversations *memory.ConversationStore
+	files         *memory.FileConversations
+	session       string
+	out           io.Writer
+}
+
+// runChat starts an interactive chat with the configured agent. Sessions
+// are saved to disk, so a chat can be resumed by name.
+func runChat(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("chat", flag.ExitOnError)
+	configFile := flags.String("config", "config.yaml", "Path to configuration file")
+	session := flags.String("session", "default", "Name of the session to resume or start")
+	dir := flags.String("sessions-dir", defaultSessionsDir(), "Directory sessions are saved in")
+	maxTurns := flags.Int("max-turns", 50, "Earlier messages sent with each request (0 = all)")
+	flags.Parse(args)
+
+	cfg, err := config.LoadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	files, err := memory.NewFileConversations(*dir)
+	if err != nil {
+		return err
+	}
+	c := &chatSession{
+		conversations: memory.NewConversationStore(memory.ConversationConfig{
+			MaxTurns:    *maxTurns,
+			Persistence: files,
+		}),
+		files:   files,
+		session: *session,
+		out:     os.Stdout,
+	}
+	if err := c.rebuild(cfg); err != nil {
+		return err
+	}
+	defer func() { c.app.Close(context.Background()) }()
+
+	fmt.Fprintf(c.out, "Chatting with %s (%s) in session %q. Type /help for commands.\n",
+		c.app.Agent.GetName(), cfg.Provider.ModelName, c.session)
+
+	lines := readLines(os.Stdin)
+	for {
+		fmt.Fprint(c.out, "> ")
+		var line string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(c.out)
+			return nil
+		case line, ok = <-lines:
+			if !ok {
+				fmt.Fprintln(c.out)
+				return nil
+			}
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "/"):
+			quit, err := c.command(ctx, line)
+			if err != nil {
+				fmt.Fprintf(c.out, "error: %v\n", err)
+			}
+			if quit {
+				return nil
+			}
+		default:
+			if err := c.send(ctx, line); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				fmt.Fprintf(c.out, "\nerror: %v\n", err)
+			}
+		}
+	}
+}
+
+// send streams the agent's reply to input
+func (c *chatSession) send(ctx context.Context, input string) error {
+	ctx = memory.WithConversation(ctx, c.session)
+	chunks, err := c.app.Agent.ExecuteStream(ctx, input)
+	if err != nil {
+		return err
+	}
+	for chunk := range chunks {
+		fmt.Fprint(c.out, chunk.Content)
+		if chunk.Err != nil {
+			return chunk.Err
+		}
+	}
+	fmt.Fprintln(c.out)
+	return nil
+}
+
+// command runs a slash command, reporting whether the chat should end
+func (c *chatSession) command(ctx context.Context, line string) (bool, error) {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+
+	switch name {
+	case "/exit", "/quit":
+		return true, nil
+
+	case "/help":
+		fmt.Fprintln(c.out, chatHelp)
+
+	case "/model":
+		if len(args) == 0 {
+			fmt.Fprintf(c.out, "%s %s\n", c.app.Config.Provider.Type, c.app.Config.Provider.ModelName)
+			return false, nil
+		}
+		updated := *c.app.Config
+		updated.Provider.ModelName = args[0]
+		if err := c.rebuild(&updated); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(c.out, "Switched to %s\n", args[0])
+
+	case "/tools":
+		definitions := c.app.Tools.ToolDefinitions()
+		if len(definitions) == 0 {
+			fmt.Fprintln(c.out, "No tools enabled")
+		}
+		for _, d := range definitions {
+			fmt.Fprintf(c.out, "  %-16s %s\n", d.Name, d.Description)
+		}
+
+	case "/tool":
+		if len(args) != 2 || (args[0] != "add" && args[0] != "remove") {
+			return false, fmt.Errorf("usage: /tool add|remove NAME")
+		}
+		updated := *c.app.Config
+		updated.Tools.EnabledTools = toggle(c.app.Config.Tools.EnabledTools, args[1], args[0] == "add")
+		if err := c.rebuild(&updated); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(c.out, "Enabled tools: %s\n", strings.Join(updated.Tools.EnabledTools, ", "))
+
+	case "/session":
+		if len(args) == 0 {
+			fmt.Fprintln(c.out, c.session)
+			return false, nil
+		}
+		c.session = args[0]
+		fmt.Fprintf(c.out, "Switched to session %q\n", c.session)
+
+	case "/sessions":
+		ids, err := c.files.Sessions()
+		if err != nil {
+			return false, err
+		}
+		for _, id := range ids {
+			marker := " "
+			if id == c.session {
+				marker = "*"
+			}
+			fmt.Fprintf(c.out, "%s %s\n", marker, id)
+		}
+
+	case "/history":
+		history, err := c.conversations.History(ctx, c.session)
+		if err != nil {
+			return false, err
+		}
+		for _, m := range history {
+			fmt.Fprintf(c.out, "%s: %s\n", m.Role, m.Content)
+		}
+
+	case "/clear":
+		if err := c.conversations.Clear(ctx, c.session); err != nil {
+			return false, err
+		}
+		fmt.Fprintf(c.out, "Cleared session %q\n", c.session)
+
+	default:
+		return false, fmt.Errorf("unknown command %s; type /help for commands", name)
+	}
+	return false, nil
+}
+
+// rebuild replaces the agent with one built from cfg, keeping the current
+// agent when cfg is invalid
+func (c *chatSession) rebuild(cfg *config.Config) error {
+	app, err := modulox.New(cfg, agent.WithConversation(c.conversations))
+	if err != nil {
+		return err
+	}
+	if c.app != nil {
+		c.app.Close(context.Background())
+	}
+	c.app = app
+	return nil
+}
+
+// toggle returns names with name added or removed
+func toggle(names []string, name string, add bool) []string {
+	var result []string
+	for _, n := range names {
+		if n != name {
+			result = append(result, n)
+		}
+	}
+	if add {
+		result = append(result, name)
+	}
+	return result
+}
+
+// readLines delivers the lines of r until it ends
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+// defaultSessionsDir is ~/.modulox/sessions, or a relative directory when
+// the home directory is unknown
+func defaultSessionsDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".modulox", "sessions")
+	}
+	return filepath.Join(home, ".modulox", "sessions")
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/user/modulox/pkg/rest"
+	"github.com/user/modulox/pkg/types"
+)
+
+// defaultServer is the REST URL remote commands use unless told otherwise
+const defaultServer = "http://localhost:8080"
+
+// clusterCommands are the subcommands of modulox cluster
+var clusterCommands = []command{
+	{"status", "Summarize the cluster", clusterStatus},
+	{"nodes", "List the nodes with their state and load", clusterNodes},
+	{"drain", "Drain a node and remove it from the cluster", clusterDrain},
+	{"tasks", "List queued, running, and finished tasks", clusterTasks},
+}
+
+// runCluster inspects and manages a cluster through a server's REST API
+func runCluster(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		for _, cmd := range clusterCommands {
+			if cmd.name == args[0] {
+				return cmd.run(ctx, args[1:])
+			}
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "Usage: modulox cluster <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range clusterCommands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	if len(args) == 0 {
+		return usageError{fmt.Errorf("a command is required")}
+	}
+	return usageError{fmt.Errorf("unknown command %q", args[0])}
+}
+
+// remoteFlags adds the flags selecting a server to flags, returning a
+// function creating the client once they are parsed
+func remoteFlags(flags *flag.FlagSet) func() *remoteClient {
+	server := os.Getenv("MODULOX_SERVER")
+	if server == "" {
+		server = defaultServer
+	}
+	address := flags.String("server", server, "REST URL of the server; defaults to $MODULOX_SERVER")
+	token := flags.String("token", os.Getenv("MODULOX_TOKEN"), "Bearer token for the server; defaults to $MODULOX_TOKEN")
+	return func() *remoteClient {
+		return newRemoteClient(*address, *token)
+	}
+}
+
+func clusterStatus(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("cluster status", flag.ExitOnError)
+	client := remoteFlags(flags)
+	asJSON := flags.Bool("json", false, "Print the topology as JSON")
+	flags.Parse(args)
+
+	var topology types.ClusterTopology
+	if err := client().do(ctx, http.MethodGet, "/v1/cluster", nil, &topology); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(topology)
+	}
+
+	states := make(map[string]int)
+	load, capacity := 0, 0
+	for _, n := range topology.Nodes {
+		states[n.State]++
+		load += n.Node.Load
+		capacity += n.Node.Capacity
+	}
+	var counts []string
+	for _, state := range []string{"healthy", "overloaded", "draining", "unhealthy", "unknown"} {
+		if states[state] > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", states[state], state))
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Scheduler:\t%s (leader: %t)\n", topology.SchedulerID, topology.Leader)
+	fmt.Fprintf(w, "Nodes:\t%d (%s)\n", len(topology.Nodes), strings.Join(counts, ", "))
+	fmt.Fprintf(w, "Tasks running:\t%d of %d slots\n", load, capacity)
+	fmt.Fprintf(w, "Tasks pending:\t%d\n", topology.PendingTasks)
+	fmt.Fprintf(w, "Sessions:\t%d\n", topology.Sessions)
+	return w.Flush()
+}
+
+func clusterNodes(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("cluster nodes", flag.ExitOnError)
+	client := remoteFlags(flags)
+	asJSON := flags.Bool("json", false, "Print the nodes as JSON")
+	flags.Parse(args)
+
+	var topology types.ClusterTopology
+	if err := client().do(ctx, http.MethodGet, "/v1/cluster", nil, &topology); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(topology.Nodes)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tADDRESS\tLOAD\tQUEUED\tAGENTS\tVERSION\tLAST SEEN")
+	for _, n := range topology.Nodes {
+		address := n.Node.Address
+		if !n.Remote {
+			address = "(local)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%d\t%d\t%s\t%s\n",
+			n.Node.ID, n.State, address, n.Node.Load, n.Node.Capacity, n.Node.QueueDepth,
+			n.Node.AgentCount, n.Node.Version, since(n.Node.LastPing))
+	}
+	return w.Flush()
+}
+
+func clusterDrain(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("cluster drain", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: modulox cluster drain [flags] <node-id>")
+		flags.PrintDefaults()
+	}
+	client := remoteFlags(flags)
+	positional := parseArgs(flags, args)
+	if len(positional) != 1 {
+		return usageError{fmt.Errorf("exactly one node ID is required")}
+	}
+	id := positional[0]
+
+	fmt.Fprintf(os.Stderr, "Draining node %s...\n", id)
+	var resp rest.DrainResponse
+	path := "/v1/cluster/nodes/" + url.PathEscape(id) + "/drain"
+	if err := client().do(ctx, http.MethodPost, path, nil, &resp); err != nil {
+		return err
+	}
+	if resp.TimedOut {
+		fmt.Printf("Node %s removed; tasks still running at the drain timeout were retried on other nodes\n", id)
+		return nil
+	}
+	fmt.Printf("Node %s drained and removed\n", id)
+	return nil
+}
+
+func clusterTasks(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("cluster tasks", flag.ExitOnError)
+	client := remoteFlags(flags)
+	state := flags.String("state", "", "Only list tasks in this state: pending, running, completed, or failed")
+	asJSON := flags.Bool("json", false, "Print the tasks as JSON")
+	flags.Parse(args)
+
+	path := "/v1/cluster/tasks"
+	if *state != "" {
+		path += "?" + url.Values{"state": {*state}}.Encode()
+	}
+	var tasks []rest.TaskInfo
+	if err := client().do(ctx, http.MethodGet, path, nil, &tasks); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(tasks)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATE\tNODE\tATTEMPTS\tUPDATED\tTASK\tERROR")
+	for _, t := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			t.ID, t.State, t.NodeID, t.Attempts, since(t.UpdatedAt), truncate(t.Task, 40), truncate(t.Error, 60))
+	}
+	return w.Flush()
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// since formats how long ago t was
+func since(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return time.Since(t).Round(time.Second).String() + " ago"
+}
+
+// truncate shortens s to at most n runes on a single line
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
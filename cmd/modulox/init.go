@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// projectTemplates are the files modulox init generates, rendered with a
+// projectData
+//
+//go:embed templates/init
+var projectTemplates embed.FS
+
+// projectTemplateRoot is the directory of projectTemplates holding them
+const projectTemplateRoot = "templates/init"
+
+// projectName matches names usable as agent, binary, and image names
+var projectName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// projectData fills in the project templates
+type projectData struct {
+	Name     string
+	Module   string
+	Provider string
+}
+
+// runInit generates a starter project: configuration, an example agent
+// with a custom tool, a workflow, and a Dockerfile
+func runInit(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: modulox init [flags] [directory]")
+		flags.PrintDefaults()
+	}
+	name := flags.String("name", "", "Project name (default: the directory name)")
+	module := flags.String("module", "", "Go module path (default: the project name)")
+	provider := flags.String("provider", "azure", "LLM provider to configure: azure or bedrock")
+	force := flags.Bool("force", false, "Overwrite existing files")
+	positional := parseArgs(flags, args)
+	if len(positional) > 1 {
+		return usageError{fmt.Errorf("at most one directory is allowed")}
+	}
+
+	dir := "."
+	if len(positional) == 1 {
+		dir = positional[0]
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	data := projectData{Name: *name, Module: *module, Provider: *provider}
+	if data.Name == "" {
+		data.Name = strings.ToLower(filepath.Base(abs))
+	}
+	if data.Module == "" {
+		data.Module = data.Name
+	}
+	if !projectName.MatchString(data.Name) {
+		return usageError{fmt.Errorf("invalid project name %q; use lowercase letters, digits, '.', '_', and '-', or pass -name", data.Name)}
+	}
+	if data.Provider != "azure" && data.Provider != "bedrock" {
+		return usageError{fmt.Errorf("unknown provider %q; use azure or bedrock", data.Provider)}
+	}
+
+	files, err := renderProject(data)
+	if err != nil {
+		return err
+	}
+	if !*force {
+		for name := range files {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return usageError{fmt.Errorf("%s already exists; pass -force to overwrite it", filepath.Join(dir, name))}
+			}
+		}
+	}
+	for _, name := range sortedFiles(files) {
+		target := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(target, files[name], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		fmt.Printf("  created %s\n", target)
+	}
+
+	fmt.Printf("\nProject %s is ready. Next steps:\n", data.Name)
+	if dir != "." {
+		fmt.Printf("  cd %s\n", dir)
+	}
+	fmt.Println("  go mod tidy")
+	if data.Provider == "bedrock" {
+		fmt.Println("  export AWS_REGION=... AWS_ACCESS_KEY_ID=... AWS_SECRET_ACCESS_KEY=...")
+	} else {
+		fmt.Println("  export AZURE_OPENAI_ENDPOINT=... AZURE_OPENAI_API_KEY=...")
+	}
+	fmt.Println("  go run . \"What is 17% of 2,340?\"")
+	fmt.Println("  modulox run workflow.yaml -input \"How many days are left until New Year?\"")
+	fmt.Println("  modulox chat")
+	return nil
+}
+
+// renderProject renders the project templates, keyed by the path of the
+// generated file
+func renderProject(data projectData) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := fs.WalkDir(projectTemplates, projectTemplateRoot, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		text, err := projectTemplates.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(path.Base(name)).Parse(string(text))
+		if err != nil {
+			return fmt.Errorf("invalid template %s: %w", name, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		target := strings.TrimSuffix(strings.TrimPrefix(name, projectTemplateRoot+"/"), ".tmpl")
+		files[filepath.FromSlash(target)] = out.Bytes()
+		return nil
+	})
+	return files, err
+}
+
+// sortedFiles returns the paths of generated files in order
+func sortedFiles(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -2,23 +2,41 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
-
-	"github.com/user/modulox/pkg/agent"
-	"github.com/user/modulox/pkg/llm"
-	"github.com/user/modulox/pkg/memory"
-	"github.com/user/modulox/pkg/tools"
 )
 
+// command is a modulox subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"init", "Generate a starter project in a directory", runInit},
+	{"serve", "Start the agent server, REST gateway, metrics, and health endpoints", runServe},
+	{"chat", "Chat with the configured agent in the terminal", runChat},
+	{"run", "Run a workflow file once and print its result", runRun},
+	{"cluster", "Inspect cluster nodes and tasks, and drain nodes", runCluster},
+	{"tools", "List, describe, and invoke the configured tools", runTools},
+}
+
+// usageError is an invalid invocation, as opposed to a failed run; commands
+// exit with status 2 for it
+type usageError struct {
+	error
+}
+
 func main() {
-	// Parse command line flags
-	configFile := flag.String("config", "config.yaml", "Path to configuration file")
-	flag.Parse()
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		usage()
+		return
+	}
 
 	// Setup signal handling for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -31,28 +49,45 @@ func main() {
 		cancel()
 	}()
 
-	// Initialize components
-	provider := &llm.BaseProvider{
-		Config: llm.ProviderConfig{
-			ModelName: "gpt-3.5-turbo",
-			MaxTokens: 4096,
-		},
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(ctx, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "modulox %s: %v\n", cmd.name, err)
+				var invalid usageError
+				if errors.As(err, &invalid) {
+					os.Exit(2)
+				}
+				os.Exit(1)
+			}
+			return
+		}
 	}
 
-	store := memory.NewBaseStore()
-	registry := tools.NewToolRegistry()
-
-	// Create base agent
-	agent := agent.NewBaseAgent(agent.BaseAgentConfig{
-		Name:        "modulox-agent",
-		Description: "ModuloX framework base agent",
-		Provider:    provider,
-		Memory:      store,
-		Registry:    registry,
-	})
-
-	// Run agent until context is cancelled
-	fmt.Println("ModuloX agent started. Press Ctrl+C to exit.")
-	<-ctx.Done()
-	fmt.Println("\nShutting down...")
+	fmt.Fprintf(os.Stderr, "modulox: unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+// parseArgs parses flags given before, between, or after the positional
+// arguments, returning the positional ones
+func parseArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: modulox <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'modulox <command> -h' for the flags of a command.")
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/user/modulox/pkg/rest"
+)
+
+// remoteClient calls the REST API of a running modulox server
+type remoteClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// newRemoteClient creates a client for the server at baseURL, sending
+// token as a bearer token when set
+func newRemoteClient(baseURL, token string) *remoteClient {
+	return &remoteClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// do sends a request with body encoded as JSON, decoding the response into
+// out when it is not nil. Error responses are returned as errors.
+func (c *remoteClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	resp, err := c.send(ctx, method, path, reader)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// streamEvents delivers the server's events of the given types to handle
+// until ctx is cancelled or the stream ends
+func (c *remoteClient) streamEvents(ctx context.Context, types []string, handle func(rest.Event)) error {
+	query := url.Values{"type": types}
+	resp, err := c.send(ctx, http.MethodGet, "/v1/events?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+
+	var name string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := strings.TrimPrefix(line, "data: ")
+			if name == "error" {
+				var e rest.ErrorResponse
+				json.Unmarshal([]byte(data), &e)
+				return fmt.Errorf("event stream failed: %s", e.Error)
+			}
+			var event rest.Event
+			if err := json.Unmarshal([]byte(data), &event); err == nil {
+				handle(event)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+// send issues a request with the client's credentials
+func (c *remoteClient) send(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", c.baseURL, err)
+	}
+	return resp, nil
+}
+
+// responseError turns an error response into an error, using the server's
+// message when it sent one
+func responseError(resp *http.Response) error {
+	var e rest.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&e); err == nil && e.Error != "" {
+		return fmt.Errorf("%s (HTTP %d)", e.Error, resp.StatusCode)
+	}
+	return fmt.Errorf("request failed: %s", resp.Status)
+}
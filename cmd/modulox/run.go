@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/user/modulox"
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/rest"
+	"github.com/user/modulox/pkg/tools"
+	"github.com/user/modulox/pkg/tools/builtin"
+	"github.com/user/modulox/pkg/workflow"
+)
+
+// remoteWorkflowEvents are the events streamed while a workflow runs on a
+// remote server
+var remoteWorkflowEvents = []string{
+	"workflow_execution_start",
+	"workflow_execution_complete",
+	"workflow_execution_error",
+	"workflow_step_complete",
+	"workflow_step_error",
+}
+
+// runRun runs a declarative workflow once and prints its result. Step
+// progress goes to stderr so the result can be piped; the command fails
+// when the workflow does.
+func runRun(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: modulox run <workflow.yaml> -input TEXT [flags]")
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", "config.yaml", "Path to configuration file, for local runs")
+	input := flags.String("input", "", "Task for the workflow; - reads it from stdin")
+	remote := flags.String("remote", "", "REST URL of a server to run the workflow on, e.g. http://localhost:8080")
+	token := flags.String("token", os.Getenv("MODULOX_TOKEN"), "Bearer token for the remote server")
+	quiet := flags.Bool("quiet", false, "Do not report step progress")
+	positional := parseArgs(flags, args)
+	if len(positional) != 1 {
+		return usageError{fmt.Errorf("exactly one workflow file is required")}
+	}
+
+	spec, err := workflow.LoadSpec(positional[0])
+	if err != nil {
+		return usageError{err}
+	}
+	task, err := readInput(*input)
+	if err != nil {
+		return usageError{err}
+	}
+
+	progress := io.Writer(os.Stderr)
+	if *quiet {
+		progress = io.Discard
+	}
+	started := time.Now()
+	var result string
+	if *remote != "" {
+		result, err = runRemote(ctx, newRemoteClient(*remote, *token), spec, task, progress)
+	} else {
+		result, err = runLocal(ctx, *configFile, spec, task, progress)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "Workflow %s completed in %s\n", spec.Name, time.Since(started).Round(time.Millisecond))
+	fmt.Println(result)
+	return nil
+}
+
+// runLocal builds the workflow's agents from the configuration and runs it
+// in this process
+func runLocal(ctx context.Context, configFile string, spec *workflow.Spec, task string, progress io.Writer) (string, error) {
+	app, err := modulox.FromConfig(configFile)
+	if err != nil {
+		return "", usageError{err}
+	}
+	defer app.Close(context.Background())
+
+	w, err := spec.Build(stepAgents(app), workflow.WorkflowOptions{})
+	if err != nil {
+		return "", usageError{err}
+	}
+
+	fmt.Fprintf(progress, "Running workflow %s (%d steps)\n", spec.Name, len(spec.Steps))
+	ctx = workflow.WithStepObserver(ctx, func(step workflow.StepRecord) {
+		reportStep(progress, step.Step, step.Duration, step.Error)
+	})
+	return w.Execute(ctx, task)
+}
+
+// stepAgents creates the agents of workflow steps from the app's
+// components, replacing the model and tools where a step names its own
+func stepAgents(app *modulox.App) workflow.AgentFactory {
+	return func(step workflow.StepSpec) (agent.Agent, error) {
+		cfg := *app.Config
+		provider := app.Provider
+		if step.Model != "" {
+			cfg.Provider.ModelName = step.Model
+			var err error
+			if provider, err = llm.NewFromConfig(&cfg); err != nil {
+				return nil, err
+			}
+		}
+		registry := app.Tools
+		if step.Tools != nil {
+			cfg.Tools.EnabledTools = step.Tools
+			registry = tools.NewToolRegistry()
+			if err := builtin.RegisterFromConfig(registry, &cfg); err != nil {
+				return nil, err
+			}
+		}
+
+		b := agent.New(
+			agent.WithName(step.Name),
+			agent.WithDescription(step.Description),
+			agent.WithProvider(provider),
+			agent.WithMemory(app.Memory),
+			agent.WithRegistry(registry),
+		)
+		if step.SystemPrompt != "" {
+			b.WithSystemPrompt(step.SystemPrompt)
+		}
+		return b.Build()
+	}
+}
+
+// runRemote executes the spec's workflow on a server, reporting its step
+// events while it runs. The server must have registered the same spec.
+func runRemote(ctx context.Context, client *remoteClient, spec *workflow.Spec, task string, progress io.Writer) (string, error) {
+	digest, err := spec.Digest()
+	if err != nil {
+		return "", err
+	}
+	name := spec.Name
+	streamCtx, stopStream := context.WithCancel(ctx)
+	defer stopStream()
+	go func() {
+		err := client.streamEvents(streamCtx, remoteWorkflowEvents, func(event rest.Event) {
+			if event.Metadata["workflow_name"] != name {
+				return
+			}
+			switch event.Type {
+			case "workflow_step_complete", "workflow_step_error":
+				duration, _ := time.ParseDuration(event.Metadata["duration_ms"] + "ms")
+				reportStep(progress, event.Metadata["step"], duration, event.Metadata["error"])
+			default:
+				fmt.Fprintln(progress, event.Payload)
+			}
+		})
+		// Progress is best effort; the result is reported either way
+		if err != nil && streamCtx.Err() == nil {
+			fmt.Fprintf(progress, "Not streaming step events: %v\n", err)
+		}
+	}()
+
+	var resp rest.WorkflowResponse
+	path := "/v1/workflows/" + url.PathEscape(name) + "/executions"
+	if err := client.do(ctx, http.MethodPost, path, rest.WorkflowRequest{Task: task, SpecDigest: digest}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Result, nil
+}
+
+// reportStep prints a finished step
+func reportStep(w io.Writer, step string, duration time.Duration, stepErr string) {
+	if stepErr != "" {
+		fmt.Fprintf(w, "  ✗ %s failed after %s: %s\n", step, duration.Round(time.Millisecond), stepErr)
+		return
+	}
+	fmt.Fprintf(w, "  ✓ %s (%s)\n", step, duration.Round(time.Millisecond))
+}
+
+// readInput returns the task given with -input, reading stdin for "-"
+func readInput(input string) (string, error) {
+	switch input {
+	case "":
+		return "", fmt.Errorf("an input is required; pass -input TEXT, or -input - to read stdin")
+	case "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return input, nil
+}
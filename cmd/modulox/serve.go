@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/user/modulox"
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/communication/interceptors"
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/distributed"
+	"github.com/user/modulox/pkg/observability"
+	pb "github.com/user/modulox/pkg/pb"
+	"github.com/user/modulox/pkg/rest"
+	"github.com/user/modulox/pkg/tools"
+	"github.com/user/modulox/pkg/workflow"
+	"google.golang.org/grpc"
+)
+
+// shutdownTimeout bounds how long serve waits for in-flight requests
+const shutdownTimeout = 30 * time.Second
+
+// runServe serves the configured agent until ctx is cancelled
+func runServe(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	configFile := flags.String("config", "config.yaml", "Path to configuration file")
+	flags.Parse(args)
+
+	app, err := modulox.FromConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	defer app.Close(context.Background())
+	cfg := app.Config
+	if err := checkExposure(cfg); err != nil {
+		return err
+	}
+
+	// Provider, memory, rate limit, and logging changes apply without a
+	// restart
+	watcher, err := config.NewWatcher(config.WatcherConfig{Path: *configFile})
+	if err != nil {
+		return err
+	}
+	app.Watch(watcher)
+	go watcher.Run(ctx)
+
+	s := &services{errs: make(chan error, 4)}
+	defer s.shutdown()
+
+	if err := s.startAgentServer(ctx, app); err != nil {
+		return err
+	}
+	if cfg.Server.RESTAddress != "" {
+		if err := s.startREST(app); err != nil {
+			return err
+		}
+	}
+	if cfg.Server.MetricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", app.Metrics.Handler())
+		s.startHTTP("metrics", cfg.Server.MetricsAddress, mux)
+	}
+	if cfg.Server.HealthAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", s.health.Handler())
+		s.startHTTP("health", cfg.Server.HealthAddress, mux)
+	}
+	if cfg.Cluster.Enabled {
+		if err := s.joinCluster(ctx, app); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("ModuloX agent %s serving on %s. Press Ctrl+C to exit.\n", app.Agent.GetName(), cfg.Server.Address)
+	select {
+	case <-ctx.Done():
+		fmt.Println("\nShutting down...")
+		return nil
+	case err := <-s.errs:
+		return err
+	}
+}
+
+// services tracks the servers serve started so they can be shut down
+type services struct {
+	agentServer *communication.AgentServer
+	rest        *rest.Server
+	coordinator *workflow.Coordinator
+	conn        *grpc.ClientConn
+	http        []*http.Server
+	health      *observability.HealthChecker
+	node        *distributed.Node
+	cluster     *distributed.Cluster
+	bus         communication.MessageBus
+	state       *communication.StateStore
+	errs        chan error
+}
+
+// startAgentServer starts the gRPC agent server. Tasks run on the agent
+// directly, or through the cluster node when the cluster is enabled.
+func (s *services) startAgentServer(ctx context.Context, app *modulox.App) error {
+	cfg := app.Config
+	server := communication.NewAgentServer()
+	// Faults apply to the cluster calls this process makes as a client
+	instrumentation := interceptors.Config{
+		Tracer:  app.Tracer,
+		Metrics: app.Metrics,
+		Faults:  app.Faults,
+	}
+	server.EnableInstrumentation(instrumentation)
+	if tls := communication.TLSConfigFromConfig(cfg); tls != nil {
+		if err := server.EnableTLS(*tls); err != nil {
+			return err
+		}
+	}
+	ids, az := tokenAuth(cfg)
+	if ids != nil {
+		server.EnableAuthorization(ids, az)
+	}
+
+	var err error
+	if s.bus, err = communication.NewMessageBusFromConfig(cfg, app.Logger); err != nil {
+		return fmt.Errorf("failed to create message bus: %w", err)
+	}
+	if app.Faults != nil {
+		s.bus = communication.NewFaultyBus(s.bus, app.Faults)
+	}
+	server.SetMessageBus(s.bus)
+	if s.state, err = communication.NewStateStoreFromConfig(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+	server.SetStateStore(s.state)
+	// Remote tool calls are authorized per tool
+	executor := tools.NewSafeExecutor(app.Tools)
+	if az != nil {
+		executor.SetAuthorizer(az)
+	}
+	server.SetToolExecutor(executor)
+	server.SetTaskExecutor(agentExecutor{agent: app.Agent})
+
+	s.health = observability.NewHealthChecker()
+	if cfg.Cluster.Enabled {
+		nodeConfig := distributed.NodeConfigFromConfig(cfg)
+		nodeConfig.Interceptors = &instrumentation
+		if s.node, err = distributed.NewNode(nodeConfig); err != nil {
+			return err
+		}
+		server.SetTaskExecutor(s.node)
+		s.health.RegisterCheck("node", nodeCheck(s.node))
+
+		// The server hosting the cluster's registry is the one nodes join
+		if cfg.HostsCluster() {
+			clusterConfig := distributed.ClusterConfigFromConfig(cfg)
+			clusterConfig.Authorizer = az
+			clusterConfig.Interceptors = &instrumentation
+			clusterConfig.Bulkheads = app.Bulkheads
+			if s.cluster, err = distributed.NewCluster(clusterConfig); err != nil {
+				return err
+			}
+			server.SetNodeRegistry(s.cluster)
+			server.SetTopologySource(s.cluster)
+		}
+	}
+
+	s.agentServer = server
+	go func() {
+		if err := server.Start(cfg.Server.Address); err != nil {
+			s.errs <- fmt.Errorf("agent server: %w", err)
+		}
+	}()
+	return nil
+}
+
+// startREST starts the REST API, forwarding gateway routes to the agent
+// server
+func (s *services) startREST(app *modulox.App) error {
+	cfg := app.Config
+	transport := grpc.WithInsecure()
+	if tls := communication.TLSConfigFromConfig(cfg); tls != nil {
+		creds, err := tls.ClientCredentials()
+		if err != nil {
+			return fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		transport = grpc.WithTransportCredentials(creds)
+	}
+	conn, err := grpc.Dial(localAddress(cfg.Server.Address), transport)
+	if err != nil {
+		return fmt.Errorf("failed to connect to agent server: %w", err)
+	}
+	s.conn = conn
+
+	ids, az := tokenAuth(cfg)
+	restConfig := rest.ServerConfig{
+		Identity:     ids,
+		Authorizer:   az,
+		AgentService: pb.NewAgentServiceClient(conn),
+	}
+	if s.cluster != nil {
+		restConfig.Cluster = s.cluster
+	}
+	if len(cfg.Server.Workflows) > 0 {
+		if err := s.startWorkflows(app); err != nil {
+			return err
+		}
+		restConfig.Workflows = s.coordinator
+	}
+	server := rest.NewServer(restConfig)
+	if err := server.RegisterAgent(app.Agent.GetName(), app.Agent); err != nil {
+		return err
+	}
+
+	s.rest = server
+	go func() {
+		if err := server.Start(cfg.Server.RESTAddress); err != nil {
+			s.errs <- fmt.Errorf("REST server: %w", err)
+		}
+	}()
+	return nil
+}
+
+// startWorkflows registers the configured workflow files with a coordinator
+// publishing through the agent server
+func (s *services) startWorkflows(app *modulox.App) error {
+	cfg := app.Config
+	coordinator, err := workflow.NewCoordinatorWithConfig(communication.ClientConfig{
+		Address: localAddress(cfg.Server.Address),
+		TLS:     communication.TLSConfigFromConfig(cfg),
+		Token:   cfg.Server.Token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create workflow coordinator: %w", err)
+	}
+	s.coordinator = coordinator
+
+	for _, path := range cfg.Server.Workflows {
+		spec, err := workflow.LoadSpec(path)
+		if err != nil {
+			return err
+		}
+		if err := coordinator.RegisterSpec(spec, stepAgents(app), workflow.WorkflowOptions{}); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// startHTTP serves handler on address
+func (s *services) startHTTP(name, address string, handler http.Handler) {
+	server := &http.Server{Addr: address, Handler: handler}
+	s.http = append(s.http, server)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.errs <- fmt.Errorf("%s server: %w", name, err)
+		}
+	}()
+}
+
+// joinCluster registers the agent with the node and joins the cluster
+func (s *services) joinCluster(ctx context.Context, app *modulox.App) error {
+	if err := s.node.RegisterAgent(app.Agent); err != nil {
+		return fmt.Errorf("failed to register agent with node: %w", err)
+	}
+	return s.node.Join(ctx)
+}
+
+// shutdown leaves the cluster and stops the servers, waiting up to
+// shutdownTimeout for in-flight requests
+func (s *services) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	report := func(name string, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error stopping %s: %v\n", name, err)
+		}
+	}
+	if s.node != nil {
+		report("node", s.node.Leave(ctx))
+	}
+	if s.rest != nil {
+		report("REST server", s.rest.Shutdown(ctx))
+	}
+	for _, server := range s.http {
+		report(server.Addr, server.Shutdown(ctx))
+	}
+	if s.agentServer != nil {
+		report("agent server", s.agentServer.Shutdown(ctx))
+	}
+	if s.coordinator != nil {
+		report("workflow coordinator", s.coordinator.Close())
+	}
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	if s.node != nil {
+		report("node", s.node.Close())
+	}
+	if s.cluster != nil {
+		report("cluster", s.cluster.Close())
+	}
+	if s.state != nil {
+		report("state store", s.state.Close())
+	}
+	if s.bus != nil {
+		report("message bus", s.bus.Close())
+	}
+}
+
+// agentExecutor runs agent server tasks on the configured agent
+type agentExecutor struct {
+	agent *agent.BaseAgent
+}
+
+// ExecuteTask implements communication.TaskExecutor.ExecuteTask
+func (e agentExecutor) ExecuteTask(ctx context.Context, agentID string, task string) (string, error) {
+	if agentID != "" && agentID != e.agent.GetName() {
+		return "", fmt.Errorf("agent not found: %s", agentID)
+	}
+	return e.agent.Execute(ctx, task)
+}
+
+// tokenAuth requires Config.Server.Token from clients, granting its holders
+// the admin role, or a cluster peer's token, granting the node role; it
+// returns nils when no token is configured
+func tokenAuth(cfg *config.Config) (auth.IdentitySource, *auth.Authorizer) {
+	if cfg.Server.Token == "" && len(cfg.Cluster.Peers) == 0 {
+		return nil, nil
+	}
+	ids := auth.NewStaticIdentitySource()
+	if cfg.Server.Token != "" {
+		ids.AddToken(cfg.Server.Token, &auth.Principal{ID: cfg.Agent.Name, Roles: []string{"admin"}})
+	}
+	for id, token := range cfg.Cluster.Peers {
+		ids.AddToken(token, &auth.Principal{
+			ID:       id,
+			Roles:    []string{"node"},
+			Metadata: map[string]string{auth.AgentIDKey: id},
+		})
+	}
+	return ids, auth.NewAuthorizer(auth.DefaultRoles()...)
+}
+
+// checkExposure refuses to serve the agent or REST API beyond the loopback
+// interface without authentication
+func checkExposure(cfg *config.Config) error {
+	if ids, _ := tokenAuth(cfg); ids != nil {
+		return nil
+	}
+	for _, address := range []string{cfg.Server.Address, cfg.Server.RESTAddress} {
+		if address != "" && !isLoopback(address) {
+			return fmt.Errorf("refusing to serve on %s without authentication: set server.token or listen on a loopback address", address)
+		}
+	}
+	return nil
+}
+
+// isLoopback reports whether a listen address accepts only local connections
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// nodeCheck reports the cluster node's status
+func nodeCheck(node *distributed.Node) observability.HealthCheck {
+	return func(ctx context.Context) observability.HealthStatus {
+		status := node.GetStatus()
+		health := "healthy"
+		if s := distributed.NodeStatus(status.Status); s != distributed.StatusHealthy && s != distributed.StatusOverloaded {
+			health = s.String()
+		}
+		return observability.HealthStatus{
+			Status:    health,
+			Timestamp: time.Now(),
+			Details:   map[string]interface{}{"node_id": status.ID, "load": status.Load},
+		}
+	}
+}
+
+// localAddress turns a listen address such as ":50051" into one to dial
+func localAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || (host != "" && host != "0.0.0.0" && host != "::") {
+		return address
+	}
+	return net.JoinHostPort("localhost", port)
+}
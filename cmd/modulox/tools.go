@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/tools"
+	"github.com/user/modulox/pkg/tools/builtin"
+)
+
+// toolCommands are the subcommands of modulox tools
+var toolCommands = []command{
+	{"list", "List the built-in and plugin tools", toolsList},
+	{"describe", "Print a tool's description and schemas", toolsDescribe},
+	{"invoke", "Run a tool with JSON input and print its output", toolsInvoke},
+}
+
+// runTools inspects and invokes the tools a configuration provides
+func runTools(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		for _, cmd := range toolCommands {
+			if cmd.name == args[0] {
+				return cmd.run(ctx, args[1:])
+			}
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "Usage: modulox tools <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range toolCommands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	if len(args) == 0 {
+		return usageError{fmt.Errorf("a command is required")}
+	}
+	return usageError{fmt.Errorf("unknown command %q", args[0])}
+}
+
+// toolSet is a registry of the configured tools and where each came from
+type toolSet struct {
+	registry *tools.ToolRegistry
+	sources  map[string]string
+}
+
+// toolFlags adds the flags selecting tools to flags, returning a function
+// loading them once the flags are parsed
+func toolFlags(flags *flag.FlagSet) func() (*toolSet, error) {
+	configFile := flags.String("config", "config.yaml", "Path to configuration file")
+	all := flags.Bool("all", false, "Include built-in tools the configuration does not enable")
+	return func() (*toolSet, error) {
+		cfg, err := config.LoadConfig(*configFile)
+		if err != nil {
+			return nil, usageError{err}
+		}
+		return loadTools(cfg, *all)
+	}
+}
+
+// loadTools registers the enabled built-in tools, every available one when
+// all is set, and the tools of the plugin directory
+func loadTools(cfg *config.Config, all bool) (*toolSet, error) {
+	opts, err := builtin.OptionsFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	set := &toolSet{registry: tools.NewToolRegistry(), sources: make(map[string]string)}
+
+	if err := builtin.Register(set.registry, cfg.Tools.EnabledTools, opts); err != nil {
+		return nil, err
+	}
+	for _, name := range cfg.Tools.EnabledTools {
+		set.sources[name] = "built-in"
+	}
+	if all {
+		for _, name := range builtin.Names() {
+			if _, enabled := set.sources[name]; enabled {
+				continue
+			}
+			// Tools lacking required options, such as a search backend, are skipped
+			tool, err := builtin.New(name, opts)
+			if err != nil {
+				continue
+			}
+			if err := set.registry.RegisterContextTool(name, tool, nil); err != nil {
+				return nil, err
+			}
+			set.sources[name] = "built-in (disabled)"
+		}
+	}
+
+	if cfg.Tools.PluginDir != "" {
+		names, err := tools.LoadPluginDir(set.registry, cfg.Tools.PluginDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			set.sources[name] = "plugin"
+		}
+	}
+	return set, nil
+}
+
+func toolsList(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("tools list", flag.ExitOnError)
+	load := toolFlags(flags)
+	asJSON := flags.Bool("json", false, "Print the tool definitions as JSON")
+	flags.Parse(args)
+
+	set, err := load()
+	if err != nil {
+		return err
+	}
+	definitions := set.registry.ToolDefinitions()
+	if *asJSON {
+		return printJSON(definitions)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tDESCRIPTION")
+	for _, d := range definitions {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Name, set.sources[d.Name], truncate(d.Description, 80))
+	}
+	return w.Flush()
+}
+
+func toolsDescribe(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("tools describe", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: modulox tools describe [flags] <tool>")
+		flags.PrintDefaults()
+	}
+	load := toolFlags(flags)
+	positional := parseArgs(flags, args)
+	if len(positional) != 1 {
+		return usageError{fmt.Errorf("exactly one tool name is required")}
+	}
+	name := positional[0]
+
+	set, err := load()
+	if err != nil {
+		return err
+	}
+	for _, d := range set.registry.ToolDefinitions() {
+		if d.Name != name {
+			continue
+		}
+		fmt.Printf("Name:        %s\n", d.Name)
+		fmt.Printf("Source:      %s\n", set.sources[d.Name])
+		fmt.Printf("Description: %s\n", d.Description)
+		fmt.Println("\nArguments:")
+		if err := printJSON(d.Parameters); err != nil {
+			return err
+		}
+		for _, c := range set.registry.DiscoverCapabilities() {
+			if c.Name == name && c.Parameters["output"] != nil {
+				fmt.Println("\nOutput:")
+				return printJSON(c.Parameters["output"])
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("tool not found: %s", name)
+}
+
+func toolsInvoke(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("tools invoke", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: modulox tools invoke [flags] <tool> [json-input]")
+		flags.PrintDefaults()
+	}
+	load := toolFlags(flags)
+	timeout := flags.Duration("timeout", 30*time.Second, "Time limit for the invocation")
+	positional := parseArgs(flags, args)
+	if len(positional) < 1 || len(positional) > 2 {
+		return usageError{fmt.Errorf("a tool name and at most one JSON input are required")}
+	}
+
+	// Without an input argument, the input is read from stdin
+	var data []byte
+	if len(positional) == 2 {
+		data = []byte(positional[1])
+	} else {
+		var err error
+		if data, err = io.ReadAll(os.Stdin); err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+	}
+	var input interface{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return usageError{fmt.Errorf("input is not valid JSON (quote strings, e.g. '\"text\"'): %w", err)}
+	}
+
+	set, err := load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	started := time.Now()
+	output, err := set.registry.ExecuteToolContext(ctx, positional[0], input)
+	fmt.Fprintf(os.Stderr, "%s ran in %s\n", positional[0], time.Since(started).Round(time.Millisecond))
+	if err != nil {
+		return err
+	}
+
+	if s, ok := output.(string); ok {
+		fmt.Println(strings.TrimRight(s, "\n"))
+		return nil
+	}
+	return printJSON(output)
+}
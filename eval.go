@@ -0,0 +1,45 @@
+package modulox
+
+import (
+	"fmt"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/eval"
+)
+
+// VariantConfig is what an A/B comparison varies between agents. Empty
+// fields keep the value of the base configuration.
+type VariantConfig struct {
+	Name         string
+	Model        string
+	SystemPrompt string
+	Temperature  *float64
+}
+
+// NewVariant builds an App from base with the model, system prompt, and
+// temperature of v, and returns an eval.Variant running its agent. The
+// caller closes the App once the comparison is done.
+func NewVariant(base *config.Config, v VariantConfig) (eval.Variant, *App, error) {
+	cfg := *base
+	cfg.Provider.Parameters = make(map[string]interface{}, len(base.Provider.Parameters)+1)
+	for key, value := range base.Provider.Parameters {
+		cfg.Provider.Parameters[key] = value
+	}
+	if v.Model != "" {
+		cfg.Provider.ModelName = v.Model
+	}
+	if v.Temperature != nil {
+		cfg.Provider.Parameters["temperature"] = *v.Temperature
+	}
+	var options []agent.Option
+	if v.SystemPrompt != "" {
+		options = append(options, agent.WithSystemPrompt(v.SystemPrompt))
+	}
+
+	app, err := New(&cfg, options...)
+	if err != nil {
+		return eval.Variant{}, nil, fmt.Errorf("variant %s: %w", v.Name, err)
+	}
+	return eval.Variant{Name: v.Name, Target: app.Agent}, app, nil
+}
@@ -0,0 +1,228 @@
+// Package modulox builds a fully wired agent from configuration:
+//
+//	app, err := modulox.FromConfig("config.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	defer app.Close(ctx)
+//	answer, err := app.Agent.Execute(ctx, question)
+//
+// Components are created by the factories of their packages, so types
+// added with llm.RegisterProvider or memory.RegisterStore can be selected
+// in configuration.
+package modulox
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/memory"
+	"github.com/user/modulox/pkg/observability"
+	"github.com/user/modulox/pkg/reliability"
+	"github.com/user/modulox/pkg/tools"
+	"github.com/user/modulox/pkg/tools/builtin"
+)
+
+// App holds the components built from a configuration
+type App struct {
+	Config   *config.Config
+	Provider llm.Provider
+	Memory   memory.VectorStore
+	Tools    *tools.ToolRegistry
+	Logger   *observability.Logger
+	Tracer   *observability.Tracer
+	Metrics  *observability.MetricsCollector
+	// Faults injects faults into provider calls and tool executions when
+	// reliability.faults is enabled, and is nil otherwise
+	Faults *reliability.FaultInjector
+	// Bulkheads limit the concurrent calls to the provider ("llm"), each
+	// tool ("tool.<name>"), and each cluster node ("node.<id>") when
+	// reliability.bulkhead is set, and are nil otherwise
+	Bulkheads *reliability.Bulkheads
+	Agent     *agent.BaseAgent
+
+	// limitStore connects to the agent server holding a distributed rate
+	// limit's budget
+	limitStore *communication.AgentClient
+	// provider, memory, and limiter are the components Watch replaces when
+	// their settings change
+	provider *llm.ReloadableProvider
+	memory   *memory.ReloadableStore
+	limiter  *reliability.ReloadableLimiter
+}
+
+// FromConfig loads the configuration file at path and builds its components
+func FromConfig(path string) (*App, error) {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// New builds the components of a loaded configuration. Options are applied
+// to the agent after the configured components, so they can override them.
+func New(cfg *config.Config, options ...agent.Option) (*App, error) {
+	app := &App{Config: cfg, Metrics: observability.NewMetricsCollector()}
+
+	var err error
+	if app.Logger, err = observability.NewLoggerFromConfig(cfg); err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+	if app.Tracer, err = observability.NewTracerFromConfig(cfg); err != nil {
+		app.Close(context.Background())
+		return nil, fmt.Errorf("failed to create tracer: %w", err)
+	}
+	app.Faults = reliability.NewFaultInjectorFromConfig(cfg)
+	provider, err := app.newProvider(cfg)
+	if err != nil {
+		app.Close(context.Background())
+		return nil, err
+	}
+	app.provider = llm.NewReloadableProvider(provider)
+	app.Provider = llm.Narrow(app.provider, provider)
+	if cfg.Reliability.RateLimit.Distributed && cfg.Reliability.RateLimit.Rate > 0 {
+		app.limitStore, err = communication.NewAgentClientWithConfig(communication.ClientConfig{
+			Address: cfg.Cluster.Address,
+			AgentID: cfg.Agent.Name,
+			TLS:     communication.TLSConfigFromConfig(cfg),
+			Token:   cfg.ClusterToken(),
+		})
+		if err != nil {
+			app.Close(context.Background())
+			return nil, fmt.Errorf("failed to connect to rate limit store: %w", err)
+		}
+	}
+	if app.Bulkheads = reliability.NewBulkheadsFromConfig(cfg); app.Bulkheads != nil {
+		app.Bulkheads.SetMetrics(app.Metrics.ReliabilityRecorder())
+	}
+	var policy []reliability.PolicyOption
+	if limiter := reliability.NewLimiterFromConfig(cfg, app.limitStore); limiter != nil {
+		app.limiter = reliability.NewReloadableLimiter(limiter)
+		policy = append(policy, reliability.WithRateLimit(app.limiter))
+	}
+	if app.Bulkheads != nil {
+		policy = append(policy, reliability.WithBulkhead(app.Bulkheads.Get("llm")))
+	}
+	if len(policy) > 0 {
+		policy = append(policy, reliability.WithMetrics("llm", app.Metrics.ReliabilityRecorder()))
+		app.Provider = llm.Narrow(llm.NewPolicyProvider(app.Provider, reliability.NewPolicy(policy...)), app.Provider)
+	}
+	store, err := memory.NewFromConfig(cfg)
+	if err != nil {
+		app.Close(context.Background())
+		return nil, fmt.Errorf("failed to create memory: %w", err)
+	}
+	app.memory = memory.NewReloadableStore(store)
+	app.Memory = app.memory
+	app.Tools = tools.NewToolRegistry()
+	app.Tools.SetFaultInjector(app.Faults)
+	if app.Bulkheads != nil {
+		app.Tools.SetBulkheads(app.Bulkheads)
+	}
+	if err := builtin.RegisterFromConfig(app.Tools, cfg); err != nil {
+		app.Close(context.Background())
+		return nil, fmt.Errorf("failed to register tools: %w", err)
+	}
+	if cfg.Tools.PluginDir != "" {
+		if _, err := tools.LoadPluginDir(app.Tools, cfg.Tools.PluginDir); err != nil {
+			app.Close(context.Background())
+			return nil, err
+		}
+	}
+
+	app.Agent, err = agent.New(
+		agent.WithName(cfg.Agent.Name),
+		agent.WithDescription(cfg.Agent.Description),
+		agent.WithProvider(app.Provider),
+		agent.WithMemory(app.Memory),
+		agent.WithRegistry(app.Tools),
+	).With(options...).Build()
+	if err != nil {
+		app.Close(context.Background())
+		return nil, fmt.Errorf("failed to create agent: %w", err)
+	}
+	return app, nil
+}
+
+// newProvider creates the configured provider, injecting faults when
+// enabled
+func (a *App) newProvider(cfg *config.Config) (llm.Provider, error) {
+	provider, err := llm.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+	if a.Faults != nil {
+		provider = llm.NewFaultyProvider(provider, a.Faults)
+	}
+	return provider, nil
+}
+
+// Watch applies the provider, memory, rate limit, and logging settings w
+// reloads to the running components. The optional interfaces the provider
+// exposes, a rate limit not configured at startup, and log sinks change
+// only on restart; such changes are reported through w.
+func (a *App) Watch(w *config.Watcher) {
+	w.Subscribe("provider", a.reloadProvider)
+	w.Subscribe("memory", a.reloadMemory)
+	w.Subscribe("reliability.rate_limit", a.reloadRateLimit)
+	w.Subscribe("logging", a.Logger.ReloadConfig)
+}
+
+// reloadProvider replaces the provider with one built from cfg
+func (a *App) reloadProvider(cfg *config.Config, changes []config.Change) error {
+	provider, err := a.newProvider(cfg)
+	if err != nil {
+		return err
+	}
+	a.provider.Swap(provider)
+	return nil
+}
+
+// reloadMemory replaces the memory store with one built from cfg and
+// closes the old one
+func (a *App) reloadMemory(cfg *config.Config, changes []config.Change) error {
+	store, err := memory.NewFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create memory: %w", err)
+	}
+	if closer, ok := a.memory.Swap(store).(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// reloadRateLimit replaces the provider's rate limiter with one built from
+// cfg; a zero rate lifts the limit
+func (a *App) reloadRateLimit(cfg *config.Config, changes []config.Change) error {
+	rc := cfg.Reliability.RateLimit
+	if a.limiter == nil || (rc.Distributed && a.limitStore == nil) {
+		return fmt.Errorf("reliability.rate_limit changed; restart to apply")
+	}
+	a.limiter.Swap(reliability.NewLimiterFromConfig(cfg, a.limitStore))
+	return nil
+}
+
+// Close flushes pending spans and closes the log sinks and connections
+func (a *App) Close(ctx context.Context) error {
+	var firstErr error
+	if a.Tracer != nil {
+		firstErr = a.Tracer.Close(ctx)
+	}
+	if a.limitStore != nil {
+		if err := a.limitStore.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if a.Logger != nil {
+		if err := a.Logger.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
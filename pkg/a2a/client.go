@@ -0,0 +1,293 @@
+package a2a
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+// Client talks to an external A2A-compatible agent
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	nextID     int64
+}
+
+// NewClient creates a new A2A client for the agent at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{},
+	}
+}
+
+// GetAgentCard fetches the remote agent card
+func (c *Client) GetAgentCard(ctx context.Context) (*AgentCard, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+AgentCardPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch agent card: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch agent card: %s", resp.Status)
+	}
+
+	var card AgentCard
+	if err := json.NewDecoder(resp.Body).Decode(&card); err != nil {
+		return nil, fmt.Errorf("failed to decode agent card: %w", err)
+	}
+	return &card, nil
+}
+
+// SendTask sends a task and waits for its result
+func (c *Client) SendTask(ctx context.Context, params TaskSendParams) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, MethodSendTask, params, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetTask retrieves the current state of a task
+func (c *Client) GetTask(ctx context.Context, id string) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, MethodGetTask, TaskIDParams{ID: id}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// CancelTask cancels a running task
+func (c *Client) CancelTask(ctx context.Context, id string) (*Task, error) {
+	var task Task
+	if err := c.call(ctx, MethodCancelTask, TaskIDParams{ID: id}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// SendTaskSubscribe sends a task and streams its updates. Each value on the
+// channel is a *TaskStatusUpdateEvent or *TaskArtifactUpdateEvent, or an
+// error ending the stream before the final update.
+func (c *Client) SendTaskSubscribe(ctx context.Context, params TaskSendParams) (<-chan interface{}, error) {
+	resp, err := c.post(ctx, MethodSendTaskSubscribe, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Servers refuse the subscription with a plain JSON-RPC response
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		defer resp.Body.Close()
+		var rpcResp jsonRPCResponse
+		if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		if rpcResp.Error != nil {
+			return nil, rpcResp.Error
+		}
+		return nil, fmt.Errorf("failed to call %s: response is not an event stream", MethodSendTaskSubscribe)
+	}
+
+	events := make(chan interface{}, 16)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		send := func(v interface{}) bool {
+			select {
+			case events <- v:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+
+			var rpcResp jsonRPCResponse
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &rpcResp); err != nil {
+				send(fmt.Errorf("failed to decode event: %w", err))
+				return
+			}
+			if rpcResp.Error != nil {
+				send(rpcResp.Error)
+				return
+			}
+
+			event, final := decodeEvent(rpcResp.Result)
+			if event == nil {
+				continue
+			}
+			if !send(event) || final {
+				return
+			}
+		}
+
+		err := scanner.Err()
+		if err == nil {
+			err = fmt.Errorf("event stream ended before the task finished")
+		}
+		send(err)
+	}()
+
+	return events, nil
+}
+
+// decodeEvent distinguishes status and artifact updates by their fields
+func decodeEvent(data json.RawMessage) (interface{}, bool) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, false
+	}
+
+	if _, ok := probe["artifact"]; ok {
+		var event TaskArtifactUpdateEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, false
+		}
+		return &event, false
+	}
+
+	var event TaskStatusUpdateEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, false
+	}
+	return &event, event.Final
+}
+
+// call performs a JSON-RPC call and decodes the result
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	resp, err := c.post(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp jsonRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+func (c *Client) post(ctx context.Context, method string, params interface{}) (*http.Response, error) {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode params: %w", err)
+	}
+
+	body, err := json.Marshal(jsonRPCRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddInt64(&c.nextID, 1),
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to call %s: %s", method, resp.Status)
+	}
+	return resp, nil
+}
+
+// RemoteAgent adapts an external A2A agent to the agent.Agent interface so
+// workflows can delegate steps to agents from other frameworks
+type RemoteAgent struct {
+	name   string
+	client *Client
+	card   *AgentCard
+}
+
+// NewRemoteAgent creates an agent backed by the A2A agent at baseURL
+func NewRemoteAgent(ctx context.Context, baseURL string) (*RemoteAgent, error) {
+	client := NewClient(baseURL)
+	card, err := client.GetAgentCard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteAgent{
+		name:   card.Name,
+		client: client,
+		card:   card,
+	}, nil
+}
+
+// Execute implements agent.Agent.Execute
+func (r *RemoteAgent) Execute(ctx context.Context, input string) (string, error) {
+	task, err := r.client.SendTask(ctx, TaskSendParams{
+		ID:      fmt.Sprintf("task-%d", time.Now().UnixNano()),
+		Message: Message{Role: "user", Parts: []Part{TextPart(input)}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("remote agent %s failed: %w", r.name, err)
+	}
+
+	if task.Status.State != TaskStateCompleted {
+		reason := string(task.Status.State)
+		if task.Status.Message != nil {
+			reason = task.Status.Message.Text()
+		}
+		return "", fmt.Errorf("remote agent %s did not complete task: %s", r.name, reason)
+	}
+
+	return task.Text(), nil
+}
+
+// AddTool implements agent.Agent.AddTool; remote agents manage their own tools
+func (r *RemoteAgent) AddTool(tool types.Tool) error {
+	return fmt.Errorf("cannot add tools to remote agent: %s", r.name)
+}
+
+// GetCapabilities implements agent.Agent.GetCapabilities
+func (r *RemoteAgent) GetCapabilities() []types.Capability {
+	capabilities := make([]types.Capability, 0, len(r.card.Skills))
+	for _, skill := range r.card.Skills {
+		capabilities = append(capabilities, types.Capability{
+			Name:        skill.Name,
+			Description: skill.Description,
+		})
+	}
+	return capabilities
+}
+
+// GetName returns the name of the remote agent
+func (r *RemoteAgent) GetName() string {
+	return r.name
+}
@@ -0,0 +1,289 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+)
+
+// AgentCardPath is the well-known path of the agent card
+const AgentCardPath = "/.well-known/agent.json"
+
+// Finished tasks stay queryable for taskRetention, and at most
+// maxFinishedTasks of them are kept
+const (
+	taskRetention    = time.Hour
+	maxFinishedTasks = 1000
+)
+
+// Server exposes a ModuloX agent as an A2A server
+type Server struct {
+	card  AgentCard
+	agent agent.Agent
+	tasks map[string]*taskRecord
+	mu    sync.RWMutex
+}
+
+// taskRecord tracks a task and the cancel function of its execution
+type taskRecord struct {
+	task   *Task
+	cancel context.CancelFunc
+}
+
+// NewServer creates a new A2A server for the agent. Skills default to the
+// agent's capabilities when the card lists none.
+func NewServer(card AgentCard, a agent.Agent) *Server {
+	if len(card.Skills) == 0 {
+		for _, c := range a.GetCapabilities() {
+			card.Skills = append(card.Skills, AgentSkill{ID: c.Name, Name: c.Name, Description: c.Description})
+		}
+	}
+	if len(card.DefaultInputModes) == 0 {
+		card.DefaultInputModes = []string{"text"}
+	}
+	if len(card.DefaultOutputModes) == 0 {
+		card.DefaultOutputModes = []string{"text"}
+	}
+	card.Capabilities.Streaming = true
+	card.Capabilities.StateTransitionHistory = true
+
+	return &Server{
+		card:  card,
+		agent: a,
+		tasks: make(map[string]*taskRecord),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.URL.Path == AgentCardPath {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.card)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req jsonRPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRPC(w, nil, nil, &RPCError{Code: CodeParseError, Message: err.Error()})
+		return
+	}
+
+	switch req.Method {
+	case MethodSendTask:
+		var params TaskSendParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			writeRPC(w, req.ID, nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()})
+			return
+		}
+		task := s.execute(r.Context(), params, func(interface{}) {})
+		writeRPC(w, req.ID, task, nil)
+
+	case MethodSendTaskSubscribe:
+		var params TaskSendParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			writeRPC(w, req.ID, nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()})
+			return
+		}
+		s.subscribe(w, r, req.ID, params)
+
+	case MethodGetTask:
+		var params TaskIDParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			writeRPC(w, req.ID, nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()})
+			return
+		}
+		task, err := s.getTask(params)
+		writeRPC(w, req.ID, task, err)
+
+	case MethodCancelTask:
+		var params TaskIDParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			writeRPC(w, req.ID, nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()})
+			return
+		}
+		task, err := s.cancelTask(params.ID)
+		writeRPC(w, req.ID, task, err)
+
+	default:
+		writeRPC(w, req.ID, nil, &RPCError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)})
+	}
+}
+
+// execute runs a task to completion, reporting updates through emit
+func (s *Server) execute(ctx context.Context, params TaskSendParams, emit func(interface{})) *Task {
+	if params.ID == "" {
+		params.ID = fmt.Sprintf("task-%d", time.Now().UnixNano())
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	task := &Task{
+		ID:        params.ID,
+		SessionID: params.SessionID,
+		History:   []Message{params.Message},
+		Metadata:  params.Metadata,
+	}
+
+	s.mu.Lock()
+	s.pruneLocked(time.Now())
+	s.tasks[task.ID] = &taskRecord{task: task, cancel: cancel}
+	s.mu.Unlock()
+
+	s.setStatus(task, TaskStateWorking, nil, emit)
+
+	output, err := s.agent.Execute(ctx, params.Message.Text())
+	switch {
+	case ctx.Err() == context.Canceled:
+		s.setStatus(task, TaskStateCanceled, nil, emit)
+	case err != nil:
+		msg := Message{Role: "agent", Parts: []Part{TextPart(err.Error())}}
+		s.setStatus(task, TaskStateFailed, &msg, emit)
+	default:
+		artifact := Artifact{Parts: []Part{TextPart(output)}, Index: 0, LastChunk: true}
+		s.mu.Lock()
+		task.Artifacts = append(task.Artifacts, artifact)
+		task.History = append(task.History, Message{Role: "agent", Parts: artifact.Parts})
+		s.mu.Unlock()
+		emit(TaskArtifactUpdateEvent{ID: task.ID, Artifact: artifact})
+		s.setStatus(task, TaskStateCompleted, nil, emit)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cp := *task
+	return &cp
+}
+
+// pruneLocked drops finished tasks older than taskRetention, then the oldest
+// finished ones beyond maxFinishedTasks. Running tasks are always kept.
+func (s *Server) pruneLocked(now time.Time) {
+	var finished []*Task
+	for id, rec := range s.tasks {
+		if !rec.task.Status.State.Final() {
+			continue
+		}
+		if now.Sub(rec.task.Status.Timestamp) > taskRetention {
+			delete(s.tasks, id)
+			continue
+		}
+		finished = append(finished, rec.task)
+	}
+	if len(finished) <= maxFinishedTasks {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].Status.Timestamp.Before(finished[j].Status.Timestamp)
+	})
+	for _, task := range finished[:len(finished)-maxFinishedTasks] {
+		delete(s.tasks, task.ID)
+	}
+}
+
+// setStatus transitions a task and emits a status update
+func (s *Server) setStatus(task *Task, state TaskState, msg *Message, emit func(interface{})) {
+	s.mu.Lock()
+	if task.Status.State.Final() {
+		s.mu.Unlock()
+		return
+	}
+	task.Status = TaskStatus{State: state, Message: msg, Timestamp: time.Now()}
+	status := task.Status
+	s.mu.Unlock()
+
+	emit(TaskStatusUpdateEvent{ID: task.ID, Status: status, Final: state.Final()})
+}
+
+// subscribe runs a task while streaming updates as Server-Sent Events
+func (s *Server) subscribe(w http.ResponseWriter, r *http.Request, id interface{}, params TaskSendParams) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeRPC(w, id, nil, &RPCError{Code: CodeUnsupportedOperation, Message: "streaming not supported"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	s.execute(r.Context(), params, func(event interface{}) {
+		result, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		data, err := json.Marshal(jsonRPCResponse{JSONRPC: "2.0", ID: id, Result: result})
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	})
+}
+
+func (s *Server) getTask(params TaskIDParams) (*Task, *RPCError) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, exists := s.tasks[params.ID]
+	if !exists {
+		return nil, &RPCError{Code: CodeTaskNotFound, Message: fmt.Sprintf("task not found: %s", params.ID)}
+	}
+
+	cp := *rec.task
+	if params.HistoryLength > 0 && len(cp.History) > params.HistoryLength {
+		cp.History = cp.History[len(cp.History)-params.HistoryLength:]
+	}
+	return &cp, nil
+}
+
+func (s *Server) cancelTask(id string) (*Task, *RPCError) {
+	s.mu.RLock()
+	rec, exists := s.tasks[id]
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, &RPCError{Code: CodeTaskNotFound, Message: fmt.Sprintf("task not found: %s", id)}
+	}
+
+	s.mu.RLock()
+	final := rec.task.Status.State.Final()
+	s.mu.RUnlock()
+	if final {
+		return nil, &RPCError{Code: CodeTaskNotCancelable, Message: fmt.Sprintf("task cannot be canceled: %s", id)}
+	}
+
+	rec.cancel()
+	s.setStatus(rec.task, TaskStateCanceled, nil, func(interface{}) {})
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cp := *rec.task
+	return &cp, nil
+}
+
+func writeRPC(w http.ResponseWriter, id interface{}, result interface{}, rpcErr *RPCError) {
+	resp := jsonRPCResponse{JSONRPC: "2.0", ID: id}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		} else {
+			resp.Result = data
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,191 @@
+package a2a
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AgentCard describes an A2A agent and is served at /.well-known/agent.json
+type AgentCard struct {
+	Name               string            `json:"name"`
+	Description        string            `json:"description,omitempty"`
+	URL                string            `json:"url"`
+	Version            string            `json:"version"`
+	Capabilities       AgentCapabilities `json:"capabilities"`
+	DefaultInputModes  []string          `json:"defaultInputModes"`
+	DefaultOutputModes []string          `json:"defaultOutputModes"`
+	Skills             []AgentSkill      `json:"skills"`
+}
+
+// AgentCapabilities lists optional protocol features supported by an agent
+type AgentCapabilities struct {
+	Streaming              bool `json:"streaming"`
+	PushNotifications      bool `json:"pushNotifications"`
+	StateTransitionHistory bool `json:"stateTransitionHistory"`
+}
+
+// AgentSkill describes a single ability of an agent
+type AgentSkill struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// TaskState is the lifecycle state of a task
+type TaskState string
+
+const (
+	TaskStateSubmitted     TaskState = "submitted"
+	TaskStateWorking       TaskState = "working"
+	TaskStateInputRequired TaskState = "input-required"
+	TaskStateCompleted     TaskState = "completed"
+	TaskStateCanceled      TaskState = "canceled"
+	TaskStateFailed        TaskState = "failed"
+	TaskStateUnknown       TaskState = "unknown"
+)
+
+// Final reports whether no further updates will follow this state
+func (s TaskState) Final() bool {
+	return s == TaskStateCompleted || s == TaskStateCanceled || s == TaskStateFailed
+}
+
+// Part is a piece of message or artifact content
+type Part struct {
+	Type     string                 `json:"type"`
+	Text     string                 `json:"text,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// TextPart creates a text content part
+func TextPart(text string) Part {
+	return Part{Type: "text", Text: text}
+}
+
+// Message is a single turn exchanged between a client and an agent
+type Message struct {
+	Role     string                 `json:"role"`
+	Parts    []Part                 `json:"parts"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Text concatenates the text parts of the message
+func (m Message) Text() string {
+	return partsText(m.Parts)
+}
+
+// Artifact is an output produced by a task
+type Artifact struct {
+	Name      string `json:"name,omitempty"`
+	Parts     []Part `json:"parts"`
+	Index     int    `json:"index"`
+	LastChunk bool   `json:"lastChunk,omitempty"`
+}
+
+// TaskStatus is the current status of a task
+type TaskStatus struct {
+	State     TaskState `json:"state"`
+	Message   *Message  `json:"message,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Task is a unit of work handled by an A2A agent
+type Task struct {
+	ID        string                 `json:"id"`
+	SessionID string                 `json:"sessionId,omitempty"`
+	Status    TaskStatus             `json:"status"`
+	Artifacts []Artifact             `json:"artifacts,omitempty"`
+	History   []Message              `json:"history,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Text concatenates the text of all artifacts of the task
+func (t *Task) Text() string {
+	texts := make([]string, 0, len(t.Artifacts))
+	for _, a := range t.Artifacts {
+		texts = append(texts, partsText(a.Parts))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// TaskSendParams are the parameters of tasks/send and tasks/sendSubscribe
+type TaskSendParams struct {
+	ID        string                 `json:"id"`
+	SessionID string                 `json:"sessionId,omitempty"`
+	Message   Message                `json:"message"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// TaskIDParams identify a task for tasks/get and tasks/cancel
+type TaskIDParams struct {
+	ID            string `json:"id"`
+	HistoryLength int    `json:"historyLength,omitempty"`
+}
+
+// TaskStatusUpdateEvent is streamed when a task changes state
+type TaskStatusUpdateEvent struct {
+	ID     string     `json:"id"`
+	Status TaskStatus `json:"status"`
+	Final  bool       `json:"final"`
+}
+
+// TaskArtifactUpdateEvent is streamed when a task produces an artifact
+type TaskArtifactUpdateEvent struct {
+	ID       string   `json:"id"`
+	Artifact Artifact `json:"artifact"`
+}
+
+// JSON-RPC methods defined by the A2A protocol
+const (
+	MethodSendTask          = "tasks/send"
+	MethodSendTaskSubscribe = "tasks/sendSubscribe"
+	MethodGetTask           = "tasks/get"
+	MethodCancelTask        = "tasks/cancel"
+)
+
+// jsonRPCRequest is a JSON-RPC 2.0 request
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// jsonRPCResponse is a JSON-RPC 2.0 response
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC error returned by an A2A agent
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string { return e.Message }
+
+// JSON-RPC and A2A error codes
+const (
+	CodeParseError           = -32700
+	CodeInvalidRequest       = -32600
+	CodeMethodNotFound       = -32601
+	CodeInvalidParams        = -32602
+	CodeInternalError        = -32603
+	CodeTaskNotFound         = -32001
+	CodeTaskNotCancelable    = -32002
+	CodeUnsupportedOperation = -32004
+)
+
+func partsText(parts []Part) string {
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p.Type == "text" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
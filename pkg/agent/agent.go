@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 
+	"github.com/user/modulox/pkg/llm"
 	"github.com/user/modulox/pkg/types"
 )
 
@@ -18,6 +19,33 @@ type Agent interface {
 	GetCapabilities() []types.Capability
 }
 
+// StreamingAgent is implemented by agents that can stream their output
+type StreamingAgent interface {
+	Agent
+
+	// ExecuteStream runs the agent and delivers the output incrementally
+	ExecuteStream(ctx context.Context, input string) (<-chan llm.Chunk, error)
+}
+
+// ChatAgent is implemented by agents that accept structured conversations
+type ChatAgent interface {
+	Agent
+
+	// Chat runs the agent on a conversation and returns its reply
+	Chat(ctx context.Context, messages []llm.Message) (llm.Message, error)
+}
+
+// MultimodalAgent is implemented by agents that accept image attachments
+type MultimodalAgent interface {
+	Agent
+
+	// ExecuteWithImages runs the agent on input and the attached images
+	ExecuteWithImages(ctx context.Context, input string, images []llm.Image) (string, error)
+}
+
+// EventChunk is the event type used to forward streamed output chunks
+const EventChunk = "agent_chunk"
+
 // BaseAgent provides a basic implementation of the Agent interface
 type BaseAgent struct {
 	tools        []types.Tool
@@ -2,12 +2,21 @@ package agent
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
 
+	"github.com/user/modulox/pkg/cache"
+	"github.com/user/modulox/pkg/communication"
 	"github.com/user/modulox/pkg/llm"
 	"github.com/user/modulox/pkg/memory"
+	"github.com/user/modulox/pkg/prompts"
+	"github.com/user/modulox/pkg/redact"
 	"github.com/user/modulox/pkg/tools"
 	"github.com/user/modulox/pkg/types"
 )
@@ -18,7 +27,37 @@ type BaseAgentConfig struct {
 	Description string
 	Provider    llm.Provider
 	Memory      memory.VectorStore
+	// Conversation supplies per-session chat history for contexts bound
+	// with memory.WithConversation
+	Conversation *memory.ConversationStore
 	Registry    *tools.ToolRegistry
+	Cache       *cache.SemanticCache
+	Events      *communication.EventSystem
+
+	// MaxToolIterations bounds model/tool round trips per request (default 5)
+	MaxToolIterations int
+
+	// Loop enables the ReAct-style agent loop for Execute
+	Loop *LoopConfig
+
+	// SystemPrompt is a text/template rendered with PromptData into the
+	// leading system message, e.g. "You are {{.Name}}. {{.Vars.tone}}"
+	SystemPrompt string
+	// Examples are few-shot exchanges placed ahead of the conversation
+	Examples []Example
+	// Variables are exposed to the system prompt template as .Vars
+	Variables map[string]interface{}
+	// PromptRef selects the system prompt from Prompts instead of
+	// SystemPrompt; it is rendered with the same PromptData
+	PromptRef *prompts.PromptRef
+	Prompts   *prompts.Registry
+
+	// Traces stores the ExecutionTrace of each run (default: the last 100
+	// runs in memory)
+	Traces TraceStore
+	// TraceRedactor masks sensitive data in traces before they are stored
+	// (default: redact.DefaultDetectors)
+	TraceRedactor *redact.Redactor
 }
 
 // BaseAgent provides a complete implementation of the Agent interface
@@ -28,55 +67,408 @@ type BaseAgent struct {
 	executor    *tools.SafeExecutor
 	memory      memory.VectorStore
 	provider    llm.Provider
+	prompt      *template.Template
+	promptErr   error
+	traces      TraceStore
+	redactor    *redact.Redactor
 	mu          sync.RWMutex
 }
 
 // NewBaseAgent creates a new base agent instance
 func NewBaseAgent(config BaseAgentConfig) *BaseAgent {
 	executor := tools.NewSafeExecutor(config.Registry)
+	// Template errors surface on the first request
+	prompt, promptErr := parsePrompt(config)
+	traces := config.Traces
+	if traces == nil {
+		traces = NewMemoryTraceStore(0)
+	}
+	redactor := config.TraceRedactor
+	if redactor == nil {
+		redactor = redact.NewRedactor()
+	}
 	return &BaseAgent{
-		config:   config,
-		tools:    config.Registry,
-		executor: executor,
-		memory:   config.Memory,
-		provider: config.Provider,
+		config:    config,
+		tools:     config.Registry,
+		executor:  executor,
+		memory:    config.Memory,
+		provider:  config.Provider,
+		prompt:    prompt,
+		promptErr: promptErr,
+		traces:    traces,
+		redactor:  redactor,
 	}
 }
 
 // Execute implements Agent.Execute
-func (b *BaseAgent) Execute(ctx context.Context, input string) (string, error) {
+func (b *BaseAgent) Execute(ctx context.Context, input string) (output string, err error) {
+	ctx, finish := b.startRun(ctx, input)
+	defer func() { finish(output, err) }()
+
+	if b.config.Loop != nil {
+		result, err := b.RunLoop(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		return result.Output, nil
+	}
+
+	reply, err := b.Chat(ctx, []llm.Message{{Role: llm.RoleUser, Content: input}})
+	if err != nil {
+		return "", err
+	}
+	return reply.Content, nil
+}
+
+// ExecuteWithImages implements MultimodalAgent.ExecuteWithImages. Providers
+// without vision support receive a placeholder for each image.
+func (b *BaseAgent) ExecuteWithImages(ctx context.Context, input string, images []llm.Image) (string, error) {
+	reply, err := b.Chat(ctx, []llm.Message{{Role: llm.RoleUser, Content: input, Images: images}})
+	if err != nil {
+		return "", err
+	}
+	return reply.Content, nil
+}
+
+// Chat implements ChatAgent.Chat. Memory context is supplied as a system
+// message ahead of the caller's conversation.
+func (b *BaseAgent) Chat(ctx context.Context, history []llm.Message) (reply llm.Message, err error) {
+	ctx = llm.WithUsageScope(ctx, llm.UsageScope{Agent: b.config.Name})
+	input := llm.LastUserMessage(history)
+	ctx, finish := b.startRun(ctx, input)
+	defer func() { finish(reply.Content, err) }()
+
+	messages, key, cached, hit, err := b.prepare(ctx, input, history)
+	if err != nil {
+		return llm.Message{}, err
+	}
+	if hit {
+		b.rememberTurn(ctx, input, cached)
+		return llm.Message{Role: llm.RoleAssistant, Content: cached}, nil
+	}
+
+	// Generate completion with context, letting the model call tools when supported
+	reply, err = b.complete(ctx, messages)
+	if err != nil {
+		return llm.Message{}, fmt.Errorf("failed to generate completion: %w", err)
+	}
+
+	b.remember(ctx, input, key, reply.Content)
+	return reply, nil
+}
+
+// ExecuteStream implements StreamingAgent.ExecuteStream. Chunks are also
+// forwarded to the configured EventSystem as EventChunk events.
+func (b *BaseAgent) ExecuteStream(ctx context.Context, input string) (<-chan llm.Chunk, error) {
+	ctx = llm.WithUsageScope(ctx, llm.UsageScope{Agent: b.config.Name})
+	ctx, finish := b.startRun(ctx, input)
+	messages, key, cached, hit, err := b.prepare(ctx, input, []llm.Message{{Role: llm.RoleUser, Content: input}})
+	if err != nil {
+		finish("", err)
+		return nil, err
+	}
+	prompt := llm.FormatPrompt(messages)
+
+	// The upstream is cancelled once the stream ends early, so providers
+	// stop generating for a reader that has gone away
+	upstreamCtx, cancel := context.WithCancel(ctx)
+	var upstream <-chan llm.Chunk
+	if hit {
+		upstream, err = llm.Stream(upstreamCtx, cachedProvider(cached), prompt)
+	} else {
+		upstream, err = llm.Stream(upstreamCtx, b.provider, prompt)
+	}
+	if err != nil {
+		cancel()
+		err = fmt.Errorf("failed to generate completion: %w", err)
+		finish("", err)
+		return nil, err
+	}
+
+	chunks := make(chan llm.Chunk, 16)
+	go func() {
+		defer close(chunks)
+
+		var completion strings.Builder
+		var streamErr error
+		defer func() { finish(completion.String(), streamErr) }()
+		defer func() {
+			cancel()
+			// Unblock a provider still sending after cancellation
+			go func() {
+				for range upstream {
+				}
+			}()
+		}()
+		for chunk := range upstream {
+			completion.WriteString(chunk.Content)
+			b.emitChunk(ctx, chunk)
+
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				streamErr = ctx.Err()
+				return
+			}
+
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+				return
+			}
+			if chunk.Done {
+				break
+			}
+		}
+
+		if hit {
+			b.rememberTurn(ctx, input, completion.String())
+		} else {
+			b.remember(ctx, input, key, completion.String())
+		}
+	}()
+
+	return chunks, nil
+}
+
+// complete generates the reply, executing tool calls requested by providers
+// with native function calling and feeding the results back to the model
+func (b *BaseAgent) complete(ctx context.Context, messages []llm.Message) (llm.Message, error) {
+	tp, ok := b.provider.(llm.ToolCallingProvider)
+	if !ok || b.tools == nil {
+		return b.chat(ctx, messages)
+	}
+
+	definitions := b.tools.ToolDefinitions()
+	if len(definitions) == 0 {
+		return b.chat(ctx, messages)
+	}
+
+	maxIterations := b.config.MaxToolIterations
+	if maxIterations <= 0 {
+		maxIterations = 5
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		started := time.Now()
+		reply, err := tp.ChatWithTools(ctx, messages, definitions)
+		recordLLMCall(ctx, started, reply, err)
+		if err != nil {
+			return llm.Message{}, err
+		}
+		if len(reply.ToolCalls) == 0 {
+			return reply, nil
+		}
+
+		messages = append(messages, reply)
+		for _, call := range reply.ToolCalls {
+			messages = append(messages, llm.Message{
+				Role:       llm.RoleTool,
+				Name:       call.Name,
+				ToolCallID: call.ID,
+				Content:    b.callTool(ctx, call),
+			})
+		}
+	}
+
+	return llm.Message{}, fmt.Errorf("tool calling did not finish within %d iterations", maxIterations)
+}
+
+// chat sends the conversation to the provider, recording the call in the run trace
+func (b *BaseAgent) chat(ctx context.Context, messages []llm.Message) (llm.Message, error) {
+	started := time.Now()
+	reply, err := llm.Chat(ctx, b.provider, messages)
+	recordLLMCall(ctx, started, reply, err)
+	return reply, err
+}
+
+// callTool executes a tool call, recording it in the run trace
+func (b *BaseAgent) callTool(ctx context.Context, call llm.ToolCall) string {
+	started := time.Now()
+	result := b.invokeTool(ctx, call)
+	recordToolCall(ctx, started, call, result)
+	return result
+}
+
+// invokeTool executes a tool call and renders its result or error for the model
+func (b *BaseAgent) invokeTool(ctx context.Context, call llm.ToolCall) string {
+	args, err := b.tools.DecodeArguments(call.Name, call.Arguments)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err)
+	}
+
+	result, err := b.executor.Execute(ctx, call.Name, args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err)
+	}
+
+	if str, ok := result.(string); ok {
+		return str
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Sprintf("%v", result)
+	}
+	return string(data)
+}
+
+// prepare embeds the input, consults the cache, and builds the conversation
+// sent to the provider with memory context as a leading system message
+func (b *BaseAgent) prepare(ctx context.Context, input string, history []llm.Message) (messages []llm.Message, key cacheKey, cached string, hit bool, err error) {
 	// First, check memory for relevant context
-	embedding, err := b.provider.Embed(ctx, input)
+	key.embedding, err = b.provider.Embed(ctx, input)
+	if err != nil {
+		return nil, cacheKey{}, "", false, fmt.Errorf("failed to create embedding: %w", err)
+	}
+	// Recall earlier turns of the conversation bound to the context
+	var earlier []llm.Message
+	if sessionID, ok := b.conversation(ctx); ok {
+		earlier, err = b.config.Conversation.Window(ctx, sessionID)
+		if err != nil {
+			return nil, cacheKey{}, "", false, fmt.Errorf("failed to load conversation: %w", err)
+		}
+	}
+	key.scope = b.cacheScope(ctx, earlier, history)
+
+	// Short-circuit repeated questions from the semantic cache
+	if b.config.Cache != nil {
+		if cached, ok := b.config.Cache.Lookup(ctx, b.config.Name, key.scope, key.embedding); ok {
+			recordPrompt(ctx, nil, nil, true)
+			return nil, key, cached, true, nil
+		}
+	}
+
+	vectors, err := b.memory.Query(ctx, types.Vector{Values: key.embedding}, 5)
 	if err != nil {
-		return "", fmt.Errorf("failed to create embedding: %w", err)
+		return nil, cacheKey{}, "", false, fmt.Errorf("failed to query memory: %w", err)
 	}
 
-	vectors, err := b.memory.Query(ctx, types.Vector{Values: embedding}, 5)
+	// Lead with the configured system prompt and few-shot examples
+	messages, err = b.promptMessages(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to query memory: %w", err)
+		return nil, cacheKey{}, "", false, err
 	}
 
 	// Build context from memory
-	context := buildContext(vectors)
+	if context := buildContext(vectors); context != "" {
+		messages = append(messages, llm.Message{
+			Role:    llm.RoleSystem,
+			Content: fmt.Sprintf("Context:\n%s", context),
+		})
+	}
+	messages = append(messages, earlier...)
+	messages = append(messages, history...)
+	recordPrompt(ctx, messages, vectors, false)
+	return messages, key, "", false, nil
+}
 
-	// Generate completion with context
-	prompt := fmt.Sprintf("Context:\n%s\n\nInput: %s", context, input)
-	completion, err := b.provider.Complete(ctx, prompt)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate completion: %w", err)
+// cacheKey identifies a request in the semantic cache: the embedding of its
+// input, and a digest of what else shapes the answer
+type cacheKey struct {
+	embedding []float32
+	scope     string
+}
+
+// cacheScope digests the parts of a request besides the input text that
+// shape the answer: the system prompt template and its variables, the
+// few-shot examples, the conversation so far, and attached images. Cached
+// answers are only reused for requests that agree on all of them.
+func (b *BaseAgent) cacheScope(ctx context.Context, earlier, history []llm.Message) string {
+	h := sha256.New()
+	// fmt prints maps sorted by key, so equal variables digest alike
+	fmt.Fprintf(h, "%q %+v %q %v\n", b.config.SystemPrompt, b.config.PromptRef, b.config.Examples, b.promptVars(ctx))
+
+	// The final user message is matched by its embedding instead
+	last := -1
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == llm.RoleUser {
+			last = len(earlier) + i
+			break
+		}
+	}
+	for i, m := range append(append([]llm.Message(nil), earlier...), history...) {
+		if i != last {
+			fmt.Fprintf(h, "%s %q %q %q %+v\n", m.Role, m.Name, m.Content, m.ToolCallID, m.ToolCalls)
+		}
+		for _, img := range m.Images {
+			digest := sha256.Sum256([]byte(img.DataURL()))
+			h.Write(digest[:])
+		}
 	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// remember stores the interaction in memory, the conversation history, and the semantic cache
+func (b *BaseAgent) remember(ctx context.Context, input string, key cacheKey, completion string) {
+	b.rememberTurn(ctx, input, completion)
 
-	// Store the interaction in memory
 	b.memory.Store(ctx, []types.Vector{{
 		ID:     fmt.Sprintf("interaction_%d", time.Now().UnixNano()),
-		Values: embedding,
+		Values: key.embedding,
 		Metadata: map[string]interface{}{
 			"input":  input,
 			"output": completion,
 		},
 	}})
 
-	return completion, nil
+	if b.config.Cache != nil {
+		b.config.Cache.Store(ctx, b.config.Name, key.scope, input, key.embedding, completion)
+	}
+}
+
+// rememberTurn appends the exchange to the conversation history. Cached
+// answers are remembered too, so later turns see what the user was told.
+func (b *BaseAgent) rememberTurn(ctx context.Context, input, completion string) {
+	if sessionID, ok := b.conversation(ctx); ok {
+		b.config.Conversation.Append(ctx, sessionID,
+			llm.Message{Role: llm.RoleUser, Content: input},
+			llm.Message{Role: llm.RoleAssistant, Content: completion},
+		)
+	}
+}
+
+// conversation returns the session whose history the agent should use
+func (b *BaseAgent) conversation(ctx context.Context) (string, bool) {
+	if b.config.Conversation == nil {
+		return "", false
+	}
+	return memory.ConversationFromContext(ctx)
+}
+
+// emitChunk forwards a streamed chunk over the event system
+func (b *BaseAgent) emitChunk(ctx context.Context, chunk llm.Chunk) {
+	if b.config.Events == nil {
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"agent_id": b.config.Name,
+		"done":     chunk.Done,
+	}
+	if chunk.Err != nil {
+		metadata["error"] = chunk.Err.Error()
+	}
+
+	b.config.Events.EmitEvent(ctx, communication.Event{
+		Type:     EventChunk,
+		Payload:  chunk.Content,
+		Metadata: metadata,
+	})
+}
+
+// cachedProvider replays a cached answer through llm.Stream
+type cachedProvider string
+
+func (c cachedProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return string(c), nil
+}
+
+func (c cachedProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
+
+// GetName returns the agent's configured name
+func (b *BaseAgent) GetName() string {
+	return b.config.Name
 }
 
 // AddTool implements Agent.AddTool
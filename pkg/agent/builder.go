@@ -0,0 +1,285 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/user/modulox/pkg/cache"
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/memory"
+	"github.com/user/modulox/pkg/prompts"
+	"github.com/user/modulox/pkg/redact"
+	"github.com/user/modulox/pkg/tools"
+	"github.com/user/modulox/pkg/types"
+)
+
+// Option configures an agent built by New or NewAgent
+type Option func(*Builder)
+
+// namedTool is a tool added under an explicit registry name
+type namedTool struct {
+	name string
+	tool types.Tool
+}
+
+// Builder assembles a BaseAgent, filling in defaults for the components
+// left unset:
+//
+//	a, err := agent.New().
+//		WithProvider(p).
+//		WithTools(search, calculator).
+//		WithSystemPrompt("You are {{.Name}}.").
+//		Build()
+type Builder struct {
+	config BaseAgentConfig
+	tools  []namedTool
+}
+
+// New creates an agent builder with the given options applied
+func New(options ...Option) *Builder {
+	b := &Builder{}
+	return b.With(options...)
+}
+
+// NewAgent builds an agent from options in one call
+func NewAgent(options ...Option) (*BaseAgent, error) {
+	return New(options...).Build()
+}
+
+// With applies options to the builder
+func (b *Builder) With(options ...Option) *Builder {
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// WithName sets the agent's name
+func WithName(name string) Option {
+	return func(b *Builder) { b.config.Name = name }
+}
+
+// WithDescription sets the agent's description
+func WithDescription(description string) Option {
+	return func(b *Builder) { b.config.Description = description }
+}
+
+// WithProvider sets the LLM provider; it is the only required option
+func WithProvider(provider llm.Provider) Option {
+	return func(b *Builder) { b.config.Provider = provider }
+}
+
+// WithMemory sets the vector store (default: an in-memory store)
+func WithMemory(store memory.VectorStore) Option {
+	return func(b *Builder) { b.config.Memory = store }
+}
+
+// WithConversation sets the per-session chat history store
+func WithConversation(conversation *memory.ConversationStore) Option {
+	return func(b *Builder) { b.config.Conversation = conversation }
+}
+
+// WithRegistry sets the tool registry (default: an empty registry)
+func WithRegistry(registry *tools.ToolRegistry) Option {
+	return func(b *Builder) { b.config.Registry = registry }
+}
+
+// WithTools adds tools the way Agent.AddTool does, named by their
+// descriptions
+func WithTools(list ...types.Tool) Option {
+	return func(b *Builder) {
+		for _, tool := range list {
+			b.tools = append(b.tools, namedTool{name: tool.GetDescription(), tool: tool})
+		}
+	}
+}
+
+// WithTool adds a tool under the given name
+func WithTool(name string, tool types.Tool) Option {
+	return func(b *Builder) { b.tools = append(b.tools, namedTool{name: name, tool: tool}) }
+}
+
+// WithCache sets the semantic cache consulted before the provider
+func WithCache(c *cache.SemanticCache) Option {
+	return func(b *Builder) { b.config.Cache = c }
+}
+
+// WithEvents sets the event system streamed chunks are forwarded to
+func WithEvents(events *communication.EventSystem) Option {
+	return func(b *Builder) { b.config.Events = events }
+}
+
+// WithMaxToolIterations bounds model/tool round trips per request
+func WithMaxToolIterations(n int) Option {
+	return func(b *Builder) { b.config.MaxToolIterations = n }
+}
+
+// WithLoop enables the ReAct-style agent loop for Execute
+func WithLoop(loop LoopConfig) Option {
+	return func(b *Builder) { b.config.Loop = &loop }
+}
+
+// WithSystemPrompt sets the system prompt template
+func WithSystemPrompt(prompt string) Option {
+	return func(b *Builder) { b.config.SystemPrompt = prompt }
+}
+
+// WithExamples adds few-shot exchanges
+func WithExamples(examples ...Example) Option {
+	return func(b *Builder) { b.config.Examples = append(b.config.Examples, examples...) }
+}
+
+// WithVariables adds variables exposed to the system prompt as .Vars
+func WithVariables(vars map[string]interface{}) Option {
+	return func(b *Builder) {
+		if b.config.Variables == nil {
+			b.config.Variables = make(map[string]interface{}, len(vars))
+		}
+		for k, v := range vars {
+			b.config.Variables[k] = v
+		}
+	}
+}
+
+// WithPrompt selects the system prompt from a prompt registry
+func WithPrompt(registry *prompts.Registry, ref prompts.PromptRef) Option {
+	return func(b *Builder) {
+		b.config.Prompts = registry
+		b.config.PromptRef = &ref
+	}
+}
+
+// WithTraces sets the store for execution traces
+func WithTraces(store TraceStore) Option {
+	return func(b *Builder) { b.config.Traces = store }
+}
+
+// WithTraceRedactor sets the redactor masking sensitive data in traces
+func WithTraceRedactor(r *redact.Redactor) Option {
+	return func(b *Builder) { b.config.TraceRedactor = r }
+}
+
+// WithProvider sets the LLM provider
+func (b *Builder) WithProvider(provider llm.Provider) *Builder {
+	return b.With(WithProvider(provider))
+}
+
+// WithName sets the agent's name
+func (b *Builder) WithName(name string) *Builder {
+	return b.With(WithName(name))
+}
+
+// WithDescription sets the agent's description
+func (b *Builder) WithDescription(description string) *Builder {
+	return b.With(WithDescription(description))
+}
+
+// WithMemory sets the vector store
+func (b *Builder) WithMemory(store memory.VectorStore) *Builder {
+	return b.With(WithMemory(store))
+}
+
+// WithConversation sets the per-session chat history store
+func (b *Builder) WithConversation(conversation *memory.ConversationStore) *Builder {
+	return b.With(WithConversation(conversation))
+}
+
+// WithRegistry sets the tool registry
+func (b *Builder) WithRegistry(registry *tools.ToolRegistry) *Builder {
+	return b.With(WithRegistry(registry))
+}
+
+// WithTools adds tools named by their descriptions
+func (b *Builder) WithTools(list ...types.Tool) *Builder {
+	return b.With(WithTools(list...))
+}
+
+// WithTool adds a tool under the given name
+func (b *Builder) WithTool(name string, tool types.Tool) *Builder {
+	return b.With(WithTool(name, tool))
+}
+
+// WithCache sets the semantic cache
+func (b *Builder) WithCache(c *cache.SemanticCache) *Builder {
+	return b.With(WithCache(c))
+}
+
+// WithEvents sets the event system
+func (b *Builder) WithEvents(events *communication.EventSystem) *Builder {
+	return b.With(WithEvents(events))
+}
+
+// WithMaxToolIterations bounds model/tool round trips per request
+func (b *Builder) WithMaxToolIterations(n int) *Builder {
+	return b.With(WithMaxToolIterations(n))
+}
+
+// WithLoop enables the ReAct-style agent loop
+func (b *Builder) WithLoop(loop LoopConfig) *Builder {
+	return b.With(WithLoop(loop))
+}
+
+// WithSystemPrompt sets the system prompt template
+func (b *Builder) WithSystemPrompt(prompt string) *Builder {
+	return b.With(WithSystemPrompt(prompt))
+}
+
+// WithExamples adds few-shot exchanges
+func (b *Builder) WithExamples(examples ...Example) *Builder {
+	return b.With(WithExamples(examples...))
+}
+
+// WithVariables adds system prompt variables
+func (b *Builder) WithVariables(vars map[string]interface{}) *Builder {
+	return b.With(WithVariables(vars))
+}
+
+// WithPrompt selects the system prompt from a prompt registry
+func (b *Builder) WithPrompt(registry *prompts.Registry, ref prompts.PromptRef) *Builder {
+	return b.With(WithPrompt(registry, ref))
+}
+
+// WithTraces sets the store for execution traces
+func (b *Builder) WithTraces(store TraceStore) *Builder {
+	return b.With(WithTraces(store))
+}
+
+// WithTraceRedactor sets the trace redactor
+func (b *Builder) WithTraceRedactor(r *redact.Redactor) *Builder {
+	return b.With(WithTraceRedactor(r))
+}
+
+// Build validates the configuration and creates the agent. Unlike
+// NewBaseAgent, it reports system prompt template errors immediately
+// rather than on the first request.
+func (b *Builder) Build() (*BaseAgent, error) {
+	config := b.config
+	if config.Provider == nil {
+		return nil, fmt.Errorf("agent provider is required")
+	}
+	if config.MaxToolIterations < 0 {
+		return nil, fmt.Errorf("max tool iterations must not be negative")
+	}
+	if config.PromptRef != nil && config.Prompts == nil {
+		return nil, fmt.Errorf("prompt reference %s requires a prompt registry", config.PromptRef.Name)
+	}
+	if config.Name == "" {
+		config.Name = "modulox-agent"
+	}
+	if config.Memory == nil {
+		config.Memory = memory.NewBaseStore()
+	}
+	if config.Registry == nil {
+		config.Registry = tools.NewToolRegistry()
+	}
+	if _, err := parsePrompt(config); err != nil {
+		return nil, err
+	}
+
+	for _, t := range b.tools {
+		if err := config.Registry.RegisterTool(t.name, t.tool, nil); err != nil {
+			return nil, fmt.Errorf("failed to add tool: %w", err)
+		}
+	}
+	return NewBaseAgent(config), nil
+}
@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/user/modulox/pkg/llm"
+)
+
+// LoopConfig enables the ReAct-style reason→act→observe loop
+type LoopConfig struct {
+	// MaxIterations bounds the number of reasoning cycles (default 10)
+	MaxIterations int
+	// StopOnRepeat ends the loop when the model repeats the same action and input
+	StopOnRepeat bool
+}
+
+// Step is a single reason→act→observe cycle
+type Step struct {
+	Thought     string `json:"thought"`
+	Action      string `json:"action,omitempty"`
+	ActionInput string `json:"action_input,omitempty"`
+	Observation string `json:"observation,omitempty"`
+}
+
+// Result is the outcome of an agent loop run
+type Result struct {
+	Output   string
+	Steps    []Step
+	Metadata map[string]interface{}
+}
+
+// Loop termination reasons reported in Result.Metadata["termination"]
+const (
+	TerminationFinalAnswer   = "final_answer"
+	TerminationMaxIterations = "max_iterations"
+	TerminationRepeated      = "repeated_action"
+)
+
+var (
+	thoughtPattern     = regexp.MustCompile(`(?s)Thought:\s*(.*?)(?:\n(?:Action|Final Answer):|$)`)
+	actionPattern      = regexp.MustCompile(`Action:\s*(.+)`)
+	actionInputPattern = regexp.MustCompile(`(?s)Action Input:\s*(.*?)(?:\nObservation:|$)`)
+	finalAnswerPattern = regexp.MustCompile(`(?s)Final Answer:\s*(.*)`)
+)
+
+// RunLoop runs the agent in ReAct mode, returning the final answer along
+// with the transcript of intermediate steps
+func (b *BaseAgent) RunLoop(ctx context.Context, input string) (result *Result, err error) {
+	// Agents built with NewBaseAgent may lack the registry the Builder adds
+	if b.tools == nil {
+		return nil, fmt.Errorf("ReAct loop requires a tool registry")
+	}
+	ctx = llm.WithUsageScope(ctx, llm.UsageScope{Agent: b.config.Name})
+	ctx, finish := b.startRun(ctx, input)
+	defer func() {
+		output := ""
+		if result != nil {
+			output = result.Output
+		}
+		finish(output, err)
+	}()
+	config := LoopConfig{}
+	if b.config.Loop != nil {
+		config = *b.config.Loop
+	}
+	if config.MaxIterations <= 0 {
+		config.MaxIterations = 10
+	}
+
+	messages, key, cached, hit, err := b.prepare(ctx, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	if hit {
+		b.rememberTurn(ctx, input, cached)
+		return &Result{
+			Output:   cached,
+			Metadata: map[string]interface{}{"cached": true},
+		}, nil
+	}
+
+	messages = append(messages,
+		llm.Message{Role: llm.RoleSystem, Content: b.reactInstructions()},
+		llm.Message{Role: llm.RoleUser, Content: fmt.Sprintf("Question: %s", input)},
+	)
+	// The trace shows the prompt as sent, with the ReAct instructions
+	recordPrompt(ctx, messages, nil, false)
+
+	result = &Result{Metadata: make(map[string]interface{})}
+	for i := 0; i < config.MaxIterations; i++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		reply, err := b.chat(ctx, messages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate completion: %w", err)
+		}
+
+		// Discard anything the model invented after the action
+		text := reply.Content
+		if idx := strings.Index(text, "\nObservation:"); idx >= 0 {
+			text = text[:idx]
+		}
+
+		step := parseStep(text)
+		if m := finalAnswerPattern.FindStringSubmatch(text); m != nil && step.Action == "" {
+			result.Steps = append(result.Steps, step)
+			result.Output = strings.TrimSpace(m[1])
+			return b.finishLoop(ctx, input, key, result, TerminationFinalAnswer, i+1), nil
+		}
+
+		if step.Action == "" {
+			// Treat a reply without an action or final answer as the answer
+			result.Steps = append(result.Steps, step)
+			result.Output = strings.TrimSpace(text)
+			return b.finishLoop(ctx, input, key, result, TerminationFinalAnswer, i+1), nil
+		}
+
+		if config.StopOnRepeat && len(result.Steps) > 0 {
+			last := result.Steps[len(result.Steps)-1]
+			if last.Action == step.Action && last.ActionInput == step.ActionInput {
+				result.Output = last.Observation
+				return b.finishLoop(ctx, input, key, result, TerminationRepeated, i+1), nil
+			}
+		}
+
+		step.Observation = b.callTool(ctx, llm.ToolCall{
+			Name:      step.Action,
+			Arguments: toolArguments(step.ActionInput),
+		})
+		result.Steps = append(result.Steps, step)
+
+		messages = append(messages,
+			llm.Message{Role: llm.RoleAssistant, Content: text},
+			llm.Message{Role: llm.RoleUser, Content: fmt.Sprintf("Observation: %s", step.Observation)},
+		)
+	}
+
+	if len(result.Steps) > 0 {
+		result.Output = result.Steps[len(result.Steps)-1].Observation
+	}
+	return b.finishLoop(ctx, input, key, result, TerminationMaxIterations, config.MaxIterations), nil
+}
+
+// finishLoop records metadata and remembers the interaction
+func (b *BaseAgent) finishLoop(ctx context.Context, input string, key cacheKey, result *Result, termination string, iterations int) *Result {
+	result.Metadata["steps"] = result.Steps
+	result.Metadata["iterations"] = iterations
+	result.Metadata["termination"] = termination
+
+	b.remember(ctx, input, key, result.Output)
+	return result
+}
+
+// reactInstructions describes the available tools and the expected format
+func (b *BaseAgent) reactInstructions() string {
+	var tools strings.Builder
+	for _, def := range b.tools.ToolDefinitions() {
+		tools.WriteString(fmt.Sprintf("- %s: %s\n", def.Name, def.Description))
+	}
+
+	return fmt.Sprintf(`Answer the question as best you can. You have access to the following tools:
+%s
+Use the following format:
+
+Thought: reason about what to do next
+Action: the tool to use
+Action Input: the input to the tool
+Observation: the result of the tool (provided to you)
+... (Thought/Action/Action Input/Observation can repeat)
+Thought: I now know the final answer
+Final Answer: the final answer to the question`, tools.String())
+}
+
+// parseStep extracts the thought and action from a model reply
+func parseStep(text string) Step {
+	var step Step
+	if m := thoughtPattern.FindStringSubmatch(text); m != nil {
+		step.Thought = strings.TrimSpace(m[1])
+	}
+	if m := actionPattern.FindStringSubmatch(text); m != nil {
+		step.Action = strings.TrimSpace(m[1])
+	}
+	if m := actionInputPattern.FindStringSubmatch(text); m != nil {
+		step.ActionInput = strings.TrimSpace(m[1])
+	}
+	return step
+}
+
+// toolArguments wraps a free-form action input as tool call arguments,
+// passing JSON objects through unchanged
+func toolArguments(input string) string {
+	if strings.HasPrefix(input, "{") {
+		return input
+	}
+	data, _ := json.Marshal(map[string]string{"input": input})
+	return string(data)
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/modulox/pkg/observability"
+	"github.com/user/modulox/pkg/reliability"
+	"github.com/user/modulox/pkg/types"
+)
+
+// AgentFunc is the signature of Agent.Execute
+type AgentFunc func(ctx context.Context, input string) (string, error)
+
+// Middleware wraps an AgentFunc with additional behavior
+type Middleware func(next AgentFunc) AgentFunc
+
+// MiddlewareAgent is an agent whose Execute runs through a middleware chain
+type MiddlewareAgent struct {
+	inner   Agent
+	execute AgentFunc
+}
+
+// Wrap returns an agent that runs a's Execute through the middleware. The
+// first middleware is the outermost, seeing the input first and the output
+// last.
+func Wrap(a Agent, middleware ...Middleware) *MiddlewareAgent {
+	execute := AgentFunc(a.Execute)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		execute = middleware[i](execute)
+	}
+	return &MiddlewareAgent{
+		inner:   a,
+		execute: execute,
+	}
+}
+
+// Execute implements Agent.Execute
+func (m *MiddlewareAgent) Execute(ctx context.Context, input string) (string, error) {
+	return m.execute(ctx, input)
+}
+
+// AddTool implements Agent.AddTool
+func (m *MiddlewareAgent) AddTool(tool types.Tool) error {
+	return m.inner.AddTool(tool)
+}
+
+// GetCapabilities implements Agent.GetCapabilities
+func (m *MiddlewareAgent) GetCapabilities() []types.Capability {
+	return m.inner.GetCapabilities()
+}
+
+// GetName returns the wrapped agent's name when it has one
+func (m *MiddlewareAgent) GetName() string {
+	if named, ok := m.inner.(interface{ GetName() string }); ok {
+		return named.GetName()
+	}
+	return ""
+}
+
+// Unwrap returns the wrapped agent
+func (m *MiddlewareAgent) Unwrap() Agent {
+	return m.inner
+}
+
+// LoggingMiddleware logs each execution with its duration and outcome
+func LoggingMiddleware(logger *observability.Logger, name string) Middleware {
+	return func(next AgentFunc) AgentFunc {
+		return func(ctx context.Context, input string) (string, error) {
+			start := time.Now()
+			output, err := next(ctx, input)
+
+			fields := map[string]interface{}{
+				"agent":        name,
+				"duration_ms":  time.Since(start).Milliseconds(),
+				"input_length": len(input),
+			}
+			if err != nil {
+				fields["error"] = err.Error()
+				logger.Error(ctx, "agent execution failed", fields)
+			} else {
+				fields["output_length"] = len(output)
+				logger.Info(ctx, "agent execution completed", fields)
+			}
+			return output, err
+		}
+	}
+}
+
+// TracingMiddleware records each execution as a span
+func TracingMiddleware(tracer *observability.Tracer, name string) Middleware {
+	return func(next AgentFunc) AgentFunc {
+		return func(ctx context.Context, input string) (string, error) {
+			span, ctx := tracer.StartSpan(ctx, "agent.execute",
+				observability.WithTags(map[string]string{"agent": name}))
+			defer tracer.EndSpan(span)
+
+			output, err := next(ctx, input)
+			if err != nil {
+				tracer.SetError(span, err)
+			}
+			return output, err
+		}
+	}
+}
+
+// RateLimitMiddleware waits for a token from limiter before each execution
+func RateLimitMiddleware(limiter reliability.Limiter) Middleware {
+	return func(next AgentFunc) AgentFunc {
+		return func(ctx context.Context, input string) (string, error) {
+			if err := limiter.WaitN(ctx, 1); err != nil {
+				return "", err
+			}
+			return next(ctx, input)
+		}
+	}
+}
+
+// PolicyMiddleware runs each execution under policy, e.g. to retry failed
+// executions and bound their concurrency
+func PolicyMiddleware(policy *reliability.Policy) Middleware {
+	return func(next AgentFunc) AgentFunc {
+		return func(ctx context.Context, input string) (string, error) {
+			var output string
+			err := policy.Execute(ctx, func(ctx context.Context) error {
+				var err error
+				output, err = next(ctx, input)
+				return err
+			})
+			return output, err
+		}
+	}
+}
+
+// RewriteMiddleware rewrites the input before it reaches the agent
+func RewriteMiddleware(rewrite func(ctx context.Context, input string) (string, error)) Middleware {
+	return func(next AgentFunc) AgentFunc {
+		return func(ctx context.Context, input string) (string, error) {
+			rewritten, err := rewrite(ctx, input)
+			if err != nil {
+				return "", err
+			}
+			return next(ctx, rewritten)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/user/modulox/pkg/llm"
+)
+
+// Example is a few-shot exchange shown to the model ahead of the conversation
+type Example struct {
+	Input  string
+	Output string
+}
+
+// PromptData is the data available to the system prompt template
+type PromptData struct {
+	Name        string
+	Description string
+	Input       string
+	Now         time.Time
+	// Vars merges BaseAgentConfig.Variables with variables attached to the
+	// request context, the latter taking precedence
+	Vars map[string]interface{}
+}
+
+type promptVarsKey struct{}
+
+// WithPromptVariables attaches per-request template variables to the context
+func WithPromptVariables(ctx context.Context, vars map[string]interface{}) context.Context {
+	merged := make(map[string]interface{})
+	if existing, ok := ctx.Value(promptVarsKey{}).(map[string]interface{}); ok {
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, promptVarsKey{}, merged)
+}
+
+// parsePrompt parses the configured system prompt template
+func parsePrompt(config BaseAgentConfig) (*template.Template, error) {
+	if config.SystemPrompt == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(config.Name).Option("missingkey=zero").Parse(config.SystemPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid system prompt template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderSystemPrompt renders the system prompt template for an input
+func (b *BaseAgent) RenderSystemPrompt(ctx context.Context, input string) (string, error) {
+	if b.promptErr != nil {
+		return "", b.promptErr
+	}
+	if b.prompt == nil && b.config.PromptRef == nil {
+		return "", nil
+	}
+
+	data := PromptData{
+		Name:        b.config.Name,
+		Description: b.config.Description,
+		Input:       input,
+		Now:         time.Now(),
+		Vars:        b.promptVars(ctx),
+	}
+
+	if b.config.PromptRef != nil {
+		if b.config.Prompts == nil {
+			return "", fmt.Errorf("prompt %s referenced without a prompt registry", b.config.PromptRef.Name)
+		}
+		system, _, err := b.config.Prompts.Render(ctx, *b.config.PromptRef, data)
+		if err != nil {
+			return "", fmt.Errorf("failed to render system prompt: %w", err)
+		}
+		return system, nil
+	}
+
+	var out strings.Builder
+	if err := b.prompt.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render system prompt: %w", err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// promptVars merges the configured variables with those of the request
+func (b *BaseAgent) promptVars(ctx context.Context) map[string]interface{} {
+	vars := make(map[string]interface{})
+	for k, v := range b.config.Variables {
+		vars[k] = v
+	}
+	if requested, ok := ctx.Value(promptVarsKey{}).(map[string]interface{}); ok {
+		for k, v := range requested {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// promptMessages returns the system prompt and few-shot examples that lead
+// every conversation
+func (b *BaseAgent) promptMessages(ctx context.Context, input string) ([]llm.Message, error) {
+	system, err := b.RenderSystemPrompt(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]llm.Message, 0, 1+2*len(b.config.Examples))
+	if system != "" {
+		messages = append(messages, llm.Message{Role: llm.RoleSystem, Content: system})
+	}
+	for _, example := range b.config.Examples {
+		messages = append(messages,
+			llm.Message{Role: llm.RoleUser, Content: example.Input},
+			llm.Message{Role: llm.RoleAssistant, Content: example.Output},
+		)
+	}
+	return messages, nil
+}
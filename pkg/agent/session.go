@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/memory"
+)
+
+// SessionConfig contains configuration for an agent session
+type SessionConfig struct {
+	// Conversation stores the session history and decides how much of it is
+	// replayed each turn; its Persistence makes sessions resumable. Defaults
+	// to an in-memory store.
+	Conversation *memory.ConversationStore
+	// Memory, when the agent's memory is partitioned with it, loses the
+	// session's partition when the session is closed or swept
+	Memory *memory.PartitionStore
+	// TTL expires the session after this long without activity (0 = never)
+	TTL time.Duration
+	// TokenBudget bounds the estimated tokens of all turns (0 = unlimited)
+	TokenBudget int
+	// CountTokens estimates the tokens in a text; defaults to ~4 characters per token
+	CountTokens func(string) int
+}
+
+// Session is a multi-turn conversation with an agent. Its Execute calls
+// share conversation history, a memory partition, and a token budget.
+type Session struct {
+	id         string
+	agent      Agent
+	config     SessionConfig
+	tokensUsed int
+	lastActive time.Time
+	mu         sync.Mutex
+}
+
+// NewSession opens a session with the agent. Opening a session with the
+// ID of an earlier one resumes its history from the conversation store,
+// along with the tokens it used and, where the store records it, when it
+// was last active.
+func NewSession(ctx context.Context, a Agent, id string, config SessionConfig) (*Session, error) {
+	if config.Conversation == nil {
+		config.Conversation = memory.NewConversationStore(memory.ConversationConfig{})
+	}
+	if config.CountTokens == nil {
+		config.CountTokens = func(s string) int { return (len(s) + 3) / 4 }
+	}
+	s := &Session{
+		id:         id,
+		agent:      a,
+		config:     config,
+		lastActive: time.Now(),
+	}
+
+	// The budget counts every stored turn, so it is restored from them
+	history, err := config.Conversation.History(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session history: %w", err)
+	}
+	for _, m := range history {
+		s.tokensUsed += config.CountTokens(m.Content)
+	}
+	lastActive, err := config.Conversation.LastActive(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session activity: %w", err)
+	}
+	if !lastActive.IsZero() {
+		s.lastActive = lastActive
+	}
+	return s, nil
+}
+
+// NewSession opens a session with the agent using the default session config
+func (b *BaseAgent) NewSession(ctx context.Context, id string) (*Session, error) {
+	return NewSession(ctx, b, id, SessionConfig{})
+}
+
+// ID returns the session ID
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Execute runs one turn of the conversation. Chat agents receive the
+// session history as messages; other agents receive it as a transcript.
+func (s *Session) Execute(ctx context.Context, input string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.TTL > 0 && time.Since(s.lastActive) > s.config.TTL {
+		return "", ErrSessionExpired
+	}
+	if s.config.TokenBudget > 0 && s.tokensUsed+s.config.CountTokens(input) > s.config.TokenBudget {
+		return "", ErrTokenBudgetExceeded
+	}
+
+	// Memory stores partitioned with memory.PartitionStore see only this session
+	ctx = memory.WithPartition(ctx, s.id)
+	ctx = llm.WithUsageScope(ctx, llm.UsageScope{Session: s.id})
+
+	history, err := s.config.Conversation.Window(ctx, s.id)
+	if err != nil {
+		return "", fmt.Errorf("failed to load session history: %w", err)
+	}
+
+	userMessage := llm.Message{Role: llm.RoleUser, Content: input}
+	var output string
+	if chat, ok := s.agent.(ChatAgent); ok {
+		reply, err := chat.Chat(ctx, append(history, userMessage))
+		if err != nil {
+			return "", err
+		}
+		output = reply.Content
+	} else {
+		prompt := input
+		if len(history) > 0 {
+			prompt = fmt.Sprintf("Conversation so far:\n%s\n%s", llm.FormatPrompt(history), input)
+		}
+		output, err = s.agent.Execute(ctx, prompt)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = s.config.Conversation.Append(ctx, s.id, userMessage,
+		llm.Message{Role: llm.RoleAssistant, Content: output})
+	if err != nil {
+		return "", fmt.Errorf("failed to save session history: %w", err)
+	}
+
+	s.tokensUsed += s.config.CountTokens(input) + s.config.CountTokens(output)
+	s.lastActive = time.Now()
+	return output, nil
+}
+
+// History returns the complete conversation of the session
+func (s *Session) History(ctx context.Context) ([]llm.Message, error) {
+	return s.config.Conversation.History(ctx, s.id)
+}
+
+// TokensUsed returns the estimated tokens consumed by the session so far
+func (s *Session) TokensUsed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokensUsed
+}
+
+// Expired reports whether the session has been idle longer than its TTL
+func (s *Session) Expired() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.TTL > 0 && time.Since(s.lastActive) > s.config.TTL
+}
+
+// Close discards the session history and memory partition
+func (s *Session) Close(ctx context.Context) error {
+	if s.config.Memory != nil {
+		s.config.Memory.Drop(s.id)
+	}
+	return s.config.Conversation.Clear(ctx, s.id)
+}
+
+// SweepSessions deletes the sessions of config's conversation store idle
+// for longer than its TTL, and their memory partitions, every interval
+// until ctx is done. The store's persistence must implement
+// memory.ConversationActivity.
+func SweepSessions(ctx context.Context, config SessionConfig, interval time.Duration) {
+	if config.TTL <= 0 || config.Conversation == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		ids, err := config.Conversation.Sweep(ctx, config.TTL)
+		if config.Memory != nil {
+			for _, id := range ids {
+				config.Memory.Drop(id)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error sweeping sessions: %v\n", err)
+		}
+	}
+}
+
+// Error types
+type SessionError string
+
+func (e SessionError) Error() string { return string(e) }
+
+const (
+	ErrSessionExpired      = SessionError("session expired")
+	ErrTokenBudgetExceeded = SessionError("session token budget exceeded")
+)
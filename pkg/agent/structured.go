@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/user/modulox/pkg/jsonschema"
+	"github.com/user/modulox/pkg/types"
+)
+
+// StructuredAgent wraps an agent so that it answers with JSON conforming to
+// a schema. Malformed or non-conforming answers are sent back to the agent
+// with the validation error until it complies or retries run out.
+type StructuredAgent struct {
+	inner      Agent
+	schema     map[string]interface{}
+	maxRetries int
+}
+
+// NewStructuredAgent creates a structured agent for a JSON schema.
+// maxRetries bounds re-prompts after invalid output (default 2).
+func NewStructuredAgent(inner Agent, schema map[string]interface{}, maxRetries int) *StructuredAgent {
+	if maxRetries <= 0 {
+		maxRetries = 2
+	}
+	return &StructuredAgent{
+		inner:      inner,
+		schema:     schema,
+		maxRetries: maxRetries,
+	}
+}
+
+// NewStructuredAgentFor creates a structured agent whose schema is derived
+// from the Go type of target using its json tags
+func NewStructuredAgentFor(inner Agent, target interface{}, maxRetries int) *StructuredAgent {
+	return NewStructuredAgent(inner, jsonschema.ForType(reflect.TypeOf(target)), maxRetries)
+}
+
+// ExecuteStructured runs the agent and returns its validated, decoded answer
+func (s *StructuredAgent) ExecuteStructured(ctx context.Context, input string) (interface{}, error) {
+	schema, err := json.MarshalIndent(s.schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	prompt := fmt.Sprintf("%s\n\nRespond only with JSON matching this schema:\n%s", input, schema)
+
+	var lastErr error
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		output, err := s.inner.Execute(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := decodeJSON(output)
+		if err == nil {
+			err = jsonschema.Validate(s.schema, value)
+		}
+		if err == nil {
+			return value, nil
+		}
+
+		lastErr = err
+		prompt = fmt.Sprintf("%s\n\nRespond only with JSON matching this schema:\n%s\n\n"+
+			"Your previous response was invalid (%v):\n%s\n\nRespond again with corrected JSON only.",
+			input, schema, err, output)
+	}
+
+	return nil, fmt.Errorf("no valid structured output after %d attempts: %w", s.maxRetries+1, lastErr)
+}
+
+// ExecuteInto runs the agent and decodes its validated answer into target
+func (s *StructuredAgent) ExecuteInto(ctx context.Context, input string, target interface{}) error {
+	value, err := s.ExecuteStructured(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// Execute implements Agent.Execute, returning the validated answer as JSON
+func (s *StructuredAgent) Execute(ctx context.Context, input string) (string, error) {
+	value, err := s.ExecuteStructured(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// AddTool implements Agent.AddTool
+func (s *StructuredAgent) AddTool(tool types.Tool) error {
+	return s.inner.AddTool(tool)
+}
+
+// GetCapabilities implements Agent.GetCapabilities
+func (s *StructuredAgent) GetCapabilities() []types.Capability {
+	return s.inner.GetCapabilities()
+}
+
+// decodeJSON extracts a JSON value from model output, tolerating markdown
+// code fences and surrounding prose
+func decodeJSON(output string) (interface{}, error) {
+	text := strings.TrimSpace(output)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err == nil {
+		return value, nil
+	}
+
+	// Fall back to the outermost object or array in the text
+	start := strings.IndexAny(text, "{[")
+	if start >= 0 {
+		closing := "}"
+		if text[start] == '[' {
+			closing = "]"
+		}
+		if end := strings.LastIndex(text, closing); end > start {
+			if err := json.Unmarshal([]byte(text[start:end+1]), &value); err == nil {
+				return value, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("response is not valid JSON")
+}
@@ -0,0 +1,328 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/redact"
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/types"
+)
+
+// MemoryTrace is a memory retrieved for a run
+type MemoryTrace struct {
+	ID       string
+	Metadata map[string]interface{}
+}
+
+// LLMCallTrace is a model request made during a run
+type LLMCallTrace struct {
+	Reply     llm.Message
+	Error     string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// ToolCallTrace is a tool invocation made during a run
+type ToolCallTrace struct {
+	Name      string
+	Arguments string
+	Result    string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// ExecutionTrace records what happened during one agent run
+type ExecutionTrace struct {
+	RunID  string
+	Agent  string
+	Input  string
+	Output string
+	Error  string
+	// Prompt is the conversation sent to the model, including system
+	// prompt, examples, and memory context
+	Prompt    []llm.Message
+	Memories  []MemoryTrace
+	Cached    bool
+	LLMCalls  []LLMCallTrace
+	ToolCalls []ToolCallTrace
+	// Usage lists the usage reported by metered providers
+	Usage     []llm.Usage
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// TotalUsage sums the usage of the run
+func (t *ExecutionTrace) TotalUsage() llm.Usage {
+	var total llm.Usage
+	for _, u := range t.Usage {
+		total.PromptTokens += u.PromptTokens
+		total.CompletionTokens += u.CompletionTokens
+		total.Cost += u.Cost
+		total.Estimated = total.Estimated || u.Estimated
+	}
+	return total
+}
+
+// TraceStore stores execution traces by run ID
+type TraceStore interface {
+	Save(ctx context.Context, trace *ExecutionTrace) error
+	Get(ctx context.Context, runID string) (*ExecutionTrace, error)
+}
+
+// MemoryTraceStore keeps the most recent traces in memory, scoped by tenant
+type MemoryTraceStore struct {
+	traces  map[string]*ExecutionTrace
+	order   []string
+	maxRuns int
+	mu      sync.RWMutex
+}
+
+// NewMemoryTraceStore creates a trace store holding up to maxRuns traces
+// (default 100)
+func NewMemoryTraceStore(maxRuns int) *MemoryTraceStore {
+	if maxRuns <= 0 {
+		maxRuns = 100
+	}
+	return &MemoryTraceStore{
+		traces:  make(map[string]*ExecutionTrace),
+		maxRuns: maxRuns,
+	}
+}
+
+// Save implements TraceStore.Save, evicting the oldest trace when full
+func (s *MemoryTraceStore) Save(ctx context.Context, trace *ExecutionTrace) error {
+	key := tenant.ScopedKey(ctx, trace.RunID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.traces[key]; !exists {
+		s.order = append(s.order, key)
+	}
+	s.traces[key] = trace
+	for len(s.order) > s.maxRuns {
+		delete(s.traces, s.order[0])
+		s.order = s.order[1:]
+	}
+	return nil
+}
+
+// Get implements TraceStore.Get
+func (s *MemoryTraceStore) Get(ctx context.Context, runID string) (*ExecutionTrace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trace, exists := s.traces[tenant.ScopedKey(ctx, runID)]
+	if !exists {
+		return nil, ErrRunNotFound
+	}
+	return trace, nil
+}
+
+type runIDKey struct{}
+
+type runRecorderKey struct{}
+
+// WithRunID sets the run ID of the next agent run started with the
+// context; runs nested in it get IDs of their own
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunIDFromContext returns the ID of the run the context belongs to
+func RunIDFromContext(ctx context.Context) (string, bool) {
+	if rec, ok := ctx.Value(runRecorderKey{}).(*runRecorder); ok {
+		return rec.trace.RunID, true
+	}
+	runID, ok := ctx.Value(runIDKey{}).(string)
+	return runID, ok && runID != ""
+}
+
+// runRecorder collects the trace of a run in progress
+type runRecorder struct {
+	trace *ExecutionTrace
+	mu    sync.Mutex
+}
+
+// recorderFrom returns the run recorder attached to the context
+func recorderFrom(ctx context.Context) *runRecorder {
+	rec, _ := ctx.Value(runRecorderKey{}).(*runRecorder)
+	return rec
+}
+
+// startRun begins recording a run unless the context already belongs to
+// one of this agent's runs. The returned function finishes the run.
+func (b *BaseAgent) startRun(ctx context.Context, input string) (context.Context, func(output string, err error)) {
+	if rec := recorderFrom(ctx); rec != nil && rec.trace.Agent == b.config.Name {
+		return ctx, func(string, error) {}
+	}
+
+	runID, _ := ctx.Value(runIDKey{}).(string)
+	if runID == "" {
+		runID = types.NewID("run")
+	}
+	rec := &runRecorder{trace: &ExecutionTrace{
+		RunID:     runID,
+		Agent:     b.config.Name,
+		Input:     input,
+		StartedAt: time.Now(),
+	}}
+
+	// The run ID was for this run; runs of other agents nested in it must
+	// not reuse it
+	ctx = context.WithValue(ctx, runIDKey{}, "")
+	ctx = context.WithValue(ctx, runRecorderKey{}, rec)
+	ctx = llm.WithUsageObserver(ctx, func(_ context.Context, usage llm.Usage) {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.trace.Usage = append(rec.trace.Usage, usage)
+	})
+
+	return ctx, func(output string, err error) {
+		rec.mu.Lock()
+		rec.trace.Output = output
+		if err != nil {
+			rec.trace.Error = err.Error()
+		}
+		rec.trace.Duration = time.Since(rec.trace.StartedAt)
+		trace := redactTrace(ctx, b.redactor, rec.trace)
+		rec.mu.Unlock()
+
+		if b.traces != nil {
+			b.traces.Save(ctx, trace)
+		}
+	}
+}
+
+// redactTrace returns a copy of the trace with sensitive data masked in the
+// text it recorded
+func redactTrace(ctx context.Context, r *redact.Redactor, trace *ExecutionTrace) *ExecutionTrace {
+	redacted := *trace
+	redacted.Input = r.RedactString(ctx, trace.Input)
+	redacted.Output = r.RedactString(ctx, trace.Output)
+	redacted.Error = r.RedactString(ctx, trace.Error)
+
+	redacted.Prompt = make([]llm.Message, len(trace.Prompt))
+	for i, m := range trace.Prompt {
+		redacted.Prompt[i] = redactMessage(ctx, r, m)
+	}
+	redacted.Memories = make([]MemoryTrace, len(trace.Memories))
+	for i, m := range trace.Memories {
+		redacted.Memories[i] = MemoryTrace{ID: m.ID, Metadata: r.RedactMetadata(ctx, m.Metadata)}
+	}
+	redacted.LLMCalls = make([]LLMCallTrace, len(trace.LLMCalls))
+	for i, call := range trace.LLMCalls {
+		call.Reply = redactMessage(ctx, r, call.Reply)
+		call.Error = r.RedactString(ctx, call.Error)
+		redacted.LLMCalls[i] = call
+	}
+	redacted.ToolCalls = make([]ToolCallTrace, len(trace.ToolCalls))
+	for i, call := range trace.ToolCalls {
+		call.Arguments = r.RedactString(ctx, call.Arguments)
+		call.Result = r.RedactString(ctx, call.Result)
+		redacted.ToolCalls[i] = call
+	}
+	redacted.Usage = append([]llm.Usage(nil), trace.Usage...)
+	return &redacted
+}
+
+// redactMessage returns a copy of the message with its content and tool
+// call arguments redacted
+func redactMessage(ctx context.Context, r *redact.Redactor, m llm.Message) llm.Message {
+	m.Content = r.RedactString(ctx, m.Content)
+	if len(m.ToolCalls) > 0 {
+		calls := make([]llm.ToolCall, len(m.ToolCalls))
+		for i, call := range m.ToolCalls {
+			call.Arguments = r.RedactString(ctx, call.Arguments)
+			calls[i] = call
+		}
+		m.ToolCalls = calls
+	}
+	return m
+}
+
+// recordPrompt records the conversation and memories sent to the model
+func recordPrompt(ctx context.Context, messages []llm.Message, vectors []types.Vector, cached bool) {
+	rec := recorderFrom(ctx)
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.trace.Prompt = append([]llm.Message(nil), messages...)
+	rec.trace.Cached = cached
+	for _, v := range vectors {
+		rec.trace.Memories = append(rec.trace.Memories, MemoryTrace{ID: v.ID, Metadata: v.Metadata})
+	}
+}
+
+// recordLLMCall records a model request started at started
+func recordLLMCall(ctx context.Context, started time.Time, reply llm.Message, err error) {
+	rec := recorderFrom(ctx)
+	if rec == nil {
+		return
+	}
+	call := LLMCallTrace{Reply: reply, StartedAt: started, Duration: time.Since(started)}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.trace.LLMCalls = append(rec.trace.LLMCalls, call)
+}
+
+// recordToolCall records a tool invocation started at started
+func recordToolCall(ctx context.Context, started time.Time, call llm.ToolCall, result string) {
+	rec := recorderFrom(ctx)
+	if rec == nil {
+		return
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.trace.ToolCalls = append(rec.trace.ToolCalls, ToolCallTrace{
+		Name:      call.Name,
+		Arguments: call.Arguments,
+		Result:    result,
+		StartedAt: started,
+		Duration:  time.Since(started),
+	})
+}
+
+// GetTrace returns the trace of a run of the agent
+func (b *BaseAgent) GetTrace(ctx context.Context, runID string) (*ExecutionTrace, error) {
+	if b.traces == nil {
+		return nil, ErrRunNotFound
+	}
+	return b.traces.Get(ctx, runID)
+}
+
+// ExecuteTraced runs Execute and returns the trace of the run
+func (b *BaseAgent) ExecuteTraced(ctx context.Context, input string) (string, *ExecutionTrace, error) {
+	runID, ok := ctx.Value(runIDKey{}).(string)
+	if !ok || runID == "" {
+		runID = types.NewID("run")
+		ctx = WithRunID(ctx, runID)
+	}
+
+	output, err := b.Execute(ctx, input)
+	trace, traceErr := b.GetTrace(ctx, runID)
+	if traceErr != nil {
+		trace = nil
+	}
+	return output, trace, err
+}
+
+// Error types
+type RunError string
+
+func (e RunError) Error() string { return string(e) }
+
+const (
+	ErrRunNotFound = RunError("run not found")
+)
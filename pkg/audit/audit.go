@@ -0,0 +1,298 @@
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/redact"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// EventType identifies what an audit entry records
+type EventType string
+
+const (
+	EventAgentExecution EventType = "agent_execution"
+	EventToolInvocation EventType = "tool_invocation"
+	EventStateMutation  EventType = "state_mutation"
+	EventConfigChange   EventType = "config_change"
+)
+
+// Outcomes of audited actions
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Event describes an action to audit
+type Event struct {
+	Type     EventType
+	Action   string
+	Resource string
+	Outcome  string
+	// Actor overrides the principal attached to the context
+	Actor   string
+	Details map[string]interface{}
+}
+
+// Entry is a recorded audit event. Each entry includes the hash of the
+// previous one, so altering or removing an entry breaks the chain. Hashes
+// are HMACs, so the chain cannot be rebuilt without the log's key.
+type Entry struct {
+	Sequence  uint64                 `json:"sequence"`
+	Timestamp time.Time              `json:"timestamp"`
+	Type      EventType              `json:"type"`
+	Tenant    string                 `json:"tenant,omitempty"`
+	Actor     string                 `json:"actor,omitempty"`
+	Action    string                 `json:"action"`
+	Resource  string                 `json:"resource,omitempty"`
+	Outcome   string                 `json:"outcome,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	PrevHash  string                 `json:"prev_hash"`
+	Hash      string                 `json:"hash"`
+}
+
+// computeHash returns the HMAC-SHA256 of the entry without its hash
+func computeHash(key []byte, e Entry) (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Sink is an append-only destination for audit entries
+type Sink interface {
+	Append(ctx context.Context, entry Entry) error
+	Close() error
+}
+
+// Reader is implemented by sinks that can read their entries back
+type Reader interface {
+	// Entries returns the entries with a sequence number of at least from,
+	// in order
+	Entries(ctx context.Context, from uint64) ([]Entry, error)
+	// Last returns the most recent entry
+	Last(ctx context.Context) (Entry, bool, error)
+}
+
+// Config configures an audit log
+type Config struct {
+	// Key signs the hash chain and is required to verify it
+	Key []byte
+	// Redactor masks sensitive data in details before they are hashed
+	// (default redact.NewRedactor())
+	Redactor *redact.Redactor
+}
+
+// Log records hash-chained audit entries to its sinks
+type Log struct {
+	sinks    []Sink
+	key      []byte
+	redactor *redact.Redactor
+	sequence uint64
+	lastHash string
+	mu       sync.Mutex
+}
+
+// NewLog creates an audit log writing to every sink. At least one sink must
+// implement Reader; the chain continues from the latest entry any of them
+// holds, so a restart never reuses sequence numbers.
+func NewLog(ctx context.Context, cfg Config, sinks ...Sink) (*Log, error) {
+	if len(cfg.Key) == 0 {
+		return nil, fmt.Errorf("audit log requires a key")
+	}
+	if cfg.Redactor == nil {
+		cfg.Redactor = redact.NewRedactor()
+	}
+
+	l := &Log{sinks: sinks, key: cfg.Key, redactor: cfg.Redactor}
+	readable := false
+	for _, sink := range sinks {
+		r, ok := sink.(Reader)
+		if !ok {
+			continue
+		}
+		readable = true
+		last, exists, err := r.Last(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resume audit log: %w", err)
+		}
+		if exists && last.Sequence > l.sequence {
+			l.sequence = last.Sequence
+			l.lastHash = last.Hash
+		}
+	}
+	if !readable {
+		return nil, fmt.Errorf("audit log requires a sink that can be read back")
+	}
+	return l, nil
+}
+
+// Record appends an event to the log. The actor defaults to the principal
+// attached to the context. Details are redacted before the entry is hashed.
+// The entry is offered to every sink; it joins the chain if any of them
+// accepts it, and failing sinks are reported in the error.
+func (l *Log) Record(ctx context.Context, event Event) (Entry, error) {
+	entry := Entry{
+		Timestamp: time.Now().UTC(),
+		Type:      event.Type,
+		Tenant:    tenant.IDFromContext(ctx),
+		Actor:     event.Actor,
+		Action:    event.Action,
+		Resource:  event.Resource,
+		Outcome:   event.Outcome,
+		Details:   l.redactDetails(ctx, event.Details),
+	}
+	if entry.Actor == "" {
+		if p, ok := auth.PrincipalFromContext(ctx); ok {
+			entry.Actor = p.ID
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Sequence = l.sequence + 1
+	entry.PrevHash = l.lastHash
+	hash, err := computeHash(l.key, entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Hash = hash
+
+	var failures []string
+	for _, sink := range l.sinks {
+		if err := sink.Append(ctx, entry); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) == len(l.sinks) && len(l.sinks) > 0 {
+		return Entry{}, fmt.Errorf("failed to append audit entry: %s", strings.Join(failures, "; "))
+	}
+
+	l.sequence = entry.Sequence
+	l.lastHash = entry.Hash
+	if len(failures) > 0 {
+		return entry, fmt.Errorf("failed to append audit entry to %d of %d sinks: %s", len(failures), len(l.sinks), strings.Join(failures, "; "))
+	}
+	return entry, nil
+}
+
+// redactDetails returns a copy of details with sensitive data in strings
+// masked. Details that cannot be checked are dropped rather than logged.
+func (l *Log) redactDetails(ctx context.Context, details map[string]interface{}) map[string]interface{} {
+	if details == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(details))
+	for k, v := range details {
+		redacted[k] = l.redactValue(ctx, v)
+	}
+	return redacted
+}
+
+func (l *Log) redactValue(ctx context.Context, v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		masked, _, err := l.redactor.Redact(ctx, v)
+		if err != nil {
+			return "[REDACTED]"
+		}
+		return masked
+	case map[string]interface{}:
+		return l.redactDetails(ctx, v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = l.redactValue(ctx, item)
+		}
+		return out
+	}
+	return v
+}
+
+// Close closes every sink
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var firstErr error
+	for _, sink := range l.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Verify checks that entries form an unbroken hash chain signed with key.
+// The first entry may start mid-log; its previous hash is taken on trust.
+func Verify(entries []Entry, key []byte) error {
+	for i, e := range entries {
+		hash, err := computeHash(key, e)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(hash), []byte(e.Hash)) {
+			return &ChainError{Sequence: e.Sequence, Reason: "hash mismatch"}
+		}
+		if i == 0 {
+			continue
+		}
+		prev := entries[i-1]
+		if e.Sequence != prev.Sequence+1 {
+			return &ChainError{Sequence: e.Sequence, Reason: fmt.Sprintf("expected sequence %d", prev.Sequence+1)}
+		}
+		if e.PrevHash != prev.Hash {
+			return &ChainError{Sequence: e.Sequence, Reason: "previous hash mismatch"}
+		}
+	}
+	return nil
+}
+
+// VerifySink reads every entry of a sink and verifies the chain from its
+// first entry
+func VerifySink(ctx context.Context, r Reader, key []byte) error {
+	entries, err := r.Entries(ctx, 0)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 && (entries[0].Sequence != 1 || entries[0].PrevHash != "") {
+		return &ChainError{Sequence: entries[0].Sequence, Reason: "log does not start at the first entry"}
+	}
+	return Verify(entries, key)
+}
+
+// ChainError reports where an audit chain was found to be broken
+type ChainError struct {
+	Sequence uint64
+	Reason   string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at entry %d: %s", e.Sequence, e.Reason)
+}
+
+// Unwrap allows errors.Is(err, ErrChainBroken)
+func (e *ChainError) Unwrap() error { return ErrChainBroken }
+
+// Error types
+type AuditError string
+
+func (e AuditError) Error() string { return string(e) }
+
+const (
+	ErrChainBroken = AuditError("audit chain broken")
+)
@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/tools"
+)
+
+// record appends an event from a hook, which has no caller to return
+// failures to
+func (l *Log) record(ctx context.Context, event Event) {
+	if _, err := l.Record(ctx, event); err != nil {
+		fmt.Fprintf(os.Stderr, "Error recording audit entry: %v\n", err)
+	}
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// Middleware records every execution of the agent
+func Middleware(l *Log, name string) agent.Middleware {
+	return func(next agent.AgentFunc) agent.AgentFunc {
+		return func(ctx context.Context, input string) (string, error) {
+			start := time.Now()
+			output, err := next(ctx, input)
+
+			details := map[string]interface{}{
+				"input_length": len(input),
+				"duration_ms":  time.Since(start).Milliseconds(),
+			}
+			if runID, ok := agent.RunIDFromContext(ctx); ok {
+				details["run_id"] = runID
+			}
+			if err != nil {
+				details["error"] = err.Error()
+			} else {
+				details["output_length"] = len(output)
+			}
+			l.record(ctx, Event{
+				Type:     EventAgentExecution,
+				Action:   "execute",
+				Resource: name,
+				Outcome:  outcome(err),
+				Details:  details,
+			})
+			return output, err
+		}
+	}
+}
+
+// ToolHook returns a hook recording every tool invocation of a registry:
+// registry.AddInvocationHook(audit.ToolHook(log))
+func ToolHook(l *Log) tools.InvocationHook {
+	return func(ctx context.Context, name string, input, output interface{}, err error, duration time.Duration) {
+		details := map[string]interface{}{
+			"input":       fmt.Sprint(input),
+			"duration_ms": duration.Milliseconds(),
+		}
+		if err != nil {
+			details["error"] = err.Error()
+		}
+		l.record(ctx, Event{
+			Type:     EventToolInvocation,
+			Action:   "invoke",
+			Resource: name,
+			Outcome:  outcome(err),
+			Details:  details,
+		})
+	}
+}
+
+// StateHook returns a hook recording every state change made through an
+// agent server: server.AddStateHook(audit.StateHook(log))
+func StateHook(l *Log) communication.StateHook {
+	return func(ctx context.Context, key string, entry communication.StateEntry) {
+		l.record(ctx, Event{
+			Type:     EventStateMutation,
+			Action:   "set",
+			Resource: key,
+			Outcome:  OutcomeSuccess,
+			Details: map[string]interface{}{
+				"version": entry.Version,
+				"value":   fmt.Sprint(entry.Value),
+			},
+		})
+	}
+}
+
+// sensitiveKeys mark config fields whose values are not written to the log
+var sensitiveKeys = []string{"api_key", "password", "secret", "token"}
+
+// RecordConfigChange records the fields that differ between two
+// configurations. Values of sensitive fields such as API keys are masked.
+func RecordConfigChange(ctx context.Context, l *Log, source string, old, updated *config.Config) (Entry, error) {
+	before, err := flattenConfig(old)
+	if err != nil {
+		return Entry{}, err
+	}
+	after, err := flattenConfig(updated)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	paths := make(map[string]struct{})
+	for path := range before {
+		paths[path] = struct{}{}
+	}
+	for path := range after {
+		paths[path] = struct{}{}
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	changes := make(map[string]interface{})
+	for _, path := range sorted {
+		oldValue, newValue := before[path], after[path]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if isSensitive(path) {
+			oldValue, newValue = "[REDACTED]", "[REDACTED]"
+		}
+		changes[path] = map[string]interface{}{"old": oldValue, "new": newValue}
+	}
+
+	return l.Record(ctx, Event{
+		Type:     EventConfigChange,
+		Action:   "update",
+		Resource: source,
+		Outcome:  OutcomeSuccess,
+		Details:  map[string]interface{}{"changes": changes},
+	})
+}
+
+// flattenConfig maps dotted JSON paths to the leaf values of a config
+func flattenConfig(cfg *config.Config) (map[string]interface{}, error) {
+	flat := make(map[string]interface{})
+	if cfg == nil {
+		return flat, nil
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	var tree map[string]interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			flat[prefix] = v
+			return
+		}
+		for k, child := range m {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			walk(k, child)
+		}
+	}
+	walk("", tree)
+	return flat, nil
+}
+
+func isSensitive(path string) bool {
+	path = strings.ToLower(path)
+	for _, key := range sensitiveKeys {
+		if strings.Contains(path, key) {
+			return true
+		}
+	}
+	return false
+}
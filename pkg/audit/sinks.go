@@ -0,0 +1,394 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/user/modulox/pkg/persistence"
+)
+
+// FileSink appends entries as JSON lines to a file opened in append-only mode
+type FileSink struct {
+	path string
+	file *os.File
+	// fsync forces each entry to disk before Append returns
+	fsync bool
+	mu    sync.Mutex
+}
+
+// NewFileSink opens a file sink. With fsync set, every entry is flushed to
+// disk before it is acknowledged.
+func NewFileSink(path string, fsync bool) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileSink{path: path, file: file, fsync: fsync}, nil
+}
+
+// Append implements Sink.Append
+func (s *FileSink) Append(ctx context.Context, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if s.fsync {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+// Entries implements Reader.Entries
+func (s *FileSink) Entries(ctx context.Context, from uint64) ([]Entry, error) {
+	var entries []Entry
+	err := s.scan(func(e Entry) {
+		if e.Sequence >= from {
+			entries = append(entries, e)
+		}
+	})
+	return entries, err
+}
+
+// Last implements Reader.Last
+func (s *FileSink) Last(ctx context.Context) (Entry, bool, error) {
+	var last Entry
+	found := false
+	err := s.scan(func(e Entry) {
+		last = e
+		found = true
+	})
+	return last, found, err
+}
+
+// scan decodes every entry in the file
+func (s *FileSink) scan(fn func(Entry)) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("failed to decode audit entry on line %d: %w", line, err)
+		}
+		fn(e)
+	}
+	return scanner.Err()
+}
+
+// Close implements Sink.Close
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// ObjectStore reads and writes objects in blob storage such as S3. Adapting
+// an S3 client takes its PutObject, ListObjectsV2 and GetObject calls.
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, data []byte) error
+	// ListObjects returns the keys starting with prefix
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+	GetObject(ctx context.Context, key string) ([]byte, error)
+}
+
+// S3Sink batches entries into JSON lines objects named
+// <prefix><first sequence>-<last sequence>.jsonl. Objects are never
+// overwritten, so the bucket can use object lock for immutability.
+type S3Sink struct {
+	store     ObjectStore
+	prefix    string
+	batchSize int
+	pending   []Entry
+	mu        sync.Mutex
+}
+
+// NewS3Sink creates a sink writing a new object every batchSize entries
+// (default 100). Pending entries are written on Flush and Close.
+func NewS3Sink(store ObjectStore, prefix string, batchSize int) *S3Sink {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &S3Sink{store: store, prefix: prefix, batchSize: batchSize}
+}
+
+// Append implements Sink.Append
+func (s *S3Sink) Append(ctx context.Context, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, entry)
+	if len(s.pending) < s.batchSize {
+		return nil
+	}
+	return s.flush(ctx)
+}
+
+// Flush writes the pending entries as an object
+func (s *S3Sink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush(ctx)
+}
+
+func (s *S3Sink) flush(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, e := range s.pending {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	// Zero-padded sequences keep object keys in chain order
+	key := fmt.Sprintf("%s%020d-%020d.jsonl", s.prefix, s.pending[0].Sequence, s.pending[len(s.pending)-1].Sequence)
+	if err := s.store.PutObject(ctx, key, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write audit object: %w", err)
+	}
+	s.pending = nil
+	return nil
+}
+
+// Entries implements Reader.Entries, including entries not yet written
+func (s *S3Sink) Entries(ctx context.Context, from uint64) ([]Entry, error) {
+	keys, err := s.objects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, key := range keys {
+		if last, ok := s.lastSequence(key); ok && last < from {
+			continue
+		}
+		batch, err := s.read(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range batch {
+			if e.Sequence >= from {
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.pending {
+		if e.Sequence >= from {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Last implements Reader.Last
+func (s *S3Sink) Last(ctx context.Context) (Entry, bool, error) {
+	s.mu.Lock()
+	if len(s.pending) > 0 {
+		last := s.pending[len(s.pending)-1]
+		s.mu.Unlock()
+		return last, true, nil
+	}
+	s.mu.Unlock()
+
+	keys, err := s.objects(ctx)
+	if err != nil || len(keys) == 0 {
+		return Entry{}, false, err
+	}
+	batch, err := s.read(ctx, keys[len(keys)-1])
+	if err != nil || len(batch) == 0 {
+		return Entry{}, false, err
+	}
+	return batch[len(batch)-1], true, nil
+}
+
+// objects returns the sink's object keys in chain order
+func (s *S3Sink) objects(ctx context.Context) ([]string, error) {
+	keys, err := s.store.ListObjects(ctx, s.prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit objects: %w", err)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// lastSequence parses the last sequence number from an object key
+func (s *S3Sink) lastSequence(key string) (uint64, bool) {
+	name := strings.TrimSuffix(strings.TrimPrefix(key, s.prefix), ".jsonl")
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(name[i+1:], 10, 64)
+	return seq, err == nil
+}
+
+// read decodes the entries of an object
+func (s *S3Sink) read(ctx context.Context, key string) ([]Entry, error) {
+	data, err := s.store.GetObject(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit object %s: %w", key, err)
+	}
+	var entries []Entry
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry in %s: %w", key, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Close implements Sink.Close, writing pending entries
+func (s *S3Sink) Close() error {
+	return s.Flush(context.Background())
+}
+
+// SQLSink stores entries in an append-only audit_log table
+type SQLSink struct {
+	db      *sql.DB
+	dialect persistence.Dialect
+}
+
+// NewSQLSink creates a SQL-backed sink. The caller opens the *sql.DB with the
+// driver of their choice.
+func NewSQLSink(db *sql.DB, dialect persistence.Dialect) *SQLSink {
+	return &SQLSink{db: db, dialect: dialect}
+}
+
+// auditSchema creates the audit table; data holds the entry as hashed
+const auditSchema = `CREATE TABLE IF NOT EXISTS audit_log (
+	sequence BIGINT PRIMARY KEY,
+	tenant_id TEXT,
+	type TEXT NOT NULL,
+	actor TEXT,
+	action TEXT NOT NULL,
+	resource TEXT,
+	outcome TEXT,
+	created_at BIGINT NOT NULL,
+	prev_hash TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	data TEXT NOT NULL
+)`
+
+// Migrate creates the audit table if it does not exist
+func (s *SQLSink) Migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, auditSchema); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+	return nil
+}
+
+// Append implements Sink.Append
+func (s *SQLSink) Append(ctx context.Context, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(
+		`INSERT INTO audit_log (sequence, tenant_id, type, actor, action, resource, outcome, created_at, prev_hash, hash, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		entry.Sequence, entry.Tenant, string(entry.Type), entry.Actor, entry.Action, entry.Resource, entry.Outcome,
+		entry.Timestamp.UnixNano(), entry.PrevHash, entry.Hash, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// Entries implements Reader.Entries
+func (s *SQLSink) Entries(ctx context.Context, from uint64) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT data FROM audit_log WHERE sequence >= ? ORDER BY sequence`), from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Last implements Reader.Last
+func (s *SQLSink) Last(ctx context.Context) (Entry, bool, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM audit_log ORDER BY sequence DESC LIMIT 1`).Scan(&data)
+	if err == sql.ErrNoRows {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return Entry{}, false, fmt.Errorf("failed to decode audit entry: %w", err)
+	}
+	return e, true, nil
+}
+
+// Close implements Sink.Close. The database is owned by the caller.
+func (s *SQLSink) Close() error {
+	return nil
+}
+
+// rebind rewrites ? placeholders to $n for Postgres
+func (s *SQLSink) rebind(query string) string {
+	if s.dialect != persistence.DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
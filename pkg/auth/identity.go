@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// IdentitySource resolves credentials into a principal
+type IdentitySource interface {
+	// Identify returns the principal for the given credentials
+	Identify(ctx context.Context, credentials string) (*Principal, error)
+}
+
+// StaticIdentitySource maps fixed API tokens to principals
+type StaticIdentitySource struct {
+	tokens map[string]*Principal
+	mu     sync.RWMutex
+}
+
+// NewStaticIdentitySource creates a new token-based identity source
+func NewStaticIdentitySource() *StaticIdentitySource {
+	return &StaticIdentitySource{
+		tokens: make(map[string]*Principal),
+	}
+}
+
+// AddToken associates a token with a principal
+func (s *StaticIdentitySource) AddToken(token string, p *Principal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = p
+}
+
+// RevokeToken removes a token
+func (s *StaticIdentitySource) RevokeToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, token)
+}
+
+// Identify implements IdentitySource.Identify
+func (s *StaticIdentitySource) Identify(ctx context.Context, credentials string) (*Principal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, exists := s.tokens[credentials]
+	if !exists || credentials == "" {
+		return nil, ErrInvalidToken
+	}
+	return p, nil
+}
+
+// ChainIdentitySource tries each source in order until one succeeds
+type ChainIdentitySource []IdentitySource
+
+// Identify implements IdentitySource.Identify
+func (c ChainIdentitySource) Identify(ctx context.Context, credentials string) (*Principal, error) {
+	for _, source := range c {
+		if p, err := source.Identify(ctx, credentials); err == nil {
+			return p, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+// BearerToken extracts the token from an Authorization header value
+func BearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return strings.TrimSpace(header)
+}
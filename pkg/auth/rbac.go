@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Action is an operation a principal may perform on a resource
+type Action string
+
+const (
+	ActionRead       Action = "read"
+	ActionExecute    Action = "execute"
+	ActionWrite      Action = "write"
+	ActionAdminister Action = "administer"
+)
+
+// ResourceType identifies the kind of resource being accessed
+type ResourceType string
+
+const (
+	ResourceAgent    ResourceType = "agent"
+	ResourceWorkflow ResourceType = "workflow"
+	ResourceTool     ResourceType = "tool"
+	ResourceCluster  ResourceType = "cluster"
+	ResourceEvent    ResourceType = "event"
+	ResourceState    ResourceType = "state"
+)
+
+// Wildcard matches any action, resource type, or resource name
+const Wildcard = "*"
+
+// Permission grants an action on a resource. Any field may be Wildcard, and
+// names may be patterns where * matches any sequence of characters, e.g.
+// "workflow.*" for event types or "shared/*" for state keys.
+type Permission struct {
+	Action   Action
+	Resource ResourceType
+	Name     string
+}
+
+// String returns the permission in action:resource:name form
+func (p Permission) String() string {
+	return fmt.Sprintf("%s:%s:%s", p.Action, p.Resource, p.Name)
+}
+
+// Allows checks if this permission grants the requested one
+func (p Permission) Allows(req Permission) bool {
+	return (p.Action == Wildcard || p.Action == req.Action) &&
+		(p.Resource == Wildcard || p.Resource == req.Resource) &&
+		matchName(p.Name, req.Name)
+}
+
+// matchName matches a name against a pattern containing * wildcards
+func matchName(pattern, name string) bool {
+	if pattern == Wildcard || pattern == name {
+		return true
+	}
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return false
+	}
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return strings.HasSuffix(name, parts[len(parts)-1])
+}
+
+// Role is a named set of permissions
+type Role struct {
+	Name        string
+	Permissions []Permission
+}
+
+// Principal is an authenticated identity
+type Principal struct {
+	ID       string
+	Roles    []string
+	TenantID string
+	Metadata map[string]string
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the given principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal attached to the context
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// DefaultRoles returns the built-in admin, user, node, and viewer roles
+func DefaultRoles() []Role {
+	return []Role{
+		{
+			Name:        "admin",
+			Permissions: []Permission{{Action: Wildcard, Resource: Wildcard, Name: Wildcard}},
+		},
+		{
+			Name: "user",
+			Permissions: []Permission{
+				{Action: ActionRead, Resource: Wildcard, Name: Wildcard},
+				{Action: ActionExecute, Resource: ResourceAgent, Name: Wildcard},
+				{Action: ActionExecute, Resource: ResourceWorkflow, Name: Wildcard},
+				{Action: ActionExecute, Resource: ResourceTool, Name: Wildcard},
+				{Action: ActionWrite, Resource: ResourceEvent, Name: Wildcard},
+				{Action: ActionWrite, Resource: ResourceState, Name: Wildcard},
+			},
+		},
+		{
+			// Cluster members may manage membership and run each other's
+			// work, but not administer
+			Name: "node",
+			Permissions: []Permission{
+				{Action: ActionRead, Resource: Wildcard, Name: Wildcard},
+				{Action: ActionWrite, Resource: ResourceCluster, Name: Wildcard},
+				{Action: ActionExecute, Resource: ResourceAgent, Name: Wildcard},
+				{Action: ActionExecute, Resource: ResourceTool, Name: Wildcard},
+				{Action: ActionWrite, Resource: ResourceEvent, Name: Wildcard},
+				{Action: ActionWrite, Resource: ResourceState, Name: Wildcard},
+			},
+		},
+		{
+			Name:        "viewer",
+			Permissions: []Permission{{Action: ActionRead, Resource: Wildcard, Name: Wildcard}},
+		},
+	}
+}
+
+// Authorizer decides whether principals may perform actions
+type Authorizer struct {
+	roles map[string]Role
+	mu    sync.RWMutex
+}
+
+// NewAuthorizer creates a new authorizer with the given roles
+func NewAuthorizer(roles ...Role) *Authorizer {
+	az := &Authorizer{
+		roles: make(map[string]Role),
+	}
+	for _, r := range roles {
+		az.roles[r.Name] = r
+	}
+	return az
+}
+
+// AddRole registers or replaces a role
+func (az *Authorizer) AddRole(role Role) {
+	az.mu.Lock()
+	defer az.mu.Unlock()
+	az.roles[role.Name] = role
+}
+
+// IsAllowed checks if the principal holds a role granting the permission
+func (az *Authorizer) IsAllowed(p *Principal, req Permission) bool {
+	az.mu.RLock()
+	defer az.mu.RUnlock()
+
+	for _, name := range p.Roles {
+		role, exists := az.roles[name]
+		if !exists {
+			continue
+		}
+		for _, perm := range role.Permissions {
+			if perm.Allows(req) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize checks the permission against the principal attached to the context
+func (az *Authorizer) Authorize(ctx context.Context, req Permission) error {
+	p, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !az.IsAllowed(p, req) {
+		return fmt.Errorf("%w: %s may not %s", ErrPermissionDenied, p.ID, req)
+	}
+	return nil
+}
+
+// IsAdmin reports whether the principal attached to the context may
+// administer every resource
+func (az *Authorizer) IsAdmin(ctx context.Context) bool {
+	return az.Authorize(ctx, Permission{Action: ActionAdminister, Resource: Wildcard, Name: Wildcard}) == nil
+}
+
+// Error types
+type AuthError string
+
+func (e AuthError) Error() string { return string(e) }
+
+const (
+	ErrUnauthenticated  = AuthError("unauthenticated")
+	ErrPermissionDenied = AuthError("permission denied")
+	ErrInvalidToken     = AuthError("invalid credentials")
+)
@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// SemanticCacheConfig contains configuration for the semantic cache
+type SemanticCacheConfig struct {
+	// Threshold is the minimum cosine similarity for a cache hit; values
+	// of zero or below use the default
+	Threshold float64
+	// TTL is how long entries stay valid; zero means no expiry
+	TTL time.Duration
+	// MaxEntries bounds the cache size; the oldest entries are evicted first
+	MaxEntries int
+}
+
+// DefaultSemanticCacheConfig returns a default semantic cache configuration
+func DefaultSemanticCacheConfig() SemanticCacheConfig {
+	return SemanticCacheConfig{
+		Threshold:  0.95,
+		TTL:        time.Hour,
+		MaxEntries: 10000,
+	}
+}
+
+// Entry is a cached agent answer
+type Entry struct {
+	ID       string
+	AgentID  string
+	TenantID string
+	// Scope digests what besides the input shaped the answer; entries
+	// only answer lookups with the same scope
+	Scope     string
+	Input     string
+	Output    string
+	Embedding []float32
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// InvalidationHook is called for every entry removed from the cache
+type InvalidationHook func(entry Entry, reason string)
+
+// Invalidation reasons passed to hooks
+const (
+	ReasonExpired     = "expired"
+	ReasonEvicted     = "evicted"
+	ReasonInvalidated = "invalidated"
+)
+
+// SemanticCache caches agent answers keyed by the embedding of their input
+type SemanticCache struct {
+	config  SemanticCacheConfig
+	entries []Entry
+	hooks   []InvalidationHook
+	hits    int64
+	misses  int64
+	mu      sync.RWMutex
+}
+
+// NewSemanticCache creates a new semantic cache
+func NewSemanticCache(config SemanticCacheConfig) *SemanticCache {
+	// Every similarity clears a threshold of zero, which would answer
+	// unrelated questions from the cache
+	if config.Threshold <= 0 {
+		config.Threshold = DefaultSemanticCacheConfig().Threshold
+	}
+	return &SemanticCache{
+		config:  config,
+		entries: make([]Entry, 0),
+	}
+}
+
+// AddInvalidationHook registers a hook called when entries are removed
+func (sc *SemanticCache) AddInvalidationHook(hook InvalidationHook) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.hooks = append(sc.hooks, hook)
+}
+
+// Lookup returns the cached answer most similar to the embedding, if any
+// entry for the agent, tenant, and scope is above the similarity threshold
+func (sc *SemanticCache) Lookup(ctx context.Context, agentID, scope string, embedding []float32) (string, bool) {
+	tenantID := tenant.IDFromContext(ctx)
+	now := time.Now()
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	best := -1
+	bestScore := sc.config.Threshold
+	for i, e := range sc.entries {
+		if e.AgentID != agentID || e.TenantID != tenantID || e.Scope != scope {
+			continue
+		}
+		if !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt) {
+			continue
+		}
+		if score := CosineSimilarity(embedding, e.Embedding); score >= bestScore {
+			best = i
+			bestScore = score
+		}
+	}
+
+	if best < 0 {
+		sc.misses++
+		return "", false
+	}
+
+	sc.hits++
+	return sc.entries[best].Output, true
+}
+
+// Store caches an answer for the given input embedding and scope
+func (sc *SemanticCache) Store(ctx context.Context, agentID, scope, input string, embedding []float32, output string) {
+	now := time.Now()
+	entry := Entry{
+		ID:        fmt.Sprintf("cache-%d", now.UnixNano()),
+		AgentID:   agentID,
+		TenantID:  tenant.IDFromContext(ctx),
+		Scope:     scope,
+		Input:     input,
+		Output:    output,
+		Embedding: embedding,
+		CreatedAt: now,
+	}
+	if sc.config.TTL > 0 {
+		entry.ExpiresAt = now.Add(sc.config.TTL)
+	}
+
+	sc.mu.Lock()
+	sc.entries = append(sc.entries, entry)
+	removed := sc.removeLocked(func(e Entry) bool {
+		return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+	})
+	var evicted []Entry
+	if sc.config.MaxEntries > 0 && len(sc.entries) > sc.config.MaxEntries {
+		overflow := len(sc.entries) - sc.config.MaxEntries
+		evicted = append(evicted, sc.entries[:overflow]...)
+		sc.entries = append([]Entry(nil), sc.entries[overflow:]...)
+	}
+	hooks := sc.hooks
+	sc.mu.Unlock()
+
+	notify(hooks, removed, ReasonExpired)
+	notify(hooks, evicted, ReasonEvicted)
+}
+
+// Invalidate removes all entries for an agent
+func (sc *SemanticCache) Invalidate(agentID string) int {
+	return sc.InvalidateFunc(func(e Entry) bool {
+		return e.AgentID == agentID
+	})
+}
+
+// InvalidateFunc removes all entries matching the predicate
+func (sc *SemanticCache) InvalidateFunc(match func(Entry) bool) int {
+	sc.mu.Lock()
+	removed := sc.removeLocked(match)
+	hooks := sc.hooks
+	sc.mu.Unlock()
+
+	notify(hooks, removed, ReasonInvalidated)
+	return len(removed)
+}
+
+// Clear removes all entries
+func (sc *SemanticCache) Clear() {
+	sc.InvalidateFunc(func(Entry) bool { return true })
+}
+
+// Stats returns the number of entries, hits, and misses
+func (sc *SemanticCache) Stats() (entries int, hits int64, misses int64) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return len(sc.entries), sc.hits, sc.misses
+}
+
+// removeLocked removes matching entries; the caller must hold the lock
+func (sc *SemanticCache) removeLocked(match func(Entry) bool) []Entry {
+	var removed []Entry
+	kept := sc.entries[:0]
+	for _, e := range sc.entries {
+		if match(e) {
+			removed = append(removed, e)
+			continue
+		}
+		kept = append(kept, e)
+	}
+	sc.entries = kept
+	return removed
+}
+
+func notify(hooks []InvalidationHook, entries []Entry, reason string) {
+	for _, e := range entries {
+		for _, hook := range hooks {
+			hook(e, reason)
+		}
+	}
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
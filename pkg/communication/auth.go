@@ -0,0 +1,239 @@
+package communication
+
+import (
+	"context"
+	"sync"
+
+	"github.com/user/modulox/pkg/auth"
+	pb "github.com/user/modulox/pkg/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// streamEventsMethod is the full gRPC method name of AgentService.StreamEvents
+const (
+	streamEventsMethod = "/modulox.v1.AgentService/StreamEvents"
+	syncStateMethod    = "/modulox.v1.AgentService/SyncState"
+	communicateMethod  = "/modulox.v1.AgentService/Communicate"
+)
+
+// EnableAuthorization authenticates callers and enforces RBAC on all
+// AgentService RPCs. Streamed events are filtered to the event types the
+// caller may read, and callers acting as an agent may only publish events
+// as that agent.
+func (s *AgentServer) EnableAuthorization(ids auth.IdentitySource, az *auth.Authorizer) {
+	s.mu.Lock()
+	s.authorizer = az
+	s.mu.Unlock()
+
+	s.AddServerOptions(
+		grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(ids, az, RequiredPermission)),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(ids, az, RequiredPermission)),
+	)
+}
+
+// RequiredPermission maps AgentService RPCs to the permission they require
+func RequiredPermission(fullMethod string, req interface{}) (auth.Permission, bool) {
+	switch fullMethod {
+	case registerNodeMethod, heartbeatMethod, deregisterMethod:
+		// Node membership events are named by the node they speak for
+		if r, ok := req.(*pb.Event); ok {
+			return auth.Permission{Action: auth.ActionWrite, Resource: auth.ResourceCluster, Name: r.SourceAgent}, true
+		}
+	case describeClusterMethod:
+		return auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceCluster, Name: "topology"}, true
+	case communicateMethod:
+		// Communicate authorizes each frame itself, answering denied ones
+		// with an error frame instead of ending the stream
+		return auth.Permission{}, false
+	}
+
+	switch r := req.(type) {
+	case *pb.ExecuteRequest:
+		if name := r.Metadata[ToolMetadataKey]; name != "" {
+			return auth.Permission{Action: auth.ActionExecute, Resource: auth.ResourceTool, Name: name}, true
+		}
+		return auth.Permission{Action: auth.ActionExecute, Resource: auth.ResourceAgent, Name: r.AgentId}, true
+	case *pb.Event:
+		return auth.Permission{Action: auth.ActionWrite, Resource: auth.ResourceEvent, Name: r.Type}, true
+	case *pb.SyncRequest:
+		// SyncState authorizes each operation's keys itself
+		if fullMethod == syncStateMethod {
+			return auth.Permission{}, false
+		}
+		return auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceState, Name: r.Key}, true
+	}
+
+	// Streams are authorized per event or key as they are sent
+	return auth.Permission{}, false
+}
+
+// mayRead reports whether the caller may receive events of the type
+func (s *AgentServer) mayRead(ctx context.Context, eventType string) bool {
+	s.mu.RLock()
+	az := s.authorizer
+	s.mu.RUnlock()
+
+	if az == nil {
+		return true
+	}
+	return az.Authorize(ctx, auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceEvent, Name: eventType}) == nil
+}
+
+// mayReadState reports whether the caller may watch a state key
+func (s *AgentServer) mayReadState(ctx context.Context, key string) bool {
+	s.mu.RLock()
+	az := s.authorizer
+	s.mu.RUnlock()
+
+	if az == nil {
+		return true
+	}
+	return az.Authorize(ctx, auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceState, Name: key}) == nil
+}
+
+// mayWriteState checks that the caller may write every key of a batch
+func (s *AgentServer) mayWriteState(ctx context.Context, ops []StateOp) error {
+	s.mu.RLock()
+	az := s.authorizer
+	s.mu.RUnlock()
+
+	if az == nil {
+		return nil
+	}
+	for _, op := range ops {
+		if err := az.Authorize(ctx, auth.Permission{Action: auth.ActionWrite, Resource: auth.ResourceState, Name: op.Key}); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return nil
+}
+
+// checkInbox lets callers stream only their own agent's events. Admins may
+// stream any agent's, or those of every agent matching a pattern.
+func (s *AgentServer) checkInbox(ctx context.Context, agentID string) error {
+	s.mu.RLock()
+	az := s.authorizer
+	s.mu.RUnlock()
+
+	if az == nil || az.IsAdmin(ctx) {
+		return nil
+	}
+	p, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, auth.ErrUnauthenticated.Error())
+	}
+	if isPattern(agentID) {
+		return status.Errorf(codes.PermissionDenied, "%s may not subscribe to pattern %s", p.ID, agentID)
+	}
+	if agentID != principalAgent(p) {
+		return status.Errorf(codes.PermissionDenied, "%s may not stream the events of agent %s", p.ID, agentID)
+	}
+	return nil
+}
+
+// principalAgent returns the agent a principal acts as: its agent ID, or
+// its own ID for principals without one
+func principalAgent(p *auth.Principal) string {
+	if agentID := p.Metadata[auth.AgentIDKey]; agentID != "" {
+		return agentID
+	}
+	return p.ID
+}
+
+// checkSource binds the source of events to the caller's agent: events
+// without a source get it, and those naming another agent are rejected.
+// Admins may publish as any agent.
+func (s *AgentServer) checkSource(ctx context.Context, event *pb.Event) error {
+	p, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	s.mu.RLock()
+	az := s.authorizer
+	s.mu.RUnlock()
+	if az != nil && az.IsAdmin(ctx) {
+		return nil
+	}
+
+	agentID := principalAgent(p)
+	if event.SourceAgent == "" {
+		event.SourceAgent = agentID
+	}
+	if event.SourceAgent != agentID {
+		return status.Errorf(codes.PermissionDenied, "%s may not publish as agent %s", p.ID, event.SourceAgent)
+	}
+	return nil
+}
+
+// checkTarget checks that the caller may execute the agent an event is
+// addressed to, since delivering it to the agent's topic acts on its behalf
+func (s *AgentServer) checkTarget(ctx context.Context, event *pb.Event) error {
+	to := event.Metadata[MetadataTo]
+	if to == "" {
+		return nil
+	}
+	s.mu.RLock()
+	az := s.authorizer
+	s.mu.RUnlock()
+
+	if az == nil {
+		return nil
+	}
+	if err := az.Authorize(ctx, auth.Permission{Action: auth.ActionExecute, Resource: auth.ResourceAgent, Name: to}); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// PeerIdentitySource identifies callers by the SPIFFE ID of their verified
+// client certificate, giving each agent of a mutual TLS cluster its own
+// identity without tokens
+type PeerIdentitySource struct {
+	principals map[string]*auth.Principal
+	mu         sync.RWMutex
+}
+
+// NewPeerIdentitySource creates an empty certificate identity source
+func NewPeerIdentitySource() *PeerIdentitySource {
+	return &PeerIdentitySource{principals: make(map[string]*auth.Principal)}
+}
+
+// AddPeer associates a SPIFFE ID with a principal
+func (s *PeerIdentitySource) AddPeer(spiffeID string, p *auth.Principal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.principals[spiffeID] = p
+}
+
+// Identify implements auth.IdentitySource.Identify; credentials are ignored
+func (s *PeerIdentitySource) Identify(ctx context.Context, credentials string) (*auth.Principal, error) {
+	id, ok := PeerSPIFFEID(ctx)
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, exists := s.principals[id]
+	if !exists {
+		return nil, auth.ErrInvalidToken
+	}
+	return p, nil
+}
+
+// tokenCredentials attaches a bearer token to every RPC
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
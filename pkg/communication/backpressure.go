@@ -0,0 +1,312 @@
+package communication
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/observability"
+)
+
+// OverflowPolicy decides what happens when a subscriber's buffer is full
+type OverflowPolicy string
+
+const (
+	// OverflowDrop discards the new message
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowBlock waits for buffer space, up to BlockTimeout
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowEvictOldest discards the oldest buffered message to make room
+	OverflowEvictOldest OverflowPolicy = "evict_oldest"
+)
+
+// Reasons recorded with dead letters
+const (
+	ReasonBufferFull    = "buffer_full"
+	ReasonBlockTimeout  = "block_timeout"
+	ReasonEvicted       = "evicted"
+	ReasonNoSubscribers = "no_subscribers"
+)
+
+// SubscribeOptions configures a subscription's buffering
+type SubscribeOptions struct {
+	// BufferSize is the channel capacity (default 100)
+	BufferSize int
+	// Policy applies when the buffer is full (default OverflowDrop)
+	Policy OverflowPolicy
+	// BlockTimeout bounds OverflowBlock waits; zero waits until the
+	// publisher's context is done
+	BlockTimeout time.Duration
+}
+
+// DeliveryConfig configures how a bus hands messages to subscribers
+type DeliveryConfig struct {
+	// Defaults apply to subscriptions made with Subscribe
+	Defaults SubscribeOptions
+	// DeadLetters receives messages that could not be delivered
+	DeadLetters *DeadLetterQueue
+	// Metrics records bus_messages_dropped and bus_queue_depth
+	Metrics *observability.MetricsCollector
+}
+
+// DeadLetter is a message that could not be delivered
+type DeadLetter struct {
+	Topic     string
+	Message   Message
+	Reason    string
+	Timestamp time.Time
+}
+
+// DeadLetterQueue keeps the most recent undeliverable messages for
+// inspection and replay
+type DeadLetterQueue struct {
+	letters  []DeadLetter
+	capacity int
+	mu       sync.Mutex
+}
+
+// NewDeadLetterQueue creates a queue holding up to capacity letters
+// (default 1000); older letters are discarded first
+func NewDeadLetterQueue(capacity int) *DeadLetterQueue {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &DeadLetterQueue{capacity: capacity}
+}
+
+// Add appends a dead letter
+func (q *DeadLetterQueue) Add(letter DeadLetter) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.letters = append(q.letters, letter)
+	if len(q.letters) > q.capacity {
+		q.letters = q.letters[len(q.letters)-q.capacity:]
+	}
+}
+
+// List returns the queued dead letters, oldest first
+func (q *DeadLetterQueue) List() []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]DeadLetter(nil), q.letters...)
+}
+
+// Len returns the number of queued dead letters
+func (q *DeadLetterQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.letters)
+}
+
+// Drain removes and returns the queued dead letters
+func (q *DeadLetterQueue) Drain() []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letters := q.letters
+	q.letters = nil
+	return letters
+}
+
+// Replay drains the queue and publishes each letter to its topic again.
+// Letters that fail to publish are requeued.
+func (q *DeadLetterQueue) Replay(ctx context.Context, bus MessageBus) error {
+	letters := q.Drain()
+	for i, letter := range letters {
+		if err := bus.Publish(ctx, letter.Topic, letter.Message); err != nil {
+			for _, l := range letters[i:] {
+				q.Add(l)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// DeliveryStats summarizes a bus's delivery outcomes
+type DeliveryStats struct {
+	Delivered    uint64
+	Dropped      uint64
+	DeadLettered uint64
+	// QueueDepth is the number of buffered messages per topic
+	QueueDepth map[string]int
+}
+
+// delivery hands messages to subscriber channels according to their
+// options. Every bus embeds one.
+type delivery struct {
+	config   DeliveryConfig
+	options  map[chan Message]SubscribeOptions
+	topics   map[chan Message]string
+	stats    DeliveryStats
+	counters map[string]*observability.Counter
+	mu       sync.Mutex
+}
+
+func newDelivery(config DeliveryConfig) *delivery {
+	if config.Defaults.BufferSize <= 0 {
+		config.Defaults.BufferSize = 100
+	}
+	if config.Defaults.Policy == "" {
+		config.Defaults.Policy = OverflowDrop
+	}
+	return &delivery{
+		config:   config,
+		options:  make(map[chan Message]SubscribeOptions),
+		topics:   make(map[chan Message]string),
+		counters: make(map[string]*observability.Counter),
+	}
+}
+
+// channel creates a subscriber channel for topic, filling unset options
+// from the defaults
+func (d *delivery) channel(topic string, opts SubscribeOptions) chan Message {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = d.config.Defaults.BufferSize
+	}
+	if opts.Policy == "" {
+		opts.Policy = d.config.Defaults.Policy
+		if opts.BlockTimeout == 0 {
+			opts.BlockTimeout = d.config.Defaults.BlockTimeout
+		}
+	}
+	ch := make(chan Message, opts.BufferSize)
+
+	d.mu.Lock()
+	d.options[ch] = opts
+	d.topics[ch] = topic
+	d.mu.Unlock()
+	return ch
+}
+
+// release forgets an unsubscribed channel
+func (d *delivery) release(ch chan Message) {
+	d.mu.Lock()
+	delete(d.options, ch)
+	delete(d.topics, ch)
+	d.mu.Unlock()
+}
+
+// deliver hands msg to each subscriber, applying its overflow policy
+func (d *delivery) deliver(ctx context.Context, topic string, subscribers []chan Message, msg Message) {
+	if len(subscribers) == 0 {
+		d.deadLetter(topic, msg, ReasonNoSubscribers, false)
+		return
+	}
+	for _, ch := range subscribers {
+		d.mu.Lock()
+		opts, exists := d.options[ch]
+		d.mu.Unlock()
+		if !exists {
+			opts = d.config.Defaults
+		}
+
+		select {
+		case ch <- msg:
+			d.delivered()
+			continue
+		default:
+		}
+
+		switch opts.Policy {
+		case OverflowBlock:
+			d.block(ctx, topic, ch, msg, opts.BlockTimeout)
+		case OverflowEvictOldest:
+			select {
+			case evicted := <-ch:
+				d.deadLetter(topic, evicted, ReasonEvicted, true)
+			default:
+			}
+			select {
+			case ch <- msg:
+				d.delivered()
+			default:
+				d.deadLetter(topic, msg, ReasonBufferFull, true)
+			}
+		default:
+			d.deadLetter(topic, msg, ReasonBufferFull, true)
+		}
+	}
+}
+
+// block waits for buffer space until timeout or ctx is done
+func (d *delivery) block(ctx context.Context, topic string, ch chan Message, msg Message, timeout time.Duration) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case ch <- msg:
+		d.delivered()
+	case <-expired:
+		d.deadLetter(topic, msg, ReasonBlockTimeout, true)
+	case <-ctx.Done():
+		d.deadLetter(topic, msg, ReasonBlockTimeout, true)
+	}
+}
+
+func (d *delivery) delivered() {
+	d.mu.Lock()
+	d.stats.Delivered++
+	d.mu.Unlock()
+}
+
+// deadLetter records an undeliverable message; dropped reports whether a
+// subscriber missed it, as opposed to it having no subscribers at all
+func (d *delivery) deadLetter(topic string, msg Message, reason string, dropped bool) {
+	d.mu.Lock()
+	if dropped {
+		d.stats.Dropped++
+	}
+	var counter *observability.Counter
+	if d.config.Metrics != nil && dropped {
+		key := topic + "\x00" + reason
+		if counter = d.counters[key]; counter == nil {
+			counter = d.config.Metrics.NewCounter("bus_messages_dropped", map[string]string{"topic": topic, "reason": reason})
+			d.counters[key] = counter
+		}
+	}
+	if d.config.DeadLetters != nil {
+		d.stats.DeadLettered++
+	}
+	d.mu.Unlock()
+
+	if counter != nil {
+		counter.Inc()
+	}
+	if d.config.DeadLetters != nil {
+		d.config.DeadLetters.Add(DeadLetter{Topic: topic, Message: msg, Reason: reason, Timestamp: time.Now()})
+	}
+}
+
+// Stats returns delivery counts and current queue depths
+func (d *delivery) Stats() DeliveryStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.stats
+	stats.QueueDepth = make(map[string]int)
+	for ch, topic := range d.topics {
+		stats.QueueDepth[topic] += len(ch)
+	}
+	return stats
+}
+
+// ReportMetrics records the current queue depth of every topic as a
+// bus_queue_depth gauge. Call it periodically, e.g. from a health poller.
+func (d *delivery) ReportMetrics(ctx context.Context) {
+	if d.config.Metrics == nil {
+		return
+	}
+	for topic, depth := range d.Stats().QueueDepth {
+		d.config.Metrics.RecordMetric(ctx, observability.Metric{
+			Name:   "bus_queue_depth",
+			Type:   observability.GaugeMetric,
+			Value:  float64(depth),
+			Labels: map[string]string{"topic": topic},
+		})
+	}
+}
@@ -2,48 +2,194 @@ package communication
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/user/modulox/pkg/communication/interceptors"
 	pb "github.com/user/modulox/pkg/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-// AgentClient provides a high-level client for agent communication
+// AgentClient provides a high-level client for agent communication. The
+// connection is re-established with backoff after failures, RPCs wait for
+// it to become ready, and event streams are resubscribed.
 type AgentClient struct {
 	conn   *grpc.ClientConn
 	client pb.AgentServiceClient
 	agentID string
+	config  ClientConfig
+	cancel  context.CancelFunc
+}
+
+// ClientConfig contains configuration for an agent client
+type ClientConfig struct {
+	Address string
+	AgentID string
+	// TLS secures the connection; nil connects without transport security
+	TLS *TLSConfig
+	// Token is sent as a bearer token with every RPC, e.g. an API key or a
+	// JWT identifying this agent
+	Token string
+
+	// Keepalive sends pings on idle connections every KeepaliveTime and
+	// closes the connection if no reply arrives within KeepaliveTimeout
+	// (defaults 30s and 10s). Servers must permit the ping rate, see
+	// AgentServer.EnableKeepalive.
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// MinBackoff and MaxBackoff bound the delay between reconnection and
+	// resubscription attempts (defaults 1s and 30s)
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// FailFast makes RPCs fail immediately while disconnected instead of
+	// waiting for the connection to be re-established
+	FailFast bool
+
+	// OnStateChange is called whenever the connection state changes
+	OnStateChange func(state connectivity.State)
+
+	// Interceptors traces, measures, retries, and bounds every call
+	Interceptors *interceptors.Config
 }
 
 // NewAgentClient creates a new agent client
 func NewAgentClient(address, agentID string) (*AgentClient, error) {
-	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	return NewAgentClientWithConfig(ClientConfig{Address: address, AgentID: agentID})
+}
+
+// NewAgentClientWithConfig creates a new agent client from a config
+func NewAgentClientWithConfig(config ClientConfig) (*AgentClient, error) {
+	transport := grpc.WithInsecure()
+	if config.TLS != nil {
+		creds, err := config.TLS.ClientCredentials()
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure TLS: %w", err)
+		}
+		transport = grpc.WithTransportCredentials(creds)
+	}
+
+	if config.KeepaliveTime <= 0 {
+		config.KeepaliveTime = 30 * time.Second
+	}
+	if config.KeepaliveTimeout <= 0 {
+		config.KeepaliveTimeout = 10 * time.Second
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 30 * time.Second
+	}
+
+	opts := []grpc.DialOption{
+		transport,
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                config.KeepaliveTime,
+			Timeout:             config.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  config.MinBackoff,
+				Multiplier: backoff.DefaultConfig.Multiplier,
+				Jitter:     backoff.DefaultConfig.Jitter,
+				MaxDelay:   config.MaxBackoff,
+			},
+			MinConnectTimeout: 20 * time.Second,
+		}),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(!config.FailFast)),
+	}
+	if config.Interceptors != nil {
+		opts = append(opts, interceptors.DialOptions(*config.Interceptors)...)
+	}
+	if config.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{token: config.Token, secure: config.TLS != nil}))
+	}
+
+	conn, err := grpc.Dial(config.Address, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 
-	return &AgentClient{
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &AgentClient{
 		conn:    conn,
 		client:  pb.NewAgentServiceClient(conn),
-		agentID: agentID,
-	}, nil
+		agentID: config.AgentID,
+		config:  config,
+		cancel:  cancel,
+	}
+	if config.OnStateChange != nil {
+		go c.watchState(ctx)
+	}
+	return c, nil
 }
 
 // Close closes the client connection
 func (c *AgentClient) Close() error {
+	c.cancel()
 	return c.conn.Close()
 }
 
+// State returns the current connection state
+func (c *AgentClient) State() connectivity.State {
+	return c.conn.GetState()
+}
+
+// watchState reports connection state changes until the client is closed
+func (c *AgentClient) watchState(ctx context.Context) {
+	state := c.conn.GetState()
+	c.config.OnStateChange(state)
+	for c.conn.WaitForStateChange(ctx, state) {
+		state = c.conn.GetState()
+		c.config.OnStateChange(state)
+		if state == connectivity.Shutdown {
+			return
+		}
+	}
+}
+
+// retryDelay returns the delay before the given retry attempt, with jitter
+func (c *AgentClient) retryDelay(attempt int) time.Duration {
+	delay := c.config.MinBackoff
+	for i := 0; i < attempt && delay < c.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.config.MaxBackoff {
+		delay = c.config.MaxBackoff
+	}
+	// Spread reconnecting clients over [delay/2, delay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
 // ExecuteTask sends a task execution request
 func (c *AgentClient) ExecuteTask(ctx context.Context, task string, metadata map[string]string) (string, error) {
+	return c.ExecuteAgentTask(ctx, c.agentID, task, metadata)
+}
+
+// ExecuteAgentTask sends a task execution request for another agent, such
+// as one hosted by a remote node
+func (c *AgentClient) ExecuteAgentTask(ctx context.Context, agentID, task string, metadata map[string]string) (string, error) {
 	req := &pb.ExecuteRequest{
-		AgentId:  c.agentID,
+		AgentId:  agentID,
 		Task:     task,
 		Metadata: metadata,
 	}
 
-	resp, err := c.client.Execute(ctx, req)
+	resp, err := c.client.Execute(outgoingTenant(ctx), req)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute task: %w", err)
 	}
@@ -51,30 +197,74 @@ func (c *AgentClient) ExecuteTask(ctx context.Context, task string, metadata map
 	return resp.Result, nil
 }
 
-// StreamEvents subscribes to agent events
+// StreamEvents subscribes to agent events. If the stream breaks, for
+// example because the server restarted, it is resubscribed with backoff;
+// the channel is closed only once ctx is done or the server rejects the
+// subscription. Event types may be wildcard patterns such as "workflow.*".
 func (c *AgentClient) StreamEvents(ctx context.Context, eventTypes []string) (<-chan *pb.Event, error) {
+	return c.StreamEventsFiltered(ctx, MessageFilter{Types: eventTypes})
+}
+
+// StreamEventsFiltered subscribes to the agent events passing the filter.
+// Filtering happens on the server, so dropped events never cross the wire.
+func (c *AgentClient) StreamEventsFiltered(ctx context.Context, filter MessageFilter) (<-chan *pb.Event, error) {
 	req := &pb.EventRequest{
 		AgentId:    c.agentID,
-		EventTypes: eventTypes,
+		EventTypes: filter.Types,
 	}
+	ctx = outgoingFilter(ctx, filter.Metadata)
 
-	stream, err := c.client.StreamEvents(ctx, req)
+	stream, err := c.client.StreamEvents(outgoingTenant(ctx), req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stream events: %w", err)
 	}
+	// The server sends headers once it has subscribed
+	if _, err := stream.Header(); err != nil {
+		return nil, fmt.Errorf("failed to stream events: %w", err)
+	}
 
 	events := make(chan *pb.Event, 100)
 	go func() {
 		defer close(events)
+		attempt := 0
 		for {
-			event, err := stream.Recv()
-			if err != nil {
-				return
+			var err error
+			for {
+				var event *pb.Event
+				if event, err = stream.Recv(); err != nil {
+					break
+				}
+				attempt = 0
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
 			}
-			select {
-			case events <- event:
-			case <-ctx.Done():
-				return
+
+			// Resubscribe until the stream is re-established, unless the
+			// server ended it for good
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				if !retryable(err) {
+					fmt.Fprintf(os.Stderr, "Event stream for %s closed: %v\n", c.agentID, err)
+					return
+				}
+				select {
+				case <-time.After(c.retryDelay(attempt)):
+				case <-ctx.Done():
+					return
+				}
+				attempt++
+
+				if stream, err = c.client.StreamEvents(outgoingTenant(ctx), req); err != nil {
+					continue
+				}
+				if _, err = stream.Header(); err == nil {
+					break
+				}
 			}
 		}
 	}()
@@ -82,6 +272,15 @@ func (c *AgentClient) StreamEvents(ctx context.Context, eventTypes []string) (<-
 	return events, nil
 }
 
+// retryable reports whether an RPC error may succeed on a new attempt
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
 // PublishEvent publishes an event
 func (c *AgentClient) PublishEvent(ctx context.Context, eventType, payload string, metadata map[string]string) error {
 	event := &pb.Event{
@@ -92,7 +291,7 @@ func (c *AgentClient) PublishEvent(ctx context.Context, eventType, payload strin
 		Metadata:    metadata,
 	}
 
-	resp, err := c.client.PublishEvent(ctx, event)
+	resp, err := c.client.PublishEvent(outgoingTenant(ctx), event)
 	if err != nil {
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
@@ -104,6 +303,69 @@ func (c *AgentClient) PublishEvent(ctx context.Context, eventType, payload strin
 	return nil
 }
 
+// Request sends an event to agent "to" and waits for its reply, which the
+// recipient sends with ReplyEvent. The wait ends at the context deadline, or
+// after DefaultRequestTimeout if there is none.
+func (c *AgentClient) Request(ctx context.Context, to, eventType, payload string, metadata map[string]string) (*pb.Event, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultRequestTimeout)
+		defer cancel()
+	}
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	id := fmt.Sprintf("req-%d", time.Now().UnixNano())
+	replies, err := c.StreamEventsFiltered(streamCtx, MessageFilter{
+		Metadata: map[string]string{MetadataCorrelationID: id},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	md := make(map[string]string, len(metadata)+3)
+	for k, v := range metadata {
+		md[k] = v
+	}
+	md[MetadataTo] = to
+	md[MetadataReplyTo] = c.agentID
+	md[MetadataCorrelationID] = id
+	if err := c.PublishEvent(ctx, eventType, payload, md); err != nil {
+		return nil, err
+	}
+
+	select {
+	case reply, ok := <-replies:
+		if ok {
+			return reply, nil
+		}
+	case <-ctx.Done():
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, ErrRequestTimeout
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, fmt.Errorf("event stream closed before the reply arrived")
+}
+
+// ReplyEvent answers an event sent with Request
+func (c *AgentClient) ReplyEvent(ctx context.Context, request *pb.Event, eventType, payload string, metadata map[string]string) error {
+	replyTo := request.Metadata[MetadataReplyTo]
+	if replyTo == "" {
+		return ErrNoReplyTo
+	}
+
+	md := make(map[string]string, len(metadata)+2)
+	for k, v := range metadata {
+		md[k] = v
+	}
+	md[MetadataTo] = replyTo
+	md[MetadataCorrelationID] = request.Metadata[MetadataCorrelationID]
+	return c.PublishEvent(ctx, eventType, payload, md)
+}
+
 // SyncState synchronizes state with the server
 func (c *AgentClient) SyncState(ctx context.Context, key, value string) (int64, error) {
 	req := &pb.SyncRequest{
@@ -112,7 +374,7 @@ func (c *AgentClient) SyncState(ctx context.Context, key, value string) (int64,
 		Value:   value,
 	}
 
-	resp, err := c.client.SyncState(ctx, req)
+	resp, err := c.client.SyncState(outgoingTenant(ctx), req)
 	if err != nil {
 		return 0, fmt.Errorf("failed to sync state: %w", err)
 	}
@@ -123,3 +385,148 @@ func (c *AgentClient) SyncState(ctx context.Context, key, value string) (int64,
 
 	return resp.Version, nil
 }
+
+// WatchState streams changes to a key, or to every key with a prefix when
+// key ends in "*", starting with the current values. Deleted keys arrive
+// with Deleted set, and expired ones also with Expired. The channel closes when ctx is done or the stream fails.
+func (c *AgentClient) WatchState(ctx context.Context, key string) (<-chan StateChange, error) {
+	req := &pb.SyncRequest{AgentId: c.agentID, Key: key}
+	stream, err := c.client.WatchState(outgoingTenant(ctx), req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch state: %w", err)
+	}
+
+	changes := make(chan StateChange, 100)
+	go func() {
+		defer close(changes)
+		for {
+			update, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			change := StateChange{
+				Key:     update.Key,
+				Entry:   StateEntry{Value: update.Value, Version: update.Version},
+				Deleted: update.Kind == pb.StateEvent_DELETED || update.Kind == pb.StateEvent_EXPIRED,
+				Expired: update.Kind == pb.StateEvent_EXPIRED,
+			}
+			if update.UpdatedAt != 0 {
+				change.Entry.UpdatedAt = time.Unix(0, update.UpdatedAt)
+			}
+			if update.ExpiresAt != 0 {
+				change.Entry.ExpiresAt = time.Unix(0, update.ExpiresAt)
+			}
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// CompareAndSetState writes a value only if the key is at the expected
+// version; version 0 creates the key only if it does not exist. A ttl above
+// zero expires the key. Conflicts fail with ErrVersionConflict.
+func (c *AgentClient) CompareAndSetState(ctx context.Context, key, value string, expected int64, ttl time.Duration) (int64, error) {
+	req := &pb.SyncRequest{AgentId: c.agentID, Key: key, Value: value, Version: expected}
+	return c.syncState(ctx, req, OpCompareAndSet, ttl)
+}
+
+// SetStateTTL writes a value that expires after ttl
+func (c *AgentClient) SetStateTTL(ctx context.Context, key, value string, ttl time.Duration) (int64, error) {
+	req := &pb.SyncRequest{AgentId: c.agentID, Key: key, Value: value}
+	return c.syncState(ctx, req, OpSet, ttl)
+}
+
+// IncrementState atomically adds delta to an integer value and returns the
+// new value
+func (c *AgentClient) IncrementState(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.IncrementStateTTL(ctx, key, delta, 0)
+}
+
+// IncrementStateTTL increments a value like IncrementState. A value the
+// increment creates expires after ttl; existing values keep their expiry.
+func (c *AgentClient) IncrementStateTTL(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	req := &pb.SyncRequest{AgentId: c.agentID, Key: key, Value: strconv.FormatInt(delta, 10)}
+	var header metadata.MD
+	if _, err := c.syncState(ctx, req, OpIncrement, ttl, grpc.Header(&header)); err != nil {
+		return 0, err
+	}
+	values := header.Get(StateValueHeader)
+	if len(values) == 0 {
+		return 0, fmt.Errorf("failed to sync state: missing incremented value")
+	}
+	return strconv.ParseInt(values[0], 10, 64)
+}
+
+// DeleteState removes a key
+func (c *AgentClient) DeleteState(ctx context.Context, key string) error {
+	req := &pb.SyncRequest{AgentId: c.agentID, Key: key}
+	_, err := c.syncState(ctx, req, OpDelete, 0)
+	return err
+}
+
+// ListState returns the keys starting with prefix, e.g. a namespace built
+// with NamespaceKey
+func (c *AgentClient) ListState(ctx context.Context, prefix string) ([]string, error) {
+	ctx = metadata.AppendToOutgoingContext(outgoingTenant(ctx), StateOpHeader, string(stateOpList))
+	resp, err := c.client.SyncState(ctx, &pb.SyncRequest{AgentId: c.agentID, Key: prefix})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state: %w", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("failed to list state: %s", resp.Error)
+	}
+	return resp.Keys, nil
+}
+
+// DeleteStatePrefix deletes every key starting with prefix, such as a
+// finished workflow's namespace, returning how many were removed
+func (c *AgentClient) DeleteStatePrefix(ctx context.Context, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, fmt.Errorf("refusing to delete all state: prefix is empty")
+	}
+	req := &pb.SyncRequest{AgentId: c.agentID, Key: prefix}
+	n, err := c.syncState(ctx, req, stateOpDeletePrefix, 0)
+	return int(n), err
+}
+
+// BatchState applies operations atomically, returning the version of the
+// last one
+func (c *AgentClient) BatchState(ctx context.Context, ops []StateOp) (int64, error) {
+	data, err := json.Marshal(ops)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode batch: %w", err)
+	}
+	req := &pb.SyncRequest{AgentId: c.agentID, Value: string(data)}
+	return c.syncState(ctx, req, stateOpBatch, 0)
+}
+
+// syncState sends a SyncState request for a state operation
+func (c *AgentClient) syncState(ctx context.Context, req *pb.SyncRequest, op StateOpType, ttl time.Duration, opts ...grpc.CallOption) (int64, error) {
+	ctx = metadata.AppendToOutgoingContext(outgoingTenant(ctx), StateOpHeader, string(op))
+	if ttl > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, StateTTLHeader, ttl.String())
+	}
+
+	var trailer metadata.MD
+	resp, err := c.client.SyncState(ctx, req, append(opts, grpc.Trailer(&trailer))...)
+	if status.Code(err) == codes.Aborted {
+		// Restore the sentinel so callers can retry conflicts with errors.Is
+		var version int64
+		if values := trailer.Get(StateVersionHeader); len(values) > 0 {
+			version, _ = strconv.ParseInt(values[0], 10, 64)
+		}
+		detail := strings.TrimPrefix(status.Convert(err).Message(), ErrVersionConflict.Error()+": ")
+		return version, fmt.Errorf("%w: %s", ErrVersionConflict, detail)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync state: %w", err)
+	}
+	if !resp.Success {
+		return 0, fmt.Errorf("failed to sync state: %s", resp.Error)
+	}
+	return resp.Version, nil
+}
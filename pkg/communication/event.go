@@ -28,7 +28,8 @@ func NewEventSystem() *EventSystem {
 	}
 }
 
-// RegisterHandler adds an event handler for a specific event type
+// RegisterHandler adds an event handler for an event type or a wildcard
+// pattern such as "workflow.*"
 func (es *EventSystem) RegisterHandler(eventType string, handler EventHandler) {
 	es.mu.Lock()
 	defer es.mu.Unlock()
@@ -38,7 +39,12 @@ func (es *EventSystem) RegisterHandler(eventType string, handler EventHandler) {
 // EmitEvent broadcasts an event to all registered handlers
 func (es *EventSystem) EmitEvent(ctx context.Context, event Event) error {
 	es.mu.RLock()
-	handlers := es.handlers[event.Type]
+	var handlers []EventHandler
+	for pattern, registered := range es.handlers {
+		if MatchTopic(pattern, event.Type) {
+			handlers = append(handlers, registered...)
+		}
+	}
 	es.mu.RUnlock()
 
 	var wg sync.WaitGroup
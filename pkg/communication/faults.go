@@ -0,0 +1,36 @@
+package communication
+
+import (
+	"context"
+
+	"github.com/user/modulox/pkg/reliability"
+)
+
+// FaultyBus wraps a MessageBus and injects the faults drawn by a
+// reliability.FaultInjector into publishes, with targets "bus." followed by
+// the topic. Dropped messages are discarded while Publish reports success,
+// as when a broker loses them.
+type FaultyBus struct {
+	MessageBus
+	faults *reliability.FaultInjector
+}
+
+// NewFaultyBus creates a message bus injecting faults into inner
+func NewFaultyBus(inner MessageBus, faults *reliability.FaultInjector) *FaultyBus {
+	return &FaultyBus{MessageBus: inner, faults: faults}
+}
+
+// Publish implements MessageBus.Publish
+func (b *FaultyBus) Publish(ctx context.Context, topic string, msg Message) error {
+	fault := b.faults.Inject("bus." + topic)
+	if err := fault.Wait(ctx); err != nil {
+		return err
+	}
+	if fault.Drop {
+		return nil
+	}
+	if fault.Err != nil {
+		return fault.Err
+	}
+	return b.MessageBus.Publish(ctx, topic, msg)
+}
@@ -0,0 +1,170 @@
+package communication
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// EventFilterHeader is the gRPC metadata key carrying key=value metadata
+// filters for StreamEvents
+const EventFilterHeader = "x-event-filter"
+
+// MatchTopic reports whether a topic matches a subscription pattern. Topics
+// are dot-separated tokens; "*" matches exactly one token and a trailing ">"
+// matches one or more tokens, so "workflow.*" matches "workflow.start" and
+// "workflow.>" also matches "workflow.step.done". Patterns without
+// wildcards match only the identical topic.
+func MatchTopic(pattern, topic string) bool {
+	if pattern == topic {
+		return true
+	}
+	if !isPattern(pattern) {
+		return false
+	}
+
+	patternTokens := strings.Split(pattern, ".")
+	topicTokens := strings.Split(topic, ".")
+	for i, token := range patternTokens {
+		if token == ">" && i == len(patternTokens)-1 {
+			return len(topicTokens) > i
+		}
+		if i >= len(topicTokens) {
+			return false
+		}
+		if token != "*" && token != topicTokens[i] {
+			return false
+		}
+	}
+	return len(topicTokens) == len(patternTokens)
+}
+
+// isPattern reports whether a topic contains wildcard tokens
+func isPattern(topic string) bool {
+	for _, token := range strings.Split(topic, ".") {
+		if token == "*" || token == ">" {
+			return true
+		}
+	}
+	return false
+}
+
+// patternRegexp converts subscription patterns into an anchored regular
+// expression matching the same topics
+func patternRegexp(prefix string, patterns []string) string {
+	alternatives := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		tokens := strings.Split(prefix+pattern, ".")
+		for i, token := range tokens {
+			switch token {
+			case "*":
+				tokens[i] = `[^.]+`
+			case ">":
+				tokens[i] = `.+`
+			default:
+				tokens[i] = regexp.QuoteMeta(token)
+			}
+		}
+		alternatives = append(alternatives, strings.Join(tokens, `\.`))
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
+
+// MessageFilter selects messages by type and metadata. Empty fields match
+// every message.
+type MessageFilter struct {
+	// Types are message type patterns, matched with MatchTopic
+	Types []string
+	// Metadata values must equal the message's metadata values
+	Metadata map[string]string
+}
+
+// Matches reports whether the message passes the filter
+func (f MessageFilter) Matches(msg Message) bool {
+	if len(f.Types) > 0 {
+		matched := false
+		for _, pattern := range f.Types {
+			if MatchTopic(pattern, msg.Type) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for k, v := range f.Metadata {
+		value, exists := msg.Metadata[k]
+		if !exists || fmt.Sprint(value) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// SubscribeFiltered subscribes to a topic or pattern and forwards only the
+// messages passing the filter. The returned function unsubscribes.
+func SubscribeFiltered(bus MessageBus, topic string, filter MessageFilter) (<-chan Message, func()) {
+	upstream := bus.Subscribe(topic)
+	filtered := make(chan Message, cap(upstream))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(filtered)
+		for {
+			select {
+			case msg := <-upstream:
+				if !filter.Matches(msg) {
+					continue
+				}
+				select {
+				case filtered <- msg:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var closed bool
+	return filtered, func() {
+		if closed {
+			return
+		}
+		closed = true
+		bus.Unsubscribe(topic, upstream)
+		close(done)
+	}
+}
+
+// outgoingFilter attaches metadata filters to an outgoing StreamEvents call
+func outgoingFilter(ctx context.Context, filter map[string]string) context.Context {
+	for k, v := range filter {
+		ctx = metadata.AppendToOutgoingContext(ctx, EventFilterHeader, k+"="+v)
+	}
+	return ctx
+}
+
+// incomingFilter reads the metadata filters of a StreamEvents call
+func incomingFilter(ctx context.Context) map[string]string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	values := md.Get(EventFilterHeader)
+	if len(values) == 0 {
+		return nil
+	}
+	filter := make(map[string]string, len(values))
+	for _, value := range values {
+		if k, v, ok := strings.Cut(value, "="); ok {
+			filter[k] = v
+		}
+	}
+	return filter
+}
@@ -0,0 +1,223 @@
+package communication
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message addresses understood by Router.Send
+const (
+	// BroadcastAddress delivers a message to every registered inbox
+	BroadcastAddress = "*"
+	// GroupPrefix addresses the members of a group, e.g. "group:reviewers"
+	GroupPrefix = "group:"
+)
+
+// Bus topics backing broadcast and group delivery
+const (
+	broadcastTopic = "_broadcast"
+	groupTopic     = "_group."
+)
+
+// AddressTopic maps a message address to the bus topic delivering it. An
+// agent's inbox is the topic named by its ID.
+func AddressTopic(to string) string {
+	switch {
+	case to == BroadcastAddress:
+		return broadcastTopic
+	case strings.HasPrefix(to, GroupPrefix):
+		return groupTopic + strings.TrimPrefix(to, GroupPrefix)
+	default:
+		return to
+	}
+}
+
+// Router delivers addressed messages to agent inboxes over a MessageBus.
+// Messages are sent to an agent ID, to BroadcastAddress, or to a group
+// ("group:<name>"). Because delivery goes through bus topics, agents
+// registered with routers in other processes sharing the bus are reached too.
+type Router struct {
+	bus     MessageBus
+	inboxes map[string]*inbox
+	mu      sync.RWMutex
+}
+
+// inbox merges an agent's direct, broadcast, and group subscriptions
+type inbox struct {
+	ch   chan Message
+	subs map[string]*subscription
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// subscription is a bus subscription forwarded into an inbox until stop
+// is closed
+type subscription struct {
+	ch   chan Message
+	stop chan struct{}
+}
+
+// NewRouter creates a router delivering over bus
+func NewRouter(bus MessageBus) *Router {
+	return &Router{
+		bus:     bus,
+		inboxes: make(map[string]*inbox),
+	}
+}
+
+// RegisterInbox returns the channel receiving messages addressed to the
+// agent, including broadcasts. Registering an agent again returns its
+// existing inbox.
+func (r *Router) RegisterInbox(agentID string) <-chan Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if in, exists := r.inboxes[agentID]; exists {
+		return in.ch
+	}
+	in := &inbox{
+		ch:   make(chan Message, 100),
+		subs: make(map[string]*subscription),
+		stop: make(chan struct{}),
+	}
+	r.inboxes[agentID] = in
+	r.forward(in, agentID)
+	r.forward(in, broadcastTopic)
+	return in.ch
+}
+
+// UnregisterInbox stops delivery to the agent and closes its inbox
+func (r *Router) UnregisterInbox(agentID string) {
+	r.mu.Lock()
+	in, exists := r.inboxes[agentID]
+	if exists {
+		delete(r.inboxes, agentID)
+		for topic, sub := range in.subs {
+			r.bus.Unsubscribe(topic, sub.ch)
+		}
+	}
+	r.mu.Unlock()
+
+	if exists {
+		close(in.stop)
+		in.wg.Wait()
+		close(in.ch)
+	}
+}
+
+// JoinGroup adds a registered agent to a group
+func (r *Router) JoinGroup(agentID, group string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	in, exists := r.inboxes[agentID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrInboxNotFound, agentID)
+	}
+	topic := groupTopic + group
+	if _, joined := in.subs[topic]; !joined {
+		r.forward(in, topic)
+	}
+	return nil
+}
+
+// LeaveGroup removes an agent from a group
+func (r *Router) LeaveGroup(agentID, group string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	in, exists := r.inboxes[agentID]
+	if !exists {
+		return
+	}
+	topic := groupTopic + group
+	if sub, joined := in.subs[topic]; joined {
+		delete(in.subs, topic)
+		r.bus.Unsubscribe(topic, sub.ch)
+		close(sub.stop)
+	}
+}
+
+// Groups returns the groups an agent registered with this router belongs to
+func (r *Router) Groups(agentID string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	in, exists := r.inboxes[agentID]
+	if !exists {
+		return nil
+	}
+	var groups []string
+	for topic := range in.subs {
+		if strings.HasPrefix(topic, groupTopic) {
+			groups = append(groups, strings.TrimPrefix(topic, groupTopic))
+		}
+	}
+	return groups
+}
+
+// Send delivers a message to the address in msg.To
+func (r *Router) Send(ctx context.Context, msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	if msg.ID == "" {
+		msg.ID = fmt.Sprintf("msg-%d", time.Now().UnixNano())
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	return r.bus.Publish(ctx, AddressTopic(msg.To), msg)
+}
+
+// Multicast delivers a copy of the message to each recipient address
+func (r *Router) Multicast(ctx context.Context, recipients []string, msg Message) error {
+	for _, to := range recipients {
+		msg.To = to
+		msg.ID = ""
+		if err := r.Send(ctx, msg); err != nil {
+			return fmt.Errorf("failed to send to %s: %w", to, err)
+		}
+	}
+	return nil
+}
+
+// forward subscribes to a topic and copies its messages into the inbox.
+// The caller holds r.mu.
+func (r *Router) forward(in *inbox, topic string) {
+	sub := &subscription{ch: r.bus.Subscribe(topic), stop: make(chan struct{})}
+	in.subs[topic] = sub
+	in.wg.Add(1)
+	go func() {
+		defer in.wg.Done()
+		for {
+			select {
+			case msg := <-sub.ch:
+				select {
+				case in.ch <- msg:
+				case <-in.stop:
+					return
+				case <-sub.stop:
+					return
+				}
+			case <-in.stop:
+				return
+			case <-sub.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Error types
+type RouteError string
+
+func (e RouteError) Error() string { return string(e) }
+
+const (
+	ErrNoRecipient   = RouteError("message has no recipient")
+	ErrInboxNotFound = RouteError("inbox not registered")
+)
@@ -0,0 +1,101 @@
+// Package interceptors provides gRPC interceptors that trace, measure,
+// retry, and bound every AgentService call. Install them on a server with
+// ServerOptions and on a client with DialOptions.
+package interceptors
+
+import (
+	"time"
+
+	"github.com/user/modulox/pkg/observability"
+	"github.com/user/modulox/pkg/reliability"
+	"google.golang.org/grpc"
+)
+
+// Trace context headers propagated from clients to servers
+const (
+	TraceIDHeader = "x-trace-id"
+	SpanIDHeader  = "x-span-id"
+)
+
+// Config selects the interceptors to install; nil fields are skipped
+type Config struct {
+	// Tracer records a span per call, continuing the caller's trace
+	Tracer *observability.Tracer
+	// Metrics records call counts and latency histograms
+	Metrics *observability.MetricsCollector
+	// Retry retries unary calls failing with transient codes (clients only)
+	Retry *reliability.RetryConfig
+	// RetryMethods are the full names of the methods Retry applies to
+	// (default IdempotentMethods)
+	RetryMethods []string
+	// Breaker fails unary calls fast while the server keeps failing
+	// (clients only)
+	Breaker *reliability.CircuitBreaker
+	// Faults injects latency, errors, and drops into every attempt, for
+	// resilience testing (clients only)
+	Faults *reliability.FaultInjector
+	// Timeout bounds unary calls that have no deadline. Clients apply it
+	// across all retries; servers apply it to requests that arrive without
+	// one.
+	Timeout time.Duration
+}
+
+// ServerOptions returns the server options installing the configured
+// interceptors, e.g. for AgentServer.AddServerOptions
+func ServerOptions(config Config) []grpc.ServerOption {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	if config.Tracer != nil {
+		unary = append(unary, UnaryServerTracing(config.Tracer))
+		stream = append(stream, StreamServerTracing(config.Tracer))
+	}
+	if config.Metrics != nil {
+		unary = append(unary, UnaryServerMetrics(config.Metrics))
+		stream = append(stream, StreamServerMetrics(config.Metrics))
+	}
+	if config.Timeout > 0 {
+		unary = append(unary, UnaryServerTimeout(config.Timeout))
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
+
+// DialOptions returns the dial options installing the configured
+// interceptors. Tracing and metrics cover the whole call, the timeout
+// bounds all attempts, the breaker sees the result after retries, and
+// faults are injected into each attempt.
+func DialOptions(config Config) []grpc.DialOption {
+	var unary []grpc.UnaryClientInterceptor
+	var stream []grpc.StreamClientInterceptor
+	if config.Tracer != nil {
+		unary = append(unary, UnaryClientTracing(config.Tracer))
+		stream = append(stream, StreamClientTracing(config.Tracer))
+	}
+	if config.Metrics != nil {
+		unary = append(unary, UnaryClientMetrics(config.Metrics))
+		stream = append(stream, StreamClientMetrics(config.Metrics))
+	}
+	if config.Timeout > 0 {
+		unary = append(unary, UnaryClientTimeout(config.Timeout))
+	}
+	if config.Breaker != nil {
+		unary = append(unary, UnaryClientCircuitBreaker(config.Breaker))
+	}
+	if config.Retry != nil {
+		methods := config.RetryMethods
+		if methods == nil {
+			methods = IdempotentMethods
+		}
+		unary = append(unary, UnaryClientRetry(*config.Retry, methods))
+	}
+	if config.Faults != nil {
+		unary = append(unary, UnaryClientFaults(config.Faults))
+		stream = append(stream, StreamClientFaults(config.Faults))
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+}
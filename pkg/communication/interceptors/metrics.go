@@ -0,0 +1,96 @@
+package interceptors
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// rpcMetrics records a requests counter and a duration histogram per
+// method and status code, e.g. rpc_server_requests and
+// rpc_server_duration_seconds
+type rpcMetrics struct {
+	mc       *observability.MetricsCollector
+	prefix   string
+	counters map[string]*observability.Counter
+	mu       sync.Mutex
+}
+
+func newRPCMetrics(mc *observability.MetricsCollector, prefix string) *rpcMetrics {
+	return &rpcMetrics{mc: mc, prefix: prefix, counters: make(map[string]*observability.Counter)}
+}
+
+// record counts a finished call and observes its duration
+func (m *rpcMetrics) record(method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	labels := map[string]string{"method": method, "code": code}
+
+	// Counters accumulate in the instance, so reuse one per series
+	key := method + "\x00" + code
+	m.mu.Lock()
+	counter := m.counters[key]
+	if counter == nil {
+		counter = m.mc.NewCounter(m.prefix+"_requests", labels)
+		m.counters[key] = counter
+	}
+	m.mu.Unlock()
+
+	counter.Inc()
+	m.mc.NewHistogram(m.prefix+"_duration_seconds", labels, observability.HistogramConfig{}).ObserveDuration(time.Since(start))
+}
+
+// UnaryServerMetrics records rpc_server_requests and
+// rpc_server_duration_seconds for unary calls
+func UnaryServerMetrics(mc *observability.MetricsCollector) grpc.UnaryServerInterceptor {
+	m := newRPCMetrics(mc, "rpc_server")
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.record(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerMetrics records rpc_server_requests and
+// rpc_server_duration_seconds for streams, measured until the handler
+// returns
+func StreamServerMetrics(mc *observability.MetricsCollector) grpc.StreamServerInterceptor {
+	m := newRPCMetrics(mc, "rpc_server")
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.record(info.FullMethod, start, err)
+		return err
+	}
+}
+
+// UnaryClientMetrics records rpc_client_requests and
+// rpc_client_duration_seconds for unary calls, including retries
+func UnaryClientMetrics(mc *observability.MetricsCollector) grpc.UnaryClientInterceptor {
+	m := newRPCMetrics(mc, "rpc_client")
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.record(method, start, err)
+		return err
+	}
+}
+
+// StreamClientMetrics records rpc_client_requests and
+// rpc_client_duration_seconds for streams, measured until the stream ends
+func StreamClientMetrics(mc *observability.MetricsCollector) grpc.StreamClientInterceptor {
+	m := newRPCMetrics(mc, "rpc_client")
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			m.record(method, start, err)
+			return nil, err
+		}
+		return newFinishStream(ctx, cs, func(err error) { m.record(method, start, err) }), nil
+	}
+}
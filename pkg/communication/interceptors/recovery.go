@@ -0,0 +1,44 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerRecovery turns a panicking handler into an Internal error, so
+// one bad request cannot take down the server
+func UnaryServerRecovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRecovery turns a panicking stream handler into an Internal
+// error
+func StreamServerRecovery() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recovered reports a panic and returns the error sent to the client
+func recovered(method string, r interface{}) error {
+	fmt.Fprintf(os.Stderr, "Panic in %s: %v\n%s", method, r, debug.Stack())
+	return status.Error(codes.Internal, "internal error")
+}
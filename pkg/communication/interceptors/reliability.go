@@ -0,0 +1,164 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/reliability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errTransient marks attempts that may be retried
+var errTransient = errors.New("transient RPC failure")
+
+// Transient reports whether a call failed before the server could act on
+// it, so it may be retried
+func Transient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// serverFailure reports whether an error reflects on the server's health
+// rather than the request
+func serverFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// IdempotentMethods are the AgentService methods that are safe to repeat.
+// Execute, PublishEvent, and SyncState may have taken effect before a
+// transient failure is reported, so they are not retried.
+var IdempotentMethods = []string{
+	"/modulox.v1.AgentService/RegisterNode",
+	"/modulox.v1.AgentService/Heartbeat",
+	"/modulox.v1.AgentService/Deregister",
+	"/modulox.v1.AgentService/DescribeCluster",
+}
+
+// UnaryClientRetry retries calls to methods, given by full method name,
+// failing with transient codes using config's backoff. Other methods are
+// invoked once. The last attempt's error is returned unchanged.
+func UnaryClientRetry(config reliability.RetryConfig, methods []string) grpc.UnaryClientInterceptor {
+	config.RetryableErrors = []error{errTransient}
+	idempotent := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		idempotent[m] = true
+	}
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !idempotent[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		var last error
+		err := reliability.Retry(ctx, func() error {
+			last = invoker(ctx, method, req, reply, cc, opts...)
+			if last != nil && Transient(last) {
+				return fmt.Errorf("%w: %v", errTransient, last)
+			}
+			return last
+		}, config)
+		if err != nil {
+			return last
+		}
+		return nil
+	}
+}
+
+// UnaryClientCircuitBreaker fails unary calls with Unavailable while the
+// breaker is open. Only server failures count against the breaker.
+func UnaryClientCircuitBreaker(cb *reliability.CircuitBreaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		done, err := cb.Allow()
+		if err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if serverFailure(err) {
+			done(err)
+		} else {
+			done(nil)
+		}
+		return err
+	}
+}
+
+// UnaryClientTimeout applies timeout to unary calls made without a
+// deadline. gRPC propagates the deadline to the server.
+func UnaryClientTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryServerTimeout applies timeout to requests that arrive without a
+// deadline, so handlers and the calls they make downstream are bounded
+func UnaryServerTimeout(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// faultError converts an injected fault into a status error: drops surface
+// as the deadline expiring, or as Unavailable without a deadline, and
+// injected errors as Unavailable unless they already carry a status
+func faultError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+// faultTarget names the fault injection target of an RPC, "rpc." followed
+// by the method name without its service, e.g. "rpc.SendMessage"
+func faultTarget(method string) string {
+	return "rpc." + method[strings.LastIndex(method, "/")+1:]
+}
+
+// UnaryClientFaults injects the faults drawn by faults into unary calls,
+// before they are sent
+func UnaryClientFaults(faults *reliability.FaultInjector) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := faults.Inject(faultTarget(method)).Apply(ctx); err != nil {
+			return faultError(err)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientFaults injects the faults drawn by faults into opening
+// streams
+func StreamClientFaults(faults *reliability.FaultInjector) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := faults.Inject(faultTarget(method)).Apply(ctx); err != nil {
+			return nil, faultError(err)
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,145 @@
+package interceptors
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/user/modulox/pkg/observability"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerTracing records each unary call as a span, continuing the
+// trace propagated by the client
+func UnaryServerTracing(tracer *observability.Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		span, ctx := startServerSpan(ctx, tracer, info.FullMethod)
+		resp, err := handler(ctx, req)
+		endSpan(tracer, span, err)
+		return resp, err
+	}
+}
+
+// StreamServerTracing records each stream as a span lasting until the
+// handler returns
+func StreamServerTracing(tracer *observability.Tracer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		span, ctx := startServerSpan(ss.Context(), tracer, info.FullMethod)
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		endSpan(tracer, span, err)
+		return err
+	}
+}
+
+// UnaryClientTracing records each unary call as a span and propagates it
+// to the server
+func UnaryClientTracing(tracer *observability.Tracer) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		span, ctx := startClientSpan(ctx, tracer, method)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		endSpan(tracer, span, err)
+		return err
+	}
+}
+
+// StreamClientTracing records each stream as a span lasting until the
+// stream ends, and propagates it to the server
+func StreamClientTracing(tracer *observability.Tracer) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		span, ctx := startClientSpan(ctx, tracer, method)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			endSpan(tracer, span, err)
+			return nil, err
+		}
+		return newFinishStream(ctx, cs, func(err error) { endSpan(tracer, span, err) }), nil
+	}
+}
+
+// startServerSpan starts a span whose parent is the span named by the
+// incoming trace headers, if any
+func startServerSpan(ctx context.Context, tracer *observability.Tracer, method string) (*observability.Span, context.Context) {
+	opts := []observability.SpanOption{
+		observability.WithTags(map[string]string{"rpc.method": method, "span.kind": "server"}),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		traceIDs, spanIDs := md.Get(TraceIDHeader), md.Get(SpanIDHeader)
+		if len(traceIDs) > 0 && len(spanIDs) > 0 {
+			opts = append(opts, observability.WithParent(&observability.Span{TraceID: traceIDs[0], SpanID: spanIDs[0]}))
+		}
+	}
+	return tracer.StartSpan(ctx, method, opts...)
+}
+
+// startClientSpan starts a span and adds its trace headers to the
+// outgoing metadata
+func startClientSpan(ctx context.Context, tracer *observability.Tracer, method string) (*observability.Span, context.Context) {
+	span, ctx := tracer.StartSpan(ctx, method,
+		observability.WithTags(map[string]string{"rpc.method": method, "span.kind": "client"}))
+	if span != nil {
+		ctx = metadata.AppendToOutgoingContext(ctx, TraceIDHeader, span.TraceID, SpanIDHeader, span.SpanID)
+	}
+	return span, ctx
+}
+
+// endSpan tags the span with the call's status code and ends it
+func endSpan(tracer *observability.Tracer, span *observability.Span, err error) {
+	if span == nil {
+		return
+	}
+	tracer.SetTag(span, "rpc.code", status.Code(err).String())
+	if err != nil {
+		tracer.SetError(span, err)
+	}
+	tracer.EndSpan(span)
+}
+
+// contextStream overrides the stream context to carry the span
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// finishStream calls finish once when the client stream ends: when a
+// receive fails, io.EOF marking a clean end, or the caller cancels ctx
+type finishStream struct {
+	grpc.ClientStream
+	finish func(error)
+	once   sync.Once
+}
+
+func newFinishStream(ctx context.Context, cs grpc.ClientStream, finish func(error)) *finishStream {
+	s := &finishStream{ClientStream: cs, finish: finish}
+	go func() {
+		// A stream ending on its own is reported by RecvMsg
+		select {
+		case <-ctx.Done():
+		case <-cs.Context().Done():
+		}
+		if err := ctx.Err(); err != nil {
+			s.done(status.FromContextError(err).Err())
+		}
+	}()
+	return s
+}
+
+func (s *finishStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.done(err)
+	}
+	return err
+}
+
+func (s *finishStream) done(err error) {
+	if err == io.EOF {
+		err = nil
+	}
+	s.once.Do(func() { s.finish(err) })
+}
@@ -0,0 +1,318 @@
+package communication
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/observability"
+)
+
+// kafkaContentType is the Kafka REST Proxy v2 JSON embedded format
+const kafkaContentType = "application/vnd.kafka.json.v2+json"
+
+// KafkaConfig contains configuration for a Kafka message bus
+type KafkaConfig struct {
+	// RESTURL is the base URL of a Kafka REST Proxy (v2 API),
+	// e.g. http://localhost:8082
+	RESTURL string
+	// Headers are added to every request, e.g. for authentication
+	Headers map[string]string
+	// TopicPrefix is prepended to topics, e.g. "modulox."
+	TopicPrefix string
+	// Group is the consumer group. The default is unique to the bus, so
+	// every process receives every message; processes sharing a group
+	// split the messages between them.
+	Group string
+	// PollInterval is the delay between empty polls (default 500ms)
+	PollInterval time.Duration
+	HTTPClient   *http.Client
+	// Delivery configures subscriber buffering and dead letters
+	Delivery DeliveryConfig
+	// Logger receives polling errors (default stderr)
+	Logger *observability.Logger
+}
+
+// KafkaBus is a MessageBus backed by Kafka through the Kafka REST Proxy.
+// Messages are JSON encoded record values. Subscribers receive messages
+// published after the bus subscribed to the topic. Wildcard subscriptions
+// become a topic_pattern subscription, so they also match topics created
+// later.
+type KafkaBus struct {
+	*delivery
+	config   KafkaConfig
+	client   *http.Client
+	consumer string
+	subs     map[string][]chan Message
+	// changed signals the poll loop to update the consumer subscription
+	changed chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	mu      sync.RWMutex
+}
+
+// NewKafkaBus creates a consumer instance on the REST proxy and starts polling
+func NewKafkaBus(config KafkaConfig) (*KafkaBus, error) {
+	if config.RESTURL == "" {
+		return nil, fmt.Errorf("Kafka REST proxy URL is required")
+	}
+	config.RESTURL = strings.TrimRight(config.RESTURL, "/")
+	if config.Group == "" {
+		config.Group = fmt.Sprintf("modulox-%d", time.Now().UnixNano())
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 500 * time.Millisecond
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	b := &KafkaBus{
+		delivery: newDelivery(config.Delivery),
+		config:   config,
+		client:   client,
+		subs:     make(map[string][]chan Message),
+		changed:  make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	var instance struct {
+		BaseURI string `json:"base_uri"`
+	}
+	err := b.do(context.Background(), http.MethodPost, config.RESTURL+"/consumers/"+config.Group, map[string]interface{}{
+		"name":              fmt.Sprintf("consumer-%d", time.Now().UnixNano()),
+		"format":            "json",
+		"auto.offset.reset": "latest",
+	}, &instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	b.consumer = instance.BaseURI
+
+	go b.poll()
+	return b, nil
+}
+
+// Subscribe implements MessageBus.Subscribe
+func (b *KafkaBus) Subscribe(topic string) chan Message {
+	return b.SubscribeWithOptions(topic, SubscribeOptions{})
+}
+
+// SubscribeWithOptions implements MessageBus.SubscribeWithOptions
+func (b *KafkaBus) SubscribeWithOptions(topic string, opts SubscribeOptions) chan Message {
+	ch := b.channel(topic, opts)
+
+	b.mu.Lock()
+	b.subs[topic] = append(b.subs[topic], ch)
+	b.mu.Unlock()
+
+	b.notify()
+	return ch
+}
+
+// Unsubscribe implements MessageBus.Unsubscribe
+func (b *KafkaBus) Unsubscribe(topic string, ch chan Message) {
+	b.mu.Lock()
+	subscribers := b.subs[topic]
+	for i, sub := range subscribers {
+		if sub == ch {
+			b.subs[topic] = append(subscribers[:i:i], subscribers[i+1:]...)
+			break
+		}
+	}
+	removed := len(b.subs[topic]) == 0
+	if removed {
+		delete(b.subs, topic)
+	}
+	b.mu.Unlock()
+	b.release(ch)
+
+	if removed {
+		b.notify()
+	}
+}
+
+// Publish implements MessageBus.Publish
+func (b *KafkaBus) Publish(ctx context.Context, topic string, msg Message) error {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	msg = stampTenant(ctx, msg)
+	body := map[string]interface{}{
+		"records": []map[string]interface{}{{"value": msg}},
+	}
+	if err := b.do(ctx, http.MethodPost, b.config.RESTURL+"/topics/"+b.topic(topic), body, nil); err != nil {
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	return nil
+}
+
+// Close implements MessageBus.Close, deleting the consumer instance
+func (b *KafkaBus) Close() error {
+	b.once.Do(func() { close(b.stop) })
+	<-b.done
+	return b.do(context.Background(), http.MethodDelete, b.consumer, nil, nil)
+}
+
+func (b *KafkaBus) notify() {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+// topic maps a bus topic to a Kafka topic
+func (b *KafkaBus) topic(topic string) string {
+	return b.config.TopicPrefix + topic
+}
+
+// poll fetches records and dispatches them to subscribers
+func (b *KafkaBus) poll() {
+	defer close(b.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-b.stop
+		cancel()
+	}()
+
+	subscribed := false
+	for {
+		select {
+		case <-b.changed:
+			var err error
+			if subscribed, err = b.updateSubscription(ctx); err != nil {
+				logBusError(b.config.Logger, "Error updating Kafka subscription", err)
+				// Retry on the next iteration
+				b.notify()
+			}
+		case <-b.stop:
+			return
+		default:
+		}
+
+		received := 0
+		if subscribed {
+			n, err := b.fetch(ctx)
+			if err != nil && ctx.Err() == nil {
+				logBusError(b.config.Logger, "Error polling Kafka", err)
+			}
+			received = n
+		}
+		if received > 0 {
+			continue
+		}
+
+		select {
+		case <-time.After(b.config.PollInterval):
+		case <-b.changed:
+			b.notify()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// updateSubscription replaces the consumer's topics with the subscribed
+// topics, reporting whether any remain
+func (b *KafkaBus) updateSubscription(ctx context.Context) (bool, error) {
+	b.mu.RLock()
+	topics := make([]string, 0, len(b.subs))
+	patterns := false
+	for topic := range b.subs {
+		topics = append(topics, topic)
+		patterns = patterns || isPattern(topic)
+	}
+	b.mu.RUnlock()
+
+	if len(topics) == 0 {
+		return false, b.do(ctx, http.MethodDelete, b.consumer+"/subscription", nil, nil)
+	}
+	// The proxy accepts either a topic list or a single pattern
+	if patterns {
+		body := map[string]interface{}{"topic_pattern": patternRegexp(b.config.TopicPrefix, topics)}
+		return true, b.do(ctx, http.MethodPost, b.consumer+"/subscription", body, nil)
+	}
+	for i, topic := range topics {
+		topics[i] = b.topic(topic)
+	}
+	return true, b.do(ctx, http.MethodPost, b.consumer+"/subscription", map[string]interface{}{"topics": topics}, nil)
+}
+
+// fetch polls the consumer once, returning the number of records received
+func (b *KafkaBus) fetch(ctx context.Context) (int, error) {
+	var records []struct {
+		Topic string          `json:"topic"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := b.do(ctx, http.MethodGet, b.consumer+"/records", nil, &records); err != nil {
+		return 0, err
+	}
+
+	for _, r := range records {
+		var msg Message
+		if err := json.Unmarshal(r.Value, &msg); err != nil {
+			msg = Message{Content: string(r.Value), Timestamp: time.Now()}
+		}
+		topic := strings.TrimPrefix(r.Topic, b.config.TopicPrefix)
+
+		b.mu.RLock()
+		var subscribers []chan Message
+		for pattern, chans := range b.subs {
+			if MatchTopic(pattern, topic) {
+				subscribers = append(subscribers, chans...)
+			}
+		}
+		b.mu.RUnlock()
+		b.deliver(ctx, topic, subscribers, msg)
+	}
+	return len(records), nil
+}
+
+// do sends a REST proxy request, decoding the response into out if set
+func (b *KafkaBus) do(ctx context.Context, method, url string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", kafkaContentType)
+	}
+	req.Header.Set("Accept", kafkaContentType)
+	for k, v := range b.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("Kafka REST proxy returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,235 @@
+// Package mcp exposes ModuloX tools and agents to Model Context Protocol
+// clients such as IDEs over stdio or HTTP+SSE.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/tools"
+)
+
+// AgentToolPrefix prefixes the MCP tool names of exposed agents
+const AgentToolPrefix = "agent_"
+
+// ServerConfig contains configuration for the MCP server
+type ServerConfig struct {
+	Name     string
+	Version  string
+	Registry *tools.ToolRegistry
+	// Executor runs tool calls; defaults to a SafeExecutor over Registry
+	Executor *tools.SafeExecutor
+	// Authorizer, when set, checks tool and agent calls against the
+	// principal attached to the context, and is installed on Executor.
+	// The SSE transport requires one.
+	Authorizer *auth.Authorizer
+}
+
+// Server implements the MCP tools capability on top of a ToolRegistry
+type Server struct {
+	config ServerConfig
+	agents map[string]agent.Agent
+	mu     sync.RWMutex
+}
+
+// NewServer creates a new MCP server
+func NewServer(config ServerConfig) *Server {
+	if config.Name == "" {
+		config.Name = "modulox"
+	}
+	if config.Version == "" {
+		config.Version = "v1"
+	}
+	if config.Registry == nil {
+		config.Registry = tools.NewToolRegistry()
+	}
+	if config.Executor == nil {
+		config.Executor = tools.NewSafeExecutor(config.Registry)
+	}
+	if config.Authorizer != nil {
+		config.Executor.SetAuthorizer(config.Authorizer)
+	}
+	return &Server{
+		config: config,
+		agents: make(map[string]agent.Agent),
+	}
+}
+
+// RegisterAgent exposes an agent as the MCP tool "agent_<name>"
+func (s *Server) RegisterAgent(name string, a agent.Agent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.agents[name]; exists {
+		return fmt.Errorf("agent already registered: %s", name)
+	}
+	s.agents[name] = a
+	return nil
+}
+
+// Handle processes a single JSON-RPC message and returns the encoded
+// response, or nil for notifications
+func (s *Server) Handle(ctx context.Context, data []byte) []byte {
+	var req jsonRPCRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return encodeResponse(nil, nil, &RPCError{Code: CodeParseError, Message: err.Error()})
+	}
+
+	result, rpcErr := s.dispatch(ctx, req)
+	if len(req.ID) == 0 {
+		// Notifications never receive a response
+		return nil
+	}
+	return encodeResponse(req.ID, result, rpcErr)
+}
+
+// dispatch routes a request to its method handler
+func (s *Server) dispatch(ctx context.Context, req jsonRPCRequest) (interface{}, *RPCError) {
+	switch req.Method {
+	case MethodInitialize:
+		return InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities: map[string]interface{}{
+				"tools": map[string]interface{}{},
+			},
+			ServerInfo: ServerInfo{Name: s.config.Name, Version: s.config.Version},
+		}, nil
+
+	case MethodInitialized:
+		return nil, nil
+
+	case MethodPing:
+		return map[string]interface{}{}, nil
+
+	case MethodListTools:
+		return map[string]interface{}{"tools": s.listTools()}, nil
+
+	case MethodCallTool:
+		var params CallToolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return s.callTool(ctx, params)
+
+	default:
+		return nil, &RPCError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+}
+
+// listTools returns registry tools followed by exposed agents
+func (s *Server) listTools() []Tool {
+	definitions := s.config.Registry.ToolDefinitions()
+	list := make([]Tool, 0, len(definitions))
+	for _, d := range definitions {
+		list = append(list, Tool{
+			Name:        d.Name,
+			Description: d.Description,
+			InputSchema: d.Parameters,
+		})
+	}
+
+	s.mu.RLock()
+	names := make([]string, 0, len(s.agents))
+	for name := range s.agents {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		list = append(list, Tool{
+			Name:        AgentToolPrefix + name,
+			Description: fmt.Sprintf("Ask the %s agent to handle a request", name),
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"input": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"input"},
+			},
+		})
+	}
+	return list
+}
+
+// callTool runs a registry tool or agent. Execution failures are reported
+// as error results so the client's model can see them.
+func (s *Server) callTool(ctx context.Context, params CallToolParams) (interface{}, *RPCError) {
+	arguments := string(params.Arguments)
+	if arguments == "" {
+		arguments = "{}"
+	}
+
+	if strings.HasPrefix(params.Name, AgentToolPrefix) {
+		s.mu.RLock()
+		a, exists := s.agents[strings.TrimPrefix(params.Name, AgentToolPrefix)]
+		s.mu.RUnlock()
+
+		if exists {
+			if az := s.config.Authorizer; az != nil {
+				perm := auth.Permission{Action: auth.ActionExecute, Resource: auth.ResourceAgent, Name: strings.TrimPrefix(params.Name, AgentToolPrefix)}
+				if err := az.Authorize(ctx, perm); err != nil {
+					return toolResult(nil, err), nil
+				}
+			}
+			var args struct {
+				Input string `json:"input"`
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+			}
+			output, err := a.Execute(ctx, args.Input)
+			return toolResult(output, err), nil
+		}
+	}
+
+	input, err := s.config.Registry.DecodeArguments(params.Name, arguments)
+	if err != nil {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	output, err := s.config.Executor.Execute(ctx, params.Name, input)
+	if err != nil {
+		return toolResult(nil, err), nil
+	}
+	return toolResult(output, nil), nil
+}
+
+// toolResult renders a tool output or error as MCP content
+func toolResult(output interface{}, err error) CallToolResult {
+	if err != nil {
+		return CallToolResult{
+			Content: []Content{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}
+	}
+
+	text, ok := output.(string)
+	if !ok {
+		data, err := json.Marshal(output)
+		if err != nil {
+			text = fmt.Sprintf("%v", output)
+		} else {
+			text = string(data)
+		}
+	}
+	return CallToolResult{Content: []Content{{Type: "text", Text: text}}}
+}
+
+func encodeResponse(id json.RawMessage, result interface{}, rpcErr *RPCError) []byte {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	resp := jsonRPCResponse{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		resp.Result = result
+	}
+	data, _ := json.Marshal(resp)
+	return data
+}
@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// SSEHandler serves MCP over the HTTP+SSE transport. Clients open an event
+// stream at <prefix>/sse and post messages to the endpoint it announces.
+// Every request must carry a bearer token; messages are accepted only from
+// the principal that opened the session.
+type SSEHandler struct {
+	server   *Server
+	prefix   string
+	ids      auth.IdentitySource
+	sessions map[string]*sseSession
+	mu       sync.RWMutex
+}
+
+// sseSession is an open event stream
+type sseSession struct {
+	principal string
+	messages  chan []byte
+}
+
+// NewSSEHandler creates an HTTP handler for the server mounted at prefix.
+// Callers are identified by ids and authorized by the server's Authorizer,
+// both of which are required.
+func NewSSEHandler(server *Server, prefix string, ids auth.IdentitySource) (*SSEHandler, error) {
+	if ids == nil || server.config.Authorizer == nil {
+		return nil, fmt.Errorf("MCP over SSE requires an identity source and an authorizer")
+	}
+	return &SSEHandler{
+		server:   server,
+		prefix:   strings.TrimRight(prefix, "/"),
+		ids:      ids,
+		sessions: make(map[string]*sseSession),
+	}, nil
+}
+
+// ServeHTTP implements http.Handler
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		http.Error(w, auth.ErrUnauthenticated.Error(), http.StatusUnauthorized)
+		return
+	}
+	p, err := h.ids.Identify(r.Context(), auth.BearerToken(header))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	ctx := auth.WithPrincipal(r.Context(), p)
+	if p.TenantID != "" {
+		if err := tenant.ValidID(p.TenantID); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx = tenant.WithTenant(ctx, p.TenantID)
+	}
+	r = r.WithContext(ctx)
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == h.prefix+"/sse":
+		h.stream(w, r, p)
+	case r.Method == http.MethodPost && r.URL.Path == h.prefix+"/message":
+		h.message(w, r, p)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// stream holds an SSE connection open and relays responses for its session
+func (h *SSEHandler) stream(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := sessionID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	session := &sseSession{principal: p.ID, messages: make(chan []byte, 16)}
+
+	h.mu.Lock()
+	h.sessions[id] = session
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.sessions, id)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	fmt.Fprintf(w, "event: endpoint\ndata: %s/message?sessionId=%s\n\n", h.prefix, id)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg := <-session.messages:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+			flusher.Flush()
+		}
+	}
+}
+
+// message handles a client message and delivers the response over SSE
+func (h *SSEHandler) message(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	h.mu.RLock()
+	session, exists := h.sessions[r.URL.Query().Get("sessionId")]
+	h.mu.RUnlock()
+
+	// Other principals' sessions are reported as unknown
+	if !exists || session.principal != p.ID {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, 16*1024*1024))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if resp := h.server.Handle(r.Context(), data); resp != nil {
+		select {
+		case session.messages <- resp:
+		case <-r.Context().Done():
+		}
+	}
+}
+
+// sessionID returns an unguessable session ID
+func sessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return "mcp-" + hex.EncodeToString(b), nil
+}
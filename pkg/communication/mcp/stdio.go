@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ServeStdio serves MCP over newline-delimited JSON on the given streams
+// until the input is closed or ctx is canceled
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	writer := bufio.NewWriter(out)
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		resp := s.Handle(ctx, line)
+		if resp == nil {
+			continue
+		}
+		if _, err := writer.Write(append(resp, '\n')); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// ServeStdioProcess serves MCP over the process's standard input and output
+func (s *Server) ServeStdioProcess(ctx context.Context) error {
+	return s.ServeStdio(ctx, os.Stdin, os.Stdout)
+}
@@ -2,8 +2,13 @@ package communication
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"sync"
 	"time"
+
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/observability"
 )
 
 // Message represents a communication unit between agents
@@ -17,44 +22,147 @@ type Message struct {
 	Metadata  map[string]interface{}
 }
 
-// MessageBus handles message routing between agents
-type MessageBus struct {
+// MessageBus routes messages between agents by topic
+type MessageBus interface {
+	// Subscribe registers a subscriber for a topic or a wildcard pattern
+	// such as "workflow.*" (see MatchTopic)
+	Subscribe(topic string) chan Message
+	// SubscribeWithOptions subscribes with its own buffering and overflow
+	// policy
+	SubscribeWithOptions(topic string, opts SubscribeOptions) chan Message
+	// Publish sends a message to all subscribers of a topic, recording the
+	// tenant attached to ctx under MetadataTenant
+	Publish(ctx context.Context, topic string, msg Message) error
+	// Unsubscribe removes a subscriber channel from a topic
+	Unsubscribe(topic string, ch chan Message)
+	// Close releases the bus's connections
+	Close() error
+}
+
+// LocalBus is an in-process MessageBus
+type LocalBus struct {
+	*delivery
 	subscribers map[string][]chan Message
 	mu          sync.RWMutex
 }
 
-// NewMessageBus creates a new message bus instance
-func NewMessageBus() *MessageBus {
-	return &MessageBus{
+// NewMessageBus creates a new in-process message bus
+func NewMessageBus() *LocalBus {
+	return NewLocalBus(DeliveryConfig{})
+}
+
+// NewLocalBus creates an in-process message bus with delivery options
+func NewLocalBus(config DeliveryConfig) *LocalBus {
+	return &LocalBus{
+		delivery:    newDelivery(config),
 		subscribers: make(map[string][]chan Message),
 	}
 }
 
-// Subscribe registers a subscriber for a specific topic
-func (mb *MessageBus) Subscribe(topic string) chan Message {
+// Subscribe registers a subscriber for a topic or pattern
+func (mb *LocalBus) Subscribe(topic string) chan Message {
+	return mb.SubscribeWithOptions(topic, SubscribeOptions{})
+}
+
+// SubscribeWithOptions implements MessageBus.SubscribeWithOptions
+func (mb *LocalBus) SubscribeWithOptions(topic string, opts SubscribeOptions) chan Message {
+	ch := mb.channel(topic, opts)
+
 	mb.mu.Lock()
 	defer mb.mu.Unlock()
-
-	ch := make(chan Message, 100)
 	mb.subscribers[topic] = append(mb.subscribers[topic], ch)
 	return ch
 }
 
 // Publish sends a message to all subscribers of a topic
-func (mb *MessageBus) Publish(ctx context.Context, topic string, msg Message) error {
+func (mb *LocalBus) Publish(ctx context.Context, topic string, msg Message) error {
 	mb.mu.RLock()
-	subscribers := mb.subscribers[topic]
+	var subscribers []chan Message
+	for pattern, chans := range mb.subscribers {
+		if MatchTopic(pattern, topic) {
+			subscribers = append(subscribers, chans...)
+		}
+	}
 	mb.mu.RUnlock()
 
-	for _, ch := range subscribers {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case ch <- msg:
-		default:
-			// Non-blocking send to prevent slow subscribers from blocking publishers
-			continue
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	mb.deliver(ctx, topic, subscribers, stampTenant(ctx, msg))
+	return nil
+}
+
+// Unsubscribe removes a subscriber channel from a topic
+func (mb *LocalBus) Unsubscribe(topic string, ch chan Message) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	subscribers := mb.subscribers[topic]
+	for i, sub := range subscribers {
+		if sub == ch {
+			mb.subscribers[topic] = append(subscribers[:i:i], subscribers[i+1:]...)
+			break
 		}
 	}
+	if len(mb.subscribers[topic]) == 0 {
+		delete(mb.subscribers, topic)
+	}
+	mb.release(ch)
+}
+
+// Close implements MessageBus.Close
+func (mb *LocalBus) Close() error {
 	return nil
 }
+
+// NewMessageBusFromConfig creates the message bus selected by
+// Config.Communication.Bus. Remote buses report background errors to
+// logger, or to stderr when it is nil.
+func NewMessageBusFromConfig(cfg *config.Config, logger *observability.Logger) (MessageBus, error) {
+	bc := cfg.Communication.Bus
+	dc := DeliveryConfig{
+		Defaults: SubscribeOptions{
+			BufferSize:   bc.BufferSize,
+			Policy:       OverflowPolicy(bc.Overflow),
+			BlockTimeout: time.Duration(bc.BlockTimeoutMs) * time.Millisecond,
+		},
+	}
+	if bc.DeadLetters > 0 {
+		dc.DeadLetters = NewDeadLetterQueue(bc.DeadLetters)
+	}
+
+	switch bc.Type {
+	case "", "local":
+		return NewLocalBus(dc), nil
+	case "nats":
+		return NewNATSBus(NATSConfig{
+			URL:           bc.URL,
+			Token:         bc.Token,
+			Name:          cfg.Agent.Name,
+			SubjectPrefix: bc.Prefix,
+			Delivery:      dc,
+			Logger:        logger,
+		})
+	case "kafka":
+		return NewKafkaBus(KafkaConfig{
+			RESTURL:     bc.URL,
+			Headers:     bc.Headers,
+			TopicPrefix: bc.Prefix,
+			Group:       bc.Group,
+			Delivery:    dc,
+			Logger:      logger,
+		})
+	default:
+		return nil, fmt.Errorf("unknown message bus type: %s", bc.Type)
+	}
+}
+
+// logBusError reports a background error of a remote bus to logger, or to
+// stderr without one
+func logBusError(logger *observability.Logger, msg string, err error) {
+	if logger == nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", msg, err)
+		return
+	}
+	logger.Error(context.Background(), msg, map[string]interface{}{"error": err.Error()})
+}
@@ -0,0 +1,149 @@
+package communication
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// NamespaceKey joins namespace segments and a key with "/", e.g.
+// NamespaceKey("workflow", "run-1", "agent_1_result") is
+// "workflow/run-1/agent_1_result". Keys in distinct namespaces never collide,
+// and RBAC name patterns such as "workflow/run-1/*" scope access to one.
+func NamespaceKey(parts ...string) string {
+	return strings.Join(parts, "/")
+}
+
+// StateNamespace is a view of a StateStore restricted to keys under a
+// prefix. Keys passed to and returned by it are relative to the namespace.
+type StateNamespace struct {
+	store  *StateStore
+	prefix string
+}
+
+// Namespace returns a view of the keys under the namespace segments
+func (ss *StateStore) Namespace(parts ...string) *StateNamespace {
+	return &StateNamespace{store: ss, prefix: NamespaceKey(parts...) + "/"}
+}
+
+// WorkflowNamespace returns the namespace of a workflow run
+func (ss *StateStore) WorkflowNamespace(runID string) *StateNamespace {
+	return ss.Namespace("workflow", runID)
+}
+
+// SessionNamespace returns the namespace of a session
+func (ss *StateStore) SessionNamespace(sessionID string) *StateNamespace {
+	return ss.Namespace("session", sessionID)
+}
+
+// TenantNamespace returns the namespace of the tenant attached to the
+// context, the same one AgentServer scopes client keys to
+func (ss *StateStore) TenantNamespace(ctx context.Context) *StateNamespace {
+	return ss.Namespace(tenant.IDFromContext(ctx))
+}
+
+// Namespace returns a nested namespace
+func (n *StateNamespace) Namespace(parts ...string) *StateNamespace {
+	return &StateNamespace{store: n.store, prefix: n.prefix + NamespaceKey(parts...) + "/"}
+}
+
+// Prefix returns the full key prefix of the namespace
+func (n *StateNamespace) Prefix() string {
+	return n.prefix
+}
+
+// Get retrieves a state value
+func (n *StateNamespace) Get(key string) (StateEntry, bool) {
+	return n.store.Get(n.prefix + key)
+}
+
+// Set updates a state value
+func (n *StateNamespace) Set(key string, value interface{}) {
+	n.store.Set(n.prefix+key, value)
+}
+
+// SetTTL updates a state value that expires after ttl
+func (n *StateNamespace) SetTTL(key string, value interface{}, ttl time.Duration) (StateEntry, error) {
+	return n.store.SetTTL(n.prefix+key, value, ttl)
+}
+
+// CompareAndSet updates a state value only if it is at the expected version
+func (n *StateNamespace) CompareAndSet(key string, expected int64, value interface{}, ttl time.Duration) (StateEntry, error) {
+	return n.store.CompareAndSet(n.prefix+key, expected, value, ttl)
+}
+
+// Increment atomically adds delta to an integer value
+func (n *StateNamespace) Increment(key string, delta int64) (StateEntry, error) {
+	return n.store.Increment(n.prefix+key, delta)
+}
+
+// Delete removes a key
+func (n *StateNamespace) Delete(key string) bool {
+	return n.store.Delete(n.prefix + key)
+}
+
+// List returns the keys in the namespace starting with prefix, sorted
+func (n *StateNamespace) List(prefix string) []string {
+	keys := n.store.List(n.prefix + prefix)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, n.prefix)
+	}
+	return keys
+}
+
+// Clear deletes every key in the namespace, returning how many were removed
+func (n *StateNamespace) Clear() int {
+	return n.store.DeletePrefix(n.prefix)
+}
+
+// Watch monitors every key in the namespace. Changes carry full keys.
+func (n *StateNamespace) Watch(ctx context.Context) (<-chan StateChange, error) {
+	return n.store.WatchPrefix(ctx, n.prefix)
+}
+
+// List returns the live keys starting with prefix, sorted
+func (ss *StateStore) List(prefix string) []string {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	var keys []string
+	for key := range ss.states {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if _, exists := ss.current(key); exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DeletePrefix removes every key starting with prefix in one operation,
+// returning how many were removed
+func (ss *StateStore) DeletePrefix(prefix string) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	removed := 0
+	for key := range ss.states {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if entry, exists := ss.current(key); exists {
+			removed++
+			ss.remove(key, entry, false)
+		} else {
+			delete(ss.states, key)
+		}
+	}
+	if err := ss.flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error persisting state deletion of %s: %v\n", prefix, err)
+	}
+	return removed
+}
@@ -0,0 +1,292 @@
+package communication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/user/modulox/pkg/auth"
+	pb "github.com/user/modulox/pkg/pb"
+	"github.com/user/modulox/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Full gRPC method names of the node membership RPCs
+const (
+	registerNodeMethod = "/modulox.v1.AgentService/RegisterNode"
+	heartbeatMethod    = "/modulox.v1.AgentService/Heartbeat"
+	deregisterMethod   = "/modulox.v1.AgentService/Deregister"
+)
+
+// NodeRegistry tracks nodes joining over the RegisterNode, Heartbeat, and
+// Deregister RPCs; *distributed.Cluster satisfies it
+type NodeRegistry interface {
+	RegisterNodeStatus(ctx context.Context, status types.NodeStatus) error
+	// NodeHeartbeat fails with ErrNodeNotRegistered for unknown nodes
+	NodeHeartbeat(ctx context.Context, status types.NodeStatus) error
+	DeregisterNode(ctx context.Context, id string) error
+}
+
+// TaskExecutor runs agent tasks received through Execute;
+// *distributed.Node satisfies it
+type TaskExecutor interface {
+	ExecuteTask(ctx context.Context, agentID string, task string) (string, error)
+}
+
+// SetNodeRegistry lets nodes in other processes join through the server
+func (s *AgentServer) SetNodeRegistry(registry NodeRegistry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes = registry
+}
+
+// SetTaskExecutor runs Execute requests on the executor's agents, e.g. a
+// node's, so a cluster can schedule tasks onto this server
+func (s *AgentServer) SetTaskExecutor(executor TaskExecutor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executor = executor
+}
+
+// RegisterNode implements AgentService.RegisterNode. A node ID belongs to
+// the principal that first registered it until that principal deregisters.
+func (s *AgentServer) RegisterNode(ctx context.Context, event *pb.Event) (*pb.PublishResponse, error) {
+	registry, nodeStatus, err := s.nodeRequest(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = s.incomingTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.claimNode(ctx, nodeStatus.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	// A node that fails to register does not keep its ID reserved
+	err = registry.RegisterNodeStatus(ctx, nodeStatus)
+	if err != nil && claimed {
+		s.mu.Lock()
+		delete(s.nodeOwners, nodeStatus.ID)
+		s.mu.Unlock()
+	}
+	return nodeResponse(err)
+}
+
+// Heartbeat implements AgentService.Heartbeat
+func (s *AgentServer) Heartbeat(ctx context.Context, event *pb.Event) (*pb.PublishResponse, error) {
+	registry, nodeStatus, err := s.nodeRequest(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.claimNode(ctx, nodeStatus.ID, false); err != nil {
+		return nil, err
+	}
+	ctx, err = s.incomingTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return nodeResponse(registry.NodeHeartbeat(ctx, nodeStatus))
+}
+
+// Deregister implements AgentService.Deregister
+func (s *AgentServer) Deregister(ctx context.Context, event *pb.Event) (*pb.PublishResponse, error) {
+	if err := s.checkSource(ctx, event); err != nil {
+		return nil, err
+	}
+	registry, err := s.nodeRegistry()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.claimNode(ctx, event.SourceAgent, false); err != nil {
+		return nil, err
+	}
+	ctx, err = s.incomingTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := nodeResponse(registry.DeregisterNode(ctx, event.SourceAgent))
+	if err == nil && resp.Success {
+		s.mu.Lock()
+		delete(s.nodeOwners, event.SourceAgent)
+		s.mu.Unlock()
+	}
+	return resp, err
+}
+
+// claimNode checks that the caller owns the node, taking ownership of
+// unowned nodes when register is set. It reports whether it took
+// ownership, which the caller gives up again if registration fails.
+func (s *AgentServer) claimNode(ctx context.Context, id string, register bool) (bool, error) {
+	p, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owner, exists := s.nodeOwners[id]
+	switch {
+	case exists && owner != p.ID:
+		return false, status.Errorf(codes.PermissionDenied, "node %s is registered by another principal", id)
+	case !exists && register:
+		if s.nodeOwners == nil {
+			s.nodeOwners = make(map[string]string)
+		}
+		s.nodeOwners[id] = p.ID
+		return true, nil
+	}
+	return false, nil
+}
+
+// nodeRequest checks that the caller speaks for the node and decodes its
+// status from the payload
+func (s *AgentServer) nodeRequest(ctx context.Context, event *pb.Event) (NodeRegistry, types.NodeStatus, error) {
+	var nodeStatus types.NodeStatus
+	if err := s.checkSource(ctx, event); err != nil {
+		return nil, nodeStatus, err
+	}
+	registry, err := s.nodeRegistry()
+	if err != nil {
+		return nil, nodeStatus, err
+	}
+	if err := json.Unmarshal([]byte(event.Payload), &nodeStatus); err != nil {
+		return nil, nodeStatus, status.Errorf(codes.InvalidArgument, "invalid node status: %v", err)
+	}
+	// The node ID is the authenticated source, whatever the payload says
+	nodeStatus.ID = event.SourceAgent
+	if nodeStatus.ID == "" {
+		return nil, nodeStatus, status.Error(codes.InvalidArgument, "node ID is required")
+	}
+	if nodeStatus.Address, err = nodeAddress(ctx, nodeStatus.Address); err != nil {
+		return nil, nodeStatus, err
+	}
+	return registry, nodeStatus, nil
+}
+
+// nodeAddress checks that the address a node advertises reaches the
+// caller, so the cluster never dials, and sends its credentials to, a host
+// the node does not run on. Addresses without a host get the caller's.
+// Nodes must therefore reach the cluster directly rather than through a
+// proxy or NAT.
+func nodeAddress(ctx context.Context, address string) (string, error) {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return address, nil
+	}
+	caller, ok := pr.Addr.(*net.TCPAddr)
+	if !ok {
+		return address, nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "invalid node address %q: %v", address, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		return net.JoinHostPort(caller.IP.String(), port), nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err == nil {
+		for _, ip := range ips {
+			if ip.IP.Equal(caller.IP) || (ip.IP.IsLoopback() && caller.IP.IsLoopback()) {
+				return address, nil
+			}
+		}
+	}
+	return "", status.Errorf(codes.PermissionDenied, "node address %s does not reach the caller at %s", address, caller.IP)
+}
+
+func (s *AgentServer) nodeRegistry() (NodeRegistry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.nodes == nil {
+		return nil, status.Error(codes.Unimplemented, "server does not accept nodes")
+	}
+	return s.nodes, nil
+}
+
+// nodeResponse reports unknown nodes as NOT_FOUND so they register again,
+// and drained nodes as FAILED_PRECONDITION so they stop heartbeating
+func nodeResponse(err error) (*pb.PublishResponse, error) {
+	if errors.Is(err, ErrNodeNotRegistered) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, ErrNodeDrained) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return &pb.PublishResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &pb.PublishResponse{Success: true}, nil
+}
+
+// RegisterNode joins the cluster behind the server as the node in status,
+// which must be the client's agent ID
+func (c *AgentClient) RegisterNode(ctx context.Context, nodeStatus types.NodeStatus) error {
+	return c.nodeCall(ctx, c.client.RegisterNode, nodeStatus)
+}
+
+// Heartbeat reports the node's status. It fails with ErrNodeNotRegistered
+// when the cluster no longer knows the node, e.g. after restarting, and
+// with ErrNodeDrained once the cluster has decommissioned it.
+func (c *AgentClient) Heartbeat(ctx context.Context, nodeStatus types.NodeStatus) error {
+	return c.nodeCall(ctx, c.client.Heartbeat, nodeStatus)
+}
+
+// DeregisterNode removes the client's node from the cluster
+func (c *AgentClient) DeregisterNode(ctx context.Context) error {
+	resp, err := c.client.Deregister(outgoingTenant(ctx), &pb.Event{
+		Type:        "node_deregister",
+		SourceAgent: c.agentID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to deregister node: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("failed to deregister node: %s", resp.Error)
+	}
+	return nil
+}
+
+type nodeRPC func(ctx context.Context, in *pb.Event, opts ...grpc.CallOption) (*pb.PublishResponse, error)
+
+func (c *AgentClient) nodeCall(ctx context.Context, call nodeRPC, nodeStatus types.NodeStatus) error {
+	data, err := json.Marshal(nodeStatus)
+	if err != nil {
+		return fmt.Errorf("failed to encode node status: %w", err)
+	}
+	resp, err := call(outgoingTenant(ctx), &pb.Event{
+		Type:        "node_status",
+		Payload:     string(data),
+		SourceAgent: c.agentID,
+	})
+	switch status.Code(err) {
+	case codes.NotFound:
+		return fmt.Errorf("%w: %s", ErrNodeNotRegistered, c.agentID)
+	case codes.FailedPrecondition:
+		return fmt.Errorf("%w: %s", ErrNodeDrained, c.agentID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to report node status: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("failed to report node status: %s", resp.Error)
+	}
+	return nil
+}
+
+// Error types
+type NodeError string
+
+func (e NodeError) Error() string { return string(e) }
+
+const (
+	ErrNodeNotRegistered = NodeError("node not registered")
+	ErrNodeDrained       = NodeError("node drained from cluster")
+)
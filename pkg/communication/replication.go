@@ -0,0 +1,149 @@
+package communication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	pb "github.com/user/modulox/pkg/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ReplicaHeader marks a WatchState call from a state replica. Replicas
+// receive every tenant's raw entries, so callers need read access to the
+// state key "*", and the primary must have an authorizer.
+const ReplicaHeader = "x-state-replica"
+
+// replicateState streams the whole store to a replica: a snapshot, an
+// end-of-snapshot marker, then every change. Events carry the JSON encoded
+// StateChange in their value. The stream ends if the replica falls behind,
+// and the replica resynchronizes.
+func (s *AgentServer) replicateState(ctx context.Context, stream pb.AgentService_WatchStateServer) error {
+	s.mu.RLock()
+	az, store := s.authorizer, s.stateStore
+	s.mu.RUnlock()
+
+	// Servers without authorization cannot tell replicas from other callers
+	if az == nil || !s.mayReadState(ctx, "*") {
+		return status.Error(codes.PermissionDenied, "state replication requires read access to all state")
+	}
+
+	snapshot, changes := store.subscribe(ctx, &stateWatcher{prefix: true, strict: true}, false)
+	send := func(change StateChange) error {
+		data, err := json.Marshal(change)
+		if err != nil {
+			return fmt.Errorf("failed to encode state change: %w", err)
+		}
+		return stream.Send(&pb.StateEvent{Key: change.Key, Value: string(data), Version: change.Entry.Version})
+	}
+
+	for _, change := range snapshot {
+		if err := send(change); err != nil {
+			return err
+		}
+	}
+	if err := stream.Send(&pb.StateEvent{Kind: pb.StateEvent_SNAPSHOT_END}); err != nil {
+		return err
+	}
+	for change := range changes {
+		if err := send(change); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() == nil {
+		return status.Error(codes.ResourceExhausted, "replica fell behind")
+	}
+	return nil
+}
+
+// StateReplica keeps a StateStore in sync with a primary server's store.
+// After each (re)connection it loads a full snapshot, removing keys the
+// primary no longer has, then applies changes as they happen.
+type StateReplica struct {
+	store  *StateStore
+	client *AgentClient
+}
+
+// NewStateReplica creates a replica of the primary the client connects to
+func NewStateReplica(store *StateStore, primary *AgentClient) *StateReplica {
+	return &StateReplica{store: store, client: primary}
+}
+
+// Run follows the primary until ctx is done, reconnecting with backoff
+func (r *StateReplica) Run(ctx context.Context) error {
+	attempt := 0
+	for {
+		synced, err := r.follow(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if synced {
+			attempt = 0
+		}
+		if !retryable(err) && status.Code(err) != codes.ResourceExhausted {
+			return fmt.Errorf("state replication failed: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "State replication interrupted: %v\n", err)
+
+		select {
+		case <-time.After(r.client.retryDelay(attempt)):
+		case <-ctx.Done():
+			return nil
+		}
+		attempt++
+	}
+}
+
+// follow streams from the primary once, reporting whether the snapshot
+// completed
+func (r *StateReplica) follow(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ctx = metadata.AppendToOutgoingContext(ctx, ReplicaHeader, "true")
+	stream, err := r.client.client.WatchState(ctx, &pb.SyncRequest{AgentId: r.client.agentID})
+	if err != nil {
+		return false, err
+	}
+
+	seen := make(map[string]bool)
+	synced := false
+	for {
+		update, err := stream.Recv()
+		if err != nil {
+			return synced, err
+		}
+
+		if update.Kind == pb.StateEvent_SNAPSHOT_END {
+			// Keys missing from the snapshot were deleted while disconnected
+			for _, key := range r.store.Keys() {
+				if !seen[key] {
+					entry, _ := r.store.Get(key)
+					r.apply(StateChange{Key: key, Entry: entry, Deleted: true})
+				}
+			}
+			seen = nil
+			synced = true
+			continue
+		}
+
+		var change StateChange
+		if err := json.Unmarshal([]byte(update.Value), &change); err != nil {
+			return synced, fmt.Errorf("failed to decode state change: %w", err)
+		}
+		if seen != nil {
+			seen[change.Key] = true
+		}
+		r.apply(change)
+	}
+}
+
+func (r *StateReplica) apply(change StateChange) {
+	if err := r.store.apply(change); err != nil {
+		fmt.Fprintf(os.Stderr, "Error persisting replicated state %s: %v\n", change.Key, err)
+	}
+}
@@ -0,0 +1,104 @@
+package communication
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Metadata keys correlating requests with replies
+const (
+	MetadataTo            = "to"
+	MetadataReplyTo       = "reply_to"
+	MetadataCorrelationID = "correlation_id"
+)
+
+// DefaultRequestTimeout bounds requests whose context has no deadline
+const DefaultRequestTimeout = 30 * time.Second
+
+// Request publishes msg to the topic of agent "to" and waits for the reply
+// correlated with its ID. Responders answer with Reply. The wait ends at the
+// context deadline, or after DefaultRequestTimeout if there is none.
+func Request(ctx context.Context, bus MessageBus, to string, msg Message) (Message, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultRequestTimeout)
+		defer cancel()
+	}
+
+	if msg.ID == "" {
+		msg.ID = fmt.Sprintf("req-%d", time.Now().UnixNano())
+	}
+	msg.To = to
+	metadata := make(map[string]interface{}, len(msg.Metadata)+2)
+	for k, v := range msg.Metadata {
+		metadata[k] = v
+	}
+	// Each request listens on its own inbox so replies need no fan-out
+	inbox := "_inbox." + msg.ID
+	metadata[MetadataReplyTo] = inbox
+	metadata[MetadataCorrelationID] = msg.ID
+	msg.Metadata = metadata
+
+	replies := bus.Subscribe(inbox)
+	defer bus.Unsubscribe(inbox, replies)
+
+	if err := bus.Publish(ctx, to, msg); err != nil {
+		return Message{}, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	for {
+		select {
+		case reply := <-replies:
+			if fmt.Sprint(reply.Metadata[MetadataCorrelationID]) != msg.ID {
+				continue
+			}
+			return reply, nil
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return Message{}, ErrRequestTimeout
+			}
+			return Message{}, ctx.Err()
+		}
+	}
+}
+
+// Reply answers a message sent with Request
+func Reply(ctx context.Context, bus MessageBus, request Message, reply Message) error {
+	replyTo, _ := request.Metadata[MetadataReplyTo].(string)
+	if replyTo == "" {
+		return ErrNoReplyTo
+	}
+
+	if reply.ID == "" {
+		reply.ID = fmt.Sprintf("reply-%d", time.Now().UnixNano())
+	}
+	if reply.To == "" {
+		reply.To = request.From
+	}
+	metadata := make(map[string]interface{}, len(reply.Metadata)+1)
+	for k, v := range reply.Metadata {
+		metadata[k] = v
+	}
+	correlationID, _ := request.Metadata[MetadataCorrelationID].(string)
+	if correlationID == "" {
+		correlationID = request.ID
+	}
+	metadata[MetadataCorrelationID] = correlationID
+	reply.Metadata = metadata
+
+	if err := bus.Publish(ctx, replyTo, reply); err != nil {
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+	return nil
+}
+
+// Error types
+type RequestError string
+
+func (e RequestError) Error() string { return string(e) }
+
+const (
+	ErrRequestTimeout = RequestError("request timed out waiting for a reply")
+	ErrNoReplyTo      = RequestError("message has no reply address")
+)
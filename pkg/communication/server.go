@@ -2,20 +2,45 @@ package communication
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/communication/interceptors"
 	pb "github.com/user/modulox/pkg/pb"
+	"github.com/user/modulox/pkg/tenant"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 // AgentServer implements the gRPC server for agent communication
 type AgentServer struct {
 	pb.UnimplementedAgentServiceServer
-	messageBus *MessageBus
+	messageBus MessageBus
 	eventSys  *EventSystem
 	stateStore *StateStore
+	tools     ToolExecutor
+	options   []grpc.ServerOption
+	stateHooks []StateHook
+	authorizer *auth.Authorizer
+	nodes     NodeRegistry
+	// nodeOwners maps registered node IDs to the principal owning them
+	nodeOwners map[string]string
+	executor  TaskExecutor
+	topology  TopologySource
+	server    *grpc.Server
+	// stopReplica is set while the state store follows a primary
+	stopReplica context.CancelFunc
 	mu        sync.RWMutex
 }
 
@@ -31,6 +56,21 @@ func NewAgentServer() *AgentServer {
 // Execute implements AgentService.Execute
 func (s *AgentServer) Execute(ctx context.Context, req *pb.ExecuteRequest) (*pb.ExecuteResponse, error) {
 	// Forward task to appropriate agent and return response
+	ctx, err := s.incomingTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if name := req.Metadata[ToolMetadataKey]; name != "" {
+		result, err := s.executeTool(ctx, name, req.Task)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute tool: %w", err)
+		}
+		return &pb.ExecuteResponse{
+			Result:   result,
+			Metadata: req.Metadata,
+		}, nil
+	}
+
 	result, err := s.executeTask(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute task: %w", err)
@@ -42,41 +82,147 @@ func (s *AgentServer) Execute(ctx context.Context, req *pb.ExecuteRequest) (*pb.
 	}, nil
 }
 
-// StreamEvents implements AgentService.StreamEvents
+// StreamEvents implements AgentService.StreamEvents. EventTypes may hold
+// wildcard patterns such as "workflow.*"; metadata filters are read from the
+// x-event-filter header. With authorization enabled, AgentId must name the
+// caller's own agent unless the caller is an admin.
 func (s *AgentServer) StreamEvents(req *pb.EventRequest, stream pb.AgentService_StreamEventsServer) error {
+	if err := s.checkInbox(stream.Context(), req.AgentId); err != nil {
+		return err
+	}
+	filter := MessageFilter{
+		Types:    req.EventTypes,
+		Metadata: incomingFilter(stream.Context()),
+	}
+
 	// Create event channel for this agent
-	eventCh := s.messageBus.Subscribe(req.AgentId)
-	defer close(eventCh)
+	s.mu.RLock()
+	bus := s.messageBus
+	s.mu.RUnlock()
+
+	eventCh := bus.Subscribe(req.AgentId)
+	defer bus.Unsubscribe(req.AgentId, eventCh)
+	broadcastCh := bus.Subscribe(broadcastTopic)
+	defer bus.Unsubscribe(broadcastTopic, broadcastCh)
+
+	// Headers tell the client the subscription is live, so replies to
+	// requests sent right after subscribing are not missed
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	send := func(msg Message) error {
+		if !filter.Matches(msg) || !s.mayRead(stream.Context(), msg.Type) {
+			return nil
+		}
+		return stream.Send(messageEvent(msg))
+	}
 
 	for {
 		select {
 		case <-stream.Context().Done():
 			return nil
 		case msg := <-eventCh:
-			event := &pb.Event{
-				Type:        msg.Type,
-				Payload:     msg.Content.(string),
-				SourceAgent: msg.From,
-				Timestamp:   msg.Timestamp.Unix(),
-				Metadata:    msg.Metadata,
+			if err := send(msg); err != nil {
+				return err
 			}
-			if err := stream.Send(event); err != nil {
+		case msg := <-broadcastCh:
+			if err := send(msg); err != nil {
 				return err
 			}
 		}
 	}
 }
 
-// PublishEvent implements AgentService.PublishEvent
-func (s *AgentServer) PublishEvent(ctx context.Context, event *pb.Event) (*pb.PublishResponse, error) {
+// messageEvent converts a bus message to an event
+func messageEvent(msg Message) *pb.Event {
+	return &pb.Event{
+		Type:        msg.Type,
+		Payload:     eventPayload(msg.Content),
+		SourceAgent: msg.From,
+		Timestamp:   msg.Timestamp.Unix(),
+		Metadata:    eventMetadata(msg.Metadata),
+	}
+}
+
+// eventMessage converts a published event to a bus message and the topic it
+// is published to. Addressed events, such as requests and replies, go to the
+// recipient's topic rather than the sender's.
+func eventMessage(event *pb.Event) (string, Message) {
+	md := make(map[string]interface{}, len(event.Metadata))
+	for k, v := range event.Metadata {
+		md[k] = v
+	}
+	timestamp := time.Now()
+	if event.Timestamp > 0 {
+		timestamp = time.Unix(event.Timestamp, 0)
+	}
 	msg := Message{
+		ID:        fmt.Sprintf("event-%d", time.Now().UnixNano()),
 		Type:      event.Type,
 		Content:   event.Payload,
 		From:      event.SourceAgent,
-		Metadata:  event.Metadata,
+		Timestamp: timestamp,
+		Metadata:  md,
 	}
 
-	if err := s.messageBus.Publish(ctx, event.SourceAgent, msg); err != nil {
+	topic := event.SourceAgent
+	if to := event.Metadata[MetadataTo]; to != "" {
+		msg.To = to
+		topic = AddressTopic(to)
+	}
+	return topic, msg
+}
+
+// eventPayload converts message content to an event payload, JSON encoding
+// anything but strings
+func eventPayload(content interface{}) string {
+	switch c := content.(type) {
+	case nil:
+		return ""
+	case string:
+		return c
+	case []byte:
+		return string(c)
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Sprint(content)
+	}
+	return string(data)
+}
+
+// eventMetadata converts message metadata to event metadata
+func eventMetadata(values map[string]interface{}) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	converted := make(map[string]string, len(values))
+	for k, v := range values {
+		if str, ok := v.(string); ok {
+			converted[k] = str
+			continue
+		}
+		converted[k] = fmt.Sprint(v)
+	}
+	return converted
+}
+
+// PublishEvent implements AgentService.PublishEvent
+func (s *AgentServer) PublishEvent(ctx context.Context, event *pb.Event) (*pb.PublishResponse, error) {
+	if err := s.checkSource(ctx, event); err != nil {
+		return nil, err
+	}
+	if err := s.checkTarget(ctx, event); err != nil {
+		return nil, err
+	}
+	topic, msg := eventMessage(event)
+
+	s.mu.RLock()
+	bus := s.messageBus
+	s.mu.RUnlock()
+
+	if err := bus.Publish(ctx, topic, msg); err != nil {
 		return &pb.PublishResponse{
 			Success: false,
 			Error:   err.Error(),
@@ -86,17 +232,307 @@ func (s *AgentServer) PublishEvent(ctx context.Context, event *pb.Event) (*pb.Pu
 	return &pb.PublishResponse{Success: true}, nil
 }
 
-// SyncState implements AgentService.SyncState
+// SyncState implements AgentService.SyncState. The x-state-op header
+// selects the operation (see StateOpType); the default "set" becomes a
+// compare-and-set when the request carries a version; "list" returns the
+// keys starting with the request key that the caller may read, and
+// "delete_prefix" deletes them. x-state-ttl sets a
+// Go duration after which the key expires. A "batch" applies the JSON array
+// of StateOps in the value atomically. Conflicts fail with the current
+// version in the x-state-version trailer and code Aborted, and increments
+// return the new value in the x-state-value response header.
 func (s *AgentServer) SyncState(ctx context.Context, req *pb.SyncRequest) (*pb.SyncResponse, error) {
-	s.stateStore.Set(req.Key, req.Value)
-	
-	entry, _ := s.stateStore.Get(req.Key)
+	s.mu.RLock()
+	replica := s.stopReplica != nil
+	store := s.stateStore
+	s.mu.RUnlock()
+	if replica {
+		return nil, status.Error(codes.FailedPrecondition, "state is read-only on a replica")
+	}
+
+	// State keys are namespaced per tenant
+	ctx, err := s.incomingTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	op, ttl, err := stateOptions(ctx)
+	if err != nil {
+		return &pb.SyncResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	var ops []StateOp
+	switch op {
+	case stateOpList:
+		scopedPrefix := tenant.ScopedKey(ctx, "")
+		var keys []string
+		for _, key := range store.List(tenant.ScopedKey(ctx, req.Key)) {
+			if key = strings.TrimPrefix(key, scopedPrefix); s.mayReadState(ctx, key) {
+				keys = append(keys, key)
+			}
+		}
+		return &pb.SyncResponse{Success: true, Version: int64(len(keys)), Keys: keys}, nil
+	case stateOpDeletePrefix:
+		if req.Key == "" {
+			return &pb.SyncResponse{Success: false, Error: "refusing to delete all state: prefix is empty"}, nil
+		}
+		// Deleting through a batch reports each key to the state hooks
+		scopedPrefix := tenant.ScopedKey(ctx, "")
+		for _, key := range store.List(tenant.ScopedKey(ctx, req.Key)) {
+			ops = append(ops, StateOp{Type: OpDelete, Key: strings.TrimPrefix(key, scopedPrefix)})
+		}
+		if len(ops) == 0 {
+			return &pb.SyncResponse{Success: true}, nil
+		}
+	case stateOpBatch:
+		if err := json.Unmarshal([]byte(req.Value), &ops); err != nil {
+			return &pb.SyncResponse{Success: false, Error: fmt.Sprintf("invalid batch: %v", err)}, nil
+		}
+		if len(ops) == 0 {
+			return &pb.SyncResponse{Success: false, Error: "invalid batch: no operations"}, nil
+		}
+	case OpSet:
+		if req.Version != 0 {
+			op = OpCompareAndSet
+		}
+		ops = []StateOp{{Type: op, Key: req.Key, Value: req.Value, Version: req.Version, TTL: ttl}}
+	case OpCompareAndSet:
+		ops = []StateOp{{Type: op, Key: req.Key, Value: req.Value, Version: req.Version, TTL: ttl}}
+	case OpIncrement:
+		delta := int64(1)
+		if req.Value != "" {
+			if delta, err = strconv.ParseInt(req.Value, 10, 64); err != nil {
+				return &pb.SyncResponse{Success: false, Error: fmt.Sprintf("invalid increment: %q", req.Value)}, nil
+			}
+		}
+		ops = []StateOp{{Type: op, Key: req.Key, Delta: delta, TTL: ttl}}
+	case OpDelete:
+		ops = []StateOp{{Type: op, Key: req.Key}}
+	default:
+		return &pb.SyncResponse{Success: false, Error: fmt.Sprintf("unknown state operation: %s", op)}, nil
+	}
+
+	if err := s.mayWriteState(ctx, ops); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(ops))
+	for i := range ops {
+		keys[i] = ops[i].Key
+		ops[i].Key = tenant.ScopedKey(ctx, ops[i].Key)
+	}
+
+	entries, err := store.Batch(ops)
+	if errors.Is(err, ErrVersionConflict) {
+		if len(ops) == 1 {
+			current, _ := store.Get(ops[0].Key)
+			grpc.SetTrailer(ctx, metadata.Pairs(StateVersionHeader, strconv.FormatInt(current.Version, 10)))
+		}
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	if err != nil {
+		return &pb.SyncResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	s.mu.RLock()
+	hooks := s.stateHooks
+	s.mu.RUnlock()
+	for i, entry := range entries {
+		if ops[i].Type == OpDelete {
+			entry.Value = nil
+		}
+		for _, hook := range hooks {
+			hook(ctx, keys[i], entry)
+		}
+	}
+
+	last := entries[len(entries)-1]
+	switch op {
+	case OpIncrement:
+		grpc.SetHeader(ctx, metadata.Pairs(StateValueHeader, fmt.Sprint(last.Value)))
+	case stateOpDeletePrefix:
+		return &pb.SyncResponse{Success: true, Version: int64(len(entries))}, nil
+	}
 	return &pb.SyncResponse{
 		Success: true,
-		Version: entry.Version,
+		Version: last.Version,
 	}, nil
 }
 
+// WatchState implements AgentService.WatchState
+func (s *AgentServer) WatchState(req *pb.SyncRequest, stream pb.AgentService_WatchStateServer) error {
+	if md, ok := metadata.FromIncomingContext(stream.Context()); ok && len(md.Get(ReplicaHeader)) > 0 {
+		return s.replicateState(stream.Context(), stream)
+	}
+	ctx, err := s.incomingTenant(stream.Context())
+	if err != nil {
+		return err
+	}
+	tenantID := tenant.IDFromContext(ctx)
+
+	key, prefix := req.Key, false
+	if strings.HasSuffix(key, "*") {
+		key, prefix = strings.TrimSuffix(key, "*"), true
+	}
+	s.mu.RLock()
+	store := s.stateStore
+	s.mu.RUnlock()
+
+	// The watch ends with the stream's context
+	changes := store.watch(ctx, tenant.ScopedKey(ctx, key), prefix)
+
+	for change := range changes {
+		key, ok := tenant.Unscope(tenantID, change.Key)
+		if !ok || !s.mayReadState(ctx, key) {
+			continue
+		}
+		if err := stream.Send(stateEvent(key, change)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stateEvent converts a change to the key to its WatchState event
+func stateEvent(key string, change StateChange) *pb.StateEvent {
+	event := &pb.StateEvent{Key: key, Version: change.Entry.Version}
+	switch {
+	case change.Expired:
+		event.Kind = pb.StateEvent_EXPIRED
+	case change.Deleted:
+		event.Kind = pb.StateEvent_DELETED
+	default:
+		event.Kind = pb.StateEvent_SET
+		event.Value = eventPayload(change.Entry.Value)
+		event.UpdatedAt = change.Entry.UpdatedAt.UnixNano()
+		if !change.Entry.ExpiresAt.IsZero() {
+			event.ExpiresAt = change.Entry.ExpiresAt.UnixNano()
+		}
+	}
+	return event
+}
+
+// Headers selecting SyncState operations and carrying their results
+const (
+	StateOpHeader      = "x-state-op"
+	StateTTLHeader     = "x-state-ttl"
+	StateValueHeader   = "x-state-value"
+	StateVersionHeader = "x-state-version"
+
+	stateOpBatch        StateOpType = "batch"
+	stateOpList         StateOpType = "list"
+	stateOpDeletePrefix StateOpType = "delete_prefix"
+)
+
+// stateOptions reads the SyncState operation and TTL headers
+func stateOptions(ctx context.Context) (StateOpType, time.Duration, error) {
+	op := OpSet
+	var ttl time.Duration
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return op, ttl, nil
+	}
+	if values := md.Get(StateOpHeader); len(values) > 0 && values[0] != "" {
+		op = StateOpType(values[0])
+	}
+	if values := md.Get(StateTTLHeader); len(values) > 0 && values[0] != "" {
+		d, err := time.ParseDuration(values[0])
+		if err != nil {
+			return op, ttl, fmt.Errorf("invalid TTL: %q", values[0])
+		}
+		ttl = d
+	}
+	return op, ttl, nil
+}
+
+// SetStateStore replaces the in-memory state store, e.g. with one from
+// NewPersistentStateStore. Call it before Start.
+func (s *AgentServer) SetStateStore(store *StateStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateStore = store
+}
+
+// ReplicateState makes the server a read-only replica whose state follows
+// the primary the client connects to. Clients may read and watch state on a
+// replica; writes fail until PromoteState.
+func (s *AgentServer) ReplicateState(primary *AgentClient) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	if s.stopReplica != nil {
+		s.stopReplica()
+	}
+	s.stopReplica = cancel
+	store := s.stateStore
+	s.mu.Unlock()
+
+	go func() {
+		if err := NewStateReplica(store, primary).Run(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error replicating state: %v\n", err)
+		}
+	}()
+}
+
+// PromoteState stops following the primary and accepts writes, e.g. after
+// the primary failed
+func (s *AgentServer) PromoteState() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopReplica != nil {
+		s.stopReplica()
+		s.stopReplica = nil
+	}
+}
+
+// SetMessageBus routes events through bus, such as a NATS or Kafka bus
+// shared by several processes, instead of the in-process bus
+func (s *AgentServer) SetMessageBus(bus MessageBus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messageBus = bus
+}
+
+// StateHook is called after a client changes shared state
+type StateHook func(ctx context.Context, key string, entry StateEntry)
+
+// AddStateHook registers a hook called after every SyncState
+func (s *AgentServer) AddStateHook(hook StateHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateHooks = append(s.stateHooks, hook)
+}
+
+// AddServerOptions adds options applied to the gRPC server when it starts
+func (s *AgentServer) AddServerOptions(opts ...grpc.ServerOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.options = append(s.options, opts...)
+}
+
+// EnableKeepalive lets clients ping as often as every minTime, even without
+// active streams, and pings idle clients every interval, closing
+// connections that do not reply within timeout
+func (s *AgentServer) EnableKeepalive(minTime, interval, timeout time.Duration) {
+	s.AddServerOptions(
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             minTime,
+			PermitWithoutStream: true,
+		}),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    interval,
+			Timeout: timeout,
+		}),
+	)
+}
+
+// EnableInstrumentation traces and measures every RPC and bounds unary
+// RPCs without a deadline. Interceptors run in the order they are added, so
+// enable it before EnableAuthorization to record rejected calls too.
+func (s *AgentServer) EnableInstrumentation(config interceptors.Config) {
+	s.AddServerOptions(interceptors.ServerOptions(config)...)
+}
+
 // Start starts the gRPC server
 func (s *AgentServer) Start(address string) error {
 	listener, err := net.Listen("tcp", address)
@@ -104,14 +540,55 @@ func (s *AgentServer) Start(address string) error {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	server := grpc.NewServer()
+	// Recovery runs first, so panics anywhere in the chain become errors
+	options := append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(interceptors.UnaryServerRecovery()),
+		grpc.ChainStreamInterceptor(interceptors.StreamServerRecovery()),
+	}, s.options...)
+
+	s.mu.Lock()
+	server := grpc.NewServer(options...)
+	s.server = server
+	s.mu.Unlock()
 	pb.RegisterAgentServiceServer(server, s)
-	
+
 	return server.Serve(listener)
 }
 
+// Shutdown stops accepting connections and waits for pending RPCs to
+// finish, closing the remaining connections when ctx is done
+func (s *AgentServer) Shutdown(ctx context.Context) error {
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	if server == nil {
+		return nil
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		return ctx.Err()
+	}
+}
+
 // Helper function to execute tasks
 func (s *AgentServer) executeTask(ctx context.Context, req *pb.ExecuteRequest) (string, error) {
+	s.mu.RLock()
+	executor := s.executor
+	s.mu.RUnlock()
+	if executor != nil {
+		return executor.ExecuteTask(ctx, req.AgentId, req.Task)
+	}
+
 	// TODO: Implement task execution logic
 	// This should integrate with the workflow system
 	return fmt.Sprintf("Executed task for agent %s: %s", req.AgentId, req.Task), nil
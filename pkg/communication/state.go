@@ -2,85 +2,568 @@ package communication
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 // StateEntry represents a single state value with metadata
 type StateEntry struct {
-	Value     interface{}
+	Value interface{}
+	// Version increases with every write. A key created again after it
+	// was deleted or expired starts above every version the store has
+	// issued, so compare-and-set never mistakes it for its predecessor.
 	Version   int64
 	UpdatedAt time.Time
+	// ExpiresAt is zero for keys without a TTL
+	ExpiresAt time.Time
+}
+
+// expired reports whether the entry's TTL has passed
+func (e StateEntry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// StateOpType selects what a StateOp does
+type StateOpType string
+
+const (
+	// OpSet writes the value unconditionally
+	OpSet StateOpType = "set"
+	// OpCompareAndSet writes the value only if the key's version equals
+	// Version; version 0 means the key must not exist
+	OpCompareAndSet StateOpType = "cas"
+	// OpIncrement adds Delta to an integer value, starting from 0
+	OpIncrement StateOpType = "incr"
+	// OpDelete removes the key
+	OpDelete StateOpType = "delete"
+)
+
+// StateOp is one operation of a batch
+type StateOp struct {
+	Type    StateOpType   `json:"op"`
+	Key     string        `json:"key"`
+	Value   interface{}   `json:"value,omitempty"`
+	Version int64         `json:"version,omitempty"`
+	Delta   int64         `json:"delta,omitempty"`
+	TTL     time.Duration `json:"ttl,omitempty"`
+}
+
+// StateChange is a change to a watched key
+type StateChange struct {
+	Key   string
+	Entry StateEntry
+	// Deleted is set when the key was deleted or expired
+	Deleted bool
+	// Expired is also set when the key was removed by its TTL
+	Expired bool `json:",omitempty"`
+}
+
+// stateWatcher receives changes to one key, or to every key with a prefix
+type stateWatcher struct {
+	key    string
+	prefix bool
+	// strict watchers, such as replicas, must see every change; they are
+	// closed instead of losing changes when they fall behind
+	strict bool
+	ch     chan StateChange
+}
+
+func (w *stateWatcher) matches(key string) bool {
+	if w.prefix {
+		return strings.HasPrefix(key, w.key)
+	}
+	return key == w.key
 }
 
 // StateStore manages distributed state
 type StateStore struct {
-	states map[string]StateEntry
-	mu     sync.RWMutex
+	states   map[string]StateEntry
+	watchers map[*stateWatcher]struct{}
+	// backend persists changes; pending holds changes not yet flushed to it
+	backend StateBackend
+	pending []StateChange
+	// nextExpiry is the earliest ExpiresAt of the entries, letting Sweep
+	// skip its scan until a key has expired; expiry fires Sweep then
+	nextExpiry time.Time
+	expiry     *time.Timer
+	// maxVersion is the highest version issued or loaded
+	maxVersion int64
+	mu         sync.RWMutex
 }
 
 // NewStateStore creates a new state store
 func NewStateStore() *StateStore {
 	return &StateStore{
-		states: make(map[string]StateEntry),
+		states:   make(map[string]StateEntry),
+		watchers: make(map[*stateWatcher]struct{}),
+	}
+}
+
+// NewPersistentStateStore creates a state store that loads its entries from
+// backend and writes every change through to it. On a backend error the
+// change is kept in memory, retried with the next change, and the error
+// returned.
+func NewPersistentStateStore(ctx context.Context, backend StateBackend) (*StateStore, error) {
+	entries, err := backend.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
 	}
+	ss := NewStateStore()
+	ss.backend = backend
+	now := time.Now()
+	for key, entry := range entries {
+		ss.versioned(entry.Version)
+		if !entry.expired(now) {
+			ss.states[key] = entry
+			ss.expiresAt(entry.ExpiresAt)
+		}
+	}
+	return ss, nil
 }
 
 // Set updates a state value
 func (ss *StateStore) Set(key string, value interface{}) {
+	if _, err := ss.SetTTL(key, value, 0); err != nil {
+		fmt.Fprintf(os.Stderr, "Error persisting state %s: %v\n", key, err)
+	}
+}
+
+// SetTTL updates a state value that expires after ttl; zero never expires
+func (ss *StateStore) SetTTL(key string, value interface{}, ttl time.Duration) (StateEntry, error) {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 
-	currentEntry, exists := ss.states[key]
-	var version int64 = 1
-	if exists {
-		version = currentEntry.Version + 1
+	entry := ss.write(key, value, ttl)
+	return entry, ss.flush()
+}
+
+// CompareAndSet updates a state value only if its current version equals
+// expected, so concurrent writers cannot clobber each other. An expected
+// version of 0 creates the key only if it does not exist.
+func (ss *StateStore) CompareAndSet(key string, expected int64, value interface{}, ttl time.Duration) (StateEntry, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if err := ss.check(key, expected); err != nil {
+		return StateEntry{}, err
 	}
+	entry := ss.write(key, value, ttl)
+	return entry, ss.flush()
+}
 
-	ss.states[key] = StateEntry{
-		Value:     value,
-		Version:   version,
-		UpdatedAt: time.Now(),
+// Increment atomically adds delta to an integer value, treating a missing
+// key as 0, and returns the updated entry
+func (ss *StateStore) Increment(key string, delta int64) (StateEntry, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	entry, err := ss.increment(key, delta, 0)
+	if err != nil {
+		return StateEntry{}, err
+	}
+	return entry, ss.flush()
+}
+
+// Delete removes a key, reporting whether it existed
+func (ss *StateStore) Delete(key string) bool {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	entry, exists := ss.current(key)
+	ss.remove(key, entry, false)
+	if err := ss.flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error persisting state %s: %v\n", key, err)
 	}
+	return exists
+}
+
+// Batch applies operations atomically: if any compare-and-set or increment
+// fails, none are applied. Versions are checked against the state before the
+// batch. It returns the resulting entry of each operation.
+func (ss *StateStore) Batch(ops []StateOp) ([]StateEntry, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	// Validate everything before writing so a failure leaves no partial
+	// batch. staged holds the values earlier operations leave behind, so an
+	// increment sees a value set in the same batch.
+	type tombstone struct{}
+	staged := make(map[string]interface{})
+	value := func(key string) (interface{}, bool) {
+		if v, ok := staged[key]; ok {
+			_, deleted := v.(tombstone)
+			return v, !deleted
+		}
+		entry, exists := ss.current(key)
+		return entry.Value, exists
+	}
+	for i, op := range ops {
+		switch op.Type {
+		case OpCompareAndSet:
+			if err := ss.check(op.Key, op.Version); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			staged[op.Key] = op.Value
+		case OpSet:
+			staged[op.Key] = op.Value
+		case OpIncrement:
+			var n int64
+			if v, exists := value(op.Key); exists {
+				var err error
+				if n, err = toInt64(v); err != nil {
+					return nil, fmt.Errorf("operation %d: %w", i, err)
+				}
+			}
+			staged[op.Key] = n + op.Delta
+		case OpDelete:
+			staged[op.Key] = tombstone{}
+		default:
+			return nil, fmt.Errorf("operation %d: unknown state operation: %s", i, op.Type)
+		}
+	}
+
+	entries := make([]StateEntry, len(ops))
+	for i, op := range ops {
+		switch op.Type {
+		case OpSet, OpCompareAndSet:
+			entries[i] = ss.write(op.Key, op.Value, op.TTL)
+		case OpIncrement:
+			var err error
+			if entries[i], err = ss.increment(op.Key, op.Delta, op.TTL); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		case OpDelete:
+			entries[i], _ = ss.current(op.Key)
+			ss.remove(op.Key, entries[i], false)
+		}
+	}
+	return entries, ss.flush()
 }
 
 // Get retrieves a state value
 func (ss *StateStore) Get(key string) (StateEntry, bool) {
 	ss.mu.RLock()
 	defer ss.mu.RUnlock()
+	return ss.current(key)
+}
 
+// current returns the live entry for key, hiding expired entries. The
+// caller holds ss.mu.
+func (ss *StateStore) current(key string) (StateEntry, bool) {
 	entry, exists := ss.states[key]
-	return entry, exists
+	if !exists || entry.expired(time.Now()) {
+		return StateEntry{}, false
+	}
+	return entry, true
 }
 
-// Watch monitors a key for changes
+// check verifies the expected version of key. The caller holds ss.mu.
+func (ss *StateStore) check(key string, expected int64) error {
+	entry, exists := ss.current(key)
+	if !exists && expected == 0 || exists && entry.Version == expected {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is at version %d, expected %d", ErrVersionConflict, key, entry.Version, expected)
+}
+
+// write stores a value, bumping the version. Expired keys start over at
+// version 1. The caller holds ss.mu.
+func (ss *StateStore) write(key string, value interface{}, ttl time.Duration) StateEntry {
+	now := time.Now()
+	version := ss.maxVersion + 1
+	if currentEntry, exists := ss.current(key); exists {
+		version = currentEntry.Version + 1
+	}
+	ss.versioned(version)
+
+	entry := StateEntry{
+		Value:     value,
+		Version:   version,
+		UpdatedAt: now,
+	}
+	if ttl > 0 {
+		entry.ExpiresAt = now.Add(ttl)
+		ss.expiresAt(entry.ExpiresAt)
+	}
+	ss.states[key] = entry
+	ss.notify(StateChange{Key: key, Entry: entry})
+	return entry
+}
+
+// versioned records a version issued or loaded. The caller holds ss.mu.
+func (ss *StateStore) versioned(version int64) {
+	if version > ss.maxVersion {
+		ss.maxVersion = version
+	}
+}
+
+// remove deletes a key, deleted or expired, and notifies watchers. The
+// caller holds ss.mu.
+func (ss *StateStore) remove(key string, entry StateEntry, expired bool) {
+	if _, exists := ss.states[key]; !exists {
+		return
+	}
+	delete(ss.states, key)
+	ss.notify(StateChange{Key: key, Entry: entry, Deleted: true, Expired: expired})
+}
+
+// flush writes pending changes to the backend. Changes the backend fails
+// to apply stay pending and are retried with the next change. The caller
+// holds ss.mu.
+func (ss *StateStore) flush() error {
+	if ss.backend == nil || len(ss.pending) == 0 {
+		return nil
+	}
+	if err := ss.backend.Apply(context.Background(), ss.pending); err != nil {
+		return fmt.Errorf("failed to persist state: %w", err)
+	}
+	ss.pending = nil
+	return nil
+}
+
+// notify fans a change out to matching watchers and queues it for the
+// backend. A watcher that falls
+// behind loses its oldest pending change rather than blocking writers, so
+// it always sees the latest state. The caller holds ss.mu.
+func (ss *StateStore) notify(change StateChange) {
+	if ss.backend != nil {
+		ss.pending = append(ss.pending, change)
+	}
+	for w := range ss.watchers {
+		if !w.matches(change.Key) {
+			continue
+		}
+		select {
+		case w.ch <- change:
+			continue
+		default:
+		}
+		if w.strict {
+			ss.unwatch(w)
+			continue
+		}
+		select {
+		case <-w.ch:
+		default:
+		}
+		select {
+		case w.ch <- change:
+		default:
+		}
+	}
+}
+
+// increment adds delta to an integer value, keeping its TTL; a value it
+// creates expires after ttl. The caller holds ss.mu.
+func (ss *StateStore) increment(key string, delta int64, ttl time.Duration) (StateEntry, error) {
+	var value int64
+	if entry, exists := ss.current(key); exists {
+		ttl = 0
+		n, err := toInt64(entry.Value)
+		if err != nil {
+			return StateEntry{}, err
+		}
+		value = n
+		if !entry.ExpiresAt.IsZero() {
+			ttl = time.Until(entry.ExpiresAt)
+		}
+	}
+	return ss.write(key, value+delta, ttl), nil
+}
+
+// toInt64 converts a stored value, which gRPC clients send as a string, to
+// an integer
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrNotInteger, v)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("%w: %T", ErrNotInteger, value)
+}
+
+// Sweep removes expired keys, notifying watchers, and returns how many were
+// removed. It runs by itself as keys expire; expired keys are invisible to
+// readers even before.
+func (ss *StateStore) Sweep() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	now := time.Now()
+	if ss.nextExpiry.IsZero() || now.Before(ss.nextExpiry) {
+		return 0
+	}
+	removed := 0
+	var next time.Time
+	for key, entry := range ss.states {
+		if entry.expired(now) {
+			ss.remove(key, entry, true)
+			removed++
+			continue
+		}
+		if !entry.ExpiresAt.IsZero() && (next.IsZero() || entry.ExpiresAt.Before(next)) {
+			next = entry.ExpiresAt
+		}
+	}
+	ss.nextExpiry = time.Time{}
+	ss.expiresAt(next)
+	if err := ss.flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error persisting expired state: %v\n", err)
+	}
+	return removed
+}
+
+// expiresAt records when an entry expires, arming the timer that sweeps it;
+// zero never does. The caller holds ss.mu.
+func (ss *StateStore) expiresAt(t time.Time) {
+	if t.IsZero() || !ss.nextExpiry.IsZero() && !t.Before(ss.nextExpiry) {
+		return
+	}
+	ss.nextExpiry = t
+	if ss.expiry != nil {
+		ss.expiry.Stop()
+	}
+	ss.expiry = time.AfterFunc(time.Until(t), func() { ss.Sweep() })
+}
+
+// apply stores a change made elsewhere, such as on a primary, keeping its
+// version
+func (ss *StateStore) apply(change StateChange) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.versioned(change.Entry.Version)
+	if change.Deleted {
+		ss.remove(change.Key, change.Entry, change.Expired)
+	} else {
+		ss.states[change.Key] = change.Entry
+		ss.expiresAt(change.Entry.ExpiresAt)
+		ss.notify(change)
+	}
+	return ss.flush()
+}
+
+// Keys returns the live keys
+func (ss *StateStore) Keys() []string {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	keys := make([]string, 0, len(ss.states))
+	for key := range ss.states {
+		if _, exists := ss.current(key); exists {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Close stops sweeping expired keys and closes the backend, if any
+func (ss *StateStore) Close() error {
+	ss.mu.Lock()
+	if ss.expiry != nil {
+		ss.expiry.Stop()
+	}
+	ss.mu.Unlock()
+
+	if ss.backend == nil {
+		return nil
+	}
+	return ss.backend.Close()
+}
+
+// Watch monitors a key for changes, starting with its current value.
+// Updates are pushed as they happen; the channel closes when ctx is done.
 func (ss *StateStore) Watch(ctx context.Context, key string) (<-chan StateEntry, error) {
+	changes := ss.watch(ctx, key, false)
 	updates := make(chan StateEntry, 1)
-	
-	// Initial state
-	if entry, exists := ss.Get(key); exists {
-		updates <- entry
-	}
 
 	go func() {
-		ticker := time.NewTicker(100 * time.Millisecond)
-		defer ticker.Stop()
 		defer close(updates)
-
-		var lastVersion int64 = -1
-
-		for {
+		for change := range changes {
+			if change.Deleted {
+				continue
+			}
 			select {
+			case updates <- change.Entry:
 			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				if entry, exists := ss.Get(key); exists && entry.Version > lastVersion {
-					lastVersion = entry.Version
-					updates <- entry
-				}
 			}
 		}
 	}()
 
 	return updates, nil
 }
+
+// WatchPrefix monitors every key starting with prefix, beginning with the
+// current entries. The channel closes when ctx is done.
+func (ss *StateStore) WatchPrefix(ctx context.Context, prefix string) (<-chan StateChange, error) {
+	return ss.watch(ctx, prefix, true), nil
+}
+
+// watch registers a watcher, queueing the matching entries as initial changes
+func (ss *StateStore) watch(ctx context.Context, key string, prefix bool) chan StateChange {
+	_, ch := ss.subscribe(ctx, &stateWatcher{key: key, prefix: prefix}, true)
+	return ch
+}
+
+// subscribe registers a watcher and returns the matching entries at that
+// moment, or queues them in the channel when replay is set. The channel
+// closes when ctx is done or, for strict watchers, when it overflows.
+func (ss *StateStore) subscribe(ctx context.Context, w *stateWatcher, replay bool) ([]StateChange, chan StateChange) {
+	ss.mu.Lock()
+	var initial []StateChange
+	for k := range ss.states {
+		if entry, exists := ss.current(k); exists && w.matches(k) {
+			initial = append(initial, StateChange{Key: k, Entry: entry})
+		}
+	}
+	if replay {
+		w.ch = make(chan StateChange, len(initial)+100)
+		for _, change := range initial {
+			w.ch <- change
+		}
+		initial = nil
+	} else {
+		w.ch = make(chan StateChange, 1000)
+	}
+	ss.watchers[w] = struct{}{}
+	ss.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		ss.mu.Lock()
+		ss.unwatch(w)
+		ss.mu.Unlock()
+	}()
+	return initial, w.ch
+}
+
+// unwatch removes a watcher and closes its channel. The caller holds ss.mu.
+func (ss *StateStore) unwatch(w *stateWatcher) {
+	if _, exists := ss.watchers[w]; exists {
+		delete(ss.watchers, w)
+		close(w.ch)
+	}
+}
+
+// Error types
+type StateError string
+
+func (e StateError) Error() string { return string(e) }
+
+const (
+	ErrVersionConflict = StateError("state version conflict")
+	ErrNotInteger      = StateError("state value is not an integer")
+)
@@ -0,0 +1,360 @@
+package communication
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/persistence"
+)
+
+// StateBackend durably stores StateStore entries
+type StateBackend interface {
+	// Load returns the stored entries
+	Load(ctx context.Context) (map[string]StateEntry, error)
+	// Apply records changes, atomically where the backend allows
+	Apply(ctx context.Context, changes []StateChange) error
+	// Close releases the backend
+	Close() error
+}
+
+// NewStateStoreFromConfig creates the state store selected by
+// Config.Communication.State. SQL backends need a *sql.DB and are created
+// with NewSQLStateBackend and NewPersistentStateStore.
+func NewStateStoreFromConfig(ctx context.Context, cfg *config.Config) (*StateStore, error) {
+	sc := cfg.Communication.State
+	switch sc.Backend {
+	case "", "memory":
+		return NewStateStore(), nil
+	case "file":
+		if sc.Path == "" {
+			return nil, fmt.Errorf("file state backend requires a path")
+		}
+		backend, err := NewFileStateBackend(sc.Path, sc.Fsync)
+		if err != nil {
+			return nil, err
+		}
+		ss, err := NewPersistentStateStore(ctx, backend)
+		if err != nil {
+			backend.Close()
+			return nil, err
+		}
+		interval := time.Duration(sc.CompactIntervalMs) * time.Millisecond
+		if interval <= 0 {
+			interval = 10 * time.Minute
+		}
+		backend.CompactEvery(ss, interval)
+		return ss, nil
+	default:
+		return nil, fmt.Errorf("unknown state backend: %s", sc.Backend)
+	}
+}
+
+// FileStateBackend appends changes as JSON lines to a log file, replayed on
+// Load. Compact rewrites the log as a snapshot of the current entries.
+type FileStateBackend struct {
+	path string
+	file *os.File
+	// fsync forces each change to disk before Apply returns
+	fsync  bool
+	closed bool
+	done   chan struct{}
+	mu     sync.Mutex
+}
+
+// NewFileStateBackend opens a file backend. With fsync set, changes are
+// flushed to disk before they are acknowledged.
+func NewFileStateBackend(path string, fsync bool) (*FileStateBackend, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state log: %w", err)
+	}
+	return &FileStateBackend{path: path, file: file, fsync: fsync, done: make(chan struct{})}, nil
+}
+
+// Load implements StateBackend.Load. A final line left incomplete by a
+// crash mid-write is truncated so later changes append after the last good
+// one; damage elsewhere is an error.
+func (b *FileStateBackend) Load(ctx context.Context) (map[string]StateEntry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	file, err := os.Open(b.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state log: %w", err)
+	}
+	defer file.Close()
+
+	entries := make(map[string]StateEntry)
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				return entries, b.truncate(offset)
+			}
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state log: %w", err)
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			var change StateChange
+			if err := json.Unmarshal(data, &change); err != nil {
+				if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+					return entries, b.truncate(offset)
+				}
+				return nil, fmt.Errorf("failed to decode state change on line %d: %w", line, err)
+			}
+			if change.Deleted {
+				delete(entries, change.Key)
+			} else {
+				entries[change.Key] = change.Entry
+			}
+		}
+		offset += int64(len(data))
+	}
+}
+
+// truncate cuts a torn tail off the log. The caller holds b.mu.
+func (b *FileStateBackend) truncate(offset int64) error {
+	fmt.Fprintf(os.Stderr, "Truncating incomplete state log %s at offset %d\n", b.path, offset)
+	if err := os.Truncate(b.path, offset); err != nil {
+		return fmt.Errorf("failed to truncate state log: %w", err)
+	}
+	return nil
+}
+
+// Apply implements StateBackend.Apply. Changes are written with a single
+// write, so a crash loses the batch rather than part of it in most cases.
+func (b *FileStateBackend) Apply(ctx context.Context, changes []StateChange) error {
+	var buf bytes.Buffer
+	for _, change := range changes {
+		data, err := json.Marshal(change)
+		if err != nil {
+			return fmt.Errorf("failed to encode state change: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if b.fsync {
+		return b.file.Sync()
+	}
+	return nil
+}
+
+// Compact replaces the log with one line per live entry of the store
+func (b *FileStateBackend) Compact(ss *StateStore) error {
+	ss.mu.RLock()
+	var buf bytes.Buffer
+	now := time.Now()
+	for key, entry := range ss.states {
+		if entry.expired(now) {
+			continue
+		}
+		data, err := json.Marshal(StateChange{Key: key, Entry: entry})
+		if err != nil {
+			ss.mu.RUnlock()
+			return fmt.Errorf("failed to encode state change: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	// Hold the backend lock before releasing the store so no change lands
+	// between the snapshot and the swap
+	b.mu.Lock()
+	ss.mu.RUnlock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return fmt.Errorf("state log is closed")
+	}
+
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write state snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("failed to replace state log: %w", err)
+	}
+
+	file, err := os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open state log: %w", err)
+	}
+	b.file.Close()
+	b.file = file
+	return nil
+}
+
+// CompactEvery compacts the log against ss every interval until the
+// backend is closed
+func (b *FileStateBackend) CompactEvery(ss *StateStore, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := b.Compact(ss); err != nil {
+					fmt.Fprintf(os.Stderr, "Error compacting state log: %v\n", err)
+				}
+			case <-b.done:
+				return
+			}
+		}
+	}()
+}
+
+// Close implements StateBackend.Close
+func (b *FileStateBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	return b.file.Close()
+}
+
+// SQLStateBackend stores entries in a state_entries table
+type SQLStateBackend struct {
+	db      *sql.DB
+	dialect persistence.Dialect
+}
+
+// NewSQLStateBackend creates a SQL-backed state backend. The caller opens
+// the *sql.DB with the driver of their choice.
+func NewSQLStateBackend(db *sql.DB, dialect persistence.Dialect) *SQLStateBackend {
+	return &SQLStateBackend{db: db, dialect: dialect}
+}
+
+// stateSchema creates the state table; value holds the JSON encoded value
+const stateSchema = `CREATE TABLE IF NOT EXISTS state_entries (
+	key TEXT PRIMARY KEY,
+	value TEXT,
+	version BIGINT NOT NULL,
+	updated_at BIGINT NOT NULL,
+	expires_at BIGINT NOT NULL
+)`
+
+// Migrate creates the state table if it does not exist
+func (b *SQLStateBackend) Migrate(ctx context.Context) error {
+	if _, err := b.db.ExecContext(ctx, stateSchema); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+	return nil
+}
+
+// Load implements StateBackend.Load
+func (b *SQLStateBackend) Load(ctx context.Context) (map[string]StateEntry, error) {
+	rows, err := b.db.QueryContext(ctx, `SELECT key, value, version, updated_at, expires_at FROM state_entries`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make(map[string]StateEntry)
+	for rows.Next() {
+		var (
+			key                  string
+			value                sql.NullString
+			version              int64
+			updatedAt, expiresAt int64
+		)
+		if err := rows.Scan(&key, &value, &version, &updatedAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		entry := StateEntry{Version: version, UpdatedAt: time.Unix(0, updatedAt)}
+		if expiresAt > 0 {
+			entry.ExpiresAt = time.Unix(0, expiresAt)
+		}
+		if value.Valid {
+			if err := json.Unmarshal([]byte(value.String), &entry.Value); err != nil {
+				return nil, fmt.Errorf("failed to decode state %s: %w", key, err)
+			}
+		}
+		entries[key] = entry
+	}
+	return entries, rows.Err()
+}
+
+// Apply implements StateBackend.Apply in a single transaction
+func (b *SQLStateBackend) Apply(ctx context.Context, changes []StateChange) error {
+	tx, err := b.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, change := range changes {
+		if change.Deleted {
+			if _, err := tx.ExecContext(ctx, b.rebind(`DELETE FROM state_entries WHERE key = ?`), change.Key); err != nil {
+				return fmt.Errorf("failed to delete state %s: %w", change.Key, err)
+			}
+			continue
+		}
+
+		value, err := json.Marshal(change.Entry.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encode state %s: %w", change.Key, err)
+		}
+		var expiresAt int64
+		if !change.Entry.ExpiresAt.IsZero() {
+			expiresAt = change.Entry.ExpiresAt.UnixNano()
+		}
+		_, err = tx.ExecContext(ctx, b.rebind(
+			`INSERT INTO state_entries (key, value, version, updated_at, expires_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value, version = excluded.version,
+			updated_at = excluded.updated_at, expires_at = excluded.expires_at`),
+			change.Key, string(value), change.Entry.Version, change.Entry.UpdatedAt.UnixNano(), expiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to write state %s: %w", change.Key, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Close implements StateBackend.Close. The database is owned by the caller.
+func (b *SQLStateBackend) Close() error {
+	return nil
+}
+
+// rebind rewrites ? placeholders to $n for Postgres
+func (b *SQLStateBackend) rebind(query string) string {
+	if b.dialect != persistence.DialectPostgres {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,310 @@
+package communication
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/user/modulox/pkg/pb"
+)
+
+// Control event types on Communicate streams
+const (
+	// StreamSubscribe starts streaming the topic named by the payload
+	StreamSubscribe = "modulox.subscribe"
+	// StreamUnsubscribe stops streaming the topic named by the payload
+	StreamUnsubscribe = "modulox.unsubscribe"
+	// StreamError reports a frame the server rejected; the payload holds
+	// the error and the frame's correlation_id metadata is echoed
+	StreamError = "modulox.error"
+)
+
+// MetadataTopic is the event metadata key naming the topic of a stream frame
+const MetadataTopic = "topic"
+
+// Communicate implements AgentService.Communicate. Each stream multiplexes
+// any number of topic subscriptions and publishes in both directions, so
+// remote agents avoid one RPC per event.
+func (s *AgentServer) Communicate(stream pb.AgentService_CommunicateServer) error {
+	ctx := stream.Context()
+
+	s.mu.RLock()
+	bus := s.messageBus
+	s.mu.RUnlock()
+
+	out := make(chan *pb.Event, 100)
+	subs := make(map[string]*streamSubscription)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		for topic, sub := range subs {
+			bus.Unsubscribe(topic, sub.ch)
+		}
+		wg.Wait()
+	}()
+
+	// stream.Send is not safe for concurrent use, so a single goroutine sends
+	sendErr := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case event := <-out:
+				if err := stream.Send(event); err != nil {
+					sendErr <- err
+					return
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	reply := func(event *pb.Event) {
+		select {
+		case out <- event:
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	fail := func(frame *pb.Event, err error) {
+		reply(&pb.Event{
+			Type:      StreamError,
+			Payload:   err.Error(),
+			Timestamp: time.Now().Unix(),
+			Metadata:  map[string]string{MetadataCorrelationID: frame.Metadata[MetadataCorrelationID]},
+		})
+	}
+
+	frames := make(chan *pb.Event)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			frame, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case frames <- frame:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		var frame *pb.Event
+		select {
+		case frame = <-frames:
+		case err := <-recvErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case err := <-sendErr:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+
+		switch frame.Type {
+		case StreamSubscribe:
+			topic := frame.Payload
+			if _, exists := subs[topic]; exists || topic == "" {
+				continue
+			}
+			if err := s.checkTopic(ctx, topic); err != nil {
+				fail(frame, err)
+				continue
+			}
+			sub := &streamSubscription{ch: bus.Subscribe(topic), stop: make(chan struct{})}
+			subs[topic] = sub
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case msg := <-sub.ch:
+						if !s.mayRead(ctx, msg.Type) {
+							continue
+						}
+						event := messageEvent(msg)
+						if event.Metadata == nil {
+							event.Metadata = make(map[string]string, 1)
+						}
+						event.Metadata[MetadataTopic] = topic
+						reply(event)
+					case <-sub.stop:
+						return
+					case <-done:
+						return
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		case StreamUnsubscribe:
+			if sub, exists := subs[frame.Payload]; exists {
+				delete(subs, frame.Payload)
+				bus.Unsubscribe(frame.Payload, sub.ch)
+				close(sub.stop)
+			}
+		default:
+			if err := s.mayWrite(ctx, frame); err != nil {
+				fail(frame, err)
+				continue
+			}
+			topic, msg := eventMessage(frame)
+			if t := frame.Metadata[MetadataTopic]; t != "" && t != topic {
+				if err := s.checkTopic(ctx, t); err != nil {
+					fail(frame, err)
+					continue
+				}
+				topic = t
+			}
+			if err := bus.Publish(ctx, topic, msg); err != nil {
+				fail(frame, err)
+			}
+		}
+	}
+}
+
+// streamSubscription is a topic subscription of a Communicate stream
+type streamSubscription struct {
+	ch chan Message
+	// stop ends the subscription's forwarding goroutine
+	stop chan struct{}
+}
+
+// checkTopic lets callers subscribe and publish to the broadcast and group
+// topics and, like StreamEvents, only their own agent's topic
+func (s *AgentServer) checkTopic(ctx context.Context, topic string) error {
+	if topic == broadcastTopic || (strings.HasPrefix(topic, groupTopic) && !isPattern(topic)) {
+		return nil
+	}
+	return s.checkInbox(ctx, topic)
+}
+
+// mayWrite checks that the caller may publish the event, as PublishEvent's
+// interceptor and checkSource do for unary calls
+func (s *AgentServer) mayWrite(ctx context.Context, event *pb.Event) error {
+	if err := s.checkSource(ctx, event); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	az := s.authorizer
+	s.mu.RUnlock()
+
+	if az == nil {
+		return nil
+	}
+	perm, _ := RequiredPermission("", event)
+	return az.Authorize(ctx, perm)
+}
+
+// Stream is a client's end of a Communicate stream. It ends when ctx is
+// done or the connection breaks; open a new one to continue.
+type Stream struct {
+	stream  pb.AgentService_CommunicateClient
+	agentID string
+	events  chan *pb.Event
+	err     error
+	mu      sync.Mutex
+}
+
+// Communicate opens a bidirectional event stream
+func (c *AgentClient) Communicate(ctx context.Context) (*Stream, error) {
+	stream, err := c.client.Communicate(outgoingTenant(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+
+	s := &Stream{
+		stream:  stream,
+		agentID: c.agentID,
+		events:  make(chan *pb.Event, 100),
+	}
+	go s.receive()
+	return s, nil
+}
+
+// receive forwards incoming events until the stream ends
+func (s *Stream) receive() {
+	defer close(s.events)
+	for {
+		event, err := s.stream.Recv()
+		if err != nil {
+			if err != io.EOF {
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+			}
+			return
+		}
+		s.events <- event
+	}
+}
+
+// Events returns the events of subscribed topics, tagged with their
+// "topic" metadata, and StreamError frames. The channel closes when the
+// stream ends; Err then reports why.
+func (s *Stream) Events() <-chan *pb.Event {
+	return s.events
+}
+
+// Err returns the error that ended the stream, if any
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Subscribe starts streaming a topic. Only admins may subscribe to other
+// agents' topics or to patterns; refusals arrive as StreamError frames.
+func (s *Stream) Subscribe(topic string) error {
+	return s.send(&pb.Event{Type: StreamSubscribe, Payload: topic})
+}
+
+// Unsubscribe stops streaming a topic
+func (s *Stream) Unsubscribe(topic string) error {
+	return s.send(&pb.Event{Type: StreamUnsubscribe, Payload: topic})
+}
+
+// Publish publishes an event to a topic
+func (s *Stream) Publish(topic, eventType, payload string, metadata map[string]string) error {
+	md := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		md[k] = v
+	}
+	md[MetadataTopic] = topic
+	return s.send(&pb.Event{
+		Type:        eventType,
+		Payload:     payload,
+		SourceAgent: s.agentID,
+		Timestamp:   time.Now().Unix(),
+		Metadata:    md,
+	})
+}
+
+// send serializes writes, which gRPC streams do not allow concurrently
+func (s *Stream) send(event *pb.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.stream.Send(event); err != nil {
+		return fmt.Errorf("failed to send on stream: %w", err)
+	}
+	return nil
+}
+
+// Close ends the client's side of the stream
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream.CloseSend()
+}
@@ -0,0 +1,86 @@
+package communication
+
+import (
+	"context"
+
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/tenant"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TenantHeader is the gRPC metadata key carrying the tenant ID
+const TenantHeader = "x-tenant-id"
+
+// MetadataTenant is the message metadata key carrying the publisher's tenant
+const MetadataTenant = "tenant_id"
+
+// incomingTenant attaches the caller's tenant to the context. An
+// authenticated caller acts in its principal's tenant; only admins, and
+// callers of servers without authentication, may name one in the metadata.
+// Invalid tenant IDs fail with InvalidArgument.
+func (s *AgentServer) incomingTenant(ctx context.Context) (context.Context, error) {
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		if p.TenantID != "" {
+			if err := tenant.ValidID(p.TenantID); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			ctx = tenant.WithTenant(ctx, p.TenantID)
+		}
+		s.mu.RLock()
+		az := s.authorizer
+		s.mu.RUnlock()
+		if az == nil || !az.IsAdmin(ctx) {
+			return ctx, nil
+		}
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	if values := md.Get(TenantHeader); len(values) > 0 && values[0] != "" {
+		if err := tenant.ValidID(values[0]); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return tenant.WithTenant(ctx, values[0]), nil
+	}
+	return ctx, nil
+}
+
+// outgoingTenant propagates the tenant attached to the context to gRPC metadata
+func outgoingTenant(ctx context.Context) context.Context {
+	if id, ok := tenant.FromContext(ctx); ok {
+		return metadata.AppendToOutgoingContext(ctx, TenantHeader, id)
+	}
+	return ctx
+}
+
+// stampTenant records the tenant attached to the context in the message
+// metadata, leaving the caller's map untouched
+func stampTenant(ctx context.Context, msg Message) Message {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return msg
+	}
+	metadata := make(map[string]interface{}, len(msg.Metadata)+1)
+	for k, v := range msg.Metadata {
+		metadata[k] = v
+	}
+	metadata[MetadataTenant] = id
+	msg.Metadata = metadata
+	return msg
+}
+
+// MessageTenant returns ctx bound to the tenant the message was published
+// in, if it records one. Messages naming an invalid tenant are rejected
+// rather than handled in the default tenant.
+func MessageTenant(ctx context.Context, msg Message) (context.Context, error) {
+	if id, ok := msg.Metadata[MetadataTenant].(string); ok && id != "" {
+		if err := tenant.ValidID(id); err != nil {
+			return nil, err
+		}
+		return tenant.WithTenant(ctx, id), nil
+	}
+	return ctx, nil
+}
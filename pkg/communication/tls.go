@@ -0,0 +1,175 @@
+package communication
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/user/modulox/pkg/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// TLSConfig contains transport security settings for agent servers and
+// clients. Setting CertFile and KeyFile on clients and RequireClientCert on
+// servers enables mutual TLS.
+type TLSConfig struct {
+	// CertFile and KeyFile hold this side's PEM certificate and key
+	CertFile string
+	KeyFile  string
+	// CAFile is a PEM bundle used to verify the peer (default system roots)
+	CAFile string
+	// RequireClientCert makes servers reject clients without a valid
+	// certificate signed by CAFile
+	RequireClientCert bool
+	// ServerName overrides the name clients verify the server certificate for
+	ServerName string
+	// AllowedIDs restricts peers to certificates carrying one of these SPIFFE
+	// IDs (spiffe://domain/path) or belonging to one of these trust domains
+	// (spiffe://domain). Empty allows any verified peer.
+	AllowedIDs []string
+}
+
+// TLSConfigFromConfig returns the TLS settings of Config.Communication, or
+// nil if TLS is disabled
+func TLSConfigFromConfig(cfg *config.Config) *TLSConfig {
+	c := cfg.Communication.TLS
+	if !c.Enabled {
+		return nil
+	}
+	return &TLSConfig{
+		CertFile:          c.CertFile,
+		KeyFile:           c.KeyFile,
+		CAFile:            c.CAFile,
+		RequireClientCert: c.ClientAuth,
+		ServerName:        c.ServerName,
+		AllowedIDs:        c.AllowedIDs,
+	}
+}
+
+// ServerCredentials returns gRPC server transport credentials
+func (c *TLSConfig) ServerCredentials() (credentials.TransportCredentials, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, fmt.Errorf("server TLS requires a certificate and key")
+	}
+	if len(c.AllowedIDs) > 0 && !c.RequireClientCert {
+		return nil, fmt.Errorf("allowed peer IDs require client certificates")
+	}
+	tlsConfig, err := c.build()
+	if err != nil {
+		return nil, err
+	}
+	if c.RequireClientCert {
+		if tlsConfig.RootCAs == nil {
+			return nil, fmt.Errorf("client certificate verification requires a CA file")
+		}
+		tlsConfig.ClientCAs = tlsConfig.RootCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	tlsConfig.RootCAs = nil
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// ClientCredentials returns gRPC client transport credentials
+func (c *TLSConfig) ClientCredentials() (credentials.TransportCredentials, error) {
+	tlsConfig, err := c.build()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ServerName = c.ServerName
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// build loads the certificates shared by server and client configurations
+func (c *TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file: %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(c.AllowedIDs) > 0 {
+		allowed := c.AllowedIDs
+		// Runs after the standard chain verification succeeded
+		tlsConfig.VerifyPeerCertificate = func(_ [][]byte, chains [][]*x509.Certificate) error {
+			if len(chains) == 0 || len(chains[0]) == 0 {
+				return fmt.Errorf("peer presented no verified certificate")
+			}
+			id, ok := spiffeID(chains[0][0])
+			if !ok {
+				return fmt.Errorf("peer certificate has no SPIFFE ID")
+			}
+			if !idAllowed(id, allowed) {
+				return fmt.Errorf("peer SPIFFE ID not allowed: %s", id)
+			}
+			return nil
+		}
+	}
+	return tlsConfig, nil
+}
+
+// spiffeID returns the SPIFFE ID in a certificate's URI SANs
+func spiffeID(cert *x509.Certificate) (string, bool) {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String(), true
+		}
+	}
+	return "", false
+}
+
+// idAllowed matches an ID against exact IDs and trust domains
+func idAllowed(id string, allowed []string) bool {
+	for _, a := range allowed {
+		a = strings.TrimSuffix(a, "/")
+		if id == a || (strings.Count(a, "/") == 2 && strings.HasPrefix(id, a+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// PeerSPIFFEID returns the SPIFFE ID of the verified client certificate of
+// the RPC handled with ctx
+func PeerSPIFFEID(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return spiffeID(info.State.VerifiedChains[0][0])
+}
+
+// EnableTLS serves the agent service over TLS, or mutual TLS when the config
+// requires client certificates
+func (s *AgentServer) EnableTLS(config TLSConfig) error {
+	creds, err := config.ServerCredentials()
+	if err != nil {
+		return fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	s.AddServerOptions(grpc.Creds(creds))
+	return nil
+}
@@ -0,0 +1,73 @@
+package communication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ToolMetadataKey is the ExecuteRequest metadata key naming a tool to invoke
+// instead of an agent task
+const ToolMetadataKey = "tool"
+
+// ToolExecutor runs tools by name after checking the caller may use them;
+// *tools.SafeExecutor satisfies it
+type ToolExecutor interface {
+	Execute(ctx context.Context, name string, input interface{}) (interface{}, error)
+}
+
+// SetToolExecutor exposes tools to remote clients through Execute. With
+// authorization enabled, callers need execute permission on the tool.
+func (s *AgentServer) SetToolExecutor(executor ToolExecutor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools = executor
+}
+
+// executeTool runs a remote tool call; input and output travel as JSON
+func (s *AgentServer) executeTool(ctx context.Context, name, input string) (string, error) {
+	s.mu.RLock()
+	executor := s.tools
+	s.mu.RUnlock()
+
+	if executor == nil {
+		return "", fmt.Errorf("tool not found: %s", name)
+	}
+
+	var decoded interface{}
+	if input != "" {
+		if err := json.Unmarshal([]byte(input), &decoded); err != nil {
+			return "", fmt.Errorf("invalid tool input: %w", err)
+		}
+	}
+
+	output, err := executor.Execute(ctx, name, decoded)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tool output: %w", err)
+	}
+	return string(data), nil
+}
+
+// InvokeTool runs a tool registered on the remote server
+func (c *AgentClient) InvokeTool(ctx context.Context, name string, input interface{}) (interface{}, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tool input: %w", err)
+	}
+
+	result, err := c.ExecuteTask(ctx, string(data), map[string]string{ToolMetadataKey: name})
+	if err != nil {
+		return nil, err
+	}
+
+	var output interface{}
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		return nil, fmt.Errorf("invalid tool output: %w", err)
+	}
+	return output, nil
+}
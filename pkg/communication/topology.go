@@ -0,0 +1,148 @@
+package communication
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/user/modulox/pkg/pb"
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// describeClusterMethod is the full gRPC method name of
+// AgentService.DescribeCluster
+const describeClusterMethod = "/modulox.v1.AgentService/DescribeCluster"
+
+// TopologySource describes the cluster served through DescribeCluster, as
+// seen by a tenant or, for an empty tenant ID, as a whole;
+// *distributed.Cluster satisfies it
+type TopologySource interface {
+	Describe(tenantID string) types.ClusterTopology
+}
+
+// SetTopologySource serves the source's topology through DescribeCluster
+func (s *AgentServer) SetTopologySource(source TopologySource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.topology = source
+}
+
+// DescribeCluster implements AgentService.DescribeCluster, describing the
+// cluster as seen by the caller's tenant
+func (s *AgentServer) DescribeCluster(ctx context.Context, req *pb.DescribeClusterRequest) (*pb.ClusterTopology, error) {
+	s.mu.RLock()
+	source := s.topology
+	s.mu.RUnlock()
+	if source == nil {
+		return nil, status.Error(codes.Unimplemented, "server does not describe a cluster")
+	}
+	return TopologyToPB(source.Describe(tenant.IDFromContext(ctx))), nil
+}
+
+// DescribeCluster returns the topology of the cluster behind the server
+func (c *AgentClient) DescribeCluster(ctx context.Context) (types.ClusterTopology, error) {
+	resp, err := c.client.DescribeCluster(outgoingTenant(ctx), &pb.DescribeClusterRequest{})
+	if err != nil {
+		return types.ClusterTopology{}, fmt.Errorf("failed to describe cluster: %w", err)
+	}
+	return TopologyFromPB(resp), nil
+}
+
+// TopologyToPB converts a topology to its wire form
+func TopologyToPB(topology types.ClusterTopology) *pb.ClusterTopology {
+	msg := &pb.ClusterTopology{
+		SchedulerId:  topology.SchedulerID,
+		Leader:       topology.Leader,
+		PendingTasks: int64(topology.PendingTasks),
+		Sessions:     int64(topology.Sessions),
+		GeneratedAt:  unixNano(topology.GeneratedAt),
+	}
+	for _, node := range topology.Nodes {
+		n := node.Node
+		nodeMsg := &pb.NodeTopology{
+			Node: &pb.NodeStatus{
+				Id:           n.ID,
+				Address:      n.Address,
+				Load:         int64(n.Load),
+				Capacity:     int64(n.Capacity),
+				Status:       int32(n.Status),
+				LastPing:     unixNano(n.LastPing),
+				AgentCount:   int64(n.AgentCount),
+				Agents:       n.Agents,
+				Tags:         n.Tags,
+				Tools:        n.Tools,
+				QueueDepth:   int64(n.QueueDepth),
+				Cpu:          n.CPU,
+				Memory:       n.Memory,
+				AgentTenants: n.AgentTenants,
+				Version:      n.Version,
+			},
+			State:  node.State,
+			Remote: node.Remote,
+		}
+		for _, event := range node.Health {
+			nodeMsg.Health = append(nodeMsg.Health, &pb.HealthEvent{Status: int32(event.Status), At: unixNano(event.At)})
+		}
+		msg.Nodes = append(msg.Nodes, nodeMsg)
+	}
+	return msg
+}
+
+// TopologyFromPB converts a topology from its wire form
+func TopologyFromPB(msg *pb.ClusterTopology) types.ClusterTopology {
+	topology := types.ClusterTopology{
+		SchedulerID:  msg.GetSchedulerId(),
+		Leader:       msg.GetLeader(),
+		PendingTasks: int(msg.GetPendingTasks()),
+		Sessions:     int(msg.GetSessions()),
+		GeneratedAt:  fromUnixNano(msg.GetGeneratedAt()),
+	}
+	for _, nodeMsg := range msg.GetNodes() {
+		n := nodeMsg.GetNode()
+		node := types.NodeTopology{
+			Node: types.NodeStatus{
+				ID:           n.GetId(),
+				Address:      n.GetAddress(),
+				Load:         int(n.GetLoad()),
+				Capacity:     int(n.GetCapacity()),
+				Status:       int(n.GetStatus()),
+				LastPing:     fromUnixNano(n.GetLastPing()),
+				AgentCount:   int(n.GetAgentCount()),
+				Agents:       n.GetAgents(),
+				Tags:         n.GetTags(),
+				Tools:        n.GetTools(),
+				QueueDepth:   int(n.GetQueueDepth()),
+				CPU:          n.GetCpu(),
+				Memory:       n.GetMemory(),
+				AgentTenants: n.GetAgentTenants(),
+				Version:      n.GetVersion(),
+			},
+			State:  nodeMsg.GetState(),
+			Remote: nodeMsg.GetRemote(),
+		}
+		for _, event := range nodeMsg.GetHealth() {
+			node.Health = append(node.Health, types.HealthEvent{Status: int(event.GetStatus()), At: fromUnixNano(event.GetAt())})
+		}
+		topology.Nodes = append(topology.Nodes, node)
+	}
+	return topology
+}
+
+// unixNano and fromUnixNano convert times to and from Unix nanoseconds,
+// mapping the zero time to 0
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
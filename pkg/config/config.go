@@ -1,9 +1,13 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"time"
 )
 
 // Config holds the framework configuration
@@ -29,34 +33,274 @@ type Config struct {
 		Type        string `json:"type"`
 		Path        string `json:"path"`
 		MaxSize     int    `json:"max_size"`
+		URL         string `json:"url"`
+		APIKey      string `json:"api_key"`
+		Collection  string `json:"collection"`
+		Dimensions  int    `json:"dimensions"`
 	} `json:"memory"`
 
 	// Tool configuration
 	Tools struct {
 		PluginDir   string   `json:"plugin_dir"`
 		EnabledTools []string `json:"enabled_tools"`
+		HTTPAllowlist []string `json:"http_allowlist"`
+		FileRoot    string   `json:"file_root"`
+		AllowShell  bool     `json:"allow_shell"`
+		Search      struct {
+			Provider string `json:"provider"`
+			APIKey   string `json:"api_key"`
+			URL      string `json:"url"`
+		} `json:"search"`
 	} `json:"tools"`
+
+	// Agent communication configuration
+	Communication struct {
+		TLS struct {
+			Enabled    bool     `json:"enabled"`
+			CertFile   string   `json:"cert_file"`
+			KeyFile    string   `json:"key_file"`
+			CAFile     string   `json:"ca_file"`
+			ClientAuth bool     `json:"client_auth"`
+			ServerName string   `json:"server_name"`
+			AllowedIDs []string `json:"allowed_ids"`
+		} `json:"tls"`
+		// Bus selects the message bus: "local" (default), "nats", or "kafka"
+		Bus struct {
+			Type    string            `json:"type"`
+			URL     string            `json:"url"`
+			Token   string            `json:"token"`
+			Prefix  string            `json:"prefix"`
+			Group   string            `json:"group"`
+			Headers map[string]string `json:"headers"`
+			// BufferSize, Overflow ("drop", "block", or "evict_oldest"), and
+			// BlockTimeoutMs set the default subscriber buffering
+			BufferSize     int    `json:"buffer_size"`
+			Overflow       string `json:"overflow"`
+			BlockTimeoutMs int    `json:"block_timeout_ms"`
+			// DeadLetters keeps up to this many undeliverable messages
+			DeadLetters int `json:"dead_letters"`
+		} `json:"bus"`
+		// State selects the shared state backend: "memory" (default) or
+		// "file", whose log is compacted every CompactIntervalMs (default
+		// 10 minutes)
+		State struct {
+			Backend           string `json:"backend"`
+			Path              string `json:"path"`
+			Fsync             bool   `json:"fsync"`
+			CompactIntervalMs int    `json:"compact_interval_ms"`
+		} `json:"state"`
+	} `json:"communication"`
+
+	// Server configuration: the agent server's gRPC address and optional
+	// REST gateway, metrics, and health endpoints; TLS is set under
+	// communication
+	Server struct {
+		Address        string `json:"address"`
+		RESTAddress    string `json:"rest_address"`
+		MetricsAddress string `json:"metrics_address"`
+		HealthAddress  string `json:"health_address"`
+		// Token authenticates clients of servers requiring authorization
+		Token string `json:"token"`
+		// Workflows lists declarative workflow files the REST API runs
+		Workflows []string `json:"workflows"`
+	} `json:"server"`
+
+	// Cluster configuration for running as a distributed node
+	Cluster struct {
+		Enabled bool `json:"enabled"`
+		// Address is the agent server the cluster shares
+		Address  string   `json:"address"`
+		NodeID   string   `json:"node_id"`
+		// Token is this process's credential with other cluster members:
+		// nodes present it to the cluster's agent server, and the cluster
+		// presents it to nodes when dispatching tasks
+		Token string `json:"token"`
+		// Peers maps the members allowed to call this server, nodes by node
+		// ID and the cluster as "cluster", to their tokens. Peers get the
+		// node role rather than admin.
+		Peers map[string]string `json:"peers"`
+		Tags     []string `json:"tags"`
+		Capacity int      `json:"capacity"`
+		// HeartbeatIntervalMs and NodeTimeoutMs control failure detection
+		HeartbeatIntervalMs int `json:"heartbeat_interval_ms"`
+		NodeTimeoutMs       int `json:"node_timeout_ms"`
+		DrainTimeoutMs      int `json:"drain_timeout_ms"`
+		SessionTTLMs        int `json:"session_ttl_ms"`
+		// TaskRate bounds the tasks scheduled per second across every
+		// scheduler sharing Address; zero is unlimited
+		TaskRate float64 `json:"task_rate"`
+	} `json:"cluster"`
+
+	// Reliability defaults for calls to providers and other services
+	Reliability struct {
+		Retry struct {
+			MaxAttempts    int     `json:"max_attempts"`
+			InitialDelayMs int     `json:"initial_delay_ms"`
+			MaxDelayMs     int     `json:"max_delay_ms"`
+			BackoffFactor  float64 `json:"backoff_factor"`
+		} `json:"retry"`
+		// CircuitBreaker opens after FailureThreshold consecutive failures,
+		// or when FailureRate of at least MinRequests requests in the last
+		// WindowMs failed, and closes after SuccessThreshold successful
+		// probes once ResetTimeoutMs has passed
+		CircuitBreaker struct {
+			FailureThreshold    int     `json:"failure_threshold"`
+			ResetTimeoutMs      int     `json:"reset_timeout_ms"`
+			WindowMs            int     `json:"window_ms"`
+			FailureRate         float64 `json:"failure_rate"`
+			MinRequests         int     `json:"min_requests"`
+			SuccessThreshold    int     `json:"success_threshold"`
+			HalfOpenMaxRequests int     `json:"half_open_max_requests"`
+		} `json:"circuit_breaker"`
+		// RateLimit allows Rate provider requests per second with bursts of
+		// Burst; a zero rate disables it. With Distributed set, the rate is
+		// shared by every process using the cluster's agent server, which
+		// holds the budget under Key (default "llm"); each process leases
+		// LeaseSize tokens at a time. FailOpen allows requests while that
+		// server is unreachable instead of rejecting them.
+		RateLimit struct {
+			Rate        float64 `json:"rate"`
+			Burst       int     `json:"burst"`
+			Distributed bool    `json:"distributed"`
+			Key         string  `json:"key"`
+			LeaseSize   int     `json:"lease_size"`
+			FailOpen    bool    `json:"fail_open"`
+		} `json:"rate_limit"`
+		// Bulkhead limits the calls made to each dependency at once to
+		// MaxConcurrent, queueing up to MaxQueue more for at most MaxWaitMs;
+		// a zero max_concurrent disables it
+		Bulkhead struct {
+			MaxConcurrent int `json:"max_concurrent"`
+			MaxQueue      int `json:"max_queue"`
+			MaxWaitMs     int `json:"max_wait_ms"`
+		} `json:"bulkhead"`
+		// Faults injects latency, errors, and drops into the calls of
+		// targets matching each pattern, for resilience testing
+		Faults struct {
+			Enabled bool  `json:"enabled"`
+			Seed    int64 `json:"seed"`
+			Targets []struct {
+				Pattern     string  `json:"pattern"`
+				ErrorRate   float64 `json:"error_rate"`
+				LatencyRate float64 `json:"latency_rate"`
+				LatencyMs   int     `json:"latency_ms"`
+				JitterMs    int     `json:"jitter_ms"`
+				DropRate    float64 `json:"drop_rate"`
+			} `json:"targets"`
+		} `json:"faults"`
+	} `json:"reliability"`
+
+	// Logging configuration
+	Logging struct {
+		Level    string            `json:"level"`
+		Modules  map[string]string `json:"modules"`
+		Sampling struct {
+			Initial    int `json:"initial"`
+			Thereafter int `json:"thereafter"`
+		} `json:"sampling"`
+		Sinks []struct {
+			Type       string            `json:"type"`
+			Path       string            `json:"path"`
+			MaxSizeMB  int               `json:"max_size_mb"`
+			MaxBackups int               `json:"max_backups"`
+			Network    string            `json:"network"`
+			Address    string            `json:"address"`
+			Endpoint   string            `json:"endpoint"`
+			Headers    map[string]string `json:"headers"`
+		} `json:"sinks"`
+	} `json:"logging"`
+
+	// Tracing configuration
+	Tracing struct {
+		// SampleRate is the share of traces recorded, from 0 to 1 (default
+		// 1); it is a pointer so an explicit 0 turns sampling off
+		SampleRate *float64 `json:"sample_rate"`
+		MaxSpans   int      `json:"max_spans"`
+		// Exporter sends ended spans to "stdout" or an "otlp" collector;
+		// "none" keeps them in memory only
+		Exporter struct {
+			Type            string            `json:"type"`
+			Endpoint        string            `json:"endpoint"`
+			Headers         map[string]string `json:"headers"`
+			BatchSize       int               `json:"batch_size"`
+			FlushIntervalMs int               `json:"flush_interval_ms"`
+		} `json:"exporter"`
+	} `json:"tracing"`
+
+	// secrets records settings resolved from secret references or
+	// expanded from environment variables
+	secrets []secretSetting
 }
 
-// LoadConfig loads configuration from a file
+// LoadConfig loads configuration from a YAML or JSON file. String values
+// may reference environment variables as ${VAR} or ${VAR:-default}, and
+// secrets as secretref:// URIs resolved through Secrets. Settings left
+// empty get defaults, and the result is validated; errors name the
+// offending key.
 func LoadConfig(path string) (*Config, error) {
-	configFile, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer configFile.Close()
 
-	var config Config
-	decoder := json.NewDecoder(configFile)
-	if err := decoder.Decode(&config); err != nil {
+	config, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Parse decodes YAML or JSON configuration, as LoadConfig does
+func Parse(data []byte) (*Config, error) {
+	tree, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var secrets []secretSetting
+	if tree, err = resolveSecrets(ctx, Secrets, nil, tree, &secrets); err != nil {
 		return nil, err
 	}
 
+	var config Config
+	if err := decode("", tree, reflect.ValueOf(&config).Elem()); err != nil {
+		return nil, err
+	}
+	config.secrets = secrets
+	config.SetDefaults()
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
 
-// SaveConfig saves configuration to a file
+// HostsCluster reports whether this process's agent server is the one the
+// cluster shares
+func (c *Config) HostsCluster() bool {
+	return c.Cluster.Address == c.Server.Address
+}
+
+// ClusterToken returns the credential this process presents to the
+// cluster's agent server: the server token when it hosts the cluster, and
+// Cluster.Token otherwise
+func (c *Config) ClusterToken() string {
+	if c.HostsCluster() {
+		return c.Server.Token
+	}
+	return c.Cluster.Token
+}
+
+// SaveConfig saves configuration to a file as JSON. Settings resolved from
+// secret references or expanded from environment variables are saved as
+// written, never as the secrets or expanded values.
 func (c *Config) SaveConfig(path string) error {
+	saved, err := c.withSecretRefs()
+	if err != nil {
+		return fmt.Errorf("failed to restore secret references: %w", err)
+	}
+
 	// Ensure directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -71,5 +315,5 @@ func (c *Config) SaveConfig(path string) error {
 
 	encoder := json.NewEncoder(configFile)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(c)
+	return encoder.Encode(saved)
 }
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// decode stores a parsed value in the field rv, expanding environment
+// variables in scalars. path names the field's key in errors, e.g.
+// "logging.sinks[0].type".
+func decode(path string, value interface{}, rv reflect.Value) error {
+	if value == nil {
+		return nil
+	}
+	if s, ok := value.(plainScalar); ok && isNull(string(s)) {
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		mapping, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a mapping, got %s", keyPath(path), describe(value))
+		}
+		fields := structFields(rv.Type())
+		for _, key := range sortedKeys(mapping) {
+			index, exists := fields[key]
+			if !exists {
+				return fmt.Errorf("%s: unknown key", joinPath(path, key))
+			}
+			if err := decode(joinPath(path, key), mapping[key], rv.Field(index)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		mapping, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a mapping, got %s", keyPath(path), describe(value))
+		}
+		m := reflect.MakeMapWithSize(rv.Type(), len(mapping))
+		for _, key := range sortedKeys(mapping) {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := decode(joinPath(path, key), mapping[key], elem); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key), elem)
+		}
+		rv.Set(m)
+		return nil
+
+	case reflect.Slice:
+		sequence, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a list, got %s", keyPath(path), describe(value))
+		}
+		s := reflect.MakeSlice(rv.Type(), len(sequence), len(sequence))
+		for i, item := range sequence {
+			if err := decode(fmt.Sprintf("%s[%d]", path, i), item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(s)
+		return nil
+
+	case reflect.Ptr:
+		elem := reflect.New(rv.Type().Elem())
+		if err := decode(path, value, elem.Elem()); err != nil {
+			return err
+		}
+		rv.Set(elem)
+		return nil
+
+	case reflect.Interface:
+		resolved, err := resolve(path, value)
+		if err != nil {
+			return err
+		}
+		if resolved != nil {
+			rv.Set(reflect.ValueOf(resolved))
+		}
+		return nil
+	}
+
+	text, err := scalarText(path, value)
+	if err != nil {
+		return err
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("%s: expected true or false, got %q", keyPath(path), text)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: expected an integer, got %q", keyPath(path), text)
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: expected a non-negative integer, got %q", keyPath(path), text)
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s: expected a number, got %q", keyPath(path), text)
+		}
+		rv.SetFloat(f)
+	default:
+		return fmt.Errorf("%s: unsupported field type %s", keyPath(path), rv.Type())
+	}
+	return nil
+}
+
+// scalarText returns a scalar's text with environment variables expanded
+func scalarText(path string, value interface{}) (string, error) {
+	var text string
+	switch v := value.(type) {
+	case secretValue:
+		return string(v), nil
+	case plainScalar:
+		text = string(v)
+	case string:
+		text = v
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10), nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	default:
+		return "", fmt.Errorf("%s: expected a single value, got %s", keyPath(path), describe(value))
+	}
+
+	expanded, err := expandEnv(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", keyPath(path), err)
+	}
+	return expanded, nil
+}
+
+// resolve converts a parsed value for an interface{} field the way
+// encoding/json would, typing plain scalars as null, booleans, numbers, or
+// strings
+func resolve(path string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for _, key := range sortedKeys(v) {
+			r, err := resolve(joinPath(path, key), v[key])
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := resolve(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+	case string, secretValue:
+		return scalarText(path, v)
+	case plainScalar:
+		text, err := scalarText(path, v)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case isNull(text):
+			return nil, nil
+		case text == "true" || text == "false":
+			return text == "true", nil
+		}
+		if f, err := strconv.ParseFloat(text, 64); err == nil && !strings.ContainsAny(text, "xXnN") {
+			return f, nil
+		}
+		return text, nil
+	}
+	return value, nil
+}
+
+// expandEnv replaces ${VAR} with the environment variable VAR and
+// ${VAR:-default} with VAR or, when it is unset or empty, default. $$ is a
+// literal $; other $ signs are left alone so values such as passwords need
+// no escaping.
+func expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			expr := s[i+2 : i+end]
+			name, fallback, hasDefault := expr, "", false
+			if sep := strings.Index(expr, ":-"); sep >= 0 {
+				name, fallback, hasDefault = expr[:sep], expr[sep+2:], true
+			}
+			if name == "" {
+				return "", fmt.Errorf("empty variable name in %q", s)
+			}
+
+			value, set := os.LookupEnv(name)
+			switch {
+			case set && value != "":
+			case hasDefault:
+				value = fallback
+			case !set:
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			b.WriteString(value)
+			i += end
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// structFields maps a struct's JSON keys to its field indexes
+func structFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+func isNull(s string) bool {
+	return s == "" || s == "~" || s == "null" || s == "Null" || s == "NULL"
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "a mapping"
+	case []interface{}:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", fmt.Sprint(value))
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func keyPath(path string) string {
+	if path == "" {
+		return "config"
+	}
+	return path
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Unmarshal decodes a YAML or JSON document into the struct v points to the
+// way configuration files are decoded: keys match json tags, unknown keys
+// are errors, and environment variables in values are expanded. Secret
+// references are not resolved.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal target must be a pointer to a struct, got %T", v)
+	}
+	tree, err := parseYAML(data)
+	if err != nil {
+		return err
+	}
+	return decode("", tree, rv.Elem())
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package config
+
+import "context"
+
+// watchDir is not supported on this platform; Watcher polls instead
+func watchDir(ctx context.Context, dir string) (<-chan struct{}, error) {
+	return nil, nil
+}
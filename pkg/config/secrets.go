@@ -0,0 +1,274 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SecretScheme prefixes configuration values that reference a secret, e.g.
+// secretref://env/OPENAI_API_KEY or secretref://vault/secret/data/app#api_key
+const SecretScheme = "secretref://"
+
+// SecretRef is a parsed secret reference of the form
+// secretref://<provider>/<path>[#<field>]
+type SecretRef struct {
+	Provider string
+	Path     string
+	// Field selects a key of a secret holding a JSON object
+	Field string
+}
+
+// ParseSecretRef parses a secretref:// URI
+func ParseSecretRef(uri string) (SecretRef, error) {
+	if !strings.HasPrefix(uri, SecretScheme) {
+		return SecretRef{}, fmt.Errorf("not a secret reference: %s", uri)
+	}
+	rest := strings.TrimPrefix(uri, SecretScheme)
+
+	var ref SecretRef
+	if i := strings.LastIndexByte(rest, '#'); i >= 0 {
+		rest, ref.Field = rest[:i], rest[i+1:]
+	}
+	slash := strings.IndexByte(rest, '/')
+	if slash <= 0 || slash == len(rest)-1 {
+		return SecretRef{}, fmt.Errorf("invalid secret reference %s: want secretref://<provider>/<path>", uri)
+	}
+	ref.Provider, ref.Path = rest[:slash], rest[slash+1:]
+	return ref, nil
+}
+
+// SecretProvider fetches secrets from one secret store
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref SecretRef) (string, error)
+}
+
+// SecretProviderFunc adapts a function to SecretProvider
+type SecretProviderFunc func(ctx context.Context, ref SecretRef) (string, error)
+
+// Resolve implements SecretProvider.Resolve
+func (f SecretProviderFunc) Resolve(ctx context.Context, ref SecretRef) (string, error) {
+	return f(ctx, ref)
+}
+
+// SecretResolver resolves secret references through the provider they
+// name
+type SecretResolver struct {
+	providers map[string]SecretProvider
+	mu        sync.RWMutex
+}
+
+// NewSecretResolver creates a resolver with the built-in providers:
+//
+//	env    secretref://env/NAME reads an environment variable
+//	file   secretref://file/run/secrets/api_key reads /run/secrets/api_key
+//	vault  secretref://vault/secret/data/app#field reads HashiCorp Vault
+//	aws    secretref://aws/<secret-id>#field reads AWS Secrets Manager
+//	gcp    secretref://gcp/projects/p/secrets/s reads Google Secret Manager
+//
+// The Vault, AWS, and GCP providers take their addresses and credentials
+// from the environment; Register replaces them with configured ones.
+func NewSecretResolver() *SecretResolver {
+	r := &SecretResolver{providers: make(map[string]SecretProvider)}
+	r.Register("env", SecretProviderFunc(resolveEnvSecret))
+	r.Register("file", SecretProviderFunc(resolveFileSecret))
+	r.Register("vault", NewVaultProvider(VaultConfig{}))
+	r.Register("aws", NewAWSSecretsProvider(AWSSecretsConfig{}))
+	r.Register("gcp", NewGCPSecretsProvider(GCPSecretsConfig{}))
+	return r
+}
+
+// Secrets resolves the secret references in configuration loaded by
+// LoadConfig, Parse, and Watcher
+var Secrets = NewSecretResolver()
+
+// Register adds or replaces the provider for secretref://<name>/ URIs
+func (r *SecretResolver) Register(name string, provider SecretProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[name] = provider
+}
+
+// Resolve fetches the secret a secretref:// URI names
+func (r *SecretResolver) Resolve(ctx context.Context, uri string) (string, error) {
+	ref, err := ParseSecretRef(uri)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.RLock()
+	provider, exists := r.providers[ref.Provider]
+	r.mu.RUnlock()
+	if !exists {
+		return "", fmt.Errorf("unknown secret provider: %s", ref.Provider)
+	}
+
+	value, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s secret %s: %w", ref.Provider, ref.Path, err)
+	}
+	return value, nil
+}
+
+func resolveEnvSecret(ctx context.Context, ref SecretRef) (string, error) {
+	value, set := os.LookupEnv(ref.Path)
+	if !set {
+		return "", fmt.Errorf("environment variable %s is not set", ref.Path)
+	}
+	return secretField(value, ref)
+}
+
+func resolveFileSecret(ctx context.Context, ref SecretRef) (string, error) {
+	data, err := os.ReadFile("/" + ref.Path)
+	if err != nil {
+		return "", err
+	}
+	return secretField(strings.TrimRight(string(data), "\r\n"), ref)
+}
+
+// secretField returns the ref's field of a secret holding a JSON object,
+// or the whole secret when no field is named
+func secretField(value string, ref SecretRef) (string, error) {
+	if ref.Field == "" {
+		return value, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, so has no field %s", ref.Field)
+	}
+	return fieldValue(fields, ref.Field)
+}
+
+func fieldValue(fields map[string]interface{}, name string) (string, error) {
+	value, exists := fields[name]
+	if !exists {
+		return "", fmt.Errorf("secret has no field %s", name)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// secretValue is a resolved secret, used as is without ${VAR} expansion
+type secretValue string
+
+// secretSetting records a setting resolved from a secret reference or
+// expanded from environment variables, so SaveConfig writes back the text
+// of the file instead of the value
+type secretSetting struct {
+	// path holds the JSON keys and list indexes leading to the setting
+	path []interface{}
+	text string
+}
+
+// resolveSecrets replaces secret references in a parsed configuration
+// with their values, recording where they were along with the settings
+// that reference environment variables
+func resolveSecrets(ctx context.Context, resolver *SecretResolver, path []interface{}, value interface{}, settings *[]secretSetting) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			resolved, err := resolveSecrets(ctx, resolver, append(path[:len(path):len(path)], key), v[key], settings)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = resolved
+		}
+	case []interface{}:
+		for i, item := range v {
+			resolved, err := resolveSecrets(ctx, resolver, append(path[:len(path):len(path)], i), item, settings)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+	case string, plainScalar:
+		text := reflect.ValueOf(v).String()
+		if !strings.HasPrefix(text, SecretScheme) {
+			// Expanded when decoded
+			if strings.Contains(text, "$") {
+				*settings = append(*settings, secretSetting{path: path, text: text})
+			}
+			return value, nil
+		}
+		uri, err := expandEnv(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", formatPath(path), err)
+		}
+		secret, err := resolver.Resolve(ctx, uri)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", formatPath(path), err)
+		}
+		*settings = append(*settings, secretSetting{path: path, text: text})
+		return secretValue(secret), nil
+	}
+	return value, nil
+}
+
+// withSecretRefs returns the configuration as generic JSON values holding
+// the recorded text of settings in place of the values resolved from it.
+// Settings of any type can hold the text, e.g. a port read from ${PORT}.
+func (c *Config) withSecretRefs() (interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	for _, s := range c.secrets {
+		if err := setPath(tree, s.path, s.text); err != nil {
+			return nil, fmt.Errorf("%s: %w", formatPath(s.path), err)
+		}
+	}
+	return tree, nil
+}
+
+// setPath stores text at the path below a generic JSON value
+func setPath(value interface{}, path []interface{}, text string) error {
+	switch segment := path[0].(type) {
+	case string:
+		if m, ok := value.(map[string]interface{}); ok {
+			if len(path) == 1 {
+				m[segment] = text
+				return nil
+			}
+			if next, exists := m[segment]; exists {
+				return setPath(next, path[1:], text)
+			}
+		}
+	case int:
+		if list, ok := value.([]interface{}); ok && segment < len(list) {
+			if len(path) == 1 {
+				list[segment] = text
+				return nil
+			}
+			return setPath(list[segment], path[1:], text)
+		}
+	}
+	return fmt.Errorf("no such setting")
+}
+
+// formatPath names a setting in errors, e.g. "logging.sinks[0].path"
+func formatPath(path []interface{}) string {
+	var key string
+	for _, segment := range path {
+		switch s := segment.(type) {
+		case string:
+			key = joinPath(key, s)
+		case int:
+			key = fmt.Sprintf("%s[%d]", key, s)
+		}
+	}
+	return keyPath(key)
+}
@@ -0,0 +1,299 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/sigv4"
+)
+
+// VaultConfig contains configuration for a VaultProvider
+type VaultConfig struct {
+	// Address defaults to the VAULT_ADDR environment variable
+	Address string
+	// Token defaults to the VAULT_TOKEN environment variable
+	Token string
+	// Namespace defaults to the VAULT_NAMESPACE environment variable
+	Namespace  string
+	HTTPClient *http.Client
+}
+
+// VaultProvider reads secrets from HashiCorp Vault. The reference path is
+// the API path below /v1, e.g. secret/data/app for the app secret of a KV
+// version 2 engine mounted at secret/. The field selects a key of the
+// secret and may be left out for secrets with a single key.
+type VaultProvider struct {
+	config VaultConfig
+}
+
+// NewVaultProvider creates a Vault secret provider
+func NewVaultProvider(config VaultConfig) *VaultProvider {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &VaultProvider{config: config}
+}
+
+// Resolve implements SecretProvider.Resolve
+func (p *VaultProvider) Resolve(ctx context.Context, ref SecretRef) (string, error) {
+	address := envDefault(p.config.Address, "VAULT_ADDR")
+	token := envDefault(p.config.Token, "VAULT_TOKEN")
+	if address == "" || token == "" {
+		return "", fmt.Errorf("vault address and token are required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(address, "/")+"/v1/"+ref.Path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := envDefault(p.config.Namespace, "VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := doSecretRequest(p.config.HTTPClient, req, &resp); err != nil {
+		return "", err
+	}
+
+	// KV version 2 nests the secret under data with its metadata
+	fields := resp.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok && fields["metadata"] != nil {
+		fields = nested
+	}
+	if ref.Field == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret has %d keys; name one with #field", len(fields))
+		}
+		for name := range fields {
+			return fieldValue(fields, name)
+		}
+	}
+	return fieldValue(fields, ref.Field)
+}
+
+// AWSSecretsConfig contains configuration for an AWSSecretsProvider
+type AWSSecretsConfig struct {
+	// Region defaults to the region of an ARN reference or the AWS_REGION
+	// environment variable
+	Region string
+	// Credentials default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+	// and AWS_SESSION_TOKEN environment variables
+	Credentials sigv4.Credentials
+	// Endpoint overrides the regional endpoint, e.g. for VPC endpoints
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// AWSSecretsProvider reads secrets from AWS Secrets Manager. The reference
+// path is the secret's name or ARN; the field selects a key of a secret
+// holding a JSON object.
+type AWSSecretsProvider struct {
+	config AWSSecretsConfig
+}
+
+// NewAWSSecretsProvider creates an AWS Secrets Manager secret provider
+func NewAWSSecretsProvider(config AWSSecretsConfig) *AWSSecretsProvider {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &AWSSecretsProvider{config: config}
+}
+
+// Resolve implements SecretProvider.Resolve
+func (p *AWSSecretsProvider) Resolve(ctx context.Context, ref SecretRef) (string, error) {
+	region := p.config.Region
+	if arn := strings.Split(ref.Path, ":"); region == "" && len(arn) > 3 && arn[0] == "arn" {
+		region = arn[3]
+	}
+	region = envDefault(region, "AWS_REGION")
+	if region == "" {
+		return "", fmt.Errorf("aws region is required")
+	}
+	creds := p.config.Credentials
+	if creds.AccessKeyID == "" {
+		creds = sigv4.CredentialsFromEnv()
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return "", fmt.Errorf("aws credentials are required")
+	}
+	endpoint := p.config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+
+	payload, err := json.Marshal(map[string]string{"SecretId": ref.Path})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	sigv4.Sign(req, payload, creds, "secretsmanager", region, time.Now())
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := doSecretRequest(p.config.HTTPClient, req, &resp); err != nil {
+		return "", err
+	}
+
+	value := resp.SecretString
+	if value == "" && resp.SecretBinary != "" {
+		data, err := base64.StdEncoding.DecodeString(resp.SecretBinary)
+		if err != nil {
+			return "", fmt.Errorf("invalid binary secret: %w", err)
+		}
+		value = string(data)
+	}
+	return secretField(value, ref)
+}
+
+// GCPSecretsConfig contains configuration for a GCPSecretsProvider
+type GCPSecretsConfig struct {
+	// Token is an OAuth access token. It defaults to the
+	// GOOGLE_OAUTH_ACCESS_TOKEN environment variable, then to a token of
+	// the instance's service account from the metadata server.
+	Token string
+	// Endpoint overrides the Secret Manager API endpoint
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// GCPSecretsProvider reads secrets from Google Secret Manager. The
+// reference path is the secret's resource name, e.g.
+// projects/p/secrets/s, reading the latest version unless one is named;
+// the field selects a key of a secret holding a JSON object.
+type GCPSecretsProvider struct {
+	config GCPSecretsConfig
+}
+
+// gcpMetadataTokenURL serves access tokens on Google Cloud instances
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// NewGCPSecretsProvider creates a Google Secret Manager secret provider
+func NewGCPSecretsProvider(config GCPSecretsConfig) *GCPSecretsProvider {
+	if config.Endpoint == "" {
+		config.Endpoint = "https://secretmanager.googleapis.com"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &GCPSecretsProvider{config: config}
+}
+
+// Resolve implements SecretProvider.Resolve
+func (p *GCPSecretsProvider) Resolve(ctx context.Context, ref SecretRef) (string, error) {
+	token, err := p.token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	name := ref.Path
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.config.Endpoint, "/")+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := doSecretRequest(p.config.HTTPClient, req, &resp); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret payload: %w", err)
+	}
+	return secretField(string(data), ref)
+}
+
+func (p *GCPSecretsProvider) token(ctx context.Context) (string, error) {
+	if token := envDefault(p.config.Token, "GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doSecretRequest(p.config.HTTPClient, req, &resp); err != nil {
+		return "", fmt.Errorf("no gcp access token configured and metadata server unavailable: %w", err)
+	}
+	return resp.AccessToken, nil
+}
+
+// doSecretRequest sends a request to a secret store and decodes its JSON
+// response
+func doSecretRequest(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("secret store returned %s: %s", resp.Status, secretStoreError(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// secretStoreError extracts the error message of a failed response
+// without echoing arbitrary bodies
+func secretStoreError(body []byte) string {
+	var resp map[string]interface{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "unreadable error"
+	}
+	for _, key := range []string{"errors", "message", "Message", "error"} {
+		if value, exists := resp[key]; exists {
+			return fmt.Sprint(value)
+		}
+	}
+	keys := make([]string, 0, len(resp))
+	for key := range resp {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("error with fields %v", keys)
+}
+
+// envDefault returns value or, when empty, the environment variable
+func envDefault(value, name string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(name)
+}
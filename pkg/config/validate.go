@@ -0,0 +1,230 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetDefaults fills in settings left empty
+func (c *Config) SetDefaults() {
+	if c.Agent.Name == "" {
+		c.Agent.Name = "modulox-agent"
+	}
+	if c.Agent.MaxTokens == 0 {
+		c.Agent.MaxTokens = 4096
+	}
+	if c.Memory.Type == "" {
+		c.Memory.Type = "memory"
+	}
+	if c.Communication.Bus.Type == "" {
+		c.Communication.Bus.Type = "local"
+	}
+	if c.Communication.State.Backend == "" {
+		c.Communication.State.Backend = "memory"
+	}
+	if c.Logging.Level == "" {
+		c.Logging.Level = "info"
+	}
+
+	if c.Server.Address == "" {
+		c.Server.Address = "localhost:50051"
+	}
+	if c.Cluster.Address == "" {
+		c.Cluster.Address = c.Server.Address
+	}
+	if c.Cluster.HeartbeatIntervalMs == 0 {
+		c.Cluster.HeartbeatIntervalMs = 5000
+	}
+	if c.Cluster.NodeTimeoutMs == 0 {
+		c.Cluster.NodeTimeoutMs = 3 * c.Cluster.HeartbeatIntervalMs
+	}
+
+	retry := &c.Reliability.Retry
+	if retry.MaxAttempts == 0 {
+		retry.MaxAttempts = 3
+	}
+	if retry.InitialDelayMs == 0 {
+		retry.InitialDelayMs = 100
+	}
+	if retry.MaxDelayMs == 0 {
+		retry.MaxDelayMs = 10000
+	}
+	if retry.BackoffFactor == 0 {
+		retry.BackoffFactor = 2
+	}
+	breaker := &c.Reliability.CircuitBreaker
+	if breaker.FailureThreshold == 0 {
+		breaker.FailureThreshold = 5
+	}
+	if breaker.ResetTimeoutMs == 0 {
+		breaker.ResetTimeoutMs = 30000
+	}
+
+	if c.Tracing.SampleRate == nil {
+		rate := 1.0
+		c.Tracing.SampleRate = &rate
+	}
+	if c.Tracing.Exporter.Type == "" {
+		c.Tracing.Exporter.Type = "none"
+	}
+}
+
+// Validate checks settings for invalid values, naming the offending key
+func (c *Config) Validate() error {
+	if c.Agent.MaxTokens < 0 {
+		return fmt.Errorf("agent.max_tokens: must not be negative")
+	}
+
+	// Provider and memory types are checked by their factories, which
+	// accept types registered by other packages
+	if c.Memory.Type == "file" && c.Memory.Path == "" {
+		return fmt.Errorf("memory.path: required for the file memory type")
+	}
+	if c.Memory.Dimensions < 0 {
+		return fmt.Errorf("memory.dimensions: must not be negative")
+	}
+
+	tls := c.Communication.TLS
+	if tls.Enabled && (tls.CertFile == "") != (tls.KeyFile == "") {
+		return fmt.Errorf("communication.tls: cert_file and key_file must be set together")
+	}
+	if tls.ClientAuth && tls.CAFile == "" {
+		return fmt.Errorf("communication.tls.ca_file: required for client_auth")
+	}
+
+	bus := c.Communication.Bus
+	if err := oneOf("communication.bus.type", bus.Type, "local", "nats", "kafka"); err != nil {
+		return err
+	}
+	if bus.Type != "local" && bus.URL == "" {
+		return fmt.Errorf("communication.bus.url: required for the %s bus", bus.Type)
+	}
+	if bus.Overflow != "" {
+		if err := oneOf("communication.bus.overflow", bus.Overflow, "drop", "block", "evict_oldest"); err != nil {
+			return err
+		}
+	}
+	if bus.BufferSize < 0 || bus.BlockTimeoutMs < 0 || bus.DeadLetters < 0 {
+		return fmt.Errorf("communication.bus: buffer_size, block_timeout_ms, and dead_letters must not be negative")
+	}
+
+	state := c.Communication.State
+	if err := oneOf("communication.state.backend", state.Backend, "memory", "file"); err != nil {
+		return err
+	}
+	if state.Backend == "file" && state.Path == "" {
+		return fmt.Errorf("communication.state.path: required for the file backend")
+	}
+
+	cluster := c.Cluster
+	if cluster.Enabled && cluster.NodeID == "" {
+		return fmt.Errorf("cluster.node_id: required when the cluster is enabled")
+	}
+	if cluster.Capacity < 0 || cluster.HeartbeatIntervalMs < 0 || cluster.DrainTimeoutMs < 0 || cluster.SessionTTLMs < 0 {
+		return fmt.Errorf("cluster: capacity and durations must not be negative")
+	}
+	if cluster.TaskRate < 0 {
+		return fmt.Errorf("cluster.task_rate: must not be negative")
+	}
+	if cluster.NodeTimeoutMs <= cluster.HeartbeatIntervalMs {
+		return fmt.Errorf("cluster.node_timeout_ms: must exceed heartbeat_interval_ms")
+	}
+
+	retry := c.Reliability.Retry
+	if retry.MaxAttempts < 1 {
+		return fmt.Errorf("reliability.retry.max_attempts: must be at least 1")
+	}
+	if retry.InitialDelayMs < 0 || retry.MaxDelayMs < retry.InitialDelayMs {
+		return fmt.Errorf("reliability.retry.max_delay_ms: must not be below initial_delay_ms")
+	}
+	if retry.BackoffFactor < 1 {
+		return fmt.Errorf("reliability.retry.backoff_factor: must be at least 1")
+	}
+	breaker := c.Reliability.CircuitBreaker
+	if breaker.FailureThreshold < 1 || breaker.ResetTimeoutMs < 0 {
+		return fmt.Errorf("reliability.circuit_breaker: failure_threshold must be positive and reset_timeout_ms not negative")
+	}
+	if breaker.FailureRate < 0 || breaker.FailureRate > 1 {
+		return fmt.Errorf("reliability.circuit_breaker.failure_rate: must be between 0 and 1")
+	}
+	if breaker.WindowMs < 0 || breaker.MinRequests < 0 || breaker.SuccessThreshold < 0 || breaker.HalfOpenMaxRequests < 0 {
+		return fmt.Errorf("reliability.circuit_breaker: window_ms, min_requests, success_threshold, and half_open_max_requests must not be negative")
+	}
+	if breaker.FailureRate > 0 && breaker.WindowMs == 0 {
+		return fmt.Errorf("reliability.circuit_breaker.window_ms: required with failure_rate")
+	}
+	if c.Reliability.RateLimit.Rate < 0 || c.Reliability.RateLimit.Burst < 0 || c.Reliability.RateLimit.LeaseSize < 0 {
+		return fmt.Errorf("reliability.rate_limit: rate, burst, and lease_size must not be negative")
+	}
+	bulkhead := c.Reliability.Bulkhead
+	if bulkhead.MaxConcurrent < 0 || bulkhead.MaxQueue < 0 || bulkhead.MaxWaitMs < 0 {
+		return fmt.Errorf("reliability.bulkhead: max_concurrent, max_queue, and max_wait_ms must not be negative")
+	}
+	for i, target := range c.Reliability.Faults.Targets {
+		path := fmt.Sprintf("reliability.faults.targets[%d]", i)
+		if target.Pattern == "" {
+			return fmt.Errorf("%s.pattern: required", path)
+		}
+		for _, rate := range []float64{target.ErrorRate, target.LatencyRate, target.DropRate} {
+			if rate < 0 || rate > 1 {
+				return fmt.Errorf("%s: rates must be between 0 and 1", path)
+			}
+		}
+		if target.LatencyMs < 0 || target.JitterMs < 0 {
+			return fmt.Errorf("%s: latency_ms and jitter_ms must not be negative", path)
+		}
+	}
+
+	if err := logLevel("logging.level", c.Logging.Level); err != nil {
+		return err
+	}
+	for module, level := range c.Logging.Modules {
+		if err := logLevel("logging.modules."+module, level); err != nil {
+			return err
+		}
+	}
+	for i, sink := range c.Logging.Sinks {
+		key := fmt.Sprintf("logging.sinks[%d]", i)
+		if err := oneOf(key+".type", sink.Type, "", "stdout", "stderr", "file", "syslog", "otlp"); err != nil {
+			return err
+		}
+		if sink.Type == "file" && sink.Path == "" {
+			return fmt.Errorf("%s.path: required for file sinks", key)
+		}
+		if sink.Type == "otlp" && sink.Endpoint == "" {
+			return fmt.Errorf("%s.endpoint: required for otlp sinks", key)
+		}
+	}
+
+	tracing := c.Tracing
+	if rate := tracing.SampleRate; rate != nil && (*rate < 0 || *rate > 1) {
+		return fmt.Errorf("tracing.sample_rate: must be between 0 and 1")
+	}
+	if err := oneOf("tracing.exporter.type", tracing.Exporter.Type, "none", "stdout", "otlp"); err != nil {
+		return err
+	}
+	if tracing.Exporter.Type == "otlp" && tracing.Exporter.Endpoint == "" {
+		return fmt.Errorf("tracing.exporter.endpoint: required for the otlp exporter")
+	}
+	return nil
+}
+
+// oneOf checks that a setting has one of the allowed values
+func oneOf(key, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	var names []string
+	for _, a := range allowed {
+		if a != "" {
+			names = append(names, a)
+		}
+	}
+	return fmt.Errorf("%s: unknown value %q (want one of %s)", key, value, strings.Join(names, ", "))
+}
+
+func logLevel(key, level string) error {
+	return oneOf(key, strings.ToLower(level), "", "debug", "info", "warn", "warning", "error")
+}
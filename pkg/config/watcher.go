@@ -0,0 +1,269 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Change is a setting that differs between two configurations
+type Change struct {
+	// Key is the setting's path, e.g. "provider.model_name"
+	Key string
+	Old interface{}
+	New interface{}
+}
+
+// Subscriber is notified when settings it watches change. An error is
+// reported but does not stop other subscribers or later reloads.
+type Subscriber func(updated *Config, changes []Change) error
+
+// WatcherConfig contains configuration for a Watcher
+type WatcherConfig struct {
+	Path string
+	// Interval is how often the file is checked for changes where it
+	// cannot be watched through inotify (default 2s)
+	Interval time.Duration
+	// OnError is called when a reload or subscriber fails (default logs to
+	// stderr)
+	OnError func(err error)
+}
+
+// Watcher reloads a configuration file when it changes and notifies
+// subscribers of the settings that changed. A changed file that fails to
+// parse or validate is reported and the current configuration kept.
+//
+// On Linux the file's directory is watched through inotify, so edits made
+// by replacing the file, as editors and Kubernetes config maps do, are seen
+// too; elsewhere the file is polled.
+type Watcher struct {
+	config  WatcherConfig
+	current *Config
+	data    []byte
+	// loaded is the file's stat when last read and seen its latest stat,
+	// which must hold for an interval before the file is read, so files
+	// being written are not loaded half-way
+	loaded      fileStamp
+	seen        fileStamp
+	subscribers []subscription
+	mu          sync.RWMutex
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stamp(info os.FileInfo) fileStamp {
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+type subscription struct {
+	prefix string
+	fn     Subscriber
+}
+
+// NewWatcher loads the configuration file to watch
+func NewWatcher(config WatcherConfig) (*Watcher, error) {
+	if config.Interval <= 0 {
+		config.Interval = 2 * time.Second
+	}
+	if config.OnError == nil {
+		config.OnError = func(err error) {
+			fmt.Fprintf(os.Stderr, "Error reloading config: %v\n", err)
+		}
+	}
+
+	w := &Watcher{config: config}
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	current, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", config.Path, err)
+	}
+	w.current, w.data = current, data
+	w.loaded, w.seen = stamp(info), stamp(info)
+	return w, nil
+}
+
+// Config returns the current configuration, which must not be modified
+func (w *Watcher) Config() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe calls fn with the changes under a section, e.g. "provider" or
+// "logging.level"; an empty section subscribes to every change
+func (w *Watcher) Subscribe(section string, fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, subscription{prefix: section, fn: fn})
+}
+
+// Run reloads the file when it changes until ctx is done. Where the
+// directory cannot be watched, the file is checked every Interval.
+func (w *Watcher) Run(ctx context.Context) {
+	events, err := watchDir(ctx, filepath.Dir(w.config.Path))
+	if err != nil {
+		w.config.OnError(fmt.Errorf("watching %s, polling instead: %w", w.config.Path, err))
+	}
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		var poll <-chan time.Time
+		if events == nil {
+			poll = ticker.C
+		}
+		notified := false
+		select {
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			notified = true
+		case <-poll:
+		case <-ctx.Done():
+			return
+		}
+		if _, err := w.check(notified, false); err != nil {
+			w.config.OnError(err)
+		}
+	}
+}
+
+// Reload rereads the file, even if it looks unchanged, and returns the
+// changes applied
+func (w *Watcher) Reload() ([]Change, error) {
+	return w.check(false, true)
+}
+
+// check reloads the file once its size or modification time changed and
+// then held for an interval. Once notified that a write was closed, the
+// file is read at once and reloaded if its contents changed; when forced,
+// it is reloaded regardless.
+func (w *Watcher) check(notified, force bool) ([]Change, error) {
+	info, err := os.Stat(w.config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	current := stamp(info)
+	settled := current == w.seen
+	w.seen = current
+	if !force && !notified && (current == w.loaded || !settled) {
+		w.mu.Unlock()
+		return nil, nil
+	}
+	data, err := os.ReadFile(w.config.Path)
+	if err != nil {
+		w.mu.Unlock()
+		return nil, err
+	}
+	w.loaded = current
+	if bytes.Equal(data, w.data) && !force {
+		w.mu.Unlock()
+		return nil, nil
+	}
+	w.data = data
+
+	updated, err := Parse(data)
+	if err != nil {
+		w.mu.Unlock()
+		return nil, fmt.Errorf("%s: %w", w.config.Path, err)
+	}
+	changes := Diff(w.current, updated)
+	if len(changes) == 0 {
+		w.mu.Unlock()
+		return nil, nil
+	}
+	w.current = updated
+	subscribers := append([]subscription(nil), w.subscribers...)
+	w.mu.Unlock()
+
+	for _, s := range subscribers {
+		matched := matchChanges(changes, s.prefix)
+		if len(matched) == 0 {
+			continue
+		}
+		if err := s.fn(updated, matched); err != nil {
+			w.config.OnError(fmt.Errorf("subscriber for %q: %w", s.prefix, err))
+		}
+	}
+	return changes, nil
+}
+
+// Diff lists the settings that differ between two configurations, sorted
+// by key
+func Diff(old, updated *Config) []Change {
+	before, after := make(map[string]interface{}), make(map[string]interface{})
+	flatten("", reflect.ValueOf(*old), before)
+	flatten("", reflect.ValueOf(*updated), after)
+
+	var changes []Change
+	for key, value := range before {
+		if next, exists := after[key]; !exists || !reflect.DeepEqual(value, next) {
+			changes = append(changes, Change{Key: key, Old: value, New: next})
+		}
+	}
+	for key, value := range after {
+		if _, exists := before[key]; !exists {
+			changes = append(changes, Change{Key: key, New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// flatten maps the leaf settings of a struct or map to their keys; lists
+// are compared as a whole
+func flatten(path string, rv reflect.Value, out map[string]interface{}) {
+	switch rv.Kind() {
+	case reflect.Struct:
+		for key, index := range structFields(rv.Type()) {
+			flatten(joinPath(path, key), rv.Field(index), out)
+		}
+	case reflect.Map:
+		for _, key := range rv.MapKeys() {
+			flatten(joinPath(path, key.String()), rv.MapIndex(key), out)
+		}
+	case reflect.Ptr:
+		if rv.IsNil() {
+			out[path] = nil
+		} else {
+			flatten(path, rv.Elem(), out)
+		}
+	default:
+		out[path] = rv.Interface()
+	}
+}
+
+// matchChanges returns the changes at or below a key prefix
+func matchChanges(changes []Change, prefix string) []Change {
+	if prefix == "" {
+		return changes
+	}
+	var matched []Change
+	for _, c := range changes {
+		if c.Key == prefix || strings.HasPrefix(c.Key, prefix+".") || strings.HasPrefix(c.Key, prefix+"[") {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// plainScalar is an unquoted YAML scalar, typed by the field it is decoded
+// into: "8080" is a number for an int field and text for a string field
+type plainScalar string
+
+// parseYAML parses a YAML document; JSON documents are valid input.
+// Multiple documents are not supported.
+//
+// Mappings decode to map[string]interface{}, sequences to []interface{},
+// quoted and block scalars to string, plain scalars to plainScalar, and
+// empty values to nil.
+func parseYAML(data []byte) (interface{}, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var doc yaml.Node
+	if err := decoder.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var next yaml.Node
+	if err := decoder.Decode(&next); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("line %d: multiple documents are not supported", next.Line)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return convertNode(doc.Content[0])
+}
+
+// convertNode converts a parsed node to the values described by parseYAML
+func convertNode(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return convertNode(node.Alias)
+	case yaml.MappingNode:
+		mapping := make(map[string]interface{}, len(node.Content)/2)
+		if err := mergeMapping(mapping, node); err != nil {
+			return nil, err
+		}
+		return mapping, nil
+	case yaml.SequenceNode:
+		sequence := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := convertNode(item)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, value)
+		}
+		return sequence, nil
+	case yaml.ScalarNode:
+		if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			return node.Value, nil
+		}
+		if node.Value == "" {
+			return nil, nil
+		}
+		return plainScalar(node.Value), nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
+}
+
+// mergeMapping adds the entries of a mapping node to mapping. Keys set in
+// the node win over those of its "<<" merge keys; other duplicates are
+// errors.
+func mergeMapping(mapping map[string]interface{}, node *yaml.Node) error {
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Tag == "!!merge" {
+			merges = append(merges, value)
+			continue
+		}
+		if _, exists := mapping[key.Value]; exists {
+			return fmt.Errorf("line %d: duplicate key %q", key.Line, key.Value)
+		}
+		converted, err := convertNode(value)
+		if err != nil {
+			return err
+		}
+		mapping[key.Value] = converted
+	}
+
+	for _, merge := range merges {
+		sources := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+		for _, source := range sources {
+			if source.Kind == yaml.AliasNode {
+				source = source.Alias
+			}
+			if source.Kind != yaml.MappingNode {
+				return fmt.Errorf("line %d: merge key needs a mapping", source.Line)
+			}
+			merged := make(map[string]interface{})
+			if err := mergeMapping(merged, source); err != nil {
+				return err
+			}
+			for key, value := range merged {
+				if _, exists := mapping[key]; !exists {
+					mapping[key] = value
+				}
+			}
+		}
+	}
+	return nil
+}
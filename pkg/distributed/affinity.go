@@ -0,0 +1,277 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/types"
+)
+
+// SessionMetadataKey carries a task's session ID to remote nodes
+const SessionMetadataKey = "session_id"
+
+// EventSessionMoved is published when a session is bound to another node,
+// naming both nodes so its memory and state can be handed over
+const EventSessionMoved = "session_moved"
+
+// sessionBinding pins a session to the node holding its memory and state
+type sessionBinding struct {
+	nodeID       string
+	requirements types.TaskRequirements
+	lastUsed     time.Time
+	// saved is when the binding was last written to the cluster's state
+	saved time.Time
+}
+
+// sessionPrefix is the state namespace holding session bindings, one key
+// per session and node
+var sessionPrefix = communication.NamespaceKey("cluster", "sessions", "")
+
+// placeTask picks the node for a task. Tasks of a session go to the node
+// the session is bound to while it is available; otherwise the session is
+// bound to the most suitable node.
+func (c *Cluster) placeTask(requirements types.TaskRequirements, exclude ...string) *Node {
+	if requirements.SessionID == "" {
+		return c.findSuitableNode(requirements, exclude...)
+	}
+
+	if node := c.boundNode(requirements); node != nil && !contains(exclude, node.config.ID) {
+		c.bindSession(requirements, node.config.ID)
+		return node
+	}
+	node := c.findSuitableNode(requirements, exclude...)
+	if node != nil {
+		c.bindSession(requirements, node.config.ID)
+	}
+	return node
+}
+
+// boundNode returns the node a session is bound to, if it can still run
+// the session's tasks. Overloaded nodes keep their sessions; only
+// RebalanceSessions moves them off.
+func (c *Cluster) boundNode(requirements types.TaskRequirements) *Node {
+	c.sessionMu.Lock()
+	binding, exists := c.sessions[requirements.SessionID]
+	c.sessionMu.Unlock()
+	if !exists || c.sessionExpired(binding) {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	node, exists := c.nodes[binding.nodeID]
+	if !exists || node.status != StatusHealthy && node.status != StatusOverloaded || node.isDraining() || !c.nodeMatchesRequirements(node, requirements) {
+		return nil
+	}
+	return node
+}
+
+// bindSession binds a session to a node, or refreshes the binding. A
+// session taken from the node it was bound to is announced with a
+// session_moved event.
+func (c *Cluster) bindSession(requirements types.TaskRequirements, nodeID string) {
+	from, save := c.rebind(requirements, nodeID)
+	if save {
+		// Callers may hold the task queue's lock, so the store is updated
+		// in the background
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := c.sessionMoved(ctx, requirements.SessionID, from, nodeID); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving session %s: %v\n", requirements.SessionID, err)
+			}
+		}()
+	}
+}
+
+// rebind records a session's binding, returning the live node it was bound
+// to before and whether the binding needs saving: when it is new, and again
+// before it would expire from the store
+func (c *Cluster) rebind(requirements types.TaskRequirements, nodeID string) (string, bool) {
+	now := time.Now()
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	binding := &sessionBinding{nodeID: nodeID, requirements: requirements, lastUsed: now}
+	from := ""
+	if previous, exists := c.sessions[requirements.SessionID]; exists && !c.sessionExpired(previous) {
+		from = previous.nodeID
+		if from == nodeID {
+			binding.saved = previous.saved
+		}
+	}
+	save := now.Sub(binding.saved) > c.sessionTTL()/2
+	if save {
+		binding.saved = now
+	}
+	c.sessions[requirements.SessionID] = binding
+	return from, save
+}
+
+// sessionMoved saves a session's binding to the cluster's state, so a new
+// leader keeps it, and publishes a session_moved event if it changed nodes
+func (c *Cluster) sessionMoved(ctx context.Context, sessionID, from, to string) error {
+	if from != "" && from != to {
+		if err := c.client.DeleteState(ctx, sessionPrefix+sessionID+"/"+from); err != nil {
+			return err
+		}
+	}
+	if _, err := c.client.SetStateTTL(ctx, sessionPrefix+sessionID+"/"+to, to, c.sessionTTL()); err != nil {
+		return err
+	}
+	if from == "" || from == to {
+		return nil
+	}
+	return c.client.PublishEvent(ctx, EventSessionMoved,
+		fmt.Sprintf("Session %s moved from node %s to node %s", sessionID, from, to),
+		map[string]string{
+			SessionMetadataKey: sessionID,
+			"from_node":        from,
+			"to_node":          to,
+		})
+}
+
+// recoverSessions loads the session bindings saved by previous leaders.
+// Their requirements are restored by the sessions' next tasks.
+func (c *Cluster) recoverSessions(ctx context.Context) error {
+	keys, err := c.client.ListState(ctx, sessionPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	now := time.Now()
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	for _, key := range keys {
+		i := strings.LastIndex(key, "/")
+		if i <= len(sessionPrefix) {
+			continue
+		}
+		sessionID, nodeID := key[len(sessionPrefix):i], key[i+1:]
+		if _, exists := c.sessions[sessionID]; exists {
+			continue
+		}
+		c.sessions[sessionID] = &sessionBinding{
+			nodeID:       nodeID,
+			requirements: types.TaskRequirements{SessionID: sessionID},
+			lastUsed:     now,
+			saved:        now,
+		}
+	}
+	return nil
+}
+
+// loadSessions recovers session bindings in the background
+func (c *Cluster) loadSessions() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.recoverSessions(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error recovering session bindings: %v\n", err)
+	}
+}
+
+func (c *Cluster) sessionTTL() time.Duration {
+	if c.config.SessionTTL <= 0 {
+		return 30 * time.Minute
+	}
+	return c.config.SessionTTL
+}
+
+func (c *Cluster) sessionExpired(binding *sessionBinding) bool {
+	return time.Since(binding.lastUsed) > c.sessionTTL()
+}
+
+// pruneSessions forgets idle session bindings
+func (c *Cluster) pruneSessions() {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	for id, binding := range c.sessions {
+		if c.sessionExpired(binding) {
+			delete(c.sessions, id)
+		}
+	}
+}
+
+// SessionNode returns the node a session is bound to
+func (c *Cluster) SessionNode(sessionID string) (string, bool) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	binding, exists := c.sessions[sessionID]
+	if !exists || c.sessionExpired(binding) {
+		return "", false
+	}
+	return binding.nodeID, true
+}
+
+// MoveSession binds a session to another node. A session_moved event names
+// both nodes so the session's memory and state can be handed over.
+func (c *Cluster) MoveSession(ctx context.Context, sessionID, nodeID string) error {
+	node, err := c.GetNode(nodeID)
+	if err != nil {
+		return err
+	}
+	if node.isDraining() {
+		return fmt.Errorf("node is draining: %s", nodeID)
+	}
+
+	requirements := types.TaskRequirements{SessionID: sessionID}
+	c.sessionMu.Lock()
+	if binding, exists := c.sessions[sessionID]; exists {
+		requirements = binding.requirements
+	}
+	c.sessionMu.Unlock()
+
+	from, save := c.rebind(requirements, nodeID)
+	if !save {
+		return nil
+	}
+	return c.sessionMoved(ctx, sessionID, from, nodeID)
+}
+
+// RebalanceSessions moves sessions off nodes that are overloaded or can no
+// longer run them onto the most suitable other nodes, returning how many
+// moved. Sessions are otherwise never moved while their node is available.
+func (c *Cluster) RebalanceSessions(ctx context.Context) int {
+	c.sessionMu.Lock()
+	bindings := make(map[string]sessionBinding, len(c.sessions))
+	for id, binding := range c.sessions {
+		if !c.sessionExpired(binding) {
+			bindings[id] = *binding
+		}
+	}
+	c.sessionMu.Unlock()
+
+	moved := 0
+	for id, binding := range bindings {
+		if node := c.boundNode(binding.requirements); node != nil && node.loadFactor() <= 0.8 {
+			continue
+		}
+		target := c.findSuitableNode(binding.requirements, binding.nodeID)
+		if target == nil {
+			continue
+		}
+		if err := c.MoveSession(ctx, id, target.config.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "Error moving session %s: %v\n", id, err)
+			continue
+		}
+		moved++
+	}
+	return moved
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
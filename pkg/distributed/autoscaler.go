@@ -0,0 +1,254 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+// ClusterMetrics is a snapshot of cluster demand and capacity
+type ClusterMetrics struct {
+	// Nodes are the healthy and overloaded nodes not being drained
+	Nodes []types.NodeStatus
+	// QueueDepth counts tasks waiting in the cluster queue and on nodes
+	QueueDepth int
+	// InFlight counts tasks running on nodes
+	InFlight int
+	// Capacity is how many tasks the nodes run at once
+	Capacity int
+}
+
+// Load is the share of capacity in use or waited for, above 1 when tasks
+// wait for capacity
+func (m ClusterMetrics) Load() float64 {
+	if m.Capacity == 0 {
+		if m.QueueDepth > 0 {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	return float64(m.InFlight+m.QueueDepth) / float64(m.Capacity)
+}
+
+// Autoscaler observes cluster metrics, e.g. to add or remove nodes. The
+// cluster leader calls Observe every AutoscaleInterval.
+type Autoscaler interface {
+	Observe(ctx context.Context, metrics ClusterMetrics) error
+}
+
+// ScalePolicy computes how many nodes the cluster should have
+type ScalePolicy interface {
+	DesiredNodes(metrics ClusterMetrics) int
+}
+
+// LoadPolicy sizes the cluster so average load approaches Target, e.g. 0.7
+type LoadPolicy struct {
+	Target float64
+}
+
+// DesiredNodes implements ScalePolicy.DesiredNodes
+func (p LoadPolicy) DesiredNodes(metrics ClusterMetrics) int {
+	if len(metrics.Nodes) == 0 && metrics.QueueDepth > 0 {
+		// Work is waiting with nowhere to run
+		return 1
+	}
+	if p.Target <= 0 || len(metrics.Nodes) == 0 {
+		return len(metrics.Nodes)
+	}
+	load := metrics.Load()
+	if math.IsInf(load, 1) {
+		return len(metrics.Nodes) + 1
+	}
+	return int(math.Ceil(float64(len(metrics.Nodes)) * load / p.Target))
+}
+
+// QueuePolicy adds a node per TasksPerNode tasks waiting for capacity
+type QueuePolicy struct {
+	TasksPerNode int
+}
+
+// DesiredNodes implements ScalePolicy.DesiredNodes
+func (p QueuePolicy) DesiredNodes(metrics ClusterMetrics) int {
+	if p.TasksPerNode <= 0 {
+		return len(metrics.Nodes)
+	}
+	return len(metrics.Nodes) + (metrics.QueueDepth+p.TasksPerNode-1)/p.TasksPerNode
+}
+
+// AutoscalerConfig contains configuration for a PolicyAutoscaler
+type AutoscalerConfig struct {
+	MinNodes int
+	// MaxNodes caps scale-ups; zero means no limit
+	MaxNodes int
+	// Policies are combined by taking the largest node count (default a
+	// LoadPolicy targeting 0.7)
+	Policies []ScalePolicy
+	// ScaleUpCooldown and ScaleDownCooldown space out scaling actions in
+	// the same direction (default 3m and 10m); scaling down also waits for
+	// ScaleDownCooldown after a scale-up
+	ScaleUpCooldown   time.Duration
+	ScaleDownCooldown time.Duration
+	// ScaleUp is asked to add count nodes, e.g. through a Kubernetes or EC2
+	// driver
+	ScaleUp func(ctx context.Context, count int) error
+	// ScaleDown is asked to remove the given nodes, least loaded first. It
+	// should drain them with Cluster.DrainNode before terminating them.
+	ScaleDown func(ctx context.Context, nodeIDs []string) error
+}
+
+// PolicyAutoscaler scales the cluster between MinNodes and MaxNodes as its
+// policies decide
+type PolicyAutoscaler struct {
+	config        AutoscalerConfig
+	lastScaleUp   time.Time
+	lastScaleDown time.Time
+	mu            sync.Mutex
+}
+
+// NewAutoscaler creates a policy-driven autoscaler
+func NewAutoscaler(config AutoscalerConfig) *PolicyAutoscaler {
+	if len(config.Policies) == 0 {
+		config.Policies = []ScalePolicy{LoadPolicy{Target: 0.7}}
+	}
+	if config.ScaleUpCooldown <= 0 {
+		config.ScaleUpCooldown = 3 * time.Minute
+	}
+	if config.ScaleDownCooldown <= 0 {
+		config.ScaleDownCooldown = 10 * time.Minute
+	}
+	return &PolicyAutoscaler{config: config}
+}
+
+// Observe implements Autoscaler.Observe
+func (a *PolicyAutoscaler) Observe(ctx context.Context, metrics ClusterMetrics) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	current := len(metrics.Nodes)
+	desired := a.desiredNodes(metrics)
+	now := time.Now()
+
+	switch {
+	case desired > current && a.config.ScaleUp != nil:
+		if now.Sub(a.lastScaleUp) < a.config.ScaleUpCooldown {
+			return nil
+		}
+		if err := a.config.ScaleUp(ctx, desired-current); err != nil {
+			return fmt.Errorf("failed to scale up by %d nodes: %w", desired-current, err)
+		}
+		a.lastScaleUp = now
+
+	case desired < current && a.config.ScaleDown != nil:
+		if now.Sub(a.lastScaleDown) < a.config.ScaleDownCooldown || now.Sub(a.lastScaleUp) < a.config.ScaleDownCooldown {
+			return nil
+		}
+		nodeIDs := leastLoaded(metrics.Nodes, current-desired)
+		if err := a.config.ScaleDown(ctx, nodeIDs); err != nil {
+			return fmt.Errorf("failed to scale down nodes %v: %w", nodeIDs, err)
+		}
+		a.lastScaleDown = now
+	}
+	return nil
+}
+
+// desiredNodes takes the largest count the policies ask for, clamped to
+// the configured bounds
+func (a *PolicyAutoscaler) desiredNodes(metrics ClusterMetrics) int {
+	desired := 0
+	for _, policy := range a.config.Policies {
+		if n := policy.DesiredNodes(metrics); n > desired {
+			desired = n
+		}
+	}
+	if desired < a.config.MinNodes {
+		desired = a.config.MinNodes
+	}
+	if a.config.MaxNodes > 0 && desired > a.config.MaxNodes {
+		desired = a.config.MaxNodes
+	}
+	return desired
+}
+
+// leastLoaded returns the IDs of the count least loaded nodes
+func leastLoaded(nodes []types.NodeStatus, count int) []string {
+	sorted := append([]types.NodeStatus(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return nodeLoad(sorted[i]) < nodeLoad(sorted[j])
+	})
+
+	ids := make([]string, 0, count)
+	for _, node := range sorted[:count] {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func nodeLoad(status types.NodeStatus) float64 {
+	if status.Capacity == 0 {
+		return 0
+	}
+	return float64(status.Load+status.QueueDepth) / float64(status.Capacity)
+}
+
+// Metrics returns the cluster's current demand and capacity
+func (c *Cluster) Metrics() ClusterMetrics {
+	c.mu.RLock()
+	var serving []*Node
+	for _, node := range c.nodes {
+		// Overloaded nodes are the demand scaling up relieves
+		if (node.status == StatusHealthy || node.status == StatusOverloaded) && !node.isDraining() {
+			serving = append(serving, node)
+		}
+	}
+	c.mu.RUnlock()
+
+	var metrics ClusterMetrics
+	for _, node := range serving {
+		status := node.GetStatus()
+		metrics.Nodes = append(metrics.Nodes, status)
+		metrics.InFlight += status.Load
+		metrics.QueueDepth += status.QueueDepth
+		metrics.Capacity += status.Capacity
+	}
+	if c.queue != nil {
+		metrics.QueueDepth += c.queue.pendingCount()
+	}
+	return metrics
+}
+
+// runAutoscaler feeds metrics to the autoscaler while this instance leads
+func (c *Cluster) runAutoscaler(ctx context.Context, autoscaler Autoscaler) {
+	interval := c.config.AutoscaleInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if !c.IsLeader() {
+			continue
+		}
+		if err := autoscaler.Observe(ctx, c.Metrics()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error autoscaling cluster: %v\n", err)
+		}
+	}
+}
+
+// pendingCount counts the tasks waiting for a node
+func (q *TaskQueue) pendingCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
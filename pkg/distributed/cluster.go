@@ -3,10 +3,15 @@ package distributed
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/user/modulox/pkg/auth"
 	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/communication/interceptors"
+	"github.com/user/modulox/pkg/reliability"
+	"github.com/user/modulox/pkg/tenant"
 	"github.com/user/modulox/pkg/types"
 )
 
@@ -15,6 +20,44 @@ type ClusterConfig struct {
 	Address     string
 	HeartbeatInterval time.Duration
 	NodeTimeout      time.Duration
+	// TLS secures connections to the cluster and its nodes
+	TLS *communication.TLSConfig
+	// Token authenticates to the cluster's agent server
+	Token string
+	// Authorizer, when set, lets admins schedule tasks for tenants other
+	// than their context's
+	Authorizer *auth.Authorizer
+	// NodeToken authenticates to nodes when dispatching tasks and tool
+	// calls; nodes list it among their peers as "cluster"
+	NodeToken string
+	// Interceptors instruments calls to the cluster and its nodes
+	Interceptors *interceptors.Config
+	// Bulkheads, when set, limit the tasks and tool calls dispatched to
+	// each node at once with the bulkhead named "node." followed by its ID
+	Bulkheads *reliability.Bulkheads
+	// Election, when set, elects one of the Cluster instances sharing the
+	// agent server to schedule tasks; the others fail with ErrNotLeader
+	// until they take over
+	Election *ElectionConfig
+	// Queue, when set, enables Submit and the durable task queue
+	Queue *TaskQueueConfig
+	// DrainTimeout bounds how long DrainNode waits for in-flight tasks
+	// (default 1m)
+	DrainTimeout time.Duration
+	// Autoscaler, when set, observes the cluster's Metrics every
+	// AutoscaleInterval (default 30s) while this instance leads
+	Autoscaler        Autoscaler
+	AutoscaleInterval time.Duration
+	// SessionTTL is how long an idle session stays bound to its node
+	// (default 30m)
+	SessionTTL time.Duration
+	// Tenants, when set, applies each tenant's rate limit and concurrent
+	// task cap to scheduled and queued tasks, and accounts for its usage
+	Tenants *tenant.Manager
+	// TaskRateLimit, when set, bounds the tasks scheduled per second across
+	// every scheduler sharing the agent server, leasing tokens from its
+	// state (key default "tasks")
+	TaskRateLimit *reliability.DistributedRateLimiterConfig
 }
 
 // Cluster manages a collection of distributed nodes
@@ -22,12 +65,38 @@ type Cluster struct {
 	config    ClusterConfig
 	nodes     map[string]*Node
 	client    *communication.AgentClient
+	// toolClients holds connections to node agent servers for remote tool
+	// calls and tasks
+	toolClients map[string]*communication.AgentClient
+	elector      *LeaderElector
+	stopElection context.CancelFunc
+	electionDone chan struct{}
+	queue        *TaskQueue
+	stopQueue    context.CancelFunc
+	queueDone    chan struct{}
+	// drained holds remote nodes removed by DrainNode until they rejoin
+	drained      map[string]bool
+	stopAutoscaler context.CancelFunc
+	autoscalerDone chan struct{}
+	stopHeartbeats context.CancelFunc
+	heartbeatsDone chan struct{}
+	// sessions binds session IDs to nodes for affinity scheduling
+	sessions  map[string]*sessionBinding
+	sessionMu sync.Mutex
+	// taskLimiter applies TaskRateLimit
+	taskLimiter *reliability.DistributedRateLimiter
 	mu        sync.RWMutex
 }
 
 // NewCluster creates a new distributed cluster
 func NewCluster(config ClusterConfig) (*Cluster, error) {
-	client, err := communication.NewAgentClient(config.Address, "cluster")
+	client, err := communication.NewAgentClientWithConfig(communication.ClientConfig{
+		Address:      config.Address,
+		AgentID:      "cluster",
+		TLS:          config.TLS,
+		Token:        config.Token,
+		Interceptors: config.Interceptors,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create agent client: %w", err)
 	}
@@ -36,14 +105,95 @@ func NewCluster(config ClusterConfig) (*Cluster, error) {
 		config: config,
 		nodes:  make(map[string]*Node),
 		client: client,
+		toolClients: make(map[string]*communication.AgentClient),
+		drained:     make(map[string]bool),
+		sessions:    make(map[string]*sessionBinding),
+	}
+
+	if config.TaskRateLimit != nil {
+		limit := *config.TaskRateLimit
+		if limit.Key == "" {
+			limit.Key = "tasks"
+		}
+		cluster.taskLimiter = reliability.NewDistributedRateLimiter(client, limit)
+	}
+	if config.Queue != nil {
+		cluster.queue = NewTaskQueue(client, *config.Queue)
+		cluster.queue.onRelease = cluster.releaseQueuedTask
+	}
+	if config.Election != nil {
+		cluster.startElection(*config.Election)
+	} else {
+		go cluster.loadSessions()
+	}
+	if cluster.queue != nil {
+		cluster.startQueue()
+	}
+	if config.Autoscaler != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		cluster.stopAutoscaler = cancel
+		cluster.autoscalerDone = make(chan struct{})
+		go func() {
+			defer close(cluster.autoscalerDone)
+			cluster.runAutoscaler(ctx, config.Autoscaler)
+		}()
 	}
 
 	// Start heartbeat monitoring
-	go cluster.monitorHeartbeats()
+	ctx, cancel := context.WithCancel(context.Background())
+	cluster.stopHeartbeats = cancel
+	cluster.heartbeatsDone = make(chan struct{})
+	go func() {
+		defer close(cluster.heartbeatsDone)
+		cluster.monitorHeartbeats(ctx)
+	}()
 
 	return cluster, nil
 }
 
+// startElection campaigns for leadership, publishing leadership changes
+func (c *Cluster) startElection(config ElectionConfig) {
+	onChange := config.OnChange
+	config.OnChange = func(leader bool) {
+		eventType, verb := EventLeaderElected, "elected"
+		if !leader {
+			eventType, verb = EventLeaderLost, "lost leadership"
+		}
+		err := c.client.PublishEvent(context.Background(), eventType,
+			fmt.Sprintf("Scheduler %s %s", c.elector.ID(), verb),
+			map[string]string{"leader_id": c.elector.ID()})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error publishing %s event: %v\n", eventType, err)
+		}
+		// Tasks submitted to the previous leader, and its session
+		// bindings, live on in the store
+		if leader && c.queue != nil {
+			go c.recoverQueue()
+		}
+		if leader {
+			go c.loadSessions()
+		}
+		if onChange != nil {
+			onChange(leader)
+		}
+	}
+
+	c.elector = NewLeaderElector(c.client, config)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopElection = cancel
+	c.electionDone = make(chan struct{})
+	go func() {
+		defer close(c.electionDone)
+		c.elector.Run(ctx)
+	}()
+}
+
+// IsLeader reports whether this instance schedules tasks. Without an
+// election every instance does.
+func (c *Cluster) IsLeader() bool {
+	return c.elector == nil || c.elector.IsLeader()
+}
+
 // RegisterNode registers a new node with the cluster
 func (c *Cluster) RegisterNode(node *Node) error {
 	c.mu.Lock()
@@ -71,7 +221,7 @@ func (c *Cluster) GetNode(id string) (*Node, error) {
 
 	node, exists := c.nodes[id]
 	if !exists {
-		return nil, fmt.Errorf("node not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, id)
 	}
 
 	return node, nil
@@ -84,7 +234,7 @@ func (c *Cluster) GetHealthyNodes() []*Node {
 
 	healthy := make([]*Node, 0)
 	for _, node := range c.nodes {
-		if node.status == StatusHealthy {
+		if node.status == StatusHealthy && !node.isDraining() {
 			healthy = append(healthy, node)
 		}
 	}
@@ -92,16 +242,46 @@ func (c *Cluster) GetHealthyNodes() []*Node {
 	return healthy
 }
 
+// NodeStatuses returns the status of every registered node
+func (c *Cluster) NodeStatuses() []types.NodeStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	statuses := make([]types.NodeStatus, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		statuses = append(statuses, node.GetStatus())
+	}
+	return statuses
+}
+
 // ScheduleTask schedules a task on the most suitable node
 func (c *Cluster) ScheduleTask(ctx context.Context, task string, requirements types.TaskRequirements) (string, error) {
-	// Find suitable node based on requirements and load
-	node := c.findSuitableNode(requirements)
+	if !c.IsLeader() {
+		return "", ErrNotLeader
+	}
+	if err := c.admitTask(ctx, &requirements); err != nil {
+		return "", err
+	}
+	if c.taskLimiter != nil {
+		if err := c.taskLimiter.WaitN(ctx, 1); err != nil {
+			return "", fmt.Errorf("task rate limit: %w", err)
+		}
+	}
+
+	// Find suitable node based on requirements, session, and load
+	node := c.placeTask(requirements)
 	if node == nil {
 		return "", fmt.Errorf("no suitable node found for task")
 	}
 
-	// Execute task on selected node
-	result, err := node.ExecuteTask(ctx, requirements.AgentID, task)
+	if err := c.acquireTenantSlot(requirements.TenantID); err != nil {
+		return "", err
+	}
+	start := time.Now()
+
+	// Execute task on selected node, in the tenant's namespace
+	result, err := c.executeOn(tenant.WithTenant(ctx, requirements.TenantID), node, requirements, task)
+	c.releaseTenantSlot(requirements.TenantID, time.Since(start), err)
 	if err != nil {
 		return "", fmt.Errorf("task execution failed: %w", err)
 	}
@@ -109,16 +289,34 @@ func (c *Cluster) ScheduleTask(ctx context.Context, task string, requirements ty
 	return result, nil
 }
 
-// findSuitableNode finds the most suitable node for a task
-func (c *Cluster) findSuitableNode(requirements types.TaskRequirements) *Node {
+// prefetchTasks leases tokens of the cluster's task rate, if limited, for
+// the queued tasks leased next. Store failures are logged; the tasks wait
+// unless the limit fails open.
+func (c *Cluster) prefetchTasks(ctx context.Context) {
+	if c.taskLimiter == nil {
+		return
+	}
+	if err := c.taskLimiter.Prefetch(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to lease task rate: %v\n", err)
+	}
+}
+
+// findSuitableNode finds the most suitable node for a task, skipping the
+// excluded nodes
+func (c *Cluster) findSuitableNode(requirements types.TaskRequirements, exclude ...string) *Node {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	excluded := make(map[string]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+
 	var bestNode *Node
 	var lowestLoad float64 = 1.0
 
-	for _, node := range c.nodes {
-		if node.status != StatusHealthy {
+	for id, node := range c.nodes {
+		if node.status != StatusHealthy || excluded[id] || node.isDraining() {
 			continue
 		}
 
@@ -127,8 +325,8 @@ func (c *Cluster) findSuitableNode(requirements types.TaskRequirements) *Node {
 			continue
 		}
 
-		// Calculate load factor
-		loadFactor := float64(node.load) / float64(node.capacity)
+		// Calculate load factor, counting tasks waiting on the node
+		loadFactor := node.loadFactor()
 		if loadFactor < lowestLoad {
 			lowestLoad = loadFactor
 			bestNode = node
@@ -141,11 +339,8 @@ func (c *Cluster) findSuitableNode(requirements types.TaskRequirements) *Node {
 // nodeMatchesRequirements checks if a node meets task requirements
 func (c *Cluster) nodeMatchesRequirements(node *Node, requirements types.TaskRequirements) bool {
 	// Check if node has required agent
-	if requirements.AgentID != "" {
-		_, exists := node.agents[requirements.AgentID]
-		if !exists {
-			return false
-		}
+	if requirements.AgentID != "" && !node.hasAgent(requirements.AgentID, requirements.TenantID) {
+		return false
 	}
 
 	// Check if node has required tags
@@ -162,30 +357,89 @@ func (c *Cluster) nodeMatchesRequirements(node *Node, requirements types.TaskReq
 		}
 	}
 
+	// Check if node has the required CPU and memory available
+	if !node.hasResources(requirements) {
+		return false
+	}
+
+	// Check if node has required tools
+	if len(requirements.Tools) > 0 {
+		nodeTools := make(map[string]bool)
+		for _, name := range node.Tools() {
+			nodeTools[name] = true
+		}
+
+		for _, requiredTool := range requirements.Tools {
+			if !nodeTools[requiredTool] {
+				return false
+			}
+		}
+	}
+
 	return true
 }
 
-// monitorHeartbeats monitors node health through heartbeats
-func (c *Cluster) monitorHeartbeats() {
+// monitorHeartbeats monitors node health through heartbeats until ctx is
+// done. A round in progress finishes, so Close waits for it rather than
+// leaving rescheduled tasks half written.
+func (c *Cluster) monitorHeartbeats(ctx context.Context) {
 	ticker := time.NewTicker(c.config.HeartbeatInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var failed []string
 		c.mu.Lock()
 		for id, node := range c.nodes {
 			if time.Since(node.lastPing) > c.config.NodeTimeout {
-				node.status = StatusUnhealthy
-				c.client.PublishEvent(context.Background(), "node_unhealthy",
-					fmt.Sprintf("Node %s marked as unhealthy", id),
-					map[string]string{"node_id": id})
+				node.mu.Lock()
+				if node.status != StatusUnhealthy {
+					failed = append(failed, id)
+				}
+				node.setStatus(StatusUnhealthy)
+				node.mu.Unlock()
 			}
 		}
 		c.mu.Unlock()
+
+		for _, id := range failed {
+			c.client.PublishEvent(context.Background(), "node_unhealthy",
+				fmt.Sprintf("Node %s marked as unhealthy", id),
+				map[string]string{"node_id": id})
+		}
+		c.pruneSessions()
+
+		// Tasks held by failed nodes would otherwise never finish
+		if c.IsLeader() {
+			for _, id := range failed {
+				c.rescheduleNodeTasks(context.Background(), id, fmt.Errorf("%w: %s", ErrNodeFailed, id))
+			}
+		}
 	}
 }
 
-// Close closes the cluster and all its nodes
+// Close closes the cluster and all its nodes, resigning leadership first
 func (c *Cluster) Close() error {
+	c.stopHeartbeats()
+	<-c.heartbeatsDone
+	if c.stopAutoscaler != nil {
+		c.stopAutoscaler()
+		<-c.autoscalerDone
+	}
+	if c.stopQueue != nil {
+		c.stopQueue()
+		<-c.queueDone
+	}
+	if c.stopElection != nil {
+		c.stopElection()
+		<-c.electionDone
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -196,6 +450,12 @@ func (c *Cluster) Close() error {
 		}
 	}
 
+	for _, client := range c.toolClients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	if err := c.client.Close(); err != nil {
 		errs = append(errs, err)
 	}
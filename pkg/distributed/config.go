@@ -0,0 +1,48 @@
+package distributed
+
+import (
+	"time"
+
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/reliability"
+)
+
+// ClusterConfigFromConfig returns the cluster settings of Config.Cluster,
+// connecting to the cluster's agent server with Config.Communication.TLS
+func ClusterConfigFromConfig(cfg *config.Config) ClusterConfig {
+	cc := cfg.Cluster
+	clusterConfig := ClusterConfig{
+		Address:           cc.Address,
+		HeartbeatInterval: time.Duration(cc.HeartbeatIntervalMs) * time.Millisecond,
+		NodeTimeout:       time.Duration(cc.NodeTimeoutMs) * time.Millisecond,
+		TLS:               communication.TLSConfigFromConfig(cfg),
+		Token:             cfg.ClusterToken(),
+		NodeToken:         cc.Token,
+		DrainTimeout:      time.Duration(cc.DrainTimeoutMs) * time.Millisecond,
+		SessionTTL:        time.Duration(cc.SessionTTLMs) * time.Millisecond,
+	}
+	if cc.TaskRate > 0 {
+		clusterConfig.TaskRateLimit = &reliability.DistributedRateLimiterConfig{
+			Key:  "tasks",
+			Rate: cc.TaskRate,
+		}
+	}
+	return clusterConfig
+}
+
+// NodeConfigFromConfig returns the settings of the node described by
+// Config.Cluster, serving on Config.Server.Address
+func NodeConfigFromConfig(cfg *config.Config) NodeConfig {
+	cc := cfg.Cluster
+	return NodeConfig{
+		ID:                cc.NodeID,
+		Address:           cfg.Server.Address,
+		ClusterAddr:       cc.Address,
+		Tags:              cc.Tags,
+		TLS:               communication.TLSConfigFromConfig(cfg),
+		Token:             cfg.ClusterToken(),
+		HeartbeatInterval: time.Duration(cc.HeartbeatIntervalMs) * time.Millisecond,
+		Capacity:          cc.Capacity,
+	}
+}
@@ -0,0 +1,207 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+)
+
+// drainPollInterval is how often a draining node is checked for idleness
+const drainPollInterval = 500 * time.Millisecond
+
+// DrainNode decommissions a node, e.g. for a rolling upgrade. New tasks
+// stop being routed to it at once; DrainNode then waits up to DrainTimeout
+// for its in-flight tasks, moves its agents to other local nodes, and
+// removes it from the cluster. Tasks still running at the timeout are
+// retried elsewhere and ErrDrainTimeout is returned after the removal.
+// Cancelling ctx aborts the drain and puts the node back in service.
+//
+// A drained remote node stops heartbeating; it rejoins with Node.Join.
+func (c *Cluster) DrainNode(ctx context.Context, id string) error {
+	node, err := c.GetNode(id)
+	if err != nil {
+		return err
+	}
+	if !node.setDraining(true) {
+		return fmt.Errorf("node already draining: %s", id)
+	}
+	c.publishNodeEvent("node_draining", fmt.Sprintf("Node %s is draining", id), id, nil)
+
+	timeout := c.config.DrainTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	idle, err := c.awaitIdle(ctx, node, timeout)
+	if err != nil {
+		node.setDraining(false)
+		return fmt.Errorf("drain of node %s aborted: %w", id, err)
+	}
+	if !idle {
+		c.rescheduleNodeTasks(ctx, id, fmt.Errorf("%w: node %s", ErrDrainTimeout, id))
+	}
+
+	migrated := c.migrateAgents(node)
+
+	c.mu.Lock()
+	delete(c.nodes, id)
+	client := c.toolClients[id]
+	delete(c.toolClients, id)
+	if node.isRemote() {
+		c.drained[id] = true
+	}
+	c.mu.Unlock()
+
+	if client != nil {
+		client.Close()
+	}
+	if !node.isRemote() {
+		if err := node.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing drained node %s: %v\n", id, err)
+		}
+	}
+
+	c.publishNodeEvent("node_drained", fmt.Sprintf("Node %s drained and removed", id), id,
+		map[string]string{"migrated_agents": strconv.Itoa(migrated), "idle": strconv.FormatBool(idle)})
+
+	if !idle {
+		return fmt.Errorf("%w: node %s", ErrDrainTimeout, id)
+	}
+	return nil
+}
+
+// awaitIdle waits until the node runs no tasks, reporting false if the
+// timeout passes first
+func (c *Cluster) awaitIdle(ctx context.Context, node *Node, timeout time.Duration) (bool, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		busy := node.loadFactor() > 0
+		if c.queue != nil && c.queue.leasedTo(node.config.ID) > 0 {
+			busy = true
+		}
+		if !busy {
+			return true, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// migrateAgents registers a local node's agents on the least loaded other
+// local nodes lacking them, returning how many moved. Agents of remote
+// nodes live in their process and cannot be moved.
+func (c *Cluster) migrateAgents(from *Node) int {
+	if from.isRemote() {
+		return 0
+	}
+
+	owners := from.GetStatus().AgentTenants
+	migrated := 0
+	for id, a := range from.agentMap() {
+		target := c.migrationTarget(from.config.ID, id)
+		if target == nil {
+			fmt.Fprintf(os.Stderr, "No node to migrate agent %s from node %s to\n", id, from.config.ID)
+			continue
+		}
+		register := target.RegisterAgent
+		if owner := owners[id]; owner != "" {
+			register = func(a agent.Agent) error { return target.RegisterTenantAgent(owner, a) }
+		}
+		if err := register(a); err != nil {
+			fmt.Fprintf(os.Stderr, "Error migrating agent %s to node %s: %v\n", id, target.config.ID, err)
+			continue
+		}
+		migrated++
+	}
+	return migrated
+}
+
+// migrationTarget returns the least loaded healthy local node, other than
+// the draining one, that does not run the agent yet
+func (c *Cluster) migrationTarget(exclude, agentID string) *Node {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var best *Node
+	lowest := 0.0
+	for id, node := range c.nodes {
+		if id == exclude || node.status != StatusHealthy || node.isDraining() || node.isRemote() || node.hasAgent(agentID, "") {
+			continue
+		}
+		if load := node.loadFactor(); best == nil || load < lowest {
+			best, lowest = node, load
+		}
+	}
+	return best
+}
+
+// publishNodeEvent publishes a node lifecycle event, logging failures
+func (c *Cluster) publishNodeEvent(eventType, payload, id string, md map[string]string) {
+	if md == nil {
+		md = make(map[string]string)
+	}
+	md["node_id"] = id
+	if err := c.client.PublishEvent(context.Background(), eventType, payload, md); err != nil {
+		fmt.Fprintf(os.Stderr, "Error publishing %s event: %v\n", eventType, err)
+	}
+}
+
+// setDraining marks the node as draining or back in service, reporting
+// whether the mark changed
+func (n *Node) setDraining(draining bool) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.draining == draining {
+		return false
+	}
+	n.draining = draining
+	n.recordHealth(n.reportedStatus())
+	return true
+}
+
+// isDraining reports whether the node is being decommissioned
+func (n *Node) isDraining() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.draining
+}
+
+// agentMap returns a copy of the node's agents keyed by name
+func (n *Node) agentMap() map[string]agent.Agent {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	agents := make(map[string]agent.Agent, len(n.agents))
+	for id, a := range n.agents {
+		agents[id] = a
+	}
+	return agents
+}
+
+// leasedTo counts the tasks running on a node
+func (q *TaskQueue) leasedTo(nodeID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := 0
+	for _, t := range q.tasks {
+		if t.State == TaskRunning && t.NodeID == nodeID {
+			count++
+		}
+	}
+	return count
+}
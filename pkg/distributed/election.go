@@ -0,0 +1,177 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/communication"
+)
+
+// Leadership events published by a cluster running an election
+const (
+	EventLeaderElected = "leader_elected"
+	EventLeaderLost    = "leader_lost"
+)
+
+// LeaseStore holds election leases; *communication.AgentClient satisfies it
+type LeaseStore interface {
+	CompareAndSetState(ctx context.Context, key, value string, expected int64, ttl time.Duration) (int64, error)
+}
+
+// ElectionConfig contains configuration for leader election
+type ElectionConfig struct {
+	// Key is the state key holding the lease (default "cluster/leader")
+	Key string
+	// ID identifies this candidate (default unique per elector)
+	ID string
+	// LeaseDuration is how long leadership survives without renewal, and
+	// so bounds failover time (default 15s)
+	LeaseDuration time.Duration
+	// RenewInterval is how often the leader renews and candidates try to
+	// acquire the lease (default a third of LeaseDuration)
+	RenewInterval time.Duration
+	// OnChange is called whenever this candidate gains or loses leadership
+	OnChange func(leader bool)
+}
+
+// LeaderElector elects a single leader among candidates sharing a lease
+// key. The leader holds the key with a TTL and renews it with
+// compare-and-set; when it dies the lease expires and another candidate
+// acquires it. A lease acquired after expiry never reuses an earlier
+// version, so a leader that missed the expiry fails to renew rather than
+// renewing its successor's lease.
+type LeaderElector struct {
+	config  ElectionConfig
+	store   LeaseStore
+	leader  bool
+	version int64
+	renewed time.Time
+	mu      sync.RWMutex
+}
+
+// NewLeaderElector creates an elector; call Run to campaign
+func NewLeaderElector(store LeaseStore, config ElectionConfig) *LeaderElector {
+	if config.Key == "" {
+		config.Key = communication.NamespaceKey("cluster", "leader")
+	}
+	if config.ID == "" {
+		config.ID = fmt.Sprintf("candidate-%d", time.Now().UnixNano())
+	}
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = 15 * time.Second
+	}
+	if config.RenewInterval <= 0 || config.RenewInterval >= config.LeaseDuration {
+		config.RenewInterval = config.LeaseDuration / 3
+	}
+	return &LeaderElector{config: config, store: store}
+}
+
+// ID returns the candidate ID
+func (e *LeaderElector) ID() string {
+	return e.config.ID
+}
+
+// IsLeader reports whether this candidate currently holds the lease. A
+// leader that cannot renew stops leading as soon as its lease may have
+// expired.
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader && time.Since(e.renewed) < e.config.LeaseDuration
+}
+
+// Run campaigns for leadership until ctx is done, then resigns
+func (e *LeaderElector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			e.resign()
+			return
+		}
+	}
+}
+
+// campaign acquires the lease, or renews it when already leading
+func (e *LeaderElector) campaign(ctx context.Context) {
+	e.mu.RLock()
+	leader, version, renewed := e.leader, e.version, e.renewed
+	e.mu.RUnlock()
+
+	// Candidates only acquire a missing or expired lease
+	expected := int64(0)
+	if leader {
+		expected = version
+	}
+	attempt := time.Now()
+	version, err := e.store.CompareAndSetState(ctx, e.config.Key, e.config.ID, expected, e.config.LeaseDuration)
+
+	switch {
+	case err == nil:
+		e.mu.Lock()
+		e.version, e.renewed = version, attempt
+		e.mu.Unlock()
+		if !leader {
+			e.setLeader(true)
+		}
+	case !leader:
+	case errors.Is(err, communication.ErrVersionConflict):
+		// The lease expired and another candidate took it
+		e.setLeader(false)
+	case time.Since(renewed) >= e.config.LeaseDuration:
+		// The lease may have expired while the store was unreachable
+		fmt.Fprintf(os.Stderr, "Error renewing leader lease: %v\n", err)
+		e.setLeader(false)
+	}
+}
+
+// resign gives up the lease, if still held, so another candidate takes over
+// without waiting for it to expire
+func (e *LeaderElector) resign() {
+	e.mu.RLock()
+	leader, version := e.leader, e.version
+	e.mu.RUnlock()
+	if !leader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.RenewInterval)
+	defer cancel()
+	// A lease expiring at once is released only if no one else holds it
+	if _, err := e.store.CompareAndSetState(ctx, e.config.Key, "", version, time.Nanosecond); err != nil {
+		fmt.Fprintf(os.Stderr, "Error releasing leader lease: %v\n", err)
+	}
+	e.setLeader(false)
+}
+
+func (e *LeaderElector) setLeader(leader bool) {
+	e.mu.Lock()
+	e.leader = leader
+	if !leader {
+		e.version = 0
+	}
+	e.mu.Unlock()
+
+	if e.config.OnChange != nil {
+		e.config.OnChange(leader)
+	}
+}
+
+// Error types
+type ClusterError string
+
+func (e ClusterError) Error() string { return string(e) }
+
+const (
+	ErrNotLeader    = ClusterError("not the cluster leader")
+	ErrDrainTimeout = ClusterError("node drain timed out")
+	ErrNodeNotFound = ClusterError("node not found")
+)
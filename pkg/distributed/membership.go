@@ -0,0 +1,240 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/types"
+)
+
+// Join registers the node with the cluster behind its ClusterAddr and
+// reports its status every HeartbeatInterval until Leave or Close. The
+// cluster's agent server must have the Cluster as its node registry, and
+// the node's agent server at Address must execute tasks on the node, e.g.
+//
+//	clusterServer.SetNodeRegistry(cluster)
+//	nodeServer.SetTaskExecutor(node)
+func (n *Node) Join(ctx context.Context) error {
+	n.UpdateStatus()
+	if err := n.client.RegisterNode(ctx, n.GetStatus()); err != nil {
+		return fmt.Errorf("failed to join cluster: %w", err)
+	}
+
+	interval := n.config.HeartbeatInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	n.stopHeartbeats()
+	heartbeatCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	n.mu.Lock()
+	n.stopHeartbeat, n.heartbeatDone = cancel, done
+	n.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		n.sendHeartbeats(heartbeatCtx, interval)
+	}()
+	return nil
+}
+
+// Leave stops heartbeats and removes the node from the cluster
+func (n *Node) Leave(ctx context.Context) error {
+	n.stopHeartbeats()
+	if err := n.client.DeregisterNode(ctx); err != nil {
+		return fmt.Errorf("failed to leave cluster: %w", err)
+	}
+	return nil
+}
+
+// sendHeartbeats reports the node's status until ctx is done or the node
+// is drained, registering again when the cluster has forgotten the node,
+// e.g. after a restart
+func (n *Node) sendHeartbeats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		n.UpdateStatus()
+		status := n.GetStatus()
+		err := n.client.Heartbeat(ctx, status)
+		if errors.Is(err, communication.ErrNodeDrained) {
+			fmt.Fprintf(os.Stderr, "Node %s was drained from the cluster; stopping heartbeats\n", n.config.ID)
+			return
+		}
+		if errors.Is(err, communication.ErrNodeNotRegistered) {
+			err = n.client.RegisterNode(ctx, status)
+		}
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Error sending heartbeat for node %s: %v\n", n.config.ID, err)
+		}
+	}
+}
+
+func (n *Node) stopHeartbeats() {
+	n.mu.Lock()
+	cancel, done := n.stopHeartbeat, n.heartbeatDone
+	n.stopHeartbeat, n.heartbeatDone = nil, nil
+	n.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// newRemoteNode creates the cluster's view of a node in another process
+func newRemoteNode(status types.NodeStatus) *Node {
+	node := &Node{
+		config: NodeConfig{ID: status.ID},
+		agents: make(map[string]agent.Agent),
+	}
+	node.report(status)
+	return node
+}
+
+// isRemote reports whether the node runs in another process
+func (n *Node) isRemote() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.remote != nil
+}
+
+// report applies a status reported by a remote node
+func (n *Node) report(status types.NodeStatus) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.remote = &status
+	n.config.Address = status.Address
+	n.config.Tags = status.Tags
+	n.load, n.capacity, n.queued = status.Load, status.Capacity, status.QueueDepth
+	n.resources = Resources{CPU: status.CPU, Memory: status.Memory}
+	if n.capacity <= 0 {
+		n.capacity = 100
+	}
+	reported := NodeStatus(status.Status)
+	if reported == StatusUnknown {
+		reported = StatusHealthy
+	}
+	n.setStatus(reported)
+	// A draining node keeps its draining mark; the cluster's clock decides
+	// when the node times out
+	n.lastPing = time.Now()
+}
+
+// RegisterNodeStatus implements communication.NodeRegistry. A node that
+// registers again, e.g. after restarting, replaces its previous entry.
+func (c *Cluster) RegisterNodeStatus(ctx context.Context, status types.NodeStatus) error {
+	c.mu.Lock()
+	if existing, exists := c.nodes[status.ID]; exists && !existing.isRemote() {
+		c.mu.Unlock()
+		return fmt.Errorf("node already registered: %s", status.ID)
+	}
+	c.nodes[status.ID] = newRemoteNode(status)
+	delete(c.drained, status.ID)
+	// The node may have moved, so reconnect on next use
+	stale := c.toolClients[status.ID]
+	delete(c.toolClients, status.ID)
+	c.mu.Unlock()
+
+	if stale != nil {
+		stale.Close()
+	}
+
+	return c.client.PublishEvent(ctx, "node_registered",
+		fmt.Sprintf("Node %s registered with cluster", status.ID),
+		map[string]string{
+			"node_id": status.ID,
+			"address": status.Address,
+		})
+}
+
+// NodeHeartbeat implements communication.NodeRegistry
+func (c *Cluster) NodeHeartbeat(ctx context.Context, status types.NodeStatus) error {
+	// Status is written under the cluster lock, as monitorHeartbeats does
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.drained[status.ID] {
+		return fmt.Errorf("%w: %s", communication.ErrNodeDrained, status.ID)
+	}
+	node, exists := c.nodes[status.ID]
+	if !exists || !node.isRemote() {
+		return fmt.Errorf("%w: %s", communication.ErrNodeNotRegistered, status.ID)
+	}
+	node.report(status)
+	return nil
+}
+
+// DeregisterNode implements communication.NodeRegistry. Only nodes that
+// registered over RPC can be deregistered.
+func (c *Cluster) DeregisterNode(ctx context.Context, id string) error {
+	c.mu.Lock()
+	node, exists := c.nodes[id]
+	if !exists || !node.isRemote() {
+		c.mu.Unlock()
+		return fmt.Errorf("%w: %s", communication.ErrNodeNotRegistered, id)
+	}
+	delete(c.nodes, id)
+	client := c.toolClients[id]
+	delete(c.toolClients, id)
+	c.mu.Unlock()
+
+	if client != nil {
+		client.Close()
+	}
+
+	return c.client.PublishEvent(ctx, "node_deregistered",
+		fmt.Sprintf("Node %s deregistered from cluster", id),
+		map[string]string{"node_id": id})
+}
+
+// executeOn runs a task on a node, through its agent server when the node
+// runs in another process
+func (c *Cluster) executeOn(ctx context.Context, node *Node, requirements types.TaskRequirements, task string) (string, error) {
+	release, err := c.acquireNode(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	if !node.isRemote() {
+		return node.ExecuteTask(ctx, requirements.AgentID, task)
+	}
+
+	client, err := c.nodeClient(node)
+	if err != nil {
+		return "", err
+	}
+	var md map[string]string
+	if requirements.SessionID != "" {
+		md = map[string]string{SessionMetadataKey: requirements.SessionID}
+	}
+	return client.ExecuteAgentTask(ctx, requirements.AgentID, task, md)
+}
+
+// acquireNode takes a slot of a node's bulkhead, if bulkheads are
+// configured. The returned function frees it.
+func (c *Cluster) acquireNode(ctx context.Context, node *Node) (func(), error) {
+	if c.config.Bulkheads == nil {
+		return func() {}, nil
+	}
+	release, err := c.config.Bulkheads.Get("node." + node.config.ID).Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("node %s: %w", node.config.ID, err)
+	}
+	return release, nil
+}
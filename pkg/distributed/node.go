@@ -3,11 +3,15 @@ package distributed
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/user/modulox/pkg/agent"
 	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/communication/interceptors"
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/tools"
 	"github.com/user/modulox/pkg/types"
 )
 
@@ -17,17 +21,54 @@ type NodeConfig struct {
 	Address     string
 	ClusterAddr string
 	Tags        []string
+	// TLS secures the connection to the cluster
+	TLS *communication.TLSConfig
+	// Token authenticates to agent servers requiring authorization
+	Token string
+	// Interceptors instruments calls to the cluster
+	Interceptors *interceptors.Config
+	// HeartbeatInterval is how often a joined node reports its status
+	// (default 10s)
+	HeartbeatInterval time.Duration
+	// Capacity is how many tasks the node runs at once; further tasks wait
+	// (default 100)
+	Capacity int
+	// Resources measures the node's available CPU and memory (default
+	// SystemResources)
+	Resources ResourceProbe
+	// Version is the software version the node reports
+	Version string
 }
 
+// healthHistory bounds the status changes kept per node
+const healthHistory = 20
+
 // Node represents a single node in the distributed system
 type Node struct {
 	config    NodeConfig
 	client    *communication.AgentClient
 	agents    map[string]agent.Agent
+	// owners maps agents dedicated to a tenant to its ID
+	owners    map[string]string
+	tools     *tools.ToolRegistry
 	capacity  int
+	// load counts tasks in flight and queued those waiting for a slot
 	load      int
+	queued    int
+	slots     chan struct{}
+	resources Resources
 	status    NodeStatus
 	lastPing  time.Time
+	// remote is the last reported status of a node running in another
+	// process, which the cluster reaches through its agent server
+	remote *types.NodeStatus
+	// stopHeartbeat is set while the node is joined to a cluster
+	stopHeartbeat context.CancelFunc
+	heartbeatDone chan struct{}
+	// draining is set while the cluster decommissions the node
+	draining bool
+	// health records the latest status changes
+	health []types.HealthEvent
 	mu        sync.RWMutex
 }
 
@@ -39,59 +80,129 @@ const (
 	StatusHealthy
 	StatusOverloaded
 	StatusUnhealthy
+	StatusDraining
 )
 
+// String returns the status name
+func (s NodeStatus) String() string {
+	switch s {
+	case StatusHealthy:
+		return "healthy"
+	case StatusOverloaded:
+		return "overloaded"
+	case StatusUnhealthy:
+		return "unhealthy"
+	case StatusDraining:
+		return "draining"
+	default:
+		return "unknown"
+	}
+}
+
 // NewNode creates a new distributed node
 func NewNode(config NodeConfig) (*Node, error) {
-	client, err := communication.NewAgentClient(config.ClusterAddr, config.ID)
+	client, err := communication.NewAgentClientWithConfig(communication.ClientConfig{
+		Address:      config.ClusterAddr,
+		AgentID:      config.ID,
+		TLS:          config.TLS,
+		Token:        config.Token,
+		Interceptors: config.Interceptors,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create agent client: %w", err)
 	}
 
+	if config.Capacity <= 0 {
+		config.Capacity = 100
+	}
+	if config.Resources == nil {
+		config.Resources = SystemResources
+	}
+
 	return &Node{
 		config:    config,
 		client:    client,
 		agents:    make(map[string]agent.Agent),
-		capacity:  100, // Default capacity
+		owners:    make(map[string]string),
+		capacity:  config.Capacity,
+		slots:     make(chan struct{}, config.Capacity),
+		resources: config.Resources(),
 		status:    StatusHealthy,
 		lastPing:  time.Now(),
+		health:    []types.HealthEvent{{Status: int(StatusHealthy), At: time.Now()}},
 	}, nil
 }
 
-// RegisterAgent registers an agent with the node
+// RegisterAgent registers an agent with the node under the name its
+// GetName method returns
 func (n *Node) RegisterAgent(a agent.Agent) error {
-	n.mu.Lock()
-	defer n.mu.Unlock()
+	return n.registerAgent(a, "")
+}
 
-	if n.load >= n.capacity {
-		return fmt.Errorf("node is at capacity")
+// registerAgent registers an agent dedicated to owner, or shared when owner
+// is empty. The agent and its owner are stored together, and the previous
+// registration is restored if the registration event cannot be published.
+func (n *Node) registerAgent(a agent.Agent, owner string) error {
+	named, ok := a.(interface{ GetName() string })
+	if !ok || named.GetName() == "" {
+		return fmt.Errorf("agent has no name")
 	}
+	id := named.GetName()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
 
-	id := a.GetName()
+	previous, existed := n.agents[id]
+	previousOwner, owned := n.owners[id]
 	n.agents[id] = a
-	n.load++
+	if owner != "" {
+		n.owners[id] = owner
+	} else {
+		delete(n.owners, id)
+	}
 
 	// Publish agent registration event
-	return n.client.PublishEvent(context.Background(), "agent_registered",
+	err := n.client.PublishEvent(context.Background(), "agent_registered",
 		fmt.Sprintf("Agent %s registered on node %s", id, n.config.ID),
 		map[string]string{
 			"agent_id": id,
 			"node_id":  n.config.ID,
 		})
+	if err != nil {
+		if existed {
+			n.agents[id] = previous
+		} else {
+			delete(n.agents, id)
+		}
+		if owned {
+			n.owners[id] = previousOwner
+		} else {
+			delete(n.owners, id)
+		}
+	}
+	return err
 }
 
-// ExecuteTask executes a task on an agent
+// ExecuteTask executes a task on an agent. Agents dedicated to a tenant
+// only run tasks whose context carries that tenant.
 func (n *Node) ExecuteTask(ctx context.Context, agentID string, task string) (string, error) {
 	n.mu.RLock()
 	agent, exists := n.agents[agentID]
+	owner := n.owners[agentID]
 	n.mu.RUnlock()
 
-	if !exists {
+	if !exists || (owner != "" && owner != tenant.IDFromContext(ctx)) {
 		return "", fmt.Errorf("agent not found: %s", agentID)
 	}
 
+	release, err := n.acquireSlot(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
 	// Publish task start event
-	err := n.client.PublishEvent(ctx, "task_start",
+	err = n.client.PublishEvent(ctx, "task_start",
 		fmt.Sprintf("Starting task on agent %s", agentID),
 		map[string]string{
 			"agent_id": agentID,
@@ -124,36 +235,169 @@ func (n *Node) ExecuteTask(ctx context.Context, agentID string, task string) (st
 	return result, nil
 }
 
+// acquireSlot waits until fewer than Capacity tasks are running
+func (n *Node) acquireSlot(ctx context.Context) (func(), error) {
+	n.mu.Lock()
+	n.queued++
+	n.mu.Unlock()
+
+	select {
+	case n.slots <- struct{}{}:
+	case <-ctx.Done():
+		n.mu.Lock()
+		n.queued--
+		n.mu.Unlock()
+		return nil, ctx.Err()
+	}
+
+	n.mu.Lock()
+	n.queued--
+	n.load++
+	n.mu.Unlock()
+
+	return func() {
+		<-n.slots
+		n.mu.Lock()
+		n.load--
+		n.mu.Unlock()
+	}, nil
+}
+
+// loadFactor is the share of the node's capacity in use or waited for
+func (n *Node) loadFactor() float64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return float64(n.load+n.queued) / float64(n.capacity)
+}
+
+// hasResources reports whether the node last reported enough idle CPU and
+// available memory for the task
+func (n *Node) hasResources(requirements types.TaskRequirements) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.resources.meets(requirements.MinCPU, requirements.MinMem)
+}
+
 // GetStatus returns the current node status
 func (n *Node) GetStatus() types.NodeStatus {
+	tools := n.Tools()
+
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
+	if n.remote != nil {
+		status := *n.remote
+		status.Load, status.Capacity, status.QueueDepth = n.load, n.capacity, n.queued
+		status.CPU, status.Memory = n.resources.CPU, n.resources.Memory
+		status.Status, status.LastPing = int(n.reportedStatus()), n.lastPing
+		return status
+	}
+
+	agents := make([]string, 0, len(n.agents))
+	for id := range n.agents {
+		agents = append(agents, id)
+	}
+	sort.Strings(agents)
+	owners := make(map[string]string, len(n.owners))
+	for id, tenantID := range n.owners {
+		owners[id] = tenantID
+	}
+
 	return types.NodeStatus{
 		ID:        n.config.ID,
 		Address:   n.config.Address,
 		Load:      n.load,
 		Capacity:  n.capacity,
-		Status:    int(n.status),
+		Status:    int(n.reportedStatus()),
 		LastPing:  n.lastPing,
 		AgentCount: len(n.agents),
+		Agents:     agents,
+		Tags:       n.config.Tags,
+		Tools:      tools,
+		AgentTenants: owners,
+		QueueDepth: n.queued,
+		CPU:        n.resources.CPU,
+		Memory:     n.resources.Memory,
+		Version:    n.config.Version,
+	}
+}
+
+// reportedStatus is the node's status, or StatusDraining while it is
+// being decommissioned; callers hold n.mu
+func (n *Node) reportedStatus() NodeStatus {
+	if n.draining {
+		return StatusDraining
 	}
+	return n.status
 }
 
-// UpdateStatus updates the node's status
+// hasAgent reports whether the agent runs on the node and, unless tenantID
+// is empty, serves the tenant
+func (n *Node) hasAgent(id, tenantID string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	owners, exists := n.owners, false
+	if n.remote != nil {
+		owners = n.remote.AgentTenants
+		for _, agentID := range n.remote.Agents {
+			if agentID == id {
+				exists = true
+				break
+			}
+		}
+	} else {
+		_, exists = n.agents[id]
+	}
+
+	owner := owners[id]
+	return exists && (tenantID == "" || owner == "" || owner == tenantID)
+}
+
+// UpdateStatus measures the node's resources and updates its status
 func (n *Node) UpdateStatus() {
+	var resources Resources
+	if n.config.Resources != nil {
+		resources = n.config.Resources()
+	}
+
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
+	if n.remote == nil {
+		n.resources = resources
+	}
 	n.lastPing = time.Now()
-	if float64(n.load)/float64(n.capacity) > 0.8 {
-		n.status = StatusOverloaded
+	if float64(n.load+n.queued)/float64(n.capacity) > 0.8 {
+		n.setStatus(StatusOverloaded)
 	} else {
-		n.status = StatusHealthy
+		n.setStatus(StatusHealthy)
+	}
+}
+
+// setStatus changes the node's status, recording the change in its health
+// history; callers hold n.mu
+func (n *Node) setStatus(status NodeStatus) {
+	if n.status == status {
+		return
+	}
+	n.status = status
+	n.recordHealth(status)
+}
+
+// recordHealth appends to the health history; callers hold n.mu
+func (n *Node) recordHealth(status NodeStatus) {
+	n.health = append(n.health, types.HealthEvent{Status: int(status), At: time.Now()})
+	if len(n.health) > healthHistory {
+		n.health = append([]types.HealthEvent(nil), n.health[len(n.health)-healthHistory:]...)
 	}
 }
 
 // Close closes the node and its connections
 func (n *Node) Close() error {
+	n.stopHeartbeats()
+	if n.client == nil {
+		return nil
+	}
 	return n.client.Close()
 }
@@ -0,0 +1,774 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/types"
+)
+
+// Task events published when a queued task finishes, or is taken from a
+// failed or drained node to run elsewhere. The payload is the result or
+// error, and metadata holds task_id and node_id.
+const (
+	EventTaskCompleted   = "task_completed"
+	EventTaskFailed      = "task_failed"
+	EventTaskRescheduled = "task_rescheduled"
+)
+
+// TaskState is the lifecycle state of a queued task
+type TaskState string
+
+const (
+	TaskPending   TaskState = "pending"
+	TaskRunning   TaskState = "running"
+	TaskCompleted TaskState = "completed"
+	TaskFailed    TaskState = "failed"
+)
+
+// QueuedTask is a task submitted to the cluster's task queue
+type QueuedTask struct {
+	ID           string
+	Task         string
+	Requirements types.TaskRequirements
+	State        TaskState
+	// Attempts counts the times the task was handed to a node
+	Attempts int
+	// NodeID is the node running, or that last ran, the task
+	NodeID string
+	// FailedNodes are avoided when the task is retried
+	FailedNodes []string
+	Result      string
+	Error       string
+	// StartedAt is when the task was last handed to a node, and
+	// LeaseExpires when it is retried unless acknowledged
+	StartedAt    time.Time
+	LeaseExpires time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Done reports whether the task completed or failed for good
+func (t QueuedTask) Done() bool {
+	return t.State == TaskCompleted || t.State == TaskFailed
+}
+
+// TaskStore persists queued tasks; *communication.AgentClient satisfies it
+type TaskStore interface {
+	SyncState(ctx context.Context, key, value string) (int64, error)
+	SetStateTTL(ctx context.Context, key, value string, ttl time.Duration) (int64, error)
+	ListState(ctx context.Context, prefix string) ([]string, error)
+	WatchState(ctx context.Context, key string) (<-chan communication.StateChange, error)
+}
+
+// TaskQueueConfig contains configuration for the cluster task queue
+type TaskQueueConfig struct {
+	// Prefix is the state namespace holding tasks (default "cluster/tasks/")
+	Prefix string
+	// MaxAttempts bounds how often a task is tried before it fails (default 3)
+	MaxAttempts int
+	// AckTimeout is how long a node may hold a task without acknowledging
+	// it before it is retried elsewhere (default 5m)
+	AckTimeout time.Duration
+	// ResultTTL is how long finished tasks are kept (default 24h)
+	ResultTTL time.Duration
+	// PollInterval is how often leases are checked and, unless Pull is set,
+	// pending tasks are pushed to nodes (default 1s)
+	PollInterval time.Duration
+	// Pull leaves tasks for nodes to take with Cluster.PullTask instead of
+	// pushing them to the most suitable node
+	Pull bool
+}
+
+// TaskQueue is a durable queue of tasks awaiting nodes. Every state change
+// is written to a TaskStore, so a new leader can Recover the queue.
+type TaskQueue struct {
+	config  TaskQueueConfig
+	store   TaskStore
+	tasks   map[string]*QueuedTask
+	pending []string
+	ready   chan struct{}
+	// dirty holds the tasks changed since the last flush to the store
+	dirty map[string]bool
+	// onRelease, when set, is called as a task stops running
+	onRelease func(QueuedTask)
+	mu        sync.Mutex
+	// flushMu serializes flushes, so the store sees changes in order
+	flushMu sync.Mutex
+}
+
+// NewTaskQueue creates an empty task queue
+func NewTaskQueue(store TaskStore, config TaskQueueConfig) *TaskQueue {
+	if config.Prefix == "" {
+		config.Prefix = communication.NamespaceKey("cluster", "tasks", "")
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.AckTimeout <= 0 {
+		config.AckTimeout = 5 * time.Minute
+	}
+	if config.ResultTTL <= 0 {
+		config.ResultTTL = 24 * time.Hour
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+	return &TaskQueue{
+		config: config,
+		store:  store,
+		tasks:  make(map[string]*QueuedTask),
+		ready:  make(chan struct{}, 1),
+		dirty:  make(map[string]bool),
+	}
+}
+
+// Submit queues a task and returns its ID
+func (q *TaskQueue) Submit(ctx context.Context, task string, requirements types.TaskRequirements) (string, error) {
+	now := time.Now()
+	t := &QueuedTask{
+		ID:           types.NewID("task"),
+		Task:         task,
+		Requirements: requirements,
+		State:        TaskPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	// The task is saved before it is visible, so no later change of it
+	// can reach the store first
+	if err := q.save(ctx, t); err != nil {
+		return "", err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tasks[t.ID] = t
+	q.pending = append(q.pending, t.ID)
+	q.signal()
+	return t.ID, nil
+}
+
+// Get returns a task by ID
+func (q *TaskQueue) Get(id string) (QueuedTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, exists := q.tasks[id]
+	if !exists {
+		return QueuedTask{}, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	return *t, nil
+}
+
+// List returns the tasks in the given state, all tasks when state is
+// empty, oldest first
+func (q *TaskQueue) List(state TaskState) []QueuedTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids := make([]string, 0, len(q.tasks))
+	for id, t := range q.tasks {
+		if state == "" || t.State == state {
+			ids = append(ids, id)
+		}
+	}
+	sortTaskIDs(ids, q.tasks)
+
+	tasks := make([]QueuedTask, len(ids))
+	for i, id := range ids {
+		tasks[i] = *q.tasks[id]
+	}
+	return tasks
+}
+
+// Lease hands the oldest pending task for which pick returns a node ID to
+// that node. It returns false when no pending task can be placed.
+func (q *TaskQueue) Lease(ctx context.Context, pick func(QueuedTask) string) (QueuedTask, bool) {
+	defer q.flush(ctx)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, id := range q.pending {
+		t := q.tasks[id]
+		nodeID := pick(*t)
+		if nodeID == "" {
+			continue
+		}
+
+		q.pending = append(q.pending[:i:i], q.pending[i+1:]...)
+		now := time.Now()
+		t.State = TaskRunning
+		t.NodeID = nodeID
+		t.Attempts++
+		t.StartedAt = now
+		t.LeaseExpires = now.Add(q.config.AckTimeout)
+		t.UpdatedAt = now
+		q.persist(t)
+		return *t, true
+	}
+	return QueuedTask{}, false
+}
+
+// Ack completes a task leased to the node with its result
+func (q *TaskQueue) Ack(ctx context.Context, id, nodeID, result string) (QueuedTask, error) {
+	defer q.flush(ctx)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.leased(id, nodeID)
+	if err != nil {
+		return QueuedTask{}, err
+	}
+	t.State = TaskCompleted
+	t.Result, t.Error = result, ""
+	t.UpdatedAt = time.Now()
+	q.persist(t)
+	q.released(*t)
+	return *t, nil
+}
+
+// Nack reports that a task leased to the node failed. It is retried, on
+// another node where possible, until it has been tried MaxAttempts times.
+func (q *TaskQueue) Nack(ctx context.Context, id, nodeID string, cause error) (QueuedTask, error) {
+	defer q.flush(ctx)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.leased(id, nodeID)
+	if err != nil {
+		return QueuedTask{}, err
+	}
+	q.fail(ctx, t, cause)
+	return *t, nil
+}
+
+// leased returns a task currently leased to the node. Acknowledgements for
+// a lease that expired, and whose task moved on, are rejected.
+func (q *TaskQueue) leased(id, nodeID string) (*QueuedTask, error) {
+	t, exists := q.tasks[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	if t.State != TaskRunning || t.NodeID != nodeID {
+		return nil, fmt.Errorf("%w: %s on node %s", ErrTaskNotLeased, id, nodeID)
+	}
+	return t, nil
+}
+
+// fail records a failed attempt, requeueing the task if attempts remain
+func (q *TaskQueue) fail(ctx context.Context, t *QueuedTask, cause error) {
+	t.FailedNodes = append(t.FailedNodes, t.NodeID)
+	t.Error = cause.Error()
+	t.UpdatedAt = time.Now()
+	if t.Attempts >= q.config.MaxAttempts {
+		t.State = TaskFailed
+	} else {
+		t.State = TaskPending
+		q.pending = append(q.pending, t.ID)
+		q.signal()
+	}
+	q.persist(t)
+	q.released(*t)
+}
+
+func (q *TaskQueue) released(t QueuedTask) {
+	if q.onRelease != nil {
+		q.onRelease(t)
+	}
+}
+
+// expire fails running tasks whose lease ran out, returning them, and
+// forgets finished tasks past ResultTTL
+func (q *TaskQueue) expire(ctx context.Context) []QueuedTask {
+	defer q.flush(ctx)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	var released []QueuedTask
+	for id, t := range q.tasks {
+		switch {
+		case t.State == TaskRunning && now.After(t.LeaseExpires):
+			q.fail(ctx, t, fmt.Errorf("%w: node %s", ErrLeaseExpired, t.NodeID))
+			released = append(released, *t)
+		case t.Done() && now.Sub(t.UpdatedAt) > q.config.ResultTTL:
+			delete(q.tasks, id)
+		}
+	}
+	return released
+}
+
+// releaseNode fails the tasks running on a node so they are retried
+// elsewhere, returning them
+func (q *TaskQueue) releaseNode(ctx context.Context, nodeID string, cause error) []QueuedTask {
+	defer q.flush(ctx)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var released []QueuedTask
+	for _, t := range q.tasks {
+		if t.State != TaskRunning || t.NodeID != nodeID {
+			continue
+		}
+		q.fail(ctx, t, cause)
+		released = append(released, *t)
+	}
+	return released
+}
+
+// Recover replaces the queue's contents with the tasks in the store. Tasks
+// that were running are queued again, as their nodes' acknowledgements went
+// to the previous leader.
+func (q *TaskQueue) Recover(ctx context.Context) error {
+	keys, err := q.store.ListState(ctx, q.config.Prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	tasks := make(map[string]*QueuedTask, len(keys))
+	if len(keys) > 0 {
+		// A prefix watch starts with the current value of every key
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		changes, err := q.store.WatchState(watchCtx, q.config.Prefix+"*")
+		if err != nil {
+			return fmt.Errorf("failed to read tasks: %w", err)
+		}
+
+		remaining := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			remaining[key] = true
+		}
+		// Keys deleted or expired since they were listed never arrive;
+		// listing again while waiting drops them
+		relist := time.NewTicker(q.config.PollInterval)
+		defer relist.Stop()
+		for len(remaining) > 0 {
+			var change communication.StateChange
+			var ok bool
+			select {
+			case change, ok = <-changes:
+			case <-relist.C:
+				if err := q.dropMissing(ctx, remaining); err != nil {
+					return err
+				}
+				continue
+			case <-ctx.Done():
+				return fmt.Errorf("failed to read tasks: %w", ctx.Err())
+			}
+			if !ok {
+				return fmt.Errorf("failed to read tasks: watch closed")
+			}
+			delete(remaining, change.Key)
+			if change.Deleted {
+				continue
+			}
+
+			value, _ := change.Entry.Value.(string)
+			var t QueuedTask
+			if err := json.Unmarshal([]byte(value), &t); err != nil {
+				fmt.Fprintf(os.Stderr, "Error decoding task %s: %v\n", change.Key, err)
+				continue
+			}
+			tasks[t.ID] = &t
+		}
+	}
+
+	defer q.flush(ctx)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Tasks this queue handed out are superseded by the stored ones
+	for _, t := range q.tasks {
+		if t.State == TaskRunning {
+			t.Error = ErrLeaseExpired.Error()
+			q.released(*t)
+		}
+	}
+	q.tasks = tasks
+	q.pending = nil
+	for _, t := range tasks {
+		if t.State == TaskRunning {
+			t.State = TaskPending
+			q.persist(t)
+		}
+		if t.State == TaskPending {
+			q.pending = append(q.pending, t.ID)
+		}
+	}
+	// Keep submission order across recoveries
+	sortTaskIDs(q.pending, tasks)
+	if len(q.pending) > 0 {
+		q.signal()
+	}
+	return nil
+}
+
+// dropMissing removes keys no longer in the store from remaining
+func (q *TaskQueue) dropMissing(ctx context.Context, remaining map[string]bool) error {
+	keys, err := q.store.ListState(ctx, q.config.Prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list tasks: %w", err)
+	}
+	present := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		present[key] = true
+	}
+	for key := range remaining {
+		if !present[key] {
+			delete(remaining, key)
+		}
+	}
+	return nil
+}
+
+// save writes a task to the store, expiring finished tasks after ResultTTL
+func (q *TaskQueue) save(ctx context.Context, t *QueuedTask) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+
+	key := q.config.Prefix + t.ID
+	if t.Done() {
+		_, err = q.store.SetStateTTL(ctx, key, string(data), q.config.ResultTTL)
+	} else {
+		_, err = q.store.SyncState(ctx, key, string(data))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save task %s: %w", t.ID, err)
+	}
+	return nil
+}
+
+// persist marks a task whose change already took effect in memory for the
+// next flush. The caller holds q.mu.
+func (q *TaskQueue) persist(t *QueuedTask) {
+	q.dirty[t.ID] = true
+}
+
+// flush saves the tasks changed since the last flush, outside q.mu so the
+// store's latency does not hold up the queue. Each flush saves the tasks'
+// latest state, and flushes run one at a time, so the store never goes
+// back to an older state. Tasks that fail to save are retried by the next
+// flush.
+func (q *TaskQueue) flush(ctx context.Context) {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	q.mu.Lock()
+	tasks := make([]QueuedTask, 0, len(q.dirty))
+	for id := range q.dirty {
+		if t, exists := q.tasks[id]; exists {
+			tasks = append(tasks, *t)
+		}
+	}
+	q.dirty = make(map[string]bool)
+	q.mu.Unlock()
+
+	for i := range tasks {
+		if err := q.save(ctx, &tasks[i]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error persisting task: %v\n", err)
+			q.mu.Lock()
+			q.dirty[tasks[i].ID] = true
+			q.mu.Unlock()
+		}
+	}
+}
+
+// signal wakes the dispatcher without blocking
+func (q *TaskQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// sortTaskIDs orders task IDs by submission time
+func sortTaskIDs(ids []string, tasks map[string]*QueuedTask) {
+	sort.Slice(ids, func(i, j int) bool {
+		return tasks[ids[i]].CreatedAt.Before(tasks[ids[j]].CreatedAt)
+	})
+}
+
+// Submit queues a task for the most suitable node and returns its ID. The
+// result is available from GetTaskResult, and an EventTaskCompleted or
+// EventTaskFailed event is published when the task finishes.
+func (c *Cluster) Submit(ctx context.Context, task string, requirements types.TaskRequirements) (string, error) {
+	if err := c.queueReady(); err != nil {
+		return "", err
+	}
+	if err := c.admitTask(ctx, &requirements); err != nil {
+		return "", err
+	}
+	return c.queue.Submit(ctx, task, requirements)
+}
+
+// GetTaskResult returns the result of a completed task. It fails with
+// ErrTaskPending until the task finishes, and with ErrTaskFailed once it
+// has failed MaxAttempts times.
+func (c *Cluster) GetTaskResult(id string) (string, error) {
+	t, err := c.GetTask(id)
+	if err != nil {
+		return "", err
+	}
+	switch t.State {
+	case TaskCompleted:
+		return t.Result, nil
+	case TaskFailed:
+		return "", fmt.Errorf("%w: %s", ErrTaskFailed, t.Error)
+	}
+	return "", fmt.Errorf("%w: %s", ErrTaskPending, id)
+}
+
+// GetTask returns a queued task, including its state and attempts
+func (c *Cluster) GetTask(id string) (QueuedTask, error) {
+	if c.queue == nil {
+		return QueuedTask{}, ErrQueueDisabled
+	}
+	return c.queue.Get(id)
+}
+
+// ListTasks returns the queued tasks in the given state, all tasks when
+// state is empty, oldest first. Finished tasks are kept for ResultTTL.
+func (c *Cluster) ListTasks(state TaskState) ([]QueuedTask, error) {
+	if c.queue == nil {
+		return nil, ErrQueueDisabled
+	}
+	return c.queue.List(state), nil
+}
+
+// PullTask leases the oldest pending task the node can run, for nodes
+// taking work rather than being pushed it. It returns false when there is
+// none. The node must AckTask or NackTask it within AckTimeout.
+func (c *Cluster) PullTask(ctx context.Context, nodeID string) (QueuedTask, bool, error) {
+	if err := c.queueReady(); err != nil {
+		return QueuedTask{}, false, err
+	}
+	node, err := c.GetNode(nodeID)
+	if err != nil {
+		return QueuedTask{}, false, err
+	}
+
+	c.prefetchTasks(ctx)
+	t, ok := c.queue.Lease(ctx, func(t QueuedTask) string {
+		if !c.pullable(node, t) || !c.admitQueued(t.Requirements.TenantID) {
+			return ""
+		}
+		return nodeID
+	})
+	if ok && t.Requirements.SessionID != "" {
+		c.bindSession(t.Requirements, nodeID)
+	}
+	return t, ok, nil
+}
+
+// pullable reports whether a node may take a task, leaving tasks of
+// sessions bound to other available nodes to those nodes
+func (c *Cluster) pullable(node *Node, t QueuedTask) bool {
+	bound := false
+	if t.Requirements.SessionID != "" {
+		boundNode := c.boundNode(t.Requirements)
+		if boundNode != nil && boundNode != node {
+			return false
+		}
+		bound = boundNode == node
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	// Overloaded nodes keep taking the tasks of sessions bound to them
+	available := node.status == StatusHealthy || bound && node.status == StatusOverloaded
+	return available && !node.isDraining() && c.nodeMatchesRequirements(node, t.Requirements)
+}
+
+// AckTask completes a task the node was given
+func (c *Cluster) AckTask(ctx context.Context, id, nodeID, result string) error {
+	if err := c.queueReady(); err != nil {
+		return err
+	}
+	t, err := c.queue.Ack(ctx, id, nodeID, result)
+	if err != nil {
+		return err
+	}
+	c.publishTaskEvent(ctx, t)
+	return nil
+}
+
+// NackTask reports that a task the node was given failed, so it is retried
+func (c *Cluster) NackTask(ctx context.Context, id, nodeID string, cause error) error {
+	if err := c.queueReady(); err != nil {
+		return err
+	}
+	t, err := c.queue.Nack(ctx, id, nodeID, cause)
+	if err != nil {
+		return err
+	}
+	if t.Done() {
+		c.publishTaskEvent(ctx, t)
+	}
+	return nil
+}
+
+// queueReady checks that this instance runs the task queue
+func (c *Cluster) queueReady() error {
+	if c.queue == nil {
+		return ErrQueueDisabled
+	}
+	if !c.IsLeader() {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// queueRecoveryTimeout bounds loading the task queue from the store
+const queueRecoveryTimeout = 30 * time.Second
+
+// startQueue recovers queued tasks and runs the dispatcher until Close
+func (c *Cluster) startQueue() {
+	// With an election the queue is recovered on taking over instead
+	if c.elector == nil {
+		c.recoverQueue()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopQueue = cancel
+	c.queueDone = make(chan struct{})
+	go func() {
+		defer close(c.queueDone)
+		c.dispatchTasks(ctx)
+	}()
+}
+
+// recoverQueue loads the tasks persisted by this or a previous leader
+func (c *Cluster) recoverQueue() {
+	ctx, cancel := context.WithTimeout(context.Background(), queueRecoveryTimeout)
+	defer cancel()
+	if err := c.queue.Recover(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error recovering task queue: %v\n", err)
+	}
+}
+
+// dispatchTasks retries tasks whose lease expired and, unless nodes pull
+// tasks, pushes pending tasks to suitable nodes while this instance leads
+func (c *Cluster) dispatchTasks(ctx context.Context) {
+	ticker := time.NewTicker(c.queue.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.queue.ready:
+		case <-ctx.Done():
+			return
+		}
+		if !c.IsLeader() {
+			continue
+		}
+
+		for _, t := range c.queue.expire(ctx) {
+			c.publishTaskEvent(ctx, t)
+		}
+		if c.queue.config.Pull {
+			continue
+		}
+
+		for {
+			c.prefetchTasks(ctx)
+			t, ok := c.queue.Lease(ctx, func(t QueuedTask) string {
+				// Prefer nodes the task has not failed on
+				node := c.placeTask(t.Requirements, t.FailedNodes...)
+				if node == nil {
+					node = c.placeTask(t.Requirements)
+				}
+				// Tasks wait while the cluster's task rate is spent, and
+				// tasks of tenants at their concurrency cap wait, letting
+				// other tenants' tasks through
+				if node == nil || !c.admitQueued(t.Requirements.TenantID) {
+					return ""
+				}
+				return node.config.ID
+			})
+			if !ok {
+				break
+			}
+			go c.runTask(ctx, t)
+		}
+	}
+}
+
+// runTask executes a pushed task on its node and acknowledges the outcome
+func (c *Cluster) runTask(ctx context.Context, t QueuedTask) {
+	var result string
+	node, err := c.GetNode(t.NodeID)
+	if err == nil {
+		runCtx, cancel := context.WithTimeout(ctx, c.queue.config.AckTimeout)
+		result, err = c.executeOn(tenant.WithTenant(runCtx, t.Requirements.TenantID), node, t.Requirements, t.Task)
+		cancel()
+	}
+	// On shutdown the task stays running in the store and is recovered
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		err = c.NackTask(ctx, t.ID, t.NodeID, err)
+	} else {
+		err = c.AckTask(ctx, t.ID, t.NodeID, result)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error acknowledging task %s: %v\n", t.ID, err)
+	}
+}
+
+// rescheduleNodeTasks requeues the tasks a node holds, so they run on
+// healthy nodes, unless they are out of attempts
+func (c *Cluster) rescheduleNodeTasks(ctx context.Context, nodeID string, cause error) {
+	if c.queue == nil {
+		return
+	}
+	for _, t := range c.queue.releaseNode(ctx, nodeID, cause) {
+		c.publishTaskEvent(ctx, t)
+	}
+}
+
+// publishTaskEvent announces a finished or rescheduled task
+func (c *Cluster) publishTaskEvent(ctx context.Context, t QueuedTask) {
+	eventType, payload := EventTaskCompleted, t.Result
+	switch t.State {
+	case TaskFailed:
+		eventType, payload = EventTaskFailed, t.Error
+	case TaskPending:
+		eventType, payload = EventTaskRescheduled, t.Error
+	}
+	err := c.client.PublishEvent(ctx, eventType, payload, map[string]string{
+		"task_id": t.ID,
+		"node_id": t.NodeID,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error publishing %s event: %v\n", eventType, err)
+	}
+}
+
+// Error types
+type TaskError string
+
+func (e TaskError) Error() string { return string(e) }
+
+const (
+	ErrQueueDisabled = TaskError("task queue not enabled")
+	ErrTaskNotFound  = TaskError("task not found")
+	ErrTaskNotLeased = TaskError("task not leased to node")
+	ErrTaskPending   = TaskError("task not finished")
+	ErrTaskFailed    = TaskError("task failed")
+	ErrLeaseExpired  = TaskError("task lease expired")
+	ErrNodeFailed    = TaskError("node failed")
+)
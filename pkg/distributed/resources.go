@@ -0,0 +1,63 @@
+package distributed
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Resources are the compute resources available on a node
+type Resources struct {
+	// CPU is the number of idle cores
+	CPU float64
+	// Memory is the available memory in bytes, zero when unknown
+	Memory int64
+}
+
+// ResourceProbe measures the resources available on a node
+type ResourceProbe func() Resources
+
+// SystemResources estimates available resources from /proc on Linux: idle
+// cores from the one-minute load average and memory from MemAvailable.
+// Elsewhere it reports every core idle and memory as unknown.
+func SystemResources() Resources {
+	cores := float64(runtime.NumCPU())
+	resources := Resources{CPU: cores}
+
+	if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			if load, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				resources.CPU = cores - load
+				if resources.CPU < 0 {
+					resources.CPU = 0
+				}
+			}
+		}
+	}
+
+	if f, err := os.Open("/proc/meminfo"); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			// e.g. "MemAvailable:   12345678 kB"
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+				if kb, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+					resources.Memory = kb * 1024
+				}
+				break
+			}
+		}
+	}
+
+	return resources
+}
+
+// meets reports whether the resources satisfy a task's minimums. Unknown
+// memory is assumed sufficient, so nodes that cannot measure it still take
+// tasks with a memory minimum.
+func (r Resources) meets(minCPU float64, minMem int64) bool {
+	return r.CPU >= minCPU && (r.Memory == 0 || r.Memory >= minMem)
+}
@@ -0,0 +1,99 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/types"
+)
+
+// RegisterTenantAgent registers an agent dedicated to a tenant. Only that
+// tenant's tasks are scheduled on it, and the tasks run with the tenant in
+// their context, so memory and state the agent uses stay in the tenant's
+// namespace.
+func (n *Node) RegisterTenantAgent(tenantID string, a agent.Agent) error {
+	if tenantID == "" {
+		return fmt.Errorf("tenant agent needs a tenant ID")
+	}
+	if err := tenant.ValidID(tenantID); err != nil {
+		return err
+	}
+	return n.registerAgent(a, tenantID)
+}
+
+// admitTask attributes a task to the context's tenant and applies the
+// tenant's rate limit. Only admins may submit tasks for another tenant.
+func (c *Cluster) admitTask(ctx context.Context, requirements *types.TaskRequirements) error {
+	az := c.config.Authorizer
+	if requirements.TenantID == "" || az == nil || !az.IsAdmin(ctx) {
+		requirements.TenantID = tenant.IDFromContext(ctx)
+	}
+	if err := tenant.ValidID(requirements.TenantID); err != nil {
+		return err
+	}
+	if c.config.Tenants == nil {
+		return nil
+	}
+	if err := c.config.Tenants.Allow(tenant.WithTenant(ctx, requirements.TenantID)); err != nil {
+		return fmt.Errorf("tenant %s: %w", requirements.TenantID, err)
+	}
+	return nil
+}
+
+// acquireTenantSlot takes one of the tenant's concurrent task slots
+func (c *Cluster) acquireTenantSlot(tenantID string) error {
+	if c.config.Tenants == nil {
+		return nil
+	}
+	if err := c.config.Tenants.AcquireTask(tenantID); err != nil {
+		return fmt.Errorf("tenant %s: %w", tenantID, err)
+	}
+	return nil
+}
+
+// releaseTenantSlot frees a task slot and accounts for the task
+func (c *Cluster) releaseTenantSlot(tenantID string, duration time.Duration, err error) {
+	if c.config.Tenants != nil {
+		c.config.Tenants.ReleaseTask(tenantID, duration, err)
+	}
+}
+
+// admitQueued takes a tenant slot and a token of the task rate for a queued
+// task about to be leased, returning the slot when the rate is spent. The
+// slot is taken first so a throttled tenant does not spend the rate; the
+// token is taken from the tokens prefetched by prefetchTasks, so admitQueued
+// is safe to call under the queue's lock.
+func (c *Cluster) admitQueued(tenantID string) bool {
+	if c.acquireTenantSlot(tenantID) != nil {
+		return false
+	}
+	if c.taskLimiter != nil && !c.taskLimiter.TryAllow() {
+		if c.config.Tenants != nil {
+			c.config.Tenants.ReturnTask(tenantID)
+		}
+		return false
+	}
+	return true
+}
+
+// releaseQueuedTask frees the slot of a queued task leaving the running
+// state
+func (c *Cluster) releaseQueuedTask(t QueuedTask) {
+	var err error
+	if t.State != TaskCompleted {
+		err = errors.New(t.Error)
+	}
+	c.releaseTenantSlot(t.Requirements.TenantID, t.UpdatedAt.Sub(t.StartedAt), err)
+}
+
+// TenantUsage returns a tenant's usage of the cluster
+func (c *Cluster) TenantUsage(tenantID string) (tenant.Usage, error) {
+	if c.config.Tenants == nil {
+		return tenant.Usage{}, fmt.Errorf("tenant quotas not enabled")
+	}
+	return c.config.Tenants.Usage(tenantID)
+}
@@ -0,0 +1,182 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/tools"
+	"github.com/user/modulox/pkg/types"
+)
+
+// RegisterTools attaches the node's tool registry and advertises its tools
+// to the cluster
+func (n *Node) RegisterTools(ctx context.Context, registry *tools.ToolRegistry) error {
+	n.mu.Lock()
+	n.tools = registry
+	n.mu.Unlock()
+
+	return n.AdvertiseTools(ctx)
+}
+
+// AdvertiseTools publishes the node's current tool names so the cluster can
+// route tasks and tool calls to it. Call it again after the registry changes.
+func (n *Node) AdvertiseTools(ctx context.Context) error {
+	names := n.Tools()
+
+	if _, err := n.client.SyncState(ctx, "tools/"+n.config.ID, strings.Join(names, ",")); err != nil {
+		return fmt.Errorf("failed to advertise tools: %w", err)
+	}
+
+	return n.client.PublishEvent(ctx, "tools_advertised",
+		fmt.Sprintf("Node %s advertises %d tools", n.config.ID, len(names)),
+		map[string]string{
+			"node_id": n.config.ID,
+			"address": n.config.Address,
+			"tools":   strings.Join(names, ","),
+		})
+}
+
+// Tools returns the sorted names of the tools registered on the node
+func (n *Node) Tools() []string {
+	n.mu.RLock()
+	registry, remote := n.tools, n.remote
+	n.mu.RUnlock()
+
+	if remote != nil {
+		return remote.Tools
+	}
+	if registry == nil {
+		return nil
+	}
+
+	capabilities := registry.DiscoverCapabilities()
+	names := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		names = append(names, capability.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// toolDescriptions returns the descriptions of the node's tools keyed by name
+func (n *Node) toolDescriptions() map[string]string {
+	n.mu.RLock()
+	registry, remote := n.tools, n.remote
+	n.mu.RUnlock()
+
+	descriptions := make(map[string]string)
+	if remote != nil {
+		// Remote nodes report tool names only
+		for _, name := range remote.Tools {
+			descriptions[name] = fmt.Sprintf("Tool %s on node %s", name, n.config.ID)
+		}
+		return descriptions
+	}
+	if registry == nil {
+		return descriptions
+	}
+	for _, capability := range registry.DiscoverCapabilities() {
+		descriptions[capability.Name] = capability.Description
+	}
+	return descriptions
+}
+
+// InvokeTool runs a tool on the least loaded healthy node that has it
+func (c *Cluster) InvokeTool(ctx context.Context, name string, input interface{}) (interface{}, error) {
+	node := c.findToolNode(name)
+	if node == nil {
+		return nil, fmt.Errorf("no node has tool: %s", name)
+	}
+	release, err := c.acquireNode(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	client, err := c.nodeClient(node)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := client.InvokeTool(ctx, name, input)
+	if err != nil {
+		return nil, fmt.Errorf("remote tool %s failed on node %s: %w", name, node.config.ID, err)
+	}
+	return output, nil
+}
+
+// RegisterRemoteTools registers every tool available on a healthy node into
+// registry, so agents using it can invoke them as if they were local. Tools
+// already present in registry are left untouched.
+func (c *Cluster) RegisterRemoteTools(registry *tools.ToolRegistry) error {
+	local := make(map[string]bool)
+	for _, capability := range registry.DiscoverCapabilities() {
+		local[capability.Name] = true
+	}
+
+	remote := make(map[string]string)
+	for _, node := range c.GetHealthyNodes() {
+		for name, description := range node.toolDescriptions() {
+			if _, seen := remote[name]; !seen && !local[name] {
+				remote[name] = description
+			}
+		}
+	}
+
+	for name, description := range remote {
+		tool := &RemoteTool{cluster: c, name: name, description: description}
+		if err := registry.RegisterContextTool(name, tool, nil); err != nil {
+			return fmt.Errorf("failed to register remote tool %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// findToolNode returns the least loaded healthy node that has the tool
+func (c *Cluster) findToolNode(name string) *Node {
+	return c.findSuitableNode(types.TaskRequirements{Tools: []string{name}})
+}
+
+// nodeClient returns a client connected to the node's agent server
+func (c *Cluster) nodeClient(node *Node) (*communication.AgentClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, exists := c.toolClients[node.config.ID]; exists {
+		return client, nil
+	}
+
+	client, err := communication.NewAgentClientWithConfig(communication.ClientConfig{
+		Address:      node.config.Address,
+		AgentID:      "cluster",
+		TLS:          c.config.TLS,
+		Token:        c.config.NodeToken,
+		Interceptors: c.config.Interceptors,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to node %s: %w", node.config.ID, err)
+	}
+	c.toolClients[node.config.ID] = client
+	return client, nil
+}
+
+// RemoteTool invokes a tool on whichever cluster node currently provides it
+type RemoteTool struct {
+	cluster     *Cluster
+	name        string
+	description string
+}
+
+// Execute runs the tool on a remote node
+func (t *RemoteTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	return t.cluster.InvokeTool(ctx, t.name, input)
+}
+
+// GetDescription returns the description advertised by the remote node
+func (t *RemoteTool) GetDescription() string {
+	return t.description
+}
@@ -0,0 +1,102 @@
+package distributed
+
+import (
+	"sort"
+	"time"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+// Describe returns the cluster's topology as seen by a tenant: every node
+// with its load, version, recent health, and the agents the tenant may
+// use, plus the tenant's work waiting for them. An empty tenantID
+// describes the whole cluster. It implements communication.TopologySource.
+func (c *Cluster) Describe(tenantID string) types.ClusterTopology {
+	topology := types.ClusterTopology{
+		Leader:      c.IsLeader(),
+		GeneratedAt: time.Now(),
+	}
+	if c.elector != nil {
+		topology.SchedulerID = c.elector.ID()
+	}
+
+	c.mu.RLock()
+	nodes := make([]*Node, 0, len(c.nodes))
+	for _, node := range c.nodes {
+		nodes = append(nodes, node)
+	}
+	c.mu.RUnlock()
+
+	for _, node := range nodes {
+		topology.Nodes = append(topology.Nodes, node.describe(tenantID))
+	}
+	sort.Slice(topology.Nodes, func(i, j int) bool {
+		return topology.Nodes[i].Node.ID < topology.Nodes[j].Node.ID
+	})
+
+	if c.queue != nil {
+		topology.PendingTasks = c.queue.tenantPendingCount(tenantID)
+	}
+
+	c.sessionMu.Lock()
+	for _, binding := range c.sessions {
+		if !c.sessionExpired(binding) && visibleTo(binding.requirements.TenantID, tenantID) {
+			topology.Sessions++
+		}
+	}
+	c.sessionMu.Unlock()
+
+	return topology
+}
+
+// describe returns the node's status and health history, leaving out the
+// agents of tenants other than tenantID
+func (n *Node) describe(tenantID string) types.NodeTopology {
+	status := n.GetStatus()
+	if tenantID != "" {
+		agents := make([]string, 0, len(status.Agents))
+		owners := make(map[string]string)
+		for _, id := range status.Agents {
+			switch owner := status.AgentTenants[id]; owner {
+			case "":
+				agents = append(agents, id)
+			case tenantID:
+				agents = append(agents, id)
+				owners[id] = owner
+			}
+		}
+		status.Agents, status.AgentTenants, status.AgentCount = agents, owners, len(agents)
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	return types.NodeTopology{
+		Node:   status,
+		State:  n.reportedStatus().String(),
+		Remote: n.remote != nil,
+		Health: append([]types.HealthEvent(nil), n.health...),
+	}
+}
+
+// tenantPendingCount counts the tasks of a tenant waiting for a node, or
+// every waiting task when tenantID is empty
+func (q *TaskQueue) tenantPendingCount(tenantID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if tenantID == "" {
+		return len(q.pending)
+	}
+	count := 0
+	for _, id := range q.pending {
+		if t, exists := q.tasks[id]; exists && t.Requirements.TenantID == tenantID {
+			count++
+		}
+	}
+	return count
+}
+
+// visibleTo reports whether work owned by owner is visible to tenantID
+func visibleTo(owner, tenantID string) bool {
+	return tenantID == "" || owner == tenantID
+}
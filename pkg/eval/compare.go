@@ -0,0 +1,288 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+
+	"github.com/user/modulox/pkg/observability"
+)
+
+// Variant is one configuration under comparison, e.g. the agent of a
+// modulox.App built from a configuration file with a different model,
+// prompt, or temperature; modulox.NewVariant builds one
+type Variant struct {
+	Name   string
+	Target Target
+}
+
+// Winners of a compared case. Cases a scorer failed on for either variant
+// are unscored rather than won.
+const (
+	WinnerA        = "a"
+	WinnerB        = "b"
+	WinnerTie      = "tie"
+	WinnerUnscored = "unscored"
+)
+
+// CompareConfig contains configuration for A/B comparisons
+type CompareConfig struct {
+	Runner RunnerConfig
+	// Tolerance is the largest difference in case score counted as a tie
+	Tolerance float64
+	// Metrics records the reports of both variants and their
+	// eval_win_rate, labelled with the dataset and variant name
+	Metrics *observability.MetricsCollector
+}
+
+// DefaultCompareConfig returns a default comparison configuration
+func DefaultCompareConfig() CompareConfig {
+	return CompareConfig{
+		Runner:    DefaultRunnerConfig(),
+		Tolerance: 0.01,
+	}
+}
+
+// CaseDiff compares the results of both variants for one case. Scores
+// are the mean of the successful scorer values, zero when the target
+// failed.
+type CaseDiff struct {
+	ID       string             `json:"id"`
+	Input    string             `json:"input"`
+	Expected string             `json:"expected"`
+	A        CaseResult         `json:"a"`
+	B        CaseResult         `json:"b"`
+	ScoreA   float64            `json:"score_a"`
+	ScoreB   float64            `json:"score_b"`
+	Deltas   map[string]float64 `json:"deltas,omitempty"`
+	Winner   string             `json:"winner"`
+}
+
+// Regressed reports whether the case passed with A but not with B
+func (d CaseDiff) Regressed() bool {
+	return d.A.Passed && !d.B.Passed
+}
+
+// Improved reports whether the case passed with B but not with A
+func (d CaseDiff) Improved() bool {
+	return !d.A.Passed && d.B.Passed
+}
+
+// CompareSummary aggregates a comparison. Deltas are B minus A.
+type CompareSummary struct {
+	WinsA          int                `json:"wins_a"`
+	WinsB          int                `json:"wins_b"`
+	Ties           int                `json:"ties"`
+	Unscored       int                `json:"unscored,omitempty"`
+	WinRateA       float64            `json:"win_rate_a"`
+	WinRateB       float64            `json:"win_rate_b"`
+	TieRate        float64            `json:"tie_rate"`
+	Regressions    int                `json:"regressions"`
+	Improvements   int                `json:"improvements"`
+	PassRateDelta  float64            `json:"pass_rate_delta"`
+	ScorerDeltas   map[string]float64 `json:"scorer_deltas"`
+	LatencyDeltaMs int64              `json:"mean_latency_delta_ms"`
+}
+
+// Comparison is the outcome of running a dataset against two variants
+type Comparison struct {
+	Dataset string         `json:"dataset"`
+	A       *Report        `json:"a"`
+	B       *Report        `json:"b"`
+	Cases   []CaseDiff     `json:"cases"`
+	Summary CompareSummary `json:"summary"`
+}
+
+// Comparer runs a dataset against two variants with the same scorers
+type Comparer struct {
+	config CompareConfig
+	runner *Runner
+}
+
+// NewComparer creates a comparer scoring both variants with scorers
+func NewComparer(config CompareConfig, scorers ...Scorer) *Comparer {
+	return &Comparer{config: config, runner: NewRunner(config.Runner, scorers...)}
+}
+
+// Compare runs the dataset against a and then b and diffs the results
+// case by case. A case is won by the variant with the higher score.
+func (c *Comparer) Compare(ctx context.Context, dataset *Dataset, a, b Variant) (*Comparison, error) {
+	if a.Name == "" || b.Name == "" || a.Name == b.Name {
+		return nil, fmt.Errorf("variants need distinct names, got %q and %q", a.Name, b.Name)
+	}
+	reportA, err := c.runner.Run(ctx, dataset, a.Target)
+	if err != nil {
+		return nil, fmt.Errorf("variant %s: %w", a.Name, err)
+	}
+	reportA.Target = a.Name
+	reportB, err := c.runner.Run(ctx, dataset, b.Target)
+	if err != nil {
+		return nil, fmt.Errorf("variant %s: %w", b.Name, err)
+	}
+	reportB.Target = b.Name
+
+	comparison := &Comparison{
+		Dataset: dataset.Name,
+		A:       reportA,
+		B:       reportB,
+		Cases:   make([]CaseDiff, len(dataset.Cases)),
+	}
+	summary := CompareSummary{ScorerDeltas: make(map[string]float64)}
+	for i, cs := range dataset.Cases {
+		diff := c.diff(cs, reportA.Results[i], reportB.Results[i])
+		switch diff.Winner {
+		case WinnerA:
+			summary.WinsA++
+		case WinnerB:
+			summary.WinsB++
+		case WinnerUnscored:
+			summary.Unscored++
+		default:
+			summary.Ties++
+		}
+		if diff.Regressed() {
+			summary.Regressions++
+		}
+		if diff.Improved() {
+			summary.Improvements++
+		}
+		comparison.Cases[i] = diff
+	}
+	total := float64(len(dataset.Cases))
+	summary.WinRateA = float64(summary.WinsA) / total
+	summary.WinRateB = float64(summary.WinsB) / total
+	summary.TieRate = float64(summary.Ties) / total
+	summary.PassRateDelta = reportB.Summary.PassRate - reportA.Summary.PassRate
+	for _, name := range reportA.Scorers {
+		summary.ScorerDeltas[name] = reportB.Summary.Scorers[name].Mean - reportA.Summary.Scorers[name].Mean
+	}
+	summary.LatencyDeltaMs = reportB.Summary.MeanLatencyMs - reportA.Summary.MeanLatencyMs
+	comparison.Summary = summary
+
+	if c.config.Metrics != nil {
+		comparison.RecordMetrics(ctx, c.config.Metrics)
+	}
+	return comparison, nil
+}
+
+// diff compares the results of one case
+func (c *Comparer) diff(cs Case, a, b CaseResult) CaseDiff {
+	diff := CaseDiff{
+		ID:       cs.ID,
+		Input:    cs.Input,
+		Expected: cs.Expected,
+		A:        a,
+		B:        b,
+		ScoreA:   meanScore(a),
+		ScoreB:   meanScore(b),
+	}
+	if a.Scores != nil && b.Scores != nil {
+		diff.Deltas = make(map[string]float64, len(a.Scores))
+		for name, score := range a.Scores {
+			if other, ok := b.Scores[name]; ok && score.Error == "" && other.Error == "" {
+				diff.Deltas[name] = other.Value - score.Value
+			}
+		}
+	}
+	switch delta := diff.ScoreB - diff.ScoreA; {
+	case scorerFailed(a) || scorerFailed(b):
+		diff.Winner = WinnerUnscored
+	case math.Abs(delta) <= c.config.Tolerance:
+		diff.Winner = WinnerTie
+	case delta > 0:
+		diff.Winner = WinnerB
+	default:
+		diff.Winner = WinnerA
+	}
+	return diff
+}
+
+// meanScore averages the successful scores of a result; failed targets
+// score zero
+func meanScore(result CaseResult) float64 {
+	var total float64
+	scored := 0
+	for _, score := range result.Scores {
+		if score.Error == "" {
+			total += score.Value
+			scored++
+		}
+	}
+	if scored == 0 {
+		return 0
+	}
+	return total / float64(scored)
+}
+
+// scorerFailed reports whether any scorer failed on a result
+func scorerFailed(result CaseResult) bool {
+	for _, score := range result.Scores {
+		if score.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordMetrics records the reports of both variants and their win rates
+// as eval_win_rate gauges
+func (c *Comparison) RecordMetrics(ctx context.Context, metrics *observability.MetricsCollector) {
+	c.A.RecordMetrics(ctx, metrics)
+	c.B.RecordMetrics(ctx, metrics)
+	for variant, rate := range map[string]float64{c.A.Target: c.Summary.WinRateA, c.B.Target: c.Summary.WinRateB} {
+		metrics.RecordMetric(ctx, observability.Metric{
+			Name:   "eval_win_rate",
+			Type:   observability.GaugeMetric,
+			Value:  rate,
+			Labels: map[string]string{"dataset": c.Dataset, "target": variant},
+		})
+	}
+}
+
+// WriteJSON writes the comparison as indented JSON
+func (c *Comparison) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+// WriteHTML writes the comparison as a standalone HTML page
+func (c *Comparison) WriteHTML(w io.Writer) error {
+	return comparisonTemplate.Execute(w, c)
+}
+
+// comparisonTemplate renders a Comparison as HTML
+var comparisonTemplate = template.Must(template.New("comparison").Funcs(reportFuncs).Parse(comparisonHTML))
+
+const comparisonHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Comparison: {{.Dataset}}</title>
+<style>` + reportCSS + `</style>
+</head>
+<body>
+<h1>Comparison: {{.Dataset}}, {{.A.Target}} vs {{.B.Target}}</h1>
+<table>
+<tr><th>Variant</th><th>Wins</th><th>Win rate</th><th>Pass rate</th><th>Mean latency</th>{{range .A.Scorers}}<th>{{.}}</th>{{end}}</tr>
+<tr><td>{{.A.Target}}</td><td>{{.Summary.WinsA}}</td><td>{{percent .Summary.WinRateA}}</td><td>{{percent .A.Summary.PassRate}}</td><td>{{.A.Summary.MeanLatencyMs}} ms</td>{{range $name := .A.Scorers}}<td>{{decimal (index $.A.Summary.Scorers $name).Mean}}</td>{{end}}</tr>
+<tr><td>{{.B.Target}}</td><td>{{.Summary.WinsB}}</td><td>{{percent .Summary.WinRateB}}</td><td>{{percent .B.Summary.PassRate}}</td><td>{{.B.Summary.MeanLatencyMs}} ms</td>{{range $name := .B.Scorers}}<td>{{decimal (index $.B.Summary.Scorers $name).Mean}}</td>{{end}}</tr>
+</table>
+<p>{{.Summary.Ties}} ties, {{if .Summary.Unscored}}<span class="fail">{{.Summary.Unscored}} unscored</span>, {{end}}{{.Summary.Regressions}} regressions, {{.Summary.Improvements}} improvements.</p>
+<table>
+<tr><th>Case</th><th>Winner</th><th>Input</th><th>Expected</th><th>{{.A.Target}}</th><th>{{.B.Target}}</th></tr>
+{{range .Cases}}<tr>
+<td>{{.ID}}</td>
+<td>{{if eq .Winner "a"}}{{$.A.Target}}{{else if eq .Winner "b"}}{{$.B.Target}}{{else if eq .Winner "unscored"}}<span class="fail">unscored</span>{{else}}tie{{end}}{{if .Regressed}} <span class="fail">regressed</span>{{else if .Improved}} <span class="pass">improved</span>{{end}}</td>
+<td class="text">{{.Input}}</td>
+<td class="text">{{.Expected}}</td>
+<td class="text">{{if .A.Error}}<span class="fail">{{.A.Error}}</span>{{else}}{{.A.Output}}{{end}}<div class="reason">score {{decimal .ScoreA}}</div></td>
+<td class="text">{{if .B.Error}}<span class="fail">{{.B.Error}}</span>{{else}}{{.B.Output}}{{end}}<div class="reason">score {{decimal .ScoreB}}</div></td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`
@@ -0,0 +1,177 @@
+// Package eval measures agents and workflows against datasets of cases,
+// scoring their outputs so prompt and model changes can be compared
+// before they ship.
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/user/modulox/pkg/config"
+)
+
+// Case is one input to evaluate and the output expected for it
+type Case struct {
+	ID       string            `json:"id"`
+	Input    string            `json:"input"`
+	Expected string            `json:"expected"`
+	Tags     []string          `json:"tags,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Dataset is a named set of cases, loaded from YAML or JSON:
+//
+//	name: arithmetic
+//	cases:
+//	  - id: percent
+//	    input: What is 17% of 2,340?
+//	    expected: "397.8"
+//
+// or from JSON Lines, one case per line.
+type Dataset struct {
+	Name  string `json:"name"`
+	Cases []Case `json:"cases"`
+}
+
+// Target is what a dataset is run against. agent.Agent and
+// workflow.Workflow both satisfy it.
+type Target interface {
+	Execute(ctx context.Context, input string) (string, error)
+}
+
+// TargetFunc adapts a function to the Target interface
+type TargetFunc func(ctx context.Context, input string) (string, error)
+
+// Execute implements Target.Execute
+func (f TargetFunc) Execute(ctx context.Context, input string) (string, error) {
+	return f(ctx, input)
+}
+
+// Error types
+type EvalError string
+
+func (e EvalError) Error() string { return string(e) }
+
+const (
+	ErrEmptyDataset = EvalError("dataset has no cases")
+	ErrNoScorers    = EvalError("no scorers configured")
+)
+
+// LoadDataset reads a dataset file. Files ending in .jsonl hold one case
+// per line; anything else is decoded as YAML or JSON. Datasets without a
+// name are named after the file.
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var dataset *Dataset
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		dataset, err = ParseJSONL(data)
+	} else {
+		dataset, err = ParseDataset(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if dataset.Name == "" {
+		dataset.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return dataset, nil
+}
+
+// ParseDataset decodes and validates a YAML or JSON dataset
+func ParseDataset(data []byte) (*Dataset, error) {
+	var dataset Dataset
+	if err := config.Unmarshal(data, &dataset); err != nil {
+		return nil, err
+	}
+	if err := dataset.Validate(); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// ParseJSONL decodes and validates a dataset of one JSON case per line.
+// Blank lines are skipped.
+func ParseJSONL(data []byte) (*Dataset, error) {
+	var dataset Dataset
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var c Case
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		dataset.Cases = append(dataset.Cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := dataset.Validate(); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// Validate checks that the dataset has cases with inputs, numbering cases
+// without an ID by their position
+func (d *Dataset) Validate() error {
+	if len(d.Cases) == 0 {
+		return ErrEmptyDataset
+	}
+	seen := make(map[string]bool, len(d.Cases))
+	for i := range d.Cases {
+		c := &d.Cases[i]
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("%d", i+1)
+		}
+		if seen[c.ID] {
+			return fmt.Errorf("cases[%d]: duplicate id %q", i, c.ID)
+		}
+		seen[c.ID] = true
+		if c.Input == "" {
+			return fmt.Errorf("cases[%d] (%s): input is required", i, c.ID)
+		}
+	}
+	return nil
+}
+
+// Filter returns the cases carrying any of tags; with no tags it returns
+// the dataset unchanged
+func (d *Dataset) Filter(tags ...string) *Dataset {
+	if len(tags) == 0 {
+		return d
+	}
+	filtered := &Dataset{Name: d.Name}
+	for _, c := range d.Cases {
+		if hasAnyTag(c.Tags, tags) {
+			filtered.Cases = append(filtered.Cases, c)
+		}
+	}
+	return filtered
+}
+
+// hasAnyTag reports whether have contains any of want
+func hasAnyTag(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/user/modulox/pkg/observability"
+)
+
+// CaseResult is the outcome of one case. Error is set when the target
+// failed, in which case the case is not scored.
+type CaseResult struct {
+	Case      Case             `json:"case"`
+	Output    string           `json:"output"`
+	Error     string           `json:"error,omitempty"`
+	LatencyMs int64            `json:"latency_ms"`
+	Scores    map[string]Score `json:"scores,omitempty"`
+	Passed    bool             `json:"passed"`
+}
+
+// ScorerSummary aggregates one scorer over the cases it scored. Errors
+// counts the cases it failed to score.
+type ScorerSummary struct {
+	Mean     float64 `json:"mean"`
+	PassRate float64 `json:"pass_rate"`
+	Errors   int     `json:"errors,omitempty"`
+}
+
+// Summary aggregates the results of a run
+type Summary struct {
+	Total         int                      `json:"total"`
+	Passed        int                      `json:"passed"`
+	Failed        int                      `json:"failed"`
+	Errors        int                      `json:"errors"`
+	PassRate      float64                  `json:"pass_rate"`
+	Scorers       map[string]ScorerSummary `json:"scorers"`
+	MeanLatencyMs int64                    `json:"mean_latency_ms"`
+	P95LatencyMs  int64                    `json:"p95_latency_ms"`
+}
+
+// Report is the outcome of running a dataset against a target
+type Report struct {
+	Dataset    string       `json:"dataset"`
+	Target     string       `json:"target,omitempty"`
+	Scorers    []string     `json:"scorers"`
+	StartedAt  time.Time    `json:"started_at"`
+	DurationMs int64        `json:"duration_ms"`
+	Summary    Summary      `json:"summary"`
+	Results    []CaseResult `json:"results"`
+}
+
+// summarize aggregates case results. Errored cases count as failures and
+// are left out of scorer means, but not of latencies; failed scores are
+// left out of their scorer's mean.
+func summarize(scorers []string, results []CaseResult) Summary {
+	summary := Summary{Total: len(results), Scorers: make(map[string]ScorerSummary, len(scorers))}
+	latencies := make([]int64, 0, len(results))
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			summary.Errors++
+		case result.Passed:
+			summary.Passed++
+		}
+		latencies = append(latencies, result.LatencyMs)
+	}
+	summary.Failed = summary.Total - summary.Passed
+	if summary.Total > 0 {
+		summary.PassRate = float64(summary.Passed) / float64(summary.Total)
+	}
+
+	for _, name := range scorers {
+		var s ScorerSummary
+		scored := 0
+		for _, result := range results {
+			score, ok := result.Scores[name]
+			switch {
+			case !ok:
+			case score.Error != "":
+				s.Errors++
+			default:
+				scored++
+				s.Mean += score.Value
+				if score.Passed {
+					s.PassRate++
+				}
+			}
+		}
+		if scored > 0 {
+			s.Mean /= float64(scored)
+			s.PassRate /= float64(scored)
+		}
+		summary.Scorers[name] = s
+	}
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total int64
+		for _, l := range latencies {
+			total += l
+		}
+		summary.MeanLatencyMs = total / int64(len(latencies))
+		summary.P95LatencyMs = latencies[(len(latencies)*95+99)/100-1]
+	}
+	return summary
+}
+
+// Failures returns the results of the cases that did not pass
+func (r *Report) Failures() []CaseResult {
+	var failures []CaseResult
+	for _, result := range r.Results {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// RecordMetrics records the summary of the report as eval_pass_rate,
+// eval_score (one per scorer), and eval_latency_ms gauges labelled with the
+// dataset and target
+func (r *Report) RecordMetrics(ctx context.Context, metrics *observability.MetricsCollector) {
+	labels := func(extra ...string) map[string]string {
+		l := map[string]string{"dataset": r.Dataset, "target": r.Target}
+		for i := 0; i+1 < len(extra); i += 2 {
+			l[extra[i]] = extra[i+1]
+		}
+		return l
+	}
+	metrics.RecordMetric(ctx, observability.Metric{
+		Name: "eval_pass_rate", Type: observability.GaugeMetric, Value: r.Summary.PassRate, Labels: labels(),
+	})
+	for _, name := range r.Scorers {
+		metrics.RecordMetric(ctx, observability.Metric{
+			Name: "eval_score", Type: observability.GaugeMetric, Value: r.Summary.Scorers[name].Mean, Labels: labels("scorer", name),
+		})
+	}
+	metrics.RecordMetric(ctx, observability.Metric{
+		Name: "eval_latency_ms", Type: observability.GaugeMetric, Value: float64(r.Summary.MeanLatencyMs), Labels: labels("stat", "mean"),
+	})
+	metrics.RecordMetric(ctx, observability.Metric{
+		Name: "eval_latency_ms", Type: observability.GaugeMetric, Value: float64(r.Summary.P95LatencyMs), Labels: labels("stat", "p95"),
+	})
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteHTML writes the report as a standalone HTML page
+func (r *Report) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
+
+// reportTemplate renders a Report as HTML
+var reportTemplate = template.Must(template.New("report").Funcs(reportFuncs).Parse(reportHTML))
+
+// reportFuncs format numbers in report templates
+var reportFuncs = template.FuncMap{
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"decimal": func(v float64) string { return fmt.Sprintf("%.3f", v) },
+}
+
+// reportCSS styles report pages
+const reportCSS = `
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { border: 1px solid #ddd; padding: 0.4rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+td.text { white-space: pre-wrap; max-width: 32rem; font-family: ui-monospace, monospace; font-size: 0.85rem; }
+.pass { color: #1a7f37; font-weight: 600; }
+.fail { color: #cf222e; font-weight: 600; }
+.reason { color: #666; font-size: 0.85rem; }
+`
+
+const reportHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Evaluation: {{.Dataset}}</title>
+<style>` + reportCSS + `</style>
+</head>
+<body>
+<h1>Evaluation: {{.Dataset}}{{if .Target}} ({{.Target}}){{end}}</h1>
+<p>Started {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}, took {{.DurationMs}} ms.</p>
+<table>
+<tr><th>Cases</th><th>Passed</th><th>Failed</th><th>Errors</th><th>Pass rate</th><th>Mean latency</th><th>p95 latency</th></tr>
+<tr><td>{{.Summary.Total}}</td><td>{{.Summary.Passed}}</td><td>{{.Summary.Failed}}</td><td>{{.Summary.Errors}}</td>
+<td>{{percent .Summary.PassRate}}</td><td>{{.Summary.MeanLatencyMs}} ms</td><td>{{.Summary.P95LatencyMs}} ms</td></tr>
+</table>
+<table>
+<tr><th>Scorer</th><th>Mean</th><th>Pass rate</th></tr>
+{{range $name := .Scorers}}{{with index $.Summary.Scorers $name}}<tr><td>{{$name}}</td><td>{{decimal .Mean}}</td><td>{{percent .PassRate}}</td></tr>
+{{end}}{{end}}</table>
+<table>
+<tr><th>Case</th><th>Result</th><th>Input</th><th>Expected</th><th>Output</th><th>Scores</th><th>Latency</th></tr>
+{{range .Results}}<tr>
+<td>{{.Case.ID}}</td>
+<td>{{if .Passed}}<span class="pass">pass</span>{{else}}<span class="fail">fail</span>{{end}}</td>
+<td class="text">{{.Case.Input}}</td>
+<td class="text">{{.Case.Expected}}</td>
+<td class="text">{{if .Error}}<span class="fail">{{.Error}}</span>{{else}}{{.Output}}{{end}}</td>
+<td>{{range $name, $score := .Scores}}<div>{{$name}}: {{if $score.Error}}<span class="fail">{{$score.Error}}</span>{{else}}{{decimal $score.Value}}{{if $score.Reason}} <span class="reason">{{$score.Reason}}</span>{{end}}{{end}}</div>{{end}}</td>
+<td>{{.LatencyMs}} ms</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`
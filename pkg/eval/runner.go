@@ -0,0 +1,123 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RunnerConfig contains configuration for evaluation runs
+type RunnerConfig struct {
+	// Concurrency bounds how many cases run at once
+	Concurrency int
+	// Timeout bounds each case, including scoring; zero means no limit
+	Timeout time.Duration
+}
+
+// DefaultRunnerConfig returns a default runner configuration
+func DefaultRunnerConfig() RunnerConfig {
+	return RunnerConfig{
+		Concurrency: 4,
+		Timeout:     2 * time.Minute,
+	}
+}
+
+// Runner runs datasets against targets and scores the outputs
+type Runner struct {
+	config  RunnerConfig
+	scorers []Scorer
+}
+
+// NewRunner creates a runner scoring every output with scorers
+func NewRunner(config RunnerConfig, scorers ...Scorer) *Runner {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	return &Runner{config: config, scorers: scorers}
+}
+
+// Run executes every case of the dataset against target. A case passes
+// when the target succeeds and every scorer passes it; failing cases do
+// not stop the run. Cases not started before ctx is done are reported
+// with its error.
+func (r *Runner) Run(ctx context.Context, dataset *Dataset, target Target) (*Report, error) {
+	if len(dataset.Cases) == 0 {
+		return nil, ErrEmptyDataset
+	}
+	if len(r.scorers) == 0 {
+		return nil, ErrNoScorers
+	}
+
+	report := &Report{
+		Dataset:   dataset.Name,
+		Scorers:   make([]string, len(r.scorers)),
+		StartedAt: time.Now(),
+		Results:   make([]CaseResult, len(dataset.Cases)),
+	}
+	for i, s := range r.scorers {
+		report.Scorers[i] = s.Name()
+	}
+
+	sem := make(chan struct{}, r.config.Concurrency)
+	var wg sync.WaitGroup
+	for i, c := range dataset.Cases {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			report.Results[i] = CaseResult{Case: c, Error: ctx.Err().Error()}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, c Case) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			report.Results[i] = r.runCase(ctx, c, target)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	report.Summary = summarize(report.Scorers, report.Results)
+	return report, nil
+}
+
+// runCase executes and scores a single case
+func (r *Runner) runCase(ctx context.Context, c Case, target Target) (result CaseResult) {
+	result = CaseResult{Case: c}
+	if r.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	defer func() {
+		if p := recover(); p != nil {
+			result.Error = fmt.Sprintf("panic: %v", p)
+			result.Passed = false
+			if result.LatencyMs == 0 {
+				result.LatencyMs = time.Since(start).Milliseconds()
+			}
+		}
+	}()
+
+	output, err := target.Execute(ctx, c.Input)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Output = output
+
+	result.Passed = true
+	result.Scores = make(map[string]Score, len(r.scorers))
+	for _, s := range r.scorers {
+		score, err := s.Score(ctx, c, output)
+		if err != nil {
+			score = Score{Error: err.Error()}
+		}
+		result.Scores[s.Name()] = score
+		result.Passed = result.Passed && score.Passed
+	}
+	return result
+}
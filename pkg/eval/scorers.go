@@ -0,0 +1,228 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/user/modulox/pkg/cache"
+	"github.com/user/modulox/pkg/llm"
+)
+
+// Score is a scorer's judgement of one output. Value ranges from 0 to 1.
+// Error is set when the scorer failed, in which case Value means nothing.
+type Score struct {
+	Value  float64 `json:"value"`
+	Passed bool    `json:"passed"`
+	Reason string  `json:"reason,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// Scorer judges the output produced for a case
+type Scorer interface {
+	// Name identifies the scorer in reports
+	Name() string
+	// Score judges output against the case
+	Score(ctx context.Context, c Case, output string) (Score, error)
+}
+
+// ScorerFunc adapts a function to the Scorer interface
+type ScorerFunc struct {
+	ScorerName string
+	Fn         func(ctx context.Context, c Case, output string) (Score, error)
+}
+
+// Name implements Scorer.Name
+func (s ScorerFunc) Name() string { return s.ScorerName }
+
+// Score implements Scorer.Score
+func (s ScorerFunc) Score(ctx context.Context, c Case, output string) (Score, error) {
+	return s.Fn(ctx, c, output)
+}
+
+// passFail scores a boolean judgement
+func passFail(passed bool, reason string) Score {
+	if passed {
+		return Score{Value: 1, Passed: true}
+	}
+	return Score{Value: 0, Reason: reason}
+}
+
+// ExactMatch passes outputs equal to the expected output
+type ExactMatch struct {
+	// Normalize compares outputs ignoring case, surrounding whitespace,
+	// and runs of inner whitespace
+	Normalize bool
+}
+
+// NewExactMatch creates an exact-match scorer
+func NewExactMatch(normalize bool) *ExactMatch {
+	return &ExactMatch{Normalize: normalize}
+}
+
+// Name implements Scorer.Name
+func (s *ExactMatch) Name() string { return "exact_match" }
+
+// Score implements Scorer.Score
+func (s *ExactMatch) Score(ctx context.Context, c Case, output string) (Score, error) {
+	expected := c.Expected
+	if s.Normalize {
+		expected, output = normalize(expected), normalize(output)
+	}
+	return passFail(output == expected, fmt.Sprintf("expected %q", c.Expected)), nil
+}
+
+// normalize lowercases text and collapses its whitespace
+func normalize(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// RegexScorer passes outputs matching a pattern
+type RegexScorer struct {
+	pattern *regexp.Regexp
+}
+
+// NewRegexScorer creates a scorer matching outputs against pattern. With
+// an empty pattern each case's expected output is used as the pattern.
+func NewRegexScorer(pattern string) (*RegexScorer, error) {
+	s := &RegexScorer{}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		s.pattern = re
+	}
+	return s, nil
+}
+
+// Name implements Scorer.Name
+func (s *RegexScorer) Name() string { return "regex" }
+
+// Score implements Scorer.Score
+func (s *RegexScorer) Score(ctx context.Context, c Case, output string) (Score, error) {
+	re := s.pattern
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile(c.Expected); err != nil {
+			return Score{}, fmt.Errorf("invalid expected pattern: %w", err)
+		}
+	}
+	return passFail(re.MatchString(output), fmt.Sprintf("does not match %s", re)), nil
+}
+
+// SimilarityScorer scores outputs by the cosine similarity of their
+// embedding to the expected output's
+type SimilarityScorer struct {
+	provider  llm.Provider
+	threshold float64
+}
+
+// NewSimilarityScorer creates an embedding-similarity scorer passing
+// outputs at least threshold similar to the expected output. A threshold
+// of zero defaults to 0.8.
+func NewSimilarityScorer(provider llm.Provider, threshold float64) *SimilarityScorer {
+	if threshold <= 0 {
+		threshold = 0.8
+	}
+	return &SimilarityScorer{provider: provider, threshold: threshold}
+}
+
+// Name implements Scorer.Name
+func (s *SimilarityScorer) Name() string { return "similarity" }
+
+// Score implements Scorer.Score
+func (s *SimilarityScorer) Score(ctx context.Context, c Case, output string) (Score, error) {
+	expected, err := s.provider.Embed(ctx, c.Expected)
+	if err != nil {
+		return Score{}, fmt.Errorf("failed to embed expected output: %w", err)
+	}
+	actual, err := s.provider.Embed(ctx, output)
+	if err != nil {
+		return Score{}, fmt.Errorf("failed to embed output: %w", err)
+	}
+	similarity := cache.CosineSimilarity(expected, actual)
+	if similarity < 0 {
+		similarity = 0
+	} else if similarity > 1 {
+		similarity = 1
+	}
+	score := Score{Value: similarity, Passed: similarity >= s.threshold}
+	if !score.Passed {
+		score.Reason = fmt.Sprintf("similarity %.3f below %.3f", similarity, s.threshold)
+	}
+	return score, nil
+}
+
+// JudgeScorer asks a model to grade outputs against a rubric
+type JudgeScorer struct {
+	provider  llm.Provider
+	rubric    string
+	threshold float64
+}
+
+// judgeScore finds the grade in a judge's answer, and the scale when given
+// as e.g. "7/10"
+var judgeScore = regexp.MustCompile(`(?i)score\s*[:=]?\s*(\d+(?:\.\d+)?)(?:\s*/\s*(\d+(?:\.\d+)?))?`)
+
+// NewJudgeScorer creates an LLM-as-judge scorer. The provider grades each
+// output from 0 to 10 against rubric and the expected output; grades of
+// at least threshold (from 0 to 1, default 0.7) pass.
+func NewJudgeScorer(provider llm.Provider, rubric string, threshold float64) *JudgeScorer {
+	if rubric == "" {
+		rubric = "The response is correct, complete, and consistent with the expected answer."
+	}
+	if threshold <= 0 {
+		threshold = 0.7
+	}
+	return &JudgeScorer{provider: provider, rubric: rubric, threshold: threshold}
+}
+
+// Name implements Scorer.Name
+func (s *JudgeScorer) Name() string { return "judge" }
+
+// Score implements Scorer.Score
+func (s *JudgeScorer) Score(ctx context.Context, c Case, output string) (Score, error) {
+	prompt := fmt.Sprintf("Grade the response to the task against the rubric.\n\n"+
+		"Rubric: %s\n\nTask:\n%s\n\nExpected answer:\n%s\n\nResponse:\n%s\n\n"+
+		"Answer with SCORE: followed by a grade from 0 to 10 on the first line, "+
+		"then a short reason.", s.rubric, c.Input, c.Expected, output)
+	answer, err := s.provider.Complete(ctx, prompt)
+	if err != nil {
+		return Score{}, err
+	}
+
+	match := judgeScore.FindStringSubmatchIndex(answer)
+	if match == nil {
+		return Score{}, fmt.Errorf("judge answer has no score: %q", truncate(answer, 200))
+	}
+	grade, err := strconv.ParseFloat(answer[match[2]:match[3]], 64)
+	if err != nil {
+		return Score{}, fmt.Errorf("invalid judge score: %w", err)
+	}
+	scale := 10.0
+	if match[4] >= 0 {
+		if scale, err = strconv.ParseFloat(answer[match[4]:match[5]], 64); err != nil {
+			return Score{}, fmt.Errorf("invalid judge score: %w", err)
+		}
+	}
+	if scale <= 0 || grade > scale {
+		return Score{}, fmt.Errorf("judge score out of range: %q", answer[match[0]:match[1]])
+	}
+	reason := strings.TrimSpace(strings.TrimLeft(answer[match[1]:], ":-.\n "))
+	return Score{Value: grade / scale, Passed: grade/scale >= s.threshold, Reason: reason}, nil
+}
+
+// truncate shortens text to at most n bytes, without splitting a rune
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n] + "..."
+}
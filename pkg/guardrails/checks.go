@@ -0,0 +1,212 @@
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/redact"
+)
+
+// CheckFunc adapts a function to the Check interface
+type CheckFunc struct {
+	CheckName string
+	Fn        func(ctx context.Context, text string) ([]Violation, error)
+}
+
+// Name implements Check.Name
+func (c CheckFunc) Name() string { return c.CheckName }
+
+// Evaluate implements Check.Evaluate
+func (c CheckFunc) Evaluate(ctx context.Context, text string) ([]Violation, error) {
+	return c.Fn(ctx, text)
+}
+
+// PIICheck flags personal data found by redact detectors
+type PIICheck struct {
+	detectors []redact.Detector
+}
+
+// NewPIICheck creates a PII check; with no detectors redact.DefaultDetectors is used
+func NewPIICheck(detectors ...redact.Detector) *PIICheck {
+	if len(detectors) == 0 {
+		detectors = redact.DefaultDetectors()
+	}
+	return &PIICheck{detectors: detectors}
+}
+
+// Name implements Check.Name
+func (c *PIICheck) Name() string { return "pii" }
+
+// Evaluate implements Check.Evaluate
+func (c *PIICheck) Evaluate(ctx context.Context, text string) ([]Violation, error) {
+	var violations []Violation
+	for _, d := range c.detectors {
+		findings, err := d.Detect(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("detector failed: %w", err)
+		}
+		for _, f := range findings {
+			violations = append(violations, Violation{
+				Check:   f.Type,
+				Message: fmt.Sprintf("contains %s", strings.ToLower(f.Type)),
+				Start:   f.Start,
+				End:     f.End,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// RegexCheck flags text matching any of its patterns
+type RegexCheck struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+// NewRegexCheck creates a check named name for the given patterns
+func NewRegexCheck(name string, patterns ...string) (*RegexCheck, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for %s: %w", name, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return &RegexCheck{name: name, patterns: compiled}, nil
+}
+
+// NewWordListCheck creates a case-insensitive whole-word filter, such as a
+// profanity list
+func NewWordListCheck(name string, words []string) (*RegexCheck, error) {
+	if len(words) == 0 {
+		return &RegexCheck{name: name}, nil
+	}
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	return NewRegexCheck(name, `(?i)\b(?:`+strings.Join(quoted, "|")+`)\b`)
+}
+
+// Name implements Check.Name
+func (c *RegexCheck) Name() string { return c.name }
+
+// Evaluate implements Check.Evaluate
+func (c *RegexCheck) Evaluate(ctx context.Context, text string) ([]Violation, error) {
+	var violations []Violation
+	for _, re := range c.patterns {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			violations = append(violations, Violation{
+				Check:   c.name,
+				Message: fmt.Sprintf("matches %s filter", c.name),
+				Start:   loc[0],
+				End:     loc[1],
+			})
+		}
+	}
+	return violations, nil
+}
+
+// MaxLengthCheck flags text longer than a number of bytes. Redacting it
+// truncates the text.
+type MaxLengthCheck struct {
+	max int
+}
+
+// NewMaxLengthCheck creates a length check
+func NewMaxLengthCheck(max int) *MaxLengthCheck {
+	return &MaxLengthCheck{max: max}
+}
+
+// Name implements Check.Name
+func (c *MaxLengthCheck) Name() string { return "max_length" }
+
+// Evaluate implements Check.Evaluate
+func (c *MaxLengthCheck) Evaluate(ctx context.Context, text string) ([]Violation, error) {
+	if len(text) <= c.max {
+		return nil, nil
+	}
+	return []Violation{{
+		Check:   c.Name(),
+		Message: fmt.Sprintf("length %d exceeds %d", len(text), c.max),
+		Start:   c.max,
+		End:     len(text),
+	}}, nil
+}
+
+// injectionPatterns are phrases commonly used to override instructions
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget)\b.{0,20}\b(?:previous|prior|above|earlier|all)\b.{0,20}\b(?:instructions|rules|prompts?|directions)\b`),
+	regexp.MustCompile(`(?i)\b(?:reveal|show|print|repeat)\b.{0,20}\b(?:system|hidden|initial)\s+(?:prompt|instructions)\b`),
+	regexp.MustCompile(`(?i)\byou are now\b.{0,40}\b(?:unrestricted|jailbroken|DAN|without (?:any )?(?:rules|restrictions))\b`),
+	regexp.MustCompile(`(?i)\b(?:developer|god|jailbreak)\s+mode\b`),
+	regexp.MustCompile(`(?im)^[ \t]*(?:system|assistant)[ \t]*:`),
+}
+
+// PromptInjectionCheck flags common prompt-injection phrasing. It is a
+// heuristic and should be paired with an LLMCheck for high-risk agents.
+type PromptInjectionCheck struct{}
+
+// NewPromptInjectionCheck creates a prompt-injection heuristic check
+func NewPromptInjectionCheck() *PromptInjectionCheck {
+	return &PromptInjectionCheck{}
+}
+
+// Name implements Check.Name
+func (c *PromptInjectionCheck) Name() string { return "prompt_injection" }
+
+// Evaluate implements Check.Evaluate. Injection attempts are reported for
+// the whole text, so PolicyRedact blocks them.
+func (c *PromptInjectionCheck) Evaluate(ctx context.Context, text string) ([]Violation, error) {
+	for _, re := range injectionPatterns {
+		if match := re.FindString(text); match != "" {
+			return []Violation{{
+				Check:   c.Name(),
+				Message: fmt.Sprintf("possible prompt injection: %q", match),
+			}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// LLMCheck asks a model whether text satisfies a natural-language rule
+type LLMCheck struct {
+	name     string
+	provider llm.Provider
+	rule     string
+}
+
+// NewLLMCheck creates a check that asks provider whether text follows rule
+func NewLLMCheck(name string, provider llm.Provider, rule string) *LLMCheck {
+	return &LLMCheck{name: name, provider: provider, rule: rule}
+}
+
+// Name implements Check.Name
+func (c *LLMCheck) Name() string { return c.name }
+
+// Evaluate implements Check.Evaluate
+func (c *LLMCheck) Evaluate(ctx context.Context, text string) ([]Violation, error) {
+	prompt := fmt.Sprintf("Rule: %s\n\nText:\n%s\n\n"+
+		"Does the text follow the rule? Answer PASS, or FAIL followed by a short reason.", c.rule, text)
+	answer, err := c.provider.Complete(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	answer = strings.TrimSpace(answer)
+	if strings.HasPrefix(strings.ToUpper(answer), "PASS") {
+		return nil, nil
+	}
+	reason := answer
+	if strings.HasPrefix(strings.ToUpper(reason), "FAIL") {
+		reason = strings.TrimSpace(strings.TrimLeft(reason[len("FAIL"):], ":-. "))
+	}
+	if reason == "" {
+		reason = "violates rule: " + c.rule
+	}
+	return []Violation{{Check: c.name, Message: reason}}, nil
+}
@@ -0,0 +1,239 @@
+// Package guardrails validates agent inputs and outputs with pluggable
+// checks, blocking, redacting, or flagging content that violates them.
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/workflow"
+)
+
+// Violation describes content that failed a check. Start and End locate
+// the offending span when the check can point at one; both are zero when
+// the violation applies to the text as a whole.
+type Violation struct {
+	Check   string
+	Message string
+	Start   int
+	End     int
+}
+
+// spanned reports whether the violation locates a span of the text
+func (v Violation) spanned() bool {
+	return v.End > v.Start
+}
+
+// Check inspects text for violations
+type Check interface {
+	// Name identifies the check in violations
+	Name() string
+	// Evaluate returns the violations found in text
+	Evaluate(ctx context.Context, text string) ([]Violation, error)
+}
+
+// Policy decides what happens to content that fails a check
+type Policy int
+
+const (
+	// PolicyBlock rejects the content with a *BlockedError
+	PolicyBlock Policy = iota
+	// PolicyRedact masks violating spans; whole-text violations are blocked
+	PolicyRedact
+	// PolicyWarn lets the content through and reports the violation
+	PolicyWarn
+)
+
+// Stages at which guards run
+const (
+	StageInput  = "input"
+	StageOutput = "output"
+)
+
+// BlockedError is returned when content is blocked by a guard
+type BlockedError struct {
+	Stage      string
+	Violations []Violation
+}
+
+func (e *BlockedError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, fmt.Sprintf("%s: %s", v.Check, v.Message))
+	}
+	return fmt.Sprintf("%s blocked by guardrails: %s", e.Stage, strings.Join(messages, "; "))
+}
+
+// rule attaches a policy to a check
+type rule struct {
+	check  Check
+	policy Policy
+}
+
+// Guard applies checks to the input and output of agents and workflows
+type Guard struct {
+	input  []rule
+	output []rule
+	onWarn func(ctx context.Context, stage string, v Violation)
+	mu     sync.RWMutex
+}
+
+// New creates an empty guard
+func New() *Guard {
+	return &Guard{}
+}
+
+// AddInputCheck applies check to inputs with the given policy
+func (g *Guard) AddInputCheck(check Check, policy Policy) *Guard {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.input = append(g.input, rule{check: check, policy: policy})
+	return g
+}
+
+// AddOutputCheck applies check to outputs with the given policy
+func (g *Guard) AddOutputCheck(check Check, policy Policy) *Guard {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.output = append(g.output, rule{check: check, policy: policy})
+	return g
+}
+
+// OnWarn sets the callback receiving violations of PolicyWarn checks
+func (g *Guard) OnWarn(fn func(ctx context.Context, stage string, v Violation)) *Guard {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.onWarn = fn
+	return g
+}
+
+// CheckInput validates an input, returning it with redactions applied
+func (g *Guard) CheckInput(ctx context.Context, text string) (string, error) {
+	g.mu.RLock()
+	rules := g.input
+	g.mu.RUnlock()
+	return g.apply(ctx, StageInput, rules, text)
+}
+
+// CheckOutput validates an output, returning it with redactions applied
+func (g *Guard) CheckOutput(ctx context.Context, text string) (string, error) {
+	g.mu.RLock()
+	rules := g.output
+	g.mu.RUnlock()
+	return g.apply(ctx, StageOutput, rules, text)
+}
+
+// apply runs the rules in order; each rule sees the text as redacted by
+// the rules before it
+func (g *Guard) apply(ctx context.Context, stage string, rules []rule, text string) (string, error) {
+	for _, r := range rules {
+		violations, err := r.check.Evaluate(ctx, text)
+		if err != nil {
+			return "", fmt.Errorf("guardrail %s failed: %w", r.check.Name(), err)
+		}
+		if len(violations) == 0 {
+			continue
+		}
+
+		switch r.policy {
+		case PolicyWarn:
+			g.mu.RLock()
+			onWarn := g.onWarn
+			g.mu.RUnlock()
+			if onWarn != nil {
+				for _, v := range violations {
+					onWarn(ctx, stage, v)
+				}
+			}
+		case PolicyRedact:
+			var whole []Violation
+			for _, v := range violations {
+				if !v.spanned() {
+					whole = append(whole, v)
+				}
+			}
+			if len(whole) > 0 {
+				return "", &BlockedError{Stage: stage, Violations: whole}
+			}
+			text = redactSpans(text, violations)
+		default:
+			return "", &BlockedError{Stage: stage, Violations: violations}
+		}
+	}
+	return text, nil
+}
+
+// redactSpans masks the violating spans; earlier and longer spans win over
+// overlapping ones
+func redactSpans(text string, violations []Violation) string {
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Start == violations[j].Start {
+			return violations[i].End > violations[j].End
+		}
+		return violations[i].Start < violations[j].Start
+	})
+
+	var b strings.Builder
+	pos := 0
+	for _, v := range violations {
+		if v.Start < pos || v.End > len(text) {
+			continue
+		}
+		b.WriteString(text[pos:v.Start])
+		b.WriteString("[REDACTED:" + v.Check + "]")
+		pos = v.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// Middleware returns agent middleware that checks every input and output
+func (g *Guard) Middleware() agent.Middleware {
+	return func(next agent.AgentFunc) agent.AgentFunc {
+		return func(ctx context.Context, input string) (string, error) {
+			input, err := g.CheckInput(ctx, input)
+			if err != nil {
+				return "", err
+			}
+			output, err := next(ctx, input)
+			if err != nil {
+				return "", err
+			}
+			return g.CheckOutput(ctx, output)
+		}
+	}
+}
+
+// GuardedWorkflow applies a guard to the task and result of a workflow,
+// for guarding whole steps of a larger pipeline
+type GuardedWorkflow struct {
+	guard *Guard
+	inner workflow.Workflow
+}
+
+// Workflow wraps a workflow with the guard
+func (g *Guard) Workflow(w workflow.Workflow) *GuardedWorkflow {
+	return &GuardedWorkflow{guard: g, inner: w}
+}
+
+// Execute implements workflow.Workflow.Execute
+func (w *GuardedWorkflow) Execute(ctx context.Context, task string) (string, error) {
+	task, err := w.guard.CheckInput(ctx, task)
+	if err != nil {
+		return "", err
+	}
+	result, err := w.inner.Execute(ctx, task)
+	if err != nil {
+		return "", err
+	}
+	return w.guard.CheckOutput(ctx, result)
+}
+
+// AddAgent implements workflow.Workflow.AddAgent
+func (w *GuardedWorkflow) AddAgent(a agent.Agent) error {
+	return w.inner.AddAgent(a)
+}
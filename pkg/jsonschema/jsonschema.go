@@ -0,0 +1,283 @@
+// Package jsonschema validates values against JSON Schemas and derives
+// schemas from Go types. Tools, workflow steps, structured agent output,
+// and the REST API's OpenAPI document share it.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Validate checks a value against a JSON Schema. It supports the subset
+// used to describe tool arguments: type, properties, required,
+// additionalProperties, items, enum, minimum/maximum, minLength/maxLength,
+// minItems/maxItems, and pattern.
+func Validate(schema map[string]interface{}, value interface{}) error {
+	normalized, err := Normalize(value)
+	if err != nil {
+		return err
+	}
+	return validateValue(schema, normalized, "input")
+}
+
+// Normalize converts Go values into their generic JSON representation
+func Normalize(value interface{}) (interface{}, error) {
+	// Containers are re-encoded too, as they may hold typed values
+	switch value.(type) {
+	case nil, string, bool, float64:
+		return value, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("input is not JSON-encodable: %w", err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func validateValue(schema map[string]interface{}, value interface{}, path string) error {
+	if raw, ok := schema["enum"]; ok {
+		// Schemas built in Go may list typed values such as []string or
+		// ints; normalized, they compare like decoded JSON, keeping 1 and
+		// "1" distinct
+		normalized, err := Normalize(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid enum: %w", path, err)
+		}
+		enum, ok := normalized.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: enum must be an array", path)
+		}
+		found := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+
+	if t, ok := schema["type"].(string); ok && !matchesType(t, value) {
+		return fmt.Errorf("%s: expected %s, got %s", path, t, jsonType(value))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateObject(schema, v, path)
+	case []interface{}:
+		if min, ok := number(schema["minItems"]); ok && float64(len(v)) < min {
+			return fmt.Errorf("%s: expected at least %v items", path, min)
+		}
+		if max, ok := number(schema["maxItems"]); ok && float64(len(v)) > max {
+			return fmt.Errorf("%s: expected at most %v items", path, max)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if min, ok := number(schema["minLength"]); ok && float64(len(v)) < min {
+			return fmt.Errorf("%s: expected at least %v characters", path, min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && float64(len(v)) > max {
+			return fmt.Errorf("%s: expected at most %v characters", path, max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid pattern %q: %w", path, pattern, err)
+			}
+			if !re.MatchString(v) {
+				return fmt.Errorf("%s: does not match pattern %q", path, pattern)
+			}
+		}
+	case float64:
+		if min, ok := number(schema["minimum"]); ok && v < min {
+			return fmt.Errorf("%s: must be >= %v", path, min)
+		}
+		if max, ok := number(schema["maximum"]); ok && v > max {
+			return fmt.Errorf("%s: must be <= %v", path, max)
+		}
+	}
+
+	return nil
+}
+
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string) error {
+	for _, name := range Required(schema) {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, restricted := schema["additionalProperties"].(bool)
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prop, known := properties[k].(map[string]interface{})
+		if !known {
+			if restricted && !additional {
+				return fmt.Errorf("%s: unexpected property %q", path, k)
+			}
+			continue
+		}
+		if err := validateValue(prop, obj[k], path+"."+k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchesType(t string, value interface{}) bool {
+	switch t {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == t
+	}
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// Required returns the names in a schema's required list
+func Required(schema map[string]interface{}) []string {
+	switch list := schema["required"].(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// ForType derives a JSON schema from a Go type using its json tags
+func ForType(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": ForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": ForType(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name, omitempty := jsonName(field)
+			if name == "-" {
+				continue
+			}
+			properties[name] = ForType(field.Type)
+			if !omitempty {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// jsonName returns the JSON field name and whether it is optional
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name, false
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			return name, true
+		}
+	}
+	return name, false
+}
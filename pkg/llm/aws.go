@@ -0,0 +1,6 @@
+package llm
+
+import "github.com/user/modulox/pkg/sigv4"
+
+// AWSCredentials are the credentials used to sign AWS requests
+type AWSCredentials = sigv4.Credentials
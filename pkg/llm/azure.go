@@ -0,0 +1,276 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI REST API version used when
+// none is configured
+const DefaultAzureAPIVersion = "2024-06-01"
+
+// AzureConfig contains configuration for the Azure OpenAI provider
+type AzureConfig struct {
+	// Endpoint is the resource endpoint, e.g. https://myres.openai.azure.com
+	Endpoint string
+	APIKey   string
+	// APIVersion defaults to DefaultAzureAPIVersion
+	APIVersion string
+	// ChatDeployment serves Complete and Chat requests
+	ChatDeployment string
+	// EmbeddingDeployment serves Embed requests
+	EmbeddingDeployment string
+	Temperature         float64
+	MaxTokens           int
+	HTTPClient          *http.Client
+}
+
+// AzureProvider calls models deployed on Azure OpenAI. Requests are routed
+// to the chat or embedding deployment of the resource.
+type AzureProvider struct {
+	config AzureConfig
+	client *http.Client
+}
+
+// NewAzureProvider creates a new Azure OpenAI provider
+func NewAzureProvider(config AzureConfig) (*AzureProvider, error) {
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("azure endpoint is required")
+	}
+	if config.ChatDeployment == "" && config.EmbeddingDeployment == "" {
+		return nil, fmt.Errorf("azure provider needs a chat or embedding deployment")
+	}
+	if config.APIVersion == "" {
+		config.APIVersion = DefaultAzureAPIVersion
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &AzureProvider{config: config, client: client}, nil
+}
+
+// openAIMessage is a chat message in the OpenAI wire format. Content is a
+// string, or a list of openAIContentPart for messages with images.
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content"`
+	Name       string           `json:"name,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+}
+
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description,omitempty"`
+		Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	} `json:"function"`
+}
+
+type openAIChatRequest struct {
+	Messages    []openAIMessage `json:"messages"`
+	Tools       []openAITool    `json:"tools,omitempty"`
+	Temperature float64         `json:"temperature"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Complete implements Provider.Complete
+func (p *AzureProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	completion, _, err := p.CompleteWithUsage(ctx, prompt)
+	return completion, err
+}
+
+// CompleteWithUsage implements UsageProvider.CompleteWithUsage
+func (p *AzureProvider) CompleteWithUsage(ctx context.Context, prompt string) (string, Usage, error) {
+	reply, usage, err := p.chat(ctx, []Message{{Role: RoleUser, Content: prompt}}, nil)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	return reply.Content, usage, nil
+}
+
+// Chat implements ChatProvider.Chat
+func (p *AzureProvider) Chat(ctx context.Context, messages []Message) (Message, error) {
+	reply, _, err := p.chat(ctx, messages, nil)
+	return reply, err
+}
+
+// ChatWithTools implements ToolCallingProvider.ChatWithTools
+func (p *AzureProvider) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	reply, _, err := p.chat(ctx, messages, tools)
+	return reply, err
+}
+
+// ChatWithUsage implements ChatUsageProvider.ChatWithUsage
+func (p *AzureProvider) ChatWithUsage(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, Usage, error) {
+	return p.chat(ctx, messages, tools)
+}
+
+// SupportsImages implements VisionProvider.SupportsImages. Images reach
+// the model when the chat deployment runs a vision model such as GPT-4o.
+func (p *AzureProvider) SupportsImages() bool {
+	return p.config.ChatDeployment != ""
+}
+
+// Embed implements Provider.Embed
+func (p *AzureProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	if p.config.EmbeddingDeployment == "" {
+		return nil, fmt.Errorf("azure provider has no embedding deployment")
+	}
+
+	var resp openAIEmbeddingResponse
+	body := map[string]interface{}{"input": text}
+	if err := postJSON(ctx, p.client, p.url(p.config.EmbeddingDeployment, "embeddings"), body, &resp, p.authorize); err != nil {
+		return nil, fmt.Errorf("azure embedding failed: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("azure embedding returned no data")
+	}
+	return resp.Data[0].Embedding, nil
+}
+
+// chat sends a chat completion request to the chat deployment
+func (p *AzureProvider) chat(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, Usage, error) {
+	if p.config.ChatDeployment == "" {
+		return Message{}, Usage{}, fmt.Errorf("azure provider has no chat deployment")
+	}
+
+	req := openAIChatRequest{
+		Messages:    toOpenAIMessages(messages),
+		Tools:       toOpenAITools(tools),
+		Temperature: p.config.Temperature,
+		MaxTokens:   p.config.MaxTokens,
+	}
+	var resp openAIChatResponse
+	if err := postJSON(ctx, p.client, p.url(p.config.ChatDeployment, "chat/completions"), req, &resp, p.authorize); err != nil {
+		return Message{}, Usage{}, fmt.Errorf("azure chat completion failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return Message{}, Usage{}, fmt.Errorf("azure chat completion returned no choices")
+	}
+	if resp.Choices[0].FinishReason == "content_filter" {
+		return Message{}, Usage{}, fmt.Errorf("azure chat completion failed: %w", ErrContentFiltered)
+	}
+
+	usage := Usage{
+		Model:            resp.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}
+	return fromOpenAIMessage(resp.Choices[0].Message), usage, nil
+}
+
+// url returns the endpoint of an operation on a deployment
+func (p *AzureProvider) url(deployment, operation string) string {
+	return fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		p.config.Endpoint, url.PathEscape(deployment), operation, url.QueryEscape(p.config.APIVersion))
+}
+
+func (p *AzureProvider) authorize(req *http.Request, _ []byte) error {
+	req.Header.Set("api-key", p.config.APIKey)
+	return nil
+}
+
+func toOpenAIMessages(messages []Message) []openAIMessage {
+	out := make([]openAIMessage, len(messages))
+	for i, m := range messages {
+		out[i] = openAIMessage{
+			Role:       string(m.Role),
+			Content:    m.Content,
+			Name:       m.Name,
+			ToolCallID: m.ToolCallID,
+		}
+		if len(m.Images) > 0 {
+			parts := []openAIContentPart{{Type: "text", Text: m.Content}}
+			for _, img := range m.Images {
+				parts = append(parts, openAIContentPart{
+					Type:     "image_url",
+					ImageURL: &openAIImageURL{URL: img.DataURL()},
+				})
+			}
+			out[i].Content = parts
+		}
+		if m.Role == RoleTool {
+			// The tool name is carried by the tool call ID
+			out[i].Name = ""
+		}
+		for _, call := range m.ToolCalls {
+			tc := openAIToolCall{ID: call.ID, Type: "function"}
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = call.Arguments
+			out[i].ToolCalls = append(out[i].ToolCalls, tc)
+		}
+	}
+	return out
+}
+
+func toOpenAITools(tools []ToolDefinition) []openAITool {
+	if len(tools) == 0 {
+		return nil
+	}
+	out := make([]openAITool, len(tools))
+	for i, t := range tools {
+		out[i].Type = "function"
+		out[i].Function.Name = t.Name
+		out[i].Function.Description = t.Description
+		out[i].Function.Parameters = t.Parameters
+	}
+	return out
+}
+
+func fromOpenAIMessage(m openAIMessage) Message {
+	content, _ := m.Content.(string)
+	msg := Message{Role: RoleAssistant, Content: content}
+	for _, tc := range m.ToolCalls {
+		msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	return msg
+}
@@ -0,0 +1,299 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/sigv4"
+)
+
+// BedrockConfig contains configuration for the AWS Bedrock provider
+type BedrockConfig struct {
+	Region string
+	// Credentials default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+	// and AWS_SESSION_TOKEN environment variables
+	Credentials AWSCredentials
+	// ModelID serves Complete and Chat requests; Anthropic Claude and Amazon
+	// Titan text models are supported
+	ModelID string
+	// EmbeddingModelID serves Embed requests with a Titan embedding model
+	EmbeddingModelID string
+	Temperature      float64
+	// MaxTokens bounds the completion length (default 1024)
+	MaxTokens int
+	// Endpoint overrides the regional runtime endpoint, e.g. for VPC endpoints
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// BedrockProvider calls foundation models through the AWS Bedrock runtime,
+// signing requests with SigV4
+type BedrockProvider struct {
+	config BedrockConfig
+	client *http.Client
+}
+
+// NewBedrockProvider creates a new Bedrock provider
+func NewBedrockProvider(config BedrockConfig) (*BedrockProvider, error) {
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+	if config.Region == "" {
+		return nil, fmt.Errorf("bedrock region is required")
+	}
+	if config.ModelID == "" && config.EmbeddingModelID == "" {
+		return nil, fmt.Errorf("bedrock provider needs a model or embedding model")
+	}
+	if config.Credentials.AccessKeyID == "" {
+		config.Credentials = sigv4.CredentialsFromEnv()
+	}
+	if config.Credentials.AccessKeyID == "" || config.Credentials.SecretAccessKey == "" {
+		return nil, fmt.Errorf("bedrock credentials are required")
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = 1024
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", config.Region)
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &BedrockProvider{config: config, client: client}, nil
+}
+
+type claudeMessage struct {
+	Role    string          `json:"role"`
+	Content []claudeContent `json:"content"`
+}
+
+type claudeContent struct {
+	Type   string             `json:"type"`
+	Text   string             `json:"text,omitempty"`
+	Source *claudeImageSource `json:"source,omitempty"`
+}
+
+type claudeImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type claudeRequest struct {
+	AnthropicVersion string          `json:"anthropic_version"`
+	MaxTokens        int             `json:"max_tokens"`
+	System           string          `json:"system,omitempty"`
+	Messages         []claudeMessage `json:"messages"`
+	Temperature      float64         `json:"temperature"`
+}
+
+type claudeResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type titanRequest struct {
+	InputText            string `json:"inputText"`
+	TextGenerationConfig struct {
+		MaxTokenCount int     `json:"maxTokenCount"`
+		Temperature   float64 `json:"temperature"`
+	} `json:"textGenerationConfig"`
+}
+
+type titanResponse struct {
+	InputTextTokenCount int `json:"inputTextTokenCount"`
+	Results             []struct {
+		TokenCount       int    `json:"tokenCount"`
+		OutputText       string `json:"outputText"`
+		CompletionReason string `json:"completionReason"`
+	} `json:"results"`
+}
+
+type titanEmbeddingResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+// Complete implements Provider.Complete
+func (p *BedrockProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	completion, _, err := p.CompleteWithUsage(ctx, prompt)
+	return completion, err
+}
+
+// CompleteWithUsage implements UsageProvider.CompleteWithUsage
+func (p *BedrockProvider) CompleteWithUsage(ctx context.Context, prompt string) (string, Usage, error) {
+	reply, usage, err := p.chat(ctx, []Message{{Role: RoleUser, Content: prompt}})
+	if err != nil {
+		return "", Usage{}, err
+	}
+	return reply.Content, usage, nil
+}
+
+// Chat implements ChatProvider.Chat
+func (p *BedrockProvider) Chat(ctx context.Context, messages []Message) (Message, error) {
+	reply, _, err := p.chat(ctx, messages)
+	return reply, err
+}
+
+// ChatWithUsage implements ChatUsageProvider.ChatWithUsage. Bedrock models
+// are called without tools.
+func (p *BedrockProvider) ChatWithUsage(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, Usage, error) {
+	return p.chat(ctx, messages)
+}
+
+// SupportsImages implements VisionProvider.SupportsImages. Claude models
+// accept images; Titan text models see placeholders.
+func (p *BedrockProvider) SupportsImages() bool {
+	return strings.Contains(p.config.ModelID, "anthropic.")
+}
+
+// Embed implements Provider.Embed
+func (p *BedrockProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	if p.config.EmbeddingModelID == "" {
+		return nil, fmt.Errorf("bedrock provider has no embedding model")
+	}
+
+	var resp titanEmbeddingResponse
+	body := map[string]interface{}{"inputText": text}
+	if err := p.invoke(ctx, p.config.EmbeddingModelID, body, &resp); err != nil {
+		return nil, fmt.Errorf("bedrock embedding failed: %w", err)
+	}
+	return resp.Embedding, nil
+}
+
+// chat dispatches the conversation in the format of the model family
+func (p *BedrockProvider) chat(ctx context.Context, messages []Message) (Message, Usage, error) {
+	modelID := p.config.ModelID
+	switch {
+	case modelID == "":
+		return Message{}, Usage{}, fmt.Errorf("bedrock provider has no model")
+	case strings.Contains(modelID, "anthropic."):
+		return p.chatClaude(ctx, messages)
+	case strings.Contains(modelID, "amazon.titan"):
+		return p.completeTitan(ctx, FormatPrompt(messages))
+	default:
+		return Message{}, Usage{}, fmt.Errorf("unsupported bedrock model: %s", modelID)
+	}
+}
+
+func (p *BedrockProvider) chatClaude(ctx context.Context, messages []Message) (Message, Usage, error) {
+	req := claudeRequest{
+		AnthropicVersion: "bedrock-2023-05-31",
+		MaxTokens:        p.config.MaxTokens,
+		Temperature:      p.config.Temperature,
+	}
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			if req.System != "" {
+				req.System += "\n\n"
+			}
+			req.System += m.Content
+		case RoleAssistant:
+			req.Messages = appendClaudeMessage(req.Messages, "assistant", claudeText(m.Content))
+		case RoleTool:
+			req.Messages = appendClaudeMessage(req.Messages, "user", claudeText(fmt.Sprintf("Result of %s: %s", m.Name, m.Content)))
+		default:
+			var content []claudeContent
+			for _, img := range m.Images {
+				// Bedrock only accepts inline image data
+				if img.URL != "" {
+					return Message{}, Usage{}, fmt.Errorf("bedrock requires inline image data, got URL %s", img.URL)
+				}
+				content = append(content, claudeContent{
+					Type: "image",
+					Source: &claudeImageSource{
+						Type:      "base64",
+						MediaType: img.MediaType,
+						Data:      base64.StdEncoding.EncodeToString(img.Data),
+					},
+				})
+			}
+			content = append(content, claudeText(m.Content)...)
+			req.Messages = appendClaudeMessage(req.Messages, "user", content)
+		}
+	}
+
+	var resp claudeResponse
+	if err := p.invoke(ctx, p.config.ModelID, req, &resp); err != nil {
+		return Message{}, Usage{}, fmt.Errorf("bedrock completion failed: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	usage := Usage{
+		Model:            p.config.ModelID,
+		PromptTokens:     resp.Usage.InputTokens,
+		CompletionTokens: resp.Usage.OutputTokens,
+	}
+	return Message{Role: RoleAssistant, Content: text.String()}, usage, nil
+}
+
+// appendClaudeMessage merges consecutive messages of the same role, since
+// Claude requires user and assistant turns to alternate
+func appendClaudeMessage(messages []claudeMessage, role string, content []claudeContent) []claudeMessage {
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		messages[n-1].Content = append(messages[n-1].Content, content...)
+		return messages
+	}
+	return append(messages, claudeMessage{Role: role, Content: content})
+}
+
+// claudeText returns a text content block, or none for empty text
+func claudeText(text string) []claudeContent {
+	if text == "" {
+		return nil
+	}
+	return []claudeContent{{Type: "text", Text: text}}
+}
+
+func (p *BedrockProvider) completeTitan(ctx context.Context, prompt string) (Message, Usage, error) {
+	var req titanRequest
+	req.InputText = prompt
+	req.TextGenerationConfig.MaxTokenCount = p.config.MaxTokens
+	req.TextGenerationConfig.Temperature = p.config.Temperature
+
+	var resp titanResponse
+	if err := p.invoke(ctx, p.config.ModelID, req, &resp); err != nil {
+		return Message{}, Usage{}, fmt.Errorf("bedrock completion failed: %w", err)
+	}
+	if len(resp.Results) == 0 {
+		return Message{}, Usage{}, fmt.Errorf("bedrock completion returned no results")
+	}
+	if resp.Results[0].CompletionReason == "CONTENT_FILTERED" {
+		return Message{}, Usage{}, fmt.Errorf("bedrock completion failed: %w", ErrContentFiltered)
+	}
+
+	usage := Usage{
+		Model:            p.config.ModelID,
+		PromptTokens:     resp.InputTextTokenCount,
+		CompletionTokens: resp.Results[0].TokenCount,
+	}
+	return Message{Role: RoleAssistant, Content: strings.TrimSpace(resp.Results[0].OutputText)}, usage, nil
+}
+
+// invoke calls the InvokeModel API of a model
+func (p *BedrockProvider) invoke(ctx context.Context, modelID string, body, out interface{}) error {
+	url := fmt.Sprintf("%s/model/%s/invoke", p.config.Endpoint, sigv4.URIEncode(modelID, true))
+	return postJSON(ctx, p.client, url, body, out, func(req *http.Request, payload []byte) error {
+		sigv4.Sign(req, payload, p.config.Credentials, "bedrock", p.config.Region, time.Now())
+		return nil
+	})
+}
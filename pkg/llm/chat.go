@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Role identifies the author of a chat message
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is a single message in a chat conversation
+type Message struct {
+	Role       Role
+	Content    string
+	Name       string
+	ToolCallID string
+	ToolCalls  []ToolCall
+	// Images are attachments for providers with vision support
+	Images []Image
+}
+
+// ChatProvider is implemented by providers with a native chat API
+type ChatProvider interface {
+	Provider
+
+	// Chat generates the next assistant message for the conversation
+	Chat(ctx context.Context, messages []Message) (Message, error)
+}
+
+// Chat sends a conversation to any provider. Providers that do not implement
+// ChatProvider receive the conversation flattened by FormatPrompt.
+func Chat(ctx context.Context, p Provider, messages []Message) (Message, error) {
+	if cp, ok := p.(ChatProvider); ok {
+		return cp.Chat(ctx, messages)
+	}
+
+	completion, err := p.Complete(ctx, FormatPrompt(messages))
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Role: RoleAssistant, Content: completion}, nil
+}
+
+// FormatPrompt flattens a conversation into a single prompt string. Images
+// are replaced by placeholders.
+func FormatPrompt(messages []Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		switch {
+		case m.Role == RoleSystem:
+			b.WriteString(m.Content)
+			b.WriteString("\n\n")
+		case m.Name != "":
+			b.WriteString(fmt.Sprintf("%s (%s): %s\n", m.Role, m.Name, m.Content))
+		default:
+			b.WriteString(fmt.Sprintf("%s: %s\n", m.Role, m.Content))
+		}
+		for _, img := range m.Images {
+			if img.URL != "" {
+				b.WriteString(fmt.Sprintf("[image: %s]\n", img.URL))
+			} else {
+				b.WriteString(fmt.Sprintf("[image: %s]\n", img.MediaType))
+			}
+		}
+	}
+	b.WriteString(fmt.Sprintf("%s: ", RoleAssistant))
+	return b.String()
+}
+
+// LastUserMessage returns the content of the last user message
+func LastUserMessage(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleUser {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/reliability"
+)
+
+// Error types classifying LLM failures. Provider errors wrap one of them,
+// so callers can match a class with errors.Is.
+type LLMError string
+
+func (e LLMError) Error() string { return string(e) }
+
+const (
+	ErrRateLimited     = LLMError("llm rate limit exceeded")
+	ErrTimeout         = LLMError("llm request timed out")
+	ErrContentFiltered = LLMError("llm content filtered")
+	ErrInvalidRequest  = LLMError("llm invalid request")
+	ErrAuthentication  = LLMError("llm authentication failed")
+	ErrServer          = LLMError("llm server error")
+	// ErrNotRecorded is returned by a ReplayProvider for requests missing
+	// from its recording
+	ErrNotRecorded = LLMError("llm interaction not recorded")
+)
+
+// APIError is a failed provider API call
+type APIError struct {
+	// Class is the LLMError the failure belongs to
+	Class      LLMError
+	StatusCode int
+	Body       string
+	// Delay is the wait requested by the Retry-After header, if any
+	Delay time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", e.Class, e.StatusCode, e.Body)
+}
+
+// Unwrap returns the error class
+func (e *APIError) Unwrap() error {
+	return e.Class
+}
+
+// RetryAfter returns the delay requested by the server before retrying,
+// for reliability.Retry
+func (e *APIError) RetryAfter() time.Duration {
+	return e.Delay
+}
+
+// newAPIError classifies a non-2xx response
+func newAPIError(resp *http.Response, body string) *APIError {
+	return &APIError{
+		Class:      classifyStatus(resp.StatusCode, body),
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		Delay:      parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// classifyStatus maps an HTTP status to its error class
+func classifyStatus(status int, body string) LLMError {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	// Filtered content fails again on retry, whatever the status
+	case strings.Contains(body, "content_filter") || strings.Contains(body, "content_policy"):
+		return ErrContentFiltered
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return ErrTimeout
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuthentication
+	case status >= 500:
+		return ErrServer
+	default:
+		return ErrInvalidRequest
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Classify returns the LLMError class of err, or nil if it belongs to none.
+// Deadline and network timeouts are classified as ErrTimeout.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var class LLMError
+	if errors.As(err, &class) {
+		return class
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+	return nil
+}
+
+// RetryConfig returns retry settings for LLM calls: rate limits, timeouts,
+// and server errors are retried, honoring Retry-After; invalid requests,
+// filtered content, authentication failures, and requests missing from a
+// replayed recording are not.
+func RetryConfig() reliability.RetryConfig {
+	config := reliability.DefaultRetryConfig()
+	config.Policies = []reliability.ClassPolicy{
+		{Class: ErrInvalidRequest, NoRetry: true},
+		{Class: ErrContentFiltered, NoRetry: true},
+		{Class: ErrAuthentication, NoRetry: true},
+		{Class: ErrNotRecorded, NoRetry: true},
+		{Class: ErrRateLimited, MaxAttempts: 5, InitialDelay: time.Second},
+		{Class: ErrTimeout},
+		{Class: ErrServer},
+	}
+	return config
+}
+
+// NewCircuitBreaker creates a circuit breaker for a provider that only
+// counts failures of the service, not of individual requests
+func NewCircuitBreaker(failureThreshold int, resetTimeout time.Duration) *reliability.CircuitBreaker {
+	return reliability.NewCircuitBreaker(failureThreshold, resetTimeout).
+		IgnoreErrors(ErrInvalidRequest, ErrContentFiltered, ErrNotRecorded)
+}
@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/user/modulox/pkg/config"
+)
+
+// ProviderFactory creates a provider from configuration
+type ProviderFactory func(cfg *config.Config) (Provider, error)
+
+var (
+	providerFactories = map[string]ProviderFactory{
+		"azure":   newAzureFromConfig,
+		"bedrock": newBedrockFromConfig,
+	}
+	providerFactoriesMu sync.RWMutex
+)
+
+// RegisterProvider makes a provider type selectable by Config.Provider.Type,
+// replacing any factory registered under the same name
+func RegisterProvider(name string, factory ProviderFactory) {
+	providerFactoriesMu.Lock()
+	defer providerFactoriesMu.Unlock()
+	providerFactories[name] = factory
+}
+
+// ProviderTypes returns the registered provider types in order
+func ProviderTypes() []string {
+	providerFactoriesMu.RLock()
+	defer providerFactoriesMu.RUnlock()
+
+	names := make([]string, 0, len(providerFactories))
+	for name := range providerFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFromConfig creates the provider selected by Config.Provider.Type
+func NewFromConfig(cfg *config.Config) (Provider, error) {
+	if cfg.Provider.Type == "" {
+		return nil, fmt.Errorf("provider type is required")
+	}
+	providerFactoriesMu.RLock()
+	factory, exists := providerFactories[cfg.Provider.Type]
+	providerFactoriesMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown provider type: %s", cfg.Provider.Type)
+	}
+	return factory(cfg)
+}
+
+// newAzureFromConfig maps Config.Provider to an Azure OpenAI provider: the
+// model name is the chat deployment and the base URL the resource endpoint
+func newAzureFromConfig(cfg *config.Config) (Provider, error) {
+	temperature, err := floatParameter(cfg, "temperature")
+	if err != nil {
+		return nil, err
+	}
+	return NewAzureProvider(AzureConfig{
+		Endpoint:            cfg.Provider.BaseURL,
+		APIKey:              cfg.Provider.APIKey,
+		APIVersion:          stringParameter(cfg, "api_version"),
+		ChatDeployment:      cfg.Provider.ModelName,
+		EmbeddingDeployment: stringParameter(cfg, "embedding_deployment"),
+		Temperature:         temperature,
+		MaxTokens:           cfg.Agent.MaxTokens,
+	})
+}
+
+// newBedrockFromConfig maps Config.Provider to a Bedrock provider: the
+// model name is the model ID and the base URL overrides the endpoint
+func newBedrockFromConfig(cfg *config.Config) (Provider, error) {
+	temperature, err := floatParameter(cfg, "temperature")
+	if err != nil {
+		return nil, err
+	}
+	return NewBedrockProvider(BedrockConfig{
+		Region:           stringParameter(cfg, "region"),
+		ModelID:          cfg.Provider.ModelName,
+		EmbeddingModelID: stringParameter(cfg, "embedding_model"),
+		Temperature:      temperature,
+		MaxTokens:        cfg.Agent.MaxTokens,
+		Endpoint:         cfg.Provider.BaseURL,
+	})
+}
+
+// stringParameter returns a string from Config.Provider.Parameters
+func stringParameter(cfg *config.Config, key string) string {
+	value, exists := cfg.Provider.Parameters[key]
+	if !exists || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// floatParameter returns a number from Config.Provider.Parameters
+func floatParameter(cfg *config.Config, key string) (float64, error) {
+	switch v := cfg.Provider.Parameters[key].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("provider parameter %s: expected a number, got %q", key, v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("provider parameter %s: expected a number", key)
+	}
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/user/modulox/pkg/reliability"
+)
+
+// FaultyProvider wraps a provider and injects the faults drawn by a
+// reliability.FaultInjector into its calls, for testing how agents cope
+// with a failing model. Targets are "llm." followed by the method, e.g.
+// "llm.complete" or "llm.chat_tools". Injected errors without an LLMError
+// class are reported as ErrServer and dropped calls as ErrTimeout, so
+// retries and circuit breakers treat them like real outages.
+type FaultyProvider struct {
+	inner  Provider
+	faults *reliability.FaultInjector
+}
+
+// NewFaultyProvider creates a provider injecting faults into inner. It
+// exposes only the optional interfaces inner implements.
+func NewFaultyProvider(inner Provider, faults *reliability.FaultInjector) Provider {
+	return Narrow(&FaultyProvider{inner: inner, faults: faults}, inner)
+}
+
+// inject applies the fault drawn for a call of method
+func (p *FaultyProvider) inject(ctx context.Context, method string) error {
+	err := p.faults.Inject("llm." + method).Apply(ctx)
+	switch {
+	case err == nil || Classify(err) != nil || ctx.Err() != nil:
+		return err
+	case errors.Is(err, reliability.ErrFaultDropped):
+		return fmt.Errorf("%v: %w", err, ErrTimeout)
+	default:
+		return fmt.Errorf("%v: %w", err, ErrServer)
+	}
+}
+
+// Complete implements Provider.Complete
+func (p *FaultyProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	if err := p.inject(ctx, MethodComplete); err != nil {
+		return "", err
+	}
+	return p.inner.Complete(ctx, prompt)
+}
+
+// Embed implements Provider.Embed
+func (p *FaultyProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := p.inject(ctx, MethodEmbed); err != nil {
+		return nil, err
+	}
+	return p.inner.Embed(ctx, text)
+}
+
+// Chat implements ChatProvider.Chat
+func (p *FaultyProvider) Chat(ctx context.Context, messages []Message) (Message, error) {
+	if err := p.inject(ctx, MethodChat); err != nil {
+		return Message{}, err
+	}
+	return Chat(ctx, p.inner, messages)
+}
+
+// SupportsImages implements VisionProvider.SupportsImages
+func (p *FaultyProvider) SupportsImages() bool {
+	return SupportsImages(p.inner)
+}
+
+// ChatWithTools implements ToolCallingProvider.ChatWithTools. Without native
+// function calling in the wrapped provider the tools are ignored.
+func (p *FaultyProvider) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	if err := p.inject(ctx, MethodChatTools); err != nil {
+		return Message{}, err
+	}
+	if tp, ok := p.inner.(ToolCallingProvider); ok {
+		return tp.ChatWithTools(ctx, messages, tools)
+	}
+	return Chat(ctx, p.inner, messages)
+}
+
+// CompleteStream implements StreamingProvider.CompleteStream. Faults apply
+// to opening the stream.
+func (p *FaultyProvider) CompleteStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	if err := p.inject(ctx, MethodStream); err != nil {
+		return nil, err
+	}
+	return Stream(ctx, p.inner, prompt)
+}
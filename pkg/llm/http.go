@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// postJSON sends body as JSON to url and decodes the JSON response into
+// out. prepare may set headers or sign the request with its payload.
+// Non-2xx responses are returned as a classified *APIError.
+func postJSON(ctx context.Context, client *http.Client, url string, body, out interface{}, prepare func(req *http.Request, payload []byte) error) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if prepare != nil {
+		if err := prepare(req, payload); err != nil {
+			return err
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil && Classify(err) == ErrTimeout {
+			return fmt.Errorf("%w: %v", ErrTimeout, err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return newAPIError(resp, string(data))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
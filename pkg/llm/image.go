@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+// Image is an image attached to a message, referenced by URL or carried
+// inline as bytes
+type Image struct {
+	URL string
+	// Data holds the image bytes when no URL is given
+	Data []byte
+	// MediaType is the MIME type of Data, e.g. "image/png"
+	MediaType string
+}
+
+// ImageURL creates an image referenced by URL
+func ImageURL(url string) Image {
+	return Image{URL: url}
+}
+
+// ImageData creates an inline image; an empty media type is sniffed from the data
+func ImageData(data []byte, mediaType string) Image {
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+	}
+	return Image{Data: data, MediaType: mediaType}
+}
+
+// DataURL returns the image URL, encoding inline data as a data: URL
+func (img Image) DataURL() string {
+	if img.URL != "" {
+		return img.URL
+	}
+	return "data:" + img.MediaType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+}
+
+// VisionProvider is implemented by providers that may accept images natively
+type VisionProvider interface {
+	ChatProvider
+
+	// SupportsImages reports whether the configured model accepts images
+	SupportsImages() bool
+}
+
+// SupportsImages reports whether images sent to p reach the model. Other
+// providers only see a placeholder for each image.
+func SupportsImages(p Provider) bool {
+	vp, ok := p.(VisionProvider)
+	return ok && vp.SupportsImages()
+}
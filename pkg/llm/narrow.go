@@ -0,0 +1,108 @@
+package llm
+
+import "context"
+
+// Narrow returns wrapper, a provider wrapping inner, exposing only the
+// optional interfaces inner implements. Wrappers such as PolicyProvider
+// implement every optional interface, so callers checking for one, e.g.
+// StreamingProvider, would otherwise take the wrapper's fallback instead
+// of the path they use for providers without it.
+func Narrow(wrapper, inner Provider) Provider {
+	_, chat := inner.(ChatProvider)
+	_, tools := inner.(ToolCallingProvider)
+	_, stream := inner.(StreamingProvider)
+	_, vision := inner.(VisionProvider)
+	return narrow(wrapper, capabilities{chat: chat, tools: tools, stream: stream, vision: vision})
+}
+
+// capabilities are the optional interfaces a narrowed provider exposes
+type capabilities struct {
+	chat, tools, stream, vision bool
+}
+
+// narrow returns wrapper exposing only the optional interfaces in caps that
+// wrapper implements
+func narrow(wrapper Provider, caps capabilities) Provider {
+	stream, isStream := wrapper.(StreamingProvider)
+	var s *streamer
+	if isStream && caps.stream {
+		s = &streamer{stream}
+	}
+
+	chat, isChat := wrapper.(ChatProvider)
+	if !isChat || !caps.chat {
+		if s != nil {
+			return struct {
+				Provider
+				*streamer
+			}{wrapper, s}
+		}
+		return struct{ Provider }{wrapper}
+	}
+
+	var t *toolCaller
+	if tools, isTools := wrapper.(ToolCallingProvider); isTools && caps.tools {
+		t = &toolCaller{tools}
+	}
+	vision, isVision := wrapper.(VisionProvider)
+	if !isVision || !caps.vision {
+		vision = nil
+	}
+
+	switch {
+	case vision != nil && t != nil && s != nil:
+		return struct {
+			VisionProvider
+			*toolCaller
+			*streamer
+		}{vision, t, s}
+	case vision != nil && t != nil:
+		return struct {
+			VisionProvider
+			*toolCaller
+		}{vision, t}
+	case vision != nil && s != nil:
+		return struct {
+			VisionProvider
+			*streamer
+		}{vision, s}
+	case vision != nil:
+		return struct{ VisionProvider }{vision}
+	case t != nil && s != nil:
+		return struct {
+			ChatProvider
+			*toolCaller
+			*streamer
+		}{chat, t, s}
+	case t != nil:
+		return struct {
+			ChatProvider
+			*toolCaller
+		}{chat, t}
+	case s != nil:
+		return struct {
+			ChatProvider
+			*streamer
+		}{chat, s}
+	}
+	return struct{ ChatProvider }{chat}
+}
+
+// toolCaller and streamer carry only the methods ToolCallingProvider and
+// StreamingProvider add, so they combine with other interfaces in one
+// struct without ambiguous selectors
+type toolCaller struct {
+	p ToolCallingProvider
+}
+
+func (t *toolCaller) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	return t.p.ChatWithTools(ctx, messages, tools)
+}
+
+type streamer struct {
+	p StreamingProvider
+}
+
+func (s *streamer) CompleteStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	return s.p.CompleteStream(ctx, prompt)
+}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/user/modulox/pkg/reliability"
+)
+
+// PolicyProvider wraps a provider and runs every call under a
+// reliability.Policy, e.g. one combining RetryConfig with NewCircuitBreaker
+type PolicyProvider struct {
+	inner  Provider
+	policy *reliability.Policy
+}
+
+// NewPolicyProvider creates a provider running inner's calls under policy
+func NewPolicyProvider(inner Provider, policy *reliability.Policy) *PolicyProvider {
+	return &PolicyProvider{inner: inner, policy: policy}
+}
+
+// Complete implements Provider.Complete
+func (p *PolicyProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	var completion string
+	err := p.policy.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		completion, err = p.inner.Complete(ctx, prompt)
+		return err
+	})
+	return completion, err
+}
+
+// Embed implements Provider.Embed
+func (p *PolicyProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	var embedding []float32
+	err := p.policy.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		embedding, err = p.inner.Embed(ctx, text)
+		return err
+	})
+	return embedding, err
+}
+
+// Chat implements ChatProvider.Chat
+func (p *PolicyProvider) Chat(ctx context.Context, messages []Message) (Message, error) {
+	var reply Message
+	err := p.policy.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		reply, err = Chat(ctx, p.inner, messages)
+		return err
+	})
+	return reply, err
+}
+
+// SupportsImages implements VisionProvider.SupportsImages
+func (p *PolicyProvider) SupportsImages() bool {
+	return SupportsImages(p.inner)
+}
+
+// ChatWithTools implements ToolCallingProvider.ChatWithTools. Without native
+// function calling in the wrapped provider the tools are ignored.
+func (p *PolicyProvider) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	tp, ok := p.inner.(ToolCallingProvider)
+	if !ok {
+		return p.Chat(ctx, messages)
+	}
+	var reply Message
+	err := p.policy.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		reply, err = tp.ChatWithTools(ctx, messages, tools)
+		return err
+	})
+	return reply, err
+}
+
+// CompleteStream implements StreamingProvider.CompleteStream. The policy
+// covers opening the stream; a policy timeout would cut the stream short,
+// so streams are opened under the caller's context.
+func (p *PolicyProvider) CompleteStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	var chunks <-chan Chunk
+	err := p.policy.Execute(ctx, func(context.Context) error {
+		var err error
+		chunks, err = Stream(ctx, p.inner, prompt)
+		return err
+	})
+	return chunks, err
+}
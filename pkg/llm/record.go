@@ -0,0 +1,390 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Methods of recorded interactions
+const (
+	MethodComplete  = "complete"
+	MethodEmbed     = "embed"
+	MethodChat      = "chat"
+	MethodChatTools = "chat_tools"
+	MethodStream    = "stream"
+)
+
+// Interaction is a provider call captured by a RecordingProvider. Key
+// identifies the request; identical requests share a key.
+type Interaction struct {
+	Key        string           `json:"key"`
+	Method     string           `json:"method"`
+	Prompt     string           `json:"prompt,omitempty"`
+	Messages   []Message        `json:"messages,omitempty"`
+	Tools      []ToolDefinition `json:"tools,omitempty"`
+	Completion string           `json:"completion,omitempty"`
+	Chunks     []string         `json:"chunks,omitempty"`
+	Embedding  []float32        `json:"embedding,omitempty"`
+	Reply      *Message         `json:"reply,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	// ErrorClass is the LLMError class of Error, restored on replay
+	ErrorClass string    `json:"error_class,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// interactionKey hashes a request so it can be looked up on replay
+func interactionKey(method, prompt string, messages []Message, tools []ToolDefinition) string {
+	request, _ := json.Marshal(struct {
+		Method   string
+		Prompt   string
+		Messages []Message
+		Tools    []ToolDefinition
+	}{method, prompt, messages, tools})
+	sum := sha256.Sum256(request)
+	return hex.EncodeToString(sum[:])
+}
+
+// newInteraction describes a request ready to be recorded
+func newInteraction(method, prompt string, messages []Message, tools []ToolDefinition) Interaction {
+	return Interaction{
+		Key:      interactionKey(method, prompt, messages, tools),
+		Method:   method,
+		Prompt:   prompt,
+		Messages: messages,
+		Tools:    tools,
+	}
+}
+
+// err restores the recorded error, wrapping its class so errors.Is still
+// matches it
+func (i Interaction) err() error {
+	if i.Error == "" {
+		return nil
+	}
+	if i.ErrorClass != "" {
+		return fmt.Errorf("%s (replayed): %w", i.Error, LLMError(i.ErrorClass))
+	}
+	return errors.New(i.Error)
+}
+
+// RecordingProvider wraps a provider and appends every call and its result
+// to a JSON Lines file, for a ReplayProvider to serve back later. Hand
+// agents its Provider, which exposes only the optional interfaces of the
+// wrapped provider, so they make the calls they would make without the
+// recording.
+type RecordingProvider struct {
+	inner Provider
+	file  *os.File
+	mu    sync.Mutex
+}
+
+// NewRecordingProvider creates a recording provider appending to the file
+// at path, which is created if needed
+func NewRecordingProvider(inner Provider, path string) (*RecordingProvider, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	return &RecordingProvider{inner: inner, file: file}, nil
+}
+
+// Provider returns the recording provider exposing only the optional
+// interfaces the wrapped provider implements
+func (p *RecordingProvider) Provider() Provider {
+	return Narrow(p, p.inner)
+}
+
+// Close closes the recording file
+func (p *RecordingProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.file.Close()
+}
+
+// record appends an interaction with the outcome of its call. Calls ended
+// by their context are not recorded, as they would not replay meaningfully.
+func (p *RecordingProvider) record(ctx context.Context, interaction Interaction, callErr error) error {
+	if callErr != nil {
+		if ctx.Err() != nil {
+			return callErr
+		}
+		interaction.Error = callErr.Error()
+		if class := Classify(callErr); class != nil {
+			interaction.ErrorClass = class.Error()
+		}
+	}
+	interaction.RecordedAt = time.Now().UTC()
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return fmt.Errorf("failed to encode interaction: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to record interaction: %w", err)
+	}
+	return callErr
+}
+
+// Complete implements Provider.Complete
+func (p *RecordingProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	interaction := newInteraction(MethodComplete, prompt, nil, nil)
+	completion, err := p.inner.Complete(ctx, prompt)
+	interaction.Completion = completion
+	return completion, p.record(ctx, interaction, err)
+}
+
+// Embed implements Provider.Embed
+func (p *RecordingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	interaction := newInteraction(MethodEmbed, text, nil, nil)
+	embedding, err := p.inner.Embed(ctx, text)
+	interaction.Embedding = embedding
+	return embedding, p.record(ctx, interaction, err)
+}
+
+// Chat implements ChatProvider.Chat
+func (p *RecordingProvider) Chat(ctx context.Context, messages []Message) (Message, error) {
+	interaction := newInteraction(MethodChat, "", messages, nil)
+	reply, err := Chat(ctx, p.inner, messages)
+	if err == nil {
+		interaction.Reply = &reply
+	}
+	return reply, p.record(ctx, interaction, err)
+}
+
+// SupportsImages implements VisionProvider.SupportsImages
+func (p *RecordingProvider) SupportsImages() bool {
+	return SupportsImages(p.inner)
+}
+
+// ChatWithTools implements ToolCallingProvider.ChatWithTools. Without native
+// function calling in the wrapped provider the tools are ignored.
+func (p *RecordingProvider) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	interaction := newInteraction(MethodChatTools, "", messages, tools)
+	var reply Message
+	var err error
+	if tp, ok := p.inner.(ToolCallingProvider); ok {
+		reply, err = tp.ChatWithTools(ctx, messages, tools)
+	} else {
+		reply, err = Chat(ctx, p.inner, messages)
+	}
+	if err == nil {
+		interaction.Reply = &reply
+	}
+	return reply, p.record(ctx, interaction, err)
+}
+
+// CompleteStream implements StreamingProvider.CompleteStream. The stream
+// is recorded chunk by chunk once it finishes.
+func (p *RecordingProvider) CompleteStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	interaction := newInteraction(MethodStream, prompt, nil, nil)
+	upstream, err := Stream(ctx, p.inner, prompt)
+	if err != nil {
+		return nil, p.record(ctx, interaction, err)
+	}
+
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+		for chunk := range upstream {
+			if chunk.Content != "" {
+				interaction.Chunks = append(interaction.Chunks, chunk.Content)
+				interaction.Completion += chunk.Content
+			}
+			if chunk.Done || chunk.Err != nil {
+				if err := p.record(ctx, interaction, chunk.Err); err != nil && chunk.Err == nil {
+					chunk = Chunk{Err: err}
+				}
+			}
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return chunks, nil
+}
+
+// ReplayProvider serves interactions captured by a RecordingProvider
+// without calling a model. Identical requests are answered in the order
+// they were recorded, repeating the last answer once all are used; requests
+// that were never recorded fail with ErrNotRecorded.
+type ReplayProvider struct {
+	interactions map[string][]Interaction
+	served       map[string]int
+	// caps are the optional interfaces the recording shows in use
+	caps capabilities
+	mu   sync.Mutex
+}
+
+// NewReplayProvider creates a replay provider from the recording at path
+func NewReplayProvider(path string) (*ReplayProvider, error) {
+	interactions, err := LoadInteractions(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayProviderFromInteractions(interactions), nil
+}
+
+// NewReplayProviderFromInteractions creates a replay provider serving
+// interactions
+func NewReplayProviderFromInteractions(interactions []Interaction) *ReplayProvider {
+	p := &ReplayProvider{
+		interactions: make(map[string][]Interaction),
+		served:       make(map[string]int),
+		caps:         capabilities{chat: true, vision: true},
+	}
+	for _, interaction := range interactions {
+		p.interactions[interaction.Key] = append(p.interactions[interaction.Key], interaction)
+		switch interaction.Method {
+		case MethodChatTools:
+			p.caps.tools = true
+		case MethodStream:
+			p.caps.stream = true
+		}
+	}
+	return p
+}
+
+// Provider returns the replay provider exposing tool calling and streaming
+// only if the recording used them, so agents take the paths they took
+// while recording
+func (p *ReplayProvider) Provider() Provider {
+	return narrow(p, p.caps)
+}
+
+// LoadInteractions reads a recording written by a RecordingProvider
+func LoadInteractions(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid interaction: %w", path, line, err)
+		}
+		interactions = append(interactions, interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return interactions, nil
+}
+
+// lookup returns the next recorded answer to a request
+func (p *ReplayProvider) lookup(method, prompt string, messages []Message, tools []ToolDefinition) (Interaction, error) {
+	key := interactionKey(method, prompt, messages, tools)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	recorded := p.interactions[key]
+	if len(recorded) == 0 {
+		request := prompt
+		if request == "" {
+			request = FormatPrompt(messages)
+		}
+		if len(request) > 80 {
+			request = request[:80] + "..."
+		}
+		return Interaction{}, fmt.Errorf("%w: %s %q", ErrNotRecorded, method, request)
+	}
+	n := p.served[key]
+	p.served[key] = n + 1
+	if n >= len(recorded) {
+		n = len(recorded) - 1
+	}
+	return recorded[n], nil
+}
+
+// Complete implements Provider.Complete
+func (p *ReplayProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	interaction, err := p.lookup(MethodComplete, prompt, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return interaction.Completion, interaction.err()
+}
+
+// Embed implements Provider.Embed
+func (p *ReplayProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	interaction, err := p.lookup(MethodEmbed, text, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return interaction.Embedding, interaction.err()
+}
+
+// Chat implements ChatProvider.Chat
+func (p *ReplayProvider) Chat(ctx context.Context, messages []Message) (Message, error) {
+	return p.reply(MethodChat, messages, nil)
+}
+
+// SupportsImages implements VisionProvider.SupportsImages. Images are part
+// of the recorded requests, so replay accepts them.
+func (p *ReplayProvider) SupportsImages() bool {
+	return true
+}
+
+// ChatWithTools implements ToolCallingProvider.ChatWithTools
+func (p *ReplayProvider) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	return p.reply(MethodChatTools, messages, tools)
+}
+
+// reply returns the recorded reply to a conversation
+func (p *ReplayProvider) reply(method string, messages []Message, tools []ToolDefinition) (Message, error) {
+	interaction, err := p.lookup(method, "", messages, tools)
+	if err != nil {
+		return Message{}, err
+	}
+	if err := interaction.err(); err != nil {
+		return Message{}, err
+	}
+	if interaction.Reply == nil {
+		return Message{}, fmt.Errorf("recorded %s interaction has no reply", method)
+	}
+	return *interaction.Reply, nil
+}
+
+// CompleteStream implements StreamingProvider.CompleteStream, replaying
+// the recorded chunks
+func (p *ReplayProvider) CompleteStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	interaction, err := p.lookup(MethodStream, prompt, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan Chunk, len(interaction.Chunks)+1)
+	for _, content := range interaction.Chunks {
+		chunks <- Chunk{Content: content}
+	}
+	if err := interaction.err(); err != nil {
+		chunks <- Chunk{Err: err}
+	} else {
+		chunks <- Chunk{Done: true}
+	}
+	close(chunks)
+	return chunks, nil
+}
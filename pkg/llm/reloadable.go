@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// ReloadableProvider delegates to a provider that can be replaced while
+// the agent runs, e.g. when config.Watcher reports changed provider
+// settings. Calls started before a Swap finish on the old provider.
+type ReloadableProvider struct {
+	inner Provider
+	mu    sync.RWMutex
+}
+
+// NewReloadableProvider creates a provider delegating to inner until the
+// next Swap
+func NewReloadableProvider(inner Provider) *ReloadableProvider {
+	return &ReloadableProvider{inner: inner}
+}
+
+// Swap replaces the provider later calls are made to
+func (p *ReloadableProvider) Swap(inner Provider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inner = inner
+}
+
+// current returns the provider calls are made to
+func (p *ReloadableProvider) current() Provider {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.inner
+}
+
+// Complete implements Provider.Complete
+func (p *ReloadableProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.current().Complete(ctx, prompt)
+}
+
+// Embed implements Provider.Embed
+func (p *ReloadableProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return p.current().Embed(ctx, text)
+}
+
+// Chat implements ChatProvider.Chat
+func (p *ReloadableProvider) Chat(ctx context.Context, messages []Message) (Message, error) {
+	return Chat(ctx, p.current(), messages)
+}
+
+// SupportsImages implements VisionProvider.SupportsImages
+func (p *ReloadableProvider) SupportsImages() bool {
+	return SupportsImages(p.current())
+}
+
+// ChatWithTools implements ToolCallingProvider.ChatWithTools. Without native
+// function calling in the current provider the tools are ignored.
+func (p *ReloadableProvider) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	inner := p.current()
+	tp, ok := inner.(ToolCallingProvider)
+	if !ok {
+		return Chat(ctx, inner, messages)
+	}
+	return tp.ChatWithTools(ctx, messages, tools)
+}
+
+// CompleteStream implements StreamingProvider.CompleteStream
+func (p *ReloadableProvider) CompleteStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	return Stream(ctx, p.current(), prompt)
+}
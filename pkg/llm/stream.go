@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"context"
+)
+
+// Chunk is an incremental piece of a streamed completion
+type Chunk struct {
+	Content string
+	Done    bool
+	Err     error
+	// Usage is the usage the provider reported for the stream, if any; it
+	// is set on the final chunk
+	Usage *Usage
+}
+
+// StreamingProvider is implemented by providers that can stream tokens
+type StreamingProvider interface {
+	Provider
+
+	// CompleteStream generates a completion and delivers it incrementally.
+	// The channel is closed after a chunk with Done or Err set.
+	CompleteStream(ctx context.Context, prompt string) (<-chan Chunk, error)
+}
+
+// Stream returns a chunk stream for any provider. Providers that do not
+// implement StreamingProvider deliver the whole completion as a single chunk,
+// with the usage of those implementing UsageProvider.
+func Stream(ctx context.Context, p Provider, prompt string) (<-chan Chunk, error) {
+	if sp, ok := p.(StreamingProvider); ok {
+		return sp.CompleteStream(ctx, prompt)
+	}
+
+	done := Chunk{Done: true}
+	var completion string
+	var err error
+	if up, ok := p.(UsageProvider); ok {
+		var usage Usage
+		completion, usage, err = up.CompleteWithUsage(ctx, prompt)
+		done.Usage = &usage
+	} else {
+		completion, err = p.Complete(ctx, prompt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan Chunk, 2)
+	chunks <- Chunk{Content: completion}
+	chunks <- done
+	close(chunks)
+	return chunks, nil
+}
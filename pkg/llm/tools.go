@@ -0,0 +1,29 @@
+package llm
+
+import (
+	"context"
+)
+
+// ToolDefinition describes a tool the model may call
+type ToolDefinition struct {
+	Name        string
+	Description string
+	// Parameters is the JSON Schema of the tool arguments
+	Parameters map[string]interface{}
+}
+
+// ToolCall is a request from the model to invoke a tool
+type ToolCall struct {
+	ID   string
+	Name string
+	// Arguments is the JSON encoded tool input
+	Arguments string
+}
+
+// ToolCallingProvider is implemented by providers with native function calling
+type ToolCallingProvider interface {
+	ChatProvider
+
+	// ChatWithTools generates the next assistant message, which may request tool calls
+	ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error)
+}
@@ -0,0 +1,286 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// Usage is the token consumption and estimated cost of a single request
+type Usage struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	// Cost is the estimated cost in the currency of the configured Pricing
+	Cost float64
+	// Estimated is set when token counts were estimated rather than
+	// reported by the provider
+	Estimated bool
+}
+
+// TotalTokens returns the sum of prompt and completion tokens
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// UsageProvider is implemented by providers that report exact token counts
+type UsageProvider interface {
+	Provider
+
+	// CompleteWithUsage generates a completion and reports its token usage
+	CompleteWithUsage(ctx context.Context, prompt string) (string, Usage, error)
+}
+
+// ChatUsageProvider is implemented by providers that report exact token
+// counts for chat requests
+type ChatUsageProvider interface {
+	// ChatWithUsage generates the next assistant message and reports its
+	// token usage. Tools are ignored by providers without function calling.
+	ChatWithUsage(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, Usage, error)
+}
+
+// Pricing is the price per 1000 tokens of a model
+type Pricing struct {
+	PromptPer1K     float64
+	CompletionPer1K float64
+}
+
+// Cost returns the estimated cost of the usage
+func (p Pricing) Cost(u Usage) float64 {
+	return float64(u.PromptTokens)/1000*p.PromptPer1K +
+		float64(u.CompletionTokens)/1000*p.CompletionPer1K
+}
+
+// UsageScope attributes usage to the agent, workflow, and session that
+// caused it
+type UsageScope struct {
+	Agent    string
+	Workflow string
+	Session  string
+}
+
+type usageScopeKey struct{}
+
+// WithUsageScope returns a context attributing usage to scope. Empty fields
+// keep the values of the enclosing scope, so a workflow, the session in it,
+// and the agent it calls can each add their own.
+func WithUsageScope(ctx context.Context, scope UsageScope) context.Context {
+	current := UsageScopeFromContext(ctx)
+	if scope.Agent == "" {
+		scope.Agent = current.Agent
+	}
+	if scope.Workflow == "" {
+		scope.Workflow = current.Workflow
+	}
+	if scope.Session == "" {
+		scope.Session = current.Session
+	}
+	return context.WithValue(ctx, usageScopeKey{}, scope)
+}
+
+// UsageScopeFromContext returns the usage scope attached to the context
+func UsageScopeFromContext(ctx context.Context) UsageScope {
+	scope, _ := ctx.Value(usageScopeKey{}).(UsageScope)
+	return scope
+}
+
+// UsageRecorder receives the usage of each metered request
+type UsageRecorder func(ctx context.Context, usage Usage)
+
+type usageObserverKey struct{}
+
+// WithUsageObserver returns a context whose metered requests also report
+// their usage to observe, in addition to any observers already attached
+func WithUsageObserver(ctx context.Context, observe UsageRecorder) context.Context {
+	if parent, ok := ctx.Value(usageObserverKey{}).(UsageRecorder); ok {
+		inner := observe
+		observe = func(ctx context.Context, usage Usage) {
+			parent(ctx, usage)
+			inner(ctx, usage)
+		}
+	}
+	return context.WithValue(ctx, usageObserverKey{}, observe)
+}
+
+// MeterConfig contains configuration for a metered provider
+type MeterConfig struct {
+	// Model labels the recorded usage
+	Model   string
+	Pricing Pricing
+	// Record receives the usage of every request
+	Record UsageRecorder
+	// CountTokens estimates tokens for providers that do not implement
+	// UsageProvider; defaults to ~4 characters per token
+	CountTokens func(string) int
+}
+
+// MeteredProvider wraps a provider and records the token usage and cost of
+// every request
+type MeteredProvider struct {
+	inner  Provider
+	config MeterConfig
+}
+
+// NewMeteredProvider creates a metered provider
+func NewMeteredProvider(inner Provider, config MeterConfig) *MeteredProvider {
+	if config.CountTokens == nil {
+		config.CountTokens = func(s string) int { return (len(s) + 3) / 4 }
+	}
+	return &MeteredProvider{
+		inner:  inner,
+		config: config,
+	}
+}
+
+// Complete implements Provider.Complete
+func (p *MeteredProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	completion, _, err := p.CompleteWithUsage(ctx, prompt)
+	return completion, err
+}
+
+// CompleteWithUsage implements UsageProvider.CompleteWithUsage
+func (p *MeteredProvider) CompleteWithUsage(ctx context.Context, prompt string) (string, Usage, error) {
+	if up, ok := p.inner.(UsageProvider); ok {
+		completion, usage, err := up.CompleteWithUsage(ctx, prompt)
+		if err != nil {
+			return "", Usage{}, err
+		}
+		usage = p.record(ctx, usage)
+		return completion, usage, nil
+	}
+
+	completion, err := p.inner.Complete(ctx, prompt)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	usage := p.record(ctx, p.estimate(prompt, completion))
+	return completion, usage, nil
+}
+
+// Embed implements Provider.Embed. Embedding input counts as prompt tokens.
+func (p *MeteredProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	embedding, err := p.inner.Embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	p.record(ctx, p.estimate(text, ""))
+	return embedding, nil
+}
+
+// Chat implements ChatProvider.Chat
+func (p *MeteredProvider) Chat(ctx context.Context, messages []Message) (Message, error) {
+	if cp, ok := p.inner.(ChatUsageProvider); ok {
+		return p.chatWithUsage(ctx, cp, messages, nil)
+	}
+	reply, err := Chat(ctx, p.inner, messages)
+	if err != nil {
+		return Message{}, err
+	}
+	p.record(ctx, p.estimate(FormatPrompt(messages), reply.Content))
+	return reply, nil
+}
+
+// SupportsImages implements VisionProvider.SupportsImages
+func (p *MeteredProvider) SupportsImages() bool {
+	return SupportsImages(p.inner)
+}
+
+// ChatWithTools implements ToolCallingProvider.ChatWithTools. Without native
+// function calling in the wrapped provider the tools are ignored.
+func (p *MeteredProvider) ChatWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	tp, ok := p.inner.(ToolCallingProvider)
+	if !ok {
+		return p.Chat(ctx, messages)
+	}
+	if cp, ok := p.inner.(ChatUsageProvider); ok {
+		return p.chatWithUsage(ctx, cp, messages, tools)
+	}
+	reply, err := tp.ChatWithTools(ctx, messages, tools)
+	if err != nil {
+		return Message{}, err
+	}
+	var completion strings.Builder
+	completion.WriteString(reply.Content)
+	for _, call := range reply.ToolCalls {
+		completion.WriteString(call.Name)
+		completion.WriteString(call.Arguments)
+	}
+	p.record(ctx, p.estimate(FormatPrompt(messages), completion.String()))
+	return reply, nil
+}
+
+// chatWithUsage makes a chat request recording the usage the provider
+// reports
+func (p *MeteredProvider) chatWithUsage(ctx context.Context, cp ChatUsageProvider, messages []Message, tools []ToolDefinition) (Message, error) {
+	reply, usage, err := cp.ChatWithUsage(ctx, messages, tools)
+	if err != nil {
+		return Message{}, err
+	}
+	p.record(ctx, usage)
+	return reply, nil
+}
+
+// CompleteStream implements StreamingProvider.CompleteStream. Usage is
+// recorded once the stream ends, whether or not it finished: the usage the
+// provider reported, or an estimate from the chunks received.
+func (p *MeteredProvider) CompleteStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	upstream, err := Stream(ctx, p.inner, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+		var completion strings.Builder
+		var reported *Usage
+		defer func() {
+			if reported != nil {
+				p.record(ctx, *reported)
+				return
+			}
+			p.record(ctx, p.estimate(prompt, completion.String()))
+		}()
+
+		for chunk := range upstream {
+			completion.WriteString(chunk.Content)
+			if chunk.Usage != nil {
+				reported = chunk.Usage
+			}
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return chunks, nil
+}
+
+// estimate counts the tokens of a request locally
+func (p *MeteredProvider) estimate(prompt, completion string) Usage {
+	usage := Usage{
+		PromptTokens: p.config.CountTokens(prompt),
+		Estimated:    true,
+	}
+	if completion != "" {
+		usage.CompletionTokens = p.config.CountTokens(completion)
+	}
+	return usage
+}
+
+// record prices the usage and hands it to the recorder
+func (p *MeteredProvider) record(ctx context.Context, usage Usage) Usage {
+	if usage.Model == "" {
+		usage.Model = p.config.Model
+	}
+	if usage.Cost == 0 {
+		usage.Cost = p.config.Pricing.Cost(usage)
+	}
+	if p.config.Record != nil {
+		p.config.Record(ctx, usage)
+	}
+	if observe, ok := ctx.Value(usageObserverKey{}).(UsageRecorder); ok {
+		observe(ctx, usage)
+	}
+	return usage
+}
@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/llm"
+)
+
+// ConversationPersistence stores ordered chat histories keyed by session ID
+type ConversationPersistence interface {
+	// Load returns the full history of a session in order
+	Load(ctx context.Context, sessionID string) ([]llm.Message, error)
+
+	// Append adds messages to the end of a session's history
+	Append(ctx context.Context, sessionID string, messages ...llm.Message) error
+
+	// Delete removes a session's history
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// ConversationActivity is implemented by persistence that records when each
+// session was last appended to, so idle sessions can be swept
+type ConversationActivity interface {
+	// Sessions returns the IDs of the stored sessions
+	Sessions() ([]string, error)
+
+	// LastActive returns when messages were last appended to a session, or
+	// the zero time for unknown sessions
+	LastActive(ctx context.Context, sessionID string) (time.Time, error)
+}
+
+// ConversationConfig contains configuration for a conversation store
+type ConversationConfig struct {
+	// MaxTurns bounds the number of messages returned by Window (0 = unlimited)
+	MaxTurns int
+	// MaxTokens bounds the estimated token size of Window (0 = unlimited)
+	MaxTokens int
+	// CountTokens estimates the tokens in a message; defaults to ~4 characters per token
+	CountTokens func(llm.Message) int
+	// Persistence backs the store; defaults to an in-memory implementation
+	Persistence ConversationPersistence
+}
+
+// ConversationStore keeps ordered chat histories per session, separate from
+// vector memory, and trims them to fit a prompt budget
+type ConversationStore struct {
+	config ConversationConfig
+}
+
+// NewConversationStore creates a new conversation store
+func NewConversationStore(config ConversationConfig) *ConversationStore {
+	if config.CountTokens == nil {
+		config.CountTokens = estimateTokens
+	}
+	if config.Persistence == nil {
+		config.Persistence = NewInMemoryConversations()
+	}
+	return &ConversationStore{config: config}
+}
+
+// Append records messages in a session's history
+func (cs *ConversationStore) Append(ctx context.Context, sessionID string, messages ...llm.Message) error {
+	return cs.config.Persistence.Append(ctx, sessionID, messages...)
+}
+
+// History returns the complete history of a session
+func (cs *ConversationStore) History(ctx context.Context, sessionID string) ([]llm.Message, error) {
+	return cs.config.Persistence.Load(ctx, sessionID)
+}
+
+// Window returns the most recent messages of a session that fit within
+// MaxTurns and MaxTokens, never starting with a tool result cut off from
+// its call
+func (cs *ConversationStore) Window(ctx context.Context, sessionID string) ([]llm.Message, error) {
+	history, err := cs.config.Persistence.Load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	if cs.config.MaxTurns > 0 && len(history) > cs.config.MaxTurns {
+		start = len(history) - cs.config.MaxTurns
+	}
+
+	if cs.config.MaxTokens > 0 {
+		budget := cs.config.MaxTokens
+		i := len(history) - 1
+		for ; i >= start; i-- {
+			budget -= cs.config.CountTokens(history[i])
+			if budget < 0 {
+				break
+			}
+		}
+		start = i + 1
+	}
+
+	// Tool results are only valid after the assistant message calling the
+	// tool, so drop results whose call fell outside the window
+	for start < len(history) && history[start].Role == llm.RoleTool {
+		start++
+	}
+
+	return history[start:], nil
+}
+
+// Clear removes a session's history
+func (cs *ConversationStore) Clear(ctx context.Context, sessionID string) error {
+	return cs.config.Persistence.Delete(ctx, sessionID)
+}
+
+// LastActive returns when messages were last appended to a session, or the
+// zero time when unknown or the persistence does not record it
+func (cs *ConversationStore) LastActive(ctx context.Context, sessionID string) (time.Time, error) {
+	activity, ok := cs.config.Persistence.(ConversationActivity)
+	if !ok {
+		return time.Time{}, nil
+	}
+	return activity.LastActive(ctx, sessionID)
+}
+
+// Sweep deletes the sessions idle for longer than ttl and returns their
+// IDs. The persistence must implement ConversationActivity.
+func (cs *ConversationStore) Sweep(ctx context.Context, ttl time.Duration) ([]string, error) {
+	activity, ok := cs.config.Persistence.(ConversationActivity)
+	if !ok {
+		return nil, fmt.Errorf("conversation persistence does not record activity")
+	}
+	ids, err := activity.Sessions()
+	if err != nil {
+		return nil, err
+	}
+	var swept []string
+	for _, id := range ids {
+		lastActive, err := activity.LastActive(ctx, id)
+		if err != nil {
+			return swept, err
+		}
+		if lastActive.IsZero() || time.Since(lastActive) <= ttl {
+			continue
+		}
+		if err := cs.config.Persistence.Delete(ctx, id); err != nil {
+			return swept, err
+		}
+		swept = append(swept, id)
+	}
+	return swept, nil
+}
+
+// estimateTokens approximates the token count of a message
+func estimateTokens(m llm.Message) int {
+	return (len(m.Content) + 3) / 4
+}
+
+// InMemoryConversations provides an in-memory ConversationPersistence
+type InMemoryConversations struct {
+	sessions map[string][]llm.Message
+	active   map[string]time.Time
+	mu       sync.RWMutex
+}
+
+// NewInMemoryConversations creates a new in-memory conversation persistence
+func NewInMemoryConversations() *InMemoryConversations {
+	return &InMemoryConversations{
+		sessions: make(map[string][]llm.Message),
+		active:   make(map[string]time.Time),
+	}
+}
+
+// Load implements ConversationPersistence.Load
+func (ic *InMemoryConversations) Load(ctx context.Context, sessionID string) ([]llm.Message, error) {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+	return append([]llm.Message(nil), ic.sessions[sessionID]...), nil
+}
+
+// Append implements ConversationPersistence.Append
+func (ic *InMemoryConversations) Append(ctx context.Context, sessionID string, messages ...llm.Message) error {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	ic.sessions[sessionID] = append(ic.sessions[sessionID], messages...)
+	ic.active[sessionID] = time.Now()
+	return nil
+}
+
+// Delete implements ConversationPersistence.Delete
+func (ic *InMemoryConversations) Delete(ctx context.Context, sessionID string) error {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	delete(ic.sessions, sessionID)
+	delete(ic.active, sessionID)
+	return nil
+}
+
+// Sessions implements ConversationActivity.Sessions
+func (ic *InMemoryConversations) Sessions() ([]string, error) {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+	ids := make([]string, 0, len(ic.sessions))
+	for id := range ic.sessions {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// LastActive implements ConversationActivity.LastActive
+func (ic *InMemoryConversations) LastActive(ctx context.Context, sessionID string) (time.Time, error) {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+	return ic.active[sessionID], nil
+}
+
+type conversationKey struct{}
+
+// WithConversation returns a context bound to the given conversation session
+func WithConversation(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, conversationKey{}, sessionID)
+}
+
+// ConversationFromContext returns the conversation session bound to the context
+func ConversationFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(conversationKey{}).(string)
+	return id, ok && id != ""
+}
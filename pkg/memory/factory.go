@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/user/modulox/pkg/config"
+)
+
+// StoreFactory creates a vector store from configuration
+type StoreFactory func(cfg *config.Config) (VectorStore, error)
+
+var (
+	storeFactories = map[string]StoreFactory{
+		"memory":   newBaseFromConfig,
+		"file":     newFileFromConfig,
+		"qdrant":   newQdrantFromConfig,
+		"pinecone": newPineconeFromConfig,
+		"pgvector": newPGVectorFromConfig,
+	}
+	storeFactoriesMu sync.RWMutex
+)
+
+// RegisterStore makes a store type selectable by Config.Memory.Type,
+// replacing any factory registered under the same name
+func RegisterStore(name string, factory StoreFactory) {
+	storeFactoriesMu.Lock()
+	defer storeFactoriesMu.Unlock()
+	storeFactories[name] = factory
+}
+
+// StoreTypes returns the registered store types in order
+func StoreTypes() []string {
+	storeFactoriesMu.RLock()
+	defer storeFactoriesMu.RUnlock()
+
+	names := make([]string, 0, len(storeFactories))
+	for name := range storeFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFromConfig creates the VectorStore selected by Config.Memory.Type
+func NewFromConfig(cfg *config.Config) (VectorStore, error) {
+	storeType := cfg.Memory.Type
+	if storeType == "" {
+		storeType = "memory"
+	}
+	storeFactoriesMu.RLock()
+	factory, exists := storeFactories[storeType]
+	storeFactoriesMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown memory type: %s", storeType)
+	}
+	return factory(cfg)
+}
+
+func newBaseFromConfig(cfg *config.Config) (VectorStore, error) {
+	return NewBaseStore(), nil
+}
+
+func newFileFromConfig(cfg *config.Config) (VectorStore, error) {
+	if cfg.Memory.Path == "" {
+		return nil, fmt.Errorf("memory path is required for file store")
+	}
+	return NewFileStore(cfg.Memory.Path)
+}
+
+func newQdrantFromConfig(cfg *config.Config) (VectorStore, error) {
+	return NewQdrantStore(QdrantConfig{
+		URL:        cfg.Memory.URL,
+		APIKey:     cfg.Memory.APIKey,
+		Collection: cfg.Memory.Collection,
+		Dimensions: cfg.Memory.Dimensions,
+	}), nil
+}
+
+func newPineconeFromConfig(cfg *config.Config) (VectorStore, error) {
+	return NewPineconeStore(PineconeConfig{
+		Host:      cfg.Memory.URL,
+		APIKey:    cfg.Memory.APIKey,
+		Namespace: cfg.Memory.Collection,
+	}), nil
+}
+
+func newPGVectorFromConfig(cfg *config.Config) (VectorStore, error) {
+	// Requires a Postgres driver registered as "postgres", e.g. github.com/lib/pq
+	return OpenPGVectorStore("postgres", cfg.Memory.URL, PGVectorConfig{
+		Table:      cfg.Memory.Collection,
+		Dimensions: cfg.Memory.Dimensions,
+	})
+}
@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/llm"
+)
+
+// conversationExt is the extension of FileConversations session files
+const conversationExt = ".jsonl"
+
+// FileConversations is a ConversationPersistence keeping each session's
+// history as JSON lines in a file of its own within a directory
+type FileConversations struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileConversations creates a file conversation persistence in dir,
+// creating the directory if needed
+func NewFileConversations(dir string) (*FileConversations, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create conversation directory: %w", err)
+	}
+	return &FileConversations{dir: dir}, nil
+}
+
+// path returns the file of a session; IDs are escaped so they cannot name
+// files outside the directory
+func (fc *FileConversations) path(sessionID string) string {
+	return filepath.Join(fc.dir, url.PathEscape(sessionID)+conversationExt)
+}
+
+// Load implements ConversationPersistence.Load. A final line left
+// incomplete by a crash mid-write is truncated, and other lines that cannot
+// be decoded are skipped, so messages appended after a crash are kept.
+func (fc *FileConversations) Load(ctx context.Context, sessionID string) ([]llm.Message, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	path := fc.path(sessionID)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open conversation: %w", err)
+	}
+	defer file.Close()
+
+	var history []llm.Message
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				fmt.Fprintf(os.Stderr, "Truncating incomplete conversation %s at offset %d\n", path, offset)
+				if err := os.Truncate(path, offset); err != nil {
+					return nil, fmt.Errorf("failed to truncate conversation: %w", err)
+				}
+			}
+			return history, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read conversation: %w", err)
+		}
+		offset += int64(len(data))
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var m llm.Message
+		if err := json.Unmarshal(data, &m); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping damaged message on line %d of %s: %v\n", line, path, err)
+			continue
+		}
+		history = append(history, m)
+	}
+}
+
+// Append implements ConversationPersistence.Append. Messages always start on
+// a new line, even if a crash left the previous one incomplete.
+func (fc *FileConversations) Append(ctx context.Context, sessionID string, messages ...llm.Message) error {
+	var b strings.Builder
+	for _, m := range messages {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	file, err := os.OpenFile(fc.path(sessionID), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open conversation: %w", err)
+	}
+	torn, err := endsMidLine(file)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to read conversation: %w", err)
+	}
+	data := b.String()
+	if torn {
+		data = "\n" + data
+	}
+	if _, err := file.WriteString(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	return file.Close()
+}
+
+// endsMidLine reports whether a non-empty file lacks a final newline
+func endsMidLine(file *os.File) (bool, error) {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// Delete implements ConversationPersistence.Delete
+func (fc *FileConversations) Delete(ctx context.Context, sessionID string) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if err := os.Remove(fc.path(sessionID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
+
+// LastActive implements ConversationActivity.LastActive, reporting the
+// modification time of the session's file
+func (fc *FileConversations) LastActive(ctx context.Context, sessionID string) (time.Time, error) {
+	info, err := os.Stat(fc.path(sessionID))
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to stat conversation: %w", err)
+	}
+	return info.ModTime(), nil
+}
+
+// Sessions implements ConversationActivity.Sessions, returning the IDs of
+// the stored sessions in order
+func (fc *FileConversations) Sessions() ([]string, error) {
+	entries, err := os.ReadDir(fc.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, conversationExt) {
+			continue
+		}
+		id, err := url.PathUnescape(strings.TrimSuffix(name, conversationExt))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
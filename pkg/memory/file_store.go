@@ -0,0 +1,339 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+// fileRecord is a single entry in the FileStore log
+type fileRecord struct {
+	Op     string       `json:"op"`
+	Vector types.Vector `json:"vector"`
+}
+
+const (
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+// FileStore is a VectorStore persisted to an append-only log file.
+// The log is replayed on open and compacted once it holds mostly stale records.
+type FileStore struct {
+	path    string
+	file    *os.File
+	writer  *bufio.Writer
+	vectors map[string]types.Vector
+	order   []string
+	records int
+	mu      sync.RWMutex
+}
+
+// NewFileStore opens or creates a file-backed store at path
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create memory directory: %w", err)
+	}
+
+	fs := &FileStore{
+		path:    path,
+		vectors: make(map[string]types.Vector),
+	}
+	if err := fs.load(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open memory file: %w", err)
+	}
+	fs.file = file
+	fs.writer = bufio.NewWriter(file)
+
+	return fs, nil
+}
+
+// load replays the log file into memory. A final record left incomplete by
+// a crash mid-write is truncated so later records append after the last
+// good one; damage elsewhere is an error.
+func (fs *FileStore) load() error {
+	file, err := os.Open(fs.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open memory file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				return fs.truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read memory file: %w", err)
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			var rec fileRecord
+			if err := json.Unmarshal(data, &rec); err != nil {
+				if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+					return fs.truncate(offset)
+				}
+				return fmt.Errorf("failed to decode vector on line %d: %w", line, err)
+			}
+			fs.apply(rec)
+			fs.records++
+		}
+		offset += int64(len(data))
+	}
+}
+
+// truncate cuts a torn tail off the log
+func (fs *FileStore) truncate(offset int64) error {
+	fmt.Fprintf(os.Stderr, "Truncating incomplete memory file %s at offset %d\n", fs.path, offset)
+	if err := os.Truncate(fs.path, offset); err != nil {
+		return fmt.Errorf("failed to truncate memory file: %w", err)
+	}
+	return nil
+}
+
+// apply updates the in-memory index with a log record
+func (fs *FileStore) apply(rec fileRecord) {
+	id := rec.Vector.ID
+	switch rec.Op {
+	case opPut:
+		if _, exists := fs.vectors[id]; !exists {
+			fs.order = append(fs.order, id)
+		}
+		fs.vectors[id] = rec.Vector
+	case opDelete:
+		if _, exists := fs.vectors[id]; !exists {
+			return
+		}
+		delete(fs.vectors, id)
+		for i, existing := range fs.order {
+			if existing == id {
+				fs.order = append(fs.order[:i], fs.order[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Store implements VectorStore.Store
+func (fs *FileStore) Store(ctx context.Context, vectors []types.Vector) error {
+	records := make([]fileRecord, 0, len(vectors))
+	for _, v := range vectors {
+		records = append(records, fileRecord{Op: opPut, Vector: v})
+	}
+	return fs.write(records)
+}
+
+// Query implements VectorStore.Query using cosine similarity
+func (fs *FileStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidK, k)
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	candidates := make([]types.Vector, 0, len(fs.order))
+	for _, id := range fs.order {
+		candidates = append(candidates, fs.vectors[id])
+	}
+	return topK(candidates, vector.Values, k), nil
+}
+
+// Delete implements VectorStore.Delete
+func (fs *FileStore) Delete(ctx context.Context, ids []string) error {
+	records := make([]fileRecord, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, fileRecord{Op: opDelete, Vector: types.Vector{ID: id}})
+	}
+	return fs.write(records)
+}
+
+// Update implements VectorStore.Update
+func (fs *FileStore) Update(ctx context.Context, vectors []types.Vector) error {
+	fs.mu.RLock()
+	for _, v := range vectors {
+		if _, exists := fs.vectors[v.ID]; !exists {
+			fs.mu.RUnlock()
+			return fmt.Errorf("%w: %s", ErrVectorNotFound, v.ID)
+		}
+	}
+	fs.mu.RUnlock()
+
+	return fs.Store(ctx, vectors)
+}
+
+// Count implements VectorStore.Count
+func (fs *FileStore) Count(ctx context.Context) (int, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return len(fs.vectors), nil
+}
+
+// write appends records to the log and applies them
+func (fs *FileStore) write(records []fileRecord) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("failed to encode vector: %w", err)
+		}
+		if _, err := fs.writer.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write vector: %w", err)
+		}
+	}
+	if err := fs.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush memory file: %w", err)
+	}
+	if err := fs.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync memory file: %w", err)
+	}
+
+	for _, rec := range records {
+		fs.apply(rec)
+		fs.records++
+	}
+
+	// Compact once stale records outnumber live vectors
+	if fs.records > 1000 && fs.records > 2*len(fs.vectors) {
+		return fs.compactLocked()
+	}
+	return nil
+}
+
+// Compact rewrites the log so it only contains live vectors
+func (fs *FileStore) Compact() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.compactLocked()
+}
+
+func (fs *FileStore) compactLocked() error {
+	tmpPath := fs.path + ".compact"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create compaction file: %w", err)
+	}
+	fail := func(format string, err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf(format, err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, id := range fs.order {
+		data, err := json.Marshal(fileRecord{Op: opPut, Vector: fs.vectors[id]})
+		if err != nil {
+			return fail("failed to encode vector: %w", err)
+		}
+		if _, err := writer.Write(append(data, '\n')); err != nil {
+			return fail("failed to write compaction file: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fail("failed to write compaction file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail("failed to sync compaction file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close compaction file: %w", err)
+	}
+
+	// The old log stays open until the new one replaces it, so a failure
+	// leaves the store writable
+	if err := os.Rename(tmpPath, fs.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace memory file: %w", err)
+	}
+	file, err := os.OpenFile(fs.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen memory file: %w", err)
+	}
+	old := fs.file
+	fs.file = file
+	fs.writer = bufio.NewWriter(file)
+	fs.records = len(fs.order)
+
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("failed to close old memory file: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the log file
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.writer.Flush(); err != nil {
+		return err
+	}
+	return fs.file.Close()
+}
+
+// topK returns the k vectors most similar to the query values
+func topK(candidates []types.Vector, query []float32, k int) []types.Vector {
+	type scored struct {
+		vector types.Vector
+		score  float64
+	}
+
+	results := make([]scored, 0, len(candidates))
+	for _, v := range candidates {
+		results = append(results, scored{vector: v, score: cosineSimilarity(query, v.Values)})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].score > results[j].score })
+
+	if k > len(results) {
+		k = len(results)
+	}
+	vectors := make([]types.Vector, 0, k)
+	for _, r := range results[:k] {
+		vectors = append(vectors, r.vector)
+	}
+	return vectors
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
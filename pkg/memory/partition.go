@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+type partitionKey struct{}
+
+// WithPartition returns a context whose memory operations are confined to
+// the named partition of a PartitionStore
+func WithPartition(ctx context.Context, partition string) context.Context {
+	return context.WithValue(ctx, partitionKey{}, partition)
+}
+
+// PartitionFromContext returns the memory partition bound to the context
+func PartitionFromContext(ctx context.Context) (string, bool) {
+	partition, ok := ctx.Value(partitionKey{}).(string)
+	return partition, ok && partition != ""
+}
+
+// PartitionStore routes each call to a dedicated VectorStore for the
+// partition attached to the context, such as an agent session. Calls
+// without a partition use the shared partition "".
+type PartitionStore struct {
+	factory func(partition string) VectorStore
+	stores  map[string]VectorStore
+	mu      sync.Mutex
+}
+
+// NewPartitionStore creates a new partitioned store. The factory is called
+// once per partition to create its backing store; nil uses BaseStore.
+func NewPartitionStore(factory func(partition string) VectorStore) *PartitionStore {
+	if factory == nil {
+		factory = func(string) VectorStore { return NewBaseStore() }
+	}
+	return &PartitionStore{
+		factory: factory,
+		stores:  make(map[string]VectorStore),
+	}
+}
+
+// Store implements VectorStore.Store
+func (ps *PartitionStore) Store(ctx context.Context, vectors []types.Vector) error {
+	return ps.storeFor(ctx).Store(ctx, vectors)
+}
+
+// Query implements VectorStore.Query
+func (ps *PartitionStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
+	return ps.storeFor(ctx).Query(ctx, vector, k)
+}
+
+// Delete implements VectorStore.Delete
+func (ps *PartitionStore) Delete(ctx context.Context, ids []string) error {
+	return ps.storeFor(ctx).Delete(ctx, ids)
+}
+
+// Update implements VectorStore.Update
+func (ps *PartitionStore) Update(ctx context.Context, vectors []types.Vector) error {
+	return ps.storeFor(ctx).Update(ctx, vectors)
+}
+
+// Count implements VectorStore.Count
+func (ps *PartitionStore) Count(ctx context.Context) (int, error) {
+	return ps.storeFor(ctx).Count(ctx)
+}
+
+// Drop discards a partition and its backing store
+func (ps *PartitionStore) Drop(partition string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	delete(ps.stores, partition)
+}
+
+// storeFor returns the backing store for the context's partition, creating
+// it on first use
+func (ps *PartitionStore) storeFor(ctx context.Context) VectorStore {
+	partition, _ := PartitionFromContext(ctx)
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	store, exists := ps.stores[partition]
+	if !exists {
+		store = ps.factory(partition)
+		ps.stores[partition] = store
+	}
+	return store
+}
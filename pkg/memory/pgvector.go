@@ -0,0 +1,275 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+// PGVectorConfig contains configuration for the Postgres/pgvector store
+type PGVectorConfig struct {
+	Table      string
+	Dimensions int
+
+	// Connection pool settings applied to the database handle
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// PGVectorStore implements VectorStore on Postgres using the pgvector extension
+type PGVectorStore struct {
+	db     *sql.DB
+	config PGVectorConfig
+}
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewPGVectorStore creates a store on an existing database handle
+func NewPGVectorStore(db *sql.DB, config PGVectorConfig) (*PGVectorStore, error) {
+	if config.Table == "" {
+		config.Table = "agent_memory"
+	}
+	if !tableNamePattern.MatchString(config.Table) {
+		return nil, fmt.Errorf("invalid table name: %s", config.Table)
+	}
+
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+
+	return &PGVectorStore{
+		db:     db,
+		config: config,
+	}, nil
+}
+
+// OpenPGVectorStore opens a database using a registered Postgres driver
+// (e.g. "postgres" or "pgx") and creates a store on it
+func OpenPGVectorStore(driverName, dsn string, config PGVectorConfig) (*PGVectorStore, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return NewPGVectorStore(db, config)
+}
+
+// Migrate creates the pgvector extension, the memory table, and its indexes
+func (ps *PGVectorStore) Migrate(ctx context.Context) error {
+	if ps.config.Dimensions <= 0 {
+		return fmt.Errorf("dimensions are required to migrate %s", ps.config.Table)
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			embedding vector(%d) NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`, ps.config.Table, ps.config.Dimensions),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_embedding_idx ON %s USING hnsw (embedding vector_cosine_ops)`,
+			ps.config.Table, ps.config.Table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_metadata_idx ON %s USING gin (metadata)`,
+			ps.config.Table, ps.config.Table),
+	}
+
+	for _, stmt := range statements {
+		if _, err := ps.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Store implements VectorStore.Store, upserting vectors by ID
+func (ps *PGVectorStore) Store(ctx context.Context, vectors []types.Vector) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO %s (id, embedding, metadata, updated_at)
+		VALUES ($1, $2::vector, $3::jsonb, now())
+		ON CONFLICT (id) DO UPDATE SET embedding = EXCLUDED.embedding, metadata = EXCLUDED.metadata, updated_at = now()`,
+		ps.config.Table))
+	if err != nil {
+		return fmt.Errorf("failed to prepare upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, v := range vectors {
+		metadata, err := json.Marshal(v.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata: %w", err)
+		}
+		if v.Metadata == nil {
+			metadata = []byte("{}")
+		}
+		if _, err := stmt.ExecContext(ctx, v.ID, formatPGVector(v.Values), string(metadata)); err != nil {
+			return fmt.Errorf("failed to upsert vector %s: %w", v.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Query implements VectorStore.Query. Metadata on the query vector is
+// applied as a JSONB containment filter.
+func (ps *PGVectorStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
+	return ps.QueryFilter(ctx, vector.Values, vector.Metadata, k)
+}
+
+// QueryFilter returns the k nearest vectors whose metadata contains the filter
+func (ps *PGVectorStore) QueryFilter(ctx context.Context, values []float32, filter map[string]interface{}, k int) ([]types.Vector, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidK, k)
+	}
+	if filter == nil {
+		filter = map[string]interface{}{}
+	}
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filter: %w", err)
+	}
+
+	rows, err := ps.db.QueryContext(ctx, fmt.Sprintf(`SELECT id, embedding::text, metadata::text FROM %s
+		WHERE metadata @> $2::jsonb
+		ORDER BY embedding <=> $1::vector
+		LIMIT $3`, ps.config.Table),
+		formatPGVector(values), string(filterJSON), k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vectors: %w", err)
+	}
+	defer rows.Close()
+
+	vectors := make([]types.Vector, 0, k)
+	for rows.Next() {
+		var id, embedding, metadata string
+		if err := rows.Scan(&id, &embedding, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan vector: %w", err)
+		}
+
+		v := types.Vector{ID: id}
+		if v.Values, err = parsePGVector(embedding); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(metadata), &v.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata: %w", err)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, rows.Err()
+}
+
+// Delete implements VectorStore.Delete
+func (ps *PGVectorStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id IN (%s)`, ps.config.Table, strings.Join(placeholders, ", "))
+	if _, err := ps.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	return nil
+}
+
+// Update implements VectorStore.Update. All vectors must already exist.
+func (ps *PGVectorStore) Update(ctx context.Context, vectors []types.Vector) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`UPDATE %s
+		SET embedding = $2::vector, metadata = $3::jsonb, updated_at = now()
+		WHERE id = $1`, ps.config.Table))
+	if err != nil {
+		return fmt.Errorf("failed to prepare update: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, v := range vectors {
+		metadata := []byte("{}")
+		if v.Metadata != nil {
+			if metadata, err = json.Marshal(v.Metadata); err != nil {
+				return fmt.Errorf("failed to encode metadata: %w", err)
+			}
+		}
+
+		result, err := stmt.ExecContext(ctx, v.ID, formatPGVector(v.Values), string(metadata))
+		if err != nil {
+			return fmt.Errorf("failed to update vector %s: %w", v.ID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("%w: %s", ErrVectorNotFound, v.ID)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Count implements VectorStore.Count
+func (ps *PGVectorStore) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := ps.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, ps.config.Table)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count vectors: %w", err)
+	}
+	return count, nil
+}
+
+// Close closes the underlying database handle
+func (ps *PGVectorStore) Close() error {
+	return ps.db.Close()
+}
+
+// formatPGVector renders values in pgvector's text format
+func formatPGVector(values []float32) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// parsePGVector parses pgvector's text format
+func parsePGVector(s string) ([]float32, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "["), "]")
+	if s == "" {
+		return []float32{}, nil
+	}
+
+	parts := strings.Split(s, ",")
+	values := make([]float32, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector value %q: %w", p, err)
+		}
+		values[i] = float32(f)
+	}
+	return values, nil
+}
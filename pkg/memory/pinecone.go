@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+// PineconeConfig contains configuration for the Pinecone vector store
+type PineconeConfig struct {
+	// Host is the index endpoint, e.g. https://my-index-abc123.svc.us-east1-gcp.pinecone.io
+	Host   string
+	APIKey string
+	// Namespace partitions vectors within the index; empty uses the default namespace
+	Namespace string
+	// BatchSize bounds the number of vectors per upsert request (default 100)
+	BatchSize int
+}
+
+// PineconeStore implements VectorStore against the Pinecone data plane API
+type PineconeStore struct {
+	config PineconeConfig
+	client *http.Client
+}
+
+// NewPineconeStore creates a new Pinecone-backed store
+func NewPineconeStore(config PineconeConfig) *PineconeStore {
+	config.Host = strings.TrimRight(config.Host, "/")
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	return &PineconeStore{
+		config: config,
+		client: &http.Client{},
+	}
+}
+
+// WithNamespace returns a store sharing the same index and client that
+// operates on a different namespace
+func (ps *PineconeStore) WithNamespace(namespace string) *PineconeStore {
+	config := ps.config
+	config.Namespace = namespace
+	return &PineconeStore{
+		config: config,
+		client: ps.client,
+	}
+}
+
+// Store implements VectorStore.Store, upserting vectors in batches
+func (ps *PineconeStore) Store(ctx context.Context, vectors []types.Vector) error {
+	for start := 0; start < len(vectors); start += ps.config.BatchSize {
+		end := start + ps.config.BatchSize
+		if end > len(vectors) {
+			end = len(vectors)
+		}
+
+		batch := make([]map[string]interface{}, 0, end-start)
+		for _, v := range vectors[start:end] {
+			item := map[string]interface{}{
+				"id":     v.ID,
+				"values": v.Values,
+			}
+			if len(v.Metadata) > 0 {
+				item["metadata"] = v.Metadata
+			}
+			batch = append(batch, item)
+		}
+
+		body := map[string]interface{}{
+			"vectors":   batch,
+			"namespace": ps.config.Namespace,
+		}
+		if err := ps.do(ctx, "/vectors/upsert", body, nil); err != nil {
+			return fmt.Errorf("failed to upsert vectors: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delete implements VectorStore.Delete
+func (ps *PineconeStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	body := map[string]interface{}{
+		"ids":       ids,
+		"namespace": ps.config.Namespace,
+	}
+	if err := ps.do(ctx, "/vectors/delete", body, nil); err != nil {
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	return nil
+}
+
+// Update implements VectorStore.Update. All vectors must already exist.
+func (ps *PineconeStore) Update(ctx context.Context, vectors []types.Vector) error {
+	query := url.Values{}
+	for _, v := range vectors {
+		query.Add("ids", v.ID)
+	}
+	query.Set("namespace", ps.config.Namespace)
+
+	var resp struct {
+		Vectors map[string]interface{} `json:"vectors"`
+	}
+	headers := map[string]string{"Api-Key": ps.config.APIKey}
+	if err := doJSON(ctx, ps.client, http.MethodGet, ps.config.Host+"/vectors/fetch?"+query.Encode(), headers, nil, &resp); err != nil {
+		return fmt.Errorf("failed to fetch vectors: %w", err)
+	}
+	for _, v := range vectors {
+		if _, exists := resp.Vectors[v.ID]; !exists {
+			return fmt.Errorf("%w: %s", ErrVectorNotFound, v.ID)
+		}
+	}
+
+	return ps.Store(ctx, vectors)
+}
+
+// Count implements VectorStore.Count, returning the size of the store's namespace
+func (ps *PineconeStore) Count(ctx context.Context) (int, error) {
+	var resp struct {
+		Namespaces map[string]struct {
+			VectorCount int `json:"vectorCount"`
+		} `json:"namespaces"`
+	}
+	if err := ps.do(ctx, "/describe_index_stats", map[string]interface{}{}, &resp); err != nil {
+		return 0, fmt.Errorf("failed to describe index: %w", err)
+	}
+	return resp.Namespaces[ps.config.Namespace].VectorCount, nil
+}
+
+// DeleteAll removes every vector in the store's namespace
+func (ps *PineconeStore) DeleteAll(ctx context.Context) error {
+	body := map[string]interface{}{
+		"deleteAll": true,
+		"namespace": ps.config.Namespace,
+	}
+	if err := ps.do(ctx, "/vectors/delete", body, nil); err != nil {
+		return fmt.Errorf("failed to delete namespace: %w", err)
+	}
+	return nil
+}
+
+// Query implements VectorStore.Query. Metadata on the query vector is
+// applied as equality filters on the stored metadata.
+func (ps *PineconeStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
+	filter := make(map[string]interface{}, len(vector.Metadata))
+	for key, value := range vector.Metadata {
+		filter[key] = map[string]interface{}{"$eq": value}
+	}
+	return ps.QueryFilter(ctx, vector.Values, filter, k)
+}
+
+// QueryFilter returns the k nearest vectors matching a Pinecone metadata
+// filter expression, e.g. {"genre": {"$in": ["news", "blog"]}}
+func (ps *PineconeStore) QueryFilter(ctx context.Context, values []float32, filter map[string]interface{}, k int) ([]types.Vector, error) {
+	body := map[string]interface{}{
+		"vector":          values,
+		"topK":            k,
+		"namespace":       ps.config.Namespace,
+		"includeValues":   true,
+		"includeMetadata": true,
+	}
+	if len(filter) > 0 {
+		body["filter"] = filter
+	}
+
+	var resp struct {
+		Matches []struct {
+			ID       string                 `json:"id"`
+			Score    float64                `json:"score"`
+			Values   []float32              `json:"values"`
+			Metadata map[string]interface{} `json:"metadata"`
+		} `json:"matches"`
+	}
+	if err := ps.do(ctx, "/query", body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to query vectors: %w", err)
+	}
+
+	vectors := make([]types.Vector, 0, len(resp.Matches))
+	for _, match := range resp.Matches {
+		vectors = append(vectors, types.Vector{
+			ID:       match.ID,
+			Values:   match.Values,
+			Metadata: match.Metadata,
+		})
+	}
+	return vectors, nil
+}
+
+func (ps *PineconeStore) do(ctx context.Context, path string, body, out interface{}) error {
+	headers := map[string]string{"Api-Key": ps.config.APIKey}
+	return doJSON(ctx, ps.client, http.MethodPost, ps.config.Host+path, headers, body, out)
+}
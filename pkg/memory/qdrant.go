@@ -0,0 +1,243 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+// QdrantConfig contains configuration for the Qdrant vector store
+type QdrantConfig struct {
+	URL        string
+	APIKey     string
+	Collection string
+	// Dimensions is the vector size used when creating the collection
+	Dimensions int
+	// Distance is the similarity metric: Cosine (default), Dot, or Euclid
+	Distance string
+	// BatchSize bounds the number of points per upsert request (default 100)
+	BatchSize int
+}
+
+// QdrantStore implements VectorStore against the Qdrant HTTP API
+type QdrantStore struct {
+	config QdrantConfig
+	client *http.Client
+}
+
+// qdrantIDKey is the payload key holding the original vector ID
+const qdrantIDKey = "_id"
+
+// NewQdrantStore creates a new Qdrant-backed store
+func NewQdrantStore(config QdrantConfig) *QdrantStore {
+	config.URL = strings.TrimRight(config.URL, "/")
+	if config.Distance == "" {
+		config.Distance = "Cosine"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	return &QdrantStore{
+		config: config,
+		client: &http.Client{},
+	}
+}
+
+// EnsureCollection creates the collection if it does not exist
+func (qs *QdrantStore) EnsureCollection(ctx context.Context) error {
+	err := qs.do(ctx, http.MethodGet, "/collections/"+qs.config.Collection, nil, nil)
+	if err == nil {
+		return nil
+	}
+
+	var httpErr *httpError
+	if !errors.As(err, &httpErr) || httpErr.Status != http.StatusNotFound {
+		return fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	if qs.config.Dimensions <= 0 {
+		return fmt.Errorf("dimensions are required to create collection %s", qs.config.Collection)
+	}
+
+	body := map[string]interface{}{
+		"vectors": map[string]interface{}{
+			"size":     qs.config.Dimensions,
+			"distance": qs.config.Distance,
+		},
+	}
+	if err := qs.do(ctx, http.MethodPut, "/collections/"+qs.config.Collection, body, nil); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	return nil
+}
+
+// DeleteCollection removes the collection and all its points
+func (qs *QdrantStore) DeleteCollection(ctx context.Context) error {
+	if err := qs.do(ctx, http.MethodDelete, "/collections/"+qs.config.Collection, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	return nil
+}
+
+// Store implements VectorStore.Store, upserting points in batches
+func (qs *QdrantStore) Store(ctx context.Context, vectors []types.Vector) error {
+	for start := 0; start < len(vectors); start += qs.config.BatchSize {
+		end := start + qs.config.BatchSize
+		if end > len(vectors) {
+			end = len(vectors)
+		}
+
+		points := make([]map[string]interface{}, 0, end-start)
+		for _, v := range vectors[start:end] {
+			payload := make(map[string]interface{}, len(v.Metadata)+1)
+			for k, val := range v.Metadata {
+				payload[k] = val
+			}
+			payload[qdrantIDKey] = v.ID
+
+			points = append(points, map[string]interface{}{
+				"id":      qdrantPointID(v.ID),
+				"vector":  v.Values,
+				"payload": payload,
+			})
+		}
+
+		path := fmt.Sprintf("/collections/%s/points?wait=true", qs.config.Collection)
+		if err := qs.do(ctx, http.MethodPut, path, map[string]interface{}{"points": points}, nil); err != nil {
+			return fmt.Errorf("failed to upsert points: %w", err)
+		}
+	}
+	return nil
+}
+
+// Query implements VectorStore.Query. Metadata on the query vector is
+// applied as exact-match filters on the stored payload.
+func (qs *QdrantStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
+	return qs.QueryFilter(ctx, vector.Values, vector.Metadata, k)
+}
+
+// QueryFilter returns the k nearest vectors whose metadata matches the filter
+func (qs *QdrantStore) QueryFilter(ctx context.Context, values []float32, filter map[string]interface{}, k int) ([]types.Vector, error) {
+	body := map[string]interface{}{
+		"vector":       values,
+		"limit":        k,
+		"with_payload": true,
+		"with_vector":  true,
+	}
+	if len(filter) > 0 {
+		must := make([]map[string]interface{}, 0, len(filter))
+		for key, value := range filter {
+			must = append(must, map[string]interface{}{
+				"key":   key,
+				"match": map[string]interface{}{"value": value},
+			})
+		}
+		body["filter"] = map[string]interface{}{"must": must}
+	}
+
+	var resp struct {
+		Result []struct {
+			Payload map[string]interface{} `json:"payload"`
+			Vector  []float32              `json:"vector"`
+			Score   float64                `json:"score"`
+		} `json:"result"`
+	}
+	path := fmt.Sprintf("/collections/%s/points/search", qs.config.Collection)
+	if err := qs.do(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to search points: %w", err)
+	}
+
+	vectors := make([]types.Vector, 0, len(resp.Result))
+	for _, point := range resp.Result {
+		id, _ := point.Payload[qdrantIDKey].(string)
+		delete(point.Payload, qdrantIDKey)
+		vectors = append(vectors, types.Vector{
+			ID:       id,
+			Values:   point.Vector,
+			Metadata: point.Payload,
+		})
+	}
+	return vectors, nil
+}
+
+// Delete implements VectorStore.Delete
+func (qs *QdrantStore) Delete(ctx context.Context, ids []string) error {
+	points := make([]string, len(ids))
+	for i, id := range ids {
+		points[i] = qdrantPointID(id)
+	}
+
+	path := fmt.Sprintf("/collections/%s/points/delete?wait=true", qs.config.Collection)
+	if err := qs.do(ctx, http.MethodPost, path, map[string]interface{}{"points": points}, nil); err != nil {
+		return fmt.Errorf("failed to delete points: %w", err)
+	}
+	return nil
+}
+
+// Update implements VectorStore.Update. All points must already exist.
+func (qs *QdrantStore) Update(ctx context.Context, vectors []types.Vector) error {
+	ids := make([]string, len(vectors))
+	for i, v := range vectors {
+		ids[i] = qdrantPointID(v.ID)
+	}
+
+	var resp struct {
+		Result []struct {
+			Payload map[string]interface{} `json:"payload"`
+		} `json:"result"`
+	}
+	body := map[string]interface{}{"ids": ids, "with_payload": []string{qdrantIDKey}}
+	path := fmt.Sprintf("/collections/%s/points", qs.config.Collection)
+	if err := qs.do(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return fmt.Errorf("failed to retrieve points: %w", err)
+	}
+
+	found := make(map[string]bool, len(resp.Result))
+	for _, point := range resp.Result {
+		if id, ok := point.Payload[qdrantIDKey].(string); ok {
+			found[id] = true
+		}
+	}
+	for _, v := range vectors {
+		if !found[v.ID] {
+			return fmt.Errorf("%w: %s", ErrVectorNotFound, v.ID)
+		}
+	}
+
+	return qs.Store(ctx, vectors)
+}
+
+// Count implements VectorStore.Count
+func (qs *QdrantStore) Count(ctx context.Context) (int, error) {
+	var resp struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	path := fmt.Sprintf("/collections/%s/points/count", qs.config.Collection)
+	if err := qs.do(ctx, http.MethodPost, path, map[string]interface{}{"exact": true}, &resp); err != nil {
+		return 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	return resp.Result.Count, nil
+}
+
+func (qs *QdrantStore) do(ctx context.Context, method, path string, body, out interface{}) error {
+	headers := map[string]string{}
+	if qs.config.APIKey != "" {
+		headers["api-key"] = qs.config.APIKey
+	}
+	return doJSON(ctx, qs.client, method, qs.config.URL+path, headers, body, out)
+}
+
+// qdrantPointID maps an arbitrary vector ID to the UUID form Qdrant requires
+func qdrantPointID(id string) string {
+	sum := sha1.Sum([]byte(id))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
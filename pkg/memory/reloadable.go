@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/user/modulox/pkg/types"
+)
+
+// ReloadableStore delegates to a store that can be replaced while the
+// agent runs, e.g. when config.Watcher reports changed memory settings.
+// Swap waits for calls in flight, so the old store can be closed once it
+// returns.
+type ReloadableStore struct {
+	inner VectorStore
+	mu    sync.RWMutex
+}
+
+// NewReloadableStore creates a store delegating to inner until the next
+// Swap
+func NewReloadableStore(inner VectorStore) *ReloadableStore {
+	return &ReloadableStore{inner: inner}
+}
+
+// Swap replaces the store later calls are made to and returns the old one
+func (rs *ReloadableStore) Swap(inner VectorStore) VectorStore {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	old := rs.inner
+	rs.inner = inner
+	return old
+}
+
+// Store implements VectorStore.Store
+func (rs *ReloadableStore) Store(ctx context.Context, vectors []types.Vector) error {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.inner.Store(ctx, vectors)
+}
+
+// Query implements VectorStore.Query
+func (rs *ReloadableStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.inner.Query(ctx, vector, k)
+}
+
+// Delete implements VectorStore.Delete
+func (rs *ReloadableStore) Delete(ctx context.Context, ids []string) error {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.inner.Delete(ctx, ids)
+}
+
+// Update implements VectorStore.Update
+func (rs *ReloadableStore) Update(ctx context.Context, vectors []types.Vector) error {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.inner.Update(ctx, vectors)
+}
+
+// Count implements VectorStore.Count
+func (rs *ReloadableStore) Count(ctx context.Context) (int, error) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.inner.Count(ctx)
+}
@@ -2,6 +2,8 @@ package memory
 
 import (
 	"context"
+	"fmt"
+	"sync"
 
 	"github.com/user/go-ai-framework/pkg/types"
 )
@@ -13,11 +15,21 @@ type VectorStore interface {
 	
 	// Query retrieves the k nearest vectors to the query vector
 	Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error)
+
+	// Delete removes the vectors with the given IDs; unknown IDs are ignored
+	Delete(ctx context.Context, ids []string) error
+
+	// Update replaces existing vectors by ID
+	Update(ctx context.Context, vectors []types.Vector) error
+
+	// Count returns the number of stored vectors
+	Count(ctx context.Context) (int, error)
 }
 
 // BaseStore provides a basic implementation of VectorStore
 type BaseStore struct {
 	vectors []types.Vector
+	mu      sync.RWMutex
 }
 
 // NewBaseStore creates a new instance of BaseStore
@@ -29,6 +41,9 @@ func NewBaseStore() *BaseStore {
 
 // Store implements VectorStore.Store
 func (b *BaseStore) Store(ctx context.Context, vectors []types.Vector) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	b.vectors = append(b.vectors, vectors...)
 	return nil
 }
@@ -37,8 +52,68 @@ func (b *BaseStore) Store(ctx context.Context, vectors []types.Vector) error {
 // Note: This is a naive implementation. Real implementations should use proper vector similarity search
 func (b *BaseStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
 	// In a real implementation, this would perform proper vector similarity search
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
 	if k > len(b.vectors) {
 		k = len(b.vectors)
 	}
 	return b.vectors[:k], nil
 }
+
+// Delete implements VectorStore.Delete
+func (b *BaseStore) Delete(ctx context.Context, ids []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	kept := make([]types.Vector, 0, len(b.vectors))
+	for _, v := range b.vectors {
+		if !remove[v.ID] {
+			kept = append(kept, v)
+		}
+	}
+	b.vectors = kept
+	return nil
+}
+
+// Update implements VectorStore.Update
+func (b *BaseStore) Update(ctx context.Context, vectors []types.Vector) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	index := make(map[string]int, len(b.vectors))
+	for i, v := range b.vectors {
+		index[v.ID] = i
+	}
+	for _, v := range vectors {
+		if _, exists := index[v.ID]; !exists {
+			return fmt.Errorf("%w: %s", ErrVectorNotFound, v.ID)
+		}
+	}
+	for _, v := range vectors {
+		b.vectors[index[v.ID]] = v
+	}
+	return nil
+}
+
+// Count implements VectorStore.Count
+func (b *BaseStore) Count(ctx context.Context) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.vectors), nil
+}
+
+// Error types
+type MemoryError string
+
+func (e MemoryError) Error() string { return string(e) }
+
+const (
+	ErrVectorNotFound = MemoryError("vector not found")
+	ErrInvalidK       = MemoryError("k must not be negative")
+)
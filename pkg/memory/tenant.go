@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/types"
+)
+
+// TenantStore isolates memory per tenant by routing each call to a
+// dedicated VectorStore for the tenant attached to the context
+type TenantStore struct {
+	factory func(tenantID string) VectorStore
+	stores  map[string]VectorStore
+	mu      sync.Mutex
+}
+
+// NewTenantStore creates a new tenant-aware store. The factory is called
+// once per tenant to create its backing store; nil uses BaseStore.
+func NewTenantStore(factory func(tenantID string) VectorStore) *TenantStore {
+	if factory == nil {
+		factory = func(string) VectorStore { return NewBaseStore() }
+	}
+	return &TenantStore{
+		factory: factory,
+		stores:  make(map[string]VectorStore),
+	}
+}
+
+// Store implements VectorStore.Store
+func (ts *TenantStore) Store(ctx context.Context, vectors []types.Vector) error {
+	id := tenant.IDFromContext(ctx)
+	return ts.storeFor(id).Store(ctx, stampTenant(id, vectors))
+}
+
+// Query implements VectorStore.Query
+func (ts *TenantStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
+	return ts.storeFor(tenant.IDFromContext(ctx)).Query(ctx, vector, k)
+}
+
+// Delete implements VectorStore.Delete
+func (ts *TenantStore) Delete(ctx context.Context, ids []string) error {
+	return ts.storeFor(tenant.IDFromContext(ctx)).Delete(ctx, ids)
+}
+
+// Update implements VectorStore.Update
+func (ts *TenantStore) Update(ctx context.Context, vectors []types.Vector) error {
+	id := tenant.IDFromContext(ctx)
+	return ts.storeFor(id).Update(ctx, stampTenant(id, vectors))
+}
+
+// Count implements VectorStore.Count
+func (ts *TenantStore) Count(ctx context.Context) (int, error) {
+	return ts.storeFor(tenant.IDFromContext(ctx)).Count(ctx)
+}
+
+// stampTenant returns copies of the vectors recording the owning tenant in
+// their metadata, leaving the caller's vectors untouched
+func stampTenant(tenantID string, vectors []types.Vector) []types.Vector {
+	stamped := make([]types.Vector, len(vectors))
+	for i, v := range vectors {
+		metadata := make(map[string]interface{}, len(v.Metadata)+1)
+		for k, val := range v.Metadata {
+			metadata[k] = val
+		}
+		metadata["tenant_id"] = tenantID
+		v.Metadata = metadata
+		stamped[i] = v
+	}
+	return stamped
+}
+
+// storeFor returns the backing store for a tenant, creating it on first use
+func (ts *TenantStore) storeFor(tenantID string) VectorStore {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	store, exists := ts.stores[tenantID]
+	if !exists {
+		store = ts.factory(tenantID)
+		ts.stores[tenantID] = store
+	}
+	return store
+}
@@ -0,0 +1,548 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/observability"
+	pb "github.com/user/modulox/pkg/pb"
+	"github.com/user/modulox/pkg/types"
+)
+
+// Event kinds streamed to dashboard clients
+const (
+	KindMessage  = "message"
+	KindWorkflow = "workflow"
+	KindEvent    = "event"
+	KindHealth   = "health"
+	KindNodes    = "nodes"
+	KindMetrics  = "metrics"
+)
+
+// TenantKey is the metadata key of the tenant a bus message or agent event
+// belongs to
+const TenantKey = "tenant_id"
+
+// Event is an update streamed to dashboard clients. Events of a tenant are
+// streamed only to admins and that tenant's principals.
+type Event struct {
+	Kind      string      `json:"kind"`
+	Type      string      `json:"type,omitempty"`
+	Source    string      `json:"source,omitempty"`
+	Tenant    string      `json:"tenant,omitempty"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// metricsData is the data of a metrics event
+type metricsData struct {
+	Metrics    []observability.Metric  `json:"metrics"`
+	Histograms []observability.Summary `json:"histograms"`
+}
+
+// Config contains configuration for the dashboard server
+type Config struct {
+	// Bus and Topics select the MessageBus topics streamed to clients
+	Bus    communication.MessageBus
+	Topics []string
+
+	// Health, Nodes, and Metrics are polled every PollInterval (default 5s)
+	Health       *observability.HealthChecker
+	Nodes        func() []types.NodeStatus
+	Metrics      *observability.MetricsCollector
+	PollInterval time.Duration
+
+	// History is the number of recent events replayed to new clients
+	// (default 100)
+	History int
+
+	// Identity and Authorizer enable authentication; callers need read
+	// access to the cluster. Browsers, which cannot set headers on event
+	// streams, may pass the token in the access_token query parameter.
+	Identity   auth.IdentitySource
+	Authorizer *auth.Authorizer
+
+	// AllowedOrigins lists the origins, e.g. "https://ops.example.com",
+	// whose pages may open WebSocket connections in addition to the
+	// dashboard's own
+	AllowedOrigins []string
+}
+
+// Snapshot is the current state of the cluster
+type Snapshot struct {
+	Health     map[string]observability.HealthStatus `json:"health,omitempty"`
+	Nodes      []types.NodeStatus                    `json:"nodes,omitempty"`
+	Metrics    []observability.Metric                `json:"metrics,omitempty"`
+	Histograms []observability.Summary               `json:"histograms,omitempty"`
+	Timestamp  time.Time                             `json:"timestamp"`
+}
+
+// Server streams MessageBus events, workflow progress, node health, and
+// metrics to a bundled web UI over Server-Sent Events and WebSocket
+type Server struct {
+	config  Config
+	clients map[chan Event]struct{}
+	recent  []Event
+	mux     *http.ServeMux
+	server  *http.Server
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	mu      sync.RWMutex
+}
+
+// NewServer creates a dashboard server and starts watching its sources
+func NewServer(config Config) *Server {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.History <= 0 {
+		config.History = 100
+	}
+
+	s := &Server{
+		config:  config,
+		clients: make(map[chan Event]struct{}),
+		mux:     http.NewServeMux(),
+		stop:    make(chan struct{}),
+	}
+	s.mux.HandleFunc("/", s.handleIndex)
+	s.mux.HandleFunc("/api/snapshot", s.handleSnapshot)
+	s.mux.HandleFunc("/events", s.handleSSE)
+	s.mux.Handle("/ws", websocket.Server{Handler: s.handleWebSocket, Handshake: s.checkOrigin})
+
+	if config.Bus != nil {
+		for _, topic := range config.Topics {
+			s.watchTopic(topic)
+		}
+	}
+	s.wg.Add(1)
+	go s.poll()
+	return s
+}
+
+// Publish streams an event to every connected client
+func (s *Server) Publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recent = append(s.recent, event)
+	if len(s.recent) > s.config.History {
+		s.recent = s.recent[len(s.recent)-s.config.History:]
+	}
+	for ch := range s.clients {
+		select {
+		case ch <- event:
+		default:
+			// Drop events for slow clients rather than blocking publishers
+		}
+	}
+}
+
+// WatchEvents streams agent events, such as those returned by
+// AgentClient.StreamEvents, until the channel closes. Workflow events
+// ("workflow_*") are reported as workflow progress.
+func (s *Server) WatchEvents(events <-chan *pb.Event) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				kind := KindEvent
+				if strings.HasPrefix(event.Type, "workflow_") {
+					kind = KindWorkflow
+				}
+				s.Publish(Event{
+					Kind:   kind,
+					Type:   event.Type,
+					Source: event.SourceAgent,
+					Tenant: event.Metadata[TenantKey],
+					Data: map[string]interface{}{
+						"payload":  event.Payload,
+						"metadata": event.Metadata,
+					},
+					Timestamp: time.Unix(event.Timestamp, 0),
+				})
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// watchTopic streams the messages published to a bus topic
+func (s *Server) watchTopic(topic string) {
+	ch := s.config.Bus.Subscribe(topic)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.config.Bus.Unsubscribe(topic, ch)
+		for {
+			select {
+			case msg := <-ch:
+				tenantID, _ := msg.Metadata[TenantKey].(string)
+				s.Publish(Event{
+					Kind:   KindMessage,
+					Type:   topic,
+					Source: msg.From,
+					Tenant: tenantID,
+					Data: map[string]interface{}{
+						"id":       msg.ID,
+						"to":       msg.To,
+						"type":     msg.Type,
+						"content":  msg.Content,
+						"metadata": msg.Metadata,
+					},
+					Timestamp: msg.Timestamp,
+				})
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// poll publishes health, node, and metric updates periodically
+func (s *Server) poll() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			snapshot := s.Snapshot(context.Background())
+			if snapshot.Health != nil {
+				s.Publish(Event{Kind: KindHealth, Data: snapshot.Health, Timestamp: snapshot.Timestamp})
+			}
+			if s.config.Nodes != nil {
+				s.Publish(Event{Kind: KindNodes, Data: snapshot.Nodes, Timestamp: snapshot.Timestamp})
+			}
+			if s.config.Metrics != nil {
+				s.Publish(Event{
+					Kind:      KindMetrics,
+					Data:      metricsData{Metrics: snapshot.Metrics, Histograms: snapshot.Histograms},
+					Timestamp: snapshot.Timestamp,
+				})
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Snapshot returns the current health, nodes, and metrics
+func (s *Server) Snapshot(ctx context.Context) Snapshot {
+	snapshot := Snapshot{Timestamp: time.Now()}
+	if s.config.Health != nil {
+		snapshot.Health = make(map[string]observability.HealthStatus)
+		for name, status := range s.config.Health.RunChecks(ctx) {
+			snapshot.Health[name] = status
+		}
+	}
+	if s.config.Nodes != nil {
+		snapshot.Nodes = s.config.Nodes()
+	}
+	if s.config.Metrics != nil {
+		snapshot.Metrics = s.config.Metrics.Latest()
+		snapshot.Histograms = s.config.Metrics.AllSummaries()
+	}
+	return snapshot
+}
+
+// subscribe registers a client, returning the recent events to replay
+func (s *Server) subscribe() (chan Event, []Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan Event, 100)
+	s.clients[ch] = struct{}{}
+	return ch, append([]Event(nil), s.recent...)
+}
+
+func (s *Server) unsubscribe(ch chan Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.clients, ch)
+}
+
+// ServeHTTP implements http.Handler. The UI page itself is public; the
+// data it loads requires authentication.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		v, err := s.authenticate(r)
+		if err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, auth.ErrUnauthenticated) || errors.Is(err, auth.ErrInvalidToken) {
+				status = http.StatusUnauthorized
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), viewerKey{}, v))
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// viewer is the caller of a request, which sees every tenant's events or
+// only those of its own
+type viewer struct {
+	all    bool
+	tenant string
+}
+
+type viewerKey struct{}
+
+// viewerFrom returns the caller attached by ServeHTTP
+func viewerFrom(ctx context.Context) viewer {
+	v, _ := ctx.Value(viewerKey{}).(viewer)
+	return v
+}
+
+// authenticate identifies the caller and checks they may read the cluster.
+// Admins, and every caller when no Authorizer is set, see all tenants.
+func (s *Server) authenticate(r *http.Request) (viewer, error) {
+	if s.config.Authorizer == nil {
+		return viewer{all: true}, nil
+	}
+	token := auth.BearerToken(r.Header.Get("Authorization"))
+	if token == "" {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" || s.config.Identity == nil {
+		return viewer{}, auth.ErrUnauthenticated
+	}
+	p, err := s.config.Identity.Identify(r.Context(), token)
+	if err != nil {
+		return viewer{}, err
+	}
+	ctx := auth.WithPrincipal(r.Context(), p)
+	if err := s.config.Authorizer.Authorize(ctx, auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceCluster, Name: auth.Wildcard}); err != nil {
+		return viewer{}, err
+	}
+	return viewer{all: s.config.Authorizer.IsAdmin(ctx), tenant: p.TenantID}, nil
+}
+
+// visible returns the part of an event the viewer may see. Cluster-wide
+// health and nodes are visible to all; other events, and metrics, only to
+// their tenant.
+func (v viewer) visible(event Event) (Event, bool) {
+	if v.all {
+		return event, true
+	}
+	switch event.Kind {
+	case KindHealth, KindNodes:
+		return event, true
+	case KindMetrics:
+		data, ok := event.Data.(metricsData)
+		if !ok {
+			return event, false
+		}
+		event.Data = v.metrics(data)
+		return event, true
+	}
+	return event, event.Tenant == v.tenant
+}
+
+// metrics filters metrics to those labelled with the viewer's tenant
+func (v viewer) metrics(data metricsData) metricsData {
+	if v.all {
+		return data
+	}
+	var visible metricsData
+	for _, m := range data.Metrics {
+		if m.Labels["tenant"] == v.tenant {
+			visible.Metrics = append(visible.Metrics, m)
+		}
+	}
+	for _, h := range data.Histograms {
+		if h.Labels["tenant"] == v.tenant {
+			visible.Histograms = append(visible.Histograms, h)
+		}
+	}
+	return visible
+}
+
+// checkOrigin accepts WebSocket handshakes from the dashboard's own origin
+// and AllowedOrigins, so other sites' pages cannot read the stream with a
+// visitor's credentials
+func (s *Server) checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := url.Parse(r.Header.Get("Origin"))
+	if err != nil || origin.Host == "" {
+		return fmt.Errorf("missing or invalid origin")
+	}
+	if origin.Host == r.Host {
+		return nil
+	}
+	for _, allowed := range s.config.AllowedOrigins {
+		if strings.EqualFold(strings.TrimRight(allowed, "/"), origin.Scheme+"://"+origin.Host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin not allowed: %s", origin.Host)
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(indexHTML)
+}
+
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.Snapshot(r.Context())
+	visible := viewerFrom(r.Context()).metrics(metricsData{Metrics: snapshot.Metrics, Histograms: snapshot.Histograms})
+	snapshot.Metrics, snapshot.Histograms = visible.Metrics, visible.Histograms
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// handleSSE streams events as Server-Sent Events named by kind
+func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	ch, recent := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	v := viewerFrom(r.Context())
+	send := func(event Event) error {
+		event, ok := v.visible(event)
+		if !ok {
+			return nil
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Kind, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	for _, event := range recent {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+	for {
+		select {
+		case event := <-ch:
+			if err := send(event); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// handleWebSocket streams events as JSON messages
+func (s *Server) handleWebSocket(conn *websocket.Conn) {
+	defer conn.Close()
+
+	ch, recent := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	v := viewerFrom(conn.Request().Context())
+	send := func(event Event) error {
+		event, ok := v.visible(event)
+		if !ok {
+			return nil
+		}
+		return websocket.JSON.Send(conn, event)
+	}
+
+	for _, event := range recent {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	// Detect closed connections by reading until an error
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case event := <-ch:
+			if err := send(event); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Start starts the HTTP server and blocks until it stops
+func (s *Server) Start(address string) error {
+	s.mu.Lock()
+	s.server = &http.Server{
+		Addr:    address,
+		Handler: s,
+	}
+	server := s.server
+	s.mu.Unlock()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
+
+// Shutdown disconnects clients, stops watching sources, and stops the
+// HTTP server
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+	s.wg.Wait()
+
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
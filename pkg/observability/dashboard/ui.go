@@ -0,0 +1,8 @@
+package dashboard
+
+import _ "embed"
+
+// indexHTML is the bundled dashboard UI
+//
+//go:embed static/index.html
+var indexHTML []byte
@@ -0,0 +1,118 @@
+package observability
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Handler serves the latest metrics and histograms in the Prometheus text
+// exposition format
+func (mc *MetricsCollector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		typed := make(map[string]bool)
+		for _, m := range mc.Latest() {
+			if m.Type == HistogramMetric {
+				continue
+			}
+			name := promName(m.Name)
+			if !typed[name] {
+				typed[name] = true
+				kind := "gauge"
+				if m.Type == CounterMetric {
+					kind = "counter"
+				}
+				fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+			}
+			fmt.Fprintf(w, "%s%s %s\n", name, promLabels(m.Labels, "", ""), promValue(m.Value))
+		}
+
+		for _, s := range mc.AllSummaries() {
+			name := promName(s.Name)
+			if !typed[name] {
+				typed[name] = true
+				fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+			}
+			for _, b := range s.Buckets {
+				fmt.Fprintf(w, "%s_bucket%s %d\n", name, promLabels(s.Labels, "le", promValue(b.UpperBound)), b.Count)
+			}
+			fmt.Fprintf(w, "%s_sum%s %s\n", name, promLabels(s.Labels, "", ""), promValue(s.Sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", name, promLabels(s.Labels, "", ""), s.Count)
+		}
+	})
+}
+
+// promName replaces characters Prometheus does not allow in metric names
+func promName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// promLabels renders labels in order, adding the extra label when named
+func promLabels(labels map[string]string, extraName, extraValue string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", promName(k), strconv.Quote(labels[k])))
+	}
+	if extraName != "" {
+		parts = append(parts, fmt.Sprintf("%s=%q", extraName, extraValue))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func promValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler runs the health checks and serves their statuses as JSON, with
+// status 503 when any check is not healthy
+func (hc *HealthChecker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statuses := hc.RunChecks(r.Context())
+
+		healthy := true
+		for _, status := range statuses {
+			if status.Status != "healthy" {
+				healthy = false
+			}
+		}
+
+		response := struct {
+			Status    string                  `json:"status"`
+			Checks    map[string]HealthStatus `json:"checks"`
+			Timestamp time.Time               `json:"timestamp"`
+		}{Status: "healthy", Checks: statuses, Timestamp: time.Now()}
+
+		code := http.StatusOK
+		if !healthy {
+			response.Status = "unhealthy"
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(response)
+	})
+}
@@ -44,11 +44,13 @@ func (hc *HealthChecker) RunChecks(ctx context.Context) map[string]HealthStatus
 	hc.mu.Lock()
 	defer hc.mu.Unlock()
 
+	statuses := make(map[string]HealthStatus, len(hc.checks))
 	for name, check := range hc.checks {
 		hc.statuses[name] = check(ctx)
+		statuses[name] = hc.statuses[name]
 	}
 
-	return hc.statuses
+	return statuses
 }
 
 // GetStatus returns the current health status
@@ -0,0 +1,336 @@
+package observability
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gauge represents a value that can go up and down
+type Gauge struct {
+	name   string
+	value  float64
+	labels map[string]string
+	mc     *MetricsCollector
+	mu     sync.Mutex
+}
+
+// NewGauge creates a new gauge metric
+func (mc *MetricsCollector) NewGauge(name string, labels map[string]string) *Gauge {
+	return &Gauge{
+		name:   name,
+		labels: labels,
+		mc:     mc,
+	}
+}
+
+// Set sets the gauge to the given value
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value = value
+	g.record()
+}
+
+// Add adds the given value, which may be negative, to the gauge
+func (g *Gauge) Add(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value += value
+	g.record()
+}
+
+// Inc increments the gauge by 1
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec decrements the gauge by 1
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value returns the current value of the gauge
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+func (g *Gauge) record() {
+	g.mc.RecordMetric(context.Background(), Metric{
+		Name:   g.name,
+		Type:   GaugeMetric,
+		Value:  g.value,
+		Labels: g.labels,
+	})
+}
+
+// DefaultBuckets are latency buckets in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramConfig contains configuration for a histogram
+type HistogramConfig struct {
+	// Buckets are the upper bounds of the buckets (default DefaultBuckets)
+	Buckets []float64
+	// MaxSamples bounds the recent observations kept for percentiles
+	// (default 1024)
+	MaxSamples int
+}
+
+// Histogram counts observations in buckets and keeps a window of recent
+// samples for percentiles. Observations are aggregated in the histogram
+// rather than recorded as individual metrics.
+type Histogram struct {
+	name    string
+	labels  map[string]string
+	bounds  []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+	min     float64
+	max     float64
+	samples []float64
+	next    int
+	mu      sync.Mutex
+}
+
+// NewHistogram creates a histogram, or returns the existing one registered
+// with the same name and labels
+func (mc *MetricsCollector) NewHistogram(name string, labels map[string]string, config HistogramConfig) *Histogram {
+	key := seriesKey(name, labels)
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if h, exists := mc.histograms[key]; exists {
+		return h
+	}
+
+	bounds := config.Buckets
+	if len(bounds) == 0 {
+		bounds = DefaultBuckets
+	}
+	bounds = append([]float64(nil), bounds...)
+	sort.Float64s(bounds)
+	if config.MaxSamples <= 0 {
+		config.MaxSamples = 1024
+	}
+
+	h := &Histogram{
+		name:    name,
+		labels:  labels,
+		bounds:  bounds,
+		counts:  make([]uint64, len(bounds)),
+		samples: make([]float64, 0, config.MaxSamples),
+	}
+	mc.histograms[key] = h
+	return h
+}
+
+// Observe records a value
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	if h.count == 0 || value < h.min {
+		h.min = value
+	}
+	if h.count == 0 || value > h.max {
+		h.max = value
+	}
+	h.count++
+	h.sum += value
+
+	if len(h.samples) < cap(h.samples) {
+		h.samples = append(h.samples, value)
+	} else {
+		h.samples[h.next] = value
+		h.next = (h.next + 1) % len(h.samples)
+	}
+}
+
+// ObserveDuration records a duration in seconds
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+// Timer measures the duration of an operation into a histogram
+type Timer struct {
+	histogram *Histogram
+	start     time.Time
+}
+
+// NewTimer starts a timer for the histogram
+func NewTimer(h *Histogram) *Timer {
+	return &Timer{histogram: h, start: time.Now()}
+}
+
+// ObserveDuration records the time since the timer started, e.g.
+// defer NewTimer(h).ObserveDuration()
+func (t *Timer) ObserveDuration() time.Duration {
+	d := time.Since(t.start)
+	t.histogram.ObserveDuration(d)
+	return d
+}
+
+// Bucket is the cumulative count of observations up to an upper bound
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// Summary describes the distribution of a histogram. Percentiles are
+// computed over the most recent samples.
+type Summary struct {
+	Name    string
+	Labels  map[string]string
+	Count   uint64
+	Sum     float64
+	Min     float64
+	Max     float64
+	Mean    float64
+	P50     float64
+	P90     float64
+	P95     float64
+	P99     float64
+	Buckets []Bucket
+}
+
+// Summary returns the current distribution of the histogram
+func (h *Histogram) Summary() Summary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := Summary{
+		Name:   h.name,
+		Labels: h.labels,
+		Count:  h.count,
+		Sum:    h.sum,
+		Min:    h.min,
+		Max:    h.max,
+	}
+	if h.count > 0 {
+		s.Mean = h.sum / float64(h.count)
+	}
+
+	var cumulative uint64
+	s.Buckets = make([]Bucket, 0, len(h.bounds)+1)
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		s.Buckets = append(s.Buckets, Bucket{UpperBound: bound, Count: cumulative})
+	}
+	s.Buckets = append(s.Buckets, Bucket{UpperBound: math.Inf(1), Count: h.count})
+
+	sorted := append([]float64(nil), h.samples...)
+	sort.Float64s(sorted)
+	s.P50 = percentile(sorted, 0.50)
+	s.P90 = percentile(sorted, 0.90)
+	s.P95 = percentile(sorted, 0.95)
+	s.P99 = percentile(sorted, 0.99)
+	return s
+}
+
+// Percentile returns the p-th quantile (0-1) of the recent samples
+func (h *Histogram) Percentile(p float64) float64 {
+	h.mu.Lock()
+	sorted := append([]float64(nil), h.samples...)
+	h.mu.Unlock()
+
+	sort.Float64s(sorted)
+	return percentile(sorted, p)
+}
+
+// percentile returns the nearest-rank quantile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// GetSummary returns the summary of the histogram with the given name and labels
+func (mc *MetricsCollector) GetSummary(name string, labels map[string]string) (Summary, bool) {
+	mc.mu.RLock()
+	h, exists := mc.histograms[seriesKey(name, labels)]
+	mc.mu.RUnlock()
+
+	if !exists {
+		return Summary{}, false
+	}
+	return h.Summary(), true
+}
+
+// GetSummaries returns the summaries of every histogram with the given name
+func (mc *MetricsCollector) GetSummaries(name string) []Summary {
+	mc.mu.RLock()
+	var histograms []*Histogram
+	for _, h := range mc.histograms {
+		if h.name == name {
+			histograms = append(histograms, h)
+		}
+	}
+	mc.mu.RUnlock()
+
+	summaries := make([]Summary, 0, len(histograms))
+	for _, h := range histograms {
+		summaries = append(summaries, h.Summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return seriesKey(name, summaries[i].Labels) < seriesKey(name, summaries[j].Labels)
+	})
+	return summaries
+}
+
+// AllSummaries returns the summaries of every histogram, ordered by series
+func (mc *MetricsCollector) AllSummaries() []Summary {
+	mc.mu.RLock()
+	keys := make([]string, 0, len(mc.histograms))
+	for key := range mc.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	histograms := make([]*Histogram, 0, len(keys))
+	for _, key := range keys {
+		histograms = append(histograms, mc.histograms[key])
+	}
+	mc.mu.RUnlock()
+
+	summaries := make([]Summary, 0, len(histograms))
+	for _, h := range histograms {
+		summaries = append(summaries, h.Summary())
+	}
+	return summaries
+}
+
+// seriesKey identifies a metric series by name and sorted labels
+func seriesKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("," + k + "=" + labels[k])
+	}
+	return b.String()
+}
@@ -2,10 +2,10 @@ package observability
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,28 +20,158 @@ const (
 	ERROR
 )
 
+// String returns the lowercase name of the level
+func (l LogLevel) String() string {
+	switch l {
+	case DEBUG:
+		return "debug"
+	case INFO:
+		return "info"
+	case WARN:
+		return "warn"
+	case ERROR:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// ParseLevel parses a level name such as "info" or "WARN"
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return DEBUG, nil
+	case "", "info":
+		return INFO, nil
+	case "warn", "warning":
+		return WARN, nil
+	case "error":
+		return ERROR, nil
+	default:
+		return INFO, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
 // LogEntry represents a single log entry
 type LogEntry struct {
-	Timestamp time.Time         `json:"timestamp"`
-	Level     LogLevel         `json:"level"`
-	Message   string          `json:"message"`
+	Timestamp time.Time              `json:"timestamp"`
+	Level     LogLevel               `json:"level"`
+	Module    string                 `json:"module,omitempty"`
+	Message   string                 `json:"message"`
 	Fields    map[string]interface{} `json:"fields,omitempty"`
-	TraceID   string          `json:"trace_id,omitempty"`
-	SpanID    string          `json:"span_id,omitempty"`
+	TraceID   string                 `json:"trace_id,omitempty"`
+	SpanID    string                 `json:"span_id,omitempty"`
 }
 
-// Logger manages structured logging
+// LogFilter may rewrite a log entry before it is written, e.g. to redact it
+type LogFilter func(ctx context.Context, entry *LogEntry)
+
+// Logger manages structured logging. Loggers returned by Named share the
+// sinks, filters, and levels of the logger they were derived from.
 type Logger struct {
-	output io.Writer
-	mu     sync.Mutex
+	core   *logCore
+	module string
 }
 
-// NewLogger creates a new logger
+// logCore is the state shared by a logger and its named loggers
+type logCore struct {
+	sinks   []LogSink
+	filters []LogFilter
+	level   LogLevel
+	modules map[string]LogLevel
+	sampler *logSampler
+	mu      sync.Mutex
+}
+
+// NewLogger creates a new logger writing JSON lines to output
 func NewLogger(output io.Writer) *Logger {
 	if output == nil {
 		output = os.Stdout
 	}
-	return &Logger{output: output}
+	return NewLoggerWithSinks(NewWriterSink(output))
+}
+
+// NewLoggerWithSinks creates a new logger writing to every sink
+func NewLoggerWithSinks(sinks ...LogSink) *Logger {
+	return &Logger{
+		core: &logCore{
+			sinks:   sinks,
+			level:   DEBUG,
+			modules: make(map[string]LogLevel),
+		},
+	}
+}
+
+// Named returns a logger for a module, e.g. logger.Named("workflow").
+// Names of nested modules are joined with dots.
+func (l *Logger) Named(module string) *Logger {
+	if l.module != "" {
+		module = l.module + "." + module
+	}
+	return &Logger{core: l.core, module: module}
+}
+
+// SetLevel sets the minimum level written by loggers without a module level
+func (l *Logger) SetLevel(level LogLevel) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.level = level
+}
+
+// SetModuleLevel sets the minimum level of a module and its submodules
+func (l *Logger) SetModuleLevel(module string, level LogLevel) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.modules[module] = level
+}
+
+// SetSampling limits repeated DEBUG and INFO messages: within each tick,
+// the first initial occurrences of a message are written and then every
+// thereafter-th. WARN and ERROR entries are never sampled.
+func (l *Logger) SetSampling(initial, thereafter int, tick time.Duration) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	if initial <= 0 {
+		l.core.sampler = nil
+		return
+	}
+	l.core.sampler = newLogSampler(initial, thereafter, tick)
+}
+
+// AddSink registers an additional sink
+func (l *Logger) AddSink(sink LogSink) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.sinks = append(l.core.sinks, sink)
+}
+
+// AddFilter registers a filter applied to every entry before it is written
+func (l *Logger) AddFilter(filter LogFilter) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	l.core.filters = append(l.core.filters, filter)
+}
+
+// Enabled reports whether entries of the level are written for the logger's module
+func (l *Logger) Enabled(level LogLevel) bool {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	return level >= l.core.levelFor(l.module)
+}
+
+// levelFor returns the level of the most specific configured module
+func (c *logCore) levelFor(module string) LogLevel {
+	for module != "" {
+		if level, ok := c.modules[module]; ok {
+			return level
+		}
+		i := strings.LastIndex(module, ".")
+		if i < 0 {
+			break
+		}
+		module = module[:i]
+	}
+	return c.level
 }
 
 // Log writes a log entry
@@ -49,6 +179,7 @@ func (l *Logger) Log(ctx context.Context, level LogLevel, msg string, fields map
 	entry := LogEntry{
 		Timestamp: time.Now(),
 		Level:     level,
+		Module:    l.module,
 		Message:   msg,
 		Fields:    fields,
 	}
@@ -59,18 +190,46 @@ func (l *Logger) Log(ctx context.Context, level LogLevel, msg string, fields map
 		entry.SpanID = span.SpanID
 	}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	// Marshal to JSON
-	data, err := json.Marshal(entry)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling log entry: %v\n", err)
+	l.core.mu.Lock()
+	if level < l.core.levelFor(l.module) {
+		l.core.mu.Unlock()
 		return
 	}
+	if level < WARN && l.core.sampler != nil && !l.core.sampler.allow(l.module, msg, entry.Timestamp) {
+		l.core.mu.Unlock()
+		return
+	}
+	// Filters and sinks run outside the lock, so a slow sink does not
+	// hold up loggers writing elsewhere; sinks serialize their own writes
+	filters, sinks := l.core.filters, l.core.sinks
+	l.core.mu.Unlock()
+
+	for _, filter := range filters {
+		filter(ctx, &entry)
+	}
+
+	for _, sink := range sinks {
+		if err := sink.Write(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing log entry: %v\n", err)
+		}
+	}
+}
 
-	// Write to output
-	l.output.Write(append(data, '\n'))
+// Close closes every sink. Entries logged afterwards are dropped, and
+// closing again does nothing.
+func (l *Logger) Close() error {
+	l.core.mu.Lock()
+	sinks := l.core.sinks
+	l.core.sinks = nil
+	l.core.mu.Unlock()
+
+	var firstErr error
+	for _, sink := range sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
 // Helper methods for different log levels
@@ -89,3 +248,40 @@ func (l *Logger) Warn(ctx context.Context, msg string, fields map[string]interfa
 func (l *Logger) Error(ctx context.Context, msg string, fields map[string]interface{}) {
 	l.Log(ctx, ERROR, msg, fields)
 }
+
+// logSampler counts messages per tick
+type logSampler struct {
+	initial    int
+	thereafter int
+	tick       time.Duration
+	counts     map[string]int
+	resetAt    time.Time
+}
+
+func newLogSampler(initial, thereafter int, tick time.Duration) *logSampler {
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return &logSampler{
+		initial:    initial,
+		thereafter: thereafter,
+		tick:       tick,
+		counts:     make(map[string]int),
+	}
+}
+
+// allow reports whether an occurrence of the message should be written
+func (s *logSampler) allow(module, msg string, now time.Time) bool {
+	if now.After(s.resetAt) {
+		s.counts = make(map[string]int)
+		s.resetAt = now.Add(s.tick)
+	}
+
+	key := module + "\x00" + msg
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
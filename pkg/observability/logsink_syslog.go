@@ -0,0 +1,47 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package observability
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+)
+
+// syslogSink writes entries as JSON to syslog at the matching severity
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink connects to a syslog daemon. An empty network and address
+// use the local daemon.
+func NewSyslogSink(network, address, tag string) (LogSink, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Write(entry LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log entry: %w", err)
+	}
+	msg := string(data)
+	switch entry.Level {
+	case DEBUG:
+		return s.w.Debug(msg)
+	case INFO:
+		return s.w.Info(msg)
+	case WARN:
+		return s.w.Warning(msg)
+	default:
+		return s.w.Err(msg)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package observability
+
+import "fmt"
+
+// NewSyslogSink is not supported on this platform
+func NewSyslogSink(network, address, tag string) (LogSink, error) {
+	return nil, fmt.Errorf("syslog is not supported on this platform")
+}
@@ -0,0 +1,423 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/config"
+)
+
+// LogSink is a destination for log entries
+type LogSink interface {
+	Write(entry LogEntry) error
+	Close() error
+}
+
+// writerSink writes entries as JSON lines
+type writerSink struct {
+	w    io.Writer
+	once sync.Once
+	mu   sync.Mutex
+}
+
+// NewWriterSink creates a sink writing JSON lines to w
+func NewWriterSink(w io.Writer) LogSink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Write(entry LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log entry: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+func (s *writerSink) Close() error {
+	var err error
+	s.once.Do(func() {
+		if c, ok := s.w.(io.Closer); ok && s.w != os.Stdout && s.w != os.Stderr {
+			err = c.Close()
+		}
+	})
+	return err
+}
+
+// RotatingFileSink writes JSON lines to a file, rotating it once it exceeds
+// a size. Rotated files are named <path>.1 (newest) to <path>.<MaxBackups>.
+type RotatingFileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	mu         sync.Mutex
+}
+
+// NewRotatingFileSink opens a rotating file sink. maxSize of 0 disables
+// rotation; maxBackups of 0 keeps one backup.
+func NewRotatingFileSink(path string, maxSize int64, maxBackups int) (*RotatingFileSink, error) {
+	if maxBackups <= 0 {
+		maxBackups = 1
+	}
+	s := &RotatingFileSink{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RotatingFileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// Write implements LogSink.Write
+func (s *RotatingFileSink) Write(entry LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("log file is closed")
+	}
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	return err
+}
+
+// rotate shifts the backups and starts a new file
+func (s *RotatingFileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		os.Rename(s.path+"."+strconv.Itoa(i), s.path+"."+strconv.Itoa(i+1))
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return s.open()
+}
+
+// Close implements LogSink.Close
+func (s *RotatingFileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// OTLPSinkConfig contains configuration for an OTLP log sink
+type OTLPSinkConfig struct {
+	// Endpoint is the OTLP/HTTP logs endpoint, e.g. http://collector:4318/v1/logs
+	Endpoint string
+	Headers  map[string]string
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+	// BatchSize and FlushInterval control batching (defaults 100 and 5s)
+	BatchSize     int
+	FlushInterval time.Duration
+	HTTPClient    *http.Client
+}
+
+// OTLPSink exports entries in batches to an OpenTelemetry collector over
+// OTLP/HTTP with JSON encoding
+type OTLPSink struct {
+	config  OTLPSinkConfig
+	client  *http.Client
+	pending []LogEntry
+	// failed is the error of the last background flush, returned by the
+	// next Write
+	failed error
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+}
+
+// NewOTLPSink creates an OTLP sink and starts its flush loop
+func NewOTLPSink(config OTLPSinkConfig) *OTLPSink {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = "modulox"
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	s := &OTLPSink{
+		config: config,
+		client: client,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write implements LogSink.Write. Full batches are sent in the background;
+// a failed background send is reported by the next Write.
+func (s *OTLPSink) Write(entry LogEntry) error {
+	s.mu.Lock()
+	s.pending = append(s.pending, entry)
+	full := len(s.pending) >= s.config.BatchSize
+	err := s.failed
+	s.failed = nil
+	s.mu.Unlock()
+
+	if full {
+		go s.flushBackground()
+	}
+	return err
+}
+
+// flushBackground flushes and keeps the error for the next Write
+func (s *OTLPSink) flushBackground() {
+	if err := s.Flush(context.Background()); err != nil {
+		s.mu.Lock()
+		s.failed = err
+		s.mu.Unlock()
+	}
+}
+
+// Flush sends the pending entries
+func (s *OTLPSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(s.encode(batch))
+	if err != nil {
+		return fmt.Errorf("failed to encode logs: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export logs: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to export logs: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close implements LogSink.Close, flushing pending entries. Closing again
+// only flushes.
+func (s *OTLPSink) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return s.Flush(context.Background())
+}
+
+func (s *OTLPSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flushBackground()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// otlpSeverity maps levels to OTLP severity numbers
+var otlpSeverity = map[LogLevel]int{DEBUG: 5, INFO: 9, WARN: 13, ERROR: 17}
+
+// encode builds an OTLP ExportLogsServiceRequest
+func (s *OTLPSink) encode(entries []LogEntry) map[string]interface{} {
+	records := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		attributes := []map[string]interface{}{}
+		for k, v := range e.Fields {
+			attributes = append(attributes, otlpAttribute(k, fmt.Sprint(v)))
+		}
+		if e.Module != "" {
+			attributes = append(attributes, otlpAttribute("module", e.Module))
+		}
+		if e.TraceID != "" {
+			attributes = append(attributes, otlpAttribute("trace_id", e.TraceID), otlpAttribute("span_id", e.SpanID))
+		}
+		records = append(records, map[string]interface{}{
+			"timeUnixNano":   strconv.FormatInt(e.Timestamp.UnixNano(), 10),
+			"severityNumber": otlpSeverity[e.Level],
+			"severityText":   e.Level.String(),
+			"body":           map[string]interface{}{"stringValue": e.Message},
+			"attributes":     attributes,
+		})
+	}
+
+	return map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []interface{}{otlpAttribute("service.name", s.config.ServiceName)},
+			},
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      map[string]interface{}{"name": "modulox"},
+				"logRecords": records,
+			}},
+		}},
+	}
+}
+
+func otlpAttribute(key, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"key":   key,
+		"value": map[string]interface{}{"stringValue": value},
+	}
+}
+
+// NewLoggerFromConfig creates the logger described by Config.Logging. With
+// no sinks configured it writes JSON lines to stdout.
+func NewLoggerFromConfig(cfg *config.Config) (*Logger, error) {
+	level, err := ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		return nil, err
+	}
+	modules := make(map[string]LogLevel, len(cfg.Logging.Modules))
+	for module, name := range cfg.Logging.Modules {
+		if modules[module], err = ParseLevel(name); err != nil {
+			return nil, fmt.Errorf("module %s: %w", module, err)
+		}
+	}
+
+	var sinks []LogSink
+	for _, sc := range cfg.Logging.Sinks {
+		var sink LogSink
+		var err error
+		switch sc.Type {
+		case "", "stdout":
+			sink = NewWriterSink(os.Stdout)
+		case "stderr":
+			sink = NewWriterSink(os.Stderr)
+		case "file":
+			if sc.Path == "" {
+				err = fmt.Errorf("log path is required for file sink")
+				break
+			}
+			sink, err = NewRotatingFileSink(sc.Path, int64(sc.MaxSizeMB)*1024*1024, sc.MaxBackups)
+		case "syslog":
+			sink, err = NewSyslogSink(sc.Network, sc.Address, cfg.Agent.Name)
+		case "otlp":
+			sink = NewOTLPSink(OTLPSinkConfig{
+				Endpoint:    sc.Endpoint,
+				Headers:     sc.Headers,
+				ServiceName: cfg.Agent.Name,
+			})
+		default:
+			err = fmt.Errorf("unknown log sink type: %s", sc.Type)
+		}
+		if err != nil {
+			for _, opened := range sinks {
+				opened.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) == 0 {
+		sinks = append(sinks, NewWriterSink(os.Stdout))
+	}
+
+	logger := NewLoggerWithSinks(sinks...)
+	logger.SetLevel(level)
+	for module, level := range modules {
+		logger.SetModuleLevel(module, level)
+	}
+	logger.SetSampling(cfg.Logging.Sampling.Initial, cfg.Logging.Sampling.Thereafter, time.Second)
+	return logger, nil
+}
+
+// ReloadConfig applies changed levels and sampling from Config.Logging. It
+// is a config.Subscriber for the "logging" section; sink changes take
+// effect on restart.
+func (l *Logger) ReloadConfig(cfg *config.Config, changes []config.Change) error {
+	level, err := ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		return err
+	}
+	modules := make(map[string]LogLevel, len(cfg.Logging.Modules))
+	for module, name := range cfg.Logging.Modules {
+		if modules[module], err = ParseLevel(name); err != nil {
+			return fmt.Errorf("module %s: %w", module, err)
+		}
+	}
+
+	l.core.mu.Lock()
+	l.core.level = level
+	l.core.modules = modules
+	l.core.mu.Unlock()
+
+	for _, change := range changes {
+		if strings.HasPrefix(change.Key, "logging.sampling.") {
+			l.SetSampling(cfg.Logging.Sampling.Initial, cfg.Logging.Sampling.Thereafter, time.Second)
+			break
+		}
+	}
+	for _, change := range changes {
+		if change.Key == "logging.sinks" {
+			return fmt.Errorf("logging.sinks changed; restart to apply")
+		}
+	}
+	return nil
+}
@@ -2,17 +2,21 @@ package observability
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/user/modulox/pkg/reliability"
+	"github.com/user/modulox/pkg/tenant"
 )
 
 // MetricType represents the type of metric
 type MetricType int
 
 const (
-	Counter MetricType = iota
-	Gauge
-	Histogram
+	CounterMetric MetricType = iota
+	GaugeMetric
+	HistogramMetric
 )
 
 // Metric represents a single metric
@@ -26,14 +30,20 @@ type Metric struct {
 
 // MetricsCollector manages metric collection
 type MetricsCollector struct {
-	metrics map[string][]Metric
-	mu      sync.RWMutex
+	metrics    map[string][]Metric
+	latest     map[string]Metric
+	histograms map[string]*Histogram
+	usage      []UsageRecord
+	usageNext  int
+	mu         sync.RWMutex
 }
 
 // NewMetricsCollector creates a new metrics collector
 func NewMetricsCollector() *MetricsCollector {
 	return &MetricsCollector{
-		metrics: make(map[string][]Metric),
+		metrics:    make(map[string][]Metric),
+		latest:     make(map[string]Metric),
+		histograms: make(map[string]*Histogram),
 	}
 }
 
@@ -43,7 +53,19 @@ func (mc *MetricsCollector) RecordMetric(ctx context.Context, metric Metric) {
 	defer mc.mu.Unlock()
 
 	metric.Timestamp = time.Now()
+
+	// Label metrics with the tenant attached to the context
+	if id, ok := tenant.FromContext(ctx); ok {
+		labels := make(map[string]string, len(metric.Labels)+1)
+		for k, v := range metric.Labels {
+			labels[k] = v
+		}
+		labels["tenant"] = id
+		metric.Labels = labels
+	}
+
 	mc.metrics[metric.Name] = append(mc.metrics[metric.Name], metric)
+	mc.latest[seriesKey(metric.Name, metric.Labels)] = metric
 }
 
 // GetMetrics returns metrics for a given name
@@ -54,6 +76,37 @@ func (mc *MetricsCollector) GetMetrics(name string) []Metric {
 	return mc.metrics[name]
 }
 
+// Latest returns the most recent metric of every series, ordered by name.
+// Its cost grows with the number of series, not with the metrics recorded.
+func (mc *MetricsCollector) Latest() []Metric {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	keys := make([]string, 0, len(mc.latest))
+	for key := range mc.latest {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]Metric, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, mc.latest[key])
+	}
+	return result
+}
+
+// ReliabilityRecorder returns a recorder feeding the collector, for use
+// with reliability primitives such as BulkheadConfig.Metrics
+func (mc *MetricsCollector) ReliabilityRecorder() reliability.MetricsRecorder {
+	return func(ctx context.Context, metric reliability.Metric) {
+		metricType := GaugeMetric
+		if metric.Counter {
+			metricType = CounterMetric
+		}
+		mc.RecordMetric(ctx, Metric{Name: metric.Name, Type: metricType, Value: metric.Value, Labels: metric.Labels})
+	}
+}
+
 // Counter represents a cumulative metric
 type Counter struct {
 	name   string
@@ -85,7 +138,7 @@ func (c *Counter) Add(value float64) {
 	c.value += value
 	c.mc.RecordMetric(context.Background(), Metric{
 		Name:      c.name,
-		Type:      Counter,
+		Type:      CounterMetric,
 		Value:     c.value,
 		Labels:    c.labels,
 		Timestamp: time.Now(),
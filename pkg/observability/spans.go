@@ -0,0 +1,247 @@
+package observability
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// spanBuffer is a ring buffer of ended spans
+type spanBuffer struct {
+	spans     []*Span
+	next      int
+	full      bool
+	retention time.Duration
+}
+
+func newSpanBuffer(size int, retention time.Duration) *spanBuffer {
+	return &spanBuffer{
+		spans:     make([]*Span, size),
+		retention: retention,
+	}
+}
+
+// add stores a span, evicting the oldest once the buffer is full
+func (b *spanBuffer) add(span *Span) {
+	b.spans[b.next] = span
+	b.next = (b.next + 1) % len(b.spans)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// each calls fn for every retained span within the retention period
+func (b *spanBuffer) each(fn func(*Span)) {
+	n := b.next
+	if b.full {
+		n = len(b.spans)
+	}
+	cutoff := time.Time{}
+	if b.retention > 0 {
+		cutoff = time.Now().Add(-b.retention)
+	}
+	for i := 0; i < n; i++ {
+		span := b.spans[i]
+		if span != nil && span.EndTime.After(cutoff) {
+			fn(span)
+		}
+	}
+}
+
+// TraceNode is a span with its child spans
+type TraceNode struct {
+	Span     Span
+	Children []*TraceNode
+}
+
+// Trace is the span tree of a trace. Spans whose parent is unknown, such as
+// an evicted or remote parent, are additional roots.
+type Trace struct {
+	TraceID string
+	Roots   []*TraceNode
+	// SpanCount is the number of spans in the tree
+	SpanCount int
+}
+
+// GetTrace assembles the retained and still active spans of a trace into a
+// tree, ordered by start time. The tree holds copies of the spans, taken
+// under the tracer's lock.
+func (t *Tracer) GetTrace(traceID string) (*Trace, bool) {
+	var spans []Span
+	t.mu.RLock()
+	for _, span := range t.spans {
+		if span.TraceID == traceID {
+			spans = append(spans, span.clone())
+		}
+	}
+	t.finished.each(func(span *Span) {
+		if span.TraceID == traceID {
+			spans = append(spans, span.clone())
+		}
+	})
+	t.mu.RUnlock()
+
+	if len(spans) == 0 {
+		return nil, false
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].StartTime.Before(spans[j].StartTime) })
+
+	nodes := make(map[string]*TraceNode, len(spans))
+	for _, span := range spans {
+		nodes[span.SpanID] = &TraceNode{Span: span}
+	}
+
+	trace := &Trace{TraceID: traceID, SpanCount: len(spans)}
+	for _, span := range spans {
+		node := nodes[span.SpanID]
+		if parent, ok := nodes[span.ParentID]; ok && span.ParentID != "" {
+			parent.Children = append(parent.Children, node)
+		} else {
+			trace.Roots = append(trace.Roots, node)
+		}
+	}
+	return trace, true
+}
+
+// SpanExporter receives ended spans from a tracer
+type SpanExporter interface {
+	// Export hands over an ended span; it must not block the caller
+	Export(span Span)
+	// Shutdown flushes pending spans and stops the exporter
+	Shutdown(ctx context.Context) error
+}
+
+// SpanSink is a destination for batches of spans, such as a tracing backend
+type SpanSink interface {
+	ExportSpans(ctx context.Context, spans []Span) error
+}
+
+// SpanSinkFunc adapts a function to the SpanSink interface
+type SpanSinkFunc func(ctx context.Context, spans []Span) error
+
+// ExportSpans implements SpanSink.ExportSpans
+func (f SpanSinkFunc) ExportSpans(ctx context.Context, spans []Span) error {
+	return f(ctx, spans)
+}
+
+// BatchExporterConfig contains configuration for a batch exporter
+type BatchExporterConfig struct {
+	// BatchSize is the number of spans sent per batch (default 100)
+	BatchSize int
+	// FlushInterval sends partial batches after this long (default 5s)
+	FlushInterval time.Duration
+	// QueueSize bounds the spans waiting for export; spans are dropped when
+	// it is full (default 2048)
+	QueueSize int
+	// OnError receives export failures
+	OnError func(err error)
+}
+
+// BatchExporter queues ended spans and sends them to a sink in batches
+type BatchExporter struct {
+	sink    SpanSink
+	config  BatchExporterConfig
+	queue   chan Span
+	stop    chan struct{}
+	done    chan struct{}
+	dropped uint64
+	once    sync.Once
+	mu      sync.Mutex
+}
+
+// NewBatchExporter creates a batch exporter and starts its export loop
+func NewBatchExporter(sink SpanSink, config BatchExporterConfig) *BatchExporter {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 2048
+	}
+
+	e := &BatchExporter{
+		sink:   sink,
+		config: config,
+		queue:  make(chan Span, config.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export implements SpanExporter.Export
+func (e *BatchExporter) Export(span Span) {
+	select {
+	case e.queue <- span:
+	default:
+		e.mu.Lock()
+		e.dropped++
+		e.mu.Unlock()
+	}
+}
+
+// Dropped returns the number of spans dropped because the queue was full
+func (e *BatchExporter) Dropped() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+// Shutdown implements SpanExporter.Shutdown
+func (e *BatchExporter) Shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.stop) })
+
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *BatchExporter) run() {
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+	defer close(e.done)
+
+	batch := make([]Span, 0, e.config.BatchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.sink.ExportSpans(context.Background(), batch); err != nil && e.config.OnError != nil {
+			e.config.OnError(err)
+		}
+		batch = make([]Span, 0, e.config.BatchSize)
+	}
+
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= e.config.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case <-e.stop:
+			// Drain what is queued, then stop
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+					if len(batch) >= e.config.BatchSize {
+						send()
+					}
+				default:
+					send()
+					return
+				}
+			}
+		}
+	}
+}
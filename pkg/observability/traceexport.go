@@ -0,0 +1,217 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/config"
+)
+
+// RatioSampler samples a share of traces, from 0 to 1. The decision
+// depends only on the trace ID, so every service sampling a trace agrees.
+type RatioSampler struct {
+	Rate float64
+}
+
+// ShouldSample implements Sampler.ShouldSample
+func (s RatioSampler) ShouldSample(traceID string) bool {
+	if s.Rate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(traceID))
+	return float64(h.Sum64())/float64(^uint64(0)) < s.Rate
+}
+
+// writerSpanSink writes spans as JSON lines
+type writerSpanSink struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewWriterSpanSink creates a sink writing spans as JSON lines to w
+func NewWriterSpanSink(w io.Writer) SpanSink {
+	return &writerSpanSink{w: w}
+}
+
+// ExportSpans implements SpanSink.ExportSpans
+func (s *writerSpanSink) ExportSpans(ctx context.Context, spans []Span) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoder := json.NewEncoder(s.w)
+	for _, span := range spans {
+		if err := encoder.Encode(span); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OTLPSpanSinkConfig contains configuration for an OTLP span sink
+type OTLPSpanSinkConfig struct {
+	// Endpoint is the OTLP/HTTP traces endpoint, e.g.
+	// http://collector:4318/v1/traces
+	Endpoint string
+	Headers  map[string]string
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+	HTTPClient  *http.Client
+}
+
+// OTLPSpanSink exports spans to an OpenTelemetry collector over OTLP/HTTP
+// with JSON encoding. Trace and span IDs are hashed into the sizes OTLP
+// requires.
+type OTLPSpanSink struct {
+	config OTLPSpanSinkConfig
+	client *http.Client
+}
+
+// NewOTLPSpanSink creates an OTLP span sink; wrap it in a BatchExporter
+func NewOTLPSpanSink(config OTLPSpanSinkConfig) *OTLPSpanSink {
+	if config.ServiceName == "" {
+		config.ServiceName = "modulox"
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OTLPSpanSink{config: config, client: client}
+}
+
+// ExportSpans implements SpanSink.ExportSpans
+func (s *OTLPSpanSink) ExportSpans(ctx context.Context, spans []Span) error {
+	data, err := json.Marshal(s.encode(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to export spans: %s", resp.Status)
+	}
+	return nil
+}
+
+// encode builds an OTLP ExportTraceServiceRequest
+func (s *OTLPSpanSink) encode(spans []Span) map[string]interface{} {
+	encoded := make([]map[string]interface{}, 0, len(spans))
+	for _, span := range spans {
+		attributes := []map[string]interface{}{}
+		for k, v := range span.Tags {
+			attributes = append(attributes, otlpAttribute(k, v))
+		}
+		events := make([]map[string]interface{}, 0, len(span.Events))
+		for _, e := range span.Events {
+			eventAttributes := []map[string]interface{}{otlpAttribute("message", e.Message)}
+			for k, v := range e.Tags {
+				eventAttributes = append(eventAttributes, otlpAttribute(k, v))
+			}
+			events = append(events, map[string]interface{}{
+				"timeUnixNano": strconv.FormatInt(e.Time.UnixNano(), 10),
+				"name":         e.Name,
+				"attributes":   eventAttributes,
+			})
+		}
+		// OTLP status codes: 1 is OK, 2 is ERROR
+		status := 1
+		if span.Status == StatusError {
+			status = 2
+		}
+
+		encodedSpan := map[string]interface{}{
+			"traceId":           otlpID(span.TraceID, 16),
+			"spanId":            otlpID(span.SpanID, 8),
+			"name":              span.Name,
+			"startTimeUnixNano": strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			"attributes":        attributes,
+			"events":            events,
+			"status":            map[string]interface{}{"code": status},
+		}
+		if span.ParentID != "" {
+			encodedSpan["parentSpanId"] = otlpID(span.ParentID, 8)
+		}
+		encoded = append(encoded, encodedSpan)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []interface{}{otlpAttribute("service.name", s.config.ServiceName)},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "modulox"},
+				"spans": encoded,
+			}},
+		}},
+	}
+}
+
+// otlpID hashes a tracer ID into a hex ID of size bytes
+func otlpID(id string, size int) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:size])
+}
+
+// NewTracerFromConfig creates the tracer described by Config.Tracing,
+// exporting ended spans in batches when an exporter is configured
+func NewTracerFromConfig(cfg *config.Config) (*Tracer, error) {
+	tc := cfg.Tracing
+	rate := 1.0
+	if tc.SampleRate != nil {
+		rate = *tc.SampleRate
+	}
+	tracerConfig := TracerConfig{
+		Sampler:  RatioSampler{Rate: rate},
+		MaxSpans: tc.MaxSpans,
+	}
+
+	var sink SpanSink
+	switch tc.Exporter.Type {
+	case "", "none":
+	case "stdout":
+		sink = NewWriterSpanSink(os.Stdout)
+	case "otlp":
+		sink = NewOTLPSpanSink(OTLPSpanSinkConfig{
+			Endpoint:    tc.Exporter.Endpoint,
+			Headers:     tc.Exporter.Headers,
+			ServiceName: cfg.Agent.Name,
+		})
+	default:
+		return nil, fmt.Errorf("unknown span exporter type: %s", tc.Exporter.Type)
+	}
+	if sink != nil {
+		tracerConfig.Exporter = NewBatchExporter(sink, BatchExporterConfig{
+			BatchSize:     tc.Exporter.BatchSize,
+			FlushInterval: time.Duration(tc.Exporter.FlushIntervalMs) * time.Millisecond,
+			OnError: func(err error) {
+				fmt.Fprintf(os.Stderr, "Error exporting spans: %v\n", err)
+			},
+		})
+	}
+	return NewTracerWithConfig(tracerConfig), nil
+}
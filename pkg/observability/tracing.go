@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -36,9 +37,13 @@ const (
 	StatusError
 )
 
-// Tracer manages distributed tracing
+// Tracer manages distributed tracing. Spans are held in memory while
+// active; ended spans move to a bounded buffer and are handed to the
+// exporter.
 type Tracer struct {
 	spans    map[string]*Span
+	finished *spanBuffer
+	exporter SpanExporter
 	mu       sync.RWMutex
 	sampler  Sampler
 }
@@ -48,15 +53,37 @@ type Sampler interface {
 	ShouldSample(traceID string) bool
 }
 
+// TracerConfig contains configuration for a tracer
+type TracerConfig struct {
+	Sampler Sampler
+	// MaxSpans bounds the ended spans retained for GetTrace (default 10000)
+	MaxSpans int
+	// Retention drops ended spans older than this (0 = keep until evicted)
+	Retention time.Duration
+	// Exporter receives every ended span, e.g. a BatchExporter
+	Exporter SpanExporter
+}
+
 // NewTracer creates a new tracer
 func NewTracer(sampler Sampler) *Tracer {
+	return NewTracerWithConfig(TracerConfig{Sampler: sampler})
+}
+
+// NewTracerWithConfig creates a new tracer with bounded span storage
+func NewTracerWithConfig(config TracerConfig) *Tracer {
+	if config.MaxSpans <= 0 {
+		config.MaxSpans = 10000
+	}
 	return &Tracer{
-		spans:   make(map[string]*Span),
-		sampler: sampler,
+		spans:    make(map[string]*Span),
+		finished: newSpanBuffer(config.MaxSpans, config.Retention),
+		exporter: config.Exporter,
+		sampler:  config.Sampler,
 	}
 }
 
-// StartSpan starts a new span
+// StartSpan starts a new span. The span in ctx, if any, becomes its parent
+// unless WithParent is given.
 func (t *Tracer) StartSpan(ctx context.Context, name string, opts ...SpanOption) (*Span, context.Context) {
 	span := &Span{
 		TraceID:   generateTraceID(),
@@ -66,14 +93,17 @@ func (t *Tracer) StartSpan(ctx context.Context, name string, opts ...SpanOption)
 		Tags:      make(map[string]string),
 		Status:    StatusOK,
 	}
+	if parent, ok := SpanFromContext(ctx); ok {
+		WithParent(parent)(span)
+	}
 
 	// Apply options
 	for _, opt := range opts {
 		opt(span)
 	}
 
-	// Check if we should sample this trace
-	if !t.sampler.ShouldSample(span.TraceID) {
+	// Check if we should sample this trace; children follow their trace
+	if span.ParentID == "" && t.sampler != nil && !t.sampler.ShouldSample(span.TraceID) {
 		return nil, ctx
 	}
 
@@ -84,13 +114,48 @@ func (t *Tracer) StartSpan(ctx context.Context, name string, opts ...SpanOption)
 	return span, context.WithValue(ctx, spanKey{}, span)
 }
 
-// EndSpan ends a span
+// EndSpan ends a span, moving it to the retained spans and the exporter
 func (t *Tracer) EndSpan(span *Span) {
 	if span == nil {
 		return
 	}
 
+	t.mu.Lock()
 	span.EndTime = time.Now()
+	delete(t.spans, span.SpanID)
+	t.finished.add(span)
+	ended := span.clone()
+	t.mu.Unlock()
+
+	if t.exporter != nil {
+		t.exporter.Export(ended)
+	}
+}
+
+// clone copies a span, including its tags and events. Spans are mutated
+// under the tracer's lock, which the caller holds.
+func (s *Span) clone() Span {
+	c := *s
+	c.Tags = make(map[string]string, len(s.Tags))
+	for k, v := range s.Tags {
+		c.Tags[k] = v
+	}
+	c.Events = append([]SpanEvent(nil), s.Events...)
+	return c
+}
+
+// Close flushes and shuts down the exporter
+func (t *Tracer) Close(ctx context.Context) error {
+	if t.exporter == nil {
+		return nil
+	}
+	return t.exporter.Shutdown(ctx)
+}
+
+// SpanFromContext returns the active span attached to the context
+func SpanFromContext(ctx context.Context) (*Span, bool) {
+	span, ok := ctx.Value(spanKey{}).(*Span)
+	return span, ok && span != nil
 }
 
 // AddEvent adds an event to a span
@@ -106,6 +171,8 @@ func (t *Tracer) AddEvent(span *Span, name string, message string, tags map[stri
 		Tags:    tags,
 	}
 
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	span.Events = append(span.Events, event)
 }
 
@@ -115,10 +182,24 @@ func (t *Tracer) SetError(span *Span, err error) {
 		return
 	}
 
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	span.Status = StatusError
 	span.Tags["error"] = err.Error()
 }
 
+// SetTag sets a tag on a span. Spans are shared with readers such as
+// GetTrace, so tags must be set through the tracer.
+func (t *Tracer) SetTag(span *Span, key, value string) {
+	if span == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span.Tags[key] = value
+}
+
 // SpanOption configures a span
 type SpanOption func(*Span)
 
@@ -143,11 +224,14 @@ func WithTags(tags map[string]string) SpanOption {
 
 type spanKey struct{}
 
+// idSeq disambiguates IDs generated within the same nanosecond
+var idSeq uint64
+
 // Helper functions for generating IDs
 func generateTraceID() string {
-	return fmt.Sprintf("trace-%d", time.Now().UnixNano())
+	return fmt.Sprintf("trace-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&idSeq, 1))
 }
 
 func generateSpanID() string {
-	return fmt.Sprintf("span-%d", time.Now().UnixNano())
+	return fmt.Sprintf("span-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&idSeq, 1))
 }
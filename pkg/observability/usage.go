@@ -0,0 +1,157 @@
+package observability
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// UsageRecord is the usage of one LLM request with its attribution
+type UsageRecord struct {
+	llm.Usage
+	Scope     llm.UsageScope
+	Tenant    string
+	Timestamp time.Time
+}
+
+// UsageTotals aggregates token usage and cost
+type UsageTotals struct {
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Cost             float64
+}
+
+// add accumulates a record into the totals
+func (t *UsageTotals) add(r UsageRecord) {
+	t.Requests++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	t.TotalTokens += r.TotalTokens()
+	t.Cost += r.Cost
+}
+
+// UsageQuery filters the records aggregated by a usage report; zero fields
+// match everything
+type UsageQuery struct {
+	Tenant   string
+	Agent    string
+	Workflow string
+	Session  string
+	Since    time.Time
+	Until    time.Time
+}
+
+// matches reports whether the record satisfies the query
+func (q UsageQuery) matches(r UsageRecord) bool {
+	switch {
+	case q.Tenant != "" && r.Tenant != q.Tenant,
+		q.Agent != "" && r.Scope.Agent != q.Agent,
+		q.Workflow != "" && r.Scope.Workflow != q.Workflow,
+		q.Session != "" && r.Scope.Session != q.Session,
+		!q.Since.IsZero() && r.Timestamp.Before(q.Since),
+		!q.Until.IsZero() && !r.Timestamp.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// maxUsageRecords bounds the usage records a collector keeps; the oldest
+// are dropped first
+const maxUsageRecords = 10000
+
+// UsageReport breaks down LLM usage for spend attribution. Usage without
+// an agent, workflow, or session is only counted in Total and ByModel.
+// Reports cover the most recent maxUsageRecords requests.
+type UsageReport struct {
+	Total      UsageTotals
+	ByModel    map[string]UsageTotals
+	ByAgent    map[string]UsageTotals
+	ByWorkflow map[string]UsageTotals
+	BySession  map[string]UsageTotals
+}
+
+// RecordUsage records the usage of an LLM request, attributed to the usage
+// scope and tenant of the context. It also records llm_tokens and llm_cost
+// metrics labelled by model, agent, and workflow; sessions are too many to
+// label metrics with, so they are only reported by UsageReport.
+func (mc *MetricsCollector) RecordUsage(ctx context.Context, usage llm.Usage) {
+	record := UsageRecord{
+		Usage:     usage,
+		Scope:     llm.UsageScopeFromContext(ctx),
+		Tenant:    tenant.IDFromContext(ctx),
+		Timestamp: time.Now(),
+	}
+
+	mc.mu.Lock()
+	if len(mc.usage) < maxUsageRecords {
+		mc.usage = append(mc.usage, record)
+	} else {
+		// Once full, the records form a ring starting at usageNext
+		mc.usage[mc.usageNext] = record
+		mc.usageNext = (mc.usageNext + 1) % maxUsageRecords
+	}
+	mc.mu.Unlock()
+
+	labels := map[string]string{"model": usage.Model}
+	if record.Scope.Agent != "" {
+		labels["agent"] = record.Scope.Agent
+	}
+	if record.Scope.Workflow != "" {
+		labels["workflow"] = record.Scope.Workflow
+	}
+	mc.RecordMetric(ctx, Metric{Name: "llm_tokens", Type: CounterMetric, Value: float64(usage.TotalTokens()), Labels: labels})
+	mc.RecordMetric(ctx, Metric{Name: "llm_cost", Type: CounterMetric, Value: usage.Cost, Labels: labels})
+}
+
+// UsageRecorder returns a recorder feeding the collector, for use with
+// llm.MeterConfig
+func (mc *MetricsCollector) UsageRecorder() llm.UsageRecorder {
+	return mc.RecordUsage
+}
+
+// UsageRecords returns the usage records matching the query
+func (mc *MetricsCollector) UsageRecords(query UsageQuery) []UsageRecord {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	var records []UsageRecord
+	for i := range mc.usage {
+		if r := mc.usage[(mc.usageNext+i)%len(mc.usage)]; query.matches(r) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// UsageReport aggregates the usage records matching the query
+func (mc *MetricsCollector) UsageReport(query UsageQuery) UsageReport {
+	report := UsageReport{
+		ByModel:    make(map[string]UsageTotals),
+		ByAgent:    make(map[string]UsageTotals),
+		ByWorkflow: make(map[string]UsageTotals),
+		BySession:  make(map[string]UsageTotals),
+	}
+
+	for _, r := range mc.UsageRecords(query) {
+		report.Total.add(r)
+		addTo(report.ByModel, r.Model, r)
+		addTo(report.ByAgent, r.Scope.Agent, r)
+		addTo(report.ByWorkflow, r.Scope.Workflow, r)
+		addTo(report.BySession, r.Scope.Session, r)
+	}
+	return report
+}
+
+// addTo accumulates a record into the totals for key, skipping empty keys
+func addTo(totals map[string]UsageTotals, key string, r UsageRecord) {
+	if key == "" {
+		return
+	}
+	t := totals[key]
+	t.add(r)
+	totals[key] = t
+}
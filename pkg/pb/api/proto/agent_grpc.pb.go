@@ -30,6 +30,25 @@ type AgentServiceClient interface {
 	PublishEvent(ctx context.Context, in *Event, opts ...grpc.CallOption) (*PublishResponse, error)
 	// SyncState synchronizes state between agents
 	SyncState(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncResponse, error)
+	// Communicate exchanges events over one long-lived stream. Events are
+	// published to the topic in their "topic" metadata; events of type
+	// "modulox.subscribe" and "modulox.unsubscribe" manage the topics
+	// streamed back, named by their payload.
+	Communicate(ctx context.Context, opts ...grpc.CallOption) (AgentService_CommunicateClient, error)
+	// WatchState streams changes to a key, or to every key with a prefix when
+	// the key ends in "*", starting with the current values
+	WatchState(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (AgentService_WatchStateClient, error)
+	// RegisterNode joins a node to the cluster. source_agent is the node ID
+	// and the payload its JSON encoded status.
+	RegisterNode(ctx context.Context, in *Event, opts ...grpc.CallOption) (*PublishResponse, error)
+	// Heartbeat reports the status of a registered node, encoded as for
+	// RegisterNode. Unknown nodes fail with NOT_FOUND and must register again.
+	Heartbeat(ctx context.Context, in *Event, opts ...grpc.CallOption) (*PublishResponse, error)
+	// Deregister removes the node named by source_agent from the cluster
+	Deregister(ctx context.Context, in *Event, opts ...grpc.CallOption) (*PublishResponse, error)
+	// DescribeCluster returns the cluster topology visible to the caller's
+	// tenant
+	DescribeCluster(ctx context.Context, in *DescribeClusterRequest, opts ...grpc.CallOption) (*ClusterTopology, error)
 }
 
 type agentServiceClient struct {
@@ -99,6 +118,105 @@ func (c *agentServiceClient) SyncState(ctx context.Context, in *SyncRequest, opt
 	return out, nil
 }
 
+func (c *agentServiceClient) Communicate(ctx context.Context, opts ...grpc.CallOption) (AgentService_CommunicateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[1], "/modulox.v1.AgentService/Communicate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentServiceCommunicateClient{stream}
+	return x, nil
+}
+
+type AgentService_CommunicateClient interface {
+	Send(*Event) error
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type agentServiceCommunicateClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentServiceCommunicateClient) Send(m *Event) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *agentServiceCommunicateClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentServiceClient) WatchState(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (AgentService_WatchStateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[2], "/modulox.v1.AgentService/WatchState", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentServiceWatchStateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AgentService_WatchStateClient interface {
+	Recv() (*StateEvent, error)
+	grpc.ClientStream
+}
+
+type agentServiceWatchStateClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentServiceWatchStateClient) Recv() (*StateEvent, error) {
+	m := new(StateEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentServiceClient) RegisterNode(ctx context.Context, in *Event, opts ...grpc.CallOption) (*PublishResponse, error) {
+	out := new(PublishResponse)
+	err := c.cc.Invoke(ctx, "/modulox.v1.AgentService/RegisterNode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) Heartbeat(ctx context.Context, in *Event, opts ...grpc.CallOption) (*PublishResponse, error) {
+	out := new(PublishResponse)
+	err := c.cc.Invoke(ctx, "/modulox.v1.AgentService/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) Deregister(ctx context.Context, in *Event, opts ...grpc.CallOption) (*PublishResponse, error) {
+	out := new(PublishResponse)
+	err := c.cc.Invoke(ctx, "/modulox.v1.AgentService/Deregister", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) DescribeCluster(ctx context.Context, in *DescribeClusterRequest, opts ...grpc.CallOption) (*ClusterTopology, error) {
+	out := new(ClusterTopology)
+	err := c.cc.Invoke(ctx, "/modulox.v1.AgentService/DescribeCluster", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServiceServer is the server API for AgentService service.
 // All implementations must embed UnimplementedAgentServiceServer
 // for forward compatibility
@@ -111,6 +229,25 @@ type AgentServiceServer interface {
 	PublishEvent(context.Context, *Event) (*PublishResponse, error)
 	// SyncState synchronizes state between agents
 	SyncState(context.Context, *SyncRequest) (*SyncResponse, error)
+	// Communicate exchanges events over one long-lived stream. Events are
+	// published to the topic in their "topic" metadata; events of type
+	// "modulox.subscribe" and "modulox.unsubscribe" manage the topics
+	// streamed back, named by their payload.
+	Communicate(AgentService_CommunicateServer) error
+	// WatchState streams changes to a key, or to every key with a prefix when
+	// the key ends in "*", starting with the current values
+	WatchState(*SyncRequest, AgentService_WatchStateServer) error
+	// RegisterNode joins a node to the cluster. source_agent is the node ID
+	// and the payload its JSON encoded status.
+	RegisterNode(context.Context, *Event) (*PublishResponse, error)
+	// Heartbeat reports the status of a registered node, encoded as for
+	// RegisterNode. Unknown nodes fail with NOT_FOUND and must register again.
+	Heartbeat(context.Context, *Event) (*PublishResponse, error)
+	// Deregister removes the node named by source_agent from the cluster
+	Deregister(context.Context, *Event) (*PublishResponse, error)
+	// DescribeCluster returns the cluster topology visible to the caller's
+	// tenant
+	DescribeCluster(context.Context, *DescribeClusterRequest) (*ClusterTopology, error)
 	mustEmbedUnimplementedAgentServiceServer()
 }
 
@@ -130,6 +267,24 @@ func (UnimplementedAgentServiceServer) PublishEvent(context.Context, *Event) (*P
 func (UnimplementedAgentServiceServer) SyncState(context.Context, *SyncRequest) (*SyncResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SyncState not implemented")
 }
+func (UnimplementedAgentServiceServer) Communicate(AgentService_CommunicateServer) error {
+	return status.Errorf(codes.Unimplemented, "method Communicate not implemented")
+}
+func (UnimplementedAgentServiceServer) WatchState(*SyncRequest, AgentService_WatchStateServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchState not implemented")
+}
+func (UnimplementedAgentServiceServer) RegisterNode(context.Context, *Event) (*PublishResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterNode not implemented")
+}
+func (UnimplementedAgentServiceServer) Heartbeat(context.Context, *Event) (*PublishResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedAgentServiceServer) Deregister(context.Context, *Event) (*PublishResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deregister not implemented")
+}
+func (UnimplementedAgentServiceServer) DescribeCluster(context.Context, *DescribeClusterRequest) (*ClusterTopology, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeCluster not implemented")
+}
 func (UnimplementedAgentServiceServer) mustEmbedUnimplementedAgentServiceServer() {}
 
 // UnsafeAgentServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -218,6 +373,125 @@ func _AgentService_SyncState_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AgentService_Communicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentServiceServer).Communicate(&agentServiceCommunicateServer{stream})
+}
+
+type AgentService_CommunicateServer interface {
+	Send(*Event) error
+	Recv() (*Event, error)
+	grpc.ServerStream
+}
+
+type agentServiceCommunicateServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentServiceCommunicateServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *agentServiceCommunicateServer) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _AgentService_WatchState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SyncRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServiceServer).WatchState(m, &agentServiceWatchStateServer{stream})
+}
+
+type AgentService_WatchStateServer interface {
+	Send(*StateEvent) error
+	grpc.ServerStream
+}
+
+type agentServiceWatchStateServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentServiceWatchStateServer) Send(m *StateEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AgentService_RegisterNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Event)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).RegisterNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/modulox.v1.AgentService/RegisterNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).RegisterNode(ctx, req.(*Event))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Event)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/modulox.v1.AgentService/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).Heartbeat(ctx, req.(*Event))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_Deregister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Event)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).Deregister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/modulox.v1.AgentService/Deregister",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).Deregister(ctx, req.(*Event))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_DescribeCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeClusterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).DescribeCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/modulox.v1.AgentService/DescribeCluster",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).DescribeCluster(ctx, req.(*DescribeClusterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AgentService_ServiceDesc is the grpc.ServiceDesc for AgentService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -237,6 +511,22 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SyncState",
 			Handler:    _AgentService_SyncState_Handler,
 		},
+		{
+			MethodName: "RegisterNode",
+			Handler:    _AgentService_RegisterNode_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _AgentService_Heartbeat_Handler,
+		},
+		{
+			MethodName: "Deregister",
+			Handler:    _AgentService_Deregister_Handler,
+		},
+		{
+			MethodName: "DescribeCluster",
+			Handler:    _AgentService_DescribeCluster_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -244,6 +534,17 @@ var AgentService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _AgentService_StreamEvents_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Communicate",
+			Handler:       _AgentService_Communicate_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchState",
+			Handler:       _AgentService_WatchState_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "api/proto/agent.proto",
 }
@@ -0,0 +1,305 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/session"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// ToolCallRecord records a single tool invocation
+type ToolCallRecord struct {
+	ID        string
+	TenantID  string
+	SessionID string
+	AgentID   string
+	ToolName  string
+	Input     string
+	Output    string
+	Error     string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// Workflow run statuses
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// WorkflowRun records a single workflow execution
+type WorkflowRun struct {
+	ID         string
+	TenantID   string
+	Workflow   string
+	Input      string
+	Output     string
+	Error      string
+	Status     string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// SessionQuery filters sessions
+type SessionQuery struct {
+	AgentID string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+// MessageQuery filters messages
+type MessageQuery struct {
+	SessionID string
+	Role      string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// ToolCallQuery filters tool call records
+type ToolCallQuery struct {
+	SessionID string
+	AgentID   string
+	ToolName  string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// WorkflowRunQuery filters workflow runs
+type WorkflowRunQuery struct {
+	Workflow string
+	Status   string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// RecordToolCall persists a tool call record
+func (s *SQLStore) RecordToolCall(ctx context.Context, rec ToolCallRecord) error {
+	if rec.ID == "" {
+		rec.ID = fmt.Sprintf("toolcall-%d", time.Now().UnixNano())
+	}
+	if rec.TenantID == "" {
+		rec.TenantID = tenant.IDFromContext(ctx)
+	}
+
+	_, err := s.db.ExecContext(ctx, s.rebind(
+		`INSERT INTO tool_calls (id, tenant_id, session_id, agent_id, tool_name, input, output, error, started_at, duration_ns)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		rec.ID, rec.TenantID, rec.SessionID, rec.AgentID, rec.ToolName, rec.Input, rec.Output, rec.Error,
+		rec.StartedAt.UnixNano(), int64(rec.Duration))
+	if err != nil {
+		return fmt.Errorf("failed to record tool call: %w", err)
+	}
+	return nil
+}
+
+// RecordWorkflowRun inserts or updates a workflow run
+func (s *SQLStore) RecordWorkflowRun(ctx context.Context, run WorkflowRun) error {
+	if run.ID == "" {
+		return fmt.Errorf("workflow run id is required")
+	}
+	if run.TenantID == "" {
+		run.TenantID = tenant.IDFromContext(ctx)
+	}
+
+	var finishedAt sql.NullInt64
+	if !run.FinishedAt.IsZero() {
+		finishedAt = sql.NullInt64{Int64: run.FinishedAt.UnixNano(), Valid: true}
+	}
+
+	res, err := s.db.ExecContext(ctx, s.rebind(
+		`UPDATE workflow_runs SET output = ?, error = ?, status = ?, finished_at = ? WHERE id = ? AND tenant_id = ?`),
+		run.Output, run.Error, run.Status, finishedAt, run.ID, run.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update workflow run: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	_, err = s.db.ExecContext(ctx, s.rebind(
+		`INSERT INTO workflow_runs (id, tenant_id, workflow, input, output, error, status, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		run.ID, run.TenantID, run.Workflow, run.Input, run.Output, run.Error, run.Status,
+		run.StartedAt.UnixNano(), finishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record workflow run: %w", err)
+	}
+	return nil
+}
+
+// ListSessions returns sessions of the tenant on the context matching the query
+func (s *SQLStore) ListSessions(ctx context.Context, q SessionQuery) ([]*session.Session, error) {
+	w := newWhere("tenant_id = ?", tenant.IDFromContext(ctx))
+	w.addIf(q.AgentID != "", "agent_id = ?", q.AgentID)
+	w.addTimeRange("created_at", q.Since, q.Until)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT id, tenant_id, agent_id, metadata, created_at, updated_at FROM sessions`+w.String()+` ORDER BY created_at`+limit(q.Limit)),
+		w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*session.Session, 0)
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+// ListMessages returns messages matching the query in conversation order
+func (s *SQLStore) ListMessages(ctx context.Context, q MessageQuery) ([]session.Message, error) {
+	// Only expose messages of sessions owned by the tenant on the context
+	w := newWhere("session_id IN (SELECT id FROM sessions WHERE tenant_id = ?)", tenant.IDFromContext(ctx))
+	w.addIf(q.SessionID != "", "session_id = ?", q.SessionID)
+	w.addIf(q.Role != "", "role = ?", q.Role)
+	w.addTimeRange("created_at", q.Since, q.Until)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT role, content, metadata, created_at FROM messages`+w.String()+` ORDER BY session_id, seq`+limit(q.Limit)),
+		w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]session.Message, 0)
+	for rows.Next() {
+		var (
+			m         session.Message
+			metadata  sql.NullString
+			createdAt int64
+		)
+		if err := rows.Scan(&m.Role, &m.Content, &metadata, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		m.Timestamp = time.Unix(0, createdAt)
+		if err := decodeJSON(metadata, &m.Metadata); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// ListToolCalls returns tool call records matching the query
+func (s *SQLStore) ListToolCalls(ctx context.Context, q ToolCallQuery) ([]ToolCallRecord, error) {
+	w := newWhere("tenant_id = ?", tenant.IDFromContext(ctx))
+	w.addIf(q.SessionID != "", "session_id = ?", q.SessionID)
+	w.addIf(q.AgentID != "", "agent_id = ?", q.AgentID)
+	w.addIf(q.ToolName != "", "tool_name = ?", q.ToolName)
+	w.addTimeRange("started_at", q.Since, q.Until)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT id, tenant_id, session_id, agent_id, tool_name, input, output, error, started_at, duration_ns
+		FROM tool_calls`+w.String()+` ORDER BY started_at`+limit(q.Limit)),
+		w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tool calls: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]ToolCallRecord, 0)
+	for rows.Next() {
+		var (
+			rec                                   ToolCallRecord
+			sessionID, agentID, input, out, errst sql.NullString
+			startedAt, duration                   int64
+		)
+		if err := rows.Scan(&rec.ID, &rec.TenantID, &sessionID, &agentID, &rec.ToolName,
+			&input, &out, &errst, &startedAt, &duration); err != nil {
+			return nil, fmt.Errorf("failed to scan tool call: %w", err)
+		}
+		rec.SessionID, rec.AgentID = sessionID.String, agentID.String
+		rec.Input, rec.Output, rec.Error = input.String, out.String, errst.String
+		rec.StartedAt = time.Unix(0, startedAt)
+		rec.Duration = time.Duration(duration)
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// ListWorkflowRuns returns workflow runs matching the query
+func (s *SQLStore) ListWorkflowRuns(ctx context.Context, q WorkflowRunQuery) ([]WorkflowRun, error) {
+	w := newWhere("tenant_id = ?", tenant.IDFromContext(ctx))
+	w.addIf(q.Workflow != "", "workflow = ?", q.Workflow)
+	w.addIf(q.Status != "", "status = ?", q.Status)
+	w.addTimeRange("started_at", q.Since, q.Until)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(
+		`SELECT id, tenant_id, workflow, input, output, error, status, started_at, finished_at
+		FROM workflow_runs`+w.String()+` ORDER BY started_at`+limit(q.Limit)),
+		w.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]WorkflowRun, 0)
+	for rows.Next() {
+		var (
+			run                WorkflowRun
+			input, out, errstr sql.NullString
+			startedAt          int64
+			finishedAt         sql.NullInt64
+		)
+		if err := rows.Scan(&run.ID, &run.TenantID, &run.Workflow, &input, &out, &errstr,
+			&run.Status, &startedAt, &finishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan workflow run: %w", err)
+		}
+		run.Input, run.Output, run.Error = input.String, out.String, errstr.String
+		run.StartedAt = time.Unix(0, startedAt)
+		if finishedAt.Valid {
+			run.FinishedAt = time.Unix(0, finishedAt.Int64)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// where accumulates SQL conditions and their arguments
+type where struct {
+	conds []string
+	args  []interface{}
+}
+
+func newWhere(cond string, arg interface{}) *where {
+	return &where{conds: []string{cond}, args: []interface{}{arg}}
+}
+
+func (w *where) addIf(ok bool, cond string, arg interface{}) {
+	if ok {
+		w.conds = append(w.conds, cond)
+		w.args = append(w.args, arg)
+	}
+}
+
+func (w *where) addTimeRange(column string, since, until time.Time) {
+	w.addIf(!since.IsZero(), column+" >= ?", since.UnixNano())
+	w.addIf(!until.IsZero(), column+" < ?", until.UnixNano())
+}
+
+func (w *where) String() string {
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+func limit(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" LIMIT %d", n)
+}
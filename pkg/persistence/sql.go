@@ -0,0 +1,288 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/session"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// Dialect selects the SQL flavour used by SQLStore
+type Dialect int
+
+const (
+	DialectSQLite Dialect = iota
+	DialectPostgres
+)
+
+// SQLStore persists sessions, messages, tool calls, and workflow runs in a
+// SQL database. The caller opens the *sql.DB with the driver of their choice.
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore creates a new SQL-backed store
+func NewSQLStore(db *sql.DB, dialect Dialect) *SQLStore {
+	return &SQLStore{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// schema holds the tables created by Migrate; timestamps are Unix nanoseconds
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		agent_id TEXT NOT NULL,
+		metadata TEXT,
+		created_at BIGINT NOT NULL,
+		updated_at BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS messages (
+		session_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		metadata TEXT,
+		created_at BIGINT NOT NULL,
+		PRIMARY KEY (session_id, seq)
+	)`,
+	`CREATE TABLE IF NOT EXISTS tool_calls (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		session_id TEXT,
+		agent_id TEXT,
+		tool_name TEXT NOT NULL,
+		input TEXT,
+		output TEXT,
+		error TEXT,
+		started_at BIGINT NOT NULL,
+		duration_ns BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS workflow_runs (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL,
+		workflow TEXT NOT NULL,
+		input TEXT,
+		output TEXT,
+		error TEXT,
+		status TEXT NOT NULL,
+		started_at BIGINT NOT NULL,
+		finished_at BIGINT
+	)`,
+}
+
+// Migrate creates the tables used by the store if they do not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	for _, stmt := range schema {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Create implements session.Store.Create
+func (s *SQLStore) Create(ctx context.Context, sess *session.Session) error {
+	if sess.TenantID == "" {
+		sess.TenantID = tenant.IDFromContext(ctx)
+	}
+
+	metadata, err := encodeJSON(sess.Metadata)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, s.rebind(
+		`INSERT INTO sessions (id, tenant_id, agent_id, metadata, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`),
+		sess.ID, sess.TenantID, sess.AgentID, metadata, sess.CreatedAt.UnixNano(), sess.UpdatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if err := s.insertMessages(ctx, tx, sess.ID, 0, sess.Messages); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get implements session.Store.Get
+func (s *SQLStore) Get(ctx context.Context, id string) (*session.Session, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(
+		`SELECT id, tenant_id, agent_id, metadata, created_at, updated_at FROM sessions WHERE id = ? AND tenant_id = ?`),
+		id, tenant.IDFromContext(ctx))
+
+	sess, err := scanSession(row)
+	if err == sql.ErrNoRows {
+		return nil, session.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	sess.Messages, err = s.ListMessages(ctx, MessageQuery{SessionID: id})
+	if err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Append implements session.Store.Append. Concurrent appends to a session
+// are serialized by the session row, so each sees the other's messages when
+// numbering its own.
+func (s *SQLStore) Append(ctx context.Context, id string, messages ...session.Message) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Writing the session row first locks it until commit: a row lock in
+	// Postgres, the database write lock in SQLite. The sequence is read
+	// only after, under that lock.
+	res, err := tx.ExecContext(ctx, s.rebind(
+		`UPDATE sessions SET updated_at = ? WHERE id = ? AND tenant_id = ?`),
+		time.Now().UnixNano(), id, tenant.IDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return session.ErrSessionNotFound
+	}
+
+	var next int
+	if err := tx.QueryRowContext(ctx, s.rebind(
+		`SELECT COALESCE(MAX(seq) + 1, 0) FROM messages WHERE session_id = ?`), id).Scan(&next); err != nil {
+		return fmt.Errorf("failed to read message sequence: %w", err)
+	}
+
+	if err := s.insertMessages(ctx, tx, id, next, messages); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete implements session.Store.Delete
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, s.rebind(
+		`DELETE FROM sessions WHERE id = ? AND tenant_id = ?`), id, tenant.IDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return session.ErrSessionNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM messages WHERE session_id = ?`), id); err != nil {
+		return fmt.Errorf("failed to delete messages: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// List implements session.Store.List. Messages are not loaded; use Get to resume a session.
+func (s *SQLStore) List(ctx context.Context) ([]*session.Session, error) {
+	return s.ListSessions(ctx, SessionQuery{})
+}
+
+func (s *SQLStore) insertMessages(ctx context.Context, tx *sql.Tx, sessionID string, seq int, messages []session.Message) error {
+	for i, m := range messages {
+		metadata, err := encodeJSON(m.Metadata)
+		if err != nil {
+			return err
+		}
+		ts := m.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		_, err = tx.ExecContext(ctx, s.rebind(
+			`INSERT INTO messages (session_id, seq, role, content, metadata, created_at) VALUES (?, ?, ?, ?, ?, ?)`),
+			sessionID, seq+i, m.Role, m.Content, metadata, ts.UnixNano())
+		if err != nil {
+			return fmt.Errorf("failed to insert message: %w", err)
+		}
+	}
+	return nil
+}
+
+// rebind rewrites ? placeholders for the store's dialect
+func (s *SQLStore) rebind(query string) string {
+	if s.dialect != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row rowScanner) (*session.Session, error) {
+	var (
+		sess                 session.Session
+		metadata             sql.NullString
+		createdAt, updatedAt int64
+	)
+	if err := row.Scan(&sess.ID, &sess.TenantID, &sess.AgentID, &metadata, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	sess.CreatedAt = time.Unix(0, createdAt)
+	sess.UpdatedAt = time.Unix(0, updatedAt)
+	sess.Messages = make([]session.Message, 0)
+	if err := decodeJSON(metadata, &sess.Metadata); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func encodeJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeJSON(s sql.NullString, v interface{}) error {
+	if !s.Valid || s.String == "" || s.String == "null" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(s.String), v); err != nil {
+		return fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TemplateExt is the extension of prompt template files
+const TemplateExt = ".tmpl"
+
+// LoadDir saves the prompt templates in dir to store. Files are named
+// <name>.v<version>.tmpl, or <name>.v<version>.<variant>.tmpl for A/B
+// variants, e.g. "support.v2.tmpl" and "support.v3.concise.tmpl".
+func LoadDir(ctx context.Context, store Store, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != TemplateExt {
+			continue
+		}
+		p, err := parseFileName(entry.Name())
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read prompt %s: %w", entry.Name(), err)
+		}
+		p.Template = string(data)
+		p.Metadata = map[string]string{"source": filepath.Join(dir, entry.Name())}
+
+		if _, err := store.Save(ctx, p); err != nil {
+			return fmt.Errorf("failed to save prompt %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// parseFileName extracts the name, version, and variant of a template file
+func parseFileName(file string) (Prompt, error) {
+	parts := strings.Split(strings.TrimSuffix(file, TemplateExt), ".")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || !strings.HasPrefix(parts[1], "v") {
+		return Prompt{}, fmt.Errorf("invalid prompt file name %s: want <name>.v<version>[.<variant>]%s", file, TemplateExt)
+	}
+
+	version, err := strconv.Atoi(parts[1][1:])
+	if err != nil || version <= 0 {
+		return Prompt{}, fmt.Errorf("invalid prompt version in %s", file)
+	}
+
+	p := Prompt{Name: parts[0], Version: version}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
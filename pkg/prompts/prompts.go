@@ -0,0 +1,153 @@
+// Package prompts manages named, versioned prompt templates with A/B
+// variants, so agents can reference prompts instead of embedding them.
+package prompts
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Prompt is one version, and optionally one variant, of a named template
+type Prompt struct {
+	Name    string
+	Version int
+	// Variant distinguishes A/B alternatives within a version; "" is the
+	// only variant of prompts without alternatives
+	Variant string
+	// Template is a text/template source
+	Template string
+	// Weight is the relative share of traffic the variant receives (default 1)
+	Weight    int
+	Metadata  map[string]string
+	CreatedAt time.Time
+}
+
+// PromptRef references a prompt by name. A zero Version selects the latest
+// version; an empty Variant lets the registry choose one.
+type PromptRef struct {
+	Name    string
+	Version int
+	Variant string
+}
+
+// Store persists prompts
+type Store interface {
+	// Save stores a prompt; a zero Version is assigned the next version
+	Save(ctx context.Context, p Prompt) (Prompt, error)
+	// Get returns every variant of a version of a prompt
+	Get(ctx context.Context, name string, version int) ([]Prompt, error)
+	// Latest returns the latest version number of a prompt
+	Latest(ctx context.Context, name string) (int, error)
+	// List returns the names of all prompts
+	List(ctx context.Context) ([]string, error)
+}
+
+// MemoryStore is an in-memory Store
+type MemoryStore struct {
+	prompts map[string][]Prompt
+	mu      sync.RWMutex
+}
+
+// NewMemoryStore creates a new in-memory prompt store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		prompts: make(map[string][]Prompt),
+	}
+}
+
+// Save implements Store.Save. Saving an existing version and variant
+// replaces it.
+func (s *MemoryStore) Save(ctx context.Context, p Prompt) (Prompt, error) {
+	if p.Name == "" {
+		return Prompt{}, ErrInvalidPrompt
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.prompts[p.Name]
+	if p.Version == 0 {
+		p.Version = latestVersion(existing) + 1
+	}
+	if p.Weight <= 0 {
+		p.Weight = 1
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+
+	for i, e := range existing {
+		if e.Version == p.Version && e.Variant == p.Variant {
+			existing[i] = p
+			return p, nil
+		}
+	}
+	s.prompts[p.Name] = append(existing, p)
+	return p, nil
+}
+
+// Get implements Store.Get
+func (s *MemoryStore) Get(ctx context.Context, name string, version int) ([]Prompt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var variants []Prompt
+	for _, p := range s.prompts[name] {
+		if p.Version == version {
+			variants = append(variants, p)
+		}
+	}
+	if len(variants) == 0 {
+		return nil, ErrPromptNotFound
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].Variant < variants[j].Variant })
+	return variants, nil
+}
+
+// Latest implements Store.Latest
+func (s *MemoryStore) Latest(ctx context.Context, name string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	version := latestVersion(s.prompts[name])
+	if version == 0 {
+		return 0, ErrPromptNotFound
+	}
+	return version, nil
+}
+
+// List implements Store.List
+func (s *MemoryStore) List(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.prompts))
+	for name := range s.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func latestVersion(prompts []Prompt) int {
+	latest := 0
+	for _, p := range prompts {
+		if p.Version > latest {
+			latest = p.Version
+		}
+	}
+	return latest
+}
+
+// Error types
+type PromptError string
+
+func (e PromptError) Error() string { return string(e) }
+
+const (
+	ErrPromptNotFound  = PromptError("prompt not found")
+	ErrVariantNotFound = PromptError("prompt variant not found")
+	ErrInvalidPrompt   = PromptError("invalid prompt")
+)
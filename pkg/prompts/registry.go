@@ -0,0 +1,170 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Selector chooses among the A/B variants of a prompt version
+type Selector func(ctx context.Context, variants []Prompt) Prompt
+
+// RandomSelector picks a variant at random, proportionally to its weight
+func RandomSelector(ctx context.Context, variants []Prompt) Prompt {
+	return pick(variants, rand.Intn(totalWeight(variants)))
+}
+
+// HashSelector picks a variant from a hash of key(ctx), such as a user or
+// session ID, so the same key always sees the same variant. Contexts without
+// a key fall back to RandomSelector.
+func HashSelector(key func(ctx context.Context) string) Selector {
+	return func(ctx context.Context, variants []Prompt) Prompt {
+		k := key(ctx)
+		if k == "" {
+			return RandomSelector(ctx, variants)
+		}
+		h := fnv.New32a()
+		h.Write([]byte(k))
+		return pick(variants, int(h.Sum32()%uint32(totalWeight(variants))))
+	}
+}
+
+func totalWeight(variants []Prompt) int {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return 1
+	}
+	return total
+}
+
+// pick returns the variant owning position n of the cumulative weights
+func pick(variants []Prompt, n int) Prompt {
+	for _, v := range variants {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+	return variants[0]
+}
+
+type variantKey struct{}
+
+// WithVariant forces the variant chosen for prompts resolved with the
+// context, e.g. to pin a request to one arm of an experiment
+func WithVariant(ctx context.Context, variant string) context.Context {
+	return context.WithValue(ctx, variantKey{}, variant)
+}
+
+// Registry resolves prompt references against a store and renders them
+type Registry struct {
+	store     Store
+	selector  Selector
+	templates map[string]*template.Template
+	mu        sync.RWMutex
+}
+
+// NewRegistry creates a registry over store; a nil selector uses RandomSelector
+func NewRegistry(store Store, selector Selector) *Registry {
+	if selector == nil {
+		selector = RandomSelector
+	}
+	return &Registry{
+		store:     store,
+		selector:  selector,
+		templates: make(map[string]*template.Template),
+	}
+}
+
+// Store returns the underlying prompt store
+func (r *Registry) Store() Store {
+	return r.store
+}
+
+// Resolve returns the prompt a reference selects. An explicit variant in the
+// reference wins over one attached with WithVariant; otherwise the selector
+// chooses among the variants.
+func (r *Registry) Resolve(ctx context.Context, ref PromptRef) (Prompt, error) {
+	version := ref.Version
+	if version == 0 {
+		latest, err := r.store.Latest(ctx, ref.Name)
+		if err != nil {
+			return Prompt{}, fmt.Errorf("%w: %s", err, ref.Name)
+		}
+		version = latest
+	}
+
+	variants, err := r.store.Get(ctx, ref.Name, version)
+	if err != nil {
+		return Prompt{}, fmt.Errorf("%w: %s v%d", err, ref.Name, version)
+	}
+
+	variant := ref.Variant
+	if variant == "" {
+		variant, _ = ctx.Value(variantKey{}).(string)
+	}
+	if variant != "" {
+		for _, p := range variants {
+			if p.Variant == variant {
+				return p, nil
+			}
+		}
+		// A pinned variant may not exist in every prompt; only an explicit
+		// reference must match
+		if ref.Variant != "" {
+			return Prompt{}, fmt.Errorf("%w: %s v%d %s", ErrVariantNotFound, ref.Name, version, variant)
+		}
+	}
+
+	if len(variants) == 1 {
+		return variants[0], nil
+	}
+	return r.selector(ctx, variants), nil
+}
+
+// Render resolves a reference and executes its template with data
+func (r *Registry) Render(ctx context.Context, ref PromptRef, data interface{}) (string, Prompt, error) {
+	p, err := r.Resolve(ctx, ref)
+	if err != nil {
+		return "", Prompt{}, err
+	}
+
+	tmpl, err := r.template(p)
+	if err != nil {
+		return "", Prompt{}, err
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", Prompt{}, fmt.Errorf("failed to render prompt %s v%d: %w", p.Name, p.Version, err)
+	}
+	return strings.TrimSpace(out.String()), p, nil
+}
+
+// template returns the parsed template of a prompt, caching it by source
+// so replaced prompts are parsed again
+func (r *Registry) template(p Prompt) (*template.Template, error) {
+	r.mu.RLock()
+	tmpl, ok := r.templates[p.Template]
+	r.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := template.New(p.Name).Option("missingkey=zero").Parse(p.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template %s v%d: %w", p.Name, p.Version, err)
+	}
+
+	r.mu.Lock()
+	r.templates[p.Template] = tmpl
+	r.mu.Unlock()
+	return tmpl, nil
+}
@@ -0,0 +1,41 @@
+package redact
+
+import (
+	"context"
+
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/observability"
+)
+
+// LogFilter returns an observability.LogFilter that redacts log messages and
+// fields. The agent is taken from the "agent_id" field when present.
+func LogFilter(policies *PolicySet) observability.LogFilter {
+	return func(ctx context.Context, entry *observability.LogEntry) {
+		agentID, _ := entry.Fields["agent_id"].(string)
+		policy, redactor := policies.Resolve(ctx, agentID)
+		if !policy.Logs {
+			return
+		}
+
+		entry.Message = redactor.RedactString(ctx, entry.Message)
+		entry.Fields = redactor.RedactMetadata(ctx, entry.Fields)
+	}
+}
+
+// EventHandler wraps a communication.EventHandler so it receives redacted
+// events. The agent is taken from the "agent_id" metadata when present.
+func EventHandler(policies *PolicySet, handler communication.EventHandler) communication.EventHandler {
+	return func(ctx context.Context, event communication.Event) error {
+		agentID, _ := event.Metadata["agent_id"].(string)
+		policy, redactor := policies.Resolve(ctx, agentID)
+		if !policy.Logs {
+			return handler(ctx, event)
+		}
+
+		if payload, ok := event.Payload.(string); ok {
+			event.Payload = redactor.RedactString(ctx, payload)
+		}
+		event.Metadata = redactor.RedactMetadata(ctx, event.Metadata)
+		return handler(ctx, event)
+	}
+}
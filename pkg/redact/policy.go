@@ -0,0 +1,77 @@
+package redact
+
+import (
+	"context"
+	"sync"
+
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// Policy controls where redaction is applied
+type Policy struct {
+	// Inputs scrubs prompts before they reach the provider
+	Inputs bool
+	// Memory masks string metadata before it is stored
+	Memory bool
+	// Logs redacts log messages, log fields, and event payloads
+	Logs bool
+	// Redactor overrides the default redactor; nil uses the PolicySet default
+	Redactor *Redactor
+}
+
+// PolicySet resolves redaction policies per tenant and agent
+type PolicySet struct {
+	defaultPolicy Policy
+	redactor      *Redactor
+	policies      map[string]Policy
+	mu            sync.RWMutex
+}
+
+// NewPolicySet creates a policy set with a default policy and redactor
+func NewPolicySet(defaultPolicy Policy, redactor *Redactor) *PolicySet {
+	if redactor == nil {
+		redactor = NewRedactor()
+	}
+	return &PolicySet{
+		defaultPolicy: defaultPolicy,
+		redactor:      redactor,
+		policies:      make(map[string]Policy),
+	}
+}
+
+// SetPolicy sets the policy for a tenant and agent; either may be "*" to match any
+func (ps *PolicySet) SetPolicy(tenantID, agentID string, policy Policy) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.policies[policyKey(tenantID, agentID)] = policy
+}
+
+// Resolve returns the most specific policy for the tenant on the context and agent
+func (ps *PolicySet) Resolve(ctx context.Context, agentID string) (Policy, *Redactor) {
+	tenantID := tenant.IDFromContext(ctx)
+
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	policy := ps.defaultPolicy
+	for _, key := range []string{
+		policyKey(tenantID, agentID),
+		policyKey(tenantID, "*"),
+		policyKey("*", agentID),
+	} {
+		if p, ok := ps.policies[key]; ok {
+			policy = p
+			break
+		}
+	}
+
+	redactor := policy.Redactor
+	if redactor == nil {
+		redactor = ps.redactor
+	}
+	return policy, redactor
+}
+
+func policyKey(tenantID, agentID string) string {
+	return tenantID + "|" + agentID
+}
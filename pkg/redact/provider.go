@@ -0,0 +1,50 @@
+package redact
+
+import (
+	"context"
+
+	"github.com/user/modulox/pkg/llm"
+)
+
+// Provider wraps an llm.Provider and scrubs prompts before they are sent
+type Provider struct {
+	inner    llm.Provider
+	policies *PolicySet
+	agentID  string
+}
+
+// NewProvider creates a redacting provider for the given agent
+func NewProvider(inner llm.Provider, policies *PolicySet, agentID string) *Provider {
+	return &Provider{
+		inner:    inner,
+		policies: policies,
+		agentID:  agentID,
+	}
+}
+
+// Complete implements llm.Provider.Complete
+func (p *Provider) Complete(ctx context.Context, prompt string) (string, error) {
+	prompt, err := p.scrub(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	return p.inner.Complete(ctx, prompt)
+}
+
+// Embed implements llm.Provider.Embed
+func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
+	text, err := p.scrub(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return p.inner.Embed(ctx, text)
+}
+
+func (p *Provider) scrub(ctx context.Context, text string) (string, error) {
+	policy, redactor := p.policies.Resolve(ctx, p.agentID)
+	if !policy.Inputs {
+		return text, nil
+	}
+	redacted, _, err := redactor.Redact(ctx, text)
+	return redacted, err
+}
@@ -0,0 +1,177 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Finding is a span of sensitive data detected in a text
+type Finding struct {
+	Type  string
+	Start int
+	End   int
+	Value string
+}
+
+// Detector finds sensitive data in text. Implementations may use regular
+// expressions, dictionaries, or external NER models.
+type Detector interface {
+	// Detect returns all findings in the given text
+	Detect(ctx context.Context, text string) ([]Finding, error)
+}
+
+// DetectorFunc adapts a function to the Detector interface
+type DetectorFunc func(ctx context.Context, text string) ([]Finding, error)
+
+// Detect implements Detector.Detect
+func (f DetectorFunc) Detect(ctx context.Context, text string) ([]Finding, error) {
+	return f(ctx, text)
+}
+
+// RegexDetector detects sensitive data matching a regular expression
+type RegexDetector struct {
+	Type    string
+	Pattern *regexp.Regexp
+}
+
+// NewRegexDetector creates a detector for the given type and pattern
+func NewRegexDetector(findingType, pattern string) (*RegexDetector, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern for %s: %w", findingType, err)
+	}
+	return &RegexDetector{Type: findingType, Pattern: re}, nil
+}
+
+// Detect implements Detector.Detect
+func (d *RegexDetector) Detect(ctx context.Context, text string) ([]Finding, error) {
+	var findings []Finding
+	for _, loc := range d.Pattern.FindAllStringIndex(text, -1) {
+		findings = append(findings, Finding{
+			Type:  d.Type,
+			Start: loc[0],
+			End:   loc[1],
+			Value: text[loc[0]:loc[1]],
+		})
+	}
+	return findings, nil
+}
+
+// Built-in finding types
+const (
+	TypeEmail      = "EMAIL"
+	TypePhone      = "PHONE"
+	TypeCreditCard = "CREDIT_CARD"
+	TypeSSN        = "SSN"
+	TypeIPAddress  = "IP_ADDRESS"
+
+	// TypeUnknown marks text that could not be scanned
+	TypeUnknown = "UNKNOWN"
+)
+
+// DefaultDetectors returns regex detectors for common PII
+func DefaultDetectors() []Detector {
+	return []Detector{
+		&RegexDetector{Type: TypeEmail, Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+		&RegexDetector{Type: TypeCreditCard, Pattern: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,15}\b`)},
+		&RegexDetector{Type: TypeSSN, Pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+		&RegexDetector{Type: TypePhone, Pattern: regexp.MustCompile(`\+?\d{1,3}?[ .\-]?\(?\d{3}\)?[ .\-]?\d{3}[ .\-]?\d{4}\b`)},
+		&RegexDetector{Type: TypeIPAddress, Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)},
+	}
+}
+
+// Redactor replaces findings from its detectors with placeholders
+type Redactor struct {
+	detectors []Detector
+	mask      func(Finding) string
+}
+
+// NewRedactor creates a new redactor; with no detectors DefaultDetectors is used
+func NewRedactor(detectors ...Detector) *Redactor {
+	if len(detectors) == 0 {
+		detectors = DefaultDetectors()
+	}
+	return &Redactor{
+		detectors: detectors,
+		mask: func(f Finding) string {
+			return "[REDACTED:" + f.Type + "]"
+		},
+	}
+}
+
+// SetMask overrides how findings are replaced
+func (r *Redactor) SetMask(mask func(Finding) string) {
+	r.mask = mask
+}
+
+// Redact replaces all detected sensitive data in the text
+func (r *Redactor) Redact(ctx context.Context, text string) (string, []Finding, error) {
+	var findings []Finding
+	for _, d := range r.detectors {
+		found, err := d.Detect(ctx, text)
+		if err != nil {
+			return "", nil, fmt.Errorf("detector failed: %w", err)
+		}
+		findings = append(findings, found...)
+	}
+	if len(findings) == 0 {
+		return text, nil, nil
+	}
+
+	// Earlier and longer findings win over overlapping ones
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Start == findings[j].Start {
+			return findings[i].End > findings[j].End
+		}
+		return findings[i].Start < findings[j].Start
+	})
+
+	var b strings.Builder
+	applied := make([]Finding, 0, len(findings))
+	pos := 0
+	for _, f := range findings {
+		if f.Start < pos {
+			continue
+		}
+		b.WriteString(text[pos:f.Start])
+		b.WriteString(r.mask(f))
+		pos = f.End
+		applied = append(applied, f)
+	}
+	b.WriteString(text[pos:])
+
+	return b.String(), applied, nil
+}
+
+// RedactString is like Redact but fails closed: on detector errors the whole
+// text is masked as a single finding of type TypeUnknown
+func (r *Redactor) RedactString(ctx context.Context, text string) string {
+	redacted, _, err := r.Redact(ctx, text)
+	if err != nil {
+		if text == "" {
+			return ""
+		}
+		return r.mask(Finding{Type: TypeUnknown, End: len(text), Value: text})
+	}
+	return redacted
+}
+
+// RedactMetadata returns a copy of the metadata with string values redacted
+func (r *Redactor) RedactMetadata(ctx context.Context, metadata map[string]interface{}) map[string]interface{} {
+	if metadata == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		if s, ok := v.(string); ok {
+			redacted[k] = r.RedactString(ctx, s)
+			continue
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
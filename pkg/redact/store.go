@@ -0,0 +1,64 @@
+package redact
+
+import (
+	"context"
+
+	"github.com/user/modulox/pkg/memory"
+	"github.com/user/modulox/pkg/types"
+)
+
+// MaskingStore wraps a memory.VectorStore and masks metadata before storing
+type MaskingStore struct {
+	inner    memory.VectorStore
+	policies *PolicySet
+	agentID  string
+}
+
+// NewMaskingStore creates a masking store for the given agent
+func NewMaskingStore(inner memory.VectorStore, policies *PolicySet, agentID string) *MaskingStore {
+	return &MaskingStore{
+		inner:    inner,
+		policies: policies,
+		agentID:  agentID,
+	}
+}
+
+// Store implements memory.VectorStore.Store
+func (ms *MaskingStore) Store(ctx context.Context, vectors []types.Vector) error {
+	return ms.inner.Store(ctx, ms.mask(ctx, vectors))
+}
+
+// Query implements memory.VectorStore.Query
+func (ms *MaskingStore) Query(ctx context.Context, vector types.Vector, k int) ([]types.Vector, error) {
+	return ms.inner.Query(ctx, vector, k)
+}
+
+// Delete implements memory.VectorStore.Delete
+func (ms *MaskingStore) Delete(ctx context.Context, ids []string) error {
+	return ms.inner.Delete(ctx, ids)
+}
+
+// Update implements memory.VectorStore.Update
+func (ms *MaskingStore) Update(ctx context.Context, vectors []types.Vector) error {
+	return ms.inner.Update(ctx, ms.mask(ctx, vectors))
+}
+
+// Count implements memory.VectorStore.Count
+func (ms *MaskingStore) Count(ctx context.Context) (int, error) {
+	return ms.inner.Count(ctx)
+}
+
+// mask redacts vector metadata when the agent's policy covers memory
+func (ms *MaskingStore) mask(ctx context.Context, vectors []types.Vector) []types.Vector {
+	policy, redactor := ms.policies.Resolve(ctx, ms.agentID)
+	if !policy.Memory {
+		return vectors
+	}
+
+	masked := make([]types.Vector, len(vectors))
+	for i, v := range vectors {
+		v.Metadata = redactor.RedactMetadata(ctx, v.Metadata)
+		masked[i] = v
+	}
+	return masked
+}
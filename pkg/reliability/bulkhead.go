@@ -0,0 +1,250 @@
+package reliability
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BulkheadConfig configures a bulkhead
+type BulkheadConfig struct {
+	// Name identifies the isolated dependency in metrics, e.g. "llm.openai",
+	// "tool.search", or "node.worker-1"
+	Name string
+	// MaxConcurrent bounds the calls running at once (default 10)
+	MaxConcurrent int
+	// MaxQueue bounds the calls waiting for a slot; calls arriving with the
+	// queue full are rejected. Zero rejects calls as soon as all slots are
+	// taken.
+	MaxQueue int
+	// MaxWait bounds how long a queued call waits for a slot; zero waits
+	// until the call's context is done
+	MaxWait time.Duration
+	// Metrics receives bulkhead_active and bulkhead_queued gauges and the
+	// bulkhead_rejected counter, labelled with the bulkhead name
+	Metrics MetricsRecorder
+}
+
+// DefaultBulkheadConfig returns a default bulkhead configuration
+func DefaultBulkheadConfig() BulkheadConfig {
+	return BulkheadConfig{
+		MaxConcurrent: 10,
+		MaxQueue:      10,
+	}
+}
+
+// BulkheadStats describes the load of a bulkhead
+type BulkheadStats struct {
+	Active   int
+	Queued   int
+	Accepted int64
+	Rejected int64
+}
+
+// Bulkhead isolates a dependency by limiting the calls made to it
+// concurrently, so a slow dependency ties up a bounded share of callers
+// instead of all of them. Calls beyond the limit wait in a bounded queue;
+// calls that find the queue full, or wait longer than MaxWait, fail with
+// ErrBulkheadFull.
+type Bulkhead struct {
+	config BulkheadConfig
+	slots  chan struct{}
+	queued int
+	stats  BulkheadStats
+	mu     sync.Mutex
+}
+
+// NewBulkhead creates a bulkhead
+func NewBulkhead(config BulkheadConfig) *Bulkhead {
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 10
+	}
+	if config.MaxQueue < 0 {
+		config.MaxQueue = 0
+	}
+	return &Bulkhead{
+		config: config,
+		slots:  make(chan struct{}, config.MaxConcurrent),
+	}
+}
+
+// Name returns the name of the bulkhead
+func (b *Bulkhead) Name() string {
+	return b.config.Name
+}
+
+// Acquire takes a slot, waiting in the queue if none is free. The returned
+// release function frees the slot and must be called exactly once.
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case b.slots <- struct{}{}:
+		b.admit(ctx)
+		return b.release, nil
+	default:
+	}
+
+	b.mu.Lock()
+	if b.queued >= b.config.MaxQueue {
+		b.mu.Unlock()
+		b.reject(ctx, "queue_full")
+		return nil, ErrBulkheadFull
+	}
+	b.queued++
+	queued := b.queued
+	b.mu.Unlock()
+	b.gauge(ctx, "bulkhead_queued", queued)
+
+	defer func() {
+		b.mu.Lock()
+		b.queued--
+		queued := b.queued
+		b.mu.Unlock()
+		b.gauge(ctx, "bulkhead_queued", queued)
+	}()
+
+	var timeout <-chan time.Time
+	if b.config.MaxWait > 0 {
+		timer := time.NewTimer(b.config.MaxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case b.slots <- struct{}{}:
+		b.admit(ctx)
+		return b.release, nil
+	case <-timeout:
+		b.reject(ctx, "timeout")
+		return nil, ErrBulkheadFull
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Execute runs fn in a slot of the bulkhead
+func (b *Bulkhead) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
+
+// Stats returns the current load and the calls accepted and rejected so far
+func (b *Bulkhead) Stats() BulkheadStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.Active = len(b.slots)
+	stats.Queued = b.queued
+	return stats
+}
+
+// admit counts a call that took a slot
+func (b *Bulkhead) admit(ctx context.Context) {
+	b.mu.Lock()
+	b.stats.Accepted++
+	b.mu.Unlock()
+	b.gauge(ctx, "bulkhead_active", len(b.slots))
+}
+
+// release frees a slot
+func (b *Bulkhead) release() {
+	<-b.slots
+	b.gauge(context.Background(), "bulkhead_active", len(b.slots))
+}
+
+// reject counts a call turned away for reason
+func (b *Bulkhead) reject(ctx context.Context, reason string) {
+	b.mu.Lock()
+	b.stats.Rejected++
+	rejected := b.stats.Rejected
+	b.mu.Unlock()
+
+	if b.config.Metrics != nil {
+		b.config.Metrics(ctx, Metric{
+			Name:    "bulkhead_rejected",
+			Value:   float64(rejected),
+			Labels:  map[string]string{"bulkhead": b.config.Name, "reason": reason},
+			Counter: true,
+		})
+	}
+}
+
+// gauge reports the current value of a gauge
+func (b *Bulkhead) gauge(ctx context.Context, name string, value int) {
+	if b.config.Metrics != nil {
+		b.config.Metrics(ctx, Metric{
+			Name:   name,
+			Value:  float64(value),
+			Labels: map[string]string{"bulkhead": b.config.Name},
+		})
+	}
+}
+
+// Bulkheads keeps one bulkhead per dependency, created on first use with
+// shared defaults
+type Bulkheads struct {
+	defaults  BulkheadConfig
+	bulkheads map[string]*Bulkhead
+	mu        sync.Mutex
+}
+
+// NewBulkheads creates a set of bulkheads configured by defaults
+func NewBulkheads(defaults BulkheadConfig) *Bulkheads {
+	return &Bulkheads{
+		defaults:  defaults,
+		bulkheads: make(map[string]*Bulkhead),
+	}
+}
+
+// SetMetrics sets the metrics recorder of bulkheads created from now on
+func (bs *Bulkheads) SetMetrics(metrics MetricsRecorder) *Bulkheads {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.defaults.Metrics = metrics
+	return bs
+}
+
+// Get returns the bulkhead of a dependency, creating it if needed
+func (bs *Bulkheads) Get(name string) *Bulkhead {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	b, ok := bs.bulkheads[name]
+	if !ok {
+		config := bs.defaults
+		config.Name = name
+		b = NewBulkhead(config)
+		bs.bulkheads[name] = b
+	}
+	return b
+}
+
+// Set installs a bulkhead with its own configuration for a dependency
+func (bs *Bulkheads) Set(name string, config BulkheadConfig) *Bulkhead {
+	config.Name = name
+	b := NewBulkhead(config)
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.bulkheads[name] = b
+	return b
+}
+
+// Stats returns the stats of every bulkhead, by name
+func (bs *Bulkheads) Stats() map[string]BulkheadStats {
+	bs.mu.Lock()
+	bulkheads := make(map[string]*Bulkhead, len(bs.bulkheads))
+	for name, b := range bs.bulkheads {
+		bulkheads[name] = b
+	}
+	bs.mu.Unlock()
+
+	stats := make(map[string]BulkheadStats, len(bulkheads))
+	for name, b := range bulkheads {
+		stats[name] = b.Stats()
+	}
+	return stats
+}
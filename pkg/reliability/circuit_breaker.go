@@ -1,6 +1,8 @@
 package reliability
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
 )
@@ -9,82 +11,406 @@ import (
 type CircuitState int
 
 const (
-	StateClosed CircuitState = iota // Normal operation
-	StateOpen                       // Failing, reject requests
-	StateHalfOpen                   // Testing if service is healthy
+	StateClosed   CircuitState = iota // Normal operation
+	StateOpen                         // Failing, reject requests
+	StateHalfOpen                     // Testing if service is healthy
 )
 
-// CircuitBreaker implements the circuit breaker pattern
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures a circuit breaker. The circuit opens
+// after FailureThreshold consecutive failures, or when the failure rate
+// over the sliding Window reaches FailureRate; either trigger is disabled
+// when zero.
+type CircuitBreakerConfig struct {
+	// Name identifies the breaker in state changes and metrics
+	Name string
+	// FailureThreshold is the number of consecutive failures opening the
+	// circuit (default 5)
+	FailureThreshold int
+	// Window is the period failure rates are measured over, and
+	// FailureRate the share of failed requests in it, from 0 to 1, opening
+	// the circuit once at least MinRequests were made (default 10)
+	Window      time.Duration
+	FailureRate float64
+	MinRequests int
+	// ResetTimeout is how long the circuit stays open before probing the
+	// service (default 30s)
+	ResetTimeout time.Duration
+	// HalfOpenMaxRequests bounds the probes in flight while half-open
+	// (default 1), and SuccessThreshold is the number of successful probes
+	// closing the circuit (default 1). A failed probe reopens it.
+	HalfOpenMaxRequests int
+	SuccessThreshold    int
+	// OnStateChange is called after every state change, outside the
+	// breaker's lock
+	OnStateChange func(name string, from, to CircuitState)
+	// Metrics receives a circuit_breaker_state gauge (0 closed, 1 open,
+	// 2 half-open) and a circuit_breaker_transitions counter, labelled with
+	// the breaker name
+	Metrics MetricsRecorder
+}
+
+// DefaultCircuitBreakerConfig returns a default circuit breaker
+// configuration
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold:    5,
+		MinRequests:         10,
+		ResetTimeout:        30 * time.Second,
+		HalfOpenMaxRequests: 1,
+		SuccessThreshold:    1,
+	}
+}
+
+// windowBuckets is the number of buckets the sliding window is split into
+const windowBuckets = 10
+
+// windowBucket counts the requests of one slice of the sliding window
+type windowBucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+// CircuitBreakerStats describes the state and counts of a circuit breaker
+type CircuitBreakerStats struct {
+	State               CircuitState
+	ConsecutiveFailures int
+	// WindowRequests and WindowFailures count the requests in the sliding
+	// window
+	WindowRequests int
+	WindowFailures int
+	Transitions    int64
+}
+
+// CircuitBreaker implements the circuit breaker pattern. It is safe for
+// concurrent use.
 type CircuitBreaker struct {
-	state           CircuitState
-	failureCount    int
-	failureThreshold int
-	resetTimeout    time.Duration
-	lastFailure     time.Time
-	mu             sync.RWMutex
+	config      CircuitBreakerConfig
+	state       CircuitState
+	failures    int
+	successes   int
+	probes      int
+	openedAt    time.Time
+	buckets     [windowBuckets]windowBucket
+	transitions int64
+	// generation advances with every state change, so results of requests
+	// allowed in an earlier state are ignored
+	generation uint64
+	ignored     []error
+	mu          sync.Mutex
 }
 
-// NewCircuitBreaker creates a new circuit breaker
+// NewCircuitBreaker creates a circuit breaker opening after
+// failureThreshold consecutive failures
 func NewCircuitBreaker(failureThreshold int, resetTimeout time.Duration) *CircuitBreaker {
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = failureThreshold
+	config.ResetTimeout = resetTimeout
+	return NewCircuitBreakerWithConfig(config)
+}
+
+// NewCircuitBreakerWithConfig creates a circuit breaker
+func NewCircuitBreakerWithConfig(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold < 0 {
+		config.FailureThreshold = 0
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.HalfOpenMaxRequests <= 0 {
+		config.HalfOpenMaxRequests = 1
+	}
+	if config.SuccessThreshold <= 0 {
+		config.SuccessThreshold = 1
+	}
 	return &CircuitBreaker{
-		state:            StateClosed,
-		failureThreshold: failureThreshold,
-		resetTimeout:     resetTimeout,
+		config: config,
+		state:  StateClosed,
 	}
 }
 
-// Execute runs the given function with circuit breaker protection
-func (cb *CircuitBreaker) Execute(fn func() error) error {
-	if !cb.AllowRequest() {
-		return ErrCircuitOpen
+// IgnoreErrors excludes classes of errors, matched with errors.Is, from the
+// failure count. Use it for errors caused by the request rather than the
+// service, such as invalid requests or filtered content.
+func (cb *CircuitBreaker) IgnoreErrors(classes ...error) *CircuitBreaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.ignored = append(cb.ignored, classes...)
+	return cb
+}
+
+// Execute runs fn with circuit breaker protection, failing with
+// ErrCircuitOpen while the circuit is open. Failures caused by ctx ending
+// are not counted against the service.
+func (cb *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := cb.Allow()
+	if err != nil {
+		return err
 	}
 
-	err := fn()
-	cb.RecordResult(err)
+	err = fn(ctx)
+	if err != nil && ctx.Err() != nil {
+		done(errNotCounted)
+		return err
+	}
+	done(err)
 	return err
 }
 
-// AllowRequest checks if a request should be allowed
+// errNotCounted releases a permitted request without recording a result
+var errNotCounted = errors.New("request not counted")
+
+// Allow asks to make a request, failing with ErrCircuitOpen while the
+// circuit is open. When permitted, done must be called exactly once with
+// the request's result. Results arriving after the circuit changed state
+// are ignored, so a slow request from before a trip cannot close or
+// reopen it.
+func (cb *CircuitBreaker) Allow() (done func(err error), err error) {
+	cb.mu.Lock()
+	from := cb.state
+	allowed := cb.allow(time.Now())
+	to, generation := cb.state, cb.generation
+	cb.mu.Unlock()
+	cb.changed(from, to)
+
+	if !allowed {
+		return nil, ErrCircuitOpen
+	}
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { cb.result(generation, err) })
+	}, nil
+}
+
+// AllowRequest checks if a request should be allowed. Every permitted
+// request must be followed by exactly one RecordResult: a half-open
+// circuit admits only HalfOpenMaxRequests probes until their results are
+// recorded. Prefer Allow or Execute, which track this themselves.
 func (cb *CircuitBreaker) AllowRequest() bool {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
+	cb.mu.Lock()
+	from := cb.state
+	allowed := cb.allow(time.Now())
+	to := cb.state
+	cb.mu.Unlock()
+	cb.changed(from, to)
+	return allowed
+}
 
-	switch cb.state {
-	case StateClosed:
-		return true
-	case StateOpen:
-		if time.Since(cb.lastFailure) > cb.resetTimeout {
-			cb.mu.RUnlock()
-			cb.mu.Lock()
-			cb.state = StateHalfOpen
-			cb.mu.Unlock()
-			cb.mu.RLock()
-			return true
+// allow decides on a request, moving an open circuit to half-open once
+// the reset timeout has passed. Callers hold the lock.
+func (cb *CircuitBreaker) allow(now time.Time) bool {
+	if cb.state == StateOpen {
+		if now.Sub(cb.openedAt) < cb.config.ResetTimeout {
+			return false
 		}
-		return false
-	case StateHalfOpen:
-		return true
-	default:
-		return false
+		cb.setState(StateHalfOpen)
 	}
+	if cb.state == StateHalfOpen {
+		if cb.probes >= cb.config.HalfOpenMaxRequests {
+			return false
+		}
+		cb.probes++
+	}
+	return true
 }
 
-// RecordResult records the result of a request
+// RecordResult records the result of a request permitted by AllowRequest,
+// counting it against the current state
 func (cb *CircuitBreaker) RecordResult(err error) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	generation := cb.generation
+	cb.mu.Unlock()
+	cb.result(generation, err)
+}
 
-	if err != nil {
-		cb.failureCount++
-		cb.lastFailure = time.Now()
+// result records the result of a request allowed in a generation
+func (cb *CircuitBreaker) result(generation uint64, err error) {
+	cb.mu.Lock()
+	from := cb.state
+	if generation == cb.generation {
+		cb.record(err, time.Now())
+	}
+	to := cb.state
+	cb.mu.Unlock()
+	cb.changed(from, to)
+}
+
+// record counts a result and moves the circuit accordingly. Callers hold
+// the lock.
+func (cb *CircuitBreaker) record(err error, now time.Time) {
+	if cb.state == StateHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+	if err == errNotCounted {
+		return
+	}
+	for _, class := range cb.ignored {
+		if errors.Is(err, class) {
+			return
+		}
+	}
 
-		if cb.state == StateHalfOpen || cb.failureCount >= cb.failureThreshold {
-			cb.state = StateOpen
+	switch cb.state {
+	case StateHalfOpen:
+		if err != nil {
+			cb.trip(now)
+			return
+		}
+		cb.successes++
+		if cb.successes >= cb.config.SuccessThreshold {
+			cb.setState(StateClosed)
+		}
+	case StateClosed:
+		bucket := cb.bucket(now)
+		if err == nil {
+			bucket.successes++
+			cb.failures = 0
+			return
 		}
-	} else {
-		if cb.state == StateHalfOpen {
-			cb.state = StateClosed
-			cb.failureCount = 0
+		bucket.failures++
+		cb.failures++
+		if cb.config.FailureThreshold > 0 && cb.failures >= cb.config.FailureThreshold {
+			cb.trip(now)
+			return
+		}
+		if cb.config.FailureRate > 0 && cb.config.Window > 0 {
+			requests, failures := cb.windowCounts(now)
+			if requests >= cb.config.MinRequests && float64(failures)/float64(requests) >= cb.config.FailureRate {
+				cb.trip(now)
+			}
+		}
+	}
+}
+
+// trip opens the circuit. Callers hold the lock.
+func (cb *CircuitBreaker) trip(now time.Time) {
+	cb.openedAt = now
+	cb.setState(StateOpen)
+}
+
+// setState moves the circuit to state, resetting the counts of the state
+// it leaves. Callers hold the lock.
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	if cb.state == state {
+		return
+	}
+	cb.state = state
+	cb.transitions++
+	cb.generation++
+	cb.failures = 0
+	cb.successes = 0
+	cb.probes = 0
+	if state == StateClosed {
+		cb.buckets = [windowBuckets]windowBucket{}
+	}
+}
+
+// changed reports a state change to the callback and metrics
+func (cb *CircuitBreaker) changed(from, to CircuitState) {
+	if from == to {
+		return
+	}
+	if cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(cb.config.Name, from, to)
+	}
+	if cb.config.Metrics != nil {
+		cb.mu.Lock()
+		transitions := cb.transitions
+		cb.mu.Unlock()
+
+		ctx := context.Background()
+		cb.config.Metrics(ctx, Metric{
+			Name:   "circuit_breaker_state",
+			Value:  float64(to),
+			Labels: map[string]string{"breaker": cb.config.Name},
+		})
+		cb.config.Metrics(ctx, Metric{
+			Name:    "circuit_breaker_transitions",
+			Value:   float64(transitions),
+			Labels:  map[string]string{"breaker": cb.config.Name},
+			Counter: true,
+		})
+	}
+}
+
+// bucket returns the window bucket of now, clearing it if it holds an
+// older slice of time. Callers hold the lock.
+func (cb *CircuitBreaker) bucket(now time.Time) *windowBucket {
+	if cb.config.Window <= 0 {
+		return &cb.buckets[0]
+	}
+	width := cb.config.Window / windowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	start := now.Truncate(width)
+	b := &cb.buckets[(start.UnixNano()/int64(width))%windowBuckets]
+	if !b.start.Equal(start) {
+		*b = windowBucket{start: start}
+	}
+	return b
+}
+
+// windowCounts sums the requests and failures of the sliding window.
+// Callers hold the lock.
+func (cb *CircuitBreaker) windowCounts(now time.Time) (requests, failures int) {
+	if cb.config.Window <= 0 {
+		return 0, 0
+	}
+	for _, b := range cb.buckets {
+		if now.Sub(b.start) < cb.config.Window {
+			requests += b.successes + b.failures
+			failures += b.failures
 		}
 	}
+	return requests, failures
+}
+
+// State returns the current state. An open circuit whose reset timeout has
+// passed reports half-open only once a request probes it.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Stats returns the state and counts of the breaker
+func (cb *CircuitBreaker) Stats() CircuitBreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	requests, failures := cb.windowCounts(time.Now())
+	return CircuitBreakerStats{
+		State:               cb.state,
+		ConsecutiveFailures: cb.failures,
+		WindowRequests:      requests,
+		WindowFailures:      failures,
+		Transitions:         cb.transitions,
+	}
+}
+
+// Reset closes the circuit and clears its counts
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	from := cb.state
+	cb.setState(StateClosed)
+	cb.buckets = [windowBuckets]windowBucket{}
+	cb.failures = 0
+	cb.generation++
+	cb.mu.Unlock()
+	cb.changed(from, StateClosed)
 }
@@ -0,0 +1,128 @@
+package reliability
+
+import (
+	"time"
+
+	"github.com/user/modulox/pkg/config"
+)
+
+// RetryConfigFromConfig returns the retry defaults of Config.Reliability
+func RetryConfigFromConfig(cfg *config.Config) RetryConfig {
+	rc := cfg.Reliability.Retry
+	return RetryConfig{
+		MaxAttempts:   rc.MaxAttempts,
+		InitialDelay:  time.Duration(rc.InitialDelayMs) * time.Millisecond,
+		MaxDelay:      time.Duration(rc.MaxDelayMs) * time.Millisecond,
+		BackoffFactor: rc.BackoffFactor,
+	}
+}
+
+// NewCircuitBreakerFromConfig creates a circuit breaker with the defaults
+// of Config.Reliability
+func NewCircuitBreakerFromConfig(cfg *config.Config) *CircuitBreaker {
+	bc := cfg.Reliability.CircuitBreaker
+	config := DefaultCircuitBreakerConfig()
+	config.FailureThreshold = bc.FailureThreshold
+	config.ResetTimeout = time.Duration(bc.ResetTimeoutMs) * time.Millisecond
+	config.Window = time.Duration(bc.WindowMs) * time.Millisecond
+	config.FailureRate = bc.FailureRate
+	config.MinRequests = bc.MinRequests
+	config.SuccessThreshold = bc.SuccessThreshold
+	config.HalfOpenMaxRequests = bc.HalfOpenMaxRequests
+	return NewCircuitBreakerWithConfig(config)
+}
+
+// NewRateLimiterFromConfig creates the rate limiter of Config.Reliability,
+// or returns nil when no rate is set. The burst defaults to one second's
+// worth of requests.
+func NewRateLimiterFromConfig(cfg *config.Config) *RateLimiter {
+	rc := cfg.Reliability.RateLimit
+	if rc.Rate <= 0 {
+		return nil
+	}
+	burst := rc.Burst
+	if burst <= 0 {
+		burst = int(rc.Rate)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return NewRateLimiter(rc.Rate, burst)
+}
+
+// NewLimiterFromConfig creates the provider rate limit of
+// Config.Reliability.RateLimit: a DistributedRateLimiter sharing the rate
+// through store when distributed is set, otherwise a local RateLimiter. It
+// returns nil when no rate is set.
+func NewLimiterFromConfig(cfg *config.Config, store CounterStore) Limiter {
+	rc := cfg.Reliability.RateLimit
+	if rc.Rate <= 0 {
+		return nil
+	}
+	if !rc.Distributed {
+		return NewRateLimiterFromConfig(cfg)
+	}
+	key := rc.Key
+	if key == "" {
+		key = "llm"
+	}
+	return NewDistributedRateLimiter(store, DistributedRateLimiterConfig{
+		Key:       key,
+		Rate:      rc.Rate,
+		LeaseSize: rc.LeaseSize,
+		FailOpen:  rc.FailOpen,
+	})
+}
+
+// NewBulkheadsFromConfig creates per-dependency bulkheads configured by
+// Config.Reliability.Bulkhead, or returns nil when no limit is set
+func NewBulkheadsFromConfig(cfg *config.Config) *Bulkheads {
+	bc := cfg.Reliability.Bulkhead
+	if bc.MaxConcurrent <= 0 {
+		return nil
+	}
+	return NewBulkheads(BulkheadConfig{
+		MaxConcurrent: bc.MaxConcurrent,
+		MaxQueue:      bc.MaxQueue,
+		MaxWait:       time.Duration(bc.MaxWaitMs) * time.Millisecond,
+	})
+}
+
+// NewPolicyFromConfig creates a policy for the dependency name with the
+// defaults of Config.Reliability: retries and a circuit breaker, plus a
+// rate limit and a bulkhead when configured. Options are applied after, so
+// they can override any layer.
+func NewPolicyFromConfig(cfg *config.Config, name string, options ...PolicyOption) *Policy {
+	defaults := []PolicyOption{
+		WithRetry(RetryConfigFromConfig(cfg)),
+		WithCircuitBreaker(NewCircuitBreakerFromConfig(cfg)),
+	}
+	if limiter := NewRateLimiterFromConfig(cfg); limiter != nil {
+		defaults = append(defaults, WithRateLimit(limiter))
+	}
+	if bulkheads := NewBulkheadsFromConfig(cfg); bulkheads != nil {
+		defaults = append(defaults, WithBulkhead(bulkheads.Get(name)))
+	}
+	return NewPolicy(append(defaults, options...)...)
+}
+
+// NewFaultInjectorFromConfig creates the fault injector of
+// Config.Reliability.Faults, or returns nil when fault injection is not
+// enabled
+func NewFaultInjectorFromConfig(cfg *config.Config) *FaultInjector {
+	fc := cfg.Reliability.Faults
+	if !fc.Enabled {
+		return nil
+	}
+	fi := NewFaultInjector(fc.Seed)
+	for _, target := range fc.Targets {
+		fi.Set(target.Pattern, FaultConfig{
+			ErrorRate:   target.ErrorRate,
+			LatencyRate: target.LatencyRate,
+			Latency:     time.Duration(target.LatencyMs) * time.Millisecond,
+			Jitter:      time.Duration(target.JitterMs) * time.Millisecond,
+			DropRate:    target.DropRate,
+		})
+	}
+	return fi.Enable(true)
+}
@@ -0,0 +1,276 @@
+package reliability
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter is a rate limit callers take tokens from; *RateLimiter and
+// *DistributedRateLimiter implement it
+type Limiter interface {
+	// Allow takes a token if one is available now
+	Allow() bool
+	// WaitN waits for n tokens
+	WaitN(ctx context.Context, n int) error
+}
+
+// CounterStore holds the shared budgets of distributed rate limiters;
+// *communication.AgentClient satisfies it
+type CounterStore interface {
+	// IncrementStateTTL adds delta to a counter, which expires after ttl
+	// when the increment creates it
+	IncrementStateTTL(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	DeleteState(ctx context.Context, key string) error
+}
+
+// DistributedRateLimiterConfig configures a distributed rate limiter
+type DistributedRateLimiterConfig struct {
+	// Key names the shared limit; limiters with the same key share a rate
+	Key string
+	// Rate is the requests per second allowed across all limiters sharing
+	// Key
+	Rate float64
+	// Window is the period budgets are granted for (default 1s). Each
+	// window allows Rate × Window requests; bursts of up to two budgets can
+	// straddle a window boundary.
+	Window time.Duration
+	// LeaseSize is the number of tokens taken from the shared budget at
+	// once (default a tenth of the budget). Larger leases save round trips
+	// to the store; smaller ones share the budget more evenly.
+	LeaseSize int
+	// Prefix is the state namespace of the budgets (default "ratelimit/")
+	Prefix string
+	// StoreTimeout bounds each request to the store (default 1s)
+	StoreTimeout time.Duration
+	// FailOpen allows requests while the store is unreachable instead of
+	// rejecting them
+	FailOpen bool
+}
+
+// DistributedRateLimiter enforces a rate shared by every process using the
+// same CounterStore and key, e.g. all nodes of a cluster calling the same
+// provider. Time is split into fixed windows, each with a budget of
+// Rate × Window tokens counted in the store. Limiters lease tokens from the
+// current window's budget LeaseSize at a time with an atomic increment and
+// hand them out locally, so most requests need no round trip; leased
+// tokens left unused when the window ends are forfeited. Budgets expire
+// from the store two windows after they are created.
+type DistributedRateLimiter struct {
+	config DistributedRateLimiterConfig
+	store  CounterStore
+	budget int64
+	// window is the window the local tokens belong to, and exhausted
+	// records that its shared budget is spent
+	window    int64
+	tokens    int64
+	exhausted bool
+	// unreachable records that the last lease failed
+	unreachable bool
+	mu          sync.Mutex
+}
+
+// NewDistributedRateLimiter creates a limiter sharing config.Rate through
+// store
+func NewDistributedRateLimiter(store CounterStore, config DistributedRateLimiterConfig) *DistributedRateLimiter {
+	if config.Window <= 0 {
+		config.Window = time.Second
+	}
+	if config.Prefix == "" {
+		config.Prefix = "ratelimit/"
+	}
+	if config.StoreTimeout <= 0 {
+		config.StoreTimeout = time.Second
+	}
+	budget := int64(math.Round(config.Rate * config.Window.Seconds()))
+	if budget < 1 {
+		budget = 1
+	}
+	if config.LeaseSize <= 0 {
+		config.LeaseSize = int(budget / 10)
+		if config.LeaseSize < 1 {
+			config.LeaseSize = 1
+		}
+	}
+	if int64(config.LeaseSize) > budget {
+		config.LeaseSize = int(budget)
+	}
+	return &DistributedRateLimiter{
+		config: config,
+		store:  store,
+		budget: budget,
+		window: -1,
+	}
+}
+
+// Allow implements Limiter.Allow, leasing tokens from the store when none
+// are left locally
+func (l *DistributedRateLimiter) Allow() bool {
+	ctx, cancel := l.storeContext(context.Background())
+	defer cancel()
+	allowed, _ := l.take(ctx, time.Now())
+	return allowed
+}
+
+// Prefetch leases tokens from the current window's budget when none are
+// held locally, so TryAllow can hand them out without a round trip, e.g.
+// while the caller holds a lock
+func (l *DistributedRateLimiter) Prefetch(ctx context.Context) error {
+	ctx, cancel := l.storeContext(ctx)
+	defer cancel()
+	window := l.windowOf(time.Now())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(window)
+	if l.tokens > 0 || l.exhausted {
+		return nil
+	}
+	granted, err := l.lease(ctx, window)
+	l.unreachable = err != nil
+	if err != nil {
+		return err
+	}
+	l.tokens, l.exhausted = granted, granted == 0
+	return nil
+}
+
+// TryAllow takes a token held locally without contacting the store. While
+// the store is unreachable it allows requests if FailOpen is set.
+func (l *DistributedRateLimiter) TryAllow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(l.windowOf(time.Now()))
+	if l.tokens > 0 {
+		l.tokens--
+		return true
+	}
+	return l.unreachable && l.config.FailOpen
+}
+
+// WaitN implements Limiter.WaitN, waiting for later windows while the
+// shared budget is spent. Store failures are returned unless FailOpen is
+// set.
+func (l *DistributedRateLimiter) WaitN(ctx context.Context, n int) error {
+	for n > 0 {
+		now := time.Now()
+		storeCtx, cancel := l.storeContext(ctx)
+		allowed, err := l.take(storeCtx, now)
+		cancel()
+		if err != nil && !l.config.FailOpen {
+			return err
+		}
+		if allowed {
+			n--
+			continue
+		}
+
+		// Wait for the next window's budget
+		next := time.Unix(0, (now.UnixNano()/int64(l.config.Window)+1)*int64(l.config.Window))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// take takes a local token, leasing more from the current window's budget
+// when needed
+func (l *DistributedRateLimiter) take(ctx context.Context, now time.Time) (bool, error) {
+	window := l.windowOf(now)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(window)
+	if l.tokens > 0 {
+		l.tokens--
+		return true, nil
+	}
+	if l.exhausted {
+		return false, nil
+	}
+
+	granted, err := l.lease(ctx, window)
+	l.unreachable = err != nil
+	if err != nil {
+		return l.config.FailOpen, err
+	}
+	if granted == 0 {
+		l.exhausted = true
+		return false, nil
+	}
+	l.tokens = granted - 1
+	return true, nil
+}
+
+// windowOf returns the window a time falls in
+func (l *DistributedRateLimiter) windowOf(now time.Time) int64 {
+	return now.UnixNano() / int64(l.config.Window)
+}
+
+// advance drops the local tokens of past windows. The caller holds l.mu.
+func (l *DistributedRateLimiter) advance(window int64) {
+	if window == l.window {
+		return
+	}
+	if l.window >= 0 {
+		l.forget(l.window - 1)
+	}
+	l.window, l.tokens, l.exhausted = window, 0, false
+}
+
+// storeContext bounds a store request by StoreTimeout and the caller's
+// deadline. It carries none of the caller's values: a tenant in ctx would
+// scope the budget's key to that tenant instead of sharing it.
+func (l *DistributedRateLimiter) storeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := l.config.StoreTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining < timeout {
+			timeout = remaining
+		}
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// lease takes up to LeaseSize tokens from a window's shared budget
+func (l *DistributedRateLimiter) lease(ctx context.Context, window int64) (int64, error) {
+	lease := int64(l.config.LeaseSize)
+	total, err := l.store.IncrementStateTTL(ctx, l.key(window), lease, 2*l.config.Window)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lease rate limit tokens: %w", err)
+	}
+	granted := l.budget - (total - lease)
+	if granted > lease {
+		granted = lease
+	}
+	if granted < 0 {
+		granted = 0
+	}
+	return granted, nil
+}
+
+// forget deletes the budget of a past window in the background. Every
+// limiter sharing the key tries; the first succeeds.
+func (l *DistributedRateLimiter) forget(window int64) {
+	key := l.key(window)
+	go func() {
+		ctx, cancel := l.storeContext(context.Background())
+		defer cancel()
+		l.store.DeleteState(ctx, key)
+	}()
+}
+
+// key returns the state key of a window's budget
+func (l *DistributedRateLimiter) key(window int64) string {
+	return l.config.Prefix + l.config.Key + "/" + strconv.FormatInt(window, 10)
+}
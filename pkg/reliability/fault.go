@@ -0,0 +1,259 @@
+package reliability
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FaultConfig configures the faults injected into calls of a target.
+// Rates are shares of calls from 0 to 1, drawn independently.
+type FaultConfig struct {
+	// ErrorRate fails calls with Error without making them
+	ErrorRate float64
+	// Error is returned by failed calls (default ErrFaultInjected)
+	Error error
+	// LatencyRate delays calls by Latency plus up to Jitter
+	LatencyRate float64
+	Latency     time.Duration
+	Jitter      time.Duration
+	// DropRate loses calls: they are not made, and what the caller sees
+	// depends on the call, e.g. a published message silently disappears
+	DropRate float64
+}
+
+// Fault is the outcome drawn for one call. A zero Fault lets the call
+// through untouched.
+type Fault struct {
+	Delay time.Duration
+	Err   error
+	Drop  bool
+}
+
+// FaultStats counts the faults injected into a target
+type FaultStats struct {
+	Calls   int64
+	Delayed int64
+	Failed  int64
+	Dropped int64
+}
+
+// faultRule attaches a fault configuration to a target pattern
+type faultRule struct {
+	pattern string
+	config  FaultConfig
+}
+
+// matches reports whether the rule applies to target. A trailing "*"
+// matches any suffix; "*" alone matches every target.
+func (r faultRule) matches(target string) bool {
+	if strings.HasSuffix(r.pattern, "*") {
+		return strings.HasPrefix(target, strings.TrimSuffix(r.pattern, "*"))
+	}
+	return r.pattern == target
+}
+
+// FaultInjector injects latency, errors, and drops into calls for
+// resilience testing. Calls are identified by target names such as
+// "llm.complete", "tool.calculator", "bus.<topic>", or "rpc.<method>", and
+// configured by pattern; the longest matching pattern applies. Injection
+// is off until Enable is called, and a nil *FaultInjector never injects,
+// so components can hold one unconditionally.
+type FaultInjector struct {
+	rules   []faultRule
+	enabled bool
+	rng     *rand.Rand
+	stats   map[string]*FaultStats
+	mu      sync.Mutex
+}
+
+// NewFaultInjector creates a disabled fault injector. Injectors with the
+// same seed draw the same faults for the same sequence of calls; a zero
+// seed is replaced by the current time.
+func NewFaultInjector(seed int64) *FaultInjector {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &FaultInjector{
+		rng:   rand.New(rand.NewSource(seed)),
+		stats: make(map[string]*FaultStats),
+	}
+}
+
+// Set configures the faults of targets matching pattern, replacing any
+// configuration of the same pattern
+func (fi *FaultInjector) Set(pattern string, config FaultConfig) *FaultInjector {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	for i, r := range fi.rules {
+		if r.pattern == pattern {
+			fi.rules[i].config = config
+			return fi
+		}
+	}
+	fi.rules = append(fi.rules, faultRule{pattern: pattern, config: config})
+	// Longest patterns first, so the most specific rule matches first
+	sort.SliceStable(fi.rules, func(i, j int) bool {
+		return len(fi.rules[i].pattern) > len(fi.rules[j].pattern)
+	})
+	return fi
+}
+
+// Remove drops the configuration of pattern
+func (fi *FaultInjector) Remove(pattern string) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	for i, r := range fi.rules {
+		if r.pattern == pattern {
+			fi.rules = append(fi.rules[:i], fi.rules[i+1:]...)
+			return
+		}
+	}
+}
+
+// Enable turns injection on or off
+func (fi *FaultInjector) Enable(enabled bool) *FaultInjector {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.enabled = enabled
+	return fi
+}
+
+// Enabled reports whether faults are being injected
+func (fi *FaultInjector) Enabled() bool {
+	if fi == nil {
+		return false
+	}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	return fi.enabled
+}
+
+// Inject draws the fault for a call of target
+func (fi *FaultInjector) Inject(target string) Fault {
+	if fi == nil {
+		return Fault{}
+	}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if !fi.enabled {
+		return Fault{}
+	}
+
+	var config FaultConfig
+	matched := false
+	for _, r := range fi.rules {
+		if r.matches(target) {
+			config, matched = r.config, true
+			break
+		}
+	}
+	if !matched {
+		return Fault{}
+	}
+
+	stats := fi.stats[target]
+	if stats == nil {
+		stats = &FaultStats{}
+		fi.stats[target] = stats
+	}
+	stats.Calls++
+
+	var fault Fault
+	if config.LatencyRate > 0 && fi.rng.Float64() < config.LatencyRate {
+		fault.Delay = config.Latency
+		if config.Jitter > 0 {
+			fault.Delay += time.Duration(fi.rng.Int63n(int64(config.Jitter)))
+		}
+		stats.Delayed++
+	}
+	if config.DropRate > 0 && fi.rng.Float64() < config.DropRate {
+		fault.Drop = true
+		stats.Dropped++
+	} else if config.ErrorRate > 0 && fi.rng.Float64() < config.ErrorRate {
+		fault.Err = config.Error
+		if fault.Err == nil {
+			fault.Err = ErrFaultInjected
+		}
+		stats.Failed++
+	}
+	return fault
+}
+
+// Wait sleeps for the fault's delay, returning early with the context's
+// error if ctx is done first
+func (f Fault) Wait(ctx context.Context) error {
+	if f.Delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(f.Delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Lose stands in for a call whose answer never arrives: it waits for ctx
+// to expire when it has a deadline, returning its error, and otherwise
+// returns ErrFaultDropped right away
+func (f Fault) Lose(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); ok {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return ErrFaultDropped
+}
+
+// Apply waits out the fault's delay and returns the error the call should
+// fail with: the injected error, Lose's error for dropped calls, or nil
+func (f Fault) Apply(ctx context.Context) error {
+	if err := f.Wait(ctx); err != nil {
+		return err
+	}
+	if f.Drop {
+		return f.Lose(ctx)
+	}
+	return f.Err
+}
+
+// Do runs fn for target unless the fault drawn for it fails the call, see
+// Fault.Apply
+func (fi *FaultInjector) Do(ctx context.Context, target string, fn func(ctx context.Context) error) error {
+	if err := fi.Inject(target).Apply(ctx); err != nil {
+		return err
+	}
+	return fn(ctx)
+}
+
+// Stats returns the faults injected so far, by target
+func (fi *FaultInjector) Stats() map[string]FaultStats {
+	if fi == nil {
+		return nil
+	}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	stats := make(map[string]FaultStats, len(fi.stats))
+	for target, s := range fi.stats {
+		stats[target] = *s
+	}
+	return stats
+}
+
+// ResetStats clears the injected fault counts
+func (fi *FaultInjector) ResetStats() {
+	if fi == nil {
+		return
+	}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fi.stats = make(map[string]*FaultStats)
+}
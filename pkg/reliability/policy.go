@@ -0,0 +1,255 @@
+package reliability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Metric is a measurement reported by a reliability primitive
+type Metric struct {
+	Name   string
+	Value  float64
+	Labels map[string]string
+	// Counter marks running totals; other metrics are gauges
+	Counter bool
+}
+
+// MetricsRecorder receives the metrics of reliability primitives, e.g.
+// observability.MetricsCollector.ReliabilityRecorder
+type MetricsRecorder func(ctx context.Context, metric Metric)
+
+// PolicyStats counts the calls made through a policy and how each layer
+// treated them
+type PolicyStats struct {
+	// Calls, Successes, and Failures count Execute calls and their outcome
+	Calls     int64
+	Successes int64
+	Failures  int64
+	// Attempts counts the tries made, including retries
+	Attempts int64
+	Retries  int64
+	// CircuitOpen and BulkheadFull count attempts rejected by those layers
+	CircuitOpen  int64
+	BulkheadFull int64
+	// RateLimited counts attempts that had to wait for a token
+	RateLimited int64
+	// Timeouts counts attempts cut off by the policy's timeout
+	Timeouts int64
+}
+
+// timeoutError is returned for attempts cut off by a policy's timeout. It
+// matches ErrTimeout and wraps the error the call returned.
+type timeoutError struct {
+	after time.Duration
+	err   error
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s after %s: %v", ErrTimeout, e.after, e.err)
+}
+
+func (e *timeoutError) Is(target error) bool { return target == ErrTimeout }
+
+func (e *timeoutError) Unwrap() error { return e.err }
+
+// Policy runs calls through retries, a circuit breaker, a rate limiter, a
+// bulkhead, and a timeout, so callers configure the protection of a
+// dependency once instead of nesting the primitives by hand. Layers that
+// are not configured are skipped; the others always apply in this order:
+//
+//	Retry → CircuitBreaker → RateLimit → Bulkhead → Timeout → fn
+//
+// Every attempt is checked against the breaker, then waits for a rate
+// limit token, then for a bulkhead slot, so open circuits spend no tokens
+// and waiting for tokens holds no slot. The timeout bounds the call itself,
+// and its expiry counts as a failure for the breaker and can be retried.
+// Rejections by the rate limiter or the bulkhead, and calls abandoned by
+// the caller, do not count against the breaker, as they say nothing about
+// the dependency's health.
+type Policy struct {
+	name     string
+	retry    *RetryConfig
+	breaker  *CircuitBreaker
+	limiter  Limiter
+	bulkhead *Bulkhead
+	timeout  time.Duration
+	metrics  MetricsRecorder
+	stats    PolicyStats
+	mu       sync.Mutex
+}
+
+// PolicyOption configures a Policy
+type PolicyOption func(*Policy)
+
+// WithRetry retries failed attempts with config
+func WithRetry(config RetryConfig) PolicyOption {
+	return func(p *Policy) {
+		p.retry = &config
+	}
+}
+
+// WithCircuitBreaker fails attempts fast with ErrCircuitOpen while cb is
+// open
+func WithCircuitBreaker(cb *CircuitBreaker) PolicyOption {
+	return func(p *Policy) {
+		p.breaker = cb
+	}
+}
+
+// WithRateLimit makes each attempt wait for a token from limiter, a
+// *RateLimiter or a *DistributedRateLimiter shared across processes
+func WithRateLimit(limiter Limiter) PolicyOption {
+	return func(p *Policy) {
+		p.limiter = limiter
+	}
+}
+
+// WithBulkhead limits concurrent attempts with b
+func WithBulkhead(b *Bulkhead) PolicyOption {
+	return func(p *Policy) {
+		p.bulkhead = b
+	}
+}
+
+// WithTimeout bounds each attempt to timeout; timed out attempts fail with
+// an error matching ErrTimeout
+func WithTimeout(timeout time.Duration) PolicyOption {
+	return func(p *Policy) {
+		p.timeout = timeout
+	}
+}
+
+// WithMetrics reports the policy's counters to metrics as policy_calls,
+// policy_failures, policy_retries, policy_rejected (by reason),
+// policy_rate_limited, and policy_timeouts, labelled with the policy name
+func WithMetrics(name string, metrics MetricsRecorder) PolicyOption {
+	return func(p *Policy) {
+		p.name = name
+		p.metrics = metrics
+	}
+}
+
+// NewPolicy creates a policy from options; without any, calls run
+// unprotected
+func NewPolicy(options ...PolicyOption) *Policy {
+	p := &Policy{}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// Execute runs fn under the policy
+func (p *Policy) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	p.count(ctx, "policy_calls", nil, func(s *PolicyStats) int64 { s.Calls++; return s.Calls })
+
+	var err error
+	if p.retry == nil {
+		err = p.attempt(ctx, fn)
+	} else {
+		first := true
+		err = Retry(ctx, func() error {
+			if !first {
+				p.count(ctx, "policy_retries", nil, func(s *PolicyStats) int64 { s.Retries++; return s.Retries })
+			}
+			first = false
+			return p.attempt(ctx, fn)
+		}, *p.retry)
+	}
+
+	if err != nil {
+		p.count(ctx, "policy_failures", nil, func(s *PolicyStats) int64 { s.Failures++; return s.Failures })
+	} else {
+		p.count(ctx, "", nil, func(s *PolicyStats) int64 { s.Successes++; return s.Successes })
+	}
+	return err
+}
+
+// attempt makes a single try through the breaker, rate limiter, bulkhead,
+// and timeout
+func (p *Policy) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.count(ctx, "", nil, func(s *PolicyStats) int64 { s.Attempts++; return s.Attempts })
+
+	done := func(error) {}
+	if p.breaker != nil {
+		var err error
+		if done, err = p.breaker.Allow(); err != nil {
+			p.count(ctx, "policy_rejected", map[string]string{"reason": "circuit_open"},
+				func(s *PolicyStats) int64 { s.CircuitOpen++; return s.CircuitOpen })
+			return err
+		}
+	}
+	if p.limiter != nil && !p.limiter.Allow() {
+		p.count(ctx, "policy_rate_limited", nil, func(s *PolicyStats) int64 { s.RateLimited++; return s.RateLimited })
+		if err := p.limiter.WaitN(ctx, 1); err != nil {
+			done(errNotCounted)
+			return err
+		}
+	}
+	if p.bulkhead != nil {
+		release, err := p.bulkhead.Acquire(ctx)
+		if err != nil {
+			if errors.Is(err, ErrBulkheadFull) {
+				p.count(ctx, "policy_rejected", map[string]string{"reason": "bulkhead_full"},
+					func(s *PolicyStats) int64 { s.BulkheadFull++; return s.BulkheadFull })
+			}
+			done(errNotCounted)
+			return err
+		}
+		defer release()
+	}
+
+	err := p.call(ctx, fn)
+	if err != nil && ctx.Err() != nil {
+		done(errNotCounted)
+	} else {
+		done(err)
+	}
+	return err
+}
+
+// call runs fn, bounded by the policy's timeout
+func (p *Policy) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if p.timeout <= 0 {
+		return fn(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	err := fn(attemptCtx)
+	if err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		p.count(ctx, "policy_timeouts", nil, func(s *PolicyStats) int64 { s.Timeouts++; return s.Timeouts })
+		return &timeoutError{after: p.timeout, err: err}
+	}
+	return err
+}
+
+// count updates a counter of the stats and reports its new value as the
+// named metric, unless name is empty
+func (p *Policy) count(ctx context.Context, name string, labels map[string]string, update func(*PolicyStats) int64) {
+	p.mu.Lock()
+	value := update(&p.stats)
+	p.mu.Unlock()
+
+	if name == "" || p.metrics == nil {
+		return
+	}
+	metricLabels := map[string]string{"policy": p.name}
+	for k, v := range labels {
+		metricLabels[k] = v
+	}
+	p.metrics(ctx, Metric{Name: name, Value: float64(value), Labels: metricLabels, Counter: true})
+}
+
+// Stats returns the policy's counters
+func (p *Policy) Stats() PolicyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
@@ -59,6 +59,49 @@ func (rl *RateLimiter) WaitN(ctx context.Context, n int) error {
 	return nil
 }
 
+// ReloadableLimiter delegates to a limiter that can be replaced while it
+// is in use, e.g. when config.Watcher reports a changed rate limit. A nil
+// limiter allows every request.
+type ReloadableLimiter struct {
+	inner Limiter
+	mu    sync.RWMutex
+}
+
+// NewReloadableLimiter creates a limiter delegating to inner until the
+// next Swap
+func NewReloadableLimiter(inner Limiter) *ReloadableLimiter {
+	return &ReloadableLimiter{inner: inner}
+}
+
+// Swap replaces the limiter later requests take tokens from
+func (rl *ReloadableLimiter) Swap(inner Limiter) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.inner = inner
+}
+
+// current returns the limiter requests take tokens from
+func (rl *ReloadableLimiter) current() Limiter {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.inner
+}
+
+// Allow implements Limiter.Allow
+func (rl *ReloadableLimiter) Allow() bool {
+	inner := rl.current()
+	return inner == nil || inner.Allow()
+}
+
+// WaitN implements Limiter.WaitN
+func (rl *ReloadableLimiter) WaitN(ctx context.Context, n int) error {
+	inner := rl.current()
+	if inner == nil {
+		return nil
+	}
+	return inner.WaitN(ctx, n)
+}
+
 func min(a, b float64) float64 {
 	if a < b {
 		return a
@@ -2,7 +2,7 @@ package reliability
 
 import (
 	"context"
-	"math"
+	"errors"
 	"time"
 )
 
@@ -13,6 +13,31 @@ type RetryConfig struct {
 	MaxDelay         time.Duration
 	BackoffFactor    float64
 	RetryableErrors  []error
+	// MaxRetryAfter caps the delay a server may request through
+	// RetryAfterError (0 = no cap)
+	MaxRetryAfter time.Duration
+	// Policies override the behavior for classes of errors; the first
+	// policy matching an error applies
+	Policies []ClassPolicy
+}
+
+// ClassPolicy configures retries for errors matching a class
+type ClassPolicy struct {
+	// Class is matched against errors with errors.Is
+	Class error
+	// NoRetry fails immediately on errors of the class
+	NoRetry bool
+	// MaxAttempts and InitialDelay override the config when non-zero
+	MaxAttempts  int
+	InitialDelay time.Duration
+}
+
+// RetryAfterError is implemented by errors carrying a server-requested
+// delay, such as a Retry-After header. Retry waits at least that long, up
+// to RetryConfig.MaxRetryAfter.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
 }
 
 // DefaultRetryConfig returns a default retry configuration
@@ -22,32 +47,60 @@ func DefaultRetryConfig() RetryConfig {
 		InitialDelay:  100 * time.Millisecond,
 		MaxDelay:      10 * time.Second,
 		BackoffFactor: 2.0,
+		MaxRetryAfter: time.Minute,
 	}
 }
 
 // Retry executes the function with retry logic
 func Retry(ctx context.Context, fn func() error, config RetryConfig) error {
 	var err error
+	var class error
 	delay := config.InitialDelay
 
-	for attempt := 1; attempt <= config.MaxAttempts; attempt++ {
+	for attempt := 1; ; attempt++ {
 		err = fn()
 		if err == nil {
 			return nil
 		}
 
-		if !isRetryable(err, config.RetryableErrors) {
+		policy, matched := policyFor(err, config.Policies)
+		if matched && policy.NoRetry {
+			return err
+		}
+		if !matched && !isRetryable(err, config.RetryableErrors) {
 			return err
 		}
 
-		if attempt == config.MaxAttempts {
+		// Each class starts its own backoff from its initial delay
+		maxAttempts := config.MaxAttempts
+		if matched {
+			if policy.Class != class {
+				class = policy.Class
+				if policy.InitialDelay > 0 {
+					delay = policy.InitialDelay
+				}
+			}
+			if policy.MaxAttempts > 0 {
+				maxAttempts = policy.MaxAttempts
+			}
+		}
+		if attempt >= maxAttempts {
 			break
 		}
 
+		wait := delay
+		var retryAfter RetryAfterError
+		if errors.As(err, &retryAfter) && retryAfter.RetryAfter() > wait {
+			wait = retryAfter.RetryAfter()
+			if config.MaxRetryAfter > 0 && wait > config.MaxRetryAfter {
+				wait = config.MaxRetryAfter
+			}
+		}
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(delay):
+		case <-time.After(wait):
 			delay = time.Duration(float64(delay) * config.BackoffFactor)
 			if delay > config.MaxDelay {
 				delay = config.MaxDelay
@@ -58,13 +111,23 @@ func Retry(ctx context.Context, fn func() error, config RetryConfig) error {
 	return err
 }
 
+// policyFor returns the first policy whose class matches the error
+func policyFor(err error, policies []ClassPolicy) (ClassPolicy, bool) {
+	for _, policy := range policies {
+		if errors.Is(err, policy.Class) {
+			return policy, true
+		}
+	}
+	return ClassPolicy{}, false
+}
+
 // isRetryable checks if an error should be retried
 func isRetryable(err error, retryableErrors []error) bool {
 	if len(retryableErrors) == 0 {
 		return true
 	}
 	for _, retryableErr := range retryableErrors {
-		if err == retryableErr {
+		if errors.Is(err, retryableErr) {
 			return true
 		}
 	}
@@ -79,4 +142,12 @@ func (e ReliabilityError) Error() string { return string(e) }
 const (
 	ErrCircuitOpen = ReliabilityError("circuit breaker is open")
 	ErrRateLimited = ReliabilityError("rate limit exceeded")
+	// ErrTimeout is matched by attempts cut off by a Policy's timeout
+	ErrTimeout = ReliabilityError("call timed out")
+	// ErrBulkheadFull is returned for calls rejected by a Bulkhead
+	ErrBulkheadFull = ReliabilityError("bulkhead is full")
+	// ErrFaultInjected is the default error of calls failed by a
+	// FaultInjector; ErrFaultDropped is returned for dropped calls
+	ErrFaultInjected = ReliabilityError("injected fault")
+	ErrFaultDropped  = ReliabilityError("call dropped by fault injection")
 )
@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/distributed"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// ClusterAdmin drains nodes and lists queued tasks; *distributed.Cluster
+// satisfies it
+type ClusterAdmin interface {
+	DrainNode(ctx context.Context, id string) error
+	ListTasks(state distributed.TaskState) ([]distributed.QueuedTask, error)
+}
+
+// clusterRoutes returns the cluster management routes, when configured
+func (s *Server) clusterRoutes() []route {
+	if s.config.Cluster == nil {
+		return nil
+	}
+	return []route{
+		{http.MethodPost, "/v1/cluster/nodes/{id}/drain", "Drain a node and remove it from the cluster", nil, DrainResponse{}, http.StatusOK, nil, s.handleDrainNode},
+		{http.MethodGet, "/v1/cluster/tasks", "List queued tasks", nil, []TaskInfo{}, http.StatusOK, []string{"state"}, s.handleListTasks},
+	}
+}
+
+// DrainResponse is the result of a node drain. TimedOut is set when tasks
+// still running at the drain timeout were retried on other nodes.
+type DrainResponse struct {
+	Node     string `json:"node"`
+	TimedOut bool   `json:"timed_out"`
+}
+
+// TaskInfo describes a task in the cluster queue
+type TaskInfo struct {
+	ID        string    `json:"id"`
+	State     string    `json:"state"`
+	Task      string    `json:"task"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	NodeID    string    `json:"node_id,omitempty"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// handleDrainNode drains a node, responding once it has been removed. The
+// drain is aborted and the node kept if the client disconnects first.
+func (s *Server) handleDrainNode(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	id := params["id"]
+	if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionAdminister, Resource: auth.ResourceCluster, Name: id}) {
+		return
+	}
+
+	err := s.config.Cluster.DrainNode(r.Context(), id)
+	if err != nil && !errors.Is(err, distributed.ErrDrainTimeout) {
+		writeError(w, statusFor(err), fmt.Errorf("failed to drain node: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, DrainResponse{Node: id, TimedOut: err != nil})
+}
+
+func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceCluster, Name: "tasks"}) {
+		return
+	}
+
+	state := distributed.TaskState(r.URL.Query().Get("state"))
+	switch state {
+	case "", distributed.TaskPending, distributed.TaskRunning, distributed.TaskCompleted, distributed.TaskFailed:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown task state %q", state))
+		return
+	}
+
+	tasks, err := s.config.Cluster.ListTasks(state)
+	if err != nil {
+		writeError(w, statusFor(err), fmt.Errorf("failed to list tasks: %w", err))
+		return
+	}
+	// Callers see their own tenant's tasks; admins see every tenant's
+	admin := s.config.Authorizer == nil || s.config.Authorizer.IsAdmin(r.Context())
+	tenantID := tenant.IDFromContext(r.Context())
+	infos := make([]TaskInfo, 0, len(tasks))
+	for _, t := range tasks {
+		if !admin && t.Requirements.TenantID != tenantID {
+			continue
+		}
+		infos = append(infos, TaskInfo{
+			ID:        t.ID,
+			State:     string(t.State),
+			Task:      t.Task,
+			AgentID:   t.Requirements.AgentID,
+			NodeID:    t.NodeID,
+			Attempts:  t.Attempts,
+			Error:     t.Error,
+			CreatedAt: t.CreatedAt,
+			UpdatedAt: t.UpdatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
@@ -0,0 +1,406 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/communication"
+	pb "github.com/user/modulox/pkg/pb"
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// WorkflowRunner executes registered workflows by name;
+// *workflow.Coordinator satisfies it
+type WorkflowRunner interface {
+	ExecuteWorkflow(ctx context.Context, name, task string) (string, error)
+	// SpecDigest returns the digest of the spec a workflow was registered
+	// from, if any
+	SpecDigest(name string) (string, bool)
+}
+
+// gatewayRoutes returns the routes forwarding to the agent service and
+// workflow runner, when configured
+func (s *Server) gatewayRoutes() []route {
+	var routes []route
+	if s.config.AgentService != nil {
+		routes = append(routes,
+			route{http.MethodPost, "/v1/agents/{agent}/execute", "Execute a task on an agent", ExecuteRequest{}, ExecuteResponse{}, http.StatusOK, nil, s.handleExecute},
+			route{http.MethodPost, "/v1/events", "Publish an event", Event{}, nil, http.StatusAccepted, nil, s.handlePublishEvent},
+			route{http.MethodGet, "/v1/events", "Stream events as Server-Sent Events", nil, Event{}, http.StatusOK, []string{"type", "agent_id"}, s.handleStreamEvents},
+			route{http.MethodGet, "/v1/state", "List state keys", nil, []string{}, http.StatusOK, []string{"prefix"}, s.handleListState},
+			route{http.MethodPut, "/v1/state/{key...}", "Set a state value", StateRequest{}, StateResponse{}, http.StatusOK, nil, s.handleSetState},
+			route{http.MethodDelete, "/v1/state/{key...}", "Delete a state value", nil, nil, http.StatusNoContent, nil, s.handleDeleteState},
+			route{http.MethodGet, "/v1/cluster", "Describe the cluster topology", nil, types.ClusterTopology{}, http.StatusOK, nil, s.handleDescribeCluster},
+		)
+	}
+	if s.config.Workflows != nil {
+		routes = append(routes,
+			route{http.MethodPost, "/v1/workflows/{name}/executions", "Execute a workflow", WorkflowRequest{}, WorkflowResponse{}, http.StatusOK, nil, s.handleExecuteWorkflow},
+		)
+	}
+	return routes
+}
+
+// ExecuteRequest is the body of a task execution call
+type ExecuteRequest struct {
+	Task     string            `json:"task"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ExecuteResponse is the result of a task execution call
+type ExecuteResponse struct {
+	AgentID  string            `json:"agent_id"`
+	Result   string            `json:"result"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Event is a published or streamed event
+type Event struct {
+	Type      string            `json:"type"`
+	Payload   string            `json:"payload,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// StateRequest is the body of a state write. A non-zero version makes the
+// write conditional on the current version; TTL is a duration such as "30s".
+type StateRequest struct {
+	Value   string `json:"value"`
+	Version int64  `json:"version,omitempty"`
+	TTL     string `json:"ttl,omitempty"`
+}
+
+// StateResponse is the result of a state write
+type StateResponse struct {
+	Key     string `json:"key"`
+	Version int64  `json:"version"`
+}
+
+// WorkflowRequest is the body of a workflow execution call
+type WorkflowRequest struct {
+	Task string `json:"task"`
+	// SpecDigest, when set, must match the digest of the spec the server's
+	// workflow was registered from
+	SpecDigest string `json:"spec_digest,omitempty"`
+}
+
+// WorkflowResponse is the result of a workflow execution call
+type WorkflowResponse struct {
+	Workflow string `json:"workflow"`
+	Result   string `json:"result"`
+}
+
+func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	agentID := params["agent"]
+	if !s.authorize(w, r, auth.ActionExecute, agentID) {
+		return
+	}
+
+	var req ExecuteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Task == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("task is required"))
+		return
+	}
+
+	resp, err := s.config.AgentService.Execute(outgoing(r), &pb.ExecuteRequest{
+		AgentId:  agentID,
+		Task:     req.Task,
+		Metadata: req.Metadata,
+	})
+	if err != nil {
+		writeError(w, grpcStatus(err), fmt.Errorf("failed to execute task: %w", err))
+		return
+	}
+	if resp.Error != "" {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to execute task: %s", resp.Error))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ExecuteResponse{AgentID: agentID, Result: resp.Result, Metadata: resp.Metadata})
+}
+
+func (s *Server) handlePublishEvent(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	var event Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if event.Type == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("type is required"))
+		return
+	}
+	if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionWrite, Resource: auth.ResourceEvent, Name: event.Type}) {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	resp, err := s.config.AgentService.PublishEvent(outgoing(r), &pb.Event{
+		Type:        event.Type,
+		Payload:     event.Payload,
+		SourceAgent: event.Source,
+		Timestamp:   event.Timestamp.Unix(),
+		Metadata:    event.Metadata,
+	})
+	if err != nil {
+		writeError(w, grpcStatus(err), fmt.Errorf("failed to publish event: %w", err))
+		return
+	}
+	if !resp.Success {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to publish event: %s", resp.Error))
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleStreamEvents streams events of the requested types, all types when
+// none are given, as "event" Server-Sent Events until the client disconnects
+func (s *Server) handleStreamEvents(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	query := r.URL.Query()
+	for _, eventType := range query["type"] {
+		if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceEvent, Name: eventType}) {
+			return
+		}
+	}
+	agentID, err := s.streamAgent(r, query.Get("agent_id"))
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	stream, err := s.config.AgentService.StreamEvents(outgoing(r), &pb.EventRequest{
+		AgentId:    agentID,
+		EventTypes: query["type"],
+	})
+	if err != nil {
+		writeError(w, grpcStatus(err), fmt.Errorf("failed to stream events: %w", err))
+		return
+	}
+	// The server rejects unauthorized subscriptions before sending headers
+	if _, err := stream.Header(); err != nil {
+		writeError(w, grpcStatus(err), fmt.Errorf("failed to stream events: %w", err))
+		return
+	}
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF || r.Context().Err() != nil {
+			return
+		}
+		if err != nil {
+			sse.Send("error", ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err := sse.Send("event", Event{
+			Type:      event.Type,
+			Payload:   event.Payload,
+			Source:    event.SourceAgent,
+			Metadata:  event.Metadata,
+			Timestamp: time.Unix(event.Timestamp, 0),
+		}); err != nil {
+			return
+		}
+	}
+}
+
+// streamAgent returns the agent whose events the caller streams: the
+// agent it acts as, or any requested agent for admins
+func (s *Server) streamAgent(r *http.Request, requested string) (string, error) {
+	if s.config.Authorizer == nil || s.config.Authorizer.IsAdmin(r.Context()) {
+		return requested, nil
+	}
+	p, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return "", auth.ErrUnauthenticated
+	}
+	agentID := p.Metadata[auth.AgentIDKey]
+	if agentID == "" {
+		agentID = p.ID
+	}
+	if requested != "" && requested != agentID {
+		return "", fmt.Errorf("%w: %s may not stream the events of agent %s", auth.ErrPermissionDenied, p.ID, requested)
+	}
+	return agentID, nil
+}
+
+func (s *Server) handleListState(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	prefix := r.URL.Query().Get("prefix")
+	if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceState, Name: prefix + auth.Wildcard}) {
+		return
+	}
+
+	ctx := metadata.AppendToOutgoingContext(outgoing(r), communication.StateOpHeader, "list")
+	resp, err := s.config.AgentService.SyncState(ctx, &pb.SyncRequest{Key: prefix})
+	if err != nil {
+		writeError(w, grpcStatus(err), err)
+		return
+	}
+	if !resp.Success {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%s", resp.Error))
+		return
+	}
+
+	keys := resp.Keys
+	if keys == nil {
+		keys = []string{}
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (s *Server) handleSetState(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	key := params["key"]
+	if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionWrite, Resource: auth.ResourceState, Name: key}) {
+		return
+	}
+
+	var req StateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	ctx := outgoing(r)
+	if req.TTL != "" {
+		if _, err := time.ParseDuration(req.TTL); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid ttl: %q", req.TTL))
+			return
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, communication.StateTTLHeader, req.TTL)
+	}
+
+	version, err := s.syncState(ctx, &pb.SyncRequest{Key: key, Value: req.Value, Version: req.Version})
+	if err != nil {
+		writeError(w, grpcStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, StateResponse{Key: key, Version: version})
+}
+
+func (s *Server) handleDeleteState(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	key := params["key"]
+	if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionWrite, Resource: auth.ResourceState, Name: key}) {
+		return
+	}
+
+	ctx := metadata.AppendToOutgoingContext(outgoing(r), communication.StateOpHeader, string(communication.OpDelete))
+	if _, err := s.syncState(ctx, &pb.SyncRequest{Key: key}); err != nil {
+		writeError(w, grpcStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// syncState calls SyncState, turning unsuccessful responses into errors.
+// Version conflicts keep their gRPC Aborted code.
+func (s *Server) syncState(ctx context.Context, req *pb.SyncRequest, opts ...grpc.CallOption) (int64, error) {
+	resp, err := s.config.AgentService.SyncState(ctx, req, opts...)
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Success {
+		return 0, status.Error(codes.InvalidArgument, resp.Error)
+	}
+	return resp.Version, nil
+}
+
+func (s *Server) handleDescribeCluster(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionRead, Resource: auth.ResourceCluster, Name: "topology"}) {
+		return
+	}
+
+	resp, err := s.config.AgentService.DescribeCluster(outgoing(r), &pb.DescribeClusterRequest{})
+	if err != nil {
+		writeError(w, grpcStatus(err), fmt.Errorf("failed to describe cluster: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, communication.TopologyFromPB(resp))
+}
+
+func (s *Server) handleExecuteWorkflow(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	name := params["name"]
+	if !s.authorizePermission(w, r, auth.Permission{Action: auth.ActionExecute, Resource: auth.ResourceWorkflow, Name: name}) {
+		return
+	}
+
+	var req WorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Task == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("task is required"))
+		return
+	}
+	if req.SpecDigest != "" {
+		if digest, ok := s.config.Workflows.SpecDigest(name); !ok || digest != req.SpecDigest {
+			writeError(w, http.StatusConflict, fmt.Errorf("workflow %s on the server differs from the requested spec", name))
+			return
+		}
+	}
+
+	result, err := s.config.Workflows.ExecuteWorkflow(r.Context(), name, req.Task)
+	if err != nil {
+		writeError(w, statusFor(err), fmt.Errorf("failed to execute workflow: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, WorkflowResponse{Workflow: name, Result: result})
+}
+
+// outgoing forwards the caller's credentials and tenant to the agent
+// service, which enforces its own authorization
+func outgoing(r *http.Request) context.Context {
+	ctx := r.Context()
+	if header := r.Header.Get("Authorization"); header != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", header)
+	}
+	if id, ok := tenant.FromContext(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, communication.TenantHeader, id)
+	}
+	return ctx
+}
+
+// grpcStatus maps gRPC status codes to HTTP status codes
+func grpcStatus(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
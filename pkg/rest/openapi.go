@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/user/modulox/pkg/jsonschema"
+	"github.com/user/modulox/pkg/session"
+)
+
+// route describes a single API endpoint; it drives both dispatch and the OpenAPI document
+type route struct {
+	method   string
+	pattern  string
+	summary  string
+	request  interface{}
+	response interface{}
+	status   int
+	// query names the query parameters the route accepts
+	query   []string
+	handler func(http.ResponseWriter, *http.Request, map[string]string)
+}
+
+// buildRoutes returns the route table served by the API
+func (s *Server) buildRoutes() []route {
+	routes := []route{
+		{http.MethodGet, "/v1/agents", "List registered agents", nil, []AgentInfo{}, http.StatusOK, nil, s.handleListAgents},
+		{http.MethodPost, "/v1/agents/{agent}/completions", "Run a completion against an agent", CompletionRequest{}, CompletionResponse{}, http.StatusOK, nil, s.handleCompletion},
+		{http.MethodGet, "/v1/sessions", "List sessions", nil, []session.Session{}, http.StatusOK, nil, s.handleListSessions},
+		{http.MethodPost, "/v1/sessions", "Create a session", CreateSessionRequest{}, session.Session{}, http.StatusCreated, nil, s.handleCreateSession},
+		{http.MethodGet, "/v1/sessions/{id}", "Get a session", nil, session.Session{}, http.StatusOK, nil, s.handleGetSession},
+		{http.MethodDelete, "/v1/sessions/{id}", "Delete a session", nil, nil, http.StatusNoContent, nil, s.handleDeleteSession},
+		{http.MethodGet, "/v1/openapi.json", "OpenAPI document", nil, nil, http.StatusOK, nil, s.handleOpenAPI},
+	}
+	routes = append(routes, s.gatewayRoutes()...)
+	return append(routes, s.clusterRoutes()...)
+}
+
+// paramName returns the name of a path parameter segment; "{name...}"
+// segments match the rest of the path, slashes included
+func paramName(seg string) (name string, rest bool, ok bool) {
+	if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+		return "", false, false
+	}
+	name = seg[1 : len(seg)-1]
+	if strings.HasSuffix(name, "...") {
+		return strings.TrimSuffix(name, "..."), true, true
+	}
+	return name, false, true
+}
+
+// match checks a request path against the route pattern and extracts path parameters
+func (rt route) match(path string) (map[string]string, bool) {
+	want := strings.Split(strings.Trim(rt.pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if _, rest, _ := paramName(want[len(want)-1]); rest && len(got) > len(want) {
+		got = append(got[:len(want)-1], strings.Join(got[len(want)-1:], "/"))
+	}
+	if len(want) != len(got) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, seg := range want {
+		if name, _, ok := paramName(seg); ok {
+			if got[i] == "" {
+				return nil, false
+			}
+			params[name] = got[i]
+			continue
+		}
+		if seg != got[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// OpenAPI generates an OpenAPI 3 document from the route table
+func (s *Server) OpenAPI() map[string]interface{} {
+	paths := make(map[string]interface{})
+
+	for _, rt := range s.routes {
+		op := map[string]interface{}{
+			"summary": rt.summary,
+		}
+
+		var parameters []interface{}
+		segments := strings.Split(rt.pattern, "/")
+		for i, seg := range segments {
+			if name, _, ok := paramName(seg); ok {
+				segments[i] = "{" + name + "}"
+				parameters = append(parameters, map[string]interface{}{
+					"name":     name,
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string"},
+				})
+			}
+		}
+		pattern := strings.Join(segments, "/")
+		for _, name := range rt.query {
+			parameters = append(parameters, map[string]interface{}{
+				"name":   name,
+				"in":     "query",
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			op["parameters"] = parameters
+		}
+
+		if rt.request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": jsonschema.ForType(reflect.TypeOf(rt.request)),
+					},
+				},
+			}
+		}
+
+		response := map[string]interface{}{"description": http.StatusText(rt.status)}
+		if rt.response != nil {
+			content := map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": jsonschema.ForType(reflect.TypeOf(rt.response)),
+				},
+			}
+			if _, ok := rt.request.(CompletionRequest); ok {
+				content["text/event-stream"] = map[string]interface{}{
+					"schema": map[string]interface{}{"type": "string"},
+				}
+			}
+			// Event streams are only served as Server-Sent Events
+			if _, ok := rt.response.(Event); ok {
+				content = map[string]interface{}{
+					"text/event-stream": map[string]interface{}{
+						"schema": jsonschema.ForType(reflect.TypeOf(rt.response)),
+					},
+				}
+			}
+			response["content"] = content
+		}
+		op["responses"] = map[string]interface{}{
+			strconv.Itoa(rt.status): response,
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": jsonschema.ForType(reflect.TypeOf(ErrorResponse{})),
+					},
+				},
+			},
+		}
+
+		item, ok := paths[pattern].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[pattern] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   s.config.Title,
+			"version": s.config.Version,
+		},
+		"paths": paths,
+	}
+}
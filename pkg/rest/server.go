@@ -0,0 +1,586 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/distributed"
+	"github.com/user/modulox/pkg/llm"
+	pb "github.com/user/modulox/pkg/pb"
+	"github.com/user/modulox/pkg/reliability"
+	"github.com/user/modulox/pkg/session"
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/types"
+)
+
+// ServerConfig contains configuration for the REST API server
+type ServerConfig struct {
+	Title    string
+	Version  string
+	Sessions session.Store
+
+	// Tenants enables quota enforcement; requests must name a registered tenant
+	Tenants *tenant.Manager
+
+	// Identity and Authorizer enable authentication and RBAC enforcement
+	Identity   auth.IdentitySource
+	Authorizer *auth.Authorizer
+
+	// AgentService enables the gateway routes for task execution, events,
+	// and state, e.g. pb.NewAgentServiceClient(conn). Callers' credentials
+	// and tenant are forwarded with each call.
+	AgentService pb.AgentServiceClient
+	// Workflows enables the workflow execution route
+	Workflows WorkflowRunner
+	// Cluster enables the node drain and task listing routes
+	Cluster ClusterAdmin
+}
+
+// TenantHeader is the HTTP header carrying the tenant ID
+const TenantHeader = "X-Tenant-ID"
+
+// Server exposes registered agents and their sessions over HTTP
+type Server struct {
+	config   ServerConfig
+	agents   map[string]agent.Agent
+	sessions session.Store
+	routes   []route
+	server   *http.Server
+	mu       sync.RWMutex
+}
+
+// NewServer creates a new REST API server
+func NewServer(config ServerConfig) *Server {
+	if config.Title == "" {
+		config.Title = "ModuloX API"
+	}
+	if config.Version == "" {
+		config.Version = "v1"
+	}
+	if config.Sessions == nil {
+		config.Sessions = session.NewMemoryStore()
+	}
+
+	s := &Server{
+		config:   config,
+		agents:   make(map[string]agent.Agent),
+		sessions: config.Sessions,
+	}
+	s.routes = s.buildRoutes()
+	return s
+}
+
+// RegisterAgent exposes an agent under the given name
+func (s *Server) RegisterAgent(name string, a agent.Agent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.agents[name]; exists {
+		return fmt.Errorf("agent already registered: %s", name)
+	}
+
+	s.agents[name] = a
+	return nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Authenticated callers act in their principal's tenant; only admins,
+	// and callers of servers without authentication, may name one
+	if s.config.Authorizer != nil {
+		p, err := s.authenticate(r)
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		ctx := auth.WithPrincipal(r.Context(), p)
+		if p.TenantID != "" {
+			if err := tenant.ValidID(p.TenantID); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			ctx = tenant.WithTenant(ctx, p.TenantID)
+		}
+		if id := r.Header.Get(TenantHeader); id != "" && s.config.Authorizer.IsAdmin(ctx) {
+			if err := tenant.ValidID(id); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			ctx = tenant.WithTenant(ctx, id)
+		}
+		r = r.WithContext(ctx)
+	} else if id := r.Header.Get(TenantHeader); id != "" {
+		if err := tenant.ValidID(id); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		r = r.WithContext(tenant.WithTenant(r.Context(), id))
+	}
+	if s.config.Tenants != nil {
+		if err := s.config.Tenants.Allow(r.Context()); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+	}
+
+	for _, rt := range s.routes {
+		params, ok := rt.match(r.URL.Path)
+		if !ok || rt.method != r.Method {
+			continue
+		}
+		rt.handler(w, r, params)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Errorf("route not found: %s %s", r.Method, r.URL.Path))
+}
+
+// Start starts the HTTP server and blocks until it stops
+func (s *Server) Start(address string) error {
+	s.mu.Lock()
+	s.server = &http.Server{
+		Addr:    address,
+		Handler: s,
+	}
+	server := s.server
+	s.mu.Unlock()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the HTTP server
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.RLock()
+	server := s.server
+	s.mu.RUnlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+// AgentInfo describes an agent exposed by the server
+type AgentInfo struct {
+	Name         string             `json:"name"`
+	Capabilities []types.Capability `json:"capabilities"`
+}
+
+// CompletionRequest is the body of a completion call
+type CompletionRequest struct {
+	Input     string            `json:"input"`
+	SessionID string            `json:"session_id,omitempty"`
+	Stream    bool              `json:"stream,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// CompletionResponse is the result of a completion call
+type CompletionResponse struct {
+	AgentID   string            `json:"agent_id"`
+	SessionID string            `json:"session_id,omitempty"`
+	Output    string            `json:"output"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// CreateSessionRequest is the body of a session creation call
+type CreateSessionRequest struct {
+	AgentID  string                 `json:"agent_id"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ErrorResponse is returned for failed requests
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handleListAgents(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if !s.authorize(w, r, auth.ActionRead, auth.Wildcard) {
+		return
+	}
+
+	s.mu.RLock()
+	infos := make([]AgentInfo, 0, len(s.agents))
+	for name, a := range s.agents {
+		infos = append(infos, AgentInfo{
+			Name:         name,
+			Capabilities: a.GetCapabilities(),
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) handleCompletion(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	agentID := params["agent"]
+	if !s.authorize(w, r, auth.ActionExecute, agentID) {
+		return
+	}
+
+	s.mu.RLock()
+	a, exists := s.agents[agentID]
+	s.mu.RUnlock()
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("agent not found: %s", agentID))
+		return
+	}
+
+	var req CompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Input == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("input is required"))
+		return
+	}
+
+	ctx := r.Context()
+	prompt := req.Input
+	history := []llm.Message{{Role: llm.RoleUser, Content: req.Input}}
+
+	// Replay the session history so the agent sees the whole conversation
+	if req.SessionID != "" {
+		sess, err := s.sessions.Get(ctx, req.SessionID)
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		if sess.AgentID != agentID {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("session %s belongs to agent %s", sess.ID, sess.AgentID))
+			return
+		}
+		prompt = fmt.Sprintf("%s%s: %s", sess.Transcript(), session.RoleUser, req.Input)
+		history = append(toChatMessages(sess.Messages), history...)
+	}
+
+	if sa, ok := a.(agent.StreamingAgent); ok && req.Stream {
+		s.streamCompletion(w, r, sa, agentID, prompt, req)
+		return
+	}
+
+	output, err := execute(ctx, a, prompt, history)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to execute agent: %w", err))
+		return
+	}
+
+	if err := s.recordTurn(ctx, req, output); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	resp := CompletionResponse{
+		AgentID:   agentID,
+		SessionID: req.SessionID,
+		Output:    output,
+		Metadata:  req.Metadata,
+		CreatedAt: time.Now(),
+	}
+
+	if req.Stream {
+		sse, err := newSSEWriter(w)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		sse.Send("completion", resp)
+		sse.Send("done", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// streamCompletion forwards output chunks as "chunk" events followed by the full completion
+func (s *Server) streamCompletion(w http.ResponseWriter, r *http.Request, sa agent.StreamingAgent, agentID, prompt string, req CompletionRequest) {
+	ctx := r.Context()
+
+	chunks, err := sa.ExecuteStream(ctx, prompt)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to execute agent: %w", err))
+		return
+	}
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var output strings.Builder
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			sse.Send("error", ErrorResponse{Error: chunk.Err.Error()})
+			return
+		}
+		if chunk.Content != "" {
+			output.WriteString(chunk.Content)
+			sse.Send("chunk", map[string]string{"content": chunk.Content})
+		}
+	}
+
+	if err := s.recordTurn(ctx, req, output.String()); err != nil {
+		sse.Send("error", ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	sse.Send("completion", CompletionResponse{
+		AgentID:   agentID,
+		SessionID: req.SessionID,
+		Output:    output.String(),
+		Metadata:  req.Metadata,
+		CreatedAt: time.Now(),
+	})
+	sse.Send("done", nil)
+}
+
+// execute runs the agent, preferring structured conversations for chat agents
+func execute(ctx context.Context, a agent.Agent, prompt string, history []llm.Message) (string, error) {
+	if ca, ok := a.(agent.ChatAgent); ok {
+		reply, err := ca.Chat(ctx, history)
+		if err != nil {
+			return "", err
+		}
+		return reply.Content, nil
+	}
+	return a.Execute(ctx, prompt)
+}
+
+// toChatMessages converts session history into chat messages
+func toChatMessages(messages []session.Message) []llm.Message {
+	converted := make([]llm.Message, 0, len(messages))
+	for _, m := range messages {
+		converted = append(converted, llm.Message{Role: llm.Role(m.Role), Content: m.Content})
+	}
+	return converted
+}
+
+// recordTurn appends the exchange to the request's session, if any
+func (s *Server) recordTurn(ctx context.Context, req CompletionRequest, output string) error {
+	if req.SessionID == "" {
+		return nil
+	}
+
+	now := time.Now()
+	err := s.sessions.Append(ctx, req.SessionID,
+		session.Message{Role: session.RoleUser, Content: req.Input, Timestamp: now},
+		session.Message{Role: session.RoleAssistant, Content: output, Timestamp: now},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	var req CreateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if !s.authorize(w, r, auth.ActionExecute, req.AgentID) {
+		return
+	}
+
+	s.mu.RLock()
+	_, exists := s.agents[req.AgentID]
+	s.mu.RUnlock()
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Errorf("agent not found: %s", req.AgentID))
+		return
+	}
+
+	if err := s.checkSessionQuota(r.Context()); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	sess := session.NewSession(req.AgentID)
+	for k, v := range req.Metadata {
+		sess.Metadata[k] = v
+	}
+
+	if err := s.sessions.Create(r.Context(), sess); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to create session: %w", err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, sess)
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	if !s.authorize(w, r, auth.ActionRead, auth.Wildcard) {
+		return
+	}
+
+	sessions, err := s.sessions.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list sessions: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	sess, err := s.sessions.Get(r.Context(), params["id"])
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if !s.authorize(w, r, auth.ActionRead, sess.AgentID) {
+		return
+	}
+	writeJSON(w, http.StatusOK, sess)
+}
+
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	sess, err := s.sessions.Get(r.Context(), params["id"])
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if !s.authorize(w, r, auth.ActionWrite, sess.AgentID) {
+		return
+	}
+
+	if err := s.sessions.Delete(r.Context(), params["id"]); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	writeJSON(w, http.StatusOK, s.OpenAPI())
+}
+
+// authenticate resolves the principal from the Authorization header
+func (s *Server) authenticate(r *http.Request) (*auth.Principal, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" || s.config.Identity == nil {
+		return nil, auth.ErrUnauthenticated
+	}
+	return s.config.Identity.Identify(r.Context(), auth.BearerToken(header))
+}
+
+// authorize checks an agent permission and writes an error response when denied
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, action auth.Action, agentID string) bool {
+	return s.authorizePermission(w, r, auth.Permission{Action: action, Resource: auth.ResourceAgent, Name: agentID})
+}
+
+// authorizePermission checks a permission and writes an error response when denied
+func (s *Server) authorizePermission(w http.ResponseWriter, r *http.Request, perm auth.Permission) bool {
+	if s.config.Authorizer == nil {
+		return true
+	}
+
+	if err := s.config.Authorizer.Authorize(r.Context(), perm); err != nil {
+		writeError(w, statusFor(err), err)
+		return false
+	}
+	return true
+}
+
+// checkSessionQuota enforces the session limit of the tenant attached to the context
+func (s *Server) checkSessionQuota(ctx context.Context) error {
+	if s.config.Tenants == nil {
+		return nil
+	}
+
+	t, err := s.config.Tenants.Get(tenant.IDFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if t.Quota.MaxSessions <= 0 {
+		return nil
+	}
+
+	sessions, err := s.sessions.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(sessions) >= t.Quota.MaxSessions {
+		return tenant.ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Helper function to map errors to HTTP status codes
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, session.ErrSessionNotFound), errors.Is(err, types.ErrWorkflowNotFound),
+		errors.Is(err, distributed.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tenant.ErrInvalidTenantID):
+		return http.StatusBadRequest
+	case errors.Is(err, distributed.ErrQueueDisabled):
+		return http.StatusNotImplemented
+	case errors.Is(err, auth.ErrUnauthenticated), errors.Is(err, auth.ErrInvalidToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, tenant.ErrTenantNotFound), errors.Is(err, auth.ErrPermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, reliability.ErrRateLimited), errors.Is(err, tenant.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// sseWriter writes Server-Sent Events to a response
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming not supported")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	return &sseWriter{w: w, flusher: flusher}, nil
+}
+
+// Send writes a single event with a JSON encoded payload
+func (sw *sseWriter) Send(event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(sw.w, "event: %s\ndata: %s\n\n", event, strings.TrimSpace(string(payload))); err != nil {
+		return err
+	}
+	sw.flusher.Flush()
+	return nil
+}
@@ -0,0 +1,196 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/tenant"
+	"github.com/user/modulox/pkg/types"
+)
+
+// Message roles used within a session
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message represents a single turn within a session
+type Message struct {
+	Role      string                 `json:"role"`
+	Content   string                 `json:"content"`
+	Timestamp time.Time              `json:"timestamp"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Session represents a conversation between a client and an agent
+type Session struct {
+	ID        string                 `json:"id"`
+	AgentID   string                 `json:"agent_id"`
+	TenantID  string                 `json:"tenant_id"`
+	Messages  []Message              `json:"messages"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// NewSession creates a new empty session for the given agent
+func NewSession(agentID string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:        types.NewID("session"),
+		AgentID:   agentID,
+		Messages:  make([]Message, 0),
+		Metadata:  make(map[string]interface{}),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Transcript renders the session history as plain text suitable for a prompt
+func (s *Session) Transcript() string {
+	var b strings.Builder
+	for _, m := range s.Messages {
+		b.WriteString(fmt.Sprintf("%s: %s\n", m.Role, m.Content))
+	}
+	return b.String()
+}
+
+// Store defines the interface for session persistence
+type Store interface {
+	// Create saves a new session
+	Create(ctx context.Context, s *Session) error
+
+	// Get retrieves a session by ID
+	Get(ctx context.Context, id string) (*Session, error)
+
+	// Append adds messages to an existing session
+	Append(ctx context.Context, id string, messages ...Message) error
+
+	// Delete removes a session
+	Delete(ctx context.Context, id string) error
+
+	// List returns all sessions ordered by creation time
+	List(ctx context.Context) ([]*Session, error)
+}
+
+// MemoryStore provides an in-memory implementation of Store.
+// Sessions are isolated per tenant using the tenant attached to the context.
+type MemoryStore struct {
+	sessions map[string]*Session
+	mu       sync.RWMutex
+}
+
+// NewMemoryStore creates a new in-memory session store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]*Session),
+	}
+}
+
+// Create implements Store.Create
+func (ms *MemoryStore) Create(ctx context.Context, s *Session) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, exists := ms.sessions[s.ID]; exists {
+		return fmt.Errorf("session already exists: %s", s.ID)
+	}
+	if s.TenantID == "" {
+		s.TenantID = tenant.IDFromContext(ctx)
+	}
+
+	ms.sessions[s.ID] = s.clone()
+	return nil
+}
+
+// Get implements Store.Get
+func (ms *MemoryStore) Get(ctx context.Context, id string) (*Session, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	s, exists := ms.sessions[id]
+	if !exists || !owned(ctx, s) {
+		return nil, ErrSessionNotFound
+	}
+
+	// Return a copy so callers cannot mutate stored history
+	return s.clone(), nil
+}
+
+// Append implements Store.Append
+func (ms *MemoryStore) Append(ctx context.Context, id string, messages ...Message) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	s, exists := ms.sessions[id]
+	if !exists || !owned(ctx, s) {
+		return ErrSessionNotFound
+	}
+
+	s.Messages = append(s.Messages, messages...)
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// Delete implements Store.Delete
+func (ms *MemoryStore) Delete(ctx context.Context, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if s, exists := ms.sessions[id]; !exists || !owned(ctx, s) {
+		return ErrSessionNotFound
+	}
+
+	delete(ms.sessions, id)
+	return nil
+}
+
+// List implements Store.List
+func (ms *MemoryStore) List(ctx context.Context) ([]*Session, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(ms.sessions))
+	for _, s := range ms.sessions {
+		if owned(ctx, s) {
+			sessions = append(sessions, s.clone())
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+// clone copies a session along with its messages and metadata
+func (s *Session) clone() *Session {
+	cp := *s
+	cp.Messages = append([]Message(nil), s.Messages...)
+	if s.Metadata != nil {
+		cp.Metadata = make(map[string]interface{}, len(s.Metadata))
+		for k, v := range s.Metadata {
+			cp.Metadata[k] = v
+		}
+	}
+	return &cp
+}
+
+// owned reports whether the session belongs to the tenant attached to the context
+func owned(ctx context.Context, s *Session) bool {
+	return s.TenantID == tenant.IDFromContext(ctx)
+}
+
+// Error types
+type SessionError string
+
+func (e SessionError) Error() string { return string(e) }
+
+const (
+	ErrSessionNotFound = SessionError("session not found")
+)
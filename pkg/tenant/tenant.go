@@ -0,0 +1,256 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/reliability"
+)
+
+// DefaultTenant is used when no tenant is attached to a request
+const DefaultTenant = "default"
+
+// Quota limits the resources a tenant may consume
+type Quota struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxSessions       int
+	// MaxConcurrentTasks caps the tenant's tasks running in a cluster at
+	// once, so one tenant cannot starve others on shared nodes
+	MaxConcurrentTasks int
+}
+
+// Usage accounts for a tenant's consumption
+type Usage struct {
+	Requests       int64
+	RateLimited    int64
+	Throttled      int64
+	TasksCompleted int64
+	TasksFailed    int64
+	// TaskTime is the total time the tenant's tasks ran
+	TaskTime time.Duration
+	// Running counts the tenant's tasks running now
+	Running int
+}
+
+// Tenant represents a customer served by a ModuloX deployment
+type Tenant struct {
+	ID       string
+	Name     string
+	Quota    Quota
+	Metadata map[string]interface{}
+}
+
+type tenantKey struct{}
+
+// ValidID checks that id can name a tenant. Scoped keys separate the tenant
+// from the key with "/", so IDs containing it are rejected.
+func ValidID(id string) error {
+	if strings.Contains(id, "/") {
+		return fmt.Errorf("%w: %q", ErrInvalidTenantID, id)
+	}
+	return nil
+}
+
+// WithTenant returns a context carrying the given tenant ID. IDs accepted
+// from callers must pass ValidID first.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant ID attached to the context
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
+
+// IDFromContext returns the tenant ID attached to the context or DefaultTenant
+func IDFromContext(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+	return DefaultTenant
+}
+
+// Key scopes a key to a tenant namespace
+func Key(tenantID, key string) string {
+	return tenantID + "/" + key
+}
+
+// ScopedKey scopes a key to the tenant attached to the context
+func ScopedKey(ctx context.Context, key string) string {
+	return Key(IDFromContext(ctx), key)
+}
+
+// Unscope strips the tenant namespace from a scoped key
+func Unscope(tenantID, key string) (string, bool) {
+	prefix := tenantID + "/"
+	if !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(key, prefix), true
+}
+
+// Manager keeps track of tenants and enforces their quotas
+type Manager struct {
+	tenants  map[string]*Tenant
+	limiters map[string]*reliability.RateLimiter
+	usage    map[string]*Usage
+	mu       sync.RWMutex
+}
+
+// NewManager creates a new tenant manager
+func NewManager() *Manager {
+	return &Manager{
+		tenants:  make(map[string]*Tenant),
+		limiters: make(map[string]*reliability.RateLimiter),
+		usage:    make(map[string]*Usage),
+	}
+}
+
+// Register adds a tenant to the manager
+func (m *Manager) Register(t *Tenant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ValidID(t.ID); err != nil {
+		return err
+	}
+	if _, exists := m.tenants[t.ID]; exists {
+		return fmt.Errorf("tenant already registered: %s", t.ID)
+	}
+
+	m.tenants[t.ID] = t
+	m.usage[t.ID] = &Usage{}
+	if t.Quota.RequestsPerSecond > 0 {
+		burst := t.Quota.Burst
+		if burst <= 0 {
+			burst = int(t.Quota.RequestsPerSecond)
+		}
+		// Rates below one per second still need a burst of one to allow
+		// any request
+		if burst < 1 {
+			burst = 1
+		}
+		m.limiters[t.ID] = reliability.NewRateLimiter(t.Quota.RequestsPerSecond, burst)
+	}
+
+	return nil
+}
+
+// Get returns a tenant by ID
+func (m *Manager) Get(id string) (*Tenant, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, exists := m.tenants[id]
+	if !exists {
+		return nil, ErrTenantNotFound
+	}
+	return t, nil
+}
+
+// Allow checks the rate limit of the tenant attached to the context
+func (m *Manager) Allow(ctx context.Context) error {
+	id := IDFromContext(ctx)
+
+	m.mu.RLock()
+	_, exists := m.tenants[id]
+	limiter := m.limiters[id]
+	m.mu.RUnlock()
+
+	if !exists {
+		return ErrTenantNotFound
+	}
+	allowed := limiter == nil || limiter.Allow()
+
+	m.mu.Lock()
+	usage := m.usage[id]
+	usage.Requests++
+	if !allowed {
+		usage.RateLimited++
+	}
+	m.mu.Unlock()
+
+	if !allowed {
+		return reliability.ErrRateLimited
+	}
+	return nil
+}
+
+// AcquireTask reserves one of the tenant's concurrent task slots, failing
+// with ErrQuotaExceeded when all are in use. Release it with ReleaseTask.
+func (m *Manager) AcquireTask(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, exists := m.tenants[id]
+	if !exists {
+		return ErrTenantNotFound
+	}
+	usage := m.usage[id]
+	if t.Quota.MaxConcurrentTasks > 0 && usage.Running >= t.Quota.MaxConcurrentTasks {
+		usage.Throttled++
+		return ErrQuotaExceeded
+	}
+	usage.Running++
+	return nil
+}
+
+// ReleaseTask frees a task slot taken with AcquireTask and accounts for the
+// task's run time and outcome
+func (m *Manager) ReleaseTask(id string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage, exists := m.usage[id]
+	if !exists {
+		return
+	}
+	if usage.Running > 0 {
+		usage.Running--
+	}
+	usage.TaskTime += duration
+	if err != nil {
+		usage.TasksFailed++
+	} else {
+		usage.TasksCompleted++
+	}
+}
+
+// ReturnTask frees a task slot taken with AcquireTask for a task that never
+// ran, without accounting for it
+func (m *Manager) ReturnTask(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if usage, exists := m.usage[id]; exists && usage.Running > 0 {
+		usage.Running--
+	}
+}
+
+// Usage returns the tenant's consumption so far
+func (m *Manager) Usage(id string) (Usage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	usage, exists := m.usage[id]
+	if !exists {
+		return Usage{}, ErrTenantNotFound
+	}
+	return *usage, nil
+}
+
+// Error types
+type TenantError string
+
+func (e TenantError) Error() string { return string(e) }
+
+const (
+	ErrTenantNotFound  = TenantError("tenant not found")
+	ErrQuotaExceeded   = TenantError("tenant quota exceeded")
+	ErrInvalidTenantID = TenantError("invalid tenant ID")
+)
@@ -0,0 +1,155 @@
+// Package builtin provides ready-made tools that can be registered into a
+// tools.ToolRegistry by name.
+package builtin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/tools"
+	"github.com/user/modulox/pkg/types"
+)
+
+// Names of the built-in tools
+const (
+	HTTPRequest = "http_request"
+	FileRead    = "file_read"
+	FileWrite   = "file_write"
+	Shell       = "shell"
+	Calculator  = "calculator"
+	CurrentTime = "current_time"
+	WebSearch   = "web_search"
+	WebFetch    = "web_fetch"
+)
+
+// Names returns the names of all built-in tools
+func Names() []string {
+	return []string{HTTPRequest, FileRead, FileWrite, Shell, Calculator, CurrentTime, WebSearch, WebFetch}
+}
+
+// Options configures the built-in tools
+type Options struct {
+	// HTTPAllowlist lists hosts the HTTP tool may call. Entries starting
+	// with "." match any subdomain. An empty list denies all hosts.
+	HTTPAllowlist []string
+	// FileRoot is the directory file tools are sandboxed to
+	FileRoot string
+	// AllowShell must be set for the shell tool to be registered
+	AllowShell bool
+	// Search is the backend used by the web search tool
+	Search SearchBackend
+	// Timeout bounds HTTP requests and shell commands (default 30s)
+	Timeout time.Duration
+}
+
+// New creates the built-in tool with the given name
+func New(name string, opts Options) (types.ContextTool, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+
+	switch name {
+	case HTTPRequest:
+		return NewHTTPTool(opts.HTTPAllowlist, opts.Timeout), nil
+	case FileRead, FileWrite:
+		if opts.FileRoot == "" {
+			return nil, fmt.Errorf("file root is required for %s", name)
+		}
+		if name == FileRead {
+			return NewFileReadTool(opts.FileRoot), nil
+		}
+		return NewFileWriteTool(opts.FileRoot), nil
+	case Shell:
+		if !opts.AllowShell {
+			return nil, fmt.Errorf("shell tool is disabled")
+		}
+		return NewShellTool(opts.FileRoot, opts.Timeout), nil
+	case Calculator:
+		return NewCalculatorTool(), nil
+	case CurrentTime:
+		return NewTimeTool(), nil
+	case WebSearch:
+		if opts.Search == nil {
+			return nil, fmt.Errorf("search backend is required for %s", name)
+		}
+		return NewWebSearchTool(opts.Search), nil
+	case WebFetch:
+		return NewWebFetchTool(opts.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown built-in tool: %s", name)
+	}
+}
+
+// Register adds the named built-in tools to the registry
+func Register(registry *tools.ToolRegistry, names []string, opts Options) error {
+	for _, name := range names {
+		tool, err := New(name, opts)
+		if err != nil {
+			return err
+		}
+		if err := registry.RegisterContextTool(name, tool, nil); err != nil {
+			return fmt.Errorf("failed to register %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// RegisterFromConfig adds the tools listed in Config.Tools.EnabledTools
+func RegisterFromConfig(registry *tools.ToolRegistry, cfg *config.Config) error {
+	opts, err := OptionsFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return Register(registry, cfg.Tools.EnabledTools, opts)
+}
+
+// OptionsFromConfig returns the built-in tool options of a configuration
+func OptionsFromConfig(cfg *config.Config) (Options, error) {
+	opts := Options{
+		HTTPAllowlist: cfg.Tools.HTTPAllowlist,
+		FileRoot:      cfg.Tools.FileRoot,
+		AllowShell:    cfg.Tools.AllowShell,
+	}
+	if cfg.Tools.Search.Provider != "" {
+		backend, err := NewSearchBackend(cfg.Tools.Search.Provider, cfg.Tools.Search.APIKey, cfg.Tools.Search.URL)
+		if err != nil {
+			return Options{}, err
+		}
+		opts.Search = backend
+	}
+	return opts, nil
+}
+
+// stringArg returns a string argument from an object input
+func stringArg(input interface{}, key string, required bool) (string, error) {
+	args, ok := input.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("expected object input")
+	}
+	value, exists := args[key]
+	if !exists || value == nil {
+		if required {
+			return "", fmt.Errorf("missing argument: %s", key)
+		}
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %s must be a string", key)
+	}
+	return s, nil
+}
+
+// objectSchema builds a JSON Schema for an object with string properties
+func objectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func stringProp(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
@@ -0,0 +1,228 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CalculatorTool evaluates arithmetic expressions
+type CalculatorTool struct{}
+
+// NewCalculatorTool creates a new calculator tool
+func NewCalculatorTool() *CalculatorTool {
+	return &CalculatorTool{}
+}
+
+// Execute implements types.ContextTool.Execute
+func (t *CalculatorTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	expression, err := stringArg(input, "expression", true)
+	if err != nil {
+		return nil, err
+	}
+	return Evaluate(expression)
+}
+
+// GetDescription implements types.ContextTool.GetDescription
+func (t *CalculatorTool) GetDescription() string {
+	return "Evaluate an arithmetic expression with + - * / % ^, parentheses, and sqrt, abs, floor, ceil, round, ln, log"
+}
+
+// InputSchema implements types.SchemaTool.InputSchema
+func (t *CalculatorTool) InputSchema() map[string]interface{} {
+	return objectSchema([]string{"expression"}, map[string]interface{}{
+		"expression": stringProp("Expression to evaluate, e.g. (2 + 3) * sqrt(16)"),
+	})
+}
+
+// OutputSchema implements types.SchemaTool.OutputSchema
+func (t *CalculatorTool) OutputSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "number"}
+}
+
+// Evaluate computes the value of an arithmetic expression
+func Evaluate(expression string) (float64, error) {
+	p := &exprParser{input: expression}
+	value, err := p.parseExpr()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos)
+	}
+	return value, nil
+}
+
+// exprParser is a recursive descent parser for arithmetic expressions
+type exprParser struct {
+	input string
+	pos   int
+}
+
+var calculatorFuncs = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+	"ln":    math.Log,
+	"log":   math.Log10,
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+// parseExpr handles addition and subtraction
+func (p *exprParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+}
+
+// parseTerm handles multiplication, division, and modulo
+func (p *exprParser) parseTerm() (float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			left *= right
+		case '/':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		case '%':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+// parseUnary handles leading signs
+func (p *exprParser) parseUnary() (float64, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		v, err := p.parseUnary()
+		return -v, err
+	case '+':
+		p.pos++
+		return p.parseUnary()
+	}
+	return p.parsePower()
+}
+
+// parsePower handles right-associative exponentiation
+func (p *exprParser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.pos++
+	exp, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exp), nil
+}
+
+// parsePrimary handles numbers, constants, function calls, and parentheses
+func (p *exprParser) parsePrimary() (float64, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		v, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return v, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+			p.pos++
+		}
+		return strconv.ParseFloat(p.input[start:p.pos], 64)
+	case unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && unicode.IsLetter(rune(p.input[p.pos])) {
+			p.pos++
+		}
+		name := strings.ToLower(p.input[start:p.pos])
+		switch name {
+		case "pi":
+			return math.Pi, nil
+		case "e":
+			return math.E, nil
+		}
+		fn, ok := calculatorFuncs[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown function: %s", name)
+		}
+		if p.peek() != '(' {
+			return 0, fmt.Errorf("expected ( after %s", name)
+		}
+		arg, err := p.parsePrimary()
+		if err != nil {
+			return 0, err
+		}
+		return fn(arg), nil
+	case c == 0:
+		return 0, fmt.Errorf("unexpected end of expression")
+	default:
+		return 0, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+	}
+}
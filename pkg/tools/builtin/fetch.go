@@ -0,0 +1,333 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxFetchChars bounds the extracted page content returned by the fetch tool
+const maxFetchChars = 20000
+
+// WebFetchTool fetches web pages and extracts their main text as Markdown
+type WebFetchTool struct {
+	client *http.Client
+}
+
+// NewWebFetchTool creates a new web fetch tool. Private addresses are
+// refused when dialing, so names resolving to them and redirects to them
+// are rejected too.
+func NewWebFetchTool(timeout time.Duration) *WebFetchTool {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialPublic}
+	return &WebFetchTool{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+}
+
+// dialPublic is a net.Dialer Control func rejecting private addresses. It
+// sees the resolved address, not the host name in the URL.
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isPrivateIP(ip) {
+		return fmt.Errorf("address not allowed: %s", host)
+	}
+	return nil
+}
+
+// Execute implements types.ContextTool.Execute
+func (t *WebFetchTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	rawURL, err := stringArg(input, "url", true)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme: %s", u.Scheme)
+	}
+	if isPrivateHost(u.Hostname()) {
+		return nil, fmt.Errorf("host not allowed: %s", u.Hostname())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,text/plain;q=0.9,*/*;q=0.5")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+
+	body := io.LimitReader(resp.Body, 5*maxResponseBytes)
+	title, content := "", ""
+	if strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		title, content, err = ExtractMarkdown(body, u)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		content = string(data)
+	}
+
+	if len(content) > maxFetchChars {
+		content = content[:maxFetchChars] + "\n\n[truncated]"
+	}
+	return map[string]interface{}{
+		"url":     u.String(),
+		"title":   title,
+		"content": content,
+	}, nil
+}
+
+// GetDescription implements types.ContextTool.GetDescription
+func (t *WebFetchTool) GetDescription() string {
+	return "Fetch a web page and return its title and main content as Markdown"
+}
+
+// InputSchema implements types.SchemaTool.InputSchema
+func (t *WebFetchTool) InputSchema() map[string]interface{} {
+	return objectSchema([]string{"url"}, map[string]interface{}{
+		"url": stringProp("Absolute http or https URL of the page"),
+	})
+}
+
+// OutputSchema implements types.SchemaTool.OutputSchema
+func (t *WebFetchTool) OutputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url":     map[string]interface{}{"type": "string"},
+			"title":   map[string]interface{}{"type": "string"},
+			"content": map[string]interface{}{"type": "string"},
+		},
+	}
+}
+
+// isPrivateHost reports whether a host refers to a loopback or private address
+func isPrivateHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return isPrivateIP(ip)
+}
+
+// isPrivateIP reports whether an address is loopback, private, link-local,
+// multicast or unspecified
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// skippedElements are not part of a page's readable content
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "svg": true, "iframe": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true,
+	"head": true,
+}
+
+// ExtractMarkdown parses an HTML document and renders its readable content
+// as Markdown. Relative links are resolved against base.
+func ExtractMarkdown(r io.Reader, base *url.URL) (title, markdown string, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	title = strings.TrimSpace(findTitle(doc))
+	root := findElement(doc, "main")
+	if root == nil {
+		root = findElement(doc, "article")
+	}
+	if root == nil {
+		root = doc
+	}
+
+	var b strings.Builder
+	renderMarkdown(&b, root, base)
+	return title, collapseBlankLines(b.String()), nil
+}
+
+func findTitle(n *html.Node) string {
+	if t := findElement(n, "title"); t != nil && t.FirstChild != nil {
+		return t.FirstChild.Data
+	}
+	return ""
+}
+
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func renderMarkdown(b *strings.Builder, n *html.Node, base *url.URL) {
+	switch n.Type {
+	case html.TextNode:
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if text == "" {
+			return
+		}
+		if strings.TrimLeft(n.Data, " \t\n") != n.Data {
+			b.WriteString(" ")
+		}
+		b.WriteString(text)
+		if strings.TrimRight(n.Data, " \t\n") != n.Data {
+			b.WriteString(" ")
+		}
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderMarkdown(b, c, base)
+		}
+		return
+	}
+
+	if skippedElements[n.Data] {
+		return
+	}
+
+	children := func() {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderMarkdown(b, c, base)
+		}
+	}
+
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		b.WriteString("\n\n" + strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		children()
+		b.WriteString("\n\n")
+	case "p", "div", "section", "article", "main", "table", "blockquote":
+		b.WriteString("\n\n")
+		children()
+		b.WriteString("\n\n")
+	case "br":
+		b.WriteString("\n")
+	case "li":
+		b.WriteString("\n- ")
+		children()
+	case "tr":
+		b.WriteString("\n")
+		children()
+	case "td", "th":
+		children()
+		b.WriteString(" | ")
+	case "pre":
+		b.WriteString("\n\n```\n" + textContent(n) + "\n```\n\n")
+	case "code":
+		b.WriteString("`" + textContent(n) + "`")
+	case "strong", "b":
+		b.WriteString("**")
+		children()
+		b.WriteString("**")
+	case "em", "i":
+		b.WriteString("_")
+		children()
+		b.WriteString("_")
+	case "a":
+		href := attr(n, "href")
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+			children()
+			return
+		}
+		if base != nil {
+			if ref, err := base.Parse(href); err == nil {
+				href = ref.String()
+			}
+		}
+		b.WriteString("[")
+		children()
+		b.WriteString("](" + href + ")")
+	case "img":
+		if alt := attr(n, "alt"); alt != "" {
+			b.WriteString("[image: " + alt + "]")
+		}
+	default:
+		children()
+	}
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// collapseBlankLines trims trailing spaces and squeezes runs of blank lines
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		out = append(out, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,166 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolvePath maps a path relative to root, rejecting paths that escape it.
+// Symlinks are resolved before the check, including those in the parents
+// of files that do not exist yet, so links inside root cannot lead out.
+func resolvePath(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(absRoot); err == nil {
+		absRoot = resolved
+	}
+
+	full, err := evalSymlinks(filepath.Join(absRoot, filepath.Clean("/"+path)))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+	if full != absRoot && !strings.HasPrefix(full, absRoot+string(filepath.Separator)) {
+		return "", fmt.Errorf("path escapes sandbox: %s", path)
+	}
+	return full, nil
+}
+
+// evalSymlinks resolves the symlinks of a path whose last elements may not
+// exist yet. Dangling links are rejected, as writing through one would
+// create its target wherever it points.
+func evalSymlinks(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	if _, lerr := os.Lstat(path); lerr == nil {
+		return "", fmt.Errorf("dangling symlink: %w", err)
+	}
+
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	resolvedParent, err := evalSymlinks(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(path)), nil
+}
+
+// FileReadTool reads files below a root directory
+type FileReadTool struct {
+	root string
+}
+
+// NewFileReadTool creates a new file read tool sandboxed to root
+func NewFileReadTool(root string) *FileReadTool {
+	return &FileReadTool{root: root}
+}
+
+// Execute implements types.ContextTool.Execute
+func (t *FileReadTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	path, err := stringArg(input, "path", true)
+	if err != nil {
+		return nil, err
+	}
+	full, err := resolvePath(t.root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(data), nil
+}
+
+// GetDescription implements types.ContextTool.GetDescription
+func (t *FileReadTool) GetDescription() string {
+	return "Read a text file from the sandboxed workspace"
+}
+
+// InputSchema implements types.SchemaTool.InputSchema
+func (t *FileReadTool) InputSchema() map[string]interface{} {
+	return objectSchema([]string{"path"}, map[string]interface{}{
+		"path": stringProp("Path relative to the workspace root"),
+	})
+}
+
+// OutputSchema implements types.SchemaTool.OutputSchema
+func (t *FileReadTool) OutputSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+// FileWriteTool writes files below a root directory
+type FileWriteTool struct {
+	root string
+}
+
+// NewFileWriteTool creates a new file write tool sandboxed to root
+func NewFileWriteTool(root string) *FileWriteTool {
+	return &FileWriteTool{root: root}
+}
+
+// Execute implements types.ContextTool.Execute
+func (t *FileWriteTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	path, err := stringArg(input, "path", true)
+	if err != nil {
+		return nil, err
+	}
+	content, err := stringArg(input, "content", true)
+	if err != nil {
+		return nil, err
+	}
+	full, err := resolvePath(t.root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode, _ := input.(map[string]interface{})["append"].(bool); appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(full, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	return fmt.Sprintf("wrote %d bytes to %s", len(content), path), nil
+}
+
+// GetDescription implements types.ContextTool.GetDescription
+func (t *FileWriteTool) GetDescription() string {
+	return "Write or append text to a file in the sandboxed workspace"
+}
+
+// InputSchema implements types.SchemaTool.InputSchema
+func (t *FileWriteTool) InputSchema() map[string]interface{} {
+	return objectSchema([]string{"path", "content"}, map[string]interface{}{
+		"path":    stringProp("Path relative to the workspace root"),
+		"content": stringProp("Text to write"),
+		"append":  map[string]interface{}{"type": "boolean", "description": "Append instead of overwriting"},
+	})
+}
+
+// OutputSchema implements types.SchemaTool.OutputSchema
+func (t *FileWriteTool) OutputSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
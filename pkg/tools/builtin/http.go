@@ -0,0 +1,139 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxResponseBytes bounds the response body returned by the HTTP tool
+const maxResponseBytes = 1 << 20
+
+// HTTPTool performs HTTP requests against an allowlist of hosts
+type HTTPTool struct {
+	allowlist []string
+	client    *http.Client
+}
+
+// NewHTTPTool creates a new HTTP request tool. Redirects are followed only
+// to allowed hosts.
+func NewHTTPTool(allowlist []string, timeout time.Duration) *HTTPTool {
+	t := &HTTPTool{allowlist: allowlist}
+	t.client = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("unsupported redirect scheme: %s", req.URL.Scheme)
+			}
+			if !t.allowed(req.URL.Hostname()) {
+				return fmt.Errorf("redirect to host not allowed: %s", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+	return t
+}
+
+// Execute implements types.ContextTool.Execute
+func (t *HTTPTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	rawURL, err := stringArg(input, "url", true)
+	if err != nil {
+		return nil, err
+	}
+	method, err := stringArg(input, "method", false)
+	if err != nil {
+		return nil, err
+	}
+	body, err := stringArg(input, "body", false)
+	if err != nil {
+		return nil, err
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme: %s", u.Scheme)
+	}
+	if !t.allowed(u.Hostname()) {
+		return nil, fmt.Errorf("host not allowed: %s", u.Hostname())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if headers, ok := input.(map[string]interface{})["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			req.Header.Set(k, fmt.Sprint(v))
+		}
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return map[string]interface{}{
+		"status": resp.StatusCode,
+		"body":   string(data),
+	}, nil
+}
+
+// allowed reports whether the host matches the allowlist
+func (t *HTTPTool) allowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range t.allowlist {
+		entry = strings.ToLower(entry)
+		if host == entry || (strings.HasPrefix(entry, ".") && strings.HasSuffix(host, entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDescription implements types.ContextTool.GetDescription
+func (t *HTTPTool) GetDescription() string {
+	return "Send an HTTP request to an allowed host and return the status and body"
+}
+
+// InputSchema implements types.SchemaTool.InputSchema
+func (t *HTTPTool) InputSchema() map[string]interface{} {
+	return objectSchema([]string{"url"}, map[string]interface{}{
+		"url":    stringProp("Absolute http or https URL"),
+		"method": stringProp("HTTP method, GET by default"),
+		"body":   stringProp("Request body"),
+		"headers": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+		},
+	})
+}
+
+// OutputSchema implements types.SchemaTool.OutputSchema
+func (t *HTTPTool) OutputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"status": map[string]interface{}{"type": "integer"},
+			"body":   map[string]interface{}{"type": "string"},
+		},
+	}
+}
@@ -0,0 +1,239 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchResult is a single web search hit
+type SearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// SearchBackend performs web searches for the web search tool
+type SearchBackend interface {
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
+
+// NewSearchBackend creates a backend by provider name: serpapi, brave, or searxng.
+// baseURL is required for searxng and optional for the hosted providers.
+func NewSearchBackend(provider, apiKey, baseURL string) (SearchBackend, error) {
+	switch provider {
+	case "serpapi":
+		return &SerpAPIBackend{APIKey: apiKey, BaseURL: baseURL}, nil
+	case "brave":
+		return &BraveBackend{APIKey: apiKey, BaseURL: baseURL}, nil
+	case "searxng":
+		if baseURL == "" {
+			return nil, fmt.Errorf("base url is required for searxng")
+		}
+		return &SearxNGBackend{BaseURL: baseURL}, nil
+	default:
+		return nil, fmt.Errorf("unknown search provider: %s", provider)
+	}
+}
+
+// SerpAPIBackend searches Google through SerpAPI
+type SerpAPIBackend struct {
+	APIKey  string
+	BaseURL string
+}
+
+// Search implements SearchBackend.Search
+func (b *SerpAPIBackend) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	base := b.BaseURL
+	if base == "" {
+		base = "https://serpapi.com"
+	}
+	params := url.Values{
+		"q":       {query},
+		"api_key": {b.APIKey},
+		"num":     {strconv.Itoa(limit)},
+		"engine":  {"google"},
+	}
+
+	var resp struct {
+		OrganicResults []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+		} `json:"organic_results"`
+	}
+	if err := getJSON(ctx, base+"/search.json?"+params.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(resp.OrganicResults))
+	for _, r := range resp.OrganicResults {
+		results = append(results, SearchResult{Title: r.Title, URL: r.Link, Snippet: r.Snippet})
+	}
+	return truncateResults(results, limit), nil
+}
+
+// BraveBackend searches through the Brave Search API
+type BraveBackend struct {
+	APIKey  string
+	BaseURL string
+}
+
+// Search implements SearchBackend.Search
+func (b *BraveBackend) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	base := b.BaseURL
+	if base == "" {
+		base = "https://api.search.brave.com"
+	}
+	params := url.Values{
+		"q":     {query},
+		"count": {strconv.Itoa(limit)},
+	}
+
+	var resp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	headers := map[string]string{"X-Subscription-Token": b.APIKey}
+	if err := getJSON(ctx, base+"/res/v1/web/search?"+params.Encode(), headers, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(resp.Web.Results))
+	for _, r := range resp.Web.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description})
+	}
+	return truncateResults(results, limit), nil
+}
+
+// SearxNGBackend searches through a self-hosted SearxNG instance
+type SearxNGBackend struct {
+	BaseURL string
+}
+
+// Search implements SearchBackend.Search
+func (b *SearxNGBackend) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{
+		"q":      {query},
+		"format": {"json"},
+	}
+
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getJSON(ctx, strings.TrimRight(b.BaseURL, "/")+"/search?"+params.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return truncateResults(results, limit), nil
+}
+
+// WebSearchTool searches the web using a pluggable backend
+type WebSearchTool struct {
+	backend SearchBackend
+}
+
+// NewWebSearchTool creates a new web search tool
+func NewWebSearchTool(backend SearchBackend) *WebSearchTool {
+	return &WebSearchTool{backend: backend}
+}
+
+// Execute implements types.ContextTool.Execute
+func (t *WebSearchTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	query, err := stringArg(input, "query", true)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := 5
+	if n, ok := input.(map[string]interface{})["limit"].(float64); ok && n > 0 {
+		limit = int(n)
+	}
+
+	results, err := t.backend.Search(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	return results, nil
+}
+
+// GetDescription implements types.ContextTool.GetDescription
+func (t *WebSearchTool) GetDescription() string {
+	return "Search the web and return the top results with titles, URLs, and snippets"
+}
+
+// InputSchema implements types.SchemaTool.InputSchema
+func (t *WebSearchTool) InputSchema() map[string]interface{} {
+	return objectSchema([]string{"query"}, map[string]interface{}{
+		"query": stringProp("Search query"),
+		"limit": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 20},
+	})
+}
+
+// OutputSchema implements types.SchemaTool.OutputSchema
+func (t *WebSearchTool) OutputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"title":   map[string]interface{}{"type": "string"},
+				"url":     map[string]interface{}{"type": "string"},
+				"snippet": map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+}
+
+var searchClient = &http.Client{Timeout: 30 * time.Second}
+
+// getJSON performs a GET request and decodes the JSON response
+func getJSON(ctx context.Context, rawURL string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := searchClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func truncateResults(results []SearchResult, limit int) []SearchResult {
+	if limit > 0 && len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,79 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// ShellTool runs shell commands. It is only registered when explicitly enabled.
+type ShellTool struct {
+	dir     string
+	timeout time.Duration
+}
+
+// NewShellTool creates a new shell tool running commands in dir
+func NewShellTool(dir string, timeout time.Duration) *ShellTool {
+	return &ShellTool{
+		dir:     dir,
+		timeout: timeout,
+	}
+}
+
+// Execute implements types.ContextTool.Execute
+func (t *ShellTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	command, err := stringArg(input, "command", true)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = t.dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || ctx.Err() != nil {
+			return nil, err
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	return map[string]interface{}{
+		"stdout":    stdout.String(),
+		"stderr":    stderr.String(),
+		"exit_code": exitCode,
+	}, nil
+}
+
+// GetDescription implements types.ContextTool.GetDescription
+func (t *ShellTool) GetDescription() string {
+	return "Run a shell command and return its output and exit code"
+}
+
+// InputSchema implements types.SchemaTool.InputSchema
+func (t *ShellTool) InputSchema() map[string]interface{} {
+	return objectSchema([]string{"command"}, map[string]interface{}{
+		"command": stringProp("Command line passed to sh -c"),
+	})
+}
+
+// OutputSchema implements types.SchemaTool.OutputSchema
+func (t *ShellTool) OutputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"stdout":    map[string]interface{}{"type": "string"},
+			"stderr":    map[string]interface{}{"type": "string"},
+			"exit_code": map[string]interface{}{"type": "integer"},
+		},
+	}
+}
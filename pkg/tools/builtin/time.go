@@ -0,0 +1,55 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeTool reports the current time
+type TimeTool struct {
+	now func() time.Time
+}
+
+// NewTimeTool creates a new current-time tool
+func NewTimeTool() *TimeTool {
+	return &TimeTool{now: time.Now}
+}
+
+// Execute implements types.ContextTool.Execute
+func (t *TimeTool) Execute(ctx context.Context, input interface{}) (interface{}, error) {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	timezone, err := stringArg(input, "timezone", false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := t.now().UTC()
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone: %s", timezone)
+		}
+		now = now.In(loc)
+	}
+	return now.Format(time.RFC3339), nil
+}
+
+// GetDescription implements types.ContextTool.GetDescription
+func (t *TimeTool) GetDescription() string {
+	return "Return the current date and time in RFC 3339 format"
+}
+
+// InputSchema implements types.SchemaTool.InputSchema
+func (t *TimeTool) InputSchema() map[string]interface{} {
+	return objectSchema(nil, map[string]interface{}{
+		"timezone": stringProp("IANA time zone name, UTC by default"),
+	})
+}
+
+// OutputSchema implements types.SchemaTool.OutputSchema
+func (t *TimeTool) OutputSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "format": "date-time"}
+}
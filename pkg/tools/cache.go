@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/jsonschema"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// ResultCacheConfig contains configuration for tool result caching
+type ResultCacheConfig struct {
+	// Tools lists the idempotent tools whose results may be cached
+	Tools []string
+	// TTL is how long results stay valid; zero means no expiry
+	TTL time.Duration
+	// MaxEntries bounds the cache size; least recently used entries are evicted
+	MaxEntries int
+}
+
+// CacheStats reports tool result cache usage
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+// ResultCache caches tool results keyed by tenant, tool name, and
+// canonicalized input
+type ResultCache struct {
+	config    ResultCacheConfig
+	cacheable map[string]bool
+	entries   map[string]*list.Element
+	lru       *list.List
+	hits      int64
+	misses    int64
+	mu        sync.Mutex
+}
+
+type cacheEntry struct {
+	key       string
+	tool      string
+	output    interface{}
+	expiresAt time.Time
+}
+
+// NewResultCache creates a new tool result cache
+func NewResultCache(config ResultCacheConfig) *ResultCache {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 1000
+	}
+	cacheable := make(map[string]bool, len(config.Tools))
+	for _, name := range config.Tools {
+		cacheable[name] = true
+	}
+	return &ResultCache{
+		config:    config,
+		cacheable: cacheable,
+		entries:   make(map[string]*list.Element),
+		lru:       list.New(),
+	}
+}
+
+// Get returns a cached result for the tool call, if present
+func (rc *ResultCache) Get(ctx context.Context, name string, input interface{}) (interface{}, bool) {
+	key, ok := rc.key(ctx, name, input)
+	if !ok {
+		return nil, false
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	elem, exists := rc.entries[key]
+	if !exists {
+		rc.misses++
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		rc.remove(elem)
+		rc.misses++
+		return nil, false
+	}
+
+	rc.lru.MoveToFront(elem)
+	rc.hits++
+	return entry.output, true
+}
+
+// Put stores a tool result
+func (rc *ResultCache) Put(ctx context.Context, name string, input, output interface{}) {
+	key, ok := rc.key(ctx, name, input)
+	if !ok {
+		return
+	}
+
+	entry := &cacheEntry{key: key, tool: name, output: output}
+	if rc.config.TTL > 0 {
+		entry.expiresAt = time.Now().Add(rc.config.TTL)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if elem, exists := rc.entries[key]; exists {
+		elem.Value = entry
+		rc.lru.MoveToFront(elem)
+		return
+	}
+
+	rc.entries[key] = rc.lru.PushFront(entry)
+	for rc.lru.Len() > rc.config.MaxEntries {
+		rc.remove(rc.lru.Back())
+	}
+}
+
+// InvalidateTool removes all cached results of a tool
+func (rc *ResultCache) InvalidateTool(name string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for elem := rc.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*cacheEntry).tool == name {
+			rc.remove(elem)
+		}
+		elem = next
+	}
+}
+
+// Clear removes all cached results
+func (rc *ResultCache) Clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.entries = make(map[string]*list.Element)
+	rc.lru.Init()
+}
+
+// Stats returns cache usage statistics
+func (rc *ResultCache) Stats() CacheStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return CacheStats{
+		Hits:    rc.hits,
+		Misses:  rc.misses,
+		Entries: rc.lru.Len(),
+	}
+}
+
+func (rc *ResultCache) remove(elem *list.Element) {
+	delete(rc.entries, elem.Value.(*cacheEntry).key)
+	rc.lru.Remove(elem)
+}
+
+// key builds the cache key for a call; inputs that cannot be encoded are not cached
+func (rc *ResultCache) key(ctx context.Context, name string, input interface{}) (string, bool) {
+	if !rc.cacheable[name] {
+		return "", false
+	}
+
+	// encoding/json sorts map keys, so equal inputs encode identically
+	normalized, err := jsonschema.Normalize(input)
+	if err != nil {
+		return "", false
+	}
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return "", false
+	}
+	return tenant.Key(tenant.IDFromContext(ctx), name+"\x00"+string(data)), true
+}
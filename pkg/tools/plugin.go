@@ -32,31 +32,37 @@ func NewPluginManager() *PluginManager {
 
 // LoadPlugin loads a tool plugin from a .so file
 func (pm *PluginManager) LoadPlugin(path string) error {
+	_, err := pm.loadPlugin(path)
+	return err
+}
+
+// loadPlugin loads a tool plugin from a .so file and returns its metadata
+func (pm *PluginManager) loadPlugin(path string) (*ToolPlugin, error) {
 	p, err := plugin.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to open plugin: %w", err)
+		return nil, fmt.Errorf("failed to open plugin: %w", err)
 	}
 
 	// Load plugin metadata
 	metadataSymbol, err := p.Lookup("ToolMetadata")
 	if err != nil {
-		return fmt.Errorf("plugin metadata not found: %w", err)
+		return nil, fmt.Errorf("plugin metadata not found: %w", err)
 	}
 
 	metadata, ok := metadataSymbol.(*ToolPlugin)
 	if !ok {
-		return fmt.Errorf("invalid plugin metadata type")
+		return nil, fmt.Errorf("invalid plugin metadata type")
 	}
 
 	// Load execute function
 	executeSymbol, err := p.Lookup("Execute")
 	if err != nil {
-		return fmt.Errorf("execute function not found: %w", err)
+		return nil, fmt.Errorf("execute function not found: %w", err)
 	}
 
 	execute, ok := executeSymbol.(func(interface{}) (interface{}, error))
 	if !ok {
-		return fmt.Errorf("invalid execute function type")
+		return nil, fmt.Errorf("invalid execute function type")
 	}
 
 	metadata.Execute = execute
@@ -65,7 +71,7 @@ func (pm *PluginManager) LoadPlugin(path string) error {
 	defer pm.mu.Unlock()
 	pm.plugins[metadata.Name] = metadata
 
-	return nil
+	return metadata, nil
 }
 
 // GetPlugin retrieves a loaded plugin by name
@@ -79,3 +85,24 @@ func (pm *PluginManager) GetPlugin(name string) (*ToolPlugin, error) {
 	}
 	return plugin, nil
 }
+
+// UnloadPlugin forgets a loaded plugin. The shared object itself stays
+// mapped, since Go cannot unload plugins.
+func (pm *PluginManager) UnloadPlugin(name string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	delete(pm.plugins, name)
+}
+
+// pluginTool adapts a ToolPlugin to the Tool interface
+type pluginTool struct {
+	plugin *ToolPlugin
+}
+
+func (p *pluginTool) Execute(input interface{}) (interface{}, error) {
+	return p.plugin.Execute(input)
+}
+
+func (p *pluginTool) GetDescription() string {
+	return p.plugin.Description
+}
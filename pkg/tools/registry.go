@@ -1,20 +1,42 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/jsonschema"
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/reliability"
 	"github.com/user/modulox/pkg/types"
 )
 
+// Events emitted when the set of registered tools changes
+const (
+	EventToolAdded   = "tool_added"
+	EventToolRemoved = "tool_removed"
+)
+
 // ToolRegistry manages tool registration and discovery
 type ToolRegistry struct {
 	tools      map[string]types.Tool
 	mu         sync.RWMutex
 	validators map[string]func(interface{}) error
+	cache      *ResultCache
+	events     *communication.EventSystem
+	hooks      []InvocationHook
+	faults     *reliability.FaultInjector
+	bulkheads  *reliability.Bulkheads
 }
 
+// InvocationHook is called after every tool execution
+type InvocationHook func(ctx context.Context, name string, input, output interface{}, err error, duration time.Duration)
+
 // NewToolRegistry creates a new tool registry
 func NewToolRegistry() *ToolRegistry {
 	return &ToolRegistry{
@@ -26,9 +48,8 @@ func NewToolRegistry() *ToolRegistry {
 // RegisterTool adds a tool to the registry with type validation
 func (tr *ToolRegistry) RegisterTool(name string, tool types.Tool, validator func(interface{}) error) error {
 	tr.mu.Lock()
-	defer tr.mu.Unlock()
-
 	if _, exists := tr.tools[name]; exists {
+		tr.mu.Unlock()
 		return fmt.Errorf("tool already registered: %s", name)
 	}
 
@@ -36,17 +57,157 @@ func (tr *ToolRegistry) RegisterTool(name string, tool types.Tool, validator fun
 	if validator != nil {
 		tr.validators[name] = validator
 	}
+	tr.mu.Unlock()
 
+	tr.emit(EventToolAdded, name, tool)
 	return nil
 }
 
+// UnregisterTool removes a tool from the registry
+func (tr *ToolRegistry) UnregisterTool(name string) error {
+	tr.mu.Lock()
+	tool, exists := tr.tools[name]
+	if !exists {
+		tr.mu.Unlock()
+		return fmt.Errorf("tool not found: %s", name)
+	}
+
+	delete(tr.tools, name)
+	delete(tr.validators, name)
+	cache := tr.cache
+	tr.mu.Unlock()
+
+	if cache != nil {
+		cache.InvalidateTool(name)
+	}
+	tr.emit(EventToolRemoved, name, tool)
+	return nil
+}
+
+// ReplaceTool swaps the implementation of a registered tool, dropping any
+// cached results of the old one
+func (tr *ToolRegistry) ReplaceTool(name string, tool types.Tool, validator func(interface{}) error) error {
+	tr.mu.Lock()
+	old, exists := tr.tools[name]
+	if !exists {
+		tr.mu.Unlock()
+		return fmt.Errorf("tool not found: %s", name)
+	}
+
+	tr.tools[name] = tool
+	if validator != nil {
+		tr.validators[name] = validator
+	} else {
+		delete(tr.validators, name)
+	}
+	cache := tr.cache
+	tr.mu.Unlock()
+
+	if cache != nil {
+		cache.InvalidateTool(name)
+	}
+	tr.emit(EventToolRemoved, name, old)
+	tr.emit(EventToolAdded, name, tool)
+	return nil
+}
+
+// SetEventSystem emits tool_added and tool_removed events on events whenever
+// the set of registered tools changes
+func (tr *ToolRegistry) SetEventSystem(events *communication.EventSystem) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.events = events
+}
+
+// emit publishes a registry change event, if an event system is configured
+func (tr *ToolRegistry) emit(eventType, name string, tool types.Tool) {
+	tr.mu.RLock()
+	events := tr.events
+	tr.mu.RUnlock()
+
+	if events == nil {
+		return
+	}
+
+	events.EmitEvent(context.Background(), communication.Event{
+		Type:    eventType,
+		Payload: name,
+		Metadata: map[string]interface{}{
+			"tool":        name,
+			"description": tool.GetDescription(),
+		},
+	})
+}
+
+// EnableCache caches results of the configured idempotent tools
+func (tr *ToolRegistry) EnableCache(config ResultCacheConfig) *ResultCache {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.cache = NewResultCache(config)
+	return tr.cache
+}
+
+// AddInvocationHook registers a hook called after every tool execution
+func (tr *ToolRegistry) AddInvocationHook(hook InvocationHook) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.hooks = append(tr.hooks, hook)
+}
+
+// SetFaultInjector injects the faults drawn by faults into tool executions,
+// with targets "tool." followed by the tool name. Cached results are
+// served without faults.
+func (tr *ToolRegistry) SetFaultInjector(faults *reliability.FaultInjector) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.faults = faults
+}
+
+// SetBulkheads limits the concurrent executions of each tool with the
+// bulkhead of bulkheads named "tool." followed by the tool name. Cached
+// results are served without taking a slot.
+func (tr *ToolRegistry) SetBulkheads(bulkheads *reliability.Bulkheads) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.bulkheads = bulkheads
+}
+
+// RegisterContextTool adds a context-aware tool to the registry
+func (tr *ToolRegistry) RegisterContextTool(name string, tool types.ContextTool, validator func(interface{}) error) error {
+	return tr.RegisterTool(name, &contextTool{inner: tool}, validator)
+}
+
 // ExecuteTool runs a tool with type-safe input validation
 func (tr *ToolRegistry) ExecuteTool(name string, input interface{}) (interface{}, error) {
+	return tr.ExecuteToolContext(context.Background(), name, input)
+}
+
+// ExecuteToolContext runs a tool with type-safe input validation. Context
+// tools receive ctx; other tools are only started if ctx is still live.
+func (tr *ToolRegistry) ExecuteToolContext(ctx context.Context, name string, input interface{}) (output interface{}, err error) {
 	tr.mu.RLock()
 	tool, exists := tr.tools[name]
 	validator := tr.validators[name]
+	cache := tr.cache
+	hooks := tr.hooks
+	faults := tr.faults
+	bulkheads := tr.bulkheads
 	tr.mu.RUnlock()
 
+	if len(hooks) > 0 {
+		start := time.Now()
+		defer func() {
+			for _, hook := range hooks {
+				hook(ctx, name, input, output, err, time.Since(start))
+			}
+		}()
+	}
+
 	if !exists {
 		return nil, fmt.Errorf("tool not found: %s", name)
 	}
@@ -56,7 +217,46 @@ func (tr *ToolRegistry) ExecuteTool(name string, input interface{}) (interface{}
 			return nil, fmt.Errorf("input validation failed: %w", err)
 		}
 	}
+	if st, ok := tool.(types.SchemaTool); ok {
+		if schema := st.InputSchema(); schema != nil {
+			if err := jsonschema.Validate(schema, input); err != nil {
+				return nil, fmt.Errorf("input validation failed: %w", err)
+			}
+		}
+	}
+
+	if cache != nil {
+		if output, ok := cache.Get(ctx, name, input); ok {
+			return output, nil
+		}
+	}
+
+	if bulkheads != nil {
+		release, err := bulkheads.Get("tool." + name).Acquire(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %w", name, err)
+		}
+		defer release()
+	}
+	if err := faults.Inject("tool." + name).Apply(ctx); err != nil {
+		return nil, fmt.Errorf("tool %s: %w", name, err)
+	}
+
+	output, err = executeTool(ctx, tool, input)
+	if err == nil && cache != nil {
+		cache.Put(ctx, name, input, output)
+	}
+	return output, err
+}
 
+// executeTool runs a tool, passing ctx to context-aware tools
+func executeTool(ctx context.Context, tool types.Tool, input interface{}) (interface{}, error) {
+	if ct, ok := tool.(*contextTool); ok {
+		return ct.inner.Execute(ctx, input)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return tool.Execute(input)
 }
 
@@ -67,11 +267,120 @@ func (tr *ToolRegistry) DiscoverCapabilities() []types.Capability {
 
 	capabilities := make([]types.Capability, 0, len(tr.tools))
 	for name, tool := range tr.tools {
-		capabilities = append(capabilities, types.Capability{
+		capability := types.Capability{
 			Name:        name,
 			Description: tool.GetDescription(),
-		})
+		}
+		if st, ok := tool.(types.SchemaTool); ok && st.InputSchema() != nil {
+			capability.Parameters = map[string]interface{}{
+				"input":  st.InputSchema(),
+				"output": st.OutputSchema(),
+			}
+		}
+		capabilities = append(capabilities, capability)
 	}
 
 	return capabilities
 }
+
+// ToolDefinitions returns definitions of all registered tools for LLM tool calling.
+// Tools with an object input schema use it as their arguments; all other
+// tools take a single "input" argument.
+func (tr *ToolRegistry) ToolDefinitions() []llm.ToolDefinition {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	definitions := make([]llm.ToolDefinition, 0, len(tr.tools))
+	for name, tool := range tr.tools {
+		parameters := objectSchema(tool)
+		if parameters == nil {
+			input := map[string]interface{}{"type": "string"}
+			if st, ok := tool.(types.SchemaTool); ok && st.InputSchema() != nil {
+				input = st.InputSchema()
+			}
+			parameters = map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"input": input},
+				"required":   []string{"input"},
+			}
+		}
+
+		definitions = append(definitions, llm.ToolDefinition{
+			Name:        name,
+			Description: tool.GetDescription(),
+			Parameters:  parameters,
+		})
+	}
+
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
+
+// DecodeArguments converts tool call arguments produced from ToolDefinitions
+// into the input passed to the tool
+func (tr *ToolRegistry) DecodeArguments(name, arguments string) (interface{}, error) {
+	tr.mu.RLock()
+	tool, exists := tr.tools[name]
+	tr.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("tool not found: %s", name)
+	}
+
+	var args interface{}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return nil, fmt.Errorf("invalid tool arguments: %w", err)
+	}
+	if objectSchema(tool) != nil {
+		return args, nil
+	}
+
+	// Other tools take their "input" argument directly
+	if m, ok := args.(map[string]interface{}); ok && len(m) == 1 {
+		if input, ok := m["input"]; ok {
+			return input, nil
+		}
+	}
+	return args, nil
+}
+
+// objectSchema returns the tool's input schema when it describes an object
+func objectSchema(tool types.Tool) map[string]interface{} {
+	st, ok := tool.(types.SchemaTool)
+	if !ok {
+		return nil
+	}
+	schema := st.InputSchema()
+	if t, _ := schema["type"].(string); t != "object" {
+		return nil
+	}
+	return schema
+}
+
+// contextTool adapts a ContextTool to the Tool interface so it can be
+// stored alongside other tools, forwarding its schema when it has one
+type contextTool struct {
+	inner types.ContextTool
+}
+
+func (c *contextTool) Execute(input interface{}) (interface{}, error) {
+	return c.inner.Execute(context.Background(), input)
+}
+
+func (c *contextTool) GetDescription() string {
+	return c.inner.GetDescription()
+}
+
+func (c *contextTool) InputSchema() map[string]interface{} {
+	if st, ok := c.inner.(interface{ InputSchema() map[string]interface{} }); ok {
+		return st.InputSchema()
+	}
+	return nil
+}
+
+func (c *contextTool) OutputSchema() map[string]interface{} {
+	if st, ok := c.inner.(interface{ OutputSchema() map[string]interface{} }); ok {
+		return st.OutputSchema()
+	}
+	return nil
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+
+	"github.com/user/modulox/pkg/auth"
 )
 
 // SafeExecutor provides type-safe tool execution
 type SafeExecutor struct {
-	registry *ToolRegistry
+	registry   *ToolRegistry
+	authorizer *auth.Authorizer
+	sandbox    *sandbox
 }
 
 // NewSafeExecutor creates a new safe executor
@@ -18,9 +22,37 @@ func NewSafeExecutor(registry *ToolRegistry) *SafeExecutor {
 	}
 }
 
+// SetAuthorizer enables RBAC checks against the principal attached to the context
+func (se *SafeExecutor) SetAuthorizer(az *auth.Authorizer) {
+	se.authorizer = az
+}
+
+// SetSandbox applies timeouts, memory and concurrency limits, panic
+// recovery, and auditing to every tool call
+func (se *SafeExecutor) SetSandbox(config SandboxConfig) {
+	se.sandbox = newSandbox(config)
+}
+
+// Execute runs a tool after checking the caller may use it
+func (se *SafeExecutor) Execute(ctx context.Context, name string, input interface{}) (interface{}, error) {
+	if se.authorizer != nil {
+		perm := auth.Permission{Action: auth.ActionExecute, Resource: auth.ResourceTool, Name: name}
+		if err := se.authorizer.Authorize(ctx, perm); err != nil {
+			return nil, err
+		}
+	}
+
+	if se.sandbox == nil {
+		return se.registry.ExecuteToolContext(ctx, name, input)
+	}
+	return se.sandbox.run(ctx, name, input, func(ctx context.Context) (interface{}, error) {
+		return se.registry.ExecuteToolContext(ctx, name, input)
+	})
+}
+
 // ExecuteWithType runs a tool with strict type checking
 func (se *SafeExecutor) ExecuteWithType(ctx context.Context, name string, input interface{}, outputType reflect.Type) (interface{}, error) {
-	result, err := se.registry.ExecuteTool(name, input)
+	result, err := se.Execute(ctx, name, input)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/auth"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// SandboxConfig contains resource limits applied by SafeExecutor
+type SandboxConfig struct {
+	// DefaultTimeout bounds every tool call (0 = no limit)
+	DefaultTimeout time.Duration
+	// Timeouts overrides DefaultTimeout per tool name
+	Timeouts map[string]time.Duration
+	// MaxConcurrent bounds simultaneous tool calls (0 = unlimited)
+	MaxConcurrent int
+	// MaxMemory aborts a call once process heap growth since it started
+	// exceeds this many bytes (0 = unlimited). The Go runtime does not
+	// account memory per goroutine, so this is a best-effort guard.
+	MaxMemory uint64
+	// MemoryCheckInterval is how often heap usage is sampled (default 50ms)
+	MemoryCheckInterval time.Duration
+	// Audit receives a record of every invocation
+	Audit AuditSink
+}
+
+// AuditRecord describes a single tool invocation
+type AuditRecord struct {
+	ID          string
+	Tool        string
+	PrincipalID string
+	TenantID    string
+	Input       interface{}
+	Output      interface{}
+	Error       string
+	Stack       string
+	StartedAt   time.Time
+	Duration    time.Duration
+}
+
+// AuditSink receives audit records of tool invocations
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord)
+}
+
+// AuditFunc adapts a function to the AuditSink interface
+type AuditFunc func(ctx context.Context, record AuditRecord)
+
+// Record implements AuditSink.Record
+func (f AuditFunc) Record(ctx context.Context, record AuditRecord) {
+	f(ctx, record)
+}
+
+// MemoryAuditLog keeps the most recent audit records in memory
+type MemoryAuditLog struct {
+	records []AuditRecord
+	limit   int
+	mu      sync.RWMutex
+}
+
+// NewMemoryAuditLog creates an audit log retaining up to limit records
+func NewMemoryAuditLog(limit int) *MemoryAuditLog {
+	if limit <= 0 {
+		limit = 1000
+	}
+	return &MemoryAuditLog{limit: limit}
+}
+
+// Record implements AuditSink.Record
+func (l *MemoryAuditLog) Record(ctx context.Context, record AuditRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = append(l.records, record)
+	if len(l.records) > l.limit {
+		l.records = l.records[len(l.records)-l.limit:]
+	}
+}
+
+// Records returns the retained audit records, oldest first
+func (l *MemoryAuditLog) Records() []AuditRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]AuditRecord(nil), l.records...)
+}
+
+// PanicError is returned when a tool panics during execution
+type PanicError struct {
+	Tool  string
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("tool %s panicked: %v", e.Tool, e.Value)
+}
+
+// sandbox enforces SandboxConfig around tool calls
+type sandbox struct {
+	config SandboxConfig
+	slots  chan struct{}
+}
+
+func newSandbox(config SandboxConfig) *sandbox {
+	if config.MemoryCheckInterval <= 0 {
+		config.MemoryCheckInterval = 50 * time.Millisecond
+	}
+	s := &sandbox{config: config}
+	if config.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, config.MaxConcurrent)
+	}
+	return s
+}
+
+// run executes fn under the sandbox limits and audits the call
+func (s *sandbox) run(ctx context.Context, name string, input interface{}, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	record := AuditRecord{
+		ID:        fmt.Sprintf("tool-call-%d", time.Now().UnixNano()),
+		Tool:      name,
+		TenantID:  tenant.IDFromContext(ctx),
+		Input:     input,
+		StartedAt: time.Now(),
+	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		record.PrincipalID = p.ID
+	}
+
+	output, err := s.execute(ctx, name, fn)
+
+	record.Duration = time.Since(record.StartedAt)
+	record.Output = output
+	if err != nil {
+		record.Error = err.Error()
+		if pe, ok := err.(*PanicError); ok {
+			record.Stack = pe.Stack
+		}
+	}
+	if s.config.Audit != nil {
+		s.config.Audit.Record(ctx, record)
+	}
+
+	return output, err
+}
+
+func (s *sandbox) execute(ctx context.Context, name string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	timeout := s.config.DefaultTimeout
+	if t, ok := s.config.Timeouts[name]; ok {
+		timeout = t
+	}
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	type outcome struct {
+		output interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+
+	go func() {
+		// The slot is held until the tool returns, even after a timeout,
+		// so tools ignoring ctx still count against MaxConcurrent
+		if s.slots != nil {
+			defer func() { <-s.slots }()
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: &PanicError{Tool: name, Value: r, Stack: string(debug.Stack())}}
+			}
+		}()
+		output, err := fn(ctx)
+		done <- outcome{output: output, err: err}
+	}()
+
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	var baseline uint64
+	if s.config.MaxMemory > 0 {
+		baseline = heapAlloc()
+		ticker = time.NewTicker(s.config.MemoryCheckInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case o := <-done:
+			return o.output, o.err
+		case <-ctx.Done():
+			// Tools that ignore ctx keep running in the background; their result is discarded
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, fmt.Errorf("%w: %s exceeded %v", ErrToolTimeout, name, timeout)
+			}
+			return nil, ctx.Err()
+		case <-tick:
+			if used := heapAlloc(); used > baseline && used-baseline > s.config.MaxMemory {
+				cancel()
+				return nil, fmt.Errorf("%w: %s grew heap by %d bytes", ErrToolMemoryExceeded, name, used-baseline)
+			}
+		}
+	}
+}
+
+func heapAlloc() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// Error types
+type ToolError string
+
+func (e ToolError) Error() string { return string(e) }
+
+const (
+	ErrToolTimeout        = ToolError("tool execution timed out")
+	ErrToolMemoryExceeded = ToolError("tool exceeded memory limit")
+)
@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginWatcherConfig contains configuration for plugin hot-reloading
+type PluginWatcherConfig struct {
+	// Dir is the directory scanned for .so plugins, usually Config.Tools.PluginDir
+	Dir string
+	// Interval is how often Dir is scanned for changes (default 5s)
+	Interval time.Duration
+	// OnError receives load failures; they are otherwise dropped
+	OnError func(path string, err error)
+}
+
+// PluginWatcher keeps a registry in sync with the plugins in a directory,
+// registering new plugins, replacing modified ones, and unregistering
+// deleted ones. Go caches plugins by path, so modified plugins are opened
+// from a fresh copy of the file. Code that did not change cannot be
+// loaded twice; touching a plugin without rebuilding it reports an error
+// and keeps the loaded version.
+type PluginWatcher struct {
+	config   PluginWatcherConfig
+	registry *ToolRegistry
+	manager  *PluginManager
+	files    map[string]pluginFile
+	stop     chan struct{}
+	once     sync.Once
+	mu       sync.Mutex
+}
+
+// pluginFile records a loaded plugin file
+type pluginFile struct {
+	name    string
+	modTime time.Time
+}
+
+// NewPluginWatcher creates a watcher that loads plugins into registry
+func NewPluginWatcher(registry *ToolRegistry, manager *PluginManager, config PluginWatcherConfig) *PluginWatcher {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+	if manager == nil {
+		manager = NewPluginManager()
+	}
+	return &PluginWatcher{
+		config:   config,
+		registry: registry,
+		manager:  manager,
+		files:    make(map[string]pluginFile),
+		stop:     make(chan struct{}),
+	}
+}
+
+// LoadPluginDir registers the tools of the plugins in dir once, without
+// watching it, and returns their names in order. A plugin that fails to
+// load fails the call.
+func LoadPluginDir(registry *ToolRegistry, dir string) ([]string, error) {
+	var loadErr error
+	w := NewPluginWatcher(registry, nil, PluginWatcherConfig{
+		Dir: dir,
+		OnError: func(path string, err error) {
+			if loadErr == nil {
+				loadErr = fmt.Errorf("failed to load plugin %s: %w", filepath.Base(path), err)
+			}
+		},
+	})
+	if err := w.Scan(); err != nil {
+		return nil, err
+	}
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	names := make([]string, 0, len(w.files))
+	for _, loaded := range w.files {
+		names = append(names, loaded.name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Start loads the plugins currently in the directory and keeps watching it
+// until ctx is done or Stop is called
+func (w *PluginWatcher) Start(ctx context.Context) error {
+	if err := w.Scan(); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				if err := w.Scan(); err != nil {
+					w.reportError(w.config.Dir, err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops watching the directory; loaded tools stay registered
+func (w *PluginWatcher) Stop() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+// Scan reconciles the registry with the plugins currently in the directory
+func (w *PluginWatcher) Scan() error {
+	entries, err := os.ReadDir(w.config.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read plugin dir: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".so") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(w.config.Dir, entry.Name())
+		seen[path] = true
+		if loaded, exists := w.files[path]; exists && loaded.modTime.Equal(info.ModTime()) {
+			continue
+		}
+
+		if err := w.load(path, info.ModTime()); err != nil {
+			w.reportError(path, err)
+		}
+	}
+
+	for path, loaded := range w.files {
+		if seen[path] {
+			continue
+		}
+		delete(w.files, path)
+		w.manager.UnloadPlugin(loaded.name)
+		if err := w.registry.UnregisterTool(loaded.name); err != nil {
+			w.reportError(path, err)
+		}
+	}
+
+	return nil
+}
+
+// load registers the plugin at path, replacing the tool from an earlier
+// version of the file
+func (w *PluginWatcher) load(path string, modTime time.Time) error {
+	previous, reloaded := w.files[path]
+	open := path
+	if reloaded {
+		copied, err := copyPlugin(path)
+		if err != nil {
+			return err
+		}
+		// The copy stays mapped once opened
+		defer os.Remove(copied)
+		open = copied
+	}
+
+	plugin, err := w.manager.loadPlugin(open)
+	if err != nil {
+		if reloaded {
+			// Keep the loaded version and report the error once
+			w.files[path] = pluginFile{name: previous.name, modTime: modTime}
+		}
+		return err
+	}
+
+	tool := &pluginTool{plugin: plugin}
+	w.files[path] = pluginFile{name: plugin.Name, modTime: modTime}
+
+	if reloaded && previous.name != plugin.Name {
+		// The file now provides a different tool
+		w.manager.UnloadPlugin(previous.name)
+		if err := w.registry.UnregisterTool(previous.name); err != nil {
+			w.reportError(path, err)
+		}
+	}
+
+	if err := w.registry.ReplaceTool(plugin.Name, tool, nil); err == nil {
+		return nil
+	}
+	return w.registry.RegisterTool(plugin.Name, tool, nil)
+}
+
+// copyPlugin copies a plugin to a new temporary path, so plugin.Open does
+// not return the version cached for the original path
+func copyPlugin(path string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open plugin: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", strings.TrimSuffix(filepath.Base(path), ".so")+"-*.so")
+	if err != nil {
+		return "", fmt.Errorf("failed to copy plugin: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to copy plugin: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to copy plugin: %w", err)
+	}
+	return dst.Name(), nil
+}
+
+func (w *PluginWatcher) reportError(path string, err error) {
+	if w.config.OnError != nil {
+		w.config.OnError(path, err)
+	}
+}
@@ -0,0 +1,9 @@
+package types
+
+// Error is an error shared by packages that do not import each other
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// ErrWorkflowNotFound is returned for workflows that are not registered
+const ErrWorkflowNotFound = Error("workflow not found")
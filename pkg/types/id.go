@@ -0,0 +1,19 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// NewID returns a random identifier starting with prefix, e.g.
+// "task-9f86d081884c7d659a2feaa0c55ad015". IDs are unguessable, so they
+// are safe to hand to other tenants' callers, and unique across processes.
+func NewID(prefix string) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// The system's random source is unavailable; nothing can recover
+		panic(fmt.Sprintf("failed to generate ID: %v", err))
+	}
+	return prefix + "-" + hex.EncodeToString(b)
+}
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"context"
+)
+
 // Capability represents a specific ability that an agent can perform
 type Capability struct {
 	Name        string
@@ -15,6 +19,24 @@ type Tool interface {
 	GetDescription() string
 }
 
+// ContextTool is a tool that honors cancellation and deadlines
+type ContextTool interface {
+	// Execute runs the tool with given input
+	Execute(ctx context.Context, input interface{}) (interface{}, error)
+	// GetDescription returns information about the tool
+	GetDescription() string
+}
+
+// SchemaTool is implemented by tools that describe their inputs and
+// outputs with JSON Schema
+type SchemaTool interface {
+	Tool
+	// InputSchema returns the JSON Schema the tool input must satisfy
+	InputSchema() map[string]interface{}
+	// OutputSchema returns the JSON Schema of the tool result
+	OutputSchema() map[string]interface{}
+}
+
 // Vector represents an embedding vector
 type Vector struct {
 	ID       string
@@ -31,14 +53,62 @@ type NodeStatus struct {
 	Status     int
 	LastPing   time.Time
 	AgentCount int
+	// Agents, Tags, and Tools let the cluster match tasks to nodes running
+	// in other processes
+	Agents []string
+	Tags   []string
+	Tools  []string
+	// Load counts tasks in flight and QueueDepth those waiting for
+	// capacity; CPU is idle cores and Memory available bytes
+	QueueDepth int
+	CPU        float64
+	Memory     int64
+	// AgentTenants maps agents dedicated to a tenant to its ID
+	AgentTenants map[string]string
+	// Version is the software version the node runs
+	Version string
+}
+
+// HealthEvent records a node entering a status
+type HealthEvent struct {
+	Status int
+	At     time.Time
+}
+
+// NodeTopology describes a node and its recent health
+type NodeTopology struct {
+	Node   NodeStatus
+	State  string
+	Remote bool
+	// Health lists the node's latest status changes, oldest first
+	Health []HealthEvent
+}
+
+// ClusterTopology describes what runs where in a cluster
+type ClusterTopology struct {
+	// SchedulerID identifies the cluster instance that answered and Leader
+	// whether it schedules tasks
+	SchedulerID  string
+	Leader       bool
+	Nodes        []NodeTopology
+	PendingTasks int
+	Sessions     int
+	GeneratedAt  time.Time
 }
 
 // TaskRequirements specifies requirements for task execution
 type TaskRequirements struct {
 	AgentID string
 	Tags    []string
+	Tools   []string
 	MinCPU  float64
 	MinMem  int64
+	// SessionID keeps tasks of a session on the node holding its memory
+	// and state
+	SessionID string
+	// TenantID restricts the task to agents shared or owned by the tenant;
+	// the cluster sets it from the context
+	TenantID string
 }
 
 // WorkflowResult represents the result of a workflow execution
@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/types"
+)
+
+// AgentAdapter adapts a Workflow to the agent.Agent interface, so a
+// workflow can be added anywhere an agent can and composed hierarchically
+type AgentAdapter struct {
+	name     string
+	workflow Workflow
+}
+
+// NewAgentAdapter wraps a workflow as an agent with the given name
+func NewAgentAdapter(name string, w Workflow) *AgentAdapter {
+	return &AgentAdapter{
+		name:     name,
+		workflow: w,
+	}
+}
+
+// Execute implements agent.Agent.Execute by running the workflow
+func (a *AgentAdapter) Execute(ctx context.Context, input string) (string, error) {
+	return a.workflow.Execute(ctx, input)
+}
+
+// AddTool implements agent.Agent.AddTool. Workflows have no tools of their
+// own; add tools to the agents inside the workflow instead.
+func (a *AgentAdapter) AddTool(tool types.Tool) error {
+	return fmt.Errorf("workflow %s does not accept tools", a.name)
+}
+
+// GetCapabilities implements agent.Agent.GetCapabilities
+func (a *AgentAdapter) GetCapabilities() []types.Capability {
+	return []types.Capability{{
+		Name:        a.name,
+		Description: fmt.Sprintf("Runs the %s workflow", a.name),
+	}}
+}
+
+// GetName returns the name of the wrapped workflow
+func (a *AgentAdapter) GetName() string {
+	return a.name
+}
+
+// WorkflowAgent returns an agent that runs the named workflow through the
+// coordinator, so nested runs are recorded and published like top-level
+// ones. The workflow is resolved on each execution.
+func (c *Coordinator) WorkflowAgent(name string) agent.Agent {
+	return NewAgentAdapter(name, &registeredWorkflow{coordinator: c, name: name})
+}
+
+// registeredWorkflow runs a workflow registered with a coordinator by name
+type registeredWorkflow struct {
+	coordinator *Coordinator
+	name        string
+}
+
+func (w *registeredWorkflow) Execute(ctx context.Context, task string) (string, error) {
+	return w.coordinator.ExecuteWorkflow(ctx, w.name, task)
+}
+
+func (w *registeredWorkflow) AddAgent(a agent.Agent) error {
+	return fmt.Errorf("agents must be added to workflow %s directly", w.name)
+}
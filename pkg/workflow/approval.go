@@ -0,0 +1,142 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/communication"
+)
+
+// MessageBus topics used for human approval
+const (
+	EventApprovalRequest  = "approval_request"
+	EventApprovalResponse = "approval_response"
+)
+
+// ApprovalFallback decides what happens when no approval arrives in time
+type ApprovalFallback int
+
+const (
+	// FallbackReject fails the step with ErrApprovalTimeout
+	FallbackReject ApprovalFallback = iota
+	// FallbackApprove lets the pending output through
+	FallbackApprove
+)
+
+// ApprovalConfig contains configuration for an approval step
+type ApprovalConfig struct {
+	// Name identifies the step in approval requests
+	Name string
+	// Bus carries approval requests and responses
+	Bus communication.MessageBus
+	// Timeout bounds the wait for a response; zero waits until ctx is done
+	Timeout time.Duration
+	// OnTimeout is applied when Timeout elapses without a response
+	OnTimeout ApprovalFallback
+}
+
+// ApprovalStep pauses a workflow until a human approves the pending output.
+// It publishes an approval_request message carrying the output and a
+// request_id, then waits for an approval_response message with the same
+// request_id and an "approved" metadata flag.
+type ApprovalStep struct {
+	inner  Workflow
+	config ApprovalConfig
+}
+
+// NewApprovalStep creates a step that requests approval for the output of
+// inner. With a nil inner workflow the step's input itself is approved.
+func NewApprovalStep(inner Workflow, config ApprovalConfig) *ApprovalStep {
+	if config.Name == "" {
+		config.Name = "approval"
+	}
+	return &ApprovalStep{
+		inner:  inner,
+		config: config,
+	}
+}
+
+// Execute implements Workflow.Execute, returning the approved output
+func (s *ApprovalStep) Execute(ctx context.Context, task string) (string, error) {
+	output := task
+	if s.inner != nil {
+		var err error
+		output, err = s.inner.Execute(ctx, task)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	responses := s.config.Bus.Subscribe(EventApprovalResponse)
+	defer s.config.Bus.Unsubscribe(EventApprovalResponse, responses)
+
+	requestID := fmt.Sprintf("approval-%d", time.Now().UnixNano())
+	err := s.config.Bus.Publish(ctx, EventApprovalRequest, communication.Message{
+		ID:        requestID,
+		From:      s.config.Name,
+		Type:      EventApprovalRequest,
+		Content:   output,
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"request_id": requestID,
+			"step":       s.config.Name,
+			"task":       task,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to publish approval request: %w", err)
+	}
+
+	var timeout <-chan time.Time
+	if s.config.Timeout > 0 {
+		timer := time.NewTimer(s.config.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timeout:
+			if s.config.OnTimeout == FallbackApprove {
+				return output, nil
+			}
+			return "", ErrApprovalTimeout
+		case msg := <-responses:
+			if id, _ := msg.Metadata["request_id"].(string); id != requestID {
+				continue
+			}
+			if approved, _ := msg.Metadata["approved"].(bool); !approved {
+				if reason, _ := msg.Metadata["reason"].(string); reason != "" {
+					return "", fmt.Errorf("%w: %s", ErrApprovalRejected, reason)
+				}
+				return "", ErrApprovalRejected
+			}
+			return output, nil
+		}
+	}
+}
+
+// AddAgent implements Workflow.AddAgent by adding the agent to the inner workflow
+func (s *ApprovalStep) AddAgent(a agent.Agent) error {
+	if s.inner == nil {
+		return fmt.Errorf("approval step has no inner workflow")
+	}
+	return s.inner.AddAgent(a)
+}
+
+// RespondToApproval publishes an approval or rejection for a pending request
+func RespondToApproval(ctx context.Context, bus communication.MessageBus, requestID string, approved bool, reason string) error {
+	return bus.Publish(ctx, EventApprovalResponse, communication.Message{
+		Type:      EventApprovalResponse,
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"request_id": requestID,
+			"approved":   approved,
+			"reason":     reason,
+		},
+	})
+}
@@ -4,27 +4,44 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/user/modulox/pkg/agent"
 	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/llm"
+	"github.com/user/modulox/pkg/types"
 )
 
 // Coordinator manages collaboration between multiple agents
 type Coordinator struct {
 	workflows map[string]Workflow
-	client    *communication.AgentClient
-	mu        sync.RWMutex
+	// digests holds the spec digests of workflows registered from specs
+	digests map[string]string
+	client  *communication.AgentClient
+	history WorkflowHistoryStore
+	mu      sync.RWMutex
 }
 
 // NewCoordinator creates a new coordinator instance
 func NewCoordinator(serverAddr string) (*Coordinator, error) {
-	client, err := communication.NewAgentClient(serverAddr, "coordinator")
+	return NewCoordinatorWithConfig(communication.ClientConfig{Address: serverAddr})
+}
+
+// NewCoordinatorWithConfig creates a coordinator publishing its events
+// through the agent server described by config, as agent "coordinator"
+// unless config names another
+func NewCoordinatorWithConfig(config communication.ClientConfig) (*Coordinator, error) {
+	if config.AgentID == "" {
+		config.AgentID = "coordinator"
+	}
+	client, err := communication.NewAgentClientWithConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create agent client: %w", err)
 	}
 
 	return &Coordinator{
 		workflows: make(map[string]Workflow),
+		digests:   make(map[string]string),
 		client:    client,
 	}, nil
 }
@@ -34,6 +51,7 @@ func (c *Coordinator) RegisterWorkflow(name string, w Workflow) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.workflows[name] = w
+	delete(c.digests, name)
 
 	// Publish workflow registration event
 	c.client.PublishEvent(context.Background(), "workflow_registered",
@@ -41,14 +59,100 @@ func (c *Coordinator) RegisterWorkflow(name string, w Workflow) {
 		map[string]string{"workflow_name": name})
 }
 
+// RegisterSpec builds a workflow from its spec and registers it under the
+// spec's name, remembering the spec's digest
+func (c *Coordinator) RegisterSpec(spec *Spec, newAgent AgentFactory, options WorkflowOptions) error {
+	w, err := spec.Build(newAgent, options)
+	if err != nil {
+		return err
+	}
+	digest, err := spec.Digest()
+	if err != nil {
+		return err
+	}
+	c.RegisterWorkflow(spec.Name, w)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.digests[spec.Name] = digest
+	return nil
+}
+
+// SpecDigest returns the digest of the spec the named workflow was
+// registered from, if it was registered with RegisterSpec
+func (c *Coordinator) SpecDigest(name string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	digest, ok := c.digests[name]
+	return digest, ok
+}
+
+// SetHistoryStore records every workflow execution in store
+func (c *Coordinator) SetHistoryStore(store WorkflowHistoryStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = store
+}
+
 // ExecuteWorkflow runs a specific workflow by name
 func (c *Coordinator) ExecuteWorkflow(ctx context.Context, name string, task string) (string, error) {
+	rec, err := c.execute(ctx, name, task, "")
+	if err != nil {
+		return "", err
+	}
+	return rec.Output, nil
+}
+
+// History returns recorded workflow executions
+func (c *Coordinator) History(ctx context.Context, q HistoryQuery) ([]ExecutionRecord, error) {
+	c.mu.RLock()
+	history := c.history
+	c.mu.RUnlock()
+
+	if history == nil {
+		return nil, fmt.Errorf("workflow history is not enabled")
+	}
+	return history.List(ctx, q)
+}
+
+// GetExecution returns a recorded workflow execution by ID
+func (c *Coordinator) GetExecution(ctx context.Context, id string) (ExecutionRecord, error) {
+	c.mu.RLock()
+	history := c.history
+	c.mu.RUnlock()
+
+	if history == nil {
+		return ExecutionRecord{}, fmt.Errorf("workflow history is not enabled")
+	}
+	return history.Get(ctx, id)
+}
+
+// Replay re-runs a recorded execution with its original input against the
+// currently registered workflow and compares the outcomes
+func (c *Coordinator) Replay(ctx context.Context, id string) (ReplayResult, error) {
+	original, err := c.GetExecution(ctx, id)
+	if err != nil {
+		return ReplayResult{}, err
+	}
+
+	replayed, err := c.execute(ctx, original.Workflow, original.Input, original.ID)
+	if replayed.ID == "" {
+		// The workflow could not be started at all
+		return ReplayResult{}, err
+	}
+	return compareExecutions(original, replayed), nil
+}
+
+// execute runs a workflow and records the execution when history is
+// enabled and it is not a replay. The returned record is empty if the workflow could not start.
+func (c *Coordinator) execute(ctx context.Context, name, task, replayOf string) (ExecutionRecord, error) {
 	c.mu.RLock()
 	workflow, exists := c.workflows[name]
+	history := c.history
 	c.mu.RUnlock()
 
 	if !exists {
-		return "", fmt.Errorf("workflow not found: %s", name)
+		return ExecutionRecord{}, fmt.Errorf("workflow not found: %s", name)
 	}
 
 	// Publish workflow execution start event
@@ -59,17 +163,41 @@ func (c *Coordinator) ExecuteWorkflow(ctx context.Context, name string, task str
 			"task_length":   fmt.Sprintf("%d", len(task)),
 		})
 	if err != nil {
-		return "", fmt.Errorf("failed to publish start event: %w", err)
+		return ExecutionRecord{}, fmt.Errorf("failed to publish start event: %w", err)
+	}
+
+	rec := ExecutionRecord{
+		ID:        fmt.Sprintf("exec-%d", time.Now().UnixNano()),
+		Workflow:  name,
+		Input:     task,
+		StartedAt: time.Now(),
+		ReplayOf:  replayOf,
 	}
+	runCtx, steps := withStepRecorder(llm.WithUsageScope(ctx, llm.UsageScope{Workflow: name}))
+	runCtx = WithStepObserver(runCtx, c.stepObserver(ctx, name))
 
 	// Execute workflow
-	result, err := workflow.Execute(ctx, task)
+	result, err := workflow.Execute(runCtx, task)
+
+	rec.Output = result
+	rec.Steps = steps.recorded()
+	rec.Duration = time.Since(rec.StartedAt)
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	// Replays are compared against the original, not kept as history
+	if history != nil && replayOf == "" {
+		if saveErr := history.Save(ctx, rec); saveErr != nil && err == nil {
+			err = fmt.Errorf("failed to record execution: %w", saveErr)
+		}
+	}
+
 	if err != nil {
 		// Publish error event
 		c.client.PublishEvent(ctx, "workflow_execution_error",
 			fmt.Sprintf("Workflow %s failed: %v", name, err),
 			map[string]string{"workflow_name": name})
-		return "", fmt.Errorf("workflow execution failed: %w", err)
+		return rec, fmt.Errorf("workflow execution failed: %w", err)
 	}
 
 	// Publish completion event
@@ -80,7 +208,31 @@ func (c *Coordinator) ExecuteWorkflow(ctx context.Context, name string, task str
 			"result_length": fmt.Sprintf("%d", len(result)),
 		})
 
-	return result, nil
+	return rec, nil
+}
+
+// stepObserver publishes an event for each finished step of a workflow,
+// then passes the step on to the observer of ctx, if any
+func (c *Coordinator) stepObserver(ctx context.Context, name string) StepObserver {
+	caller, _ := ctx.Value(observerKey{}).(StepObserver)
+	return func(step StepRecord) {
+		metadata := map[string]string{
+			"workflow_name": name,
+			"step":          step.Step,
+			"duration_ms":   fmt.Sprintf("%d", step.Duration.Milliseconds()),
+		}
+		if step.Error != "" {
+			metadata["error"] = step.Error
+			c.client.PublishEvent(ctx, "workflow_step_error",
+				fmt.Sprintf("Step %s of workflow %s failed: %s", step.Step, name, step.Error), metadata)
+		} else {
+			c.client.PublishEvent(ctx, "workflow_step_complete",
+				fmt.Sprintf("Step %s of workflow %s completed", step.Step, name), metadata)
+		}
+		if caller != nil {
+			caller(step)
+		}
+	}
 }
 
 // Close closes the coordinator and its connections
@@ -94,5 +246,9 @@ type WorkflowError string
 func (e WorkflowError) Error() string { return string(e) }
 
 const (
-	ErrWorkflowNotFound = WorkflowError("workflow not found")
+	ErrWorkflowNotFound = types.ErrWorkflowNotFound
+	ErrApprovalRejected = WorkflowError("approval rejected")
+	ErrApprovalTimeout  = WorkflowError("approval timed out")
+	ErrStepTimeout      = WorkflowError("step timed out")
+	ErrInvalidSpec      = WorkflowError("invalid workflow spec")
 )
@@ -0,0 +1,121 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/user/modulox/pkg/agent"
+)
+
+// DebateWorkflow has several agents answer a task, critique and revise
+// each other's answers over a number of rounds, then asks a judge agent to
+// select or synthesize the final answer
+type DebateWorkflow struct {
+	agents []agent.Agent
+	judge  agent.Agent
+	rounds int
+}
+
+// NewDebateWorkflow creates a debate judged by judge. rounds is the number
+// of critique-and-revise rounds after the initial answers.
+func NewDebateWorkflow(judge agent.Agent, rounds int) *DebateWorkflow {
+	if rounds < 0 {
+		rounds = 0
+	}
+	return &DebateWorkflow{
+		agents: make([]agent.Agent, 0),
+		judge:  judge,
+		rounds: rounds,
+	}
+}
+
+// Execute implements Workflow.Execute for debate-style processing
+func (w *DebateWorkflow) Execute(ctx context.Context, task string) (string, error) {
+	if len(w.agents) == 0 {
+		return "", fmt.Errorf("debate workflow has no agents")
+	}
+
+	answers, err := w.runAll(ctx, func(int) string { return task })
+	if err != nil {
+		return "", err
+	}
+
+	for round := 1; round <= w.rounds; round++ {
+		previous := answers
+		answers, err = w.runAll(ctx, func(index int) string {
+			return revisionPrompt(task, index, previous)
+		})
+		if err != nil {
+			return "", fmt.Errorf("round %d: %w", round, err)
+		}
+	}
+
+	judgeInput := fmt.Sprintf("Task:\n%s\n\nCandidate answers:\n%s\n\n"+
+		"Select the best answer or synthesize a better one from them. Respond with the final answer only.",
+		task, formatAnswers(answers, -1))
+	finalResult, err := w.judge.Execute(ctx, judgeInput)
+	if err != nil {
+		return "", fmt.Errorf("judge failed: %w", err)
+	}
+
+	return finalResult, nil
+}
+
+// AddAgent implements Workflow.AddAgent by adding a debater
+func (w *DebateWorkflow) AddAgent(a agent.Agent) error {
+	w.agents = append(w.agents, a)
+	return nil
+}
+
+// runAll executes every debater in parallel on its own prompt
+func (w *DebateWorkflow) runAll(ctx context.Context, prompt func(index int) string) ([]string, error) {
+	var wg sync.WaitGroup
+	results := make([]string, len(w.agents))
+	errors := make(chan error, len(w.agents))
+
+	for i, a := range w.agents {
+		wg.Add(1)
+		go func(index int, a agent.Agent) {
+			defer wg.Done()
+
+			result, err := a.Execute(ctx, prompt(index))
+			if err != nil {
+				errors <- fmt.Errorf("agent %d failed: %w", index, err)
+				return
+			}
+			results[index] = result
+		}(i, a)
+	}
+
+	wg.Wait()
+	close(errors)
+
+	select {
+	case err := <-errors:
+		return nil, err
+	default:
+	}
+
+	return results, nil
+}
+
+// revisionPrompt asks a debater to critique the other answers and revise its own
+func revisionPrompt(task string, index int, answers []string) string {
+	return fmt.Sprintf("Task:\n%s\n\nYour previous answer:\n%s\n\nOther answers:\n%s\n\n"+
+		"Critique the other answers, point out any errors, and give your revised answer.",
+		task, answers[index], formatAnswers(answers, index))
+}
+
+// formatAnswers numbers the answers, leaving out the one at skip
+func formatAnswers(answers []string, skip int) string {
+	var b strings.Builder
+	for i, answer := range answers {
+		if i == skip {
+			continue
+		}
+		fmt.Fprintf(&b, "Answer %d:\n%s\n\n", i+1, answer)
+	}
+	return strings.TrimSpace(b.String())
+}
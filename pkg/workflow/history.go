@@ -0,0 +1,234 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// StepRecord records a single step of a workflow execution
+type StepRecord struct {
+	Step      string
+	Input     string
+	Output    string
+	Error     string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// ExecutionRecord records a single workflow execution
+type ExecutionRecord struct {
+	ID        string
+	TenantID  string
+	Workflow  string
+	Input     string
+	Output    string
+	Error     string
+	Steps     []StepRecord
+	StartedAt time.Time
+	Duration  time.Duration
+	// ReplayOf is the ID of the execution this one replayed, if any
+	ReplayOf string
+}
+
+// HistoryQuery filters execution records
+type HistoryQuery struct {
+	Workflow   string
+	FailedOnly bool
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// WorkflowHistoryStore persists workflow execution records. Records are
+// scoped to the tenant attached to the context.
+type WorkflowHistoryStore interface {
+	// Save stores an execution record
+	Save(ctx context.Context, rec ExecutionRecord) error
+	// Get returns an execution record by ID
+	Get(ctx context.Context, id string) (ExecutionRecord, error)
+	// List returns matching records, most recent first
+	List(ctx context.Context, q HistoryQuery) ([]ExecutionRecord, error)
+}
+
+// MemoryHistoryStore keeps execution records in memory
+type MemoryHistoryStore struct {
+	records    []ExecutionRecord
+	maxRecords int
+	mu         sync.RWMutex
+}
+
+// NewMemoryHistoryStore creates an in-memory history store retaining at
+// most maxRecords records (default 1000)
+func NewMemoryHistoryStore(maxRecords int) *MemoryHistoryStore {
+	if maxRecords <= 0 {
+		maxRecords = 1000
+	}
+	return &MemoryHistoryStore{
+		records:    make([]ExecutionRecord, 0),
+		maxRecords: maxRecords,
+	}
+}
+
+// Save implements WorkflowHistoryStore.Save
+func (s *MemoryHistoryStore) Save(ctx context.Context, rec ExecutionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec.TenantID = tenant.IDFromContext(ctx)
+	s.records = append(s.records, rec)
+	if len(s.records) > s.maxRecords {
+		s.records = s.records[len(s.records)-s.maxRecords:]
+	}
+	return nil
+}
+
+// Get implements WorkflowHistoryStore.Get
+func (s *MemoryHistoryStore) Get(ctx context.Context, id string) (ExecutionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	for _, rec := range s.records {
+		if rec.ID == id && rec.TenantID == tenantID {
+			return rec, nil
+		}
+	}
+	return ExecutionRecord{}, fmt.Errorf("execution not found: %s", id)
+}
+
+// List implements WorkflowHistoryStore.List
+func (s *MemoryHistoryStore) List(ctx context.Context, q HistoryQuery) ([]ExecutionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenantID := tenant.IDFromContext(ctx)
+	records := make([]ExecutionRecord, 0)
+	for _, rec := range s.records {
+		switch {
+		case rec.TenantID != tenantID:
+		case q.Workflow != "" && rec.Workflow != q.Workflow:
+		case q.FailedOnly && rec.Error == "":
+		case !q.Since.IsZero() && rec.StartedAt.Before(q.Since):
+		case !q.Until.IsZero() && !rec.StartedAt.Before(q.Until):
+		default:
+			records = append(records, rec)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].StartedAt.After(records[j].StartedAt) })
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[:q.Limit]
+	}
+	return records, nil
+}
+
+// stepRecorder collects the steps of a running workflow
+type stepRecorder struct {
+	steps []StepRecord
+	mu    sync.Mutex
+}
+
+type recorderKey struct{}
+
+type observerKey struct{}
+
+// StepObserver is called as each step of a workflow finishes
+type StepObserver func(step StepRecord)
+
+// WithStepObserver returns a context under which workflows report each
+// finished step to observe, e.g. to stream progress to a terminal
+func WithStepObserver(ctx context.Context, observe StepObserver) context.Context {
+	return context.WithValue(ctx, observerKey{}, observe)
+}
+
+// withStepRecorder attaches a new step recorder to the context
+func withStepRecorder(ctx context.Context) (context.Context, *stepRecorder) {
+	rec := &stepRecorder{}
+	return context.WithValue(ctx, recorderKey{}, rec), rec
+}
+
+// recordStep adds a step to the recorder attached to ctx and reports it to
+// the observer, if any
+func recordStep(ctx context.Context, step, input, output string, started time.Time, err error) {
+	rec, recording := ctx.Value(recorderKey{}).(*stepRecorder)
+	observe, observing := ctx.Value(observerKey{}).(StepObserver)
+	if !recording && !observing {
+		return
+	}
+
+	record := StepRecord{
+		Step:      step,
+		Input:     input,
+		Output:    output,
+		StartedAt: started,
+		Duration:  time.Since(started),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if observing {
+		observe(record)
+	}
+	if !recording {
+		return
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.steps = append(rec.steps, record)
+}
+
+// recorded returns the steps collected so far in start order
+func (r *stepRecorder) recorded() []StepRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	steps := append([]StepRecord(nil), r.steps...)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].StartedAt.Before(steps[j].StartedAt) })
+	return steps
+}
+
+// ReplayResult compares a replayed execution with the original
+type ReplayResult struct {
+	Original ExecutionRecord
+	Replayed ExecutionRecord
+	// OutputChanged reports whether the final output or error differs
+	OutputChanged bool
+	// ChangedSteps lists, in order, the steps whose output or error differs
+	// from the step at the same position in the original
+	ChangedSteps []string
+}
+
+// compareExecutions builds a ReplayResult for two executions
+func compareExecutions(original, replayed ExecutionRecord) ReplayResult {
+	result := ReplayResult{
+		Original:      original,
+		Replayed:      replayed,
+		OutputChanged: original.Output != replayed.Output || original.Error != replayed.Error,
+	}
+
+	// Steps are matched by position, as a workflow may run the same step
+	// name more than once
+	for i, step := range replayed.Steps {
+		if i >= len(original.Steps) {
+			result.ChangedSteps = append(result.ChangedSteps, step.Step)
+			continue
+		}
+		prev := original.Steps[i]
+		if prev.Step != step.Step || prev.Output != step.Output || prev.Error != step.Error {
+			result.ChangedSteps = append(result.ChangedSteps, step.Step)
+		}
+	}
+	if len(original.Steps) > len(replayed.Steps) {
+		for _, step := range original.Steps[len(replayed.Steps):] {
+			result.ChangedSteps = append(result.ChangedSteps, step.Step)
+		}
+	}
+
+	return result
+}
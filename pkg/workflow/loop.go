@@ -0,0 +1,144 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/types"
+)
+
+// Reasons a LoopWorkflow stops, reported under the "stop_reason" metadata key
+const (
+	StopDone          = "done"
+	StopMaxIterations = "max_iterations"
+	StopTimeout       = "timeout"
+)
+
+// UntilFunc reports whether the loop is done after the given iteration
+type UntilFunc func(ctx context.Context, iteration int, output string) (bool, error)
+
+// LoopConfig contains configuration for a loop workflow
+type LoopConfig struct {
+	// Condition is asked after each iteration whether the task is done.
+	// Ignored when Until is set.
+	Condition agent.Agent
+	// Until decides whether the loop is done without consulting an agent
+	Until UntilFunc
+	// MaxIterations bounds the number of iterations (default 10)
+	MaxIterations int
+	// Timeout bounds the whole loop; zero means no limit
+	Timeout time.Duration
+}
+
+// LoopWorkflow repeatedly runs an inner workflow, feeding each output back
+// in as the next input, until the condition says done or a limit is hit
+type LoopWorkflow struct {
+	inner  Workflow
+	config LoopConfig
+}
+
+// NewLoopWorkflow creates a new loop workflow around inner
+func NewLoopWorkflow(inner Workflow, config LoopConfig) *LoopWorkflow {
+	if config.MaxIterations <= 0 {
+		config.MaxIterations = 10
+	}
+	return &LoopWorkflow{
+		inner:  inner,
+		config: config,
+	}
+}
+
+// Execute implements Workflow.Execute and returns the last iteration's output
+func (w *LoopWorkflow) Execute(ctx context.Context, task string) (string, error) {
+	result, err := w.Run(ctx, task)
+	if err != nil {
+		return "", err
+	}
+	return result.Output, nil
+}
+
+// Run executes the loop and reports every iteration's output under the
+// "iterations" metadata key, along with "iteration_count" and "stop_reason"
+func (w *LoopWorkflow) Run(ctx context.Context, task string) (types.WorkflowResult, error) {
+	loopCtx := ctx
+	if w.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		loopCtx, cancel = context.WithTimeout(ctx, w.config.Timeout)
+		defer cancel()
+	}
+
+	iterations := make([]string, 0)
+	result := func(reason string) types.WorkflowResult {
+		output := ""
+		if len(iterations) > 0 {
+			output = iterations[len(iterations)-1]
+		}
+		return types.WorkflowResult{
+			Output: output,
+			Metadata: map[string]interface{}{
+				"iterations":      iterations,
+				"iteration_count": len(iterations),
+				"stop_reason":     reason,
+			},
+		}
+	}
+
+	input := task
+	for i := 1; i <= w.config.MaxIterations; i++ {
+		output, err := w.inner.Execute(loopCtx, input)
+		if err != nil {
+			if w.timedOut(ctx, loopCtx, err) && len(iterations) > 0 {
+				return result(StopTimeout), nil
+			}
+			return types.WorkflowResult{Error: err}, fmt.Errorf("iteration %d failed: %w", i, err)
+		}
+		iterations = append(iterations, output)
+
+		done, err := w.done(loopCtx, task, i, output)
+		if err != nil {
+			if w.timedOut(ctx, loopCtx, err) {
+				return result(StopTimeout), nil
+			}
+			return types.WorkflowResult{Error: err}, fmt.Errorf("loop condition failed: %w", err)
+		}
+		if done {
+			return result(StopDone), nil
+		}
+
+		input = output
+	}
+
+	return result(StopMaxIterations), nil
+}
+
+// AddAgent implements Workflow.AddAgent by adding the agent to the inner workflow
+func (w *LoopWorkflow) AddAgent(a agent.Agent) error {
+	return w.inner.AddAgent(a)
+}
+
+// done evaluates the loop condition after an iteration
+func (w *LoopWorkflow) done(ctx context.Context, task string, iteration int, output string) (bool, error) {
+	if w.config.Until != nil {
+		return w.config.Until(ctx, iteration, output)
+	}
+	if w.config.Condition == nil {
+		return false, nil
+	}
+
+	prompt := fmt.Sprintf("Original task:\n%s\n\nLatest result:\n%s\n\n"+
+		"Is the task complete? Respond with DONE if it is, otherwise CONTINUE.", task, output)
+	answer, err := w.config.Condition.Execute(ctx, prompt)
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(answer)), "DONE"), nil
+}
+
+// timedOut reports whether err was caused by the loop timeout rather than
+// cancellation of the caller's context
+func (w *LoopWorkflow) timedOut(parent, loopCtx context.Context, err error) bool {
+	return err != nil && parent.Err() == nil && loopCtx.Err() == context.DeadlineExceeded
+}
@@ -0,0 +1,151 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/user/modulox/pkg/agent"
+)
+
+// RouteFunc selects the name of the branch that should handle a task
+type RouteFunc func(ctx context.Context, task string) (string, error)
+
+// RouterWorkflow routes each task to one of several named branches, chosen
+// by a classifier agent or a user-supplied RouteFunc. Branches are
+// workflows themselves, so routers can be nested.
+type RouterWorkflow struct {
+	classifier    agent.Agent
+	route         RouteFunc
+	branches      map[string]Workflow
+	order         []string
+	defaultBranch string
+	mu            sync.RWMutex
+}
+
+// NewRouterWorkflow creates a router that asks the classifier agent which
+// branch fits the task
+func NewRouterWorkflow(classifier agent.Agent) *RouterWorkflow {
+	return &RouterWorkflow{
+		classifier: classifier,
+		branches:   make(map[string]Workflow),
+	}
+}
+
+// NewPredicateRouterWorkflow creates a router that selects branches with route
+func NewPredicateRouterWorkflow(route RouteFunc) *RouterWorkflow {
+	return &RouterWorkflow{
+		route:    route,
+		branches: make(map[string]Workflow),
+	}
+}
+
+// AddBranch registers a named branch
+func (w *RouterWorkflow) AddBranch(name string, branch Workflow) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, exists := w.branches[name]; exists {
+		return fmt.Errorf("branch already registered: %s", name)
+	}
+
+	w.branches[name] = branch
+	w.order = append(w.order, name)
+	return nil
+}
+
+// SetDefaultBranch sets the branch used when no branch matches the route
+func (w *RouterWorkflow) SetDefaultBranch(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, exists := w.branches[name]; !exists {
+		return fmt.Errorf("branch not found: %s", name)
+	}
+
+	w.defaultBranch = name
+	return nil
+}
+
+// Execute implements Workflow.Execute by running the selected branch
+func (w *RouterWorkflow) Execute(ctx context.Context, task string) (string, error) {
+	name, err := w.selectBranch(ctx, task)
+	if err != nil {
+		return "", err
+	}
+
+	w.mu.RLock()
+	branch := w.branches[name]
+	w.mu.RUnlock()
+
+	result, err := branch.Execute(ctx, task)
+	if err != nil {
+		return "", fmt.Errorf("branch %s failed: %w", name, err)
+	}
+	return result, nil
+}
+
+// AddAgent implements Workflow.AddAgent. Routers hold workflows rather than
+// agents, so agents must be wrapped in a workflow and added with AddBranch.
+func (w *RouterWorkflow) AddAgent(a agent.Agent) error {
+	return fmt.Errorf("router workflow branches must be added with AddBranch")
+}
+
+// selectBranch resolves the route for a task, falling back to the default
+// branch when the route names no registered branch
+func (w *RouterWorkflow) selectBranch(ctx context.Context, task string) (string, error) {
+	var name string
+	var err error
+	if w.route != nil {
+		name, err = w.route(ctx, task)
+	} else {
+		name, err = w.classify(ctx, task)
+	}
+	if err != nil {
+		return "", fmt.Errorf("routing failed: %w", err)
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if _, exists := w.branches[name]; exists {
+		return name, nil
+	}
+	if w.defaultBranch != "" {
+		return w.defaultBranch, nil
+	}
+	return "", fmt.Errorf("no branch matches route %q", name)
+}
+
+// classify asks the classifier agent to name a branch and matches its
+// answer against the registered branch names
+func (w *RouterWorkflow) classify(ctx context.Context, task string) (string, error) {
+	w.mu.RLock()
+	names := append([]string(nil), w.order...)
+	w.mu.RUnlock()
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("router has no branches")
+	}
+
+	prompt := fmt.Sprintf("Classify the following task into exactly one of these categories: %s.\n"+
+		"Respond with the category name only.\n\nTask: %s", strings.Join(names, ", "), task)
+	answer, err := w.classifier.Execute(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	for _, name := range names {
+		if strings.ToLower(name) == answer {
+			return name, nil
+		}
+	}
+	for _, name := range names {
+		if strings.Contains(answer, strings.ToLower(name)) {
+			return name, nil
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,202 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/config"
+)
+
+// Spec is a declarative workflow, loaded from YAML or JSON:
+//
+//	name: research
+//	type: sequential
+//	steps:
+//	  - name: researcher
+//	    system_prompt: Collect the facts relevant to the task.
+//	    tools: [web_search]
+//	  - name: writer
+//	    system_prompt: Write a short report from the facts.
+//	    model: gpt-4o
+type Spec struct {
+	Name string `json:"name"`
+	// Type is "sequential" (default) or "mixture"
+	Type string `json:"type"`
+	// StepTimeoutMs bounds sequential steps without a timeout of their own
+	StepTimeoutMs int `json:"step_timeout_ms"`
+	// MaxParallelism bounds how many mixture steps run at once
+	MaxParallelism int         `json:"max_parallelism"`
+	Failure        FailureSpec `json:"failure"`
+	Steps          []StepSpec  `json:"steps"`
+	// Aggregator combines the results of a mixture workflow's steps
+	Aggregator StepSpec `json:"aggregator"`
+}
+
+// FailureSpec is the failure policy of a mixture workflow
+type FailureSpec struct {
+	// Mode is "fail_fast" (default), "best_effort", or "fallback"
+	Mode     string `json:"mode"`
+	Quorum   int    `json:"quorum"`
+	Fallback string `json:"fallback"`
+}
+
+// StepSpec describes the agent of a workflow step. Model and Tools override
+// the configured agent's when set.
+type StepSpec struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	SystemPrompt string   `json:"system_prompt"`
+	Model        string   `json:"model"`
+	Tools        []string `json:"tools"`
+	TimeoutMs    int      `json:"timeout_ms"`
+}
+
+// AgentFactory creates the agent of a workflow step
+type AgentFactory func(step StepSpec) (agent.Agent, error)
+
+// failureModes maps FailureSpec.Mode values to failure modes
+var failureModes = map[string]FailureMode{
+	"":            FailFast,
+	"fail_fast":   FailFast,
+	"best_effort": BestEffort,
+	"fallback":    UseFallback,
+}
+
+// LoadSpec loads a workflow spec from a YAML or JSON file
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := ParseSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ParseSpec decodes and validates a YAML or JSON workflow spec.
+// Environment variables are expanded as in configuration files.
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := config.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Type == "" {
+		spec.Type = "sequential"
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Digest identifies the spec's content, so a client can check that a server
+// runs the same workflow it does
+func (s *Spec) Digest() (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode spec: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Validate checks that the spec describes a workflow that can be built
+func (s *Spec) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSpec)
+	}
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("%w: at least one step is required", ErrInvalidSpec)
+	}
+	if s.StepTimeoutMs < 0 || s.MaxParallelism < 0 || s.Failure.Quorum < 0 {
+		return fmt.Errorf("%w: step_timeout_ms, max_parallelism, and failure.quorum must not be negative", ErrInvalidSpec)
+	}
+
+	names := make(map[string]bool)
+	for i, step := range s.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("%w: steps[%d]: name is required", ErrInvalidSpec, i)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("%w: steps[%d]: duplicate step name %q", ErrInvalidSpec, i, step.Name)
+		}
+		names[step.Name] = true
+		if step.TimeoutMs < 0 {
+			return fmt.Errorf("%w: steps[%d]: timeout_ms must not be negative", ErrInvalidSpec, i)
+		}
+	}
+
+	switch s.Type {
+	case "sequential":
+		if s.Aggregator.Name != "" {
+			return fmt.Errorf("%w: aggregator is only used by mixture workflows", ErrInvalidSpec)
+		}
+	case "mixture":
+		if s.Aggregator.Name == "" {
+			return fmt.Errorf("%w: mixture workflows require a named aggregator", ErrInvalidSpec)
+		}
+		if _, ok := failureModes[s.Failure.Mode]; !ok {
+			return fmt.Errorf("%w: unknown failure mode %q", ErrInvalidSpec, s.Failure.Mode)
+		}
+		for i, step := range s.Steps {
+			if step.TimeoutMs > 0 {
+				return fmt.Errorf("%w: steps[%d]: timeout_ms is only supported by sequential workflows", ErrInvalidSpec, i)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown workflow type %q", ErrInvalidSpec, s.Type)
+	}
+	return nil
+}
+
+// Build creates the workflow, creating each step's agent with newAgent.
+// Options apply to mixture workflows.
+func (s *Spec) Build(newAgent AgentFactory, options WorkflowOptions) (Workflow, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch s.Type {
+	case "mixture":
+		aggregator, err := newAgent(s.Aggregator)
+		if err != nil {
+			return nil, fmt.Errorf("aggregator %s: %w", s.Aggregator.Name, err)
+		}
+		if s.MaxParallelism > 0 {
+			options.MaxParallelism = s.MaxParallelism
+		}
+		w := NewMixtureWorkflowWithOptions(aggregator, options)
+		w.SetFailurePolicy(FailurePolicy{
+			Mode:     failureModes[s.Failure.Mode],
+			Quorum:   s.Failure.Quorum,
+			Fallback: s.Failure.Fallback,
+		})
+		for _, step := range s.Steps {
+			a, err := newAgent(step)
+			if err != nil {
+				return nil, fmt.Errorf("step %s: %w", step.Name, err)
+			}
+			w.AddAgent(a)
+		}
+		return w, nil
+
+	default:
+		w := NewSequentialWorkflow()
+		w.SetStepTimeout(time.Duration(s.StepTimeoutMs) * time.Millisecond)
+		for _, step := range s.Steps {
+			a, err := newAgent(step)
+			if err != nil {
+				return nil, fmt.Errorf("step %s: %w", step.Name, err)
+			}
+			w.AddAgentWithTimeout(a, time.Duration(step.TimeoutMs)*time.Millisecond)
+		}
+		return w, nil
+	}
+}
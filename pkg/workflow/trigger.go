@@ -0,0 +1,143 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/tenant"
+)
+
+// TaskMapper builds a workflow task from an event payload and metadata
+type TaskMapper func(payload interface{}, metadata map[string]interface{}) (string, error)
+
+// Trigger launches a registered workflow whenever an event arrives on Topic
+type Trigger struct {
+	// Topic is the MessageBus topic or EventSystem event type to watch
+	Topic string
+	// Workflow is the name of the workflow to run
+	Workflow string
+	// Map builds the task from the event; defaults to the payload as text
+	Map TaskMapper
+	// OnError receives mapping and execution failures, and messages
+	// naming an invalid tenant
+	OnError func(err error)
+	// MaxConcurrent bounds the workflows the trigger runs at once
+	// (default 16)
+	MaxConcurrent int
+}
+
+// slots returns a semaphore bounding the trigger's concurrent workflows
+func (t Trigger) slots() chan struct{} {
+	if t.MaxConcurrent <= 0 {
+		return make(chan struct{}, 16)
+	}
+	return make(chan struct{}, t.MaxConcurrent)
+}
+
+// TriggerOnEvent runs the trigger's workflow for every matching event
+// emitted on events. Workflows run in the background so emitters are not
+// blocked; those beyond MaxConcurrent wait for a running one to finish. The returned function stops the trigger.
+func (c *Coordinator) TriggerOnEvent(events *communication.EventSystem, trigger Trigger) func() {
+	var mu sync.RWMutex
+	stopped := false
+	slots := trigger.slots()
+
+	events.RegisterHandler(trigger.Topic, func(ctx context.Context, event communication.Event) error {
+		mu.RLock()
+		defer mu.RUnlock()
+		if stopped {
+			return nil
+		}
+
+		ctx = detach(ctx)
+		go func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			c.fire(ctx, trigger, event.Payload, event.Metadata)
+		}()
+		return nil
+	})
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+	}
+}
+
+// TriggerOnMessage runs the trigger's workflow, in the publisher's tenant,
+// for every message published to the trigger's topic on bus. Once
+// MaxConcurrent workflows are running, further messages wait in the
+// subscription. The returned function stops the trigger.
+func (c *Coordinator) TriggerOnMessage(bus communication.MessageBus, trigger Trigger) func() {
+	messages := bus.Subscribe(trigger.Topic)
+	done := make(chan struct{})
+	slots := trigger.slots()
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case msg := <-messages:
+				select {
+				case slots <- struct{}{}:
+				case <-done:
+					return
+				}
+				go func() {
+					defer func() { <-slots }()
+					ctx, err := communication.MessageTenant(context.Background(), msg)
+					if err != nil {
+						if trigger.OnError != nil {
+							trigger.OnError(fmt.Errorf("trigger %s -> %s: %w", trigger.Topic, trigger.Workflow, err))
+						}
+						return
+					}
+					c.fire(ctx, trigger, msg.Content, msg.Metadata)
+				}()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			bus.Unsubscribe(trigger.Topic, messages)
+			close(done)
+		})
+	}
+}
+
+// fire maps an event into a task and runs the trigger's workflow
+func (c *Coordinator) fire(ctx context.Context, trigger Trigger, payload interface{}, metadata map[string]interface{}) {
+	task, err := mapTask(trigger, payload, metadata)
+	if err == nil {
+		_, err = c.ExecuteWorkflow(ctx, trigger.Workflow, task)
+	}
+	if err != nil && trigger.OnError != nil {
+		trigger.OnError(fmt.Errorf("trigger %s -> %s: %w", trigger.Topic, trigger.Workflow, err))
+	}
+}
+
+// mapTask applies the trigger's mapper, defaulting to the payload as text
+func mapTask(trigger Trigger, payload interface{}, metadata map[string]interface{}) (string, error) {
+	if trigger.Map != nil {
+		return trigger.Map(payload, metadata)
+	}
+	if s, ok := payload.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(payload), nil
+}
+
+// detach returns a background context that keeps the tenant of ctx, so
+// triggered workflows outlive the emitter's context
+func detach(ctx context.Context) context.Context {
+	if id, ok := tenant.FromContext(ctx); ok {
+		return tenant.WithTenant(context.Background(), id)
+	}
+	return context.Background()
+}
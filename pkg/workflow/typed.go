@@ -0,0 +1,227 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/jsonschema"
+)
+
+// TypedStep is a pipeline step with a declared payload shape. Input and
+// Output are JSON schemas; a nil schema accepts any text.
+type TypedStep struct {
+	Name   string
+	Agent  agent.Agent
+	Input  map[string]interface{}
+	Output map[string]interface{}
+	// Timeout bounds the step; zero means no limit
+	Timeout time.Duration
+}
+
+// SchemaOf derives a step schema from the Go type of v using its json tags
+func SchemaOf(v interface{}) map[string]interface{} {
+	return jsonschema.ForType(reflect.TypeOf(v))
+}
+
+// StepError reports the workflow step that failed
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string { return fmt.Sprintf("step %s: %v", e.Step, e.Err) }
+
+func (e *StepError) Unwrap() error { return e.Err }
+
+// TypedPipeline runs steps sequentially, validating every payload against
+// the step schemas. Structured payloads travel between agents as JSON, so
+// a step whose output does not match its schema fails instead of passing a
+// malformed prompt to the next step.
+type TypedPipeline struct {
+	steps []TypedStep
+}
+
+// NewTypedPipeline creates a new typed pipeline
+func NewTypedPipeline() *TypedPipeline {
+	return &TypedPipeline{
+		steps: make([]TypedStep, 0),
+	}
+}
+
+// AddStep appends a step, failing if its input schema cannot accept the
+// previous step's output schema
+func (p *TypedPipeline) AddStep(step TypedStep) error {
+	if step.Name == "" {
+		step.Name = fmt.Sprintf("step_%d", len(p.steps)+1)
+	}
+	if len(p.steps) > 0 {
+		prev := p.steps[len(p.steps)-1]
+		if err := compatibleSchemas(prev.Output, step.Input, "output"); err != nil {
+			return fmt.Errorf("step %s cannot accept output of step %s: %w", step.Name, prev.Name, err)
+		}
+	}
+
+	p.steps = append(p.steps, step)
+	return nil
+}
+
+// AddAgent implements Workflow.AddAgent by appending an untyped step
+func (p *TypedPipeline) AddAgent(a agent.Agent) error {
+	return p.AddStep(TypedStep{Agent: a})
+}
+
+// Execute implements Workflow.Execute. The task is decoded as JSON when the
+// first step expects structured input.
+func (p *TypedPipeline) Execute(ctx context.Context, task string) (string, error) {
+	var input interface{} = task
+	if len(p.steps) > 0 && structured(p.steps[0].Input) {
+		var err error
+		if input, err = decodePayload(task); err != nil {
+			return "", &StepError{Step: p.steps[0].Name, Err: fmt.Errorf("invalid input: %w", err)}
+		}
+	}
+
+	output, err := p.Run(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return encodePayload(output)
+}
+
+// Run executes the pipeline on a Go value and returns the last step's
+// output, decoded from JSON when the step declares a structured output
+func (p *TypedPipeline) Run(ctx context.Context, input interface{}) (interface{}, error) {
+	payload := input
+	for _, step := range p.steps {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		if step.Input != nil {
+			if err := jsonschema.Validate(step.Input, payload); err != nil {
+				return nil, &StepError{Step: step.Name, Err: fmt.Errorf("invalid input: %w", err)}
+			}
+		}
+
+		prompt, err := encodePayload(payload)
+		if err != nil {
+			return nil, &StepError{Step: step.Name, Err: err}
+		}
+
+		result, err := runStep(ctx, step.Name, step.Agent, prompt, step.Timeout)
+		if err != nil {
+			return nil, &StepError{Step: step.Name, Err: err}
+		}
+
+		payload = result
+		if structured(step.Output) {
+			if payload, err = decodePayload(result); err != nil {
+				return nil, &StepError{Step: step.Name, Err: fmt.Errorf("invalid output: %w", err)}
+			}
+		}
+		if step.Output != nil {
+			if err := jsonschema.Validate(step.Output, payload); err != nil {
+				return nil, &StepError{Step: step.Name, Err: fmt.Errorf("invalid output: %w", err)}
+			}
+		}
+	}
+
+	return payload, nil
+}
+
+// DecodeResult converts a Run result into the Go value pointed to by target
+func DecodeResult(result interface{}, target interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// structured reports whether a schema describes a non-string payload
+func structured(schema map[string]interface{}) bool {
+	if schema == nil {
+		return false
+	}
+	t, _ := schema["type"].(string)
+	return t != "" && t != "string"
+}
+
+// encodePayload renders a payload as agent input, passing strings unchanged
+func encodePayload(payload interface{}) (string, error) {
+	if s, ok := payload.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("payload is not JSON-encodable: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodePayload parses agent output as JSON, tolerating a surrounding
+// markdown code fence
+func decodePayload(text string) (interface{}, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal([]byte(text), &payload); err != nil {
+		return nil, fmt.Errorf("expected JSON: %w", err)
+	}
+	return payload, nil
+}
+
+// compatibleSchemas checks that every value valid under out is plausibly
+// valid under in: matching types and, for objects, every property in
+// requires is guaranteed by out
+func compatibleSchemas(out, in map[string]interface{}, path string) error {
+	if out == nil || in == nil {
+		return nil
+	}
+
+	outType, _ := out["type"].(string)
+	inType, _ := in["type"].(string)
+	if outType != "" && inType != "" && outType != inType && !(outType == "integer" && inType == "number") {
+		return fmt.Errorf("%s: produces %s, expected %s", path, outType, inType)
+	}
+
+	switch inType {
+	case "object":
+		outProps, _ := out["properties"].(map[string]interface{})
+		inProps, _ := in["properties"].(map[string]interface{})
+		guaranteed := make(map[string]bool)
+		for _, name := range jsonschema.Required(out) {
+			guaranteed[name] = true
+		}
+		for _, name := range jsonschema.Required(in) {
+			if !guaranteed[name] {
+				return fmt.Errorf("%s: required property %q is not guaranteed", path, name)
+			}
+		}
+		for name, inProp := range inProps {
+			outSchema, _ := outProps[name].(map[string]interface{})
+			inSchema, _ := inProp.(map[string]interface{})
+			if err := compatibleSchemas(outSchema, inSchema, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		outItems, _ := out["items"].(map[string]interface{})
+		inItems, _ := in["items"].(map[string]interface{})
+		return compatibleSchemas(outItems, inItems, path+"[]")
+	}
+
+	return nil
+}
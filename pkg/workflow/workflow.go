@@ -7,8 +7,9 @@ import (
 	"time"
 
 	"github.com/user/modulox/pkg/agent"
-	"github.com/user/modulox/pkg/types"
 	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/reliability"
+	"github.com/user/modulox/pkg/types"
 )
 
 // Workflow defines the interface for agent workflow orchestration
@@ -21,19 +22,30 @@ type Workflow interface {
 
 // SequentialWorkflow implements sequential execution of agents
 type SequentialWorkflow struct {
-	agents  []agent.Agent
-	results chan types.WorkflowResult
+	agents      []agent.Agent
+	timeouts    []time.Duration
+	stepTimeout time.Duration
+	results     chan types.WorkflowResult
 }
 
 // NewSequentialWorkflow creates a new sequential workflow
 func NewSequentialWorkflow() *SequentialWorkflow {
 	return &SequentialWorkflow{
-		agents:  make([]agent.Agent, 0),
-		results: make(chan types.WorkflowResult),
+		agents:   make([]agent.Agent, 0),
+		timeouts: make([]time.Duration, 0),
+		results:  make(chan types.WorkflowResult),
 	}
 }
 
-// Execute implements Workflow.Execute for sequential processing
+// SetStepTimeout bounds each step that has no timeout of its own; zero
+// means no limit
+func (w *SequentialWorkflow) SetStepTimeout(timeout time.Duration) {
+	w.stepTimeout = timeout
+}
+
+// Execute implements Workflow.Execute for sequential processing. Each step
+// runs under its own cancellation context; a failing or timed out step is
+// reported as a *StepError.
 func (w *SequentialWorkflow) Execute(ctx context.Context, task string) (string, error) {
 	var finalResult string
 	var err error
@@ -43,9 +55,14 @@ func (w *SequentialWorkflow) Execute(ctx context.Context, task string) (string,
 		case <-ctx.Done():
 			return "", ctx.Err()
 		default:
-			result, execErr := agent.Execute(ctx, task)
+			timeout := w.timeouts[i]
+			if timeout <= 0 {
+				timeout = w.stepTimeout
+			}
+			name := stepName(i, agent)
+			result, execErr := runStep(ctx, name, agent, task, timeout)
 			if execErr != nil {
-				return "", execErr
+				return "", &StepError{Step: name, Err: execErr}
 			}
 			// For sequential workflow, each agent's input is previous agent's output
 			task = result
@@ -60,123 +77,345 @@ func (w *SequentialWorkflow) Execute(ctx context.Context, task string) (string,
 
 // AddAgent implements Workflow.AddAgent
 func (w *SequentialWorkflow) AddAgent(a agent.Agent) error {
+	return w.AddAgentWithTimeout(a, 0)
+}
+
+// AddAgentWithTimeout adds a step bounded by its own timeout
+func (w *SequentialWorkflow) AddAgentWithTimeout(a agent.Agent, timeout time.Duration) error {
 	w.agents = append(w.agents, a)
+	w.timeouts = append(w.timeouts, timeout)
+	return nil
+}
+
+// runStep executes an agent under its own cancellation context, bounded by
+// timeout when set. Agents that ignore cancellation are abandoned once the
+// step times out so they cannot stall the workflow.
+func runStep(ctx context.Context, name string, a agent.Agent, input string, timeout time.Duration) (output string, err error) {
+	started := time.Now()
+	defer func() { recordStep(ctx, name, input, output, started, err) }()
+
+	var stepCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		stepCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		stepCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	type stepResult struct {
+		output string
+		err    error
+	}
+	done := make(chan stepResult, 1)
+	go func() {
+		output, err := a.Execute(stepCtx, input)
+		done <- stepResult{output: output, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil && timeout > 0 && ctx.Err() == nil && stepCtx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%w after %s", ErrStepTimeout, timeout)
+		}
+		return res.output, res.err
+	case <-stepCtx.Done():
+		if ctx.Err() == nil && timeout > 0 {
+			return "", fmt.Errorf("%w after %s", ErrStepTimeout, timeout)
+		}
+		return "", stepCtx.Err()
+	}
+}
+
+// stepName identifies a workflow step by its agent name when available
+func stepName(index int, a agent.Agent) string {
+	if named, ok := a.(interface{ GetName() string }); ok && named.GetName() != "" {
+		return fmt.Sprintf("%d (%s)", index+1, named.GetName())
+	}
+	return fmt.Sprintf("%d", index+1)
+}
+
+// EventPublisher publishes workflow progress events;
+// *communication.AgentClient satisfies it
+type EventPublisher interface {
+	PublishEvent(ctx context.Context, eventType, payload string, metadata map[string]string) error
+}
+
+// StateSyncer stores intermediate workflow results;
+// *communication.AgentClient satisfies it
+type StateSyncer interface {
+	SyncState(ctx context.Context, key, value string) (int64, error)
+}
+
+// WorkflowOptions contains optional dependencies of a workflow. Nil fields
+// default to no-ops, so workflows run standalone without an agent server.
+type WorkflowOptions struct {
+	Events EventPublisher
+	State  StateSyncer
+	// MaxParallelism bounds how many agents run at once; zero means no limit
+	MaxParallelism int
+	// RateLimiter, if set, is waited on before each agent starts
+	RateLimiter *reliability.RateLimiter
+}
+
+// withDefaults fills unset options with no-op implementations
+func (o WorkflowOptions) withDefaults() WorkflowOptions {
+	if o.Events == nil {
+		o.Events = noopClient{}
+	}
+	if o.State == nil {
+		o.State = noopClient{}
+	}
+	return o
+}
+
+// noopClient discards events and state
+type noopClient struct{}
+
+func (noopClient) PublishEvent(ctx context.Context, eventType, payload string, metadata map[string]string) error {
 	return nil
 }
 
+func (noopClient) SyncState(ctx context.Context, key, value string) (int64, error) {
+	return 0, nil
+}
+
+// FailureMode selects how MixtureWorkflow handles failing agents
+type FailureMode int
+
+const (
+	// FailFast aborts the workflow and cancels the other agents on the first failure
+	FailFast FailureMode = iota
+	// BestEffort aggregates the successful results if at least Quorum agents succeed
+	BestEffort
+	// UseFallback substitutes Fallback for the result of each failed agent
+	UseFallback
+)
+
+// FailurePolicy controls partial failure tolerance in MixtureWorkflow
+type FailurePolicy struct {
+	Mode FailureMode
+	// Quorum is the minimum number of successful agents under BestEffort (default 1)
+	Quorum int
+	// Fallback is the result used for failed agents under UseFallback
+	Fallback string
+}
+
 // MixtureWorkflow implements parallel execution with result aggregation
 type MixtureWorkflow struct {
 	agents     []agent.Agent
 	aggregator agent.Agent
+	policy     FailurePolicy
+	options    WorkflowOptions
 	results    chan types.WorkflowResult
 }
 
-// NewMixtureWorkflow creates a new mixture workflow
+// NewMixtureWorkflow creates a new mixture workflow that publishes no events
 func NewMixtureWorkflow(aggregator agent.Agent) *MixtureWorkflow {
+	return NewMixtureWorkflowWithOptions(aggregator, WorkflowOptions{})
+}
+
+// NewMixtureWorkflowWithOptions creates a new mixture workflow that reports
+// progress through the given options
+func NewMixtureWorkflowWithOptions(aggregator agent.Agent, options WorkflowOptions) *MixtureWorkflow {
 	return &MixtureWorkflow{
 		agents:     make([]agent.Agent, 0),
 		aggregator: aggregator,
+		options:    options.withDefaults(),
 		results:    make(chan types.WorkflowResult),
 	}
 }
 
+// SetFailurePolicy sets how failing agents are handled (default FailFast)
+func (w *MixtureWorkflow) SetFailurePolicy(policy FailurePolicy) {
+	if policy.Quorum <= 0 {
+		policy.Quorum = 1
+	}
+	w.policy = policy
+}
+
 // Execute implements Workflow.Execute for parallel processing
 func (w *MixtureWorkflow) Execute(ctx context.Context, task string) (string, error) {
-	// Create event publisher
-	client, err := communication.NewAgentClient("localhost:50051", "mixture-workflow")
+	result, err := w.Run(ctx, task)
 	if err != nil {
-		return "", fmt.Errorf("failed to create event client: %w", err)
+		return "", err
 	}
-	defer client.Close()
+	return result.Output, nil
+}
+
+// Run executes the workflow and reports failed agents under the
+// "failed_agents" metadata key (agent index to error message), along with
+// the "succeeded" count
+func (w *MixtureWorkflow) Run(ctx context.Context, task string) (types.WorkflowResult, error) {
+	events, state := w.options.Events, w.options.State
+
+	// Results are stored under a per-run namespace so concurrent runs don't
+	// overwrite each other's agent_N_result keys
+	runID := fmt.Sprintf("run-%d", time.Now().UnixNano())
 
 	// Publish workflow start event
-	err = client.PublishEvent(ctx, "workflow_start",
+	err := events.PublishEvent(ctx, "workflow_start",
 		fmt.Sprintf("Starting mixture workflow with %d agents", len(w.agents)),
-		map[string]string{"num_agents": fmt.Sprintf("%d", len(w.agents))})
+		map[string]string{
+			"num_agents": fmt.Sprintf("%d", len(w.agents)),
+			"run_id":     runID,
+		})
 	if err != nil {
-		return "", fmt.Errorf("failed to publish start event: %w", err)
+		return types.WorkflowResult{}, fmt.Errorf("failed to publish start event: %w", err)
 	}
 
+	// Agents run under their own context so fail-fast can cancel the rest
+	agentCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
 	results := make([]string, len(w.agents))
-	errors := make(chan error, len(w.agents))
+	failures := make([]error, len(w.agents))
+	fail := func(index int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures[index] = err
+		if firstErr == nil {
+			firstErr = err
+			if w.policy.Mode == FailFast {
+				cancel()
+			}
+		}
+	}
 
-	// Execute all agents in parallel
-	for i, agent := range w.agents {
-		wg.Add(1)
-		go func(index int, a agent.Agent) {
-			defer wg.Done()
+	runAgent := func(index int, a agent.Agent) {
+		// Queued agents are skipped once fail-fast has cancelled the run
+		if err := agentCtx.Err(); err != nil {
+			fail(index, fmt.Errorf("agent %d not started: %w", index, err))
+			return
+		}
+		if limiter := w.options.RateLimiter; limiter != nil {
+			if err := limiter.WaitN(agentCtx, 1); err != nil {
+				fail(index, fmt.Errorf("agent %d not started: %w", index, err))
+				return
+			}
+		}
+
+		// Publish agent start event
+		events.PublishEvent(ctx, "agent_start",
+			fmt.Sprintf("Starting agent %d: %s", index+1, a.GetName()),
+			map[string]string{"agent_index": fmt.Sprintf("%d", index+1)})
 
-			// Publish agent start event
-			client.PublishEvent(ctx, "agent_start",
-				fmt.Sprintf("Starting agent %d: %s", index+1, a.GetName()),
+		started := time.Now()
+		result, err := a.Execute(agentCtx, task)
+		recordStep(ctx, stepName(index, a), task, result, started, err)
+		if err != nil {
+			events.PublishEvent(ctx, "agent_error",
+				fmt.Sprintf("Agent %d failed: %v", index+1, err),
 				map[string]string{"agent_index": fmt.Sprintf("%d", index+1)})
+			fail(index, fmt.Errorf("agent %d failed: %w", index, err))
+			return
+		}
 
-			result, err := a.Execute(ctx, task)
-			if err != nil {
-				client.PublishEvent(ctx, "agent_error",
-					fmt.Sprintf("Agent %d failed: %v", index+1, err),
-					map[string]string{"agent_index": fmt.Sprintf("%d", index+1)})
-				errors <- fmt.Errorf("agent %d failed: %w", index, err)
-				return
-			}
+		// Store result in synchronized state
+		stateKey := communication.NamespaceKey("workflow", runID, fmt.Sprintf("agent_%d_result", index+1))
+		version, err := state.SyncState(ctx, stateKey, result)
+		if err != nil {
+			fail(index, fmt.Errorf("failed to sync state: %w", err))
+			return
+		}
 
-			// Store result in synchronized state
-			version, err := client.SyncState(ctx,
-				fmt.Sprintf("agent_%d_result", index+1), result)
-			if err != nil {
-				errors <- fmt.Errorf("failed to sync state: %w", err)
-				return
-			}
+		// Publish agent complete event
+		events.PublishEvent(ctx, "agent_complete",
+			fmt.Sprintf("Agent %d completed", index+1),
+			map[string]string{
+				"agent_index": fmt.Sprintf("%d", index+1),
+				"state_key": stateKey,
+				"state_version": fmt.Sprintf("%d", version),
+				"result_length": fmt.Sprintf("%d", len(result)),
+			})
 
-			// Publish agent complete event
-			client.PublishEvent(ctx, "agent_complete",
-				fmt.Sprintf("Agent %d completed", index+1),
-				map[string]string{
-					"agent_index": fmt.Sprintf("%d", index+1),
-					"state_version": fmt.Sprintf("%d", version),
-					"result_length": fmt.Sprintf("%d", len(result)),
-				})
+		results[index] = result
+	}
 
-			results[index] = result
-		}(i, agent)
+	// Execute agents in parallel, queueing them when parallelism is limited
+	workers := len(w.agents)
+	if max := w.options.MaxParallelism; max > 0 && max < workers {
+		workers = max
+	}
+	queue := make(chan int, len(w.agents))
+	for i := range w.agents {
+		queue <- i
+	}
+	close(queue)
+
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				runAgent(index, w.agents[index])
+			}
+		}()
 	}
 
 	// Wait for all agents to complete
 	wg.Wait()
-	close(errors)
 
-	// Check for errors
-	select {
-	case err := <-errors:
-		client.PublishEvent(ctx, "workflow_error",
+	// Apply the failure policy
+	succeeded := make([]string, 0, len(results))
+	failed := make(map[int]string)
+	for i, failure := range failures {
+		if failure == nil {
+			succeeded = append(succeeded, results[i])
+			continue
+		}
+		failed[i] = failure.Error()
+		if w.policy.Mode == UseFallback {
+			succeeded = append(succeeded, w.policy.Fallback)
+		}
+	}
+
+	metadata := map[string]interface{}{
+		"failed_agents": failed,
+		"succeeded":     len(w.agents) - len(failed),
+	}
+
+	if firstErr != nil {
+		switch {
+		case w.policy.Mode == FailFast:
+			err = firstErr
+		case w.policy.Mode == BestEffort && len(w.agents)-len(failed) < w.policy.Quorum:
+			err = fmt.Errorf("quorum not met: %d of %d agents succeeded, need %d: %w",
+				len(w.agents)-len(failed), len(w.agents), w.policy.Quorum, firstErr)
+		}
+	}
+	if err != nil {
+		events.PublishEvent(ctx, "workflow_error",
 			fmt.Sprintf("Workflow failed: %v", err),
 			nil)
-		return "", err
-	default:
+		return types.WorkflowResult{Error: err, Metadata: metadata}, err
 	}
 
 	// Publish aggregation start event
-	client.PublishEvent(ctx, "aggregation_start",
+	events.PublishEvent(ctx, "aggregation_start",
 		"Starting result aggregation",
-		map[string]string{"num_results": fmt.Sprintf("%d", len(results))})
+		map[string]string{"num_results": fmt.Sprintf("%d", len(succeeded))})
 
 	// Aggregate results using the aggregator agent
-	aggregatedInput := fmt.Sprintf("Aggregate the following results:\n%s", stringSliceToString(results))
+	aggregatedInput := fmt.Sprintf("Aggregate the following results:\n%s", stringSliceToString(succeeded))
 	finalResult, err := w.aggregator.Execute(ctx, aggregatedInput)
 	if err != nil {
-		client.PublishEvent(ctx, "aggregation_error",
+		events.PublishEvent(ctx, "aggregation_error",
 			fmt.Sprintf("Aggregation failed: %v", err),
 			nil)
-		return "", fmt.Errorf("aggregation failed: %w", err)
+		err = fmt.Errorf("aggregation failed: %w", err)
+		return types.WorkflowResult{Error: err, Metadata: metadata}, err
 	}
 
 	// Publish workflow complete event
-	client.PublishEvent(ctx, "workflow_complete",
+	events.PublishEvent(ctx, "workflow_complete",
 		"Mixture workflow completed successfully",
 		map[string]string{"final_result_length": fmt.Sprintf("%d", len(finalResult))})
 
-	return finalResult, nil
+	return types.WorkflowResult{Output: finalResult, Metadata: metadata}, nil
 }
 
 // AddAgent implements Workflow.AddAgent
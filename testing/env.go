@@ -0,0 +1,107 @@
+package testing
+
+import (
+	"context"
+	"io"
+
+	"github.com/user/modulox"
+	"github.com/user/modulox/pkg/agent"
+	"github.com/user/modulox/pkg/communication"
+	"github.com/user/modulox/pkg/config"
+	"github.com/user/modulox/pkg/memory"
+	"github.com/user/modulox/pkg/observability"
+	"github.com/user/modulox/pkg/session"
+	"github.com/user/modulox/pkg/tools"
+	"github.com/user/modulox/pkg/workflow"
+)
+
+// Env is a modulox.App built entirely from in-memory components around a
+// MockProvider, plus the messaging and storage components agents and
+// workflows are commonly wired to. Events from the agent, the tool
+// registry, and the message bus are captured by Recorder.
+type Env struct {
+	*modulox.App
+	Provider      *MockProvider
+	Conversations *memory.ConversationStore
+	Events        *communication.EventSystem
+	Bus           *communication.LocalBus
+	State         *communication.StateStore
+	Sessions      *session.MemoryStore
+	Recorder      *EventRecorder
+}
+
+// NewEnv creates an in-memory environment, closed when the test ends.
+// Options are applied to the agent after the environment's components, so
+// they can override them.
+func NewEnv(t TB, options ...agent.Option) *Env {
+	t.Helper()
+	env := &Env{
+		Provider:      NewMockProvider(),
+		Conversations: memory.NewConversationStore(memory.ConversationConfig{}),
+		Events:        communication.NewEventSystem(),
+		Bus:           communication.NewMessageBus(),
+		State:         communication.NewStateStore(),
+		Sessions:      session.NewMemoryStore(),
+		Recorder:      NewEventRecorder(),
+	}
+	env.App = &modulox.App{
+		Config:   &config.Config{},
+		Provider: env.Provider,
+		Memory:   memory.NewBaseStore(),
+		Tools:    tools.NewToolRegistry(),
+		Logger:   observability.NewLogger(io.Discard),
+		Tracer:   observability.NewTracer(nil),
+		Metrics:  observability.NewMetricsCollector(),
+	}
+	env.Config.Agent.Name = "test-agent"
+	env.Recorder.Attach(env.Events)
+	env.Tools.SetEventSystem(env.Events)
+	stop := env.Recorder.AttachBus(env.Bus, ">")
+
+	var err error
+	env.Agent, err = agent.New(
+		agent.WithName(env.Config.Agent.Name),
+		agent.WithProvider(env.Provider),
+		agent.WithMemory(env.Memory),
+		agent.WithConversation(env.Conversations),
+		agent.WithRegistry(env.Tools),
+		agent.WithEvents(env.Events),
+	).With(options...).Build()
+	if err != nil {
+		stop()
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	t.Cleanup(func() {
+		stop()
+		env.Close(context.Background())
+	})
+	return env
+}
+
+// WorkflowOptions returns workflow options publishing to Recorder and
+// syncing state to State
+func (e *Env) WorkflowOptions() workflow.WorkflowOptions {
+	return workflow.WorkflowOptions{Events: e.Recorder, State: stateSyncer{e.State}}
+}
+
+// NewAgent builds another agent on the environment's provider, memory,
+// tools, and events, e.g. for the steps of a workflow
+func (e *Env) NewAgent(name string, options ...agent.Option) (*agent.BaseAgent, error) {
+	return agent.New(
+		agent.WithName(name),
+		agent.WithProvider(e.Provider),
+		agent.WithMemory(e.Memory),
+		agent.WithRegistry(e.Tools),
+		agent.WithEvents(e.Events),
+	).With(options...).Build()
+}
+
+// stateSyncer adapts a StateStore to workflow.StateSyncer
+type stateSyncer struct {
+	store *communication.StateStore
+}
+
+func (s stateSyncer) SyncState(ctx context.Context, key, value string) (int64, error) {
+	entry, err := s.store.SetTTL(key, value, 0)
+	return entry.Version, err
+}
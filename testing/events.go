@@ -0,0 +1,279 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/communication"
+)
+
+// TB is the part of testing.TB the assertions use
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// Event is an event captured by an EventRecorder, with payload and
+// metadata rendered as strings whatever their source
+type Event struct {
+	Type     string
+	Payload  string
+	Metadata map[string]string
+	Time     time.Time
+}
+
+// Matches reports whether the event has eventType and carries every
+// key/value pair of metadata, given as alternating keys and values
+func (e Event) Matches(eventType string, metadata ...string) bool {
+	if e.Type != eventType {
+		return false
+	}
+	for i := 0; i+1 < len(metadata); i += 2 {
+		if e.Metadata[metadata[i]] != metadata[i+1] {
+			return false
+		}
+	}
+	return true
+}
+
+// EventRecorder captures events published to it directly, as a
+// workflow.EventPublisher, or from an EventSystem or MessageBus it is
+// attached to
+type EventRecorder struct {
+	events  []Event
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+// NewEventRecorder creates an empty event recorder
+func NewEventRecorder() *EventRecorder {
+	return &EventRecorder{changed: make(chan struct{})}
+}
+
+// record appends an event and wakes waiters
+func (r *EventRecorder) record(event Event) {
+	event.Time = time.Now()
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.mu.Unlock()
+}
+
+// PublishEvent implements workflow.EventPublisher
+func (r *EventRecorder) PublishEvent(ctx context.Context, eventType, payload string, metadata map[string]string) error {
+	copied := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	r.record(Event{Type: eventType, Payload: payload, Metadata: copied})
+	return nil
+}
+
+// Attach records every event emitted on events
+func (r *EventRecorder) Attach(events *communication.EventSystem) {
+	events.RegisterHandler(">", func(ctx context.Context, event communication.Event) error {
+		r.record(Event{Type: event.Type, Payload: stringify(event.Payload), Metadata: stringMap(event.Metadata)})
+		return nil
+	})
+}
+
+// AttachBus records the messages published on bus topics matching
+// pattern until stop is called. Events take the type of the message, or
+// pattern for untyped messages.
+func (r *EventRecorder) AttachBus(bus communication.MessageBus, pattern string) (stop func()) {
+	ch := bus.Subscribe(pattern)
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case msg := <-ch:
+				eventType := msg.Type
+				if eventType == "" {
+					eventType = pattern
+				}
+				r.record(Event{Type: eventType, Payload: stringify(msg.Content), Metadata: stringMap(msg.Metadata)})
+			case <-quit:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			bus.Unsubscribe(pattern, ch)
+			close(quit)
+			<-done
+		})
+	}
+}
+
+// stringify renders an event payload
+func stringify(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// stringMap renders event metadata
+func stringMap(metadata map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		result[k] = stringify(v)
+	}
+	return result
+}
+
+// Events returns the recorded events, only those of the given types if
+// any are given
+func (r *EventRecorder) Events(types ...string) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []Event
+	for _, event := range r.events {
+		if len(types) == 0 || containsString(types, event.Type) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Types returns the types of the recorded events, in order
+func (r *EventRecorder) Types() []string {
+	events := r.Events()
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// Find returns the first recorded event matching eventType and metadata
+func (r *EventRecorder) Find(eventType string, metadata ...string) (Event, bool) {
+	for _, event := range r.Events() {
+		if event.Matches(eventType, metadata...) {
+			return event, true
+		}
+	}
+	return Event{}, false
+}
+
+// Wait blocks until an event matching eventType and metadata is recorded,
+// or ctx is done
+func (r *EventRecorder) Wait(ctx context.Context, eventType string, metadata ...string) (Event, error) {
+	for {
+		r.mu.Lock()
+		changed := r.changed
+		r.mu.Unlock()
+		if event, ok := r.Find(eventType, metadata...); ok {
+			return event, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return Event{}, fmt.Errorf("no %s event: %w", eventType, ctx.Err())
+		}
+	}
+}
+
+// Reset discards the recorded events
+func (r *EventRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
+
+// AssertPublished fails the test unless an event matching eventType and
+// metadata was recorded, and returns it
+func (r *EventRecorder) AssertPublished(t TB, eventType string, metadata ...string) Event {
+	t.Helper()
+	event, ok := r.Find(eventType, metadata...)
+	if !ok {
+		t.Errorf("expected a %s event%s; recorded: %s", eventType, describeMetadata(metadata), strings.Join(r.Types(), ", "))
+	}
+	return event
+}
+
+// AssertNotPublished fails the test if an event matching eventType and
+// metadata was recorded
+func (r *EventRecorder) AssertNotPublished(t TB, eventType string, metadata ...string) {
+	t.Helper()
+	if event, ok := r.Find(eventType, metadata...); ok {
+		t.Errorf("unexpected %s event: payload %q, metadata %v", eventType, event.Payload, event.Metadata)
+	}
+}
+
+// AssertCount fails the test unless exactly n events of eventType were
+// recorded
+func (r *EventRecorder) AssertCount(t TB, eventType string, n int) {
+	t.Helper()
+	if got := len(r.Events(eventType)); got != n {
+		t.Errorf("expected %d %s events, recorded %d", n, eventType, got)
+	}
+}
+
+// AssertSequence fails the test unless events of the given types were
+// recorded in this order, possibly with other events in between
+func (r *EventRecorder) AssertSequence(t TB, types ...string) {
+	t.Helper()
+	next := 0
+	for _, eventType := range r.Types() {
+		if next < len(types) && eventType == types[next] {
+			next++
+		}
+	}
+	if next < len(types) {
+		t.Errorf("expected events in order %s; missing %s; recorded: %s",
+			strings.Join(types, ", "), types[next], strings.Join(r.Types(), ", "))
+	}
+}
+
+// RequireEventually stops the test unless an event matching eventType
+// and metadata is recorded within timeout, and returns it
+func (r *EventRecorder) RequireEventually(t TB, timeout time.Duration, eventType string, metadata ...string) Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	event, err := r.Wait(ctx, eventType, metadata...)
+	if err != nil {
+		t.Fatalf("expected a %s event%s within %s; recorded: %s",
+			eventType, describeMetadata(metadata), timeout, strings.Join(r.Types(), ", "))
+	}
+	return event
+}
+
+// describeMetadata renders alternating keys and values for messages
+func describeMetadata(metadata []string) string {
+	if len(metadata) < 2 {
+		return ""
+	}
+	pairs := make([]string, 0, len(metadata)/2)
+	for i := 0; i+1 < len(metadata); i += 2 {
+		pairs = append(pairs, metadata[i]+"="+metadata[i+1])
+	}
+	return " with " + strings.Join(pairs, ", ")
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,326 @@
+// Package testing provides fixtures for testing code built on ModuloX
+// without a model or an agent server: a scriptable MockProvider, an Env
+// wiring in-memory components around it, and an EventRecorder with
+// assertions on published events. Import it under another name next to the
+// standard library package:
+//
+//	import mxtest "github.com/user/modulox/testing"
+//
+//	func TestAnswer(t *testing.T) {
+//		env := mxtest.NewEnv(t)
+//		env.Provider.CallTool("calculator", map[string]interface{}{"expression": "6*7"}).
+//			Reply("42")
+//		answer, err := env.Agent.Execute(ctx, "What is 6*7?")
+//		...
+//	}
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/modulox/pkg/llm"
+)
+
+// DefaultReply is the answer of a MockProvider with nothing scripted
+const DefaultReply = "mock reply"
+
+// Response is a scripted provider answer
+type Response struct {
+	Content   string
+	ToolCalls []llm.ToolCall
+	// Err fails the call instead of answering
+	Err error
+	// Delay holds the answer back, or until the call's context is done
+	Delay time.Duration
+}
+
+// Call is a request received by a MockProvider
+type Call struct {
+	Method   string
+	Prompt   string
+	Messages []llm.Message
+	Tools    []llm.ToolDefinition
+}
+
+// Text returns the request as a single string: the prompt, or the
+// conversation flattened by llm.FormatPrompt
+func (c Call) Text() string {
+	if c.Messages != nil {
+		return llm.FormatPrompt(c.Messages)
+	}
+	return c.Prompt
+}
+
+// rule answers requests containing a substring
+type rule struct {
+	contains string
+	response Response
+}
+
+// MockProvider is a deterministic llm.Provider answering from a script.
+// Each request is answered by the first rule whose substring it contains,
+// otherwise by the next queued response, otherwise by the fallback.
+// Embeddings are derived from the words of the text, so equal texts embed
+// equally and texts sharing words are similar. It implements the chat,
+// tool calling, and streaming interfaces, and is safe for concurrent use.
+type MockProvider struct {
+	// Dimensions is the length of embeddings (default 64)
+	Dimensions int
+
+	rules    []rule
+	queue    []Response
+	fallback Response
+	embedErr error
+	calls    []Call
+	nextID   int
+	mu       sync.Mutex
+}
+
+// NewMockProvider creates a mock provider answering DefaultReply until
+// scripted otherwise
+func NewMockProvider() *MockProvider {
+	return &MockProvider{
+		Dimensions: 64,
+		fallback:   Response{Content: DefaultReply},
+	}
+}
+
+// Reply queues text answers, used in order
+func (m *MockProvider) Reply(contents ...string) *MockProvider {
+	for _, content := range contents {
+		m.ReplyWith(Response{Content: content})
+	}
+	return m
+}
+
+// ReplyWith queues answers, used in order
+func (m *MockProvider) ReplyWith(responses ...Response) *MockProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queue = append(m.queue, responses...)
+	return m
+}
+
+// CallTool queues an answer requesting a call of the named tool. args is
+// encoded as JSON unless it already is a string.
+func (m *MockProvider) CallTool(name string, args interface{}) *MockProvider {
+	arguments, ok := args.(string)
+	if !ok {
+		data, err := json.Marshal(args)
+		if err != nil {
+			panic(fmt.Sprintf("mock tool call %s: %v", name, err))
+		}
+		arguments = string(data)
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	id := fmt.Sprintf("call_%d", m.nextID)
+	m.mu.Unlock()
+	return m.ReplyWith(Response{ToolCalls: []llm.ToolCall{{ID: id, Name: name, Arguments: arguments}}})
+}
+
+// Fail queues an error answer
+func (m *MockProvider) Fail(err error) *MockProvider {
+	return m.ReplyWith(Response{Err: err})
+}
+
+// When answers every request containing substring with content, ahead of
+// the queue
+func (m *MockProvider) When(substring, content string) *MockProvider {
+	return m.WhenRespond(substring, Response{Content: content})
+}
+
+// WhenRespond answers every request containing substring with response,
+// ahead of the queue
+func (m *MockProvider) WhenRespond(substring string, response Response) *MockProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append(m.rules, rule{contains: substring, response: response})
+	return m
+}
+
+// Fallback sets the answer used when no rule matches and the queue is empty
+func (m *MockProvider) Fallback(response Response) *MockProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallback = response
+	return m
+}
+
+// FailEmbeddings makes Embed fail with err; nil restores embeddings
+func (m *MockProvider) FailEmbeddings(err error) *MockProvider {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.embedErr = err
+	return m
+}
+
+// Calls returns the requests received so far, in order
+func (m *MockProvider) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the requests received by method ("complete", "embed",
+// "chat", "chat_tools", or "stream")
+func (m *MockProvider) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range m.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Pending returns the number of queued answers not used yet
+func (m *MockProvider) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
+// Reset clears the script and the recorded calls
+func (m *MockProvider) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = nil
+	m.queue = nil
+	m.fallback = Response{Content: DefaultReply}
+	m.embedErr = nil
+	m.calls = nil
+}
+
+// answer records a request and returns its scripted answer
+func (m *MockProvider) answer(ctx context.Context, call Call) (Response, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	response, matched := m.match(call.Text())
+	if !matched {
+		if len(m.queue) > 0 {
+			response = m.queue[0]
+			m.queue = m.queue[1:]
+		} else {
+			response = m.fallback
+		}
+	}
+	m.mu.Unlock()
+
+	if response.Delay > 0 {
+		timer := time.NewTimer(response.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		}
+	}
+	if response.Err != nil {
+		return Response{}, response.Err
+	}
+	return response, nil
+}
+
+// match returns the answer of the first rule matching text
+func (m *MockProvider) match(text string) (Response, bool) {
+	for _, r := range m.rules {
+		if strings.Contains(text, r.contains) {
+			return r.response, true
+		}
+	}
+	return Response{}, false
+}
+
+// Complete implements llm.Provider.Complete
+func (m *MockProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	response, err := m.answer(ctx, Call{Method: llm.MethodComplete, Prompt: prompt})
+	return response.Content, err
+}
+
+// Embed implements llm.Provider.Embed
+func (m *MockProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, Call{Method: llm.MethodEmbed, Prompt: text})
+	err := m.embedErr
+	dimensions := m.Dimensions
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return Embedding(text, dimensions), nil
+}
+
+// Chat implements llm.ChatProvider.Chat
+func (m *MockProvider) Chat(ctx context.Context, messages []llm.Message) (llm.Message, error) {
+	response, err := m.answer(ctx, Call{Method: llm.MethodChat, Messages: messages})
+	if err != nil {
+		return llm.Message{}, err
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: response.Content}, nil
+}
+
+// ChatWithTools implements llm.ToolCallingProvider.ChatWithTools
+func (m *MockProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.ToolDefinition) (llm.Message, error) {
+	response, err := m.answer(ctx, Call{Method: llm.MethodChatTools, Messages: messages, Tools: tools})
+	if err != nil {
+		return llm.Message{}, err
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: response.Content, ToolCalls: response.ToolCalls}, nil
+}
+
+// CompleteStream implements llm.StreamingProvider.CompleteStream, streaming
+// the answer word by word
+func (m *MockProvider) CompleteStream(ctx context.Context, prompt string) (<-chan llm.Chunk, error) {
+	response, err := m.answer(ctx, Call{Method: llm.MethodStream, Prompt: prompt})
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.SplitAfter(response.Content, " ")
+	chunks := make(chan llm.Chunk, len(words)+1)
+	for _, word := range words {
+		if word != "" {
+			chunks <- llm.Chunk{Content: word}
+		}
+	}
+	chunks <- llm.Chunk{Done: true}
+	close(chunks)
+	return chunks, nil
+}
+
+// Embedding returns the deterministic, normalized embedding MockProvider
+// uses for text: a bag of its lowercased words hashed into dimensions
+func Embedding(text string, dimensions int) []float32 {
+	if dimensions <= 0 {
+		dimensions = 64
+	}
+	vector := make([]float32, dimensions)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(strings.Trim(word, ".,;:!?\"'()")))
+		vector[h.Sum32()%uint32(dimensions)]++
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		vector[0] = 1
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+	return vector
+}